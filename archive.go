@@ -0,0 +1,291 @@
+// ## Archives and modules
+//
+// Sometimes the code to weave is not checked out anywhere: a dependency, or
+// a release tarball. goweave accepts such code directly:
+//
+// * a `.zip`, `.tar.gz`, or `.tgz` archive, like `goweave release.tar.gz`,
+// * a module version, like `goweave golang.org/x/text@v0.14.0`, which goweave
+// downloads from the module proxy. `@latest` asks the proxy for the latest
+// version.
+//
+// goweave unpacks the archive into its cache directory and weaves all .go
+// files in it, as if it were a directory on the command line. The documents
+// keep the paths of their source files within the archive; for modules,
+// the paths within the module.
+//
+// The module proxy is the first one in the GOPROXY environment variable,
+// like with the go tool, or else proxy.golang.org.
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// maxArchiveSize limits the size of downloads and of unpacked files, to
+// protect against archives that unpack into huge files. Larger ones fail.
+var maxArchiveSize int64 = 500 << 20
+
+// isArchive returns true if the file name has the extension of a supported
+// archive format.
+func isArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// isModuleVersion returns true if the argument looks like module@version.
+func isModuleVersion(arg string) bool {
+	i := strings.LastIndex(arg, "@")
+	return i > 0 && i < len(arg)-1 && !strings.ContainsAny(arg, `\`) && strings.Contains(arg[:i], ".")
+}
+
+// cacheDir returns the directory that archives get unpacked into.
+func cacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "goweave")
+}
+
+// inputArchive unpacks a command line argument that names an archive or a
+// module version, and returns the directory of its contents. ok is false
+// for all other arguments. A file that exists is never taken for a module.
+func inputArchive(arg string) (dir string, ok bool, err error) {
+	_, statErr := os.Stat(arg)
+	switch {
+	case isArchive(arg):
+		dir, err = unpackArchiveFile(arg)
+	case isModuleVersion(arg) && os.IsNotExist(statErr):
+		dir, err = unpackModule(arg)
+	default:
+		return "", false, nil
+	}
+	if err == nil && revTree != nil {
+		err = fmt.Errorf("-rev cannot be combined with archives or modules")
+	}
+	return dir, true, err
+}
+
+// unpackArchiveFile unpacks a local archive. The directory is named after
+// the hash of the archive, so that unchanged archives get unpacked only
+// once.
+func unpackArchiveFile(filename string) (string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	dir := filepath.Join(cacheDir(), "archives", hex.EncodeToString(sum[:8]))
+	return dir, unpack(dir, filename, data, "")
+}
+
+// unpackModule downloads a module version from the module proxy.
+func unpackModule(arg string) (string, error) {
+	i := strings.LastIndex(arg, "@")
+	mod, version := arg[:i], arg[i+1:]
+	proxy, err := moduleProxy()
+	if err != nil {
+		return "", err
+	}
+	escaped, err := escapeModulePath(mod)
+	if err != nil {
+		return "", err
+	}
+	base := proxy + "/" + escaped + "/@v/"
+	if version == "latest" {
+		var info struct{ Version string }
+		data, err := download(proxy + "/" + escaped + "/@latest")
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal(data, &info); err != nil || info.Version == "" {
+			return "", fmt.Errorf("%s: the module proxy returned no version", arg)
+		}
+		version = info.Version
+	}
+	escVersion, err := escapeModulePath(version)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cacheDir(), "modules", filepath.FromSlash(escaped)+"@"+escVersion)
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil // module versions never change
+	}
+	data, err := download(base + escVersion + ".zip")
+	if err != nil {
+		return "", err
+	}
+	return dir, unpack(dir, arg, data, mod+"@"+version+"/")
+}
+
+// moduleProxy returns the URL of the first module proxy in GOPROXY.
+func moduleProxy() (string, error) {
+	env := os.Getenv("GOPROXY")
+	if env == "" {
+		return "https://proxy.golang.org", nil
+	}
+	for _, p := range strings.FieldsFunc(env, func(r rune) bool { return r == ',' || r == '|' }) {
+		switch p {
+		case "off":
+			return "", fmt.Errorf("GOPROXY=off forbids downloading modules")
+		case "direct":
+			continue
+		}
+		return strings.TrimSuffix(p, "/"), nil
+	}
+	return "", fmt.Errorf("GOPROXY=%s names no module proxy", env)
+}
+
+// escapeModulePath applies the case encoding of the module proxy protocol:
+// each upper-case letter becomes an exclamation mark followed by the
+// lower-case letter.
+func escapeModulePath(p string) (string, error) {
+	var b strings.Builder
+	for _, r := range p {
+		switch {
+		case r == '!' || r >= unicode.MaxASCII:
+			return "", fmt.Errorf("invalid module path or version %q", p)
+		case unicode.IsUpper(r):
+			b.WriteByte('!')
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+// download fetches a URL.
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxArchiveSize+1))
+	if err == nil && int64(len(data)) > maxArchiveSize {
+		err = fmt.Errorf("%s: larger than %d bytes", url, maxArchiveSize)
+	}
+	return data, err
+}
+
+// unpack unpacks the archive data into dir, unless dir exists already.
+// name is the name of the archive, for error messages, and prefix is a
+// directory that all files of the archive are in and that gets left out.
+func unpack(dir, name string, data []byte, prefix string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	// Unpack into a temporary directory first, so that a failure leaves no
+	// half-unpacked directory behind for the next run to reuse.
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), "unpack")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	write := func(p string, r io.Reader) error {
+		p = strings.TrimPrefix(strings.TrimPrefix(p, prefix), "./")
+		clean := path.Clean("/" + p)[1:]
+		if clean == "" || clean != strings.TrimSuffix(p, "/") {
+			return fmt.Errorf("%s: invalid file name %q", name, p)
+		}
+		dst := filepath.Join(tmp, filepath.FromSlash(clean))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(f, io.LimitReader(r, maxArchiveSize+1))
+		if err == nil && n > maxArchiveSize {
+			err = fmt.Errorf("%s is larger than %d bytes", clean, maxArchiveSize)
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	if strings.HasSuffix(strings.ToLower(name), ".zip") || prefix != "" {
+		err = unzip(data, write)
+	} else {
+		err = untar(data, write)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return os.Rename(tmp, dir)
+}
+
+// unzip calls write for each file in a zip archive.
+func unzip(data []byte, write func(string, io.Reader) error) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, zf := range zr.File {
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = write(zf.Name, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// untar calls write for each file in a gzipped tar archive.
+func untar(data []byte, write func(string, io.Reader) error) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := write(h.Name, tr); err != nil {
+			return err
+		}
+	}
+}
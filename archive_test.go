@@ -0,0 +1,173 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// zipFiles returns a zip archive of the files.
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// tgzFiles returns a gzipped tar archive of the files.
+func tgzFiles(t *testing.T, files map[string]string) []byte {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return b.Bytes()
+}
+
+// relFiles returns the files below dir, relative to dir.
+func relFiles(t *testing.T, dir string, files []string) []string {
+	var rel []string
+	for _, f := range files {
+		r, err := filepath.Rel(dir, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	return rel
+}
+
+func TestArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(x string) { os.Setenv("XDG_CACHE_HOME", x) }(os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	defer func(m map[string]string) { outputDirs = m }(outputDirs)
+	outputDirs = map[string]string{}
+
+	files := map[string]string{"./a.go": "package a\n", "./b/c.go": "package b\n", "./README": "Read me\n"}
+	archives := map[string][]byte{"src.zip": zipFiles(t, files), "src.tar.gz": tgzFiles(t, files)}
+	for name, data := range archives {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		unpacked, ok, err := inputArchive(filename)
+		if err != nil || !ok {
+			t.Fatalf("inputArchive(%s) = %v, %v", name, ok, err)
+		}
+		got, err := expandArgs([]string{filename})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"a.go", "b/c.go"}; !reflect.DeepEqual(relFiles(t, unpacked, got), want) {
+			t.Errorf("expandArgs(%s) = %q, want %q", name, got, want)
+		}
+		if out := outputName(got[1]); out != "b/c.html" {
+			t.Errorf("outputName(%s) = %q, want b/c.html", got[1], out)
+		}
+	}
+
+	evil := filepath.Join(dir, "evil.zip")
+	ioutil.WriteFile(evil, zipFiles(t, map[string]string{"../evil.go": "package evil\n"}), 0644)
+	if _, _, err := inputArchive(evil); err == nil {
+		t.Errorf("inputArchive() unpacked a file outside of the archive directory")
+	}
+}
+
+func TestModuleDownload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "module")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(x string) { os.Setenv("XDG_CACHE_HOME", x) }(os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", dir)
+	defer func(p string) { os.Setenv("GOPROXY", p) }(os.Getenv("GOPROXY"))
+
+	modZip := zipFiles(t, map[string]string{"example.com/MyMod@v1.0.0/mod.go": "package mod\n"})
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/!my!mod/@latest":
+			w.Write([]byte(`{"Version":"v1.0.0"}`))
+		case "/example.com/!my!mod/@v/v1.0.0.zip":
+			w.Write(modZip)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxy.Close()
+	os.Setenv("GOPROXY", proxy.URL+",direct")
+
+	for _, arg := range []string{"example.com/MyMod@latest", "example.com/MyMod@v1.0.0"} {
+		unpacked, ok, err := inputArchive(arg)
+		if err != nil || !ok {
+			t.Fatalf("inputArchive(%s) = %v, %v", arg, ok, err)
+		}
+		if _, err := os.Stat(filepath.Join(unpacked, "mod.go")); err != nil {
+			t.Errorf("inputArchive(%s) did not unpack mod.go: %v", arg, err)
+		}
+	}
+	if _, _, err := inputArchive("example.com/MyMod@v2.0.0"); err == nil {
+		t.Errorf("inputArchive() found a version that does not exist")
+	}
+	if _, ok, _ := inputArchive("main.go"); ok {
+		t.Errorf("inputArchive() took a source file for an archive")
+	}
+}
+
+func TestArchiveSizeLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(n int64) { maxArchiveSize = n }(maxArchiveSize)
+	maxArchiveSize = 8
+
+	if err := unpack(filepath.Join(dir, "fits"), "fits.zip", zipFiles(t, map[string]string{"a.go": "package"}), ""); err != nil {
+		t.Errorf("unpack() failed on a file within the limit: %v", err)
+	}
+	big := filepath.Join(dir, "big")
+	if err := unpack(big, "big.zip", zipFiles(t, map[string]string{"a.go": "package a\n"}), ""); err == nil {
+		t.Errorf("unpack() took a file above the limit")
+	}
+	if _, err := os.Stat(big); !os.IsNotExist(err) {
+		t.Errorf("unpack() left %s behind: %v", big, err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+	if _, err := download(server.URL + "/a"); err != nil {
+		t.Errorf("download() failed within the limit: %v", err)
+	}
+	if data, err := download(server.URL + "/too/large"); err == nil {
+		t.Errorf("download() = %q, want an error above the limit", data)
+	}
+}
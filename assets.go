@@ -0,0 +1,121 @@
+// ## Assets
+//
+// Documents refer to files besides themselves, which goweave copies into
+// the output directory along with them: the assets. Each format declares
+// the assets that its documents need, and goweave copies exactly these, and
+// only those that the documents of the run refer to. HTML documents need
+// the CSS file, with -theme, unless -inline puts it into each document or
+// -bare leaves out the head that would refer to it; the font files of
+// -font-files, likewise; and goweave.js, the
+// script of the buttons of -rawview and -color-scheme=auto, if the
+// documents have any of them. Without a head to load it from, the script
+// goes into the documents, too. Markdown, reStructuredText, LaTeX, and
+// JSON need no assets; the tools that process them bring their own styles.
+package main
+
+import (
+	"errors"
+	"html/template"
+	"io/fs"
+	"sync"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// jsfilename is the name of the script in the resources and in the output.
+const jsfilename = "goweave.js"
+
+var (
+	script       template.JS // the script of the buttons, from the resources
+	assetsMu     sync.Mutex
+	assetsCopied = map[int]bool{} // the assets of -format copied so far, by index
+)
+
+// A pageAsset is a file, or a set of files, that pages refer to.
+type pageAsset struct {
+	used func(full bool) bool // whether a page refers to it; full is whether the page has a head
+	copy func() error         // copies it into the output
+}
+
+// formatAssets returns the assets that the pages of a format may need.
+func formatAssets(format string) []pageAsset {
+	switch format {
+	case formatHTML:
+		return []pageAsset{
+			{linked, copyCssFile},
+			{linked, copyFonts},
+			{func(full bool) bool { return linked(full) && hasButtons(full) }, copyScript},
+		}
+	}
+	return nil
+}
+
+// linked reports whether a page refers to the assets, rather than having
+// them inline, or not at all.
+func linked(full bool) bool {
+	return full && !*inline
+}
+
+// copyAssets copies the assets of -format that a page refers to, unless
+// they were copied already.
+func copyAssets(full bool) error {
+	assetsMu.Lock()
+	defer assetsMu.Unlock()
+	for i, a := range formatAssets(*format) {
+		if assetsCopied[i] || !a.used(full) {
+			continue
+		}
+		if err := a.copy(); err != nil {
+			return err
+		}
+		assetsCopied[i] = true
+	}
+	return nil
+}
+
+// resetAssets makes copyAssets copy the assets again, as the resources
+// have changed.
+func resetAssets() {
+	assetsMu.Lock()
+	assetsCopied = map[int]bool{}
+	assetsMu.Unlock()
+}
+
+// hasButtons reports whether a page has buttons that need the script.
+func hasButtons(full bool) bool {
+	return *rawView || colorSchemeToggle(full)
+}
+
+// scriptHref returns the URL of the script relative to the output
+// directory. It goes next to the CSS file.
+func scriptHref() urlPath {
+	return fsPath(*csspath).toURL().join(jsfilename)
+}
+
+// pageScript returns the link to the script, or the script itself, for a
+// page whose output directory is at root.
+func pageScript(root urlPath, full bool) (urlPath, template.JS) {
+	switch {
+	case !hasButtons(full):
+		return "", ""
+	case linked(full):
+		return root + scriptHref(), ""
+	}
+	return "", script
+}
+
+// loadScript reads the script from the resource directory, or the
+// built-in one if the resource directory has none, like those that
+// predate it.
+func loadScript(path string) (template.JS, error) {
+	data, err := fs.ReadFile(resourceFS(path), jsfilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = fs.ReadFile(weave.Resources(), jsfilename)
+	}
+	return template.JS(data), err
+}
+
+// copyScript writes the script into the output.
+func copyScript() error {
+	return outputFS().WriteFile(scriptHref().String(), []byte(script))
+}
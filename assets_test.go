@@ -0,0 +1,68 @@
+package main
+
+import (
+	"html/template"
+	"reflect"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestCopyAssets(t *testing.T) {
+	defer func(f, c string, b, i, r bool) {
+		*format, *colorScheme, *bare, *inline, *rawView = f, c, b, i, r
+	}(*format, *colorScheme, *bare, *inline, *rawView)
+	defer func(r string, o weave.OutputFS) { resourcedir, output = r, o }(resourcedir, output)
+	defer resetAssets()
+	defer func(s template.JS) { script = s }(script)
+	resourcedir = ""
+	js, err := loadScript(resourcedir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script = js
+
+	tests := []struct {
+		format, colorScheme string
+		bare, inline, full  bool
+		want                []string
+	}{
+		{formatHTML, schemeAuto, false, false, true, []string{cssfilename, jsfilename}},
+		{formatHTML, schemeLight, false, false, true, []string{cssfilename}},
+		{formatHTML, schemeAuto, false, true, true, nil},
+		{formatHTML, schemeAuto, true, false, false, nil},
+		{formatHTML, schemeAuto, true, false, true, []string{cssfilename, jsfilename}}, // the index page
+		{formatMD, schemeAuto, false, false, true, nil},
+		{formatRST, schemeAuto, false, false, true, nil},
+	}
+	for _, tt := range tests {
+		*format, *colorScheme, *bare, *inline = tt.format, tt.colorScheme, tt.bare, tt.inline
+		mem := &weave.MemFS{}
+		output = mem
+		resetAssets()
+		if err := copyAssets(tt.full); err != nil {
+			t.Fatal(err)
+		}
+		if got := mem.Names(); !reflect.DeepEqual(got, tt.want) && len(got)+len(tt.want) > 0 {
+			t.Errorf("copyAssets(%v) with -format=%s -color-scheme=%s -bare=%v -inline=%v wrote %v, want %v",
+				tt.full, tt.format, tt.colorScheme, tt.bare, tt.inline, got, tt.want)
+		}
+	}
+}
+
+func TestPageScript(t *testing.T) {
+	defer func(c string, i, r bool) { *colorScheme, *inline, *rawView = c, i, r }(*colorScheme, *inline, *rawView)
+	defer func(s template.JS) { script = s }(script)
+	script = "f()"
+	*colorScheme, *rawView = schemeLight, false
+	if path, js := pageScript("../", true); path != "" || js != "" {
+		t.Errorf("pageScript() without buttons = %q, %q", path, js)
+	}
+	*rawView = true
+	if path, js := pageScript("../", true); path != "../goweave.js" || js != "" {
+		t.Errorf("pageScript() = %q, %q, want the link", path, js)
+	}
+	if path, js := pageScript("../", false); path != "" || js != "f()" {
+		t.Errorf("pageScript() without a head = %q, %q, want the script", path, js)
+	}
+}
@@ -0,0 +1,60 @@
+// ## Coverage badge
+//
+// With -badge, goweave writes an SVG badge into the output directory that
+// shows the documentation coverage and the date of the build, in the style of
+// the usual README badges:
+//
+//	![literate docs](docs/goweave-badge.svg)
+package main
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const badgeFilename = "goweave-badge.svg"
+
+const badgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[3]s: %[4]s">
+<title>%[3]s: %[4]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[5]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="14">%[3]s</text>
+<text x="%[8]d" y="14">%[4]s</text>
+</g>
+</svg>
+`
+
+// badgeTextWidth estimates the rendered width of s in pixels. Verdana at
+// 11px averages about 7 pixels per character, which is good enough for
+// short labels.
+func badgeTextWidth(s string) int {
+	return 7*utf8.RuneCountInString(s) + 10
+}
+
+// badgeColor picks a color that reflects how good the coverage is.
+func badgeColor(ratio float64) string {
+	switch {
+	case ratio >= 0.8:
+		return "#4c1"
+	case ratio >= 0.6:
+		return "#dfb317"
+	default:
+		return "#e05d44"
+	}
+}
+
+// makeBadge renders the badge for the given coverage and build date.
+func makeBadge(c docCoverage, built time.Time) []byte {
+	label := "literate docs"
+	value := fmt.Sprintf("%.0f%% | %s", 100*c.Ratio(), built.Format("2006-01-02"))
+	lw, vw := badgeTextWidth(label), badgeTextWidth(value)
+	return []byte(fmt.Sprintf(badgeTemplate, lw+vw, lw, label, value, vw, badgeColor(c.Ratio()), lw/2, lw+vw/2))
+}
+
+// saveBadge writes the badge for the total coverage of this run.
+func saveBadge(name string) error {
+	return outputFS().WriteFile(name, makeBadge(totalCoverage(), time.Now()))
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMakeBadge(t *testing.T) {
+	built := time.Date(2016, 4, 12, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		c     docCoverage
+		value string
+		color string
+	}{
+		{docCoverage{9, 10}, "90% | 2016-04-12", "#4c1"},
+		{docCoverage{2, 3}, "67% | 2016-04-12", "#dfb317"},
+		{docCoverage{1, 10}, "10% | 2016-04-12", "#e05d44"},
+	}
+	for _, tt := range tests {
+		got := string(makeBadge(tt.c, built))
+		if !strings.Contains(got, ">"+tt.value+"</text>") || !strings.Contains(got, `fill="`+tt.color+`"`) {
+			t.Errorf("makeBadge(%v) = %s, want value %q and color %s", tt.c, got, tt.value, tt.color)
+		}
+	}
+}
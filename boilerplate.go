@@ -0,0 +1,88 @@
+// ## Package clause and imports
+//
+// The first code section of almost every file is the package clause and the
+// import declarations. Readers of a woven article rarely care about them,
+// yet they fill the first screen. With -boilerplate=collapse, goweave moves
+// them into a collapsed block that the reader can expand, and with
+// -boilerplate=hide, it drops them entirely.
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// The values of the -boilerplate option.
+const (
+	boilerplateShow     = "show"
+	boilerplateCollapse = "collapse"
+	boilerplateHide     = "hide"
+)
+
+// checkBoilerplate validates the -boilerplate option.
+func checkBoilerplate() error {
+	switch *boilerplate {
+	case boilerplateShow, boilerplateCollapse, boilerplateHide:
+		return nil
+	}
+	return fmt.Errorf("-boilerplate must be one of %s, %s, or %s, not %q",
+		boilerplateShow, boilerplateCollapse, boilerplateHide, *boilerplate)
+}
+
+// boilerplateLen returns the length of the package clause and import
+// declarations at the start of code, including the blank lines that follow
+// them. It returns 0 if code does not start with a package clause.
+func boilerplateLen(code string) int {
+	n, end := 0, 0
+	inImports, seenPackage := false, false
+	for n < len(code) {
+		line := code[n:]
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i+1]
+		}
+		n += len(line)
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			if !inImports && seenPackage {
+				end = n
+			}
+			continue
+		case inImports:
+			inImports = trimmed != ")"
+		case !seenPackage && strings.HasPrefix(trimmed, "package "):
+			seenPackage = true
+		case seenPackage && strings.HasPrefix(trimmed, "import ("):
+			inImports = !strings.HasSuffix(trimmed, ")")
+		case seenPackage && strings.HasPrefix(trimmed, "import "):
+		default:
+			return end
+		}
+		end = n
+	}
+	if inImports {
+		return 0 // unterminated import block; leave the code alone
+	}
+	return end
+}
+
+// splitBoilerplate moves the package clause and imports of the first code
+// section into its Boilerplate field, or drops them with -boilerplate=hide.
+func splitBoilerplate(sections []*section) {
+	if *boilerplate == boilerplateShow {
+		return
+	}
+	for _, s := range weave.InSourceOrder(sections) {
+		if strings.TrimSpace(s.Code) == "" {
+			continue
+		}
+		n := boilerplateLen(s.Code)
+		if *boilerplate == boilerplateCollapse {
+			s.Boilerplate = s.Code[:n]
+		}
+		s.Code = s.Code[n:]
+		return
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestBoilerplateLen(t *testing.T) {
+	tests := []struct {
+		code string
+		want int
+	}{
+		{"\npackage main\n\nimport (\n\t\"fmt\"\n\n\t\"os\"\n)\n\nfunc main() {}\n", 41},
+		{"package main\n\nimport \"fmt\"\nimport f \"flag\"\n\nvar x int\n", 44},
+		{"package main\n", 13},
+		{"func main() {}\n", 0},
+		{"package main\nimport (\n\t\"fmt\"\n", 0},
+		{"var x int\npackage main\n", 0},
+	}
+	for _, tt := range tests {
+		if got := boilerplateLen(tt.code); got != tt.want {
+			t.Errorf("boilerplateLen(%q) = %d (%q), want %d", tt.code, got, tt.code[:got], tt.want)
+		}
+	}
+}
+
+func TestSplitBoilerplate(t *testing.T) {
+	defer func(b string) { *boilerplate = b }(*boilerplate)
+	for _, mode := range []string{boilerplateShow, boilerplateCollapse, boilerplateHide} {
+		*boilerplate = mode
+		sections := []*section{
+			{Doc: "Doc\n", Code: ""},
+			{Doc: "More doc\n", Code: "package main\n\nfunc main() {}\n"},
+		}
+		splitBoilerplate(sections)
+		s := sections[1]
+		switch {
+		case mode == boilerplateShow && (s.Code != "package main\n\nfunc main() {}\n" || s.Boilerplate != ""),
+			mode == boilerplateCollapse && (s.Code != "func main() {}\n" || s.Boilerplate != "package main\n\n"),
+			mode == boilerplateHide && (s.Code != "func main() {}\n" || s.Boilerplate != ""):
+			t.Errorf("splitBoilerplate() with %s: Code = %q, Boilerplate = %q", mode, s.Code, s.Boilerplate)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// ## The build cache
+//
+// Weaving a large tree takes a while, and most of the time, only a few files
+// changed since the last run. goweave therefore remembers a fingerprint of
+// each document in the file `.goweave-cache` in the output directory. The
+// fingerprint covers everything the document is made of: the comments and
+// the code, the title and the other data of the document, the template and
+// the CSS, the options, and the version of goweave. If the fingerprint did
+// not change and the document still exists, goweave leaves it alone.
+//
+// Documents with cross-references are always generated, as the titles and
+// headings of the targets may have changed. Changes to other files that
+// comments refer to, like images, go unnoticed; `-force` regenerates all
+// documents regardless of the cache. In watch mode, goweave does not use
+// the cache.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"runtime/debug"
+	"sort"
+	"sync"
+)
+
+const cacheFilename = ".goweave-cache"
+
+// cacheNeutralFlags are the options that do not affect the documents.
+var cacheNeutralFlags = map[string]bool{
+	"jobs": true, "force": true, "watch": true, "serve": true,
+	"coverage": true, "min-doc-coverage": true, "badge": true,
+	"manifest": true, "site-report": true, "index": true, "index-title": true,
+	"robots": true, "config": true, "epub": true, "epub-title": true,
+	"epub-author": true, "webhook-secret": true,
+}
+
+// buildCache maps the documents to their fingerprints, by output name.
+type buildCache struct {
+	mu        sync.Mutex
+	Documents map[string]string `json:"documents"`
+	base      string            // the fingerprint of the template, CSS, and options
+	changed   bool
+}
+
+var cache = buildCache{Documents: map[string]string{}}
+
+// useCache reports whether documents may be skipped.
+func useCache() bool {
+	return !*force && !*watch && !isOutputArchive()
+}
+
+// loadCache reads the cache of the last run from the output directory, and
+// fingerprints what all documents depend on. A missing or broken cache
+// file just means that all documents get generated.
+func loadCache() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.base = baseFingerprint()
+	data, err := fs.ReadFile(outputFS(), cacheFilename)
+	if err != nil {
+		return
+	}
+	var c buildCache
+	if json.Unmarshal(data, &c) == nil && c.Documents != nil {
+		cache.Documents = c.Documents
+	}
+}
+
+// saveCache writes the cache to the output directory, if any document
+// changed.
+func saveCache() error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if !cache.changed || isOutputArchive() {
+		return nil
+	}
+	data, err := json.MarshalIndent(&cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return outputFS().WriteFile(cacheFilename, append(data, '\n'))
+}
+
+// baseFingerprint hashes the resource files, the options, and the build
+// information of goweave. Subdirectories of the resource directory and
+// other files that cannot be read count as empty.
+func baseFingerprint() string {
+	h := sha256.New()
+	files := append([]string(nil), resourceDeps...)
+	sort.Strings(files)
+	for _, f := range files {
+		data, _ := ioutil.ReadFile(f)
+		fmt.Fprintf(h, "%s %d\n", f, len(data))
+		h.Write(data)
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		if !cacheNeutralFlags[f.Name] {
+			fmt.Fprintf(h, "-%s=%q\n", f.Name, f.Value.String())
+		}
+	})
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintln(h, bi.String())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprint hashes a document before rendering. It returns "" for
+// documents that must be generated anyway.
+func fingerprint(d docs, sections []*section, filename string) string {
+	for _, s := range sections {
+		if crossRef.MatchString(s.Doc) {
+			return ""
+		}
+	}
+	data, err := json.Marshal(struct {
+		Source   string
+		Doc      docs
+		Sections []*section
+		Links    map[string]map[string]string
+	}{filename, d, sections, linkFingerprint(filename)})
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	cache.mu.Lock()
+	h.Write([]byte(cache.base))
+	cache.mu.Unlock()
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// linkFingerprint returns the declarations in other files that the
+// identifiers of a file link to, which the document depends on, too.
+func linkFingerprint(filename string) map[string]map[string]string {
+	links := map[string]map[string]string{}
+	for pkg, decls := range linkTargets(filename) {
+		links[pkg] = map[string]string{}
+		for name, t := range decls {
+			links[pkg][name] = outputName(t.file) + "#" + t.fragment
+		}
+	}
+	return links
+}
+
+// upToDate reports whether the document exists and has the fingerprint
+// that the cache recorded for it. With -pdf, the PDF file must exist, too.
+func upToDate(outname, sum string) bool {
+	if sum == "" || !useCache() {
+		return false
+	}
+	cache.mu.Lock()
+	cached := cache.Documents[outname]
+	cache.mu.Unlock()
+	if cached != sum {
+		return false
+	}
+	_, err := fs.Stat(outputFS(), outname)
+	if err == nil && *pdf {
+		_, err = fs.Stat(outputFS(), pdfName(outname))
+	}
+	return err == nil
+}
+
+// remember records the fingerprint of a document that was just written.
+func remember(outname, sum string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if sum == "" {
+		if _, ok := cache.Documents[outname]; ok {
+			delete(cache.Documents, outname)
+			cache.changed = true
+		}
+		return
+	}
+	if cache.Documents[outname] != sum {
+		cache.Documents[outname] = sum
+		cache.changed = true
+	}
+}
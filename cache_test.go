@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(o string, m, f bool) { *outdir, *md, *force = o, m, f }(*outdir, *md, *force)
+	*outdir, *md = dir, true
+	defer func() { cache = buildCache{Documents: map[string]string{}} }()
+	cache = buildCache{Documents: map[string]string{}}
+	loadCache()
+
+	out := filepath.Join(dir, "a.md")
+	write := func(doc string) string {
+		t.Helper()
+		err := writeDocument(docs{Title: "a.go"}, extractSections("a.go", doc), "a.go", "a.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(got)
+	}
+	const stale = "stale"
+	tests := []struct {
+		name    string
+		doc     string
+		force   bool
+		updated bool
+	}{
+		{"first run", "// Text\npackage main", false, true},
+		{"unchanged", "// Text\npackage main", false, false},
+		{"changed", "// Other text\npackage main", false, true},
+		{"forced", "// Other text\npackage main", true, true},
+		{"cross-reference", "// See [[b.go]]\npackage main", false, true},
+		{"cross-reference again", "// See [[b.go]]\npackage main", false, true},
+	}
+	for _, tt := range tests {
+		*force = tt.force
+		if err := ioutil.WriteFile(out, []byte(stale), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if got := write(tt.doc) != stale; got != tt.updated {
+			t.Errorf("%s: document updated = %v, want %v", tt.name, got, tt.updated)
+		}
+	}
+
+	if err := saveCache(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, cacheFilename)); err != nil {
+		t.Errorf("saveCache() wrote no cache file: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+// ## Canonical URLs and redirects
+//
+// Published documents get linked from elsewhere, so renaming a source file
+// should not break these links. A source file can list the former output
+// paths of its document in an aliases pragma:
+//
+//	//goweave:aliases old-name.html, articles/older-name.html
+//
+// goweave then writes a small redirect page to each of these paths, which
+// forwards the reader to the current document.
+//
+// Each document can also declare its canonical URL, to tell search engines
+// which copy of a page is the original. The `//goweave:canonical` pragma
+// sets it explicitly; otherwise, if -base-url is set, the canonical URL is
+// the base URL plus the name of the document.
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"strings"
+)
+
+// canonicalURL returns the canonical URL of a document, or "" if there is
+// none.
+func canonicalURL(fm frontMatter, outname string) string {
+	if c := fm["canonical"]; c != "" {
+		return c
+	}
+	if *baseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(*baseURL, "/") + "/" + outname
+}
+
+// aliases returns the alias paths from the front matter. They can be
+// separated by commas, spaces, or both.
+func (fm frontMatter) aliases() []string {
+	return strings.FieldsFunc(fm["aliases"], func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+var redirectPage = template.Must(template.New("redirect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>{{.Title}}</title>
+<meta charset="utf-8"/>
+<meta http-equiv="refresh" content="0; url={{.Target}}">
+{{with .Canonical}}<link rel="canonical" href="{{.}}">{{end}}
+</head>
+<body>
+<p>This page has moved to <a href="{{.Target}}">{{.Title}}</a>.</p>
+</body>
+</html>
+`))
+
+// redirectTarget returns the URL of the document relative to the alias.
+// Both paths are relative to the output directory.
+func redirectTarget(alias, outname string) (urlPath, error) {
+	alias = path.Clean(alias)
+	if path.IsAbs(alias) || alias == "." || alias == ".." || strings.HasPrefix(alias, "../") {
+		return "", fmt.Errorf("alias %q must be a path within the output directory", alias)
+	}
+	return urlPath(strings.Repeat("../", strings.Count(alias, "/")) + outname), nil
+}
+
+// writeRedirects writes a redirect page to each alias of a document.
+func writeRedirects(aliases []string, outname, title, canonical string) error {
+	for _, alias := range aliases {
+		target, err := redirectTarget(alias, outname)
+		if err != nil {
+			return err
+		}
+		if path.Clean(alias) == outname {
+			return fmt.Errorf("alias %q is the document itself", alias)
+		}
+		b := getBuffer()
+		err = redirectPage.Execute(b, struct {
+			Title     string
+			Target    urlPath
+			Canonical string
+		}{title, target, canonical})
+		if err == nil {
+			err = outputFS().WriteFile(path.Clean(alias), b.Bytes())
+		}
+		bufPool.Put(b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
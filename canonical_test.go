@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	defer func(u string) { *baseURL = u }(*baseURL)
+	tests := []struct {
+		fm   frontMatter
+		base string
+		want string
+	}{
+		{frontMatter{}, "", ""},
+		{frontMatter{}, "https://example.com/go/", "https://example.com/go/a.html"},
+		{frontMatter{}, "https://example.com/go", "https://example.com/go/a.html"},
+		{frontMatter{"canonical": "https://example.org/a"}, "https://example.com", "https://example.org/a"},
+	}
+	for _, tt := range tests {
+		*baseURL = tt.base
+		if got := canonicalURL(tt.fm, "a.html"); got != tt.want {
+			t.Errorf("canonicalURL(%v) with -base-url=%q = %q, want %q", tt.fm, tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestRedirectTarget(t *testing.T) {
+	tests := []struct {
+		alias   string
+		want    urlPath
+		wantErr bool
+	}{
+		{"old.html", "new.html", false},
+		{"a/b/old.html", "../../new.html", false},
+		{"./a/../old.html", "new.html", false},
+		{"../old.html", "", true},
+		{"/old.html", "", true},
+	}
+	for _, tt := range tests {
+		got, err := redirectTarget(tt.alias, "new.html")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("redirectTarget(%q) = %q, %v; want %q, error %v", tt.alias, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteRedirects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "redirects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(d string) { *outdir = d }(*outdir)
+	*outdir = dir
+	err = writeRedirects([]string{"old/name.html"}, "new.html", "New", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := ioutil.ReadFile(filepath.Join(dir, "old", "name.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(page), `url=../new.html`) {
+		t.Errorf("writeRedirects() wrote %s, want a redirect to ../new.html", page)
+	}
+	if err := writeRedirects([]string{"new.html"}, "new.html", "New", ""); err == nil {
+		t.Errorf("writeRedirects() accepted an alias that overwrites the document")
+	}
+}
@@ -0,0 +1,47 @@
+// ## Change markers
+//
+// In a long document, an author who saves a file in the live preview of
+// -serve and -watch has to look for the effect of the edit. So the preview
+// marks what changed: when a page loads, a script compares its sections to
+// those of the last load, which it keeps in the session storage of the
+// browser, and briefly highlights the sections that changed or were added.
+// A red line shows where sections were removed. The first load of a page
+// has nothing to compare to, and shows no markers.
+//
+// The script matches the sections by their content, through the longest
+// common subsequence of the old and the new sections, so that adding a
+// section marks only that section and not all the ones after it. Only the
+// preview server adds the script; the generated files stay as they are.
+// The script is goweave-changes.js of the resource directory, or the
+// built-in one.
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// changesfilename is the name of the script in the resources.
+const changesfilename = "goweave-changes.js"
+
+// changesPath is the URL path of the script on the preview server.
+const changesPath = "/_goweave/changes.js"
+
+// serveChangeScript serves the script that marks the sections that changed
+// since the last load of the page.
+func serveChangeScript(w http.ResponseWriter, req *http.Request) {
+	data, err := fs.ReadFile(resourceFS(resourcedir), changesfilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = fs.ReadFile(weave.Resources(), changesfilename)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(data)
+}
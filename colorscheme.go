@@ -0,0 +1,85 @@
+// ## Dark mode
+//
+// The colors of goweave.css are CSS custom properties, like
+// `--goweave-text`, in a light and a dark palette. Documents follow the
+// reader's system setting through the `prefers-color-scheme` media query,
+// and complete documents get a button that switches between the palettes.
+// The browser remembers the reader's choice for all documents of the site,
+// and a script in the head of each document applies it before the page
+// shows up, so that it does not flash in the wrong colors.
+//
+// `-color-scheme=light` or `-color-scheme=dark` fixes the palette instead,
+// and leaves out the button. So does -theme: the bundled themes set the
+// colors for both palettes.
+//
+// With -highlighter=chroma, the code gets the colors of -chroma-style in
+// the light palette and those of -chroma-dark-style in the dark one.
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// The values of the -color-scheme option.
+const (
+	schemeAuto  = "auto"
+	schemeLight = "light"
+	schemeDark  = "dark"
+)
+
+// The selectors of the root element when the dark palette applies: when
+// the system prefers dark colors and the reader has not picked the light
+// ones, and when the reader picked the dark ones.
+const (
+	darkBySystem = `:root:not([data-color-scheme="light"])`
+	darkByChoice = `:root[data-color-scheme="dark"]`
+)
+
+// checkColorScheme validates the -color-scheme option.
+func checkColorScheme() error {
+	switch *colorScheme {
+	case schemeAuto, schemeLight, schemeDark:
+		return nil
+	}
+	return fmt.Errorf("-color-scheme must be one of %s, %s, or %s, not %q",
+		schemeAuto, schemeLight, schemeDark, *colorScheme)
+}
+
+// fixedColorScheme returns the palette that -color-scheme fixes, or "" if
+// the documents follow the reader's setting.
+func fixedColorScheme() string {
+	if *colorScheme == schemeAuto {
+		return ""
+	}
+	return *colorScheme
+}
+
+// colorSchemeToggle reports whether a document gets the button that
+// switches between the palettes. Documents without a head of their own
+// leave the colors to the page around them.
+func colorSchemeToggle(full bool) bool {
+	return full && *colorScheme == schemeAuto && *theme == ""
+}
+
+// darkHighlightCSS returns the rules for the color style of the dark
+// palette, for the code within the elements that root selects, or "" if
+// there is no dark style.
+func darkHighlightCSS(c *weave.Chroma, root string) string {
+	if c == nil || *theme != "" || *colorScheme == schemeLight {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("@media screen and (prefers-color-scheme: dark) {\n")
+	for _, scope := range highlightScopes {
+		b.WriteString(c.CSS(darkBySystem + " " + root + scope))
+	}
+	b.WriteString("}\n@media screen {\n")
+	for _, scope := range highlightScopes {
+		b.WriteString(c.CSS(darkByChoice + " " + root + scope))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestCheckColorScheme(t *testing.T) {
+	defer func(s string) { *colorScheme = s }(*colorScheme)
+	for scheme, ok := range map[string]bool{schemeAuto: true, schemeLight: true, schemeDark: true, "sepia": false} {
+		*colorScheme = scheme
+		if err := checkColorScheme(); (err == nil) != ok {
+			t.Errorf("checkColorScheme() with %q = %v", scheme, err)
+		}
+	}
+}
+
+func TestDarkHighlightCSS(t *testing.T) {
+	defer func(name, style, dark, scheme, th string, h weave.Highlighter, d *weave.Chroma) {
+		*highlighterName, *chromaStyle, *chromaDarkStyle, *colorScheme, *theme = name, style, dark, scheme, th
+		codeHighlighter, darkChroma = h, d
+	}(*highlighterName, *chromaStyle, *chromaDarkStyle, *colorScheme, *theme, codeHighlighter, darkChroma)
+	tests := []struct {
+		darkStyle, scheme, theme string
+		wantDark                 bool
+	}{
+		{"monokai", schemeAuto, "", true},
+		{"monokai", schemeDark, "", true},
+		{"monokai", schemeLight, "", false},
+		{"monokai", schemeAuto, "classic", false},
+		{"", schemeAuto, "", false},
+	}
+	for _, tt := range tests {
+		*highlighterName, *chromaStyle, *chromaDarkStyle = "chroma", "github", tt.darkStyle
+		*colorScheme, *theme = tt.scheme, tt.theme
+		if err := loadHighlighter(); err != nil {
+			t.Fatal(err)
+		}
+		css := string(highlightCSS())
+		if !strings.Contains(css, "#goweave .code .kd") {
+			t.Errorf("highlightCSS() with %+v lacks the light rules", tt)
+		}
+		for _, sel := range []string{darkBySystem + " #goweave .code .kd", darkByChoice + " #goweave #raw .kd"} {
+			if strings.Contains(css, sel) != tt.wantDark {
+				t.Errorf("highlightCSS() with %+v: contains %q = %v, want %v", tt, sel, !tt.wantDark, tt.wantDark)
+			}
+		}
+	}
+}
+
+func TestColorSchemeToggle(t *testing.T) {
+	defer func(scheme, th string) { *colorScheme, *theme = scheme, th }(*colorScheme, *theme)
+	templ = template.Must(template.ParseFS(weave.Resources(), tplfilename))
+	tests := []struct {
+		scheme, theme string
+		wantToggle    bool
+		wantAttr      string
+	}{
+		{schemeAuto, "", true, ""},
+		{schemeDark, "", false, `data-color-scheme="dark"`},
+		{schemeAuto, "dark", false, ""},
+	}
+	for _, tt := range tests {
+		*colorScheme, *theme = tt.scheme, tt.theme
+		got := generateDocs("a.go", "// Doc\npackage main\n")
+		if strings.Contains(got, `id="color-scheme-toggle"`) != tt.wantToggle {
+			t.Errorf("generateDocs() with %+v: toggle = %v, want %v", tt, !tt.wantToggle, tt.wantToggle)
+		}
+		if tt.wantAttr != "" && !strings.Contains(got, "<html"+" "+tt.wantAttr+">") && !strings.Contains(got, tt.wantAttr+">") {
+			t.Errorf("generateDocs() with %+v lacks %s on the html element", tt, tt.wantAttr)
+		}
+	}
+}
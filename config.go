@@ -0,0 +1,231 @@
+// ## Configuration file
+//
+// Instead of passing the same flags over and over again, put them into a
+// `goweave.yaml` file in the current directory (or point -config to another
+// file). The keys are the names of the flags:
+//
+//	outdir: docs
+//	csspath: css
+//	exclude: [vendor, "*_gen.go"]
+//
+// `.goweave.yaml` works as well, and so do `goweave.toml` and
+// `.goweave.toml` for those who prefer TOML:
+//
+//	outdir = "docs"
+//	exclude = ["vendor", "*_gen.go"]
+//
+// A list sets a flag to the comma-separated items.
+//
+// The `languages` key maps file extensions to languages, either to one that
+// goweave knows or to a new comment syntax:
+//
+//	languages:
+//	  .pyx: python
+//	  .nim:
+//	    name: nim
+//	    line: "#"
+//	    block_start: "#["
+//	    block_end: "]#"
+//
+// A config file can also define named profiles that bundle flags for a
+// particular purpose. Select a profile with -profile:
+//
+//	profiles:
+//	  blog:
+//	    md: true
+//	    outdir: content/posts
+//	  readme:
+//	    md: true
+//	    intro: true
+//
+// Flags given on the command line override the profile, and the profile
+// overrides the top-level settings of the config file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/christophberger/goweave/weave"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultConfigFiles are the config files that goweave looks for in the
+// current directory, in this order, unless -config is set.
+var defaultConfigFiles = []string{"goweave.yaml", ".goweave.yaml", "goweave.toml", ".goweave.toml"}
+
+var (
+	configFile = flag.String("config", "", "configuration file (default: goweave.yaml, .goweave.yaml, goweave.toml, or .goweave.toml)")
+	profile    = flag.String("profile", "", "named profile from the configuration file")
+)
+
+// config is the content of a configuration file. Both the top level and the
+// profiles map flag names to flag values.
+type config struct {
+	Options   map[string]interface{}            `yaml:",inline"`
+	Profiles  map[string]map[string]interface{} `yaml:"profiles"`
+	Languages map[string]languageConfig         `yaml:"languages"`
+}
+
+// languageConfig is the language of a file extension: either the name of
+// a language that goweave knows, or the comment syntax of a new language.
+type languageConfig struct {
+	Name       string   `yaml:"name"`
+	Line       string   `yaml:"line"`
+	BlockStart string   `yaml:"block_start"`
+	BlockEnd   string   `yaml:"block_end"`
+	Directives []string `yaml:"directives"`
+}
+
+// UnmarshalYAML accepts a plain language name as well as a mapping.
+func (l *languageConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&l.Name); err == nil {
+		return nil
+	}
+	type plain languageConfig
+	return unmarshal((*plain)(l))
+}
+
+// language returns the language that the entry describes.
+func (l languageConfig) language() (*weave.Language, error) {
+	if l.Line == "" && l.BlockStart == "" {
+		if known := weave.LanguageNamed(l.Name); known != nil {
+			return known, nil
+		}
+		return nil, fmt.Errorf("unknown language %q", l.Name)
+	}
+	if l.Name == "" {
+		return nil, fmt.Errorf("a language needs a name")
+	}
+	if (l.BlockStart == "") != (l.BlockEnd == "") {
+		return nil, fmt.Errorf("language %q needs both block_start and block_end", l.Name)
+	}
+	return &weave.Language{Name: l.Name, LineComment: l.Line, BlockStart: l.BlockStart, BlockEnd: l.BlockEnd, Directives: l.Directives}, nil
+}
+
+// loadConfig reads a configuration file. Without a file name, it reads the
+// first of the default config files that exists, if any; goweave simply
+// runs without one.
+func loadConfig(filename string) (*config, error) {
+	if filename == "" {
+		for _, f := range defaultConfigFiles {
+			if _, err := os.Stat(f); err == nil {
+				filename = f
+				break
+			}
+		}
+		if filename == "" {
+			return &config{}, nil
+		}
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".toml") {
+		// Go through YAML, so that both formats get the same checks.
+		var m map[string]interface{}
+		if _, err := toml.Decode(string(data), &m); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		if data, err = yaml.Marshal(m); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	c := &config{}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return c, nil
+}
+
+// flagValue turns a config value into the text of a flag value. The items
+// of a list get joined by commas.
+func flagValue(v interface{}) string {
+	if list, ok := v.([]interface{}); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// registerLanguages adds the languages of the config file to the registry
+// of the weave package.
+func (c *config) registerLanguages() error {
+	exts := make([]string, 0, len(c.Languages))
+	for ext := range c.Languages {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		l, err := c.Languages[ext].language()
+		if err != nil {
+			return fmt.Errorf("languages: %s: %v", ext, err)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		weave.RegisterLanguage(ext, l)
+	}
+	return nil
+}
+
+// apply sets all flags from the config file and the given profile, except
+// for those that were set on the command line.
+func (c *config) apply(fs *flag.FlagSet, profile string) error {
+	options := map[string]interface{}{}
+	for k, v := range c.Options {
+		options[k] = v
+	}
+	if profile != "" {
+		p, ok := c.Profiles[profile]
+		if !ok {
+			return fmt.Errorf("unknown profile %q", profile)
+		}
+		for k, v := range p {
+			options[k] = v
+		}
+	}
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	// Apply the options in a fixed order, for deterministic error messages.
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if explicit[name] {
+			continue
+		}
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown option %q", name)
+		}
+		if err := fs.Set(name, flagValue(options[name])); err != nil {
+			return fmt.Errorf("option %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// applyConfig loads the config file given by -config and applies it
+// to the command line flags.
+func applyConfig() error {
+	c, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if err := c.registerLanguages(); err != nil {
+		return err
+	}
+	return c.apply(flag.CommandLine, *profile)
+}
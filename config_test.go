@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+	"gopkg.in/yaml.v2"
+)
+
+const testConfig = `
+outdir: docs
+inline: true
+profiles:
+  blog:
+    md: true
+    outdir: content/posts
+`
+
+func TestConfigApply(t *testing.T) {
+	tests := []struct {
+		args    []string
+		profile string
+		outdir  string
+		inline  bool
+		md      bool
+		wantErr bool
+	}{
+		{nil, "", "docs", true, false, false},
+		{[]string{"-outdir=out"}, "", "out", true, false, false},
+		{nil, "blog", "content/posts", true, true, false},
+		{[]string{"-inline=false"}, "blog", "content/posts", false, true, false},
+		{nil, "nosuchprofile", ".", false, false, true},
+	}
+	for _, tt := range tests {
+		var c config
+		if err := yaml.UnmarshalStrict([]byte(testConfig), &c); err != nil {
+			t.Fatal(err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		outdir := fs.String("outdir", ".", "")
+		inline := fs.Bool("inline", false, "")
+		md := fs.Bool("md", false, "")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		err := c.apply(fs, tt.profile)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("apply(%v, %q) error = %v, wantErr %v", tt.args, tt.profile, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (*outdir != tt.outdir || *inline != tt.inline || *md != tt.md) {
+			t.Errorf("apply(%v, %q): outdir=%q inline=%v md=%v, want %q %v %v",
+				tt.args, tt.profile, *outdir, *inline, *md, tt.outdir, tt.inline, tt.md)
+		}
+	}
+}
+
+func TestConfigUnknownOption(t *testing.T) {
+	c := config{Options: map[string]interface{}{"outdri": "docs"}}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("outdir", ".", "")
+	if err := c.apply(fs, ""); err == nil {
+		t.Error("apply() accepted an unknown option")
+	}
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+
+	c, err := loadConfig("")
+	if err != nil || len(c.Options) != 0 {
+		t.Fatalf("loadConfig() without a config file = %v, %v", c, err)
+	}
+	toml := "outdir = \"docs\"\nexclude = [\"vendor\", \"*_gen.go\"]\n\n[languages]\n\".pyx\" = \"python\"\n\n[languages.\".nim\"]\nname = \"nim\"\nline = \"#\"\n\n[profiles.blog]\nmd = true\n"
+	if err := ioutil.WriteFile(".goweave.toml", []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = loadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Options["outdir"] != "docs" || flagValue(c.Options["exclude"]) != "vendor,*_gen.go" || c.Profiles["blog"]["md"] != true {
+		t.Errorf("loadConfig() = %+v", c)
+	}
+	defer func(pyx, nim *weave.Language) {
+		weave.RegisterLanguage(".pyx", pyx)
+		weave.RegisterLanguage(".nim", nim)
+	}(weave.LanguageFor("a.pyx"), weave.LanguageFor("a.nim"))
+	if err := c.registerLanguages(); err != nil {
+		t.Fatal(err)
+	}
+	if l := weave.LanguageFor("a.pyx"); l == nil || l.Name != "python" {
+		t.Errorf("languages: .pyx maps to %v, want python", l)
+	}
+	if l := weave.LanguageFor("a.nim"); l == nil || l.LineComment != "#" {
+		t.Errorf("languages: .nim maps to %v, want a language with # comments", l)
+	}
+
+	// The YAML file comes first.
+	if err := ioutil.WriteFile("goweave.yaml", []byte("outdir: yaml\nlanguages:\n  .x: cobol\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = loadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Options["outdir"] != "yaml" {
+		t.Errorf("loadConfig() read the TOML file rather than goweave.yaml")
+	}
+	if err := c.registerLanguages(); err == nil {
+		t.Errorf("registerLanguages() accepted an unknown language")
+	}
+}
@@ -0,0 +1,94 @@
+// ## Constant tables
+//
+// A block of constants, and even more so an enum built with iota, hides
+// the values behind the code that computes them:
+//
+//	const (
+//		Read Mode = 1 << iota
+//		Write
+//		Exec
+//	)
+//
+// With -const-tables, goweave adds a table beneath each const block that
+// lists the names with their values and comments, here 1, 2, and 4. The
+// values come from type-checking the code of the file, so constants that
+// depend on other packages show their expression instead. Single
+// constants, and blocks with only one name, need no table.
+//
+// Like the tables of -schema, a table is a section of its own, with the ID
+// `const-<first name>`; see tables.go.
+package main
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+// constIDPrefix starts the IDs of the sections that hold const tables.
+const constIDPrefix = "const-"
+
+// constBlockTables returns a table for each block of constants with at
+// least two names.
+func constBlockTables(c *sectionCode) []codeTable {
+	info := &types.Info{Defs: map[*ast.Ident]types.Object{}}
+	conf := types.Config{Error: func(error) {}} // imports are not needed for most constants
+	conf.Check(c.file.Name.Name, c.fset, []*ast.File{c.file}, info)
+	var tables []codeTable
+	for _, decl := range c.file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.CONST || !gd.Lparen.IsValid() {
+			continue
+		}
+		var rows [][]string
+		var first string
+		var typ types.Type
+		sameType := true
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			doc := c.docOf(vs.Pos(), vs.Doc, vs.Comment)
+			for i, n := range vs.Names {
+				if n.Name == "_" {
+					continue
+				}
+				if first == "" {
+					first = n.Name
+				}
+				value := ""
+				if i < len(vs.Values) {
+					value = types.ExprString(vs.Values[i])
+				}
+				if obj, ok := info.Defs[n].(*types.Const); ok && obj.Val().Kind() != constant.Unknown {
+					value = constValue(obj.Val())
+					if typ == nil {
+						typ = obj.Type()
+					}
+					sameType = sameType && types.Identical(typ, obj.Type())
+				} else {
+					sameType = false
+				}
+				rows = append(rows, []string{codeSpan(n.Name), codeSpan(value), doc})
+			}
+		}
+		if len(rows) < 2 {
+			continue
+		}
+		caption := "Constants"
+		if b, untyped := typ.(*types.Basic); sameType && !(untyped && b.Info()&types.IsUntyped != 0) {
+			caption += " of type `" + types.TypeString(typ, func(*types.Package) string { return "" }) + "`"
+		}
+		table := markdownTable(caption, []string{"Name", "Value", "Description"}, rows)
+		tables = append(tables, c.tableSection(constIDPrefix+first, table, gd.Pos(), gd.Rparen))
+	}
+	return tables
+}
+
+// constValue returns a constant value the way it would appear in Go code.
+func constValue(v constant.Value) string {
+	if v.Kind() == constant.String {
+		return strconv.Quote(constant.StringVal(v))
+	}
+	return v.String()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConstTables(t *testing.T) {
+	defer func(s, c bool) { *schema, *constTables = s, c }(*schema, *constTables)
+	*schema, *constTables = false, true
+	src := "package p\n\nimport \"time\"\n\ntype Mode int\n\n// The modes.\nconst (\n\t_ Mode = iota\n\t// Read reads.\n\tRead\n\tWrite // writes\n\tExec Mode = 1 << 4\n)\n\n" +
+		"const (\n\tName = \"goweave\"\n\tTimeout = 3 * time.Second\n)\n\nconst Single = 1\n"
+	var tables []*section
+	for _, s := range tableSections(extractSections("p.go", src)) {
+		if isTableSection(s.ID) {
+			tables = append(tables, s)
+		}
+	}
+	if len(tables) != 2 {
+		t.Fatalf("tableSections() added %d tables, want 2", len(tables))
+	}
+	tests := []struct {
+		id   string
+		rows []string
+	}{
+		{"const-Read", []string{"*Constants of type `Mode`*", "| `Read` | `1` | Read reads. |", "| `Write` | `2` | writes |", "| `Exec` | `16` | |"}},
+		{"const-Name", []string{"*Constants*\n", "| `Name` | `\"goweave\"` | |", "| `Timeout` | `3 * time.Second` | |"}},
+	}
+	for i, tt := range tests {
+		if tables[i].ID != tt.id {
+			t.Errorf("table %d has the ID %s, want %s", i, tables[i].ID, tt.id)
+		}
+		for _, row := range tt.rows {
+			if !strings.Contains(tables[i].Doc, row) {
+				t.Errorf("the table %s is\n%s\nwant %s in it", tt.id, tables[i].Doc, row)
+			}
+		}
+	}
+}
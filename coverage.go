@@ -0,0 +1,126 @@
+// ## Documentation coverage
+//
+// The "literate coverage" of a file is the fraction of its code sections
+// that come with some real prose. A code section whose preceding comment is
+// missing, or consists of just a word or two, counts as undocumented.
+//
+// With -coverage, goweave prints the coverage per file and per package
+// (that is, per directory) after processing all files. -min-doc-coverage
+// additionally makes goweave exit with an error if the total coverage is
+// below the given fraction, e.g., -min-doc-coverage=0.6.
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// minProseWords is the minimum number of words that make a comment
+// non-trivial.
+const minProseWords = 3
+
+// docCoverage holds the number of documented and total code sections.
+type docCoverage struct {
+	Documented int
+	Total      int
+}
+
+func (c *docCoverage) add(d docCoverage) {
+	c.Documented += d.Documented
+	c.Total += d.Total
+}
+
+// Ratio returns the coverage as a fraction between 0 and 1.
+// Files without any code count as fully covered.
+func (c docCoverage) Ratio() float64 {
+	if c.Total == 0 {
+		return 1
+	}
+	return float64(c.Documented) / float64(c.Total)
+}
+
+func (c docCoverage) String() string {
+	return fmt.Sprintf("%5.1f%% (%d of %d code sections documented)", 100*c.Ratio(), c.Documented, c.Total)
+}
+
+// sectionCoverage computes the coverage of a file from its raw,
+// unrendered sections.
+func sectionCoverage(sections []*section) docCoverage {
+	var c docCoverage
+	for _, s := range sections {
+		if strings.TrimSpace(s.Code) == "" {
+			continue
+		}
+		c.Total++
+		if len(strings.Fields(s.Doc)) >= minProseWords {
+			c.Documented++
+		}
+	}
+	return c
+}
+
+// The coverage of all files processed so far, by file name.
+var (
+	coverageMu sync.Mutex
+	coverage   = map[string]docCoverage{}
+)
+
+func addCoverage(filename string, c docCoverage) {
+	coverageMu.Lock()
+	coverage[filename] = c
+	coverageMu.Unlock()
+}
+
+// totalCoverage sums up the coverage of all files.
+func totalCoverage() docCoverage {
+	coverageMu.Lock()
+	defer coverageMu.Unlock()
+	var total docCoverage
+	for _, c := range coverage {
+		total.add(c)
+	}
+	return total
+}
+
+// printCoverage writes the coverage report to w.
+func printCoverage(w io.Writer) {
+	// Copy the coverage, as workers may still add to it.
+	coverageMu.Lock()
+	byFile := make(map[string]docCoverage, len(coverage))
+	for f, c := range coverage {
+		byFile[f] = c
+	}
+	coverageMu.Unlock()
+	files := make([]string, 0, len(byFile))
+	pkgs := map[string]docCoverage{}
+	var total docCoverage
+	for f, c := range byFile {
+		files = append(files, f)
+		pkg := pkgs[filepath.Dir(f)]
+		pkg.add(c)
+		pkgs[filepath.Dir(f)] = pkg
+		total.add(c)
+	}
+	sort.Strings(files)
+	dirs := make([]string, 0, len(pkgs))
+	for d := range pkgs {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "Documentation coverage:")
+	for _, f := range files {
+		fmt.Fprintf(tw, "  %s\t%v\n", f, byFile[f])
+	}
+	for _, d := range dirs {
+		fmt.Fprintf(tw, "  package %s\t%v\n", d, pkgs[d])
+	}
+	fmt.Fprintf(tw, "  total\t%v\n", total)
+	tw.Flush()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSectionCoverage(t *testing.T) {
+	tests := []struct {
+		sections []*section
+		want     docCoverage
+	}{
+		{[]*section{
+			{Doc: "This is documented.\n", Code: "func a() {}\n"},
+			{Doc: "Too short\n", Code: "func b() {}\n"},
+			{Doc: "", Code: "func c() {}\n"},
+			{Doc: "A full-width section without code.\n", Code: "\n"},
+		}, docCoverage{1, 3}},
+		{[]*section{{Doc: "Only prose here.\n", Code: ""}}, docCoverage{0, 0}},
+	}
+	for _, tt := range tests {
+		if got := sectionCoverage(tt.sections); got != tt.want {
+			t.Errorf("sectionCoverage() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestDocCoverageRatio(t *testing.T) {
+	tests := []struct {
+		c    docCoverage
+		want float64
+	}{
+		{docCoverage{0, 0}, 1},
+		{docCoverage{3, 4}, 0.75},
+		{docCoverage{0, 2}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Ratio(); got != tt.want {
+			t.Errorf("%#v.Ratio() = %v, want %v", tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestPrintCoverageConcurrently(t *testing.T) {
+	defer func(c map[string]docCoverage) { coverage = c }(coverage)
+	coverage = map[string]docCoverage{}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				addCoverage(fmt.Sprintf("p%d/f%d.go", i, j), docCoverage{1, 1})
+			}
+		}(i)
+	}
+	for i := 0; i < 10; i++ {
+		printCoverage(ioutil.Discard)
+	}
+	wg.Wait()
+	var b strings.Builder
+	printCoverage(&b)
+	if !strings.Contains(b.String(), "p3/f49.go") {
+		t.Errorf("printCoverage() =\n%s\nwant all files", b.String())
+	}
+}
@@ -0,0 +1,354 @@
+// ## Cross-references
+//
+// Comments can refer to other parts of the woven site in double brackets:
+//
+// * `[[#slug]]` refers to a heading, a caption like `listing-4`, or a
+// declaration like `FuncName` or `Type.Method` in the same document.
+// * `[[file.go]]` refers to the document of another input file, relative to
+// the directory of the current file.
+// * `[[file.go#FuncName]]` refers to a heading, caption, or declaration in
+// another document.
+// * `[[target|text]]` sets the text of the link.
+//
+// goweave turns each reference into a Markdown link before rendering the
+// comments. The link is relative, so it keeps working wherever the output
+// directory gets published, and unless the reference sets a text, the link
+// shows the title of the target: the document title, the heading, the
+// caption label, or the name of the declaration.
+//
+// A reference that points nowhere is reported as a warning and stays as it
+// is. With -strict, it fails the document instead.
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// crossRef matches a reference, with the target in the first group and the
+// optional link text in the second.
+var crossRef = regexp.MustCompile(`\[\[([^\[\]|]+)(?:\|([^\[\]]+))?\]\]`)
+
+// refAnchor is a place within a document that references can point to.
+type refAnchor struct {
+	fragment string // the ID of the element
+	title    string
+	code     bool // the title is an identifier
+}
+
+// refPage describes the document of an input file, as far as references
+// are concerned.
+type refPage struct {
+	title   string
+	output  string
+	pkg     string // the package name, for Go source
+	anchors map[string]refAnchor
+	// pages are the names of the pages if -split splits the document, and
+	// pageOf the page of each fragment.
+	pages  []string
+	pageOf map[string]int
+}
+
+var (
+	refInputs = map[string]bool{}     // the input files of this run
+	refPages  = map[string]*refPage{} // the documents referred to so far
+	refMu     sync.Mutex
+)
+
+// indexRefs records the input files of the run. Their documents are
+// analyzed only when a reference points to them.
+func indexRefs(filenames []string) {
+	refMu.Lock()
+	defer refMu.Unlock()
+	for _, f := range filenames {
+		refInputs[filepath.Clean(f)] = true
+	}
+}
+
+// forgetRefPage drops what is known about a document, for example, after
+// its input file has changed in watch mode.
+func forgetRefPage(filename string) {
+	refMu.Lock()
+	delete(refPages, filepath.Clean(filename))
+	refMu.Unlock()
+}
+
+// lookupRefPage returns the document of an input file.
+func lookupRefPage(filename string) (*refPage, error) {
+	filename = filepath.Clean(filename)
+	refMu.Lock()
+	defer refMu.Unlock()
+	if p, ok := refPages[filename]; ok {
+		return p, nil
+	}
+	if !refInputs[filename] {
+		return nil, fmt.Errorf("%s is not an input file", filename)
+	}
+	src, err := readSource(filename)
+	if err != nil {
+		return nil, err
+	}
+	p := newRefPage(filename, src)
+	refPages[filename] = p
+	return p, nil
+}
+
+// newRefPage collects the headings, captions, and declarations of a source
+// file. A declaration's anchor is the ID of the section that contains it.
+func newRefPage(filename string, src []byte) *refPage {
+	p := &refPage{
+		title:   filepath.Base(filename),
+		output:  outputName(filename),
+		anchors: map[string]refAnchor{},
+	}
+	if t := parseFrontMatter(filename, string(src))["title"]; t != "" {
+		p.title = t
+	}
+	sections := extractWithSidecar(filename, src, false)
+	weave.AssignIDs(sections)
+	for _, h := range weave.Headings(sections, 6) {
+		p.anchors[h.ID] = refAnchor{fragment: h.ID, title: h.Text}
+	}
+	for _, c := range weave.NumberCaptions(sections) {
+		p.anchors[c.ID()] = refAnchor{fragment: c.ID(), title: c.Label()}
+	}
+	if points := splitPoints(sections, p.output); points != nil {
+		for _, pt := range points {
+			p.pages = append(p.pages, pt.name)
+		}
+		p.pageOf = splitFragments(sections, points)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return p // not Go source, or not yet; there are no declarations then
+	}
+	p.pkg = file.Name.Name
+	lines := strings.Split(string(src), "\n")
+	add := func(name string, pos token.Pos) {
+		line := strings.TrimRight(lines[fset.Position(pos).Line-1], "\r")
+		for _, s := range sections {
+			if containsLine(s.Code, line) {
+				p.anchors[name] = refAnchor{fragment: s.ID, title: name, code: true}
+				return
+			}
+		}
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverType(d.Recv.List[0].Type) + "." + name
+			}
+			add(name, d.Pos())
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name.Name, s.Pos())
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						add(n.Name, s.Pos())
+					}
+				}
+			}
+		}
+	}
+	return p
+}
+
+// receiverType returns the name of a method's receiver type, without any
+// pointer or type parameters.
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// containsLine returns true if one of the lines of code is exactly line.
+func containsLine(code, line string) bool {
+	for _, l := range strings.Split(code, "\n") {
+		if strings.TrimRight(l, "\r") == line {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRefs replaces the references in the comments of a document with
+// Markdown links. source is the input file of the document, and outname
+// the name of the document within the output directory.
+func resolveRefs(sections []*section, source, outname string) error {
+	var errs []string
+	for _, s := range sections {
+		s.Doc = replaceRefs(s.Doc, func(ref, target, text string) string {
+			link, err := resolveRef(target, text, source, outname)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("cannot resolve %s: %v", ref, err))
+				return ref
+			}
+			return link
+		})
+	}
+	if *siteReport {
+		for _, e := range errs {
+			addDeadRef(source, e)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	if *strict {
+		return fmt.Errorf("%s: %s", source, strings.Join(errs, "\n"+source+": "))
+	}
+	for _, e := range errs {
+		log.Printf("%s: %s", source, e)
+	}
+	return nil
+}
+
+// replaceRefs replaces the references in a comment, outside fenced and
+// indented code blocks and code spans, with what repl returns for them.
+func replaceRefs(doc string, repl func(ref, target, text string) string) string {
+	if !strings.Contains(doc, "[[") {
+		return doc
+	}
+	lines := strings.Split(doc, "\n")
+	fenced, indented := false, false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+		}
+		// An indented line starts a code block after an empty line, and
+		// continues it.
+		blank := i == 0 || strings.TrimSpace(lines[i-1]) == ""
+		indented = (blank || indented) && strings.TrimSpace(line) != "" &&
+			(strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"))
+		if fenced || indented {
+			continue // references in code examples are no references
+		}
+		lines[i] = outsideCodeSpans(line, func(text string) string {
+			return crossRef.ReplaceAllStringFunc(text, func(ref string) string {
+				m := crossRef.FindStringSubmatch(ref)
+				return repl(ref, strings.TrimSpace(m[1]), strings.TrimSpace(m[2]))
+			})
+		})
+	}
+	return strings.Join(lines, "\n")
+}
+
+// outsideCodeSpans applies f to the text of a line outside of its code
+// spans. A code span ends with a run of as many backticks as it started
+// with; a run without such an end is text.
+func outsideCodeSpans(line string, f func(string) string) string {
+	var b strings.Builder
+	text := 0 // the start of the text before the next code span
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		n := backticks(line[i:])
+		end := -1
+		for j := i + n; j < len(line); {
+			if line[j] != '`' {
+				j++
+				continue
+			}
+			m := backticks(line[j:])
+			if m == n {
+				end = j + m
+				break
+			}
+			j += m
+		}
+		if end < 0 {
+			i += n
+			continue
+		}
+		b.WriteString(f(line[text:i]))
+		b.WriteString(line[i:end])
+		i, text = end, end
+	}
+	b.WriteString(f(line[text:]))
+	return b.String()
+}
+
+// backticks returns the length of the run of backticks at the start of s.
+func backticks(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	return n
+}
+
+// refFile returns the input file that the target of a reference in source
+// points to, and the anchor within its document.
+func refFile(target, source string) (filename, anchor string) {
+	file := target
+	if i := strings.Index(target, "#"); i >= 0 {
+		file, anchor = target[:i], target[i+1:]
+	}
+	filename = source
+	if file != "" {
+		filename = filepath.Join(filepath.Dir(source), filepath.FromSlash(file))
+	}
+	return filename, anchor
+}
+
+// resolveRef turns a single reference into a Markdown link.
+func resolveRef(target, text, source, outname string) (string, error) {
+	filename, anchor := refFile(target, source)
+	page, err := lookupRefPage(filename)
+	if err != nil {
+		return "", err
+	}
+	href, title := "", page.title
+	if filepath.Clean(filename) != filepath.Clean(source) {
+		href = string(rootPath(outname)) + page.output
+	}
+	if anchor != "" {
+		a, ok := page.anchors[anchor]
+		if !ok {
+			return "", fmt.Errorf("%s has no heading, caption, or declaration %q", page.output, anchor)
+		}
+		title = a.title
+		if a.code {
+			title = "`" + title + "`"
+		}
+		if h := splitHref(page, a.fragment, outname, filepath.Clean(filename) == filepath.Clean(source)); h != "" {
+			href = h
+		}
+		// Markdown documents have no anchors for sections.
+		if !a.code || sectionAnchors() {
+			href += "#" + a.fragment
+		}
+	}
+	if href == "" {
+		href = "#"
+	}
+	if text == "" {
+		text = title
+	}
+	text = strings.NewReplacer("[", `\[`, "]", `\]`).Replace(text)
+	return "[" + text + "](" + strings.Replace(href, " ", "%20", -1) + ")", nil
+}
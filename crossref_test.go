@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveRefs(t *testing.T) {
+	defer func(inputs map[string]bool, pages map[string]*refPage) { refInputs, refPages = inputs, pages }(refInputs, refPages)
+	refInputs, refPages = map[string]bool{}, map[string]*refPage{}
+	dir, err := ioutil.TempDir("", "goweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"server.go": "//goweave:title A Web Server\n// # Serving requests\npackage main\n\n// Start it.\n//goweave:caption Listing: startup\nfunc (s *Server) Start() {}\n",
+		"main.go":   "// ## Setup\n// See [[server.go]], [[server.go#Server.Start]], [[server.go#listing-1|the listing]],\n// [[#setup]], and [[#main]].\n// ```\n// m[[0]]\n// ```\n// Write `[[file.go#Name]]` or ``[[#x]]`` for [[#setup]].\n//\n//     n[[1]]\npackage main\n\nfunc main() {}\n",
+	}
+	var names []string
+	for name, src := range files {
+		filename := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, filename)
+	}
+	indexRefs(names)
+	source := filepath.Join(dir, "main.go")
+	sections := extractSections(source, files["main.go"])
+	if err := resolveRefs(sections, source, outputName(source)); err != nil {
+		t.Fatal(err)
+	}
+	server, err := lookupRefPage(filepath.Join(dir, "server.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, err := lookupRefPage(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, mainID := server.anchors["Server.Start"].fragment, self.anchors["main"].fragment
+	want := "## Setup\nSee [A Web Server](server.html), [`Server.Start`](server.html#" + start + "), [the listing](server.html#listing-1),\n" +
+		"[Setup](#setup), and [`main`](#" + mainID + ").\n```\nm[[0]]\n```\n" +
+		"Write `[[file.go#Name]]` or ``[[#x]]`` for [Setup](#setup).\n\n    n[[1]]\n"
+	if sections[0].Doc != want {
+		t.Errorf("resolveRefs() = %q, want %q", sections[0].Doc, want)
+	}
+	if start == "" || mainID == "" {
+		t.Errorf("Server.Start and main have anchors %q and %q, want section IDs", start, mainID)
+	}
+
+	defer func(s bool) { *strict = s }(*strict)
+	for _, s := range []bool{false, true} {
+		*strict = s
+		broken := []*section{{Doc: "See [[server.go#Missing]] and [[nofile.go]].\n"}}
+		err := resolveRefs(broken, source, outputName(source))
+		if (err != nil) != s {
+			t.Errorf("resolveRefs() with -strict=%v: error = %v", s, err)
+		}
+		if broken[0].Doc != "See [[server.go#Missing]] and [[nofile.go]].\n" {
+			t.Errorf("resolveRefs() changed unresolved references to %q", broken[0].Doc)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// ## Diagrams
+//
+// A picture of the architecture says more than the prose around it, and
+// it ages better if it lives in the comments as text, next to the code it
+// shows. -diagrams turns fenced blocks of [Mermaid](https://mermaid.js.org)
+// and [Graphviz](https://graphviz.org) in the comments into diagrams:
+//
+//	// ```mermaid
+//	// graph LR
+//	//     Client --> Server --> Database
+//	// ```
+//
+// With -diagrams=client, the browser draws Mermaid diagrams, with the
+// script that -mermaid-url points to, which documents load only if they
+// have any. With -diagrams=svg, goweave draws them at weave time, with
+// `mmdc`, the command of the Mermaid CLI, so that the documents need no
+// script. Graphviz blocks, ```` ```dot ````, always become SVG at weave
+// time, with the `dot` command, as there is no lean script for them. The SVG
+// goes through the same sanitizing as that of -inline-svg, and its IDs get
+// a prefix of their own, as the tools give the elements of every diagram the
+// same IDs. A diagram that a tool fails to draw fails the document, with
+// the message of the tool.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// The values of -diagrams.
+const (
+	diagramsOff    = "off"
+	diagramsClient = "client"
+	diagramsSVG    = "svg"
+)
+
+// diagramBlock matches a fenced block of a diagram language as blackfriday
+// writes it.
+var diagramBlock = regexp.MustCompile(`(?s)<pre><code class="language-(mermaid|dot)">(.*?)</code></pre>`)
+
+// svgID matches the IDs of SVG elements and the references to them.
+var svgID = regexp.MustCompile(`(\bid="|#)([\w-]+)`)
+
+// diagramCache holds the SVG of the diagrams drawn so far, by language and
+// source, so that watch mode redraws only the diagrams that changed.
+var (
+	diagramCache   = map[string]string{}
+	diagramCacheMu sync.Mutex
+)
+
+// checkDiagrams checks the value of -diagrams.
+func checkDiagrams() error {
+	switch *diagrams {
+	case diagramsOff, diagramsClient, diagramsSVG:
+		return nil
+	}
+	return fmt.Errorf("-diagrams must be %s, %s, or %s, not %q", diagramsOff, diagramsClient, diagramsSVG, *diagrams)
+}
+
+// renderDiagrams replaces the diagram blocks of the rendered comments with
+// the diagrams, and reports whether any Mermaid diagrams are left for the
+// browser to draw.
+func renderDiagrams(sections []*section) (client bool, err error) {
+	n := 0
+	for _, s := range sections {
+		s.Doc = diagramBlock.ReplaceAllStringFunc(s.Doc, func(block string) string {
+			m := diagramBlock.FindStringSubmatch(block)
+			lang, src := m[1], html.UnescapeString(m[2])
+			if err != nil {
+				return block
+			}
+			if lang == "mermaid" && *diagrams == diagramsClient {
+				client = true
+				return `<pre class="mermaid">` + html.EscapeString(src) + `</pre>`
+			}
+			var svg string
+			svg, err = drawDiagram(lang, src)
+			if err != nil {
+				return block
+			}
+			n++
+			return `<figure class="diagram">` + prefixSVGIDs(svg, fmt.Sprintf("diagram%d-", n)) + `</figure>`
+		})
+	}
+	return client, err
+}
+
+// drawDiagram returns the sanitized SVG of a diagram.
+func drawDiagram(lang, src string) (string, error) {
+	key := lang + "\x00" + src
+	diagramCacheMu.Lock()
+	svg, ok := diagramCache[key]
+	diagramCacheMu.Unlock()
+	if ok {
+		return svg, nil
+	}
+	var data []byte
+	var err error
+	if lang == "dot" {
+		data, err = runDiagramTool(src, "dot", "-Tsvg")
+	} else {
+		data, err = mermaidSVG(src)
+	}
+	if err != nil {
+		return "", err
+	}
+	svg, err = sanitizeSVG(bytes.TrimSpace(data), lang+" diagram")
+	if err != nil {
+		return "", fmt.Errorf("%s diagram: %v", lang, err)
+	}
+	diagramCacheMu.Lock()
+	diagramCache[key] = svg
+	diagramCacheMu.Unlock()
+	return svg, nil
+}
+
+// prefixSVGIDs puts a prefix before the IDs of an SVG and the references
+// to them, in links, url() values, and style sheets.
+func prefixSVGIDs(svg, prefix string) string {
+	ids := map[string]bool{}
+	for _, m := range svgID.FindAllStringSubmatch(svg, -1) {
+		if m[1] != "#" {
+			ids[m[2]] = true
+		}
+	}
+	return svgID.ReplaceAllStringFunc(svg, func(s string) string {
+		m := svgID.FindStringSubmatch(s)
+		if !ids[m[2]] {
+			return s
+		}
+		return m[1] + prefix + m[2]
+	})
+}
+
+// mermaidSVG draws a Mermaid diagram with mmdc, which reads and writes
+// files.
+func mermaidSVG(src string) ([]byte, error) {
+	dir, err := ioutil.TempDir("", "goweave-mermaid")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "diagram.mmd"), filepath.Join(dir, "diagram.svg")
+	if err := ioutil.WriteFile(in, []byte(src), 0644); err != nil {
+		return nil, err
+	}
+	if _, err := runDiagramTool("", "mmdc", "-q", "-i", in, "-o", out); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(out)
+}
+
+// runDiagramTool runs a tool with the source of a diagram as its input,
+// and returns its output.
+func runDiagramTool(src, name string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("-diagrams needs the %s command to draw the diagrams: %v", name, err)
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(src)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
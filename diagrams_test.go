@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRenderDiagrams(t *testing.T) {
+	defer func(d string) { *diagrams = d }(*diagrams)
+	*diagrams = diagramsClient
+	sections := []*section{{Doc: "Flow:\n\n```mermaid\ngraph LR\n  A --> B\n```\n"}}
+	markdownComments(sections)
+	client, err := renderDiagrams(sections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !client || !strings.Contains(sections[0].Doc, "<pre class=\"mermaid\">graph LR\n  A --&gt; B\n</pre>") {
+		t.Errorf("renderDiagrams() = %v, %s", client, sections[0].Doc)
+	}
+
+	sections = []*section{{Doc: "```go\nx := 1\n```\n"}}
+	markdownComments(sections)
+	doc := sections[0].Doc
+	if client, err := renderDiagrams(sections); err != nil || client || sections[0].Doc != doc {
+		t.Errorf("renderDiagrams() changed a Go block: %v, %v, %s", client, err, sections[0].Doc)
+	}
+
+	if _, err := exec.LookPath("dot"); err != nil {
+		t.Skip("no dot command")
+	}
+	sections = []*section{{Doc: "```dot\ndigraph { a -> b }\n```\n"}}
+	markdownComments(sections)
+	if _, err := renderDiagrams(sections); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sections[0].Doc, `<figure class="diagram"><svg`) || strings.Contains(sections[0].Doc, "<?xml") {
+		t.Errorf("renderDiagrams() = %s", sections[0].Doc)
+	}
+}
+
+func TestCheckDiagrams(t *testing.T) {
+	defer func(d string) { *diagrams = d }(*diagrams)
+	for _, v := range []string{diagramsOff, diagramsClient, diagramsSVG} {
+		*diagrams = v
+		if err := checkDiagrams(); err != nil {
+			t.Errorf("checkDiagrams() with %q: %v", v, err)
+		}
+	}
+	*diagrams = "png"
+	if err := checkDiagrams(); err == nil {
+		t.Error("checkDiagrams() accepted png")
+	}
+}
+
+func TestPrefixSVGIDs(t *testing.T) {
+	svg := `<svg id="my-svg"><style>#my-svg .node{fill:#333}</style><g id="graph0"><use href="#graph0"/><a href="#top"/></g></svg>`
+	want := `<svg id="d1-my-svg"><style>#d1-my-svg .node{fill:#333}</style><g id="d1-graph0"><use href="#d1-graph0"/><a href="#top"/></g></svg>`
+	if got := prefixSVGIDs(svg, "d1-"); got != want {
+		t.Errorf("prefixSVGIDs() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,436 @@
+// ## EPUB books
+//
+// A book of literate Go spans many files, one per chapter. With
+// `-epub=book.epub`, goweave bundles all documents into a single EPUB
+// container, along with a table of contents and the CSS, fonts, and images
+// that the documents use. The chapters appear in the order of their source
+// paths, like on the index page, so numbered file names like
+// `01-intro.go` put them in order.
+//
+// EPUB readers expect XHTML, so goweave reads each document back from the
+// output directory, parses it, and writes it out again as XML, without any
+// scripts. The table of contents lists the chapters along with the `#` and
+// `##` headings of each. -epub-title and -epub-author set the metadata of
+// the book; the title defaults to the name of the EPUB file.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	epubMimetype = "application/epub+zip"
+	epubRoot     = "EPUB"                    // the directory of the content in the container
+	epubNavName  = "goweave-nav.xhtml"       // the table of contents
+	epubPackage  = epubRoot + "/package.opf" // the package document
+	xhtmlNS      = "http://www.w3.org/1999/xhtml"
+)
+
+// epubItem is a file of the book, by its path relative to the package
+// document.
+type epubItem struct {
+	ID, Href, MediaType string
+	data                []byte
+}
+
+// epubChapter is a document of the book.
+type epubChapter struct {
+	epubItem
+	Title    string
+	Headings []epubHeading
+}
+
+// epubHeading is a heading of a chapter, for the table of contents.
+type epubHeading struct {
+	ID, Text string
+}
+
+// epubBook is what the templates of the package document and the table of
+// contents get.
+type epubBook struct {
+	Identifier, Title, Author, Lang, Modified string
+	Nav                                       string
+	Chapters                                  []*epubChapter
+	Resources                                 []*epubItem
+}
+
+var epubTempl = template.Must(template.New("container").Funcs(template.FuncMap{"x": xmlEscape, "href": xmlHref}).Parse(
+	`<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+	<rootfiles>
+		<rootfile full-path="` + epubPackage + `" media-type="application/oebps-package+xml"/>
+	</rootfiles>
+</container>
+{{define "package"}}<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="id" xml:lang="{{x .Lang}}">
+	<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+		<dc:identifier id="id">{{x .Identifier}}</dc:identifier>
+		<dc:title>{{x .Title}}</dc:title>
+		{{- if .Author}}
+		<dc:creator>{{x .Author}}</dc:creator>
+		{{- end}}
+		<dc:language>{{x .Lang}}</dc:language>
+		<meta property="dcterms:modified">{{.Modified}}</meta>
+	</metadata>
+	<manifest>
+		<item id="nav" href="{{x .Nav}}" media-type="application/xhtml+xml" properties="nav"/>
+		{{- range .Chapters}}
+		<item id="{{.ID}}" href="{{href .Href}}" media-type="{{.MediaType}}"/>
+		{{- end}}
+		{{- range .Resources}}
+		<item id="{{.ID}}" href="{{href .Href}}" media-type="{{.MediaType}}"/>
+		{{- end}}
+	</manifest>
+	<spine>
+		{{- range .Chapters}}
+		<itemref idref="{{.ID}}"/>
+		{{- end}}
+	</spine>
+</package>
+{{end}}
+{{define "nav"}}<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="` + xhtmlNS + `" xmlns:epub="http://www.idpf.org/2007/ops" lang="{{x .Lang}}" xml:lang="{{x .Lang}}">
+<head><meta charset="utf-8"/><title>{{x .Title}}</title></head>
+<body>
+<nav epub:type="toc" id="toc">
+<h1>{{x .Title}}</h1>
+<ol>
+{{- range .Chapters}}
+{{- $href := .Href}}
+<li><a href="{{href $href}}">{{x .Title}}</a>
+{{- if .Headings}}
+<ol>
+{{- range .Headings}}
+<li><a href="{{href $href}}#{{x .ID}}">{{x .Text}}</a></li>
+{{- end}}
+</ol>
+{{- end}}</li>
+{{- end}}
+</ol>
+</nav>
+</body>
+</html>
+{{end}}`))
+
+// xmlEscape escapes a string for XML text and attribute values.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xmlHref escapes a path for a URL in XML.
+func xmlHref(name string) string {
+	return xmlEscape((&url.URL{Path: name}).String())
+}
+
+// checkEPUB returns an error if -epub cannot work with the other options.
+func checkEPUB() error {
+	if *epub == "" {
+		return nil
+	}
+	if *md {
+		return fmt.Errorf("-epub does not work with -md")
+	}
+	if isOutputArchive() {
+		return fmt.Errorf("-epub needs an output directory, not an archive")
+	}
+	return nil
+}
+
+// saveEPUB bundles all documents, read back from the output, into an EPUB
+// file.
+func saveEPUB(filename string) error {
+	pagesMu.Lock()
+	sources := make([]pageInfo, 0, len(pages))
+	for _, p := range pages {
+		sources = append(sources, p)
+	}
+	pagesMu.Unlock()
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Source < sources[j].Source })
+
+	book := &epubBook{
+		Title:    *epubTitle,
+		Author:   *epubAuthor,
+		Lang:     *lang,
+		Modified: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		Nav:      epubNavName,
+	}
+	if book.Title == "" {
+		book.Title = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	}
+	id := sha1.New()
+	fmt.Fprintf(id, "%s\x00%s", book.Title, book.Author)
+	seen := map[string]bool{epubNavName: true}
+	var refs []string
+	for i, p := range sources {
+		doc, err := fs.ReadFile(outputFS(), p.Output.String())
+		if err != nil {
+			return err
+		}
+		c := &epubChapter{
+			epubItem: epubItem{ID: fmt.Sprintf("chapter%d", i+1), Href: p.Output.String(), MediaType: "application/xhtml+xml"},
+			Title:    p.Title,
+		}
+		var docRefs []string
+		c.data, c.Headings, docRefs, err = xhtmlDocument(doc, *lang)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p.Output, err)
+		}
+		for _, ref := range docRefs {
+			if name, ok := epubRef(c.Href, ref); ok {
+				refs = append(refs, name)
+			}
+		}
+		seen[c.Href] = true
+		book.Chapters = append(book.Chapters, c)
+		fmt.Fprintf(id, "\x00%s", c.Href)
+	}
+	if len(book.Chapters) == 0 {
+		return fmt.Errorf("there are no documents to put into %s", filename)
+	}
+	sum := id.Sum(nil)
+	book.Identifier = fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+
+	// Collect the files that the chapters refer to, and the files that
+	// these refer to in turn, like the fonts of a CSS file.
+	for len(refs) > 0 {
+		name := refs[0]
+		refs = refs[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		mediaType := epubMediaType(name)
+		if mediaType == "" {
+			continue
+		}
+		data, err := fs.ReadFile(outputFS(), name)
+		if err != nil {
+			log.Printf("Leaving %s out of %s: %v", name, filename, err)
+			continue
+		}
+		if mediaType == "text/css" {
+			for _, ref := range cssRefs(string(data)) {
+				if ref, ok := epubRef(name, ref); ok {
+					refs = append(refs, ref)
+				}
+			}
+		}
+		book.Resources = append(book.Resources, &epubItem{
+			ID:        fmt.Sprintf("res%d", len(book.Resources)+1),
+			Href:      name,
+			MediaType: mediaType,
+			data:      data,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := writeEPUB(&buf, book); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0666)
+}
+
+// writeEPUB writes the container of a book. The mimetype file must come
+// first, and uncompressed, so that tools can identify the file by its
+// first bytes.
+func writeEPUB(buf *bytes.Buffer, book *epubBook) error {
+	zw := zip.NewWriter(buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(epubMimetype)); err != nil {
+		return err
+	}
+	add := func(name string, data []byte) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	for _, t := range []struct{ name, templ string }{
+		{"META-INF/container.xml", "container"},
+		{epubPackage, "package"},
+		{epubRoot + "/" + epubNavName, "nav"},
+	} {
+		var b bytes.Buffer
+		if err := epubTempl.ExecuteTemplate(&b, t.templ, book); err != nil {
+			return err
+		}
+		if err := add(t.name, b.Bytes()); err != nil {
+			return err
+		}
+	}
+	for _, c := range book.Chapters {
+		if err := add(epubRoot+"/"+c.Href, c.data); err != nil {
+			return err
+		}
+	}
+	for _, r := range book.Resources {
+		if err := add(epubRoot+"/"+r.Href, r.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// xhtmlDocument turns an HTML document into XHTML without scripts. It also
+// returns the headings for the table of contents, and the URLs of the
+// stylesheets, images, and other files that the document refers to.
+func xhtmlDocument(doc []byte, lang string) (xhtml []byte, headings []epubHeading, refs []string, err error) {
+	root, err := html.Parse(bytes.NewReader(doc))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			if c.Type == html.ElementNode && c.Data == "script" {
+				n.RemoveChild(c)
+			} else {
+				walk(c)
+			}
+			c = next
+		}
+		if n.Type != html.ElementNode {
+			return
+		}
+		switch n.Data {
+		case "html":
+			n.Attr = setAttr(n.Attr, "xmlns", xhtmlNS)
+			if attr(n, "lang") == "" {
+				n.Attr = setAttr(n.Attr, "lang", lang)
+			}
+			n.Attr = setAttr(n.Attr, "xml:lang", attr(n, "lang"))
+		case "link":
+			if strings.EqualFold(attr(n, "rel"), "stylesheet") {
+				refs = append(refs, attr(n, "href"))
+			}
+		case "img", "source":
+			refs = append(refs, attr(n, "src"))
+		case "style":
+			if n.FirstChild != nil {
+				refs = append(refs, cssRefs(n.FirstChild.Data)...)
+			}
+		case "h1", "h2":
+			if id := attr(n, "id"); id != "" {
+				headings = append(headings, epubHeading{ID: id, Text: strings.Join(strings.Fields(textContent(n)), " ")})
+			}
+		}
+	}
+	walk(root)
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	if err := html.Render(&b, root); err != nil {
+		return nil, nil, nil, err
+	}
+	return b.Bytes(), headings, refs, nil
+}
+
+// attr returns the value of an attribute of a node.
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// setAttr sets the value of an attribute in a list.
+func setAttr(attrs []html.Attribute, key, val string) []html.Attribute {
+	for i, a := range attrs {
+		if a.Namespace == "" && a.Key == key {
+			attrs[i].Val = val
+			return attrs
+		}
+	}
+	return append(attrs, html.Attribute{Key: key, Val: val})
+}
+
+// textContent returns the text of a node and its descendants.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+var cssURLRe = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+// cssRefs returns the URLs in a style sheet.
+func cssRefs(css string) []string {
+	var refs []string
+	for _, m := range cssURLRe.FindAllStringSubmatch(css, -1) {
+		refs = append(refs, m[1])
+	}
+	return refs
+}
+
+// epubRef returns the output name of the file that a URL in the file
+// named base refers to. It returns false for URLs that point elsewhere,
+// like to other sites or out of the output directory.
+func epubRef(base, ref string) (string, bool) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" || path.IsAbs(u.Path) {
+		return "", false
+	}
+	name := path.Join(path.Dir(base), u.Path)
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+	return name, true
+}
+
+// epubMediaType returns the media type of a file of the book, or "" if the
+// type is unknown.
+func epubMediaType(name string) string {
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".css":
+		return "text/css"
+	case ".woff2":
+		return "font/woff2"
+	case ".woff":
+		return "font/woff"
+	case ".ttf":
+		return "font/ttf"
+	case ".otf":
+		return "font/otf"
+	case ".svg":
+		return "image/svg+xml"
+	default:
+		t := mime.TypeByExtension(ext)
+		if i := strings.Index(t, ";"); i >= 0 {
+			t = t[:i]
+		}
+		return t
+	}
+}
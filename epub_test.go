@@ -0,0 +1,129 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestSaveEPUB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(p map[string]pageInfo) { pages = p }(pages)
+	defer func(o weave.OutputFS, a string) { output, *epubAuthor = o, a }(output, *epubAuthor)
+	mem := &weave.MemFS{}
+	output, pages, *epubAuthor = mem, map[string]pageInfo{}, "Jo & Co"
+	files := map[string]string{
+		"b.html": `<!DOCTYPE html><html><head><link rel="stylesheet" href="css/goweave.css"><script>x()</script></head>` +
+			`<body><h1>B</h1><h2 id="usage">Usage &amp; more</h2><p>a<br>b</p><img src="img/x.png" alt=""></body></html>`,
+		"a/a.html":        `<html><head><link rel="stylesheet" href="../css/goweave.css"></head><body><h1 id="intro">Intro</h1><a href="../b.html#usage">B</a></body></html>`,
+		"css/goweave.css": `@font-face { src: url("../fonts/f.woff2") } body { background: url(http://example.com/x.png) }`,
+		"fonts/f.woff2":   "font",
+		"img/x.png":       "png",
+	}
+	for name, data := range files {
+		if err := mem.WriteFile(name, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addPage(pageInfo{Source: "b.go", Output: "b.html", Title: "Chapter B"})
+	addPage(pageInfo{Source: "a/a.go", Output: "a/a.html", Title: "Chapter A"})
+
+	filename := filepath.Join(dir, "My Book.epub")
+	if err := saveEPUB(filename); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if f := zr.File[0]; f.Name != "mimetype" || f.Method != zip.Store {
+		t.Errorf("the first file is %s with method %d, want an uncompressed mimetype file", f.Name, f.Method)
+	}
+	for _, name := range []string{
+		"mimetype", "META-INF/container.xml", "EPUB/package.opf", "EPUB/goweave-nav.xhtml",
+		"EPUB/a/a.html", "EPUB/b.html", "EPUB/css/goweave.css", "EPUB/fonts/f.woff2", "EPUB/img/x.png",
+	} {
+		data, err := fs.ReadFile(zr, name)
+		if err != nil {
+			t.Errorf("the book lacks %s: %v", name, err)
+			continue
+		}
+		if strings.HasSuffix(name, "l") || strings.HasSuffix(name, ".opf") {
+			if err := wellFormed(data); err != nil {
+				t.Errorf("%s is not well-formed XML: %v\n%s", name, err, data)
+			}
+		}
+	}
+	read := func(name string) string {
+		data, _ := fs.ReadFile(zr, name)
+		return string(data)
+	}
+	opf := read("EPUB/package.opf")
+	for _, want := range []string{"<dc:title>My Book</dc:title>", "<dc:creator>Jo &amp; Co</dc:creator>", `href="img/x.png" media-type="image/png"/>`} {
+		if !strings.Contains(opf, want) {
+			t.Errorf("package.opf does not contain %s:\n%s", want, opf)
+		}
+	}
+	if strings.Index(opf, `<itemref idref="chapter1"/>`) > strings.Index(opf, `<itemref idref="chapter2"/>`) {
+		t.Errorf("the chapters are out of order:\n%s", opf)
+	}
+	nav := read("EPUB/goweave-nav.xhtml")
+	for _, want := range []string{`<a href="a/a.html">Chapter A</a>`, `<a href="a/a.html#intro">Intro</a>`, `<a href="b.html#usage">Usage &amp; more</a>`} {
+		if !strings.Contains(nav, want) {
+			t.Errorf("the table of contents does not contain %s:\n%s", want, nav)
+		}
+	}
+	if strings.Contains(read("EPUB/b.html"), "<script") {
+		t.Errorf("the chapter still contains the script")
+	}
+}
+
+// wellFormed returns an error if data is not well-formed XML.
+func wellFormed(data []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func TestEPUBRef(t *testing.T) {
+	tests := []struct {
+		base, ref string
+		want      string
+		ok        bool
+	}{
+		{"a/b.html", "../css/goweave.css", "css/goweave.css", true},
+		{"a/b.html", "img/x%20y.png?v=1", "a/img/x y.png", true},
+		{"css/goweave.css", "../fonts/f.woff2", "fonts/f.woff2", true},
+		{"b.html", "../outside.css", "", false},
+		{"b.html", "https://example.com/x.css", "", false},
+		{"b.html", "/x.css", "", false},
+		{"b.html", "data:image/png;base64,xyz", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := epubRef(tt.base, tt.ref)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("epubRef(%q, %q) = %q, %v; want %q, %v", tt.base, tt.ref, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// ## Error pages
+//
+// In watch mode, goweave must not stop at the first error, nor leave a stale
+// document behind that makes the author wonder why their changes don't show
+// up. Instead, it replaces the document with an error page that tells which
+// file and line caused the error, including an excerpt from around that line.
+package main
+
+import (
+	"bufio"
+	"html/template"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// renderError describes why a document could not be generated.
+type renderError struct {
+	Source string // the source file of the document
+	File   string // the file that caused the error, if known
+	Line   int    // the line within File, or 0 if unknown
+	Err    error
+}
+
+func (e *renderError) Error() string {
+	return e.Err.Error()
+}
+
+// templateErrPos matches the position at the start of template errors, like
+// in `template: goweave.templ:25:12: executing "sections" at <.Foo>: ...`.
+var templateErrPos = regexp.MustCompile(`^template: ([^:]+):(\d+):`)
+
+// locateError finds out which file and line caused err, as far as possible.
+func locateError(source string, err error) *renderError {
+	e := &renderError{Source: source, File: source, Err: err}
+	switch err := err.(type) {
+	case *os.PathError:
+		e.File = err.Path
+	default:
+		if m := templateErrPos.FindStringSubmatch(err.Error()); m != nil {
+			e.File = resourcePath(resourcedir, m[1])
+			e.Line, _ = strconv.Atoi(m[2])
+		}
+	}
+	return e
+}
+
+// excerptLine is a line of the file excerpt on the error page.
+type excerptLine struct {
+	Num    int
+	Text   string
+	Failed bool
+}
+
+// excerptContext is the number of lines shown before and after the
+// failing line.
+const excerptContext = 3
+
+// excerpt returns the lines around the failing line.
+func (e *renderError) excerpt() []excerptLine {
+	if e.Line <= 0 {
+		return nil
+	}
+	f, err := os.Open(e.File)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var lines []excerptLine
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan() && n <= e.Line+excerptContext; n++ {
+		if n >= e.Line-excerptContext {
+			lines = append(lines, excerptLine{n, sc.Text(), n == e.Line})
+		}
+	}
+	return lines
+}
+
+// The error page does not depend on the resource files, as these may be
+// what is broken.
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Error: {{.Source}}</title>
+<meta charset="utf-8"/>
+<style type="text/css">
+body { margin: 2em; font-family: 'Lato', 'Helvetica', sans-serif; color: #404040; background-color: #f8f8f8; }
+h1 { color: #c00; }
+pre { padding: 1em; white-space: pre-wrap; background-color: #fdd; border-left: 4px solid #c00; }
+pre.excerpt { background-color: #ececec; border-left-color: #404040; }
+pre.excerpt .failed { display: inline-block; width: 100%; background-color: #fdd; }
+</style>
+</head>
+<body>
+<h1>goweave could not generate this document</h1>
+<p>Source file: <code>{{.Source}}</code></p>
+{{if .Line}}<p>Error in <code>{{.File}}</code>, line {{.Line}}:</p>{{else if ne .File .Source}}<p>Error in <code>{{.File}}</code>:</p>{{end}}
+<pre>{{.Err}}</pre>
+{{with .Excerpt}}<pre class="excerpt">{{range .}}<span{{if .Failed}} class="failed"{{end}}>{{printf "%4d" .Num}}  {{.Text}}</span>
+{{end}}</pre>{{end}}
+<p>This page gets replaced as soon as the problem is fixed.</p>
+</body>
+</html>
+`))
+
+// showError replaces the documents of the given sources with an error page.
+func showError(sources []string, err error) {
+	if *md {
+		return
+	}
+	for _, source := range sources {
+		e := locateError(source, err)
+		b := getBuffer()
+		werr := errorPage.Execute(b, struct {
+			*renderError
+			Excerpt []excerptLine
+		}{e, e.excerpt()})
+		if werr == nil {
+			werr = outputFS().WriteFile(outputName(source), b.Bytes())
+		}
+		bufPool.Put(b)
+		if werr != nil {
+			log.Print(werr)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocateError(t *testing.T) {
+	defer func(dir string) { resourcedir = dir }(resourcedir)
+	resourcedir = "res"
+	tests := []struct {
+		err  error
+		file string
+		line int
+	}{
+		{errors.New(`template: goweave.templ:25:12: executing "sections" at <.Foo>: can't evaluate field Foo`), filepath.Join("res", "goweave.templ"), 25},
+		{errors.New("template: goweave.templ:43: unexpected EOF"), filepath.Join("res", "goweave.templ"), 43},
+		{&os.PathError{Op: "open", Path: "missing.go", Err: os.ErrNotExist}, "missing.go", 0},
+		{errors.New("something else"), "a.go", 0},
+	}
+	for _, tt := range tests {
+		e := locateError("a.go", tt.err)
+		if e.File != tt.file || e.Line != tt.line {
+			t.Errorf("locateError(%v) = %s:%d, want %s:%d", tt.err, e.File, e.Line, tt.file, tt.line)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// ## Fonts
+//
+// The theme's font stacks can be replaced without editing the CSS file:
+// -prose-font sets the font of the comments, and -code-font the font of the
+// code. Both take a CSS font stack, and both can go into the configuration
+// file, like all options.
+//
+// Readers may not have these fonts installed, and fetching them from a font
+// CDN is not always an option. -font-files lists WOFF2 files to ship along
+// with the documents, as in `Lato=fonts/lato.woff2, Fira Code=fira.woff2`.
+// goweave copies them into the `fonts` directory below the output
+// directory and declares them through @font-face rules; files of the same
+// name from different directories get a number, like `lato-2.woff2`. With
+// -inline, the font files become part of each document instead, as data
+// URLs, and as every document carries its own copy, goweave reduces the
+// copy to the glyphs of the characters of the document and of printable
+// ASCII, which covers the text of the template. It does so with
+// `pyftsubset` of [fonttools](https://github.com/fonttools/fonttools),
+// which takes WOFF2 files apart and puts them together again; without it,
+// goweave warns once and embeds the whole files. The copied files stay
+// whole, as all documents share them.
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fontsDir is the directory below the output directory that font files
+// get copied into.
+const fontsDir = "fonts"
+
+// fontFile is a font family along with its WOFF2 file.
+type fontFile struct {
+	family string
+	path   string
+	name   string // the name of the copy in fontsDir
+	data   []byte // the contents of the file, with -inline
+}
+
+var fontFiles []fontFile
+
+// fontSubsetter is the command that subsets the font files of -inline.
+var fontSubsetter = "pyftsubset"
+
+var (
+	subsetMu      sync.Mutex
+	subsets       = map[string][]byte{} // by font name and characters
+	subsetMissing sync.Once
+)
+
+// checkFontStack rejects font stacks that could break out of the CSS rule
+// they go into.
+func checkFontStack(stack string) error {
+	if strings.ContainsAny(stack, "<>{};\\") {
+		return fmt.Errorf("invalid font stack %q", stack)
+	}
+	return nil
+}
+
+// parseFontFiles reads the value of -font-files.
+func parseFontFiles(s string) ([]fontFile, error) {
+	var files []fontFile
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+			return nil, fmt.Errorf("-font-files: %q is not of the form family=file.woff2", item)
+		}
+		family := strings.TrimSpace(kv[0])
+		if strings.ContainsAny(family, `"'`) || checkFontStack(family) != nil {
+			return nil, fmt.Errorf("-font-files: invalid font family %q", family)
+		}
+		files = append(files, fontFile{family: family, path: strings.TrimSpace(kv[1])})
+	}
+	return files, nil
+}
+
+// loadFonts checks the font options, and with -inline, reads the font
+// files.
+func loadFonts() error {
+	for _, stack := range []string{*proseFont, *codeFont} {
+		if err := checkFontStack(stack); err != nil {
+			return err
+		}
+	}
+	files, err := parseFontFiles(*fontFileList)
+	if err != nil {
+		return err
+	}
+	names := map[string]string{} // by path
+	taken := map[string]bool{}
+	for i, f := range files {
+		if _, err := os.Stat(f.path); err != nil {
+			return err
+		}
+		files[i].name = names[filepath.Clean(f.path)]
+		if files[i].name == "" {
+			files[i].name = uniqueFontName(filepath.Base(f.path), taken)
+			names[filepath.Clean(f.path)] = files[i].name
+		}
+		if *inline {
+			files[i].data, err = ioutil.ReadFile(f.path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	fontFiles = files
+	return nil
+}
+
+// uniqueFontName returns the name of a font file, or if it is taken, the
+// name with the first number from 2 on that makes it unique, and takes it.
+func uniqueFontName(name string, taken map[string]bool) string {
+	ext := path.Ext(name)
+	unique := name
+	for i := 2; taken[strings.ToLower(unique)]; i++ {
+		unique = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+	taken[strings.ToLower(unique)] = true
+	return unique
+}
+
+// copyFonts copies the font files into the output directory.
+func copyFonts() error {
+	if len(fontFiles) == 0 {
+		return nil
+	}
+	copied := map[string]bool{}
+	for _, f := range fontFiles {
+		if copied[f.name] {
+			continue // another family of the same file
+		}
+		copied[f.name] = true
+		if err := copyToOutput(path.Join(fontsDir, f.name), f.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fontCSS returns the CSS rules for the font options, for a document whose
+// output directory is at root and whose text is text.
+func fontCSS(root urlPath, text string) (template.CSS, error) {
+	var b strings.Builder
+	for _, f := range fontFiles {
+		src := root + fsPath(fontsDir).toURL().join(f.name)
+		if f.data != nil {
+			data, err := subsetFont(f, text)
+			if err != nil {
+				return "", err
+			}
+			src = urlPath("data:font/woff2;base64," + base64.StdEncoding.EncodeToString(data))
+		}
+		fmt.Fprintf(&b, "@font-face { font-family: %q; src: url(%q) format(\"woff2\"); }\n", f.family, src)
+	}
+	if *proseFont != "" {
+		fmt.Fprintf(&b, "body, #goweave .doc { font-family: %s; }\n", *proseFont)
+	}
+	if *codeFont != "" {
+		fmt.Fprintf(&b, "tt, code, kbd, samp { font-family: %s; }\n", *codeFont)
+	}
+	return template.CSS(b.String()), nil
+}
+
+// documentText returns the text of a document that its fonts need glyphs
+// for: the title, the sections, and the titles of the pages it links to.
+func documentText(d docs, sections []*section) string {
+	var b strings.Builder
+	b.WriteString(d.Title)
+	for _, s := range sections {
+		b.WriteString(s.Doc)
+		b.WriteString(s.Code)
+	}
+	for _, l := range []*splitLink{d.Prev, d.Next} {
+		if l != nil {
+			b.WriteString(l.Title)
+		}
+	}
+	for _, r := range d.Related {
+		b.WriteString(r.Title)
+	}
+	return b.String()
+}
+
+// fontChars returns the characters of text and of printable ASCII, each
+// once, in order.
+func fontChars(text string) string {
+	seen := map[rune]bool{}
+	for r := rune(0x20); r < 0x7f; r++ {
+		seen[r] = true
+	}
+	for _, r := range text {
+		if r >= 0x20 {
+			seen[r] = true
+		}
+	}
+	chars := make([]rune, 0, len(seen))
+	for r := range seen {
+		chars = append(chars, r)
+	}
+	sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+	return string(chars)
+}
+
+// subsetFont returns the data of a font file of -inline, reduced to the
+// glyphs of the characters of text and of printable ASCII, or the whole
+// file if there is no fontSubsetter.
+func subsetFont(f fontFile, text string) ([]byte, error) {
+	chars := fontChars(text)
+	key := f.name + "\x00" + chars
+	subsetMu.Lock()
+	data, ok := subsets[key]
+	subsetMu.Unlock()
+	if ok {
+		return data, nil
+	}
+	if _, err := exec.LookPath(fontSubsetter); err != nil {
+		subsetMissing.Do(func() {
+			log.Printf("-inline embeds whole font files; install fonttools for %s to embed only the glyphs that the documents use", fontSubsetter)
+		})
+		return f.data, nil
+	}
+	dir, err := ioutil.TempDir("", "goweave-font")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	in, textFile, out := filepath.Join(dir, "font.woff2"), filepath.Join(dir, "text.txt"), filepath.Join(dir, "subset.woff2")
+	if err := ioutil.WriteFile(in, f.data, 0644); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(textFile, []byte(chars), 0644); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(fontSubsetter, in, "--text-file="+textFile, "--flavor=woff2", "--layout-features=*", "--output-file="+out)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %s: %v: %s", f.path, fontSubsetter, err, strings.TrimSpace(stderr.String()))
+	}
+	data, err = ioutil.ReadFile(out)
+	if err != nil {
+		return nil, err
+	}
+	subsetMu.Lock()
+	subsets[key] = data
+	subsetMu.Unlock()
+	return data, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseFontFiles(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []fontFile
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"Lato=fonts/lato.woff2, Fira Code = fira.woff2,", []fontFile{{family: "Lato", path: "fonts/lato.woff2"}, {family: "Fira Code", path: "fira.woff2"}}, false},
+		{"lato.woff2", nil, true},
+		{`"Lato"=lato.woff2`, nil, true},
+		{"Lato}=lato.woff2", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseFontFiles(tt.value)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFontFiles(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFontCSS(t *testing.T) {
+	defer func(files []fontFile, p, c, s string) {
+		fontFiles, *proseFont, *codeFont, fontSubsetter = files, p, c, s
+	}(fontFiles, *proseFont, *codeFont, fontSubsetter)
+	fontFiles = []fontFile{{family: "Lato", path: "x/lato.woff2", name: "lato.woff2"}, {family: "Fira", path: "fira.woff2", name: "fira.woff2", data: []byte("abc")}}
+	*proseFont, *codeFont, fontSubsetter = "Lato, sans-serif", "", "goweave-no-such-command"
+	css, err := fontCSS("../", "Text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(css)
+	for _, want := range []string{
+		`@font-face { font-family: "Lato"; src: url("../fonts/lato.woff2") format("woff2"); }`,
+		`src: url("data:font/woff2;base64,YWJj")`,
+		`body, #goweave .doc { font-family: Lato, sans-serif; }`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("fontCSS() = %s, want it to contain %s", got, want)
+		}
+	}
+	if strings.Contains(got, "tt, code") {
+		t.Errorf("fontCSS() = %s, want no code font rule", got)
+	}
+	if err := checkFontStack("x; } </style><script>"); err == nil {
+		t.Errorf("checkFontStack() accepted a font stack that breaks out of the style element")
+	}
+}
+
+func TestLoadFonts(t *testing.T) {
+	defer func(files []fontFile, list string, in bool) { fontFiles, *fontFileList, *inline = files, list, in }(fontFiles, *fontFileList, *inline)
+	dir, err := ioutil.TempDir("", "fonts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var paths []string
+	for _, name := range []string{"a/lato.woff2", "b/Lato.woff2"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+	*inline = false
+	*fontFileList = "Lato=" + paths[0] + ", Lato Bold=" + paths[1] + ", Lato Text=" + paths[0]
+	if err := loadFonts(); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range fontFiles {
+		names = append(names, f.name)
+	}
+	if want := []string{"lato.woff2", "Lato-2.woff2", "lato.woff2"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("loadFonts() named the copies %q, want %q", names, want)
+	}
+}
+
+// fakeSubsetter stands in for pyftsubset: it writes the characters of
+// --text-file into the file of --output-file.
+const fakeSubsetter = `#!/bin/sh
+for arg; do
+	case "$arg" in
+	--text-file=*) text="${arg#--text-file=}" ;;
+	--output-file=*) out="${arg#--output-file=}" ;;
+	esac
+done
+cat "$text" > "$out"
+`
+
+func TestSubsetFont(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake pyftsubset is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "subset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(files []fontFile, s string) { fontFiles, fontSubsetter = files, s }(fontFiles, fontSubsetter)
+	fontSubsetter = filepath.Join(dir, "pyftsubset")
+	if err := ioutil.WriteFile(fontSubsetter, []byte(fakeSubsetter), 0755); err != nil {
+		t.Fatal(err)
+	}
+	fontFiles = []fontFile{{family: "Lato", path: "lato.woff2", name: "lato.woff2", data: []byte("whole font")}}
+	css, err := fontCSS("", "Grüße\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.Split(strings.SplitAfter(string(css), "base64,")[1], `"`)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	chars := string(data)
+	for _, want := range []string{"G", "ü", "ß", "~", " "} {
+		if !strings.Contains(chars, want) {
+			t.Errorf("the subset has the characters %q, without %q", chars, want)
+		}
+	}
+	if strings.Contains(chars, "\n") || strings.Count(chars, "e") != 1 {
+		t.Errorf("the subset has the characters %q, want each printable one once", chars)
+	}
+}
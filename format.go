@@ -0,0 +1,57 @@
+// ## Output formats
+//
+// goweave writes HTML, unless -format asks for Markdown (md, the same as
+// -md, which stays), reStructuredText for Sphinx (rst, see rst.go), LaTeX
+// (tex, see latex.go), or the sections as JSON for other tools (json, see
+// json.go). The text formats have no index page, no CSS, and no PDF or EPUB
+// version, and front matter, Hugo page bundles, -md-layout, and
+// -section-ids are for Markdown only.
+package main
+
+import "fmt"
+
+// The values of -format.
+const (
+	formatHTML = "html"
+	formatMD   = "md"
+	formatRST  = "rst"
+	formatTeX  = "tex"
+	formatJSON = "json"
+)
+
+// checkFormat validates the -format option. All formats but HTML are
+// plain text, so they set -md, which most of goweave checks for "not
+// HTML".
+func checkFormat() error {
+	switch *format {
+	case formatHTML:
+		if *md {
+			*format = formatMD
+		}
+		return nil
+	case formatMD:
+		*md = true
+		return nil
+	case formatRST, formatTeX, formatJSON:
+		switch {
+		case *hugo:
+			return fmt.Errorf("-format=%s does not work with -hugo", *format)
+		case *frontMatterFmt != "":
+			return fmt.Errorf("-format=%s does not work with -frontmatter", *format)
+		case *mdLayout != mdLayoutPlain:
+			return fmt.Errorf("-format=%s does not work with -md-layout", *format)
+		case *sectionIDs:
+			return fmt.Errorf("-section-ids works with Markdown output only")
+		}
+		*md = true
+		return nil
+	}
+	return fmt.Errorf("-format must be one of %s, %s, %s, %s, or %s, not %q", formatHTML, formatMD, formatRST, formatTeX, formatJSON, *format)
+}
+
+// sectionAnchors reports whether the documents of -format have anchors for
+// the IDs of the sections, for links to them: HTML, and the labels of
+// LaTeX and reStructuredText.
+func sectionAnchors() bool {
+	return !*md || *format == formatTeX || *format == formatRST
+}
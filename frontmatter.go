@@ -0,0 +1,218 @@
+// ## Front matter
+//
+// A source file can set properties of its document through pragma comments
+// of the form `//goweave:<key> <value>`, like this:
+//
+//	//goweave:title Building a Web Server in 50 Lines
+//
+// Together, these pragmas form the front matter of the document. Like Go
+// directives, they do not show up in the output. In other languages, the
+// pragmas start with the language's line comment marker instead, as in
+// `#goweave:title` in Python. Meta lines are another way to write them,
+// familiar from other tools:
+//
+//	//meta: title=Building a Web Server in 50 Lines
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/russross/blackfriday"
+)
+
+// frontMatter maps pragma keys to their values.
+type frontMatter map[string]string
+
+// parseFrontMatter collects all pragmas of a source file. If a key occurs
+// more than once, the last value wins.
+func parseFrontMatter(filename, src string) frontMatter {
+	fm := frontMatter{}
+	lang := sourceLanguage(filename)
+	for _, line := range strings.Split(src, "\n") {
+		if key, value, ok := lang.ParsePragma(line); ok {
+			fm[key] = value
+		}
+	}
+	return fm
+}
+
+// isSet returns true if a boolean pragma is set, either without a value, as
+// in `//goweave:draft`, or with a true value, as in `//goweave:draft true`.
+func (fm frontMatter) isSet(key string) bool {
+	v, ok := fm[key]
+	if !ok {
+		return false
+	}
+	if v == "" {
+		return true
+	}
+	b, err := strconv.ParseBool(v)
+	return err == nil && b
+}
+
+// isDraft returns true if the front matter marks the document as a draft.
+// Drafts are skipped unless -drafts is set, and never show up in the
+// manifest.
+func (fm frontMatter) isDraft() bool {
+	return fm.isSet("draft")
+}
+
+// ### Front matter for static site generators
+//
+// With -frontmatter=yaml, -frontmatter=toml, or -frontmatter=json, the
+// Markdown documents start with front matter in the format that Hugo and
+// Jekyll read, so that goweave can write straight into the content
+// directory of a site. It holds the title, the date, the slug, and the
+// tags, from the pragmas of the same names, plus the description, the
+// aliases, and the draft flag if the pragmas set them. The date defaults to
+// the modification time of the source file, and the slug to its name. The
+// document then leaves the title to the site's template, rather than
+// starting with a heading of its own.
+
+// The values of the -frontmatter option.
+const (
+	frontMatterYAML = "yaml"
+	frontMatterTOML = "toml"
+	frontMatterJSON = "json"
+)
+
+// checkFrontMatter validates the -frontmatter option.
+func checkFrontMatter() error {
+	switch *frontMatterFmt {
+	case "":
+		return nil
+	case frontMatterYAML, frontMatterTOML, frontMatterJSON:
+		if !*md {
+			return fmt.Errorf("-frontmatter needs -md")
+		}
+		return nil
+	}
+	return fmt.Errorf("-frontmatter must be one of %s, %s, or %s, not %q",
+		frontMatterYAML, frontMatterTOML, frontMatterJSON, *frontMatterFmt)
+}
+
+// withDate sets the date of the front matter to the modification time of
+// the source file, unless a pragma sets it.
+func (fm frontMatter) withDate(filename string) frontMatter {
+	if fm["date"] != "" || *frontMatterFmt == "" {
+		return fm
+	}
+	if fi, err := os.Stat(filename); err == nil && revTree == nil {
+		fm["date"] = fi.ModTime().Format("2006-01-02")
+	}
+	return fm
+}
+
+// siteFields holds the front matter of a document, in the order of the
+// output.
+type siteFields struct {
+	Title       string   `json:"title"`
+	Date        string   `json:"date,omitempty"`
+	Slug        string   `json:"slug,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Draft       bool     `json:"draft,omitempty"`
+}
+
+// siteFrontMatter returns the front matter of a document in the format of
+// -frontmatter, or "" if the option is not set.
+func siteFrontMatter(d docs) string {
+	if *frontMatterFmt == "" {
+		return ""
+	}
+	fm := d.Pragmas
+	f := siteFields{
+		Title:       d.Title,
+		Date:        fm["date"],
+		Slug:        fm["slug"],
+		Description: fm["description"],
+		Aliases:     fm.aliases(),
+		Draft:       fm.isDraft(),
+	}
+	if f.Slug == "" {
+		f.Slug = defaultSlug(d.Filename)
+	}
+	for _, t := range strings.Split(fm["tags"], ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			f.Tags = append(f.Tags, t)
+		}
+	}
+	if *frontMatterFmt == frontMatterJSON {
+		data, _ := json.MarshalIndent(f, "", "  ")
+		return string(data) + "\n\n"
+	}
+	var b strings.Builder
+	delim, sep := "---", ": "
+	if *frontMatterFmt == frontMatterTOML {
+		delim, sep = "+++", " = "
+	}
+	b.WriteString(delim + "\n")
+	field := func(key, value string) {
+		b.WriteString(key + sep + value + "\n")
+	}
+	field("title", quoteValue(f.Title))
+	if f.Date != "" {
+		field("date", dateValue(f.Date))
+	}
+	field("slug", quoteValue(f.Slug))
+	if len(f.Tags) > 0 {
+		field("tags", listValue(f.Tags))
+	}
+	if f.Description != "" {
+		field("description", quoteValue(f.Description))
+	}
+	if len(f.Aliases) > 0 {
+		field("aliases", listValue(f.Aliases))
+	}
+	if f.Draft {
+		field("draft", "true")
+	}
+	b.WriteString(delim + "\n\n")
+	return b.String()
+}
+
+// defaultSlug returns the slug of a document whose pragmas set none: the
+// name of the source file without its extension, as in a heading ID.
+func defaultSlug(filename string) string {
+	name := filepath.Base(filename)
+	return blackfriday.SanitizedAnchorName(strings.TrimSuffix(name, filepath.Ext(name)))
+}
+
+// quoteValue quotes a string for YAML and TOML alike: both read JSON
+// strings as double-quoted strings.
+func quoteValue(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// listValue writes a list of strings in the flow style of YAML, which is
+// the array syntax of TOML, too.
+func listValue(list []string) string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = quoteValue(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// dateValue leaves dates and times unquoted, so that YAML and TOML read
+// them as such, and quotes anything else.
+func dateValue(s string) string {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return s
+		}
+	}
+	return quoteValue(s)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		src  string
+		want frontMatter
+	}{
+		{"//goweave:title A <Title>  \npackage main\n", frontMatter{"title": "A <Title>"}},
+		{"//goweave:draft\n// goweave:title no pragma\n//goweave:title=x\n", frontMatter{"draft": ""}},
+		{"package main\n", frontMatter{}},
+		{"//meta: title=Parsing\n//meta: tags = go, parsers\n", frontMatter{"title": "Parsing", "tags": "go, parsers"}},
+	}
+	for _, tt := range tests {
+		if got := parseFrontMatter("test.go", tt.src); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFrontMatter(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestPragmasAreRemoved(t *testing.T) {
+	got := extractSections("test.go", "//goweave:title Title\n// Doc\ncode\n")
+	want := []*section{{Doc: "Doc\n", Code: "code\n\n", DocStart: 2, DocEnd: 2, CodeStart: 3, CodeEnd: 3, DocOffset: 22, DocEndOffset: 29, CodeOffset: 29, CodeEndOffset: 34}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extractSections() = %v, want %v", got, want)
+	}
+}
+
+func TestIsDraft(t *testing.T) {
+	tests := []struct {
+		fm   frontMatter
+		want bool
+	}{
+		{frontMatter{}, false},
+		{frontMatter{"draft": ""}, true},
+		{frontMatter{"draft": "true"}, true},
+		{frontMatter{"draft": "false"}, false},
+		{frontMatter{"draft": "maybe"}, false},
+		{frontMatter{"noindex": ""}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.fm.isDraft(); got != tt.want {
+			t.Errorf("%v.isDraft() = %v, want %v", tt.fm, got, tt.want)
+		}
+	}
+}
+
+func TestSiteFrontMatter(t *testing.T) {
+	defer func(f string) { *frontMatterFmt = f }(*frontMatterFmt)
+	d := docs{Filename: "web server.go", Title: `A "Web" Server`, Pragmas: frontMatter{"date": "2024-05-01", "tags": "go, http", "draft": ""}}
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"", ""},
+		{frontMatterYAML, "---\ntitle: \"A \\\"Web\\\" Server\"\ndate: 2024-05-01\nslug: \"web-server\"\ntags: [\"go\", \"http\"]\ndraft: true\n---\n\n"},
+		{frontMatterTOML, "+++\ntitle = \"A \\\"Web\\\" Server\"\ndate = 2024-05-01\nslug = \"web-server\"\ntags = [\"go\", \"http\"]\ndraft = true\n+++\n\n"},
+		{frontMatterJSON, "{\n  \"title\": \"A \\\"Web\\\" Server\",\n  \"date\": \"2024-05-01\",\n  \"slug\": \"web-server\",\n  \"tags\": [\n    \"go\",\n    \"http\"\n  ],\n  \"draft\": true\n}\n\n"},
+	}
+	for _, tt := range tests {
+		*frontMatterFmt = tt.format
+		if got := siteFrontMatter(d); got != tt.want {
+			t.Errorf("siteFrontMatter() with -frontmatter=%s = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+	*frontMatterFmt = frontMatterYAML
+	d.Pragmas = frontMatter{"date": "last week"}
+	if got := siteFrontMatter(d); !strings.Contains(got, `date: "last week"`) {
+		t.Errorf("siteFrontMatter() did not quote an invalid date: %q", got)
+	}
+}
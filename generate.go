@@ -0,0 +1,89 @@
+// ## go generate
+//
+// Documents that live next to the code stay up to date most easily when
+// `go generate` makes them:
+//
+//	//go:generate goweave -generate
+//
+// -generate tunes goweave for this. go generate runs the command in the
+// directory of the package, with the file of the directive in $GOFILE, so
+// without input files, goweave weaves that file; `-generate .` weaves the
+// whole package instead. The documents go next to the sources, unless
+// -outdir says otherwise, which is then relative to the root of the module
+// rather than the package: `-generate -outdir=docs` writes the documents
+// of the package `internal/server` into `docs/internal/server`, so that
+// one directive per package builds a documentation tree for the module.
+//
+// goweave is quiet then, as a generator should be, and leaves out the
+// messages about files that it skips, like drafts. Warnings still show.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// checkGenerate validates -generate and, if it is set, resolves -outdir.
+func checkGenerate() error {
+	if !*generate {
+		return nil
+	}
+	if flag.NArg() == 0 && os.Getenv("GOFILE") == "" {
+		return fmt.Errorf("-generate needs input files, or $GOFILE, which go generate sets")
+	}
+	if *watch || serve != "" {
+		return fmt.Errorf("-generate does not work with -watch or -serve")
+	}
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == "outdir"
+	})
+	if set {
+		dir, err := generateOutdir(*outdir, ".")
+		if err != nil {
+			return err
+		}
+		*outdir = dir
+	}
+	return nil
+}
+
+// generateOutdir returns the output directory of the package in pkgDir
+// for a relative -outdir, which is relative to the module root.
+func generateOutdir(outdir, pkgDir string) (string, error) {
+	if filepath.IsAbs(outdir) {
+		return outdir, nil
+	}
+	m := findModule(pkgDir)
+	if m.path == "" {
+		return outdir, nil
+	}
+	abs, err := filepath.Abs(pkgDir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(m.root, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(m.root, outdir, rel), nil
+}
+
+// inputArgs returns the input files and directories of the command line,
+// or with -generate and none given, the file that go generate runs for.
+func inputArgs() []string {
+	if *generate && flag.NArg() == 0 {
+		return []string{os.Getenv("GOFILE")}
+	}
+	return flag.Args()
+}
+
+// infof logs what goweave does, unless -generate keeps it quiet.
+func infof(format string, args ...interface{}) {
+	if !*generate {
+		log.Printf(format, args...)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateOutdir(t *testing.T) {
+	root, err := ioutil.TempDir("", "goweave-generate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		t.Fatal(err)
+	}
+	pkg := filepath.Join(root, "internal", "server")
+	if err := os.MkdirAll(pkg, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		outdir, pkgDir, want string
+	}{
+		{"docs", pkg, filepath.Join(root, "docs", "internal", "server")},
+		{"docs", root, filepath.Join(root, "docs")},
+		{filepath.Join(root, "site"), pkg, filepath.Join(root, "site")},
+	}
+	for _, tt := range tests {
+		got, err := generateOutdir(tt.outdir, tt.pkgDir)
+		if err != nil || got != tt.want {
+			t.Errorf("generateOutdir(%q, %q) = %q, %v, want %q", tt.outdir, tt.pkgDir, got, err, tt.want)
+		}
+	}
+}
+
+func TestInputArgs(t *testing.T) {
+	defer func(g bool) { *generate = g }(*generate)
+	defer os.Setenv("GOFILE", os.Getenv("GOFILE"))
+	os.Setenv("GOFILE", "server.go")
+	*generate = false
+	if got := inputArgs(); len(got) != 0 {
+		t.Errorf("inputArgs() without -generate = %v, want none", got)
+	}
+	*generate = true
+	if got := inputArgs(); !reflect.DeepEqual(got, []string{"server.go"}) {
+		t.Errorf("inputArgs() with -generate = %v, want [server.go]", got)
+	}
+}
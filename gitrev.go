@@ -0,0 +1,213 @@
+// ## Weaving a git revision
+//
+// The documents of a release should show the code of the release, not the
+// half-finished changes in the working tree. With -rev, goweave reads the
+// input files from the git object database instead, so
+//
+//	goweave -rev v1.2.3 ./...
+//
+// weaves the files as they were at the tag v1.2.3, no matter what the
+// checkout looks like. -rev accepts anything that names a commit: tags,
+// branches, hashes, and expressions like `HEAD~3`. The repository is the
+// one that contains the current directory.
+//
+// The input files, the translations, and the files that cross-references
+// point to come from the revision. Images and the other files that comments
+// refer to still come from the working tree.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var (
+	revTree *object.Tree // the tree of -rev, or nil for the working tree
+	revRoot string       // the absolute path of the repository's working tree
+	// revMu guards the tree and its objects, which go-git does not make
+	// safe for the workers of -jobs.
+	revMu sync.Mutex
+)
+
+// openRevision looks up the tree of a revision in the repository that
+// contains the current directory.
+func openRevision(rev string) error {
+	if *watch {
+		return fmt.Errorf("-rev and -watch cannot be combined")
+	}
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return fmt.Errorf("-rev: %v", err)
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return fmt.Errorf("-rev: cannot resolve %s: %v", rev, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return fmt.Errorf("-rev: %s: %v", rev, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("-rev: %s: %v", rev, err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("-rev: %v", err)
+	}
+	revTree, revRoot = tree, wt.Filesystem.Root()
+	return nil
+}
+
+// treePath returns the slash-separated path of a file within the
+// repository.
+func treePath(filename string) (string, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(revRoot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the repository", filename)
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// revFile returns a file of the revision. The caller holds revMu.
+func revFile(filename string) (*object.File, error) {
+	p, err := treePath(filename)
+	if err != nil {
+		return nil, err
+	}
+	f, err := revTree.File(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v in revision %s", filename, err, *rev)
+	}
+	return f, nil
+}
+
+// readSource reads an input file, from the working tree or from -rev.
+func readSource(filename string) ([]byte, error) {
+	if revTree == nil {
+		return ioutil.ReadFile(filename)
+	}
+	revMu.Lock()
+	defer revMu.Unlock()
+	f, err := revFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	s, err := f.Contents()
+	return []byte(s), err
+}
+
+// sourceSize returns the size of an input file.
+func sourceSize(filename string) (int64, error) {
+	if revTree == nil {
+		fi, err := os.Stat(filename)
+		if err != nil {
+			return 0, err
+		}
+		return fi.Size(), nil
+	}
+	revMu.Lock()
+	defer revMu.Unlock()
+	f, err := revFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	return f.Size, nil
+}
+
+// isSourceDir returns true if the path is a directory, and false if it is
+// a file.
+func isSourceDir(name string) (bool, error) {
+	if revTree == nil {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return false, err
+		}
+		return fi.IsDir(), nil
+	}
+	p, err := treePath(name)
+	if err != nil {
+		return false, err
+	}
+	if p == "" {
+		return true, nil
+	}
+	revMu.Lock()
+	defer revMu.Unlock()
+	if _, err := revTree.Tree(p); err == nil {
+		return true, nil
+	}
+	if _, err := revTree.File(p); err != nil {
+		return false, fmt.Errorf("%s: %v in revision %s", name, err, *rev)
+	}
+	return false, nil
+}
+
+// revFiles returns the files of the revision below the directory root, for
+// which keep returns true, in lexical order. Without recursive, it stays
+// within root. Like filepath.Walk, it skips the directories for which
+// skipDir returns true.
+func revFiles(root string, recursive bool, keep func(name string) bool) ([]string, error) {
+	dir, err := treePath(root)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	revMu.Lock()
+	defer revMu.Unlock()
+	err = revTree.Files().ForEach(func(f *object.File) error {
+		rel := f.Name
+		if dir != "" {
+			if !strings.HasPrefix(rel, dir+"/") {
+				return nil
+			}
+			rel = rel[len(dir)+1:]
+		}
+		elems := strings.Split(rel, "/")
+		if len(elems) > 1 && !recursive {
+			return nil
+		}
+		for _, e := range elems[:len(elems)-1] {
+			if skipDir(e) {
+				return nil
+			}
+		}
+		name := filepath.Join(root, filepath.FromSlash(rel))
+		if keep(name) {
+			files = append(files, name)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// globSources returns the input files that match a pattern, like
+// filepath.Glob. With -rev, only the last element of the pattern may
+// contain wildcards.
+func globSources(pattern string) ([]string, error) {
+	if revTree == nil {
+		return filepath.Glob(pattern)
+	}
+	return revFiles(filepath.Dir(pattern), false, func(name string) bool {
+		ok, _ := path.Match(filepath.Base(pattern), filepath.Base(name))
+		return ok
+	})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestRevision(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitrev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "// Released\npackage a\n")
+	write("b/c.go", "package b\n")
+	write("b/testdata/d.go", "package d\n")
+	if _, err := wt.Add("."); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	if _, err := wt.Commit("release", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+	write("a.go", "// Work in progress\npackage a\n")
+	write("e.go", "package a\n")
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	defer func(r string) { *rev, revTree, revRoot = r, nil, "" }(*rev)
+	defer func(m map[string]string) { outputDirs = m }(outputDirs)
+	*rev = "HEAD"
+	if err := openRevision(*rev); err != nil {
+		t.Fatal(err)
+	}
+	src, err := readSource("a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(src), "// Released\npackage a\n"; got != want {
+		t.Errorf("readSource() = %q, want %q", got, want)
+	}
+	// The workers of -jobs read the revision at the same time.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			readSource("a.go")
+			sourceSize(filepath.Join("b", "c.go"))
+		}()
+	}
+	wg.Wait()
+	if _, err := readSource("e.go"); err == nil {
+		t.Errorf("readSource() found a file that is not in the revision")
+	}
+	files, err := expandArgs([]string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.go", filepath.Join("b", "c.go")}; !reflect.DeepEqual(files, want) {
+		t.Errorf("expandArgs() = %q, want %q", files, want)
+	}
+	if err := openRevision("no-such-tag"); err == nil {
+		t.Errorf("openRevision() accepted an unknown revision")
+	}
+}
@@ -1,4 +1,3 @@
-//go:generate go-bindata -o resources.go resources
 /*
 # goweave
 
@@ -26,42 +25,212 @@ valid Go source file, ready to be `go install`'ed.
 
         go get github.com/christophberger/goweave/...
 
-2. If you plan to modify files in the `resources/` folder, install go-bindata...
-
-		go get github.com/jteeuwen/go-bindata
-
-   ...and run `go generate` each time you modify the CSS file or the template file.
-
-3. (Optional) Install the CSS and template files into `~/.config/goweave`:
+2. (Optional) Install the CSS and template files into `~/.config/goweave`:
 
 		goweave -install
 
-4. Run goweave on a Go file with comments:
+3. Run goweave on a Go file with comments:
 
         goweave mycode.go
 
-5. Open the generated mycode.html in a browser.
+4. Open the generated mycode.html in a browser.
 
 
 ## Options
 
+* `-config=<file>`: Configuration file. Defaults to the first of `goweave.yaml`,
+  `.goweave.yaml`, `goweave.toml`, and `.goweave.toml` that exists. See "Configuration
+  file" below.
+* `-profile=<name>`: Use the settings of a named profile from the configuration file.
 * `-install`: Installs resource files into `$HOME/.config/goweave`.
 * `-resdir=<dir>`: Resource directory.(1)
-* `-outdir=<dir>`: Output directory. Defaults to the current directory.
+* `-outdir=<dir>`: Output directory. Defaults to the current directory. A name that
+  ends in `.zip` makes goweave write a zip archive instead; see "Output archives" below.
 * `-csspath=<path>`: Output path for the CSS file, relative to the output directory.
   Defaults to the current directory.
 * `-bare`: Only generate the body part of the HTML document. (No CSS file references is
   included then, use -inline instead or add the CSS reference manually in your HTML
-  header.
+  header. goweave copies no CSS file then, except for the index page.)
+* `-fragment`: Only generate the sections, wrapped in a single `<div>`, for embedding
+  the output into other pages (e.g., through a CMS or a Hugo shortcode). Unlike
+  `-bare`, this leaves out the title header and the `#goweave` container.
+* `-wrapper-class=<class>`: Class of the `<div>` that wraps a fragment. Defaults to
+  `goweave`.
+* `-stdin-name=<file>`: The file name of the source that `goweave -` reads from standard
+  input, for its language and title. See "Standard input and output" below.
+* `-generate`: Run as the command of a `//go:generate` directive. See "go generate"
+  below.
+* `-copysrc`: Copy each source file into the output directory, next to its document,
+  and add "view raw" and "download" links to the document.
+* `-heading-offset <n>`: Shift the level of Markdown headings in comments by n. With
+  `-heading-offset 1`, `# Heading` becomes `<h2>`, leaving `<h1>` to the page template.
+* `-max-heading <n>`: Render all Markdown headings deeper than level n at level n.
+  The offset is applied first.
+* `-base-url=<url>`: The URL under which the output directory gets published. If set,
+  each document declares its canonical URL. See "Canonical URLs and redirects" below.
+* `-noindex`: Ask search engines not to index any of the documents. See "Search engines"
+  below.
+* `-robots`: Also write a `robots.txt` file into the output directory.
+* `-lang=<tag>`: The language of the comments in the source files. Default: `en`.
+  See "Translations" below.
+* `-skip-tests`: Skip `_test.go` files in directories. See "Directories" below.
+* `-exclude=<patterns>`: Skip the files in directories that match one of these
+  comma-separated patterns, like `vendor,*_gen.go`. See "Directories" below.
+* `-project-files`: Also weave the go.mod, go.sum, and go.work files, Makefiles, and
+  Dockerfiles in directories. See "Project files" below.
+* `-rev <revision>`: Read the input files from this git revision rather than from the
+  working tree. See "Git revisions" below.
+* `-listings`: Add a list of all captioned code blocks to the end of each document.
+  See "Captions" below.
+* `-toc`: Add a table of contents built from the `#` and `##` headings of the comments.
+  See "Table of contents" below.
+* `-toc-style <top|sidebar>`: Where the table of contents goes. Defaults to `top`.
+* `-balance=<lines>`: Split sections whose code runs on for more than this many lines
+  past their prose, or the other way round. See "Balanced sections" below.
+* `-split=<level>`: Split the document of a file into pages at the headings of this
+  level or above: 1 for `#`, 2 for `#` and `##`. See "Splitting long files" below.
+* `-strict`: Fail documents with cross-references that cannot be resolved, instead of
+  printing a warning. See "Cross-references" below.
+* `-identifier-links=false`: Do not link the identifiers in Go code to their
+  declarations in other input files. See "Links between files" below.
+* `-related`: Add links to up to three related documents at the end of each document.
+  See "Related pages" below.
+* `-drafts`: Also generate documents whose source file is marked as a draft. See
+  "Drafts" below.
+* `-boilerplate <show|collapse|hide>`: What to do with the package clause and the imports
+  at the top of the file. `collapse` moves them into a block that the reader can expand;
+  `hide` drops them. In Markdown mode, `collapse` shows them. Default: `show`
+* `-footnotes`: Move the comments at the ends of lines of Go code into numbered
+  footnotes in the prose column. See "Footnotes" below.
+* `-schema`: Add a table of the fields beneath each Go struct with `json:` or `yaml:`
+  tags. See "Schema tables" below.
+* `-const-tables`: Add a table of the names and values beneath each block of Go
+  constants. See "Constant tables" below.
+* `-prose-marker=<marker>`: Take only the line comments that start with this marker as
+  prose, like `:` for `//:`. See "Prose markers" below.
+* `-permalinks=false`: Do not add a "¶" link to each section. See "Section IDs" below.
+* `-srclink-base=<pattern>`: Link each code block to its lines in the hosting
+  repository, with a URL pattern like `https://github.com/me/repo/blob/main/{file}#L{line}`.
+  See "Links to the source" below.
+* `-linenums`: Number the lines of the code blocks as in the source files. See "Line
+  numbers" below.
+* `-keep-directives`: Keep directives like `//go:generate` and build constraints in the
+  code. See "Directives" below.
+* `-source-order`: Keep the sections in the order of the source, ignoring
+  `//goweave:order` pragmas. See "Section order" below.
+* `-by-decl`: Make one section per declaration of Go files, with a heading, in the order
+  of godoc. See "Sections by declaration" below.
+* `-pkg-summary`: Start Go documents with a summary of the package, with links to the
+  exported identifiers. See "Package summary" below.
+* `-interfaces`: Write interfaces.html, a matrix of the types of the Go input files and
+  the interfaces that they implement. See "Interface implementations" below.
+* `-status-report`: Write status-report.html, which lists the sections by their review
+  status. See "Review status" below.
+* `-images`: Copy the images that comments refer to into the output directory. See
+  "Images" below.
+* `-max-image-width=<pixels>`: With `-images`, scale down PNG and JPEG images that are
+  wider than this. Defaults to 1280; 0 disables scaling.
+* `-inline-svg=<bytes>`: Put SVG images up to this size right into the document, so that
+  they can use the styles of the page. See "Images" below.
+* `-diagrams=<off|client|svg>`: Draw the `mermaid` and `dot` blocks of the comments.
+  Defaults to `off`. See "Diagrams" below.
+* `-mermaid-url=<url>`: The Mermaid module that documents load with `-diagrams=client`.
+  Defaults to Mermaid 11 from jsDelivr.
+* `-math`: Typeset the TeX formulas of the comments, `$...$` and `$$...$$`. See "Math"
+  below.
+* `-katex-url=<url>`: The KaTeX directory that documents load with `-math`, a URL or a
+  path within the output directory. Defaults to KaTeX 0.16 from jsDelivr.
+* `-theme=<name>`: Add a theme to the CSS file, like `classic`, `dark`, `high-contrast`,
+  or `print`. See "Themes" below.
+* `-list-themes`: Print the names of the available themes and exit.
+* `-color-scheme=<auto|light|dark>`: The palette of the documents. Defaults to `auto`,
+  which follows the reader's system setting and adds a button to switch. See "Dark mode"
+  below.
+* `-prose-font=<font stack>`: The fonts of the comments, as a CSS font stack like
+  `"Source Serif Pro", Georgia, serif`.
+* `-code-font=<font stack>`: The fonts of the code.
+* `-font-files=<family=file.woff2,...>`: Font files to ship with the documents. See
+  "Fonts" below.
+* `-highlighter <litebrite|chroma>`: The syntax highlighter for the code. Defaults to
+  `litebrite`. See "Syntax highlighting" below.
+* `-chroma-style <name>`: The color style for `-highlighter chroma`. Defaults to `github`.
+* `-chroma-dark-style <name>`: The color style for `-highlighter chroma` in the dark
+  palette. Defaults to `monokai`; empty keeps `-chroma-style` for both.
+* `-print-highlight-css`: Print the CSS rules of the Chroma color style and exit.
+* `-token-classes=<highlightjs|prism|class=new class,...>`: Rename the classes of the
+  tokens of the code for the stylesheet of another highlighter. See "Token classes"
+  below.
+* `-rawview`: Include the plain source file in the document, along with a button that
+  toggles between the side-by-side view and the plain source, for copying larger chunks
+  of code.
 * `-inline`: Include the CSS into the HTML file. Does not work with `-bare`.
+* `-standalone`: Write HTML documents that need no other files: `-inline`, plus the
+  script and the images as part of the document. See "Standalone documents" below.
+* `-template=<file>`: A template that replaces some or all of the document template.
+  See "Template data" below.
 * `-md`: Generate Markdown output rather than HTML.(2)
+* `-format=<html|md|rst|tex|json>`: The format of the documents. `md` is the same as
+  `-md`, `rst` writes reStructuredText, `tex` LaTeX, and `json` the sections as JSON. See
+  "reStructuredText for Sphinx", "LaTeX", and "JSON for other tools" below.
+* `-latex-code=<listings|minted>`: The LaTeX package for the code of `-format=tex`.
+  Defaults to `listings`.
+* `-md-layout=<plain|table>`: Put the code of Markdown documents beneath the prose
+  (`plain`, the default) or next to it, in an HTML table (`table`). See "Side-by-side
+  Markdown" below.
+* `-frontmatter=<yaml|toml|json>`: Start each Markdown document with front matter for
+  static site generators. See "Front matter for static site generators" below.
+* `-hugo`: Write the documents as page bundles of a Hugo site, with the output
+  directory as the root of the site. See "Hugo page bundles" below.
+* `-hugo-section=<dir>`: The section of the Hugo site that `-hugo` writes to. Defaults
+  to `posts`.
+* `-section-ids`: Mark the prose of each section of Markdown documents with the ID of
+  the section, for `goweave unweave`. See "Editing the prose of a document" below.
+* `-title=<title>`: Document title. Can only be used with a single input file.
+  See "Document titles" below.
 * `-intro`: Only process the very first comment (which should be some intro text that
   can be read as-is). Together with -md this comes handy for easily generating a
   README.md from the source.
+* `-jobs=<n>`: Number of files to process in parallel. Defaults to GOMAXPROCS. However
+  many jobs run, goweave reports the files that failed in the order of the input files,
+  after all files are done.
+* `-postprocess=<command>`: Pipe each generated document through this command before
+  writing it. See "Postprocessing" below.
+* `-watch`: Keep running after generating the documents, and regenerate a document
+  whenever any of its input files changes. See "Watch mode" below.
+* `-index=false`: Do not write an `index.html` file. See "Index page" below.
+* `-index-title=<title>`: The title of the index page. Default: "Contents"
+* `-serve[=<address>]`: Serve the output directory over HTTP, by default at
+  `localhost:8080`. See "Watch mode" below.
+* `-webhook-secret=<secret>`: With `-serve`, accept push events from GitHub or GitLab
+  and rebuild the documents after pulling. See "Webhooks" below.
+* `-manifest`: Also write a `manifest.json` file into the output directory. See
+  "Manifest" below.
+* `-site-report`: Print a report of orphaned pages and of references to missing targets.
+  See "Site report" below.
+* `-coverage`: Print the documentation coverage of each file and package.
+  See "Documentation coverage" below.
+* `-min-doc-coverage=<fraction>`: Exit with an error if the total documentation
+  coverage is below the given fraction, e.g. `-min-doc-coverage=0.6`.
+* `-badge`: Write an SVG badge with the documentation coverage and the build date
+  into the output directory, as `goweave-badge.svg`.
+* `-maxsize=<bytes>`: Skip source files larger than this. Defaults to 1 MiB; 0 disables
+  the check. Binary files are always skipped with a warning.
+* `-force`: Regenerate all documents, including those that the build cache finds
+  up to date. See "Incremental builds" below.
+* `-pdf`: Also print each document to a PDF file next to it, through a headless Chrome
+  or Chromium. See "PDF output" below.
+* `-pdf-browser=<command>`: The browser that prints the documents for `-pdf`. Defaults
+  to the first Chrome, Chromium, or Edge that goweave finds.
+* `-epub=<file>`: Also bundle all documents into an EPUB book. See "EPUB books" below.
+* `-epub-title=<title>`: The title of the EPUB book. Defaults to the name of the file.
+* `-epub-author=<name>`: The author of the EPUB book.
 
 (1) If -resdir is not given, goweave searches for `goweave/resources` first in the
-current dir, then in $HOME/config. If neither succeeds, it automatically installs
-the resource files into `./goweave/resources`.
+current dir, then in $HOME/config. If neither succeeds, it uses the resource files
+that are built into the binary, without writing them anywhere. To customize them, run
+`goweave -install`, or copy `$HOME/.config/goweave/resources` into `./goweave`.
+The built-in files are those of the `resources` directory of the repository, which the
+binary embeds when it is built; changes to them need no extra generate step.
 
 (2) If you generate a Markdown document instead of HTML, you need to provide your
 own CSS that matches the output of your Markdown renderer.\
@@ -72,6 +241,49 @@ tweak your CSS and/or your markdown renderer accordingly.
 
 ## Notes
 
+### Configuration file
+
+Settings that you would otherwise pass as flags can go into a configuration
+file, `goweave.yaml` in the current directory by default. The keys are the
+flag names. The file can also define named profiles, each bundling a set of
+flags, e.g. for generating a blog article or a README:
+
+        outdir: docs
+        csspath: css
+        profiles:
+          blog:
+            md: true
+            outdir: content/posts
+          readme:
+            md: true
+            intro: true
+            outdir: .
+
+Then `goweave -profile blog mycode.go` generates a blog article. Flags on the
+command line take precedence over the profile, and the profile takes precedence
+over the top-level settings. A list sets a flag to its comma-separated items, as
+in `exclude: [vendor, "*_gen.go"]`.
+
+Instead of `goweave.yaml`, the file can be named `.goweave.yaml`, or use TOML as
+`goweave.toml` or `.goweave.toml`, with the same keys:
+
+        outdir = "docs"
+        exclude = ["vendor", "*_gen.go"]
+
+        [profiles.blog]
+        md = true
+
+The `languages` key maps file extensions to languages (see "Other languages"
+below), either to a language that goweave knows or to a new comment syntax:
+
+        languages:
+          .pyx: python
+          .nim:
+            name: nim
+            line: "#"
+            block_start: "#["
+            block_end: "]#"
+
 ### Full-width sections
 
 If a comment is not followed by code but rather by another comment (separated
@@ -81,6 +293,1301 @@ without a code column.
 This can be useful for creating intro sections or READMEs, or for splitting
 long code into separate snippets.
 
+### Postprocessing
+
+To modify the generated documents without forking the template (e.g., to
+rewrite URLs for a CDN or add a consent banner), pass a command with
+`-postprocess`. goweave pipes each document through this command and writes
+the command's output instead of the original document. The environment
+variables `GOWEAVE_SOURCE` and `GOWEAVE_OUTPUT` contain the name of the source
+file and of the document. The command line is split at white space; for
+anything more complex, use a script:
+
+        goweave -postprocess="sed s/http:/https:/g" mycode.go
+
+### Standard input and output
+
+With `-` as its only argument, goweave reads a source file from standard input and
+writes the document to standard output, so that it fits into pipelines, editor plugins,
+and pre-commit hooks without temporary files:
+
+        cat mycode.go | goweave -inline - > mycode.html
+        goweave -md -stdin-name=tool.py - < tool.py
+
+goweave writes nothing else then: no CSS file, no index page, no build cache. The
+document links to the CSS file as usual, so add `-inline`, or `-fragment` to embed the
+output elsewhere. The source counts as Go and gets the title "stdin", unless
+`-stdin-name` names it; the extension of the name picks the language. -watch and
+-serve need input files.
+
+### go generate
+
+`-generate` makes goweave a good citizen of `go generate`:
+
+        //go:generate goweave -generate
+
+Without input files, it weaves the file of the directive, which go generate passes in
+`$GOFILE`; `-generate .` weaves the whole package. The documents go next to the source
+files, unless `-outdir` is set, which is then relative to the root of the module: with
+`-generate -outdir=docs`, the documents of the package in `internal/server` go to
+`docs/internal/server`, so one directive per package builds a documentation tree for
+the module. goweave stays quiet, apart from warnings. `-watch` and `-serve` do not work
+with `-generate`.
+
+### Watch mode
+
+With `-watch`, goweave keeps an eye on all files that went into the generated
+documents: the source files, the template and the CSS, and any files that the
+template pulls in through the `code` function. When a file changes, goweave
+regenerates only the documents that depend on it. Editing a comment in one
+source file thus regenerates just that file's document, while editing the
+template regenerates all of them.
+
+If a document cannot be generated, for example because the template contains
+errors, goweave keeps running and replaces the document with an error page. The
+page shows the error message along with the file and line that caused it, until
+the problem is fixed.
+
+With `-serve`, goweave also serves the output directory over HTTP. Each HTML page it
+serves includes a small script that reloads the page whenever goweave has regenerated
+the documents, so `goweave -watch -serve mycode.go` shows every change in the browser
+right away. `-serve=:3000` makes goweave listen on another port. The server is meant
+for previewing on your own machine, not for publishing.
+
+After a reload, the page briefly highlights the sections that changed since the last
+time the browser showed it: yellow for changed sections, green for new ones, and a red
+line where sections were removed. This way, the effect of an edit is easy to spot in a
+long document.
+
+### Webhooks
+
+A team without access to a CI pipeline can let goweave keep the documents of a
+repository up to date. Run goweave in a clone of the repository with `-serve` and a
+secret, and add a webhook with the same secret to the repository on GitHub or GitLab,
+pointing to `/_goweave/webhook`:
+
+        goweave -serve=:8080 -webhook-secret=s3cret -outdir=site ./...
+
+For every push to the branch of the clone, goweave runs `git pull --ff-only` and then
+generates the documents again; thanks to the build cache, only those of the changed
+files. Pushes to other branches are ignored, and requests without the right signature
+(GitHub) or token (GitLab) are turned away. One build runs at a time; a push during a
+build starts another one afterwards. `/_goweave/status` reports the state of the last
+build as JSON: `building`, `ok`, or `failed`, with the commit and the error message.
+With `-watch`, goweave only pulls, and the watcher regenerates the documents.
+
+The secret is better kept in the configuration file than on the command line, where
+other users of the machine can see it. As `git pull` runs with the credentials of the
+user that runs goweave, the clone should be one that this user can pull without a
+password prompt.
+
+### Incremental builds
+
+goweave remembers a fingerprint of each document in `.goweave-cache` in the output
+directory. The fingerprint covers the comments and the code, the template and the
+CSS, the options, and the version of goweave. When you run goweave again, it skips
+the documents whose fingerprint did not change, so re-weaving a large tree only
+regenerates the documents of the files you edited.
+
+Documents with cross-references are always regenerated, as their targets may have
+changed. Changes to images and other files that comments refer to go unnoticed;
+use `-force` to regenerate all documents after such changes. Watch mode does not
+use the cache.
+
+### Section IDs
+
+In HTML output, each section carries a `data-section-id` attribute with an ID
+that is derived from the section's content. Review tools and annotation layers
+can use it to attach notes to a section that survive regenerating the document.
+
+The ID consists of the first 12 hex digits of the SHA-256 hash of the comment
+text and the code of the section, separated by a NUL byte. Trailing whitespace
+and leading or trailing empty lines are ignored. If several sections have the
+same content, the second one gets "-2" appended to its ID, the third one "-3",
+and so forth. Hence a section's ID changes only if its comment or code changes.
+The section's `id` attribute is the same ID, so links can point to the section.
+
+For links that people share, each section also has an anchor that is easier to read:
+the ID of the nearest heading at or above the section, a dot, and the number of the
+section below that heading. The section with `## The main loop` becomes
+`#the-main-loop.1`, the section after it `#the-main-loop.2`, and sections before the
+first heading are `#section.1`, `#section.2`, and so on. A "¶" link in the corner of
+each section, which shows up when the mouse is over the section, points to its anchor;
+`-permalinks=false` leaves it out. Custom templates find the anchor in the `Anchor`
+field of each section, next to `ID`, and the setting of -permalinks in `.Permalinks`.
+
+### Editing the prose of a document
+
+Reviewers who do not write code can still improve the prose: they edit the generated
+document, and `goweave unweave` writes the changed prose back into the comments of the
+source file, going by the section IDs:
+
+        goweave unweave server.html server.go
+
+HTML documents carry the IDs anyway. Markdown documents need `-section-ids`, which
+puts HTML comments around the prose of each section, like
+`<!-- goweave:section 3f2a9c0b1d4e -->` before it and `<!-- goweave:code -->` after
+it; the reviewers leave these lines alone. goweave turns the paragraphs, headings,
+lists, links, emphasis, and formulas of HTML back into Markdown; other markup keeps
+only its text.
+
+Only the sections whose text changed get new comments, with the indentation and the
+pragmas of the old ones. Edits of the code are not written back, and neither is the
+prose of sidecar files or of included lines. As the IDs come from the content, the source file must be the
+one that the document was generated from, and options that decide what the sections
+are, like `-prose-marker`, must be the same; sections that goweave does not find get a
+warning. `goweave unweave -print` prints the new source instead of writing the file.
+
+### Table of contents
+
+With -toc, each document starts with a list of the `#` and `##` headings of its comments,
+linked to the headings. With `-toc-style sidebar`, the list goes into a sidebar that stays
+in place while the page scrolls. In Markdown mode, the list is a "Contents" section at
+the top. Each heading gets an ID derived from its text, like `getting-started` for
+`## Getting Started`; a repeated heading gets "-1" appended the second time, "-2" the
+third time, and so forth, as on GitHub. `## Setup {#install}` sets the ID explicitly.
+Custom templates get the headings as `.TOC`, each with `.Level`, `.Text`, `.ID`, and
+`.Href`, the link to the heading, and the style as `.TOCStyle`.
+
+### Splitting long files
+
+A single page for a file of thousands of lines is slow to load and hard to find one's
+way in. `-split=1` splits the document of a file into pages at the `#` headings of its
+comments, and `-split=2` at the `##` headings, too. The first page keeps the name of the
+document, and each other page gets the ID of its first heading appended, like
+`server-routing.html` for `# Routing` in server.go. All pages share a table of contents
+of the whole document, even without -toc, and link to the pages before and after them.
+
+goweave splits between sections, so a page starts with the section whose comment has the
+heading. References like `[[#routing]]` or `[[server.go#Handler]]` link to the page that
+has their target. Captions are numbered per page. `-split` does not work with `-hugo`.
+Custom templates get the links to the neighboring pages as `.Prev` and `.Next`, each
+with `.Title` and `.Href`.
+
+### Balanced sections
+
+A long function under a one-line comment leaves the prose column blank for screens, and
+so does a long explanation next to three lines of code. `-balance=40` splits each
+section whose one side runs on for more than 40 lines past the other into sections of
+about 40 lines. The longer side breaks at blank lines, preferably before unindented
+lines like the next declaration or paragraph, and prose never breaks within a fenced
+block. The paragraphs or blocks of the shorter side go with the piece they face in the
+source, and a piece of code without prose of its own gets the note *(continued)*. The
+pieces keep the caption on the first one, and the status and order of the section.
+Their section IDs differ from those without `-balance`, so translations and
+`goweave unweave` need the same setting as the documents.
+
+### Captions
+
+A `//goweave:caption` pragma right before a piece of code adds a numbered caption below
+the code block:
+
+	//goweave:caption Listing: parsing loop
+	for scanner.Scan() {
+
+The word before the colon names the kind of the caption, like "Listing", "Figure", or
+"Example"; each kind is numbered on its own, starting at 1 in each document. Without a
+colon, the caption is a listing. In HTML output, the caption's element gets an ID like
+`listing-4`, so that comments can link to the code they mention, as in
+`see [Listing 4](#listing-4)`. With -listings, each document ends with a list of its
+captions.
+
+### Section order
+
+The compiler dictates the order of the code, which is rarely the best order to explain
+it in. A `//goweave:order` pragma moves a section to another place in the document:
+
+	// ## The main loop
+	//goweave:order 20
+	func run() {
+
+Sections sort by the numbers of their pragmas, and a pragma holds for the sections after
+it, up to the next one, so a single pragma moves a whole run of sections. The sections
+before the first pragma, usually the intro and the package clause, stay at the top.
+Instead of numbers, pragmas can name chapters, and a `//goweave:chapters` pragma
+anywhere in the file puts the chapters in order:
+
+	//goweave:chapters model, handlers, main
+	...
+	//goweave:order handlers
+
+All sections of a chapter come together, in the order of the source, so related code
+from all over a file can share a chapter. Chapters that the list leaves out follow
+the listed ones, and numbers go before names. The code gets highlighted in source order
+either way, and captions are numbered in the order that the reader sees. `-source-order`
+ignores the pragmas, and the plain source view of -rawview always shows the original order.
+
+### Sections by declaration
+
+goweave starts a section at each comment, which suits prose that walks through the code.
+For a file of declarations with doc comments, like a library, `-by-decl` makes the
+document read like godoc instead: one section per function, type, or block of
+constants or variables, with its doc comment as the prose and its full body as the
+code, comments inside included. Each section gets a heading from the names that it
+declares:
+
+	## func New
+	## type Weaver
+	### func (*Weaver) Render
+
+The sections come in the order of godoc: constants, variables, functions, and then the
+types, each followed by its constants, variables, constructors, and methods, one
+heading level down. The intro and the imports stay at the top, and a comment that
+belongs to no declaration moves along with the next declaration. Order pragmas have no
+effect, and `-source-order` keeps the declarations where they are in the source. Go
+files that do not parse, and other languages, get the usual sections.
+
+### Package summary
+
+`-pkg-summary` starts each Go document with a summary like the top of a godoc page: the
+name of the package, its import path, the first sentence of the package comment, and a
+list of the exported constants, variables, functions, and types, with the constructors
+and methods of each type beneath it. Each entry links to the section that declares it,
+on its page if `-split` splits the document. Markdown documents have no anchors for
+sections, so there the entries are plain text. Together with `-by-decl`, this makes
+lightweight API docs.
+
+goweave takes the import path from the go.mod file above the source file, so files
+outside of a module, and commands, get no import path. The Markdown headings at the top
+of a literate file make no synopsis, so goweave skips them and takes the first sentence
+after them.
+
+### Review status
+
+When literate documents serve as design documents under review, a status pragma marks
+how far the review of a section got:
+
+        // ## Caching
+        //
+        // The cache keeps the parsed files in memory...
+        //goweave:status draft
+
+The pragma applies to the section of the next line. The status is `reviewed`, `draft`,
+or `obsolete`, and shows up as a colored badge next to the section's comment; goweave
+warns about other values, but shows them, too. With `-status-report`, goweave also
+writes status-report.html into the output directory. It counts the sections of each
+status and lists them by document, linked to the sections, so that reviewers see what
+is left to do.
+
+### Footnotes
+
+Short comments at the ends of lines explain a detail right where it happens, but a few
+of them turn the code column into a wall of gray. With `-footnotes`, goweave moves them
+out of the Go code and puts a small number in their place:
+
+	n := len(buf) // the bytes read so far
+
+becomes `n := len(buf)` with a superscript 1 in the code, and "the bytes read so far"
+appears as note 1 below the prose of the section. The numbers count through the whole
+document, and the number and the note link to each other. Comments on lines of their
+own start new sections as always, and comments without a space after the `//`, like
+`//nolint:errcheck`, are meant for tools and stay in the code. Custom templates find the
+notes in the `Footnotes` field of each section.
+
+### Schema tables
+
+A struct with `json:` or `yaml:` tags describes a data format, like a configuration
+file or the payload of an API. With `-schema`, goweave adds a table beneath the section
+of each such struct, with the fields, their types and tags, and their comments:
+
+| Field | Type | Tag | Description |
+| --- | --- | --- | --- |
+| `Title` | `string` | `json:"title,omitempty"` | The title of the page. |
+
+The table comes from the code, so it stays up to date with every run. The description
+of a field is the comment above it, or else the comment at the end of its line. Fields
+that neither encoding would see, like unexported fields without tags or fields tagged
+`json:"-"`, are left out. Each table is a section of its own with the ID
+`schema-<type name>`, and `goweave unweave` leaves it alone.
+
+### Constant tables
+
+An enum built with `iota` hides its values behind the code that computes them. With
+`-const-tables`, goweave adds a table beneath each `const ( ... )` block with at least
+two names, listing each name with its value and its comment:
+
+        const (
+                Read Mode = 1 << iota
+                Write
+                Exec
+        )
+
+gets a table "Constants of type `Mode`" with the values 1, 2, and 4. goweave computes the
+values from the code of the file alone, so a constant that depends on another package,
+like `3 * time.Second`, shows its expression instead. The tables have the IDs
+`const-<first name>`.
+
+### Syntax highlighting
+
+By default, litebrite highlights the code, and the CSS file of the theme colors it.
+With `-highlighter chroma`, the [Chroma](https://github.com/alecthomas/chroma) library
+highlights the code instead. It tokenizes Go more accurately and comes with dozens of
+color styles, like `monokai`, `dracula`, or `solarized-light`; `-chroma-style` selects
+one, and `-chroma-dark-style` the one for the dark palette (see "Dark mode"). goweave adds the rules of the style to the head of each document, after the CSS
+file. Documents generated with -bare or -fragment have no head; get the rules through
+`goweave -highlighter chroma -chroma-style <name> -print-highlight-css` and add them to
+the page that includes the document.
+
+Highlighters see tokens, not scopes, so goweave itself looks up the type parameters of
+generic Go code. Each use of a type parameter, like the `T` in
+`func Sum[T Number](xs ...T) T`, gets the class `typeparam` and a tooltip that names its
+constraint, and the constraints get the class `constraint`. Both link to the definition of the constraint
+if it is declared in the same file, or to the documentation of `any` and `comparable`.
+The type parameters of a method's receiver, like `K` in `func (p *Pair[K, V]) Key() K`,
+count as well. Code that does not parse is highlighted as it is.
+
+Fenced blocks in the comments get highlighted, too, in the language of their info
+string, so that an example in the prose looks like the code next to it. litebrite knows
+Go only; with Chroma, blocks like ```` ```json ```` or ```` ```sh ```` get their colors as
+well. Blocks without a language, or in one that the highlighter does not know, stay
+plain, and so do `mermaid` and `dot` blocks, which are diagrams rather than code.
+
+### Token classes
+
+Sites that already color code with the stylesheet of
+[highlight.js](https://highlightjs.org) or [Prism](https://prismjs.com) can color woven
+code with it, too: `-token-classes` renames the classes of the tokens to the ones that
+the stylesheet expects. The presets `highlightjs` and `prism` know the classes of both
+litebrite and Chroma; pairs like `keyword=kw` rename single classes, override the
+presets before them, and an empty new class drops the class. Classes without a new name,
+like `typeparam`, stay as they are.
+
+    goweave -fragment -token-classes=prism,ident=token variable server.go
+
+or, in the configuration file:
+
+    token-classes: prism, ident=token variable
+
+The CSS file of the theme and the rules of `-chroma-style` do not know the new classes,
+so goweave leaves out the rules of the Chroma style; the stylesheet of the site takes
+over. Libraries set `weave.Options.TokenClasses`, to a map of their own or to
+`weave.PrismClasses` or `weave.HighlightJSClasses`.
+
+### Other languages
+
+In Go files, goweave asks the Go parser which lines are comments, so a `//` or `/*` at
+the start of a line within a raw string stays code, and so does a comment after code,
+even if it continues on the next lines. Go files that do not parse, like snippets, go
+by the comment markers at the start of the lines, as files in other languages do.
+
+goweave weaves more than Go. It picks the comment syntax by the extension of the file,
+so `goweave script.py` takes `#` lines as comments, and `goweave schema.sql` takes `--`
+lines and block comments. Known are C, C++, C#, Java, JavaScript, TypeScript, Kotlin,
+Rust, Scala, Swift, CSS, Python, Ruby, Perl, shell scripts, YAML, TOML, SQL, Lua, and
+Haskell, as well as Makefiles and Dockerfiles, which goweave recognizes by their
+names; files with other extensions are taken for Go. Pragmas start with the line
+comment marker of the language, as in `#goweave:title`, and shebang lines stay out of
+the document. In Markdown mode, the code fences carry the name of the language, like
+` ```python `. Litebrite highlights Go only, so code in other languages stays plain
+unless `-highlighter chroma` is set; go.mod and go.sum files get highlighted either way.
+Directories still contribute their .go files only, plus the project files with
+`-project-files`; name other files on the command line.
+
+### Prose markers
+
+In most code bases, few comments are narrative; most explain a line or two of code and
+belong next to it. `-prose-marker` sets a marker that turns a line comment into prose,
+while all other comments, block comments included, stay in the code column:
+
+        //: ## Caching
+        //:
+        //: The cache keeps the parsed files in memory.
+
+        // entries are evicted in LRU order
+        var cache = newLRU(100)
+
+With `-prose-marker=:`, the first three lines become prose, and the comment on `cache`
+stays with the code. The marker follows the line comment marker of the language right
+away, so `-prose-marker=" |"` picks `// |` in Go and `# |` in Python. Languages without
+line comments, like CSS, keep taking their block comments as prose. Sidecar files put
+their notes into marked comments, too.
+
+### Directives
+
+Comments that address a tool rather than the reader, like `//go:generate`,
+`//go:embed`, or `//export`, are no prose, and goweave leaves them out of the document.
+The same goes for build constraints, both `//go:build` and the older `// +build`, and
+for the `#!` line of scripts.
+
+Yet some of them matter to the reader: a `//go:build` line tells for which platforms a
+file is, and a `//go:embed` line where a variable gets its content from. With
+`-keep-directives`, goweave keeps the directives in the code column, highlighted as
+comments, right where they are in the source. goweave's own pragmas, like
+`//goweave:title`, stay out either way.
+
+### Cross-references
+
+Comments can link to other parts of the generated documents through references in
+double brackets:
+
+* `[[#slug]]` refers to a heading, a caption like `listing-4`, or a declaration like
+  `FuncName` or `Type.Method` in the same document. Headings use the IDs that
+  Markdown gives them: `## Reading the input` becomes `reading-the-input`.
+* `[[file.go]]` refers to the document of another input file, relative to the
+  directory of the current file.
+* `[[file.go#FuncName]]` refers to a heading, a caption, or a declaration in another
+  document.
+* `[[target|text]]` sets the text of the link.
+
+goweave turns each reference into a relative link that shows the title of its target,
+unless the reference sets a text: the document title, the heading, the caption label,
+or the name of the declaration. Links to declarations point to the section that
+contains the declaration; in Markdown output, they point to the document only. A
+reference that cannot be resolved stays as it is, with a warning, or fails the
+document with -strict.
+
+### Links between files
+
+In the code, goweave links the exported identifiers that other input files declare to
+their declarations, so that a reader can click through from `Run(shapes.Square{})` to
+the documents of `Run` and `Square`. This covers two kinds of identifiers:
+
+* Identifiers of the same package, like `Run`, declared by another input file in the
+  same directory with the same package clause.
+* Qualified identifiers, like `shapes.Square`, of a package that the file imports from
+  the same module, if the files of that package are input files, too. goweave takes
+  the module path from the nearest go.mod file above the source file, so
+
+        goweave -outdir=doc ./...
+
+  in the root of a module links all of its packages to each other.
+
+The links get the class `xref` and a tooltip with the name. goweave parses the code of
+each file to tell the identifiers that the file uses from those that it declares itself,
+so a local variable named like a function of another file stays unlinked. Code that
+does not parse stays unlinked, too. `-identifier-links=false` turns the links off.
+
+### Interface implementations
+
+Nothing in the declaration of a Go type tells which interfaces it implements. With
+`-interfaces`, goweave type-checks the Go input files and writes interfaces.html into
+the output directory: a matrix with the types of the input files as rows and their
+interfaces as columns, with a ✓ where a type implements an interface, and a `*` where
+only the pointer to the type does. Below the matrix, each interface lists its
+implementations, and each implementation links to the sections that declare its
+methods, even if it gets them from an embedded type.
+
+Imports of the packages of the same module resolve to their input files, so that
+
+        goweave -interfaces -outdir=doc ./...
+
+in the root of a module finds the implementations across all of its packages. goweave
+type-checks the imports from outside the module from their source, which takes a few
+seconds. Interfaces without methods, generic types, and test files are left out, and
+so are the types that implement none of the interfaces. Like the status report, the
+page is for HTML output only.
+
+### Links to the source
+
+With `-srclink-base`, each code block of an HTML document gets a small "source" link in
+its corner that opens its lines in the hosting repository, for the history and the
+blame of the code. The option takes a URL pattern:
+
+        goweave -srclink-base='https://github.com/me/repo/blob/main/{file}#L{line}-L{end}' ./...
+
+goweave fills in these placeholders:
+
+* `{file}`: the path of the source file within its git repository, or within the
+  current directory if the file is in no repository.
+* `{line}` and `{end}`: the first and the last line of the code block, without the blank
+  lines around it.
+
+For GitLab, use `https://gitlab.com/me/repo/-/blob/main/{file}#L{line}-{end}`. The
+pattern needs a `{line}`, and the links are for HTML documents only.
+
+### Line numbers
+
+With `-linenums`, each code block of an HTML document gets a gutter with the numbers of
+its lines. The numbers are those of the source file, not of the block: a block picks up
+where the previous one left off, after the lines of the comment between them, so that a
+line from a compiler message or a stack trace is easy to find. Lines that the document
+leaves out, like directives in the code, or the package clause and imports with
+`-boilerplate`, keep their numbers to themselves, and the numbering jumps over them.
+
+Copying the code does not copy the numbers. The option is for HTML output only.
+
+### Document titles
+
+By default, the title of a document is the name of its source file. To set a
+proper title, add a `//goweave:title` pragma to the source file:
+
+        //goweave:title Building a Web Server in 50 Lines
+
+The `-title` flag overrides this. An explicit title also appears as a header
+at the top of the document.
+
+Pragmas can also be written as meta lines, `//meta: title=Building a Web Server in 50
+Lines`, which goweave treats the same way.
+
+### Drafts
+
+A source file with a `//goweave:draft` pragma is a draft. goweave skips drafts,
+unless `-drafts` is set. Even then, drafts do not show up in the manifest.
+When a file becomes a draft, goweave deletes its document from the output
+directory and its entry from the manifest. `//goweave:draft false` turns the
+pragma off again.
+
+### Side-by-side Markdown
+
+Markdown has no columns, so by default the code of each section follows its prose.
+With `-md-layout=table`, goweave writes the sections of a Markdown document as the rows
+of an HTML table, the prose in the left cell and the code in the right one. The cells
+start and end with blank lines, so that their content is still Markdown: renderers
+that allow inline HTML, like those of GitHub and GitLab, show the prose and the code
+side by side, with the usual syntax highlighting. The rows carry the section IDs as
+their `id` attributes, and `-section-ids` works as before. Hugo leaves out inline HTML
+unless the site sets `markup.goldmark.renderer.unsafe = true`. With `-intro`, there is
+no code to put beside the prose, so goweave warns and writes the intro as plain
+Markdown.
+
+### reStructuredText for Sphinx
+
+`-format=rst` writes reStructuredText documents, with the extension `.rst`, to include
+woven files in a Sphinx documentation tree. The comments are still Markdown; goweave
+turns their headings, emphasis, links, lists, tables, images, and code blocks into
+their reStructuredText counterparts. The code goes into `.. code-block::` directives
+for the language of the file, with the caption, if any, as `:caption:`. `-toc` adds a
+`.. contents::` directive, and the links to related pages and between the pages of
+`-split` use the `:doc:` role. Heading levels 1 to 6 get underlines of `=`, `-`, `~`,
+`^`, `"`, and `'`. Each section starts with a `.. _id:` label of its ID, which the
+links of cross-references and of `-pkg-summary` to the section refer to. Front matter,
+`-hugo`, `-md-layout`, and `-section-ids` are for Markdown only.
+
+### LaTeX
+
+`-format=tex` writes LaTeX documents, with the extension `.tex`, for papers and theses
+written as literate programs. Each document is an article that pdflatex compiles as it
+is. The comments become LaTeX: headings start at `\section` (shift them with
+`-heading-offset`) and get a `\label` of their ID, as do the sections, and links to
+headings and cross-references become `\hyperref` links. The code goes into
+`lstlisting` environments of the listings package, with a definition of Go in the
+preamble. pdflatex feeds listings UTF-8 byte by byte, so the preamble maps the common
+accented letters, quotes, dashes, arrows, and math signs of code to LaTeX; code with
+other characters needs `xelatex` or `lualatex`. `-latex-code=minted` uses the minted
+package instead, which highlights through Pygments and needs `pdflatex -shell-escape`.
+Captions become the captions of the listings, labeled like `listing-1`. `-toc` adds
+`\tableofcontents`, and `-listings` the list of listings. With `-bare`, goweave writes
+the body only, to `\input` it into a larger document, whose preamble then needs the
+packages of `LaTeXPreamble` in the weave package.
+
+### JSON for other tools
+
+`-format=json` writes what goweave makes of a source file as JSON, with the extension
+`.json`, for tools like custom renderers, search indexers, or editors that would
+otherwise have to split the source themselves. The document has the `source`, `title`,
+and `language` of the file and its `sections`, each with:
+
+* `id`, `anchor`, and `position`: The IDs of the section and its index in the source.
+* `doc` and `docHtml`: The comment as Markdown and as HTML.
+* `code` and `codeHtml`: The code as it is and highlighted.
+* `docStart`, `docEnd`, `codeStart`, and `codeEnd`: The lines of the comment and of
+  the code in the source.
+* `boilerplate` and `boilerplateHtml`: The package clause and the imports, with
+  `-boilerplate=collapse`.
+* `caption`, `status`, `order`, and `footnotes`: What the pragmas and `-footnotes`
+  attach to the section, if anything.
+
+The HTML is the same as in the HTML documents, with `-highlighter` and `-identifier-links`.
+Front matter, `-hugo`, `-md-layout`, and `-section-ids` do not apply.
+
+### Front matter for static site generators
+
+With `-frontmatter=yaml`, `-frontmatter=toml`, or `-frontmatter=json`, each Markdown
+document starts with front matter in the format that Hugo and Jekyll read, so that
+goweave can write straight into the content directory of a blog:
+
+        //meta: title=Building a Web Server in 50 Lines
+        //meta: date=2024-05-01
+        //meta: tags=go, http
+
+        goweave -md -frontmatter=yaml -outdir=content/posts server.go
+
+The front matter holds the title, the date, the slug, and the tags, from the pragmas of
+the same names, and the description, the aliases, and the draft flag if pragmas set them.
+The date defaults to the modification time of the source file, and the slug to the file
+name. The site's template shows the title then, so the document does not start with a
+heading of its own. The option needs `-md`.
+
+### Hugo page bundles
+
+`-hugo` turns a literate Go file into a blog post in one go. It takes the output
+directory for the root of a Hugo site, and writes each document as a page bundle,
+`content/posts/<slug>/index.md`:
+
+        goweave -hugo -outdir=~/blog server.go
+
+The slug is the one that the slug pragma sets, or else the file name, and
+`-hugo-section=<dir>` picks another section than `posts`. `-hugo` implies `-md`, and
+YAML front matter unless `-frontmatter` picks another format.
+
+goweave copies the images and other local files that comments link to into the page
+bundle, and changes the links to point there; files from outside the directory of the
+source file go to the top of the bundle. Links to other input files and
+cross-references become relative links to the pages of their bundles, like
+`../client/`. This assumes that the URLs of the pages follow the content directory,
+as they do unless the site configures other permalinks.
+
+### Related pages
+
+With `-related`, goweave compares all input files and links each document to the
+documents that are most similar to it. Files are similar if they share tags, imported
+packages, or words in their comments. Tags count most. To set the tags of a file,
+add a `//goweave:tags` pragma:
+
+        //goweave:tags http, middleware
+
+Drafts are never listed as related pages.
+
+### Canonical URLs and redirects
+
+With `-base-url`, each document gets a `<link rel="canonical">` element that points to
+the base URL plus the name of the document. A `//goweave:canonical` pragma sets the
+canonical URL of a single document explicitly.
+
+When a source file gets renamed, its document gets a new name, too, and links to the
+old name break. To avoid this, list the old names in an `//goweave:aliases` pragma:
+
+        //goweave:aliases old-name.html, articles/older-name.html
+
+goweave then writes a redirect page to each of these paths in the output directory.
+In Markdown mode, goweave writes no redirect pages.
+
+### Search engines
+
+To keep a document out of search engines, add a `//goweave:noindex` pragma to its
+source file. `-noindex` does the same for all documents. These documents get a
+`<meta name="robots" content="noindex">` tag.
+
+With `-robots`, goweave also writes a `robots.txt` file that disallows these
+documents, or the whole output directory with `-noindex`. Crawlers only read
+`robots.txt` at the root of a site; if the output directory is not the root, set
+`-base-url` so that the paths in `robots.txt` are right, and merge the file into the
+`robots.txt` of the site.
+
+### Translations
+
+To translate the comments of a source file, put the translation into a Markdown file
+next to the source file, named after the source file and the language, for example,
+`server.go.de.md`. Each translated comment starts with a marker that contains the
+section ID (see "Section IDs" above):
+
+        <!-- goweave:title Ein Webserver in 50 Zeilen -->
+
+        <!-- goweave:section 3f2a9c01b7de -->
+        Dieser Abschnitt startet den Server.
+
+goweave then also generates `server.de.html`, and adds links between all languages to
+each variant. Comments without a translation appear in the original language. If a
+section changes, its ID changes, too, and goweave warns that the translation is out of
+date.
+
+### Sidecar files
+
+Generated code and vendored third-party code cannot carry comments of their own, or
+rather, they lose them on the next update. Their documentation can live in a sidecar
+file instead: a Markdown file named after the source file plus `.weave.md`, like
+`parser.go.weave.md`. Markers attach each piece of prose to a range of lines or to a
+declaration:
+
+        The parser is generated by goyacc; do not edit parser.go.
+
+        <!-- goweave:lines 12-30 -->
+        The lexer hands the tokens over to the parser here.
+
+        <!-- goweave:symbol Parser.Next -->
+        Next is where the parser spends most of its time.
+
+Text before the first marker goes to the top of the document. Symbols are named like
+in cross-references: `FuncName`, `TypeName`, or `Type.Method`. goweave inserts the prose
+as comments before the lines or the declaration, so that it starts a section with that
+code; the prose about a declaration goes before its doc comment, which it extends. The
+code after a line range starts a new section. The source file stays as it is, and so
+does the plain source of -rawview. Markers that point to missing lines or symbols get a
+warning.
+
+### Includes
+
+An article about a program seldom finds all the code it talks about in one file. An
+include pragma pulls the lines of another file into the document, where the pragma
+stands, so that the article need not copy them:
+
+        //goweave:include store/cache.go#lookup
+
+The path is relative to the directory of the file with the pragma. The optional part
+after `#` names a region of the other file, the lines between two marker pragmas:
+
+        //goweave:start lookup
+        // Lookup returns the value of a key.
+        func (c *Cache) Lookup(key string) (Value, bool) {
+                ...
+        }
+        //goweave:end lookup
+
+Without a region, the whole file goes in; for Go files, which start with a package
+clause, regions are usually the better choice. The included lines weave as if they
+were written in place, in sections of their own: their comments in the language of the
+article are prose, and the rest is code. They get no line numbers or source links, as
+they have no lines in the article's source file, which stays as it is, like the plain
+source of -rawview. Included files can include others in turn. The markers do not show
+up in the output, and includes that point to missing files or regions, or in a circle,
+get a warning. In watch mode, changes to the included files update the article.
+`goweave unweave` leaves the prose of included lines alone; edit it in the included
+file.
+
+### Directories
+
+Instead of single files, you can pass directories to goweave. `goweave ./mypkg`
+processes all .go files in mypkg, and `goweave ./...` processes all .go files in the
+current directory and its subdirectories. Like the go tool, goweave skips `testdata`
+directories and directories whose names start with `.` or `_`. With `-skip-tests`, it
+also skips `_test.go` files, and with `-exclude`, the files that match one of the given
+patterns. A pattern without a slash, like `vendor` or `*_gen.go`, applies to the name of
+the file and of each directory above it; a pattern with a slash, like `internal/old`,
+applies to the path relative to the directory on the command line.
+
+The documents keep the relative paths of their source files under the output
+directory: `goweave -outdir=doc ./...` turns `a/b/c.go` into `doc/a/b/c.html`.
+
+If a file cannot be processed, goweave reports the error, continues with the other
+files, and finally exits with a list of the files that failed.
+
+### Project files
+
+A Go project is more than its .go files. With `-project-files`, goweave also picks up
+the `go.mod`, `go.sum`, and `go.work` files, Makefiles, and Dockerfiles in the
+directories it walks, and weaves them into pages of their own, with their comments as
+the prose:
+
+        goweave -project-files -outdir=doc ./...
+
+In go.mod files, the directives like `module` and `require` are highlighted as
+keywords, module paths as identifiers, and versions as literals; the same goes for the
+module paths, versions, and hashes of go.sum files. The documents of project files keep
+the full file name, like `go.mod.html` and `Makefile.html`, so that `go.mod` and
+`go.sum` do not end up in the same `go.html`. The index page lists them under "Project
+files", apart from the Go files, and they do not count toward the documentation
+coverage.
+
+### Git revisions
+
+`goweave -rev v1.2.3 ./...` reads the input files from the git object database instead
+of the working tree, as they were at the tag v1.2.3. Hence the documents of a release
+can be built from any checkout, dirty or not, without switching branches. -rev accepts
+anything that names a commit, like tags, branches, hashes, or `HEAD~3`, and looks it up
+in the repository that contains the current directory. Translations and the targets of
+cross-references come from the revision, too; images and other files that comments
+refer to come from the working tree. -rev cannot be combined with -watch.
+
+### Archives and modules
+
+goweave can weave code that is not checked out anywhere. Pass a `.zip`, `.tar.gz`, or
+`.tgz` archive, and goweave unpacks it and processes all .go files in it, like a
+directory. Pass a module version, like `goweave golang.org/x/text@v0.14.0` or
+`goweave golang.org/x/text@latest`, and goweave downloads the module from the module
+proxy first: the first proxy in the `GOPROXY` environment variable, or else
+proxy.golang.org. The documents keep the paths of the files within the archive or the
+module. goweave unpacks archives and modules into its cache directory, like
+`~/.cache/goweave`, and reuses them in later runs. Archives cannot be combined with -rev.
+
+### Output archives
+
+With an -outdir that ends in `.zip`, like `goweave -outdir=site.zip ./...`, goweave
+writes the documents, the CSS, the images, and everything else it generates into a zip
+archive rather than a directory. The archive is created anew in each run, so the build
+cache does not apply, and -watch and -serve need a directory.
+
+### PDF output
+
+For handouts, `goweave -pdf mycode.go` prints each document to a PDF file with the same
+name, like `mycode.pdf`, next to the HTML file. goweave leaves the printing to a Chrome
+or Chromium browser in headless mode, so the PDF looks just like the page on screen,
+with the rules of the `@media print` block of the CSS file applied: the default theme
+keeps each comment on the same page as its code, wraps long lines of code, and leaves
+out the buttons and the sidebar. goweave looks for the browser in the PATH and in the
+usual install locations; `-pdf-browser` names another one, like
+`-pdf-browser=/opt/chrome/chrome`.
+
+As the browser loads the document along with its CSS, fonts, and images from the output
+directory, -pdf does not work with output archives or with -md.
+
+### EPUB books
+
+For a book that spans many files, one per chapter, `-epub` bundles all documents into a
+single EPUB file that e-book readers can open:
+
+        goweave -epub=book.epub -epub-title="Literate Go" -epub-author="Jo Coder" chapters/
+
+The chapters appear in the order of their source paths, as on the index page, so
+numbered file names like `01-intro.go` and `02-types.go` put them in order. The book
+contains a table of contents that lists the chapters along with their `#` and `##`
+headings, and the CSS, fonts, and images that the documents use. Like any other
+option, the title and the author can go into the configuration file:
+
+        epub-title: Literate Go
+        epub-author: Jo Coder
+
+goweave writes the documents to the output directory as usual, then reads them back and
+converts them to XHTML without scripts, as EPUB requires, so -epub does not work with
+output archives or with -md. Links between the documents keep working in the book.
+
+### Images
+
+Comments can include images with the usual Markdown syntax:
+
+        // ![The request flow](img/flow.png)
+
+With `-images`, goweave copies these images into the output directory, next to the
+document, so that the output directory is ready to publish. PNG and JPEG images wider
+than `-max-image-width` get scaled down. goweave also adds `width`, `height`, and
+`loading="lazy"` attributes to the images. Images given as absolute paths or URLs are
+left alone.
+
+With `-inline-svg`, small SVG images become part of the document instead. Being part of
+the page, they can use its fonts and colors, for example, through `currentColor`.
+goweave removes scripts, event handlers, and links to anything outside the image from
+them, and restricts their style sheets to the image itself.
+
+### Diagrams
+
+Diagrams that live in the comments as text stay next to the code they show and change
+along with it. With `-diagrams`, fenced blocks of [Mermaid](https://mermaid.js.org) and
+[Graphviz](https://graphviz.org) become diagrams:
+
+        // ```mermaid
+        // graph LR
+        //     Client --> Server --> Database
+        // ```
+
+With `-diagrams=client`, the browser draws the Mermaid diagrams, with the module that
+`-mermaid-url` points to; documents load it only if they have Mermaid diagrams. With
+`-diagrams=svg`, goweave draws them when it weaves, with `mmdc` of the
+[Mermaid CLI](https://github.com/mermaid-js/mermaid-cli), and the documents need no
+script, which suits `-standalone`. Graphviz blocks, marked `dot`, become SVG when
+goweave weaves either way, with the `dot` command. The SVG becomes part of the document
+like that of `-inline-svg`, without scripts and event handlers. A missing command or a
+diagram that does not draw fails the document with the message of the tool. Documents
+of `-fragment` do not load Mermaid; the page that includes them has to. Markdown keeps
+the blocks as they are, which GitHub and many other renderers draw on their own.
+
+### Math
+
+With `-math`, comments can have formulas in TeX: `$...$` within the text and `$$...$$`
+on lines of their own.
+
+        // Merge sort takes $O(n \log n)$ time, as
+        //
+        // $$
+        // T(n) = 2T(n/2) + n
+        // $$
+
+Markdown leaves the formulas alone, so that `_` and `\` mean what they mean in TeX, and
+the browser typesets them with [KaTeX](https://katex.org). Documents load KaTeX only if
+they have formulas, from the directory that `-katex-url` points to. For documents that
+have to work offline, like those of `-standalone`, copy the `dist` directory of KaTeX
+into the output directory and set `-katex-url` to its path there, for example
+`-katex-url=katex`. Dollar signs in code, escaped ones, `\$`, and amounts like "$5 and
+$10" stay as they are. LaTeX output gets the formulas as they are, reStructuredText
+gets them as `math` roles and directives, and Markdown output keeps the dollar signs.
+`goweave unweave` turns the formulas of HTML documents back into dollar signs, whether
+or not it runs with `-math`.
+
+### Fonts
+
+`-prose-font` and `-code-font` replace the fonts of the theme without editing the CSS
+file. To make sure that readers see these fonts even if they do not have them installed,
+list their WOFF2 files in `-font-files`, or in the configuration file:
+
+        prose-font: Lato, Helvetica, sans-serif
+        code-font: '"Fira Code", monospace'
+        font-files: Lato=fonts/lato.woff2, Fira Code=fonts/firacode.woff2
+
+goweave copies these files into the `fonts` directory below the output directory, and
+declares them in each document; files of the same name from different directories get
+a number, like `lato-2.woff2`. With `-inline`, the fonts become part of each document
+instead, reduced to the glyphs of the characters of the document and of printable
+ASCII. goweave subsets them with `pyftsubset` of
+[fonttools](https://github.com/fonttools/fonttools) (`pip install fonttools brotli`);
+without it, goweave warns and embeds the whole files. The copies in the `fonts`
+directory stay whole, as all documents share them.
+
+### Standalone documents
+
+`-standalone` writes HTML documents that can be emailed or attached to a ticket as
+they are, with no files next to them. It turns on `-inline`, so that the CSS, the
+theme, and the fonts of `-font-files` become part of each document, and puts the script
+of the buttons there, too. The local images that comments refer to become data URIs,
+scaled down to `-max-image-width` as with `-images`; a missing image makes the build
+fail. Images given as URLs stay as they are. `-standalone` does not work with other
+formats than HTML, nor with `-copysrc`; `-rawview` includes the source instead.
+
+### Index page
+
+When goweave generates more than one HTML document, it also writes an `index.html`
+file into the output directory. The index page links to all documents, along with the
+first paragraph of each document as a summary. To change its layout, edit
+`goweave-index.templ` in the resource directory; if there is no such file, goweave uses
+its built-in index template. If one of the documents is named `index.html`, goweave
+writes no index page.
+
+### Assets
+
+Each output format declares the files that its documents refer to, and goweave copies
+exactly those into the output directory, and only if a document refers to them. HTML
+documents refer to the CSS file, the font files of `-font-files`, and `goweave.js`,
+the script of the buttons of `-rawview` and `-color-scheme=auto`. goweave copies the
+CSS and font files unless `-inline` puts them into the documents, or `-bare` leaves
+out the head that refers to them; it copies the script, next to the CSS file, only if
+a document has one of the buttons. Where a document cannot link to the script, the
+script goes into the document. Markdown, reStructuredText, LaTeX, and JSON documents
+refer to no files, so goweave copies nothing. A resource directory from before
+`goweave.js` works as it is; goweave then uses its built-in script.
+
+### Manifest
+
+With `-manifest`, goweave writes a `manifest.json` file that lists all generated
+documents, for use by search indexers, link checkers, and other tools:
+
+        {
+          "pages": [
+            {
+              "source": "mycode.go",
+              "output": "mycode.html",
+              "title": "mycode.go",
+              "summary": "The first paragraph of the first comment.",
+              "headings": ["The code", "Imports"],
+              "hash": "sha256:9f86d0..."
+            }
+          ]
+        }
+
+`output` is a URL path relative to the output directory, and `hash` is the hash
+of the source file. The pages of Go files also have a `package`, the import path of
+the package (or its name, outside of a module), and `symbols`, the exported
+declarations with the IDs of their sections:
+
+        "package": "example.com/mymod/mypkg",
+        "symbols": [{"name": "Client.Do", "anchor": "3f2a9c01b7de"}]
+
+### Portals
+
+`goweave portal` joins the sites of several repositories into a portal with one
+landing page:
+
+        goweave portal -outdir portal -title "ACME docs" ../api/docs ../cli
+
+Each argument is either the output directory of a run with `-manifest`, or the root
+directory of a module. goweave weaves a module into a subdirectory of the portal
+first, with all options that come before `portal` on the command line. `name=dir`
+sets the name of a site; the default is the module path, or the name of the
+directory. The landing page, `index.html`, lists the documents of all sites and has a
+search box that finds documents, headings, and exported identifiers, like
+`Client.Do`, across all sites, and links straight to the section that declares an
+identifier.
+
+The links of the portal are relative, so publish the sites at the same places
+relative to the portal directory.
+
+### Site report
+
+With -site-report, goweave prints a maintenance report after processing all files. It
+lists the pages that no chain of links leads to from the index page, as well as dead
+references: cross-references that cannot be resolved, images that do not exist, and
+links to documents that are neither generated in the same run nor present in the output
+directory.
+
+The index page is the document named `index.html` (`index.md` with -md), or else the
+index page that goweave writes. As the latter links to every page, orphaned pages only
+show up with -index=false or with an index page of your own.
+
+### Documentation coverage
+
+goweave considers a code section documented if the comment above it has at
+least three words. The documentation coverage is the fraction of documented
+code sections. `-coverage` prints it for each file, each package (that is,
+each directory), and in total. Use `-min-doc-coverage` to let builds fail when
+the documentation falls behind.
+
+### Linting
+
+`goweave lint` checks the comments of source files without weaving them:
+
+        goweave lint -max-code-lines 40 ./...
+
+It knows three rules, which `-rules` picks, separated by commas; the default is all:
+
+* `long-code`: A section has more than `-max-code-lines` lines of code (30 by default),
+  not counting the package clause and the imports, and too little prose to count as
+  documented (see "Documentation coverage").
+* `stale-ident`: The prose of a Go file has a code span that looks like an identifier,
+  like `ParseFile`, `maxLen`, `run()`, or `Client.Do`, but nothing in the package of the
+  file has that name. Plain lowercase words, names in capitals, and selectors on
+  imported packages or on lowercase names, like `robots.txt`, do not count.
+* `heading-level`: A heading is more than one level below the one before it, like a
+  `####` after a `##`.
+
+Each problem goes to standard output as `file:line: message (rule)`, the format of
+compilers and `go vet`, and goweave exits with an error if there are any. Global
+options that decide what the sections are, like `-prose-marker`, go before `lint`.
+
+### Template functions
+
+Custom templates can pull highlighted code from other files with the `code`
+function. It takes a file name (relative to the current directory) and a
+line range; an end line of 0 means "until the end of the file":
+
+        <pre><code>{{code "path/to/file.go" 10 42}}</code></pre>
+
+The file name can also be an HTTP or HTTPS URL, for an example that lives
+elsewhere. goweave downloads the file and keeps a copy in its cache directory.
+To make builds reproducible, pin the content with its SHA-256 hash in the
+fragment of the URL:
+
+        {{code "https://example.com/hello.go#sha256=9f86d081...0f00a08" 1 0}}
+
+The hash must be the full hash in hex. goweave then takes the file from the
+cache, so the build works offline after the first run, and it fails if a
+download has a different hash. A URL without a hash gets downloaded on every
+run, and goweave logs the hash to pin it with.
+
+The other functions are:
+
+* `markdown`: renders a string of Markdown as HTML, like a comment.
+* `relpath`: the relative link from one file of the output directory to another.
+  `.Path` is the path of the document, so this links to a page at the top of the
+  output directory from any document:
+
+        <a href="{{relpath .Path "about.html"}}">About</a>
+
+* `now`: the current time, as in `{{now.Format "2006-01-02"}}`. A document that shows
+  the time changes with every run, so the build cache does not notice when it is out
+  of date; use -force.
+* `slugify`: turns a text into an ID, the way goweave derives the IDs of headings.
+
+### Template data
+
+`-template=<file>` customizes the documents without copying the whole resource
+directory. The file can consist of definitions only, like
+`{{define "sections"}}...{{end}}`, which then replace the definitions of the same
+names in goweave.templ, or it can have a body of its own, which then replaces the body
+of goweave.templ. goweave.templ defines `sections`, which renders the sections, and
+`fragment`, the body of -fragment documents.
+
+Templates see these fields of the document:
+
+* `.Filename`, `.Title`: the name of the source file and the title of the document.
+  `.ShowTitle` is true if the title was set explicitly.
+* `.Path`: the path of the document within the output directory, and `.Root`, the
+  relative path from the document to the output directory.
+* `.CssPath`, `.Style`, `.InlineCSS`, `.FontCSS`, `.HighlightCSS`: the link to the
+  CSS file, or with -inline, the CSS rules.
+* `.Full`: true unless -bare is set.
+* `.ColorScheme`, `.ColorSchemeToggle`: the palette of -color-scheme, and whether the
+  document gets a button to switch palettes.
+* `.ScriptPath`, `.Script`: the link to `goweave.js`, the script of the buttons, or
+  where a document cannot link to it, the script itself; both are empty if the
+  document has no buttons.
+* `.MermaidURL`: the Mermaid module of `-diagrams=client`, if the document has Mermaid
+  diagrams.
+* `.MathURL`: the KaTeX directory of `-math`, if the document has formulas.
+* `.Pragmas`: the front matter of the source file, as a map.
+* `.WrapperClass`: the class of the element that wraps a fragment.
+* `.Permalinks`: true if the sections get a "¶" link.
+* `.SourceLink`, `.RawSource`: the link to the copy of -copysrc, and the highlighted
+  source of -rawview.
+* `.Listings`: the captions of -listings.
+* `.TOC`, `.TOCStyle`: the headings of -toc, each with `.Level`, `.Text`, `.ID`, and
+  `.Href`, and where the table of contents goes.
+* `.Prev`, `.Next`: the pages before and after this one, if -split splits the
+  document, each with `.Title` and `.Href`.
+* `.Related`: the pages of -related, each with `.Title` and `.Href`.
+* `.Canonical`, `.NoIndex`: the canonical URL, and whether search engines should skip
+  the document.
+* `.Lang`, `.Languages`: the language of the comments, and the translations, each with
+  `.Lang`, `.Href`, and `.Current`.
+* `.SrcLinks`, `.LineNums`: the links of -srclink-base and the line numbers of
+  -linenums, by section ID.
+* `.Sections`: the sections, in the order of the document.
+
+Each section has these fields:
+
+* `.Doc`, `.Code`, `.Boilerplate`: the rendered comment, the highlighted code, and the
+  package clause and imports that -boilerplate splits off.
+* `.ID`, `.Anchor`: the section ID, and the readable ID from the nearest heading.
+* `.Caption`: the caption of the code, if any, with `.Kind`, `.Number`, `.Text`,
+  `.Label`, and `.ID`.
+* `.Footnotes`: the footnotes of -footnotes, each with `.Number`, `.Text`, `.ID`, and
+  `.RefID`.
+* `.Status`, `.Order`: the review status and the order key from the pragmas.
+* `.Position`: the index of the section in the source.
+* `.DocStart`, `.DocEnd`, `.CodeStart`, `.CodeEnd`: the first and the last line of the
+  comment and of the code in the source, counting from 1, or 0 if the section has no
+  comment or no code.
+* `.DocOffset`, `.DocEndOffset`, `.CodeOffset`, `.CodeEndOffset`: the same as byte
+  offsets, from the first byte to the byte after the last line.
+* `.SkippedLines`: the lines between `.CodeStart` and `.CodeEnd` that the code leaves
+  out, like directives.
+
+With the line numbers, a template can link each section to its source on GitHub:
+
+        <a href="https://github.com/me/project/blob/main/{{$.Filename}}#L{{.CodeStart}}-L{{.CodeEnd}}">source</a>
+
+The positions refer to the source file without the prose of a sidecar file. The tables
+of -schema and -const-tables have none.
+
+### Themes
+
+goweave.css sets the layout and the colors of the documents. A theme changes the look
+without repeating the layout: it is a CSS file whose rules goweave appends to
+goweave.css, where they override the rules before them. goweave comes with four themes:
+
+* `classic`: the look of the original docco, with serif prose and code on a pale blue
+  background.
+* `dark`: light text on dark backgrounds.
+* `high-contrast`: black on white, with syntax colors that pass the WCAG AAA contrast
+  ratio of 7:1.
+* `print`: black on white with a serif font, for paper and PDF files.
+
+`-theme=<name>` selects one; the CSS file in the output directory, or the inline styles
+with -inline, are then goweave.css followed by the theme:
+
+        goweave -theme=dark -outdir=doc ./...
+
+To make a theme of your own, put a CSS file into the `themes` directory of the resource
+directory, like `goweave/resources/themes/company.css`, and select it with
+`-theme=company`. A file there with the name of a bundled theme replaces that theme.
+`-list-themes` prints the names of all themes.
+
+### Dark mode
+
+The colors of goweave.css are CSS custom properties of the root element, like
+`--goweave-background`, `--goweave-text`, or `--goweave-keyword`, in a light and a dark
+palette. The documents follow the reader's system setting through the
+`prefers-color-scheme` media query. Complete documents, and the index page, also get a
+button in the bottom right corner that switches between the palettes; the browser
+remembers the choice for all documents of the site, in local storage. Printed documents
+always use the light palette.
+
+`-color-scheme=light` or `-color-scheme=dark` fixes the palette and leaves out the
+button. A theme fixes the palette, too: the bundled themes set the custom properties for
+both palettes, and a theme of your own should do the same, with a `:root` rule that
+comes after those of goweave.css. With `-highlighter chroma`, the code gets the colors
+of `-chroma-dark-style` in the dark palette.
+
+### Checking themes
+
+`goweave theme lint` checks the contrast of the text colors of the CSS file in the
+resource directory, along with -theme, against their backgrounds. `goweave theme lint <file.css>` checks
+another CSS file. It resolves `var()` through the custom properties of `:root`, and checks
+the dark palette, too, unless a theme fixes the palette. It lists each pair of colors with its contrast ratio, and fails if any
+pair is below 4.5:1, the minimum that the Web Content Accessibility Guidelines (WCAG)
+require at level AA for normal text. The syntax colors of the default theme are
+lighter than that; use the check to make your own theme easier to read.
+
+### Checking templates
+
+A broken template shows only when a document reaches the broken part, which may be in
+the middle of a long build. `goweave template check` checks the templates of the
+resource directory, along with -template, before that happens, and
+`goweave template check <dir>` checks those of another directory, like a theme in the
+making:
+
+        goweave template check ./mytheme
+
+It follows every field in every branch of goweave.templ and goweave-index.templ through
+the data of "Template data" above, and lists the fields that do not exist, with file,
+line, and column, along with calls of templates that are not defined. If the fields
+check out, it executes the templates with a sample document, once with every field
+set and once with the fields empty, and lists the errors, like calling a function with
+the wrong number of arguments. It fails if it finds any problem.
+
+### Self-test
+
+`goweave selftest` weaves a bundled set of tricky source files, with block comments,
+strings that contain `//`, a cgo preamble, generics, and assorted Markdown, using the
+current template, CSS, and options. It fails if a document has tags that are not
+closed in order, or is missing a section. `goweave selftest -update` stores the
+documents as snapshots in `goweave/selftest`, and later runs compare against them and
+show the first line that changed. A directory after the options sets another place for
+the snapshots. If you work on a theme, store the snapshots while it works, and run the
+self-test after each change.
+
+### Using goweave as a library
+
+The core of goweave is the package `github.com/christophberger/goweave/weave`. Other
+tools, like static site generators or doc servers, can use it to weave Go source code
+without running goweave:
+
+	html, err := weave.New(weave.Options{HeadingOffset: 1}).Render(src)
+
+`Render` returns an HTML fragment like -fragment does. `weave.Options` holds the
+settings that correspond to -heading-offset, -max-heading, -intro, and -wrapper-class,
+and can replace the fragment template. `Options.Links` lets the caller link the
+identifiers of Go code that the code does not declare itself. The package also exports the individual steps:
+splitting the source into sections, assigning section IDs, highlighting the code, and
+rendering the comments. Everything else, like front matter, images, or the index page,
+stays with the goweave command.
+
+The sources and templates do not have to live in the OS file system. `RenderFile` and
+`RenderFS` read sources from any `fs.FS`, like an `embed.FS`, a `zip.Reader`, or a
+virtual file system, and pick the language of each file by its extension.
+`ParseTemplateFS` loads a template, like goweave's own `goweave.templ`, from an `fs.FS`:
+
+	tpl, err := weave.ParseTemplateFS(resources, "goweave.templ")
+	w := weave.New(weave.Options{Template: tpl})
+	err = w.RenderFS(sources, ".", func(name, html string) error {
+		return publish(name, html)
+	})
+
+On the output side, `weave.OutputFS` is anything that files can be written to.
+`weave.Dir` writes into a directory, `weave.MemFS` keeps the files in memory, which
+makes for hermetic tests, and `weave.NewZipFS` writes a zip archive. An upload target
+only needs a `WriteFile` method and an `Open` method. `WeaveFS` weaves all files of an
+`fs.FS` into an `OutputFS`:
+
+	var out weave.MemFS
+	err := weave.New(weave.Options{}).WeaveFS(sources, ".", &out)
+
+Tools that offer customizable resources, like goweave's template and CSS, can find them
+with `FindResources`. It returns the files of the first directory that has them, or the
+built-in defaults, which it uses in memory unless `ResourceOptions.Install` names a
+directory to write them to:
+
+	res, dir, err := weave.FindResources(weave.ResourceOptions{
+		Dirs:     []string{"theme", userConfigDir},
+		Marker:   "goweave.templ",
+		Defaults: builtin,
+	})
+
+Without `Defaults`, the defaults are goweave's own resource files, which
+`weave.Resources()` returns as an `fs.FS`: `goweave.css`, `goweave.templ`,
+`goweave-index.templ`, `goweave.js`, `goweave-changes.js` (the change markers of the
+preview server), and the themes, like `themes/dark.css`. A server can hand them out as
+they are:
+
+	http.Handle("/goweave/", http.StripPrefix("/goweave/", http.FileServer(http.FS(weave.Resources()))))
+
+Chat bots, editors, and wikis that show one symbol at a time get its woven view from
+`WeaveDecl`. It finds the declaration in a package, given by import path or directory,
+and returns a `Fragment`: the HTML of the declaration with its doc comment, along with
+its file, its lines, its source, and its sections. Methods go by their type, like
+`Weaver.Render` or `(*Weaver).Render`; a constant or variable brings its whole block.
+`Weaver.WeaveDecl` does the same with the options of a Weaver.
+
+	f, err := weave.WeaveDecl("github.com/christophberger/goweave/weave", "New")
+
+A highlighter turns code into HTML, through the `weave.Highlighter` interface, so
+goweave can use litebrite or Chroma, and other tools can plug in their own. For
+features that work line by line, the highlighted code also comes as lines of tokens,
+each token with its text and class: `weave.HighlightLines(h, code, lang)` returns them.
+A highlighter that tokenizes in the first place, like Chroma, implements
+`weave.LineHighlighter` and returns the lines directly; the HTML of the others gets
+parsed. `Options.LineHook` gets called with each line of the code of every section, and
+can give the line an ID or a class, or change its tokens, without taking the HTML apart:
+
+	w := weave.New(weave.Options{LineHook: func(s *weave.Section, l *weave.Line) {
+		l.ID = fmt.Sprintf("L%d", s.CodeStart+l.Number-1)
+	}})
+
 
 ## Origins
 
@@ -108,11 +1615,14 @@ the `LICENSE.txt` file.
 
 The original docgo code is copyright 2012 by Daniel Connelly. See `LICENSE_godoc`.
 
-See these files for the licenses of litebrite, blackfriday, and the CopyFile function
-from github.com/pkg/fileutils/copy.go:
+See these files for the licenses of litebrite, blackfriday, Chroma, go-git, the TOML
+package, and the CopyFile function from github.com/pkg/fileutils/copy.go:
 
 * LICENSE_litebrite.md
 * LICENSE_blackfriday.txt
+* LICENSE_chroma.txt
+* LICENSE_go-git.txt
+* LICENSE_toml.txt
 * LICENSE_CopyFile.txt
 */
 
@@ -125,40 +1635,123 @@ package main
 import (
 	"bytes"
 	"flag"
+	"fmt"
+	"html/template"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
-	"regexp"
+	"runtime"
 	"strings"
-	"text/template"
+	"sync"
+	"text/template/parse"
+	"time"
+	"unicode/utf8"
 
-	"github.com/dhconnelly/litebrite"
+	"github.com/christophberger/goweave/weave"
 	"github.com/russross/blackfriday"
 )
 
 var (
-	style            string
+	style            template.CSS
 	templ            *template.Template // html template for generated docs
-	commentPtrn      = `^\s*//\s?`
-	commentStartPtrn = `^\s*/\*\s?`
-	commentEndPtrn   = `\s?\*/\s*$`
-	directivePtrn    = `^//go:`
-	comment          = regexp.MustCompile(commentPtrn)      // pattern for single-line comments
-	commentStart     = regexp.MustCompile(commentStartPtrn) // pattern for /* comment delimiter
-	commentEnd       = regexp.MustCompile(commentEndPtrn)   // pattern for */ comment delimiter
-	directive        = regexp.MustCompile(directivePtrn)    // pattern for //go: directive, like //go:generate
-	allCommentDelims = regexp.MustCompile(commentPtrn + "|" + commentStartPtrn + "|" + commentEndPtrn)
 	outdir           = flag.String("outdir", ".", "output directory for html & css")
 	resdir           = flag.String("resdir", "", "directory containing CSS and templates")
 	csspath          = flag.String("csspath", "", "relative path to CSS file, for use with the <link> element")
 	md               = flag.Bool("md", false, "generate Markdown document (default: HTML)")
 	bare             = flag.Bool("bare", false, "generate the HTML body only")
+	fragment         = flag.Bool("fragment", false, "generate the sections only, for embedding into other pages")
+	wrapperClass     = flag.String("wrapper-class", "goweave", "class of the element that wraps a fragment")
 	inline           = flag.Bool("inline", false, "generate inline CSS")
+	standalone       = flag.Bool("standalone", false, "generate HTML documents without external files: inline CSS, fonts, script, and images")
 	installResources = flag.Bool("install", false, "install resource files into .config/goweave")
+	title            = flag.String("title", "", "document title (single input file only; default: the file name)")
 	intro            = flag.Bool("intro", false, "Only process the first comment section (that should contain some intro text).")
+	jobs             = flag.Int("jobs", runtime.GOMAXPROCS(0), "number of files to process in parallel")
+	watch            = flag.Bool("watch", false, "keep running and regenerate documents whenever their input files change")
+	headingOffset    = flag.Int("heading-offset", 0, "add this number to the level of each Markdown heading")
+	maxHeading       = flag.Int("max-heading", 6, "render deeper Markdown headings at this level")
+	baseURL          = flag.String("base-url", "", "the URL of the output directory when published, for canonical links")
+	noindex          = flag.Bool("noindex", false, "ask search engines not to index any document")
+	writeRobots      = flag.Bool("robots", false, "write a robots.txt file that keeps crawlers away from noindex documents")
+	lang             = flag.String("lang", "en", "the language of the comments in the source files")
+	skipTests        = flag.Bool("skip-tests", false, "skip _test.go files in directories")
+	exclude          = flag.String("exclude", "", "comma-separated patterns of files to skip in directories")
+	rev              = flag.String("rev", "", "read the input files from this git revision instead of the working tree")
+	strict           = flag.Bool("strict", false, "fail documents with cross-references that cannot be resolved")
+	listings         = flag.Bool("listings", false, "add a list of the captioned code blocks to each document")
+	toc              = flag.Bool("toc", false, "add a table of contents built from the # and ## headings")
+	tocStyle         = flag.String("toc-style", tocTop, "where the table of contents goes: top or sidebar")
+	related          = flag.Bool("related", false, "link each document to the most similar documents")
+	drafts           = flag.Bool("drafts", false, "also generate documents marked as drafts")
+	boilerplate      = flag.String("boilerplate", boilerplateShow, "show, collapse, or hide the package clause and imports")
+	copyImages       = flag.Bool("images", false, "copy the images that comments refer to into the output directory")
+	maxImageWidth    = flag.Int("max-image-width", 1280, "with -images, scale down wider PNG and JPEG images to this width (0: never)")
+	diagrams         = flag.String("diagrams", diagramsOff, "draw the mermaid and dot blocks of comments: off, client (Mermaid in the browser), or svg (at weave time)")
+	mermaidURL       = flag.String("mermaid-url", "https://cdn.jsdelivr.net/npm/mermaid@11/dist/mermaid.esm.min.mjs", "the Mermaid module that documents load with -diagrams=client")
+	texMath          = flag.Bool("math", false, "typeset the TeX of $...$ and $$...$$ in comments with KaTeX")
+	katexURL         = flag.String("katex-url", "https://cdn.jsdelivr.net/npm/katex@0.16.11/dist", "the KaTeX directory that documents load with -math, a URL or a path within the output directory")
+	inlineSVG        = flag.Int64("inline-svg", 0, "inline SVG images up to this many bytes into the document (0: never)")
+	proseFont        = flag.String("prose-font", "", "CSS font stack for the comments")
+	codeFont         = flag.String("code-font", "", "CSS font stack for the code")
+	fontFileList     = flag.String("font-files", "", "WOFF2 files to ship with the documents, as family=file.woff2, ...")
+	highlighterName  = flag.String("highlighter", "litebrite", "syntax highlighter: litebrite or chroma")
+	chromaStyle      = flag.String("chroma-style", "github", "color style for -highlighter=chroma")
+	chromaDarkStyle  = flag.String("chroma-dark-style", "monokai", "color style for -highlighter=chroma in the dark palette (empty: use -chroma-style)")
+	colorScheme      = flag.String("color-scheme", schemeAuto, "the palette of the documents: auto, light, or dark")
+	theme            = flag.String("theme", "", "a theme to add to goweave.css, like classic or dark; see -list-themes")
+	printThemes      = flag.Bool("list-themes", false, "print the names of the themes and exit")
+	printCSS         = flag.Bool("print-highlight-css", false, "print the CSS rules of -chroma-style and exit")
+	rawView          = flag.Bool("rawview", false, "add a button that toggles between the woven document and the plain source")
+	copySource       = flag.Bool("copysrc", false, "copy the source files to the output directory and link to them")
+	postprocess      = flag.String("postprocess", "", "command that each generated document gets piped through")
+	writeIndex       = flag.Bool("index", true, "write an index.html file that links to all documents, if there is more than one")
+	indexTitle       = flag.String("index-title", "Contents", "the title of the index page")
+	writeManifest    = flag.Bool("manifest", false, "write a manifest.json file describing all generated documents")
+	siteReport       = flag.Bool("site-report", false, "report orphaned pages and references to missing targets")
+	showCoverage     = flag.Bool("coverage", false, "print the documentation coverage of all files")
+	writeBadge       = flag.Bool("badge", false, "write an SVG badge showing the documentation coverage")
+	minCoverage      = flag.Float64("min-doc-coverage", 0, "fail if the total documentation coverage is below this fraction (0..1)")
+	maxSize          = flag.Int64("maxsize", 1<<20, "skip source files larger than this many bytes (0: no limit)")
+	force            = flag.Bool("force", false, "regenerate all documents, even those that the build cache finds up to date")
+	projectFiles     = flag.Bool("project-files", false, "also weave go.mod, go.sum, Makefiles, and Dockerfiles found in directories")
+	pdf              = flag.Bool("pdf", false, "also print each document to a PDF file, through a headless Chrome or Chromium")
+	pdfBrowser       = flag.String("pdf-browser", "", "the browser that prints the documents for -pdf; defaults to the first one found")
+	epub             = flag.String("epub", "", "bundle all documents into this EPUB file")
+	epubTitle        = flag.String("epub-title", "", "the title of the -epub book; defaults to the name of the file")
+	epubAuthor       = flag.String("epub-author", "", "the author of the -epub book")
+	identLinks       = flag.Bool("identifier-links", true, "link identifiers in Go code to their declarations in other input files")
+	footnotes        = flag.Bool("footnotes", false, "move the comments at the ends of lines of Go code into numbered footnotes next to the code")
+	permalinks       = flag.Bool("permalinks", true, "add a ¶ link to each section, so that readers can link to it")
+	sourceOrder      = flag.Bool("source-order", false, "keep the sections in source order, ignoring //goweave:order pragmas")
+	frontMatterFmt   = flag.String("frontmatter", "", "start Markdown documents with front matter for static site generators: yaml, toml, or json")
+	stdinName        = flag.String("stdin-name", "", "the file name of the source that goweave - reads from standard input")
+	proseMarker      = flag.String("prose-marker", "", "take only the line comments that start with this marker, like : for //:, as prose")
+	reviewReport     = flag.Bool("status-report", false, "write status-report.html, which lists the sections by their //goweave:status pragmas")
+	sectionIDs       = flag.Bool("section-ids", false, "mark the sections of Markdown documents with their IDs, for goweave unweave")
+	hugo             = flag.Bool("hugo", false, "write the Markdown documents as page bundles of a Hugo site in the output directory, like content/posts/<slug>/index.md")
+	hugoSection      = flag.String("hugo-section", "posts", "the section of the Hugo site that -hugo writes to")
+	templateFile     = flag.String("template", "", "a template file that replaces goweave.templ, or some of its definitions")
+	webhookSecret    = flag.String("webhook-secret", "", "accept GitHub and GitLab push events with this secret at /_goweave/webhook of -serve, and pull and rebuild")
+	schema           = flag.Bool("schema", false, "add a table of the fields beneath each struct with json or yaml tags")
+	constTables      = flag.Bool("const-tables", false, "add a table of the names and values beneath each const block")
+	interfaces       = flag.Bool("interfaces", false, "write interfaces.html, a matrix of the types of the Go input files and the interfaces that they implement")
+	srcLinkBase      = flag.String("srclink-base", "", "link each code block to its lines in the repository, with a URL pattern like https://github.com/me/repo/blob/main/{file}#L{line}-L{end}")
+	lineNums         = flag.Bool("linenums", false, "number the lines of the code blocks as in the source files")
+	keepDirectives   = flag.Bool("keep-directives", false, "keep directives like //go:generate and build constraints in the code")
+	byDecl           = flag.Bool("by-decl", false, "make one section per declaration of Go files, with a heading, in the order of godoc")
+	balanceLines     = flag.Int("balance", 0, "split sections whose code runs on for more than this many lines past their prose, or the other way round (0: never)")
+	split            = flag.Int("split", 0, "split the document of a file into pages at the headings of this level or above: 1 for #, 2 for # and ##")
+	pkgSummary       = flag.Bool("pkg-summary", false, "start Go documents with a summary of the package: import path, synopsis, and links to the exported identifiers")
+	generate         = flag.Bool("generate", false, "run as a go:generate command: weave $GOFILE unless given files, take -outdir relative to the module root, and stay quiet")
+	format           = flag.String("format", formatHTML, "the format of the documents: html, md (the same as -md), rst for reStructuredText, tex for LaTeX, or json for the sections as JSON")
+	latexCode        = flag.String("latex-code", latexListings, "the LaTeX package for the code of -format=tex: listings or minted")
+	tokenClassList   = flag.String("token-classes", "", "rename the classes of the tokens of the code, for the stylesheet of another highlighter: highlightjs, prism, or class=new class, ...")
+	mdLayout         = flag.String("md-layout", mdLayoutPlain, "the layout of Markdown documents: plain, with the code beneath the prose, or table, with prose and code side by side in an HTML table")
 	cssfilename      = "goweave.css"
 	tplfilename      = "goweave.templ"
 	configDir        = filepath.Join(getHomeDir(), ".config", "goweave")
@@ -167,254 +1760,560 @@ var (
 
 // ### Generating documentation
 //
+// The documents are rendered through html/template, so that file names and
+// other plain strings cannot break the generated HTML. Rendered Markdown and
+// highlighted code are the only values that the template receives as trusted
+// HTML; see weave.HTMLSection.
 type docs struct {
 	Filename  string
-	Sections  []*section
-	CssPath   string
-	Style     string
+	Title     string // the document title; defaults to the file name
+	ShowTitle bool   // true if Title was set explicitly and should appear as a page header
+	Sections  []weave.HTMLSection
+	CssPath   urlPath
+	Root      urlPath // the output directory, relative to the document
+	Path      urlPath // the document, relative to the output directory
+	source    string  // the path of the source file, for -images
+	outname   string  // the path of the document, for -images
+	Style     template.CSS
+	FontCSS   template.CSS // the rules for the font options
 	Full      bool
 	InlineCSS bool
+	// HighlightCSS holds the rules for the color style of -highlighter=chroma.
+	HighlightCSS template.CSS
+	// ColorScheme is the palette that -color-scheme fixes, if any, and
+	// ColorSchemeToggle adds the button that switches between the palettes.
+	ColorScheme       string
+	ColorSchemeToggle bool
+	// ScriptPath links to the script of the buttons, if the document has
+	// any, or Script is the script itself, if it cannot be linked.
+	ScriptPath urlPath
+	Script     template.JS
+	// MermaidURL is the script of -diagrams=client, if the document has
+	// Mermaid diagrams for it to draw.
+	MermaidURL string
+	// MathURL is the KaTeX directory of -math, if the document has
+	// formulas for it to typeset.
+	MathURL string
+	// Pragmas is the front matter of the source file, for -frontmatter.
+	Pragmas frontMatter
+	// WrapperClass is the class of the element that wraps a fragment.
+	WrapperClass string
+	// Permalinks adds a link to each section's anchor, if -permalinks is
+	// set.
+	Permalinks bool
+	// SourceLink points to the copy of the source file, if -copysrc is set.
+	SourceLink urlPath
+	// RawSource is the complete, highlighted source file, if -rawview is set.
+	RawSource template.HTML
+	// Listings are the captions of the code blocks, if -listings is set.
+	Listings []*weave.Caption
+	// TOC lists the headings of the comments, if -toc is set, and TOCStyle
+	// says where the list goes.
+	TOC      []tocEntry
+	TOCStyle string
+	// Related lists the most similar documents, if -related is set.
+	Related []relatedPage
+	// Prev and Next link to the pages before and after this one, if -split
+	// splits the document.
+	Prev, Next *splitLink
+	// Canonical is the canonical URL of the document, if known.
+	Canonical string
+	// NoIndex asks search engines not to index the document.
+	NoIndex bool
+	// Lang is the language of the comments, and Languages links to all
+	// variants of the document, if there are translations.
+	Lang      string
+	Languages []language
+	// SrcLinks are the links of -srclink-base to the code of the sections
+	// in the hosting repository, by section ID.
+	SrcLinks map[string]string
+	// LineNums are the line numbers of -linenums next to the code of the
+	// sections, one per line, by section ID.
+	LineNums map[string]string
 }
 
-type section struct {
-	Doc  string
-	Code string
+// section is a comment group and the code that follows it. The weave
+// package does the actual work of splitting and rendering sections.
+type section = weave.Section
+
+// bufPool recycles the buffers that documents get rendered into. When
+// weaving thousands of files in one run, this saves allocating (and
+// collecting) a fresh, ever-growing buffer for each of them.
+var bufPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer fetches an empty buffer from bufPool. Return it with
+// bufPool.Put() once its contents have been copied out.
+func getBuffer() *bytes.Buffer {
+	b := bufPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
 }
 
 // Extract comments from source code, pass them through markdown, highlight the
 // code, and render to a string.
-func generateDocs(title, src string) (result string) {
-	sections := extractSections(src)
+func generateDocs(title, src string) string {
+	result, err := renderDocs(docs{Filename: title, Title: title}, extractSections(title, src))
+	if err != nil {
+		panic(err.Error())
+	}
+	return result
+}
 
+// renderDocs renders the sections of a source file into a document. The
+// caller sets up the document properties in d; the rest is filled in here.
+// Note that this modifies the sections in place.
+func renderDocs(d docs, sections []*section) (result string, err error) {
+	if (*schema || *constTables) && !*intro && sourceLanguage(d.Filename) == weave.Go {
+		sections = tableSections(sections)
+	}
+	weave.AssignIDs(sections)
+	if *pkgSummary && !*intro && d.Prev == nil && sourceLanguage(d.Filename) == weave.Go {
+		sections = summarySections(sections, d.source, d.outname)
+	}
+	d.Path = urlPath(d.outname)
+	if d.source != "" {
+		if err := resolveRefs(sections, d.source, d.outname); err != nil {
+			return "", err
+		}
+	}
+	if *toc && d.TOC == nil {
+		d.TOC, d.TOCStyle = tableOfContents(sections), *tocStyle
+	}
 	if !*md {
-		highlightCode(sections)
-		markdownComments(sections)
-		var b bytes.Buffer
-		cleanCssPath := ""
-		if len(*csspath) > 0 {
-			cleanCssPath = path.Clean(*csspath) + string(os.PathSeparator)
-		}
-		// Now apply the template.
-		err := templ.Execute(&b, docs{title, sections, cleanCssPath + cssfilename, style, !*bare, *inline})
+		d.CssPath = d.Root + cssHref()
+		d.Style = style
+		d.FontCSS, err = fontCSS(d.Root, documentText(d, sections))
 		if err != nil {
-			panic(err.Error())
+			return "", err
+		}
+		d.HighlightCSS = highlightCSS()
+		d.Full = !*bare
+		d.InlineCSS = *inline
+		d.ColorScheme, d.ColorSchemeToggle = fixedColorScheme(), colorSchemeToggle(d.Full)
+		d.ScriptPath, d.Script = pageScript(d.Root, d.Full)
+		name := tplfilename
+		if *fragment {
+			name = "fragment"
 		}
-		result = b.String()
+		result, err = renderHTML(name, d, sections)
+	} else if *format == formatRST {
+		result = renderRST(d, sections)
+	} else if *format == formatTeX {
+		result = renderLaTeX(d, sections)
+	} else if *format == formatJSON {
+		result, err = renderJSON(d, sections)
 	} else {
+		if *boilerplate == boilerplateHide {
+			splitBoilerplate(sections)
+		}
+		if *hugo && d.source != "" {
+			if err := bundleLinks(sections, d.source, d.outname); err != nil {
+				return "", err
+			}
+		}
+		captions := weave.NumberCaptions(sections)
 		if !*intro { // Skip this if rendering the intro text only, to avoid an empty code block in the output.
-			markdownCode(sections)
+			markdownCode(sections, sourceLanguage(d.Filename).Name)
+		}
+		if *sectionIDs {
+			markSections(sections)
+		}
+		if *mdLayout == mdLayoutTable && !*intro {
+			result = markdownTOC(d.TOC) + tableLayout(sections)
+		} else {
+			result = markdownTOC(d.TOC) + joinSections(sections)
+		}
+		if d.ShowTitle && *frontMatterFmt == "" {
+			result = "# " + d.Title + "\n\n" + result
+		}
+		if *listings && len(captions) > 0 {
+			result += "\n## Listings\n\n"
+			for _, c := range captions {
+				result += "* " + c.Label() + ": " + c.Text + "\n"
+			}
+		}
+		result += markdownPageLinks(d.Prev, d.Next)
+		if len(d.Related) > 0 {
+			result += "\n## Related\n\n"
+			for _, r := range d.Related {
+				result += "* [" + r.Title + "](" + r.Href.String() + ")\n"
+			}
 		}
-		result = joinSections(sections)
+		result = siteFrontMatter(d) + result
 	}
-	return result
+	return result, err
+}
+
+// renderHTML highlights the code and renders the comments of the sections,
+// and then executes the named template.
+func renderHTML(name string, d docs, sections []*section) (string, error) {
+	if templ.Lookup(name) == nil {
+		return "", fmt.Errorf("the template file %s does not define a %q template", tplfilename, name)
+	}
+	captions := weave.NumberCaptions(sections)
+	if *listings {
+		d.Listings = captions
+	}
+	if *srcLinkBase != "" && d.source != "" {
+		d.SrcLinks = srcLinks(d.source, sections)
+	}
+	var lines map[*section][]int
+	if *lineNums {
+		lines = map[*section][]int{}
+		for _, s := range sections {
+			lines[s] = sourceLines(s)
+		}
+	}
+	splitBoilerplate(sections)
+	if *lineNums {
+		d.LineNums = lineGutters(sections, lines)
+	}
+	opts := weaverOptions(d.Filename)
+	if d.source != "" {
+		opts.Links = identLinker(d.source)
+	}
+	weave.New(opts).HighlightSections(sections)
+	markdownComments(sections)
+	if *diagrams != diagramsOff {
+		client, err := renderDiagrams(sections)
+		if err != nil {
+			return "", err
+		}
+		if client {
+			d.MermaidURL = *mermaidURL
+		}
+	}
+	if *texMath && hasMath(sections) {
+		d.MathURL = katexHref(d.Root)
+	}
+	if *inlineSVG > 0 && d.source != "" {
+		if err := inlineSVGs(sections, d.source); err != nil {
+			return "", err
+		}
+	}
+	if *standalone && d.source != "" {
+		if err := embedImages(sections, d.source); err != nil {
+			return "", err
+		}
+	} else if *copyImages && d.source != "" {
+		if err := processImages(sections, d.source, d.outname); err != nil {
+			return "", err
+		}
+	}
+	d.Sections = weave.HTMLSections(sections)
+	d.WrapperClass = *wrapperClass
+	d.Permalinks = *permalinks
+	b := getBuffer()
+	defer bufPool.Put(b)
+	err := templ.ExecuteTemplate(b, name, d)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// renderFragment renders Go source code into an HTML fragment, without any
+// document chrome around it, for embedding the result into existing pages.
+// The fragment consists of a single element with -wrapper-class as its class.
+func renderFragment(title, src string) (template.HTML, error) {
+	sections := extractSections(title, src)
+	weave.AssignIDs(sections)
+	frag, err := renderHTML("fragment", docs{Filename: title, Title: title}, sections)
+	return template.HTML(frag), err
 }
 
 // ### Processing sections
 //
-// Determine if the current line belongs to a comment region. A comment region
-// is either a comment line (starting with `//`) or a `/*...*/` multi-line comment.
-func commentFinder() func(string) bool {
-	commentSectionInProgress := false
-	return func(line string) bool {
-		if comment.FindString(line) != "" {
-			// "//" Comment line found.
-			return true
-		}
-		// If the current line is at the start `/*` of a multi-line comment,
-		// set a flag to remember we're within a multi-line comment.
-		if commentStart.FindString(line) != "" {
-			commentSectionInProgress = true
-			return true
-		}
-		// At the end `*/` of a multi-line comment, clear the flag.
-		if commentEnd.FindString(line) != "" {
-			commentSectionInProgress = false
-			return true
-		}
-		// The current line is within a `/*...*/` section.
-		if commentSectionInProgress {
-			return true
-		}
-		// Anything else is not a comment region.
-		return false
+// The weave package splits the source into sections and renders them.
+// weaver returns a Weaver set up according to the command line flags.
+func weaver() *weave.Weaver {
+	return weaverFor("")
+}
+
+// weaverFor returns a Weaver for the language of the named source file.
+func weaverFor(filename string) *weave.Weaver {
+	return weave.New(weaverOptions(filename))
+}
+
+// weaverOptions returns the options of weaverFor.
+func weaverOptions(filename string) weave.Options {
+	return weave.Options{
+		HeadingOffset:  *headingOffset,
+		MaxHeading:     *maxHeading,
+		IntroOnly:      *intro,
+		WrapperClass:   *wrapperClass,
+		Highlighter:    codeHighlighter,
+		TokenClasses:   tokenClasses,
+		Language:       sourceLanguage(filename),
+		SourceOrder:    *sourceOrder,
+		Footnotes:      *footnotes,
+		ProseMarker:    *proseMarker,
+		KeepDirectives: *keepDirectives,
+		ByDecl:         *byDecl,
+		Minted:         *latexCode == latexMinted,
+		Balance:        *balanceLines,
+		Math:           *texMath,
 	}
 }
 
-// isDirective returns true if the input argument is a Go directive.
-func isDirective(line string) bool {
-	if directive.FindString(line) != "" {
-		return true
+// sourceLanguage returns the language of a source file. Files with
+// extensions that the weave package does not know are taken for Go.
+func sourceLanguage(filename string) *weave.Language {
+	if l := weave.LanguageFor(filename); l != nil {
+		return l
 	}
-	return false
+	return weave.Go
 }
 
 // Split the source into sections, where each section contains a comment group
 // and the code that follows that group.
-func extractSections(source string) []*section {
-	var sections []*section
-	current := new(section)
-	isInComment := commentFinder()
-
-	for _, line := range strings.Split(source, "\n") {
-		// Skip the line if it is a Go directive like //go:generate
-		if isDirective(line) {
-			continue
-		}
-		// Determine if the line belongs to a comment.
-		if isInComment(line) {
-			// If currently in a Code group, switch to a new section.
-			if current.Code != "" {
-				sections = append(sections, current)
-				current = new(section)
-			}
-			// Strip out any comment delimiter and add the line to the
-			// Doc group.
-			current.Doc += allCommentDelims.ReplaceAllString(line, "") + "\n"
-
-		} else {
-			// Stop here if only the intro text shall be rendered.
-			if *intro {
-				break
-			}
-			// Add the current line to the Code group.
-			current.Code += line + "\n"
-		}
-	}
-	return append(sections, current)
+func extractSections(filename, source string) []*section {
+	return weaverFor(filename).Sections(source)
 }
 
 // Join sections into a single string.
-func joinSections(sections []*section) (res string) {
+func joinSections(sections []*section) string {
+	b := getBuffer()
+	defer bufPool.Put(b)
 	for _, s := range sections {
-		res += s.Doc
-		res += s.Code
+		b.WriteString(s.Doc)
+		b.WriteString(s.Code)
 	}
-	return res
+	return b.String()
 }
 
-// markdownString applies markdown to the input string, using the
-// commonHtmlFlags and commonExtensions as defined in blackfriday/markdown.go,
-// plus HTML_HREF_TARGET_BLANK.
+// markdownString applies markdown to the input string, with the heading
+// levels adjusted as set by -heading-offset and -max-heading.
 func markdownString(input string) string {
-	const (
-		htmlFlags = 0 |
-			blackfriday.HTML_USE_XHTML |
-			blackfriday.HTML_USE_SMARTYPANTS |
-			blackfriday.HTML_SMARTYPANTS_FRACTIONS |
-			blackfriday.HTML_SMARTYPANTS_DASHES |
-			blackfriday.HTML_HREF_TARGET_BLANK
-
-		extensions = 0 |
-			blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
-			blackfriday.EXTENSION_TABLES |
-			blackfriday.EXTENSION_FENCED_CODE |
-			blackfriday.EXTENSION_AUTOLINK |
-			blackfriday.EXTENSION_STRIKETHROUGH |
-			blackfriday.EXTENSION_SPACE_HEADERS |
-			blackfriday.EXTENSION_HEADER_IDS |
-			blackfriday.EXTENSION_BACKSLASH_LINE_BREAK |
-			blackfriday.EXTENSION_DEFINITION_LISTS
-	)
-	renderer := blackfriday.HtmlRenderer(htmlFlags, "", "")
-	return string(blackfriday.MarkdownOptions([]byte(input), renderer,
-		blackfriday.Options{Extensions: extensions}))
+	return weaver().Markdown(input)
 }
 
 // Apply markdown to each section's documentation.
 func markdownComments(sections []*section) {
-	for _, section := range sections {
-		// MarkdownCommon() enables a couple of common Markdown extensions, like
-		// Smartypants, tables, fenced code blocks, and more.
-		section.Doc = markdownString(section.Doc)
-	}
+	weaver().MarkdownSections(sections)
+}
+
+// ### Template functions
+//
+// templateFuncs are available to all templates.
+var templateFuncs = template.FuncMap{
+	"code":     codeExcerpt,
+	"markdown": templateMarkdown,
+	"relpath":  relPath,
+	"now":      time.Now,
+	"slugify":  blackfriday.SanitizedAnchorName,
 }
 
-// litebrite eats leading whitespace when fed with code snippets.
-// To address this, splitLeadingWs splits the code into leading whitespace
-// and the rest, to be re-joined after highlighting.
-func splitLeadingWs(s string) (string, string) {
-	code := strings.TrimLeft(s, "\t ")
-	return s[:strings.Index(s, code)], code
+// templateMarkdown renders Markdown text, like the comments, with the
+// heading levels of -heading-offset and -max-heading.
+//
+//	{{markdown "See the *other* [documents](index.html)."}}
+func templateMarkdown(text string) template.HTML {
+	return template.HTML(markdownString(text))
 }
 
-// Apply syntax highlighting to each section's code.
-func highlightCode(sections []*section) {
-	h := litebrite.Highlighter{
-		OperatorClass: "operator",
-		IdentClass:    "ident",
-		LiteralClass:  "literal",
-		KeywordClass:  "keyword",
-		CommentClass:  "comment",
+// relPath returns a link from a document to another file of the output
+// directory, both given by their paths within the output directory:
+//
+//	<a href="{{relpath .Path "about.html"}}">About</a>
+func relPath(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return string(rootPath(from)) + to
 	}
-	for i := range sections {
-		s := sections[i].Code
-		if strings.TrimSpace(strings.Trim(s, "\n")) != "" {
-			ws, code := splitLeadingWs(s)
-			sections[i].Code = ws + h.Highlight(code)
-		} else {
-			sections[i].Code = "" // make empty Code *really* empty
-		}
+	return filepath.ToSlash(rel)
+}
+
+// codeExcerpt loads lines from through to (counting from 1) of a source
+// file and returns them syntax-highlighted. If to is zero or negative, the
+// excerpt extends to the end of the file. Relative file names are resolved
+// against the current directory.
+//
+// In a template, use it like this:
+//
+//	<pre><code>{{code "path/to/file.go" 10 42}}</code></pre>
+func codeExcerpt(filename string, from, to int) (template.HTML, error) {
+	src, err := readCodeFile(filename)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.SplitAfter(string(src), "\n")
+	if to <= 0 || to > len(lines) {
+		to = len(lines)
+	}
+	if from < 1 || from > to {
+		return "", fmt.Errorf("code %s: invalid line range %d-%d", filename, from, to)
 	}
+	lang := filename
+	if isRemote(filename) {
+		lang = remoteName(filename)
+	}
+	return template.HTML(weaverFor(lang).Highlight(strings.Join(lines[from-1:to], ""))), nil
 }
 
-// Put the code into Markdown code fences
-func markdownCode(sections []*section) {
+// Put the code into Markdown code fences, tagged with the name of the
+// language.
+func markdownCode(sections []*section, lang string) {
 	for i := range sections {
 		if sections[i].Code != "\n" {
-			sections[i].Code = "\n```go\n" + sections[i].Code + "```\n"
+			sections[i].Code = "\n```" + lang + "\n" + sections[i].Code + "```\n"
+		}
+		if c := sections[i].Caption; c != nil {
+			sections[i].Code += "\n*" + c.Label() + ":* " + c.Text + "\n"
 		}
 	}
 }
 
+// ### Paths
+//
+// goweave deals with two kinds of paths: paths in the local file system,
+// which use the OS-specific separator, and URL paths that end up in links
+// in the generated documents, which always use forward slashes. Mixing both
+// produces broken `<link href="css\goweave.css">` elements on Windows,
+// hence each kind gets its own type.
+
+// fsPath is a path in the local file system.
+type fsPath string
+
+// urlPath is a slash-separated path as used in URLs.
+type urlPath string
+
+// join joins any number of path elements to p, using the OS separator.
+func (p fsPath) join(elem ...string) fsPath {
+	return fsPath(filepath.Join(append([]string{string(p)}, elem...)...))
+}
+
+// toURL turns a relative file system path into a URL path.
+func (p fsPath) toURL() urlPath {
+	return urlPath(filepath.ToSlash(string(p)))
+}
+
+func (u urlPath) String() string {
+	return string(u)
+}
+
+// join joins any number of slash-separated path elements to u.
+func (u urlPath) join(elem ...string) urlPath {
+	return urlPath(path.Join(append([]string{string(u)}, elem...)...))
+}
+
+// cssHref returns the URL of the CSS file relative to the generated document.
+// -csspath is given as a file system path relative to the output directory.
+func cssHref() urlPath {
+	return fsPath(*csspath).toURL().join(cssfilename)
+}
+
 // ### Setup and running
 //
-// Locate the HTML template and CSS.
+// Locate the HTML template and CSS. An empty result means the resource
+// files that the binary carries, which goweave uses in memory rather than
+// installing them anywhere; -install writes them out for customizing.
 func findResources() string {
 	// If a custom resource dir is given, use that.
 	if *resdir != "" {
 		return *resdir
 	}
 
-	// If there is a "goweave" directory in the current path,
-	// and if it contains the css and templ files, use that.
-	path := filepath.Join("goweave", "resources")
-	res, err := os.Open(path)
-	if err == nil {
-		_ = res.Close() // An error here is harmless, as we only checked for existence.
-		res, err = os.Open(filepath.Join(path, cssfilename))
-		if err == nil {
-			_ = res.Close() // Same here.
-			return path
-		}
+	// Else look for the css and templ files in a "goweave" directory in the
+	// current path, and then in $HOME/.config/goweave.
+	_, dir, err := weave.FindResources(weave.ResourceOptions{
+		Dirs:   []string{filepath.Join("goweave", "resources"), filepath.Join(configDir, "resources")},
+		Marker: cssfilename,
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
+	return dir
+}
 
-	// Else try to use the files in $HOME/.config/goweave.
-	path = filepath.Join(configDir, "resources")
-	cssFile, err := os.Open(filepath.Join(path, cssfilename))
-	if err == nil {
-		_ = cssFile.Close()
-		return path
+// resourceFS returns the files of a resource directory, or for "", the
+// built-in ones.
+func resourceFS(dir string) fs.FS {
+	if dir == "" {
+		return weave.Resources()
 	}
+	return os.DirFS(dir)
+}
 
-	// If none of the above was successful, install the resource files from
-	// the binary (under "resources") into ./goweave.
-	if install("goweave") != nil {
-		log.Fatal("Unable to install the resource files into './goweave'.")
+// resourcePath returns the path of a resource file, for messages.
+func resourcePath(dir, name string) string {
+	if dir == "" {
+		return name + " (built in)"
 	}
-	return filepath.Join("goweave", "resources")
+	return filepath.Join(dir, name)
 }
 
 // Load the HTML template.
-// Load the CSS if it shall be inlined.
-func loadResources(path string) {
+// Load the CSS, along with -theme, if it shall be inlined.
+// If anything fails, the previously loaded resources remain in place.
+func loadResources(path string) error {
+	var css template.CSS
 	if *inline {
-		data, err := ioutil.ReadFile(filepath.Join(path, "goweave.css"))
+		data, err := stylesheet(path)
 		if err != nil {
-			panic(err.Error())
+			return err
+		}
+		css = template.CSS(data)
+	} else if *theme != "" {
+		if _, err := readTheme(path, *theme); err != nil {
+			return err
 		}
-		style = string(data)
 	}
-	templ = template.Must(template.ParseFiles(filepath.Join(path, tplfilename)))
+	t, err := template.New(tplfilename).Funcs(templateFuncs).ParseFS(resourceFS(path), tplfilename)
+	if err == nil && *templateFile != "" {
+		err = parseUserTemplate(t, *templateFile)
+	}
+	if err != nil {
+		return err
+	}
+	it, err := loadIndexTemplate(path)
+	if err != nil {
+		return err
+	}
+	js, err := loadScript(path)
+	if err != nil {
+		return err
+	}
+	style, templ, indexTempl, script = css, t, it, js
+	// Every file in the resource directory may affect the output.
+	resourceDeps = nil
+	if path != "" {
+		resourceDeps, _ = filepath.Glob(filepath.Join(path, "*"))
+	}
+	resourceDeps = append(resourceDeps, templateCodeFiles(templ)...)
+	resourceDeps = append(resourceDeps, templateCodeFiles(indexTempl)...)
+	if *theme != "" && path != "" {
+		resourceDeps = append(resourceDeps, themeFile(path, *theme))
+	}
+	if *templateFile != "" {
+		resourceDeps = append(resourceDeps, *templateFile)
+	}
+	return nil
+}
+
+// parseUserTemplate adds the template file of -template to the document
+// template. Its definitions replace the ones of the same names, and unless
+// the file consists of definitions only, its body replaces the body of the
+// document template.
+func parseUserTemplate(t *template.Template, filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	name := filename
+	if name == tplfilename {
+		name = "./" + name // not to replace the document template yet
+	}
+	u, err := t.New(name).Parse(string(data))
+	if err != nil {
+		return err
+	}
+	if u.Tree != nil && !parse.IsEmptyTree(u.Tree.Root) {
+		_, err = t.AddParseTree(tplfilename, u.Tree)
+	}
+	return err
 }
 
 // copyFile copies the contents of src to dst atomically.
@@ -455,50 +2354,351 @@ func copyFile(dst, src string) error {
 // copyCssFile() copies the CSS file to the destination.
 // Use -csspath=<path> to specify a relative destination path, e.g.:
 // goweave -csspath=css ...
-func copyCssFile() {
-	// Copy only if dest path != source path
-	src := filepath.Join(resourcedir, cssfilename)
-	dst := filepath.Join(*outdir, *csspath)
+// With -theme, it writes the CSS file along with the theme instead.
+func copyCssFile() error {
+	if resourcedir != "" {
+		src := string(fsPath(resourcedir).join(cssfilename))
+		if *theme == "" {
+			return copyToOutput(cssHref().String(), src)
+		}
+		if inOutputDir(cssHref().String(), src) {
+			return fmt.Errorf("cannot add the theme to %s, which is the output file", src)
+		}
+	}
+	data, err := stylesheet(resourcedir)
+	if err != nil {
+		return err
+	}
+	return outputFS().WriteFile(cssHref().String(), data)
+}
 
-	if os.Chdir(dst) != nil {
-		err := os.MkdirAll(dst, os.ModeDir)
+// sameFile reports whether a and b refer to the same file. The paths need
+// not be spelled the same way, e.g., "./goweave/resources/goweave.css" and
+// "goweave/resources/goweave.css" are the same file.
+func sameFile(a, b fsPath) bool {
+	fa, err := os.Stat(string(a))
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(string(b))
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fa, fb)
+}
+
+// binarySniffLen is the number of leading bytes that isBinary inspects.
+const binarySniffLen = 8000
+
+// isBinary reports whether data looks like binary content rather than
+// source code. Like git, it checks the first few kilobytes for NUL bytes,
+// and additionally rejects anything that is not valid UTF-8.
+func isBinary(data []byte) bool {
+	if len(data) > binarySniffLen {
+		data = data[:binarySniffLen]
+		// Do not mistake a multi-byte rune that got cut in half for invalid UTF-8.
+		for i := 1; i < utf8.UTFMax; i++ {
+			if r, size := utf8.DecodeLastRune(data); r != utf8.RuneError || size != 1 {
+				break
+			}
+			data = data[:len(data)-1]
+		}
+	}
+	return bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)
+}
+
+// tooLarge reports whether the file at filename exceeds the -maxsize limit.
+func tooLarge(filename string) bool {
+	if *maxSize <= 0 {
+		return false
+	}
+	size, err := sourceSize(filename)
+	return err == nil && size > *maxSize
+}
+
+// outputName returns the name of the document generated from a source file,
+// relative to the output directory.
+func outputName(filename string) string {
+	if *hugo {
+		return hugoOutputName(filename)
+	}
+	name := filepath.Base(filename)
+	ext := "html"
+	if *md {
+		ext = "md"
+	}
+	switch *format {
+	case formatRST:
+		ext = "rst"
+	case formatTeX:
+		ext = "tex"
+	case formatJSON:
+		ext = "json"
+	}
+	if !isProjectFile(name) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return path.Join(outputDirs[filename], name+"."+ext)
+}
+
+// Generate documentation for a source file.
+// Binary files and files above the -maxsize limit are skipped with a warning,
+// rather than turning them into huge, useless HTML documents.
+func processFile(filename string) error {
+	var translations []translation
+	if *watch {
+		// Once the translations are known, even for skipped files.
+		defer func() {
+			files := append([]string{filename, sidecarFile(filename)}, linkedFiles(filename)...)
+			files = append(files, includedFiles(filename)...)
+			for _, t := range translations {
+				files = append(files, t.filename)
+			}
+			deps.set(filename, append(files, resourceDeps...))
+		}()
+	}
+	if tooLarge(filename) {
+		infof("Skipping %s: file is larger than %d bytes (see -maxsize).", filename, *maxSize)
+		return nil
+	}
+	src, err := readSource(filename)
+	if err != nil {
+		return err
+	}
+	if isBinary(src) {
+		infof("Skipping %s: file appears to be binary.", filename)
+		return nil
+	}
+	forgetRefPage(filename)
+	resetSiteReport(filename)
+	fm := parseFrontMatter(filename, string(src))
+	draft := fm.isDraft()
+	if draft {
+		// The file may have become a draft since its document was made.
+		removePage(filename)
+	}
+	if draft && !*drafts {
+		infof("Skipping %s: file is a draft (see -drafts).", filename)
+		return removeOutput(outputName(filename))
+	}
+	name := filepath.Base(filename)
+	outname := outputName(filename)
+	sections := extractWithSidecar(filename, src, true)
+	d := docs{Filename: name, Title: name, Root: rootPath(outname), source: filename, outname: outname, Pragmas: fm.withDate(filename)}
+	if t := fm["title"]; t != "" {
+		d.Title, d.ShowTitle = t, true
+	}
+	if *title != "" {
+		d.Title, d.ShowTitle = *title, true
+	}
+	if *copySource {
+		d.SourceLink = urlPath(name) // copied next to the document
+	}
+	d.Canonical = canonicalURL(fm, outname)
+	d.NoIndex = *noindex || fm.isSet("noindex")
+	setNoindex(outname, d.NoIndex)
+	if *related {
+		if !draft {
+			setRelated(filename, newPageFeatures(filename, src, fm))
+		}
+		d.Related = relatedPages(filename)
+	}
+	if *rawView {
+		d.RawSource = template.HTML(weaverFor(filename).Highlight(string(src)))
+	}
+	if !isProjectFile(filename) {
+		addCoverage(filename, sectionCoverage(sections))
+	}
+	if !draft {
+		addPage(newPageInfo(filename, outname, d.Title, src, sections))
+		if *reviewReport {
+			addStatuses(filename, outname, d.Title, sections)
+		}
+	}
+	translations, err = findTranslations(filename)
+	if err != nil {
+		return err
+	}
+	d.Lang = *lang
+	points := splitPoints(sections, outname)
+	if points != nil {
+		weave.AssignIDs(sections) // the IDs of the whole document, for references across the pages
+	}
+	if len(translations) > 0 {
+		d.Languages = languageLinks(outname, d.Lang, translations)
+		weave.AssignIDs(sections) // before the translations replace any comments
+	}
+	variants := make([][]*section, len(translations))
+	for i, t := range translations {
+		variants[i] = t.apply(sections)
+	}
+	err = writeSplit(d, sections, points, filename, outname, translations)
+	if err != nil {
+		return err
+	}
+	for i, t := range translations {
+		vd, vname := d, variantName(outname, t.lang)
+		vd.Lang = t.lang
+		vd.Languages = languageLinks(outname, t.lang, translations)
+		if t.title != "" {
+			vd.Title, vd.ShowTitle = t.title, true
+		}
+		vd.Canonical = canonicalURL(fm, vname)
+		setNoindex(vname, vd.NoIndex)
+		err = writeSplit(vd, variants[i], points, filename, vname, translations)
 		if err != nil {
-			panic(err.Error())
+			return err
 		}
-		err = os.Chmod(dst, 0744)
+	}
+	if !*md {
+		err = writeRedirects(fm.aliases(), outname, d.Title, d.Canonical)
 		if err != nil {
-			panic(err.Error())
+			return err
+		}
+	}
+	if *copySource {
+		dst := path.Join(path.Dir(outname), name)
+		if revTree != nil {
+			// The working tree may hold another version of the file.
+			if err := outputFS().WriteFile(dst, src); err != nil {
+				return err
+			}
+		} else if err := copyToOutput(dst, filename); err != nil {
+			return err
 		}
 	}
-	dst = filepath.Join(dst, cssfilename)
-	if dst != src {
-		err := copyFile(dst, src)
+	return copyAssets(!*bare)
+}
+
+// writeDocument renders a document, runs the -postprocess command on it,
+// and writes it to the output directory, unless the build cache finds the
+// document up to date.
+func writeDocument(d docs, sections []*section, filename, outname string) error {
+	sum := fingerprint(d, sections, filename)
+	if upToDate(outname, sum) {
+		return keepDocument(filename, outname)
+	}
+	doc, err := renderDocs(d, sections)
+	if err != nil {
+		return err
+	}
+	if *postprocess != "" {
+		doc, err = postProcess(doc, filename, outname)
 		if err != nil {
-			panic(err.Error())
+			return err
+		}
+	}
+	if *siteReport {
+		recordLinks(doc, filename, outname)
+	}
+	err = outputFS().WriteFile(outname, []byte(doc))
+	if err != nil {
+		return err
+	}
+	if *pdf {
+		if err := writePDF(outname); err != nil {
+			return err
 		}
 	}
+	remember(outname, sum)
+	return nil
 }
 
-// Generate documentation for a source file.
-func processFile(filename string) {
-	src, err := ioutil.ReadFile(filename)
+// keepDocument takes note of a document that the build cache found to be up
+// to date, instead of writing it.
+func keepDocument(filename, outname string) error {
+	if !*siteReport {
+		return nil
+	}
+	doc, err := fs.ReadFile(outputFS(), outname)
 	if err != nil {
-		panic(err.Error())
+		return err
 	}
-	name := filepath.Base(filename)
-	ext := "html"
-	if *md {
-		ext = "md"
+	recordLinks(string(doc), filename, outname)
+	return nil
+}
+
+// postProcess pipes a generated document through the -postprocess command
+// and returns the command's output. The command can find the names of the
+// source file and the document in the environment variables GOWEAVE_SOURCE
+// and GOWEAVE_OUTPUT.
+func postProcess(doc, source, output string) (string, error) {
+	args := strings.Fields(*postprocess)
+	if len(args) == 0 {
+		return doc, nil
 	}
-	outname := filepath.Join(*outdir, name[:len(name)-2]) + ext
-	docs := generateDocs(name, string(src))
-	err = ioutil.WriteFile(outname, []byte(docs), 0666)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(doc)
+	cmd.Env = append(os.Environ(), "GOWEAVE_SOURCE="+source, "GOWEAVE_OUTPUT="+output)
+	out, err := cmd.Output()
 	if err != nil {
-		panic(err.Error())
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			err = fmt.Errorf("%v: %s", err, bytes.TrimSpace(ee.Stderr))
+		}
+		return "", fmt.Errorf("postprocessing %s: %v", source, err)
+	}
+	return string(out), nil
+}
+
+// processFiles generates documentation for all files, using up to -jobs
+// worker goroutines. The work channel is unbuffered, so the file list is
+// handed out only as fast as the workers can take it. This way, no more than
+// -jobs files are open or held in memory at any time, regardless of how many
+// files are passed in.
+//
+// A file that fails does not stop the others. Each worker records the error
+// of a file in the slot of that file, so once all workers are done,
+// processFiles logs the errors in the order of the files, no matter which
+// worker finished first, and returns an error that lists all files that
+// failed, in the same order.
+func processFiles(filenames []string) error {
+	n := *jobs
+	if n < 1 {
+		n = 1
+	}
+	if n > len(filenames) {
+		n = len(filenames)
+	}
+	work := make(chan int)
+	errs := make([]error, len(filenames))
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				errs[i] = processFile(filenames[i])
+				// In watch mode, the error page replaces the document until
+				// the next change.
+				if errs[i] != nil && *watch {
+					showError([]string{filenames[i]}, errs[i])
+				}
+			}
+		}()
+	}
+	for i := range filenames {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	return joinErrors(filenames, errs)
+}
+
+// joinErrors logs the errors of the files that failed, and returns an
+// error that lists these files, or nil if none failed. errs[i] is the error
+// of filenames[i].
+func joinErrors(filenames []string, errs []error) error {
+	var failures []string
+	for i, err := range errs {
+		if err != nil {
+			log.Print(err)
+			failures = append(failures, filenames[i])
+		}
 	}
-	if !*inline {
-		copyCssFile()
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d file(s) failed: %s", len(failures), len(filenames), strings.Join(failures, ", "))
 	}
+	return nil
 }
 
 // getHomeDir finds the user's home directory in an OS-independent way.
@@ -516,25 +2716,242 @@ func getHomeDir() string {
 	return home
 }
 
-// install writes the CSS and Template files into ~/.config/goweave.
-// The source files are stored in the binary via go-bindata.
-// If you change the original CSS or Template files in the git/go workspace,
-// run go generate.
+// install writes the built-in resource files into the resources directory
+// of targetDir, usually ~/.config/goweave. The binary embeds them from the
+// resources directory of the repository, so changes to these files take
+// effect with the next build.
 func install(targetDir string) error {
-	return RestoreAssets(targetDir, "resources")
+	return weave.InstallResources(weave.Resources(), filepath.Join(targetDir, "resources"))
 }
 
 func main() {
 	flag.Parse()
+	if err := applyConfig(); err != nil {
+		log.Fatal(err)
+	}
 	if *installResources {
 		if install(configDir) != nil {
 			log.Fatal("Unable to install the resource files into '" + configDir + "'.")
 		}
 		return
 	}
+	if *printThemes {
+		listThemes(findResources())
+		return
+	}
+	if *printCSS {
+		if err := loadHighlighter(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print(highlightCSS())
+		return
+	}
+	if flag.Arg(0) == "theme" {
+		if err := themeCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "selftest" {
+		if err := selftestCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "template" {
+		if err := templateCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "portal" {
+		if err := portalCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "lint" {
+		if err := lintCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.Arg(0) == "unweave" {
+		if err := unweaveCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := checkFormat(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkLaTeXCode(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkBoilerplate(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkTOC(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkSplit(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkMDLayout(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkColorScheme(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkHugo(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkStandalone(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkFrontMatter(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkWebhook(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkSrcLink(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkLineNums(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkDiagrams(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkGenerate(); err != nil {
+		log.Fatal(err)
+	}
+	if isStdin(flag.Args()) {
+		if err := stdinCommand(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *rev != "" {
+		if err := openRevision(*rev); err != nil {
+			log.Fatal(err)
+		}
+	}
+	files, err := expandArgs(inputArgs())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *title != "" && len(files) > 1 {
+		log.Fatal("-title can only be used with a single input file.")
+	}
 	resourcedir = findResources()
-	loadResources(resourcedir)
-	for _, filename := range flag.Args() {
-		processFile(filename)
+	if err := loadResources(resourcedir); err != nil {
+		log.Fatal(err)
+	}
+	if err := loadFonts(); err != nil {
+		log.Fatal(err)
+	}
+	if err := loadHighlighter(); err != nil {
+		log.Fatal(err)
+	}
+	if err := openOutput(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkPDF(); err != nil {
+		log.Fatal(err)
+	}
+	if err := checkEPUB(); err != nil {
+		log.Fatal(err)
+	}
+	loadCache()
+	if *related {
+		indexRelated(files)
+	}
+	indexRefs(files)
+	err = processFiles(files)
+	for _, finish := range []func() error{finishBuild, closeOutput} {
+		if finishErr := finish(); finishErr != nil {
+			if err != nil {
+				log.Print(finishErr)
+			} else {
+				err = finishErr
+			}
+		}
+	}
+	if *watch || serve != "" {
+		if err != nil {
+			log.Print(err)
+		}
+		if *watch {
+			if serve != "" {
+				go func() { log.Fatal(servePreview(string(serve), flag.Args())) }()
+			}
+			watchFiles(files) // never returns
+		}
+		log.Fatal(servePreview(string(serve), flag.Args()))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// finishBuild writes the files that describe the whole build, after all
+// documents have been generated.
+func finishBuild() error {
+	if err := saveCache(); err != nil {
+		return fmt.Errorf("unable to write the build cache: %v", err)
+	}
+	if *writeManifest {
+		err := saveManifest(manifestFilename)
+		if err != nil {
+			return fmt.Errorf("unable to write the manifest: %v", err)
+		}
+	}
+	if *writeIndex && !*md {
+		err := saveIndex(indexName)
+		if err != nil {
+			return fmt.Errorf("unable to write the index page: %v", err)
+		}
+	}
+	if *reviewReport && !*md {
+		err := saveStatusReport(statusReportName)
+		if err != nil {
+			return fmt.Errorf("unable to write the status report: %v", err)
+		}
+	}
+	if *interfaces && !*md {
+		err := saveInterfaces(interfacesName)
+		if err != nil {
+			return fmt.Errorf("unable to write the interface matrix: %v", err)
+		}
+	}
+	if *writeBadge {
+		err := saveBadge(badgeFilename)
+		if err != nil {
+			return fmt.Errorf("unable to write the badge: %v", err)
+		}
+	}
+	if *writeRobots {
+		err := saveRobotsTxt(robotsFilename)
+		if err != nil {
+			return fmt.Errorf("unable to write %s: %v", robotsFilename, err)
+		}
+	}
+	if *epub != "" {
+		err := saveEPUB(*epub)
+		if err != nil {
+			return fmt.Errorf("unable to write %s: %v", *epub, err)
+		}
+	}
+	if *showCoverage || *minCoverage > 0 {
+		printCoverage(os.Stdout)
 	}
+	if *siteReport {
+		printSiteReport(os.Stdout)
+	}
+	if c := totalCoverage(); c.Ratio() < *minCoverage {
+		return fmt.Errorf("documentation coverage %.1f%% is below the minimum of %.1f%%", 100*c.Ratio(), 100*(*minCoverage))
+	}
+	return nil
 }
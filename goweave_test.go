@@ -1,10 +1,17 @@
 package main
 
 import (
-	"reflect"
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
-	"github.com/davecgh/go-spew/spew"
+	"github.com/christophberger/goweave/weave"
 )
 
 func TestGenerateDocs(t *testing.T) {
@@ -22,93 +29,13 @@ func TestGenerateDocs(t *testing.T) {
 	}
 }
 
-func TestCommentFinder(t *testing.T) {
-	tests := []struct {
-		line string
-		want bool
-	}{
-		{"// Comment", true},
-		{"package main", false},
-		{"/* Begin", true},
-		{"within", true},
-		{"", true},
-		{"End */", true},
-		{"func test() {", false},
-		{"", false},
-	}
-	isInComment := commentFinder()
-	for _, tt := range tests {
-		if got := isInComment(tt.line); got != tt.want {
-			t.Errorf("%q. commentFinder() = %v, want %v", tt.line, got, tt.want)
-		}
-	}
-}
-
-func TestIsDirective(t *testing.T) {
-	tests := []struct {
-		line string
-		want bool
-	}{
-		{"//go:generate blah blah", true},
-		{"//go:newdirective blah blah", true},
-		{"// go:generate blah blah", false},
-		{"/*go:generate blah blah", false},
-		{"/* go:generate blah blah", false},
-		{"//gotcha", false},
-	}
-	for _, tt := range tests {
-		if got := isDirective(tt.line); got != tt.want {
-			t.Errorf("isDirective(%s) = %v, want %v", tt.line, got, tt.want)
-		}
-	}
-}
-
-func TestExtractSections(t *testing.T) {
-	tests := []struct {
-		source string
-		want   []*section
-	}{
-		{`// Test comment
-// more comment
-
-Test code
-More code
-
-// Second comment
-  Second code snippet
-
-/* Third comment
-In comment section
-End of comment */
-`,
-			[]*section{{`Test comment
-more comment
-`,
-				`
-Test code
-More code
-
-`},
-				{"Second comment\n",
-					"  Second code snippet\n\n"},
-				{"Third comment\nIn comment section\nEnd of comment\n",
-					"\n"},
-			},
-		},
-	}
-	for _, tt := range tests {
-		if got := extractSections(tt.source); !reflect.DeepEqual(got, tt.want) {
-			t.Errorf("extractSections(%v) = %v, want %v", tt.source, spew.Sdump(got), spew.Sdump(tt.want))
-		}
-	}
-}
-
 func TestJoinSections(t *testing.T) {
 	tests := []struct {
 		sections []*section
 		want     string
 	}{
-	// TODO: Add test cases.
+		{nil, ""},
+		{[]*section{{Doc: "Doc\n", Code: "code\n"}, {Doc: "More doc\n", Code: ""}}, "Doc\ncode\nMore doc\n"},
 	}
 	for _, tt := range tests {
 		if got := joinSections(tt.sections); got != tt.want {
@@ -135,7 +62,7 @@ func TestHighlightCode(t *testing.T) {
 	// TODO: Add test cases.
 	}
 	for _, tt := range tests {
-		highlightCode(tt.sections)
+		weaver().HighlightSections(tt.sections)
 	}
 }
 
@@ -146,21 +73,59 @@ func TestMarkdownCode(t *testing.T) {
 	// TODO: Add test cases.
 	}
 	for _, tt := range tests {
-		markdownCode(tt.sections)
+		markdownCode(tt.sections, "go")
 	}
 }
 
 func TestFindResources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	defer func(d string) { configDir = d }(configDir)
+	configDir = filepath.Join(dir, "config")
+	defer func(d string) { *resdir = d }(*resdir)
+	custom := filepath.Join("goweave", "resources")
+
 	tests := []struct {
-		want string
+		resdir string
+		files  []string // the resource files that exist
+		want   string
 	}{
-	// TODO: Add test cases.
+		{"", nil, ""},
+		{"", []string{filepath.Join(configDir, "resources", cssfilename)}, filepath.Join(configDir, "resources")},
+		{"", []string{filepath.Join(custom, cssfilename)}, custom},
+		{"res", nil, "res"},
 	}
 	for _, tt := range tests {
+		*resdir = tt.resdir
+		for _, f := range tt.files {
+			if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(f, []byte("body {}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
 		if got := findResources(); got != tt.want {
 			t.Errorf("findResources() = %v, want %v", got, tt.want)
 		}
 	}
+
+	// The built-in resource files work in memory, without being installed.
+	os.RemoveAll("goweave")
+	os.RemoveAll(configDir)
+	*resdir = ""
+	if err := loadResources(findResources()); err != nil {
+		t.Errorf("loadResources() with the built-in files: %v", err)
+	}
+	if _, err := os.Stat("goweave"); !os.IsNotExist(err) {
+		t.Errorf("findResources() installed the resource files")
+	}
 }
 
 func TestCopyFile(t *testing.T) {
@@ -189,6 +154,17 @@ func TestProcessFile(t *testing.T) {
 	}
 }
 
+func TestProcessFilesErrorOrder(t *testing.T) {
+	defer func(j int) { *jobs = j }(*jobs)
+	*jobs = 4
+	files := []string{"z-missing.go", "b-missing.go", "y-missing.go", "a-missing.go"}
+	err := processFiles(files)
+	want := "4 of 4 file(s) failed: z-missing.go, b-missing.go, y-missing.go, a-missing.go"
+	if err == nil || err.Error() != want {
+		t.Errorf("processFiles() = %v, want %s", err, want)
+	}
+}
+
 func TestMain(t *testing.T) {
 	tests := []struct {
 	}{
@@ -198,3 +174,219 @@ func TestMain(t *testing.T) {
 		main()
 	}
 }
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want bool
+	}{
+		{[]byte("package main\n\nfunc main() {}\n"), false},
+		{[]byte("// Grüße, 世界\n"), false},
+		{[]byte("\x7fELF\x02\x01\x01\x00\x00"), true},
+		{[]byte{0xff, 0xfe, 'a'}, true},
+		{append(bytes.Repeat([]byte("a"), binarySniffLen-1), "世"...), false},
+	}
+	for _, tt := range tests {
+		if got := isBinary(tt.data); got != tt.want {
+			t.Errorf("isBinary(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestCssHref(t *testing.T) {
+	tests := []struct {
+		csspath string
+		want    urlPath
+	}{
+		{"", "goweave.css"},
+		{".", "goweave.css"},
+		{"css", "css/goweave.css"},
+		{"css/", "css/goweave.css"},
+		{"../static/css", "../static/css/goweave.css"},
+	}
+	defer func(p string) { *csspath = p }(*csspath)
+	for _, tt := range tests {
+		*csspath = tt.csspath
+		if got := cssHref(); got != tt.want {
+			t.Errorf("cssHref() with -csspath=%q = %v, want %v", tt.csspath, got, tt.want)
+		}
+	}
+}
+
+func TestTitleEscaping(t *testing.T) {
+	templ = template.Must(template.ParseFS(weave.Resources(), tplfilename))
+	got := generateDocs(`<script>alert("&")</script>.go`, "// Doc\npackage main\n")
+	if strings.Contains(got, "<script>alert") {
+		t.Errorf("generateDocs() did not escape the title:\n%s", got)
+	}
+	if !strings.Contains(got, "<title>&lt;script&gt;alert(&#34;&amp;&#34;)&lt;/script&gt;.go</title>") {
+		t.Errorf("generateDocs() = %s, want escaped title", got)
+	}
+	if !strings.Contains(got, "<p>Doc</p>") {
+		t.Errorf("generateDocs() escaped the rendered Markdown:\n%s", got)
+	}
+}
+
+func TestCodeExcerpt(t *testing.T) {
+	f, err := ioutil.TempFile("", "excerpt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("package main\n\nfunc a() {}\n\nfunc b() {}\n")
+	f.Close()
+	tests := []struct {
+		from, to int
+		want     string
+		wantErr  bool
+	}{
+		{3, 3, weave.Highlight("func a() {}\n"), false},
+		{5, 0, weave.Highlight("func b() {}\n"), false},
+		{4, 2, "", true},
+		{0, 1, "", true},
+	}
+	for _, tt := range tests {
+		got, err := codeExcerpt(f.Name(), tt.from, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("codeExcerpt(%d, %d) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+		}
+		if string(got) != tt.want {
+			t.Errorf("codeExcerpt(%d, %d) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestRenderFragment(t *testing.T) {
+	templ = template.Must(template.ParseFS(weave.Resources(), tplfilename))
+	got, err := renderFragment("test.go", "// Doc\npackage main\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	frag := string(got)
+	if !strings.HasPrefix(frag, `<div class="goweave">`) {
+		t.Errorf("renderFragment() = %s, want a goweave wrapper element", frag)
+	}
+	for _, chrome := range []string{"<html", "<head", `id="goweave"`, "<p>Doc</p>"} {
+		if strings.Contains(frag, chrome) != (chrome == "<p>Doc</p>") {
+			t.Errorf("renderFragment() = %s, containing %s is wrong", frag, chrome)
+		}
+	}
+	if !strings.Contains(frag, `<a class="permalink" id="section.1" href="#section.1"`) {
+		t.Errorf("renderFragment() = %s, want a permalink", frag)
+	}
+}
+
+func TestPostProcess(t *testing.T) {
+	if _, err := exec.LookPath("tr"); err != nil {
+		t.Skip("tr not available")
+	}
+	defer func(cmd string) { *postprocess = cmd }(*postprocess)
+	tests := []struct {
+		cmd     string
+		want    string
+		wantErr bool
+	}{
+		{"tr a-z A-Z", "<P>HELLO</P>", false},
+		{"", "<p>hello</p>", false},
+		{"tr --no-such-option", "", true},
+	}
+	for _, tt := range tests {
+		*postprocess = tt.cmd
+		got, err := postProcess("<p>hello</p>", "a.go", "a.html")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("postProcess() with %q = %q, %v; want %q, error %v", tt.cmd, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestHeadingFlags(t *testing.T) {
+	defer func(o, m int) { *headingOffset, *maxHeading = o, m }(*headingOffset, *maxHeading)
+	*headingOffset, *maxHeading = 1, 6
+	if got := markdownString("# Title\n"); !strings.HasPrefix(got, "<h2") {
+		t.Errorf("markdownString() with offset 1 = %q, want an h2 heading", got)
+	}
+	*headingOffset, *maxHeading = 0, 3
+	if got := markdownString("##### Deep\n"); !strings.HasPrefix(got, "<h3") {
+		t.Errorf("markdownString() with max 3 = %q, want an h3 heading", got)
+	}
+}
+
+func TestMarkdownCaptions(t *testing.T) {
+	defer func(m, l bool) { *md, *listings = m, l }(*md, *listings)
+	*md, *listings = true, true
+	got, err := renderDocs(docs{}, extractSections("test.go", "// Doc\n//goweave:caption Listing: a loop\nfor {}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"```\n\n*Listing 1:* a loop\n", "## Listings\n\n* Listing 1: a loop\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderDocs() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestOtherLanguages(t *testing.T) {
+	defer func(m bool) { *md = m }(*md)
+	*md = true
+	got, err := renderDocs(docs{Filename: "query.sql"}, extractSections("query.sql", "-- Doc\nSELECT 1;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Doc\n\n```sql\nSELECT 1;\n```\n"; got != want {
+		t.Errorf("renderDocs() = %q, want %q", got, want)
+	}
+	for filename, want := range map[string]string{"a/script.py": "script.md", "q.sql": "q.md", "main.go": "main.md", "go.mod": "go.mod.md"} {
+		if got := outputName(filename); got != want {
+			t.Errorf("outputName(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestRelPath(t *testing.T) {
+	tests := []struct {
+		from, to, want string
+	}{
+		{"server.html", "about.html", "about.html"},
+		{"cmd/tool/main.html", "about.html", "../../about.html"},
+		{"cmd/main.html", "cmd/util.html", "util.html"},
+		{"index.html", "img/logo.png", "img/logo.png"},
+	}
+	for _, tt := range tests {
+		if got := relPath(tt.from, tt.to); got != tt.want {
+			t.Errorf("relPath(%q, %q) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestParseUserTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tests := []struct {
+		user, want string
+	}{
+		// Definitions only: the body of the document template stays.
+		{`{{define "title"}}[{{.}}]{{end}}`, "<h1>[Doc]</h1>"},
+		// A body of its own replaces the body of the document template.
+		{`<div>{{template "title" .}} {{markdown "*x*"}} {{slugify "A B"}}</div>`, "<div>Doc <p><em>x</em></p>\n a-b</div>"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			filename := filepath.Join(dir, fmt.Sprintf("user%d.templ", i))
+			ioutil.WriteFile(filename, []byte(tt.user), 0666)
+			base := template.Must(template.New(tplfilename).Funcs(templateFuncs).Parse(`{{define "title"}}{{.}}{{end}}<h1>{{template "title" .}}</h1>`))
+			if err := parseUserTemplate(base, filename); err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			if err := base.ExecuteTemplate(&b, tplfilename, "Doc"); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("the template with %s renders %q, want %q", tt.user, b.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// ## Syntax highlighting
+//
+// By default, litebrite highlights the code, as it always did. With
+// -highlighter=chroma, the Chroma library does the job instead. Chroma
+// tokenizes more accurately and comes with dozens of color styles;
+// -chroma-style selects one. The rules of the style go into the head of
+// each document, after the CSS file, so they override the colors of the
+// theme for the code. Documents without a head, as generated by -bare and
+// -fragment, need the rules elsewhere; `goweave -highlighter=chroma
+// -print-highlight-css` prints them. The rules of -chroma-dark-style for
+// the dark palette follow those of -chroma-style (see colorscheme.go).
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// codeHighlighter is the highlighter selected by -highlighter.
+var codeHighlighter = weave.Litebrite
+
+// darkChroma holds the color style of -chroma-dark-style, if Chroma
+// highlights the code.
+var darkChroma *weave.Chroma
+
+// loadHighlighter sets up the highlighter selected by -highlighter.
+func loadHighlighter() error {
+	switch *highlighterName {
+	case "litebrite":
+		codeHighlighter, darkChroma = weave.Litebrite, nil
+	case "chroma":
+		c, err := weave.NewChroma(*chromaStyle)
+		if err != nil {
+			return err
+		}
+		codeHighlighter, darkChroma = c, nil
+		if *chromaDarkStyle != "" {
+			if darkChroma, err = weave.NewChroma(*chromaDarkStyle); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("-highlighter must be litebrite or chroma, not %q", *highlighterName)
+	}
+	var err error
+	tokenClasses, err = parseTokenClasses(*tokenClassList)
+	return err
+}
+
+// highlightScopes select the highlighted code within a document: the code
+// cells, the plain source view, and the fenced blocks of the comments.
+var highlightScopes = []string{" .code", " #raw", " .doc pre"}
+
+// highlightCSS returns the rules for the color style of the highlighter,
+// for the highlightScopes. litebrite has no style of its own; the theme's
+// CSS file takes care of it. Neither knows the classes of -token-classes.
+func highlightCSS() template.CSS {
+	c, ok := codeHighlighter.(*weave.Chroma)
+	if !ok || tokenClasses != nil {
+		return ""
+	}
+	root := "#goweave"
+	if *fragment {
+		root = "." + *wrapperClass
+	}
+	var b strings.Builder
+	for _, scope := range highlightScopes {
+		b.WriteString(c.CSS(root + scope))
+	}
+	b.WriteString(darkHighlightCSS(darkChroma, root))
+	return template.CSS(b.String())
+}
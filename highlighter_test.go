@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestLoadHighlighter(t *testing.T) {
+	defer func(name, style string, h weave.Highlighter) {
+		*highlighterName, *chromaStyle, codeHighlighter = name, style, h
+	}(*highlighterName, *chromaStyle, codeHighlighter)
+	tests := []struct {
+		name, style string
+		wantErr     bool
+		wantCSS     bool
+	}{
+		{"litebrite", "", false, false},
+		{"chroma", "monokai", false, true},
+		{"chroma", "no-such-style", true, false},
+		{"pygments", "", true, false},
+	}
+	for _, tt := range tests {
+		*highlighterName, *chromaStyle, codeHighlighter = tt.name, tt.style, weave.Litebrite
+		err := loadHighlighter()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("loadHighlighter() with %s/%s: error = %v, wantErr %v", tt.name, tt.style, err, tt.wantErr)
+		}
+		if css := highlightCSS(); strings.Contains(string(css), "#goweave .code .kd") != tt.wantCSS {
+			t.Errorf("highlightCSS() with %s/%s = %q", tt.name, tt.style, css)
+		}
+	}
+}
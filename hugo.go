@@ -0,0 +1,154 @@
+// ## Hugo page bundles
+//
+// With -frontmatter, the Markdown documents are ready for a static site
+// generator, but they still land in the output directory as flat files,
+// and the images and links of the comments point into the source tree.
+// -hugo goes all the way: it treats the output directory as the root of a
+// Hugo site, and writes each document as a page bundle,
+// `content/posts/<slug>/index.md`, so that
+//
+//	goweave -hugo -outdir=~/blog server.go
+//
+// turns server.go into a blog post. The slug comes from the slug pragma,
+// or else from the file name, and -hugo-section picks another section
+// than `posts`. -hugo implies -md, and -frontmatter=yaml unless
+// -frontmatter asks for another format.
+//
+// A page bundle keeps its resources next to its index.md, so goweave
+// copies the images and other local files that the comments link to into
+// the bundle, and points the links there. Files from outside the
+// directory of the source file go to the top of the bundle. Links to
+// other input files, and cross-references to their documents, become
+// relative links to the pages of their bundles.
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// hugoContentDir is the directory of a Hugo site that holds the pages.
+const hugoContentDir = "content"
+
+// checkHugo validates -hugo-section and turns on the options that -hugo
+// implies.
+func checkHugo() error {
+	if !*hugo {
+		return nil
+	}
+	s := path.Clean(filepath.ToSlash(*hugoSection))
+	if s == "." || s == ".." || path.IsAbs(s) || strings.HasPrefix(s, "../") {
+		return fmt.Errorf("-hugo-section must be a directory within %s, like posts, not %q", hugoContentDir, *hugoSection)
+	}
+	*md = true
+	if *frontMatterFmt == "" {
+		*frontMatterFmt = frontMatterYAML
+	}
+	return nil
+}
+
+// hugoSlug returns the slug of the document of a source file, which names
+// its page bundle.
+func hugoSlug(filename string) string {
+	if src, err := readSource(filename); err == nil {
+		slug := parseFrontMatter(filename, string(src))["slug"]
+		if slug != "" && slug != "." && slug != ".." && !strings.ContainsAny(slug, `/\`) {
+			return slug
+		}
+	}
+	return defaultSlug(filename)
+}
+
+// hugoOutputName returns the name of the index.md file of the page bundle
+// of a source file.
+func hugoOutputName(filename string) string {
+	return path.Join(hugoContentDir, filepath.ToSlash(*hugoSection), outputDirs[filename], hugoSlug(filename), "index.md")
+}
+
+// bundleLinks points the links and images of the comments to the page
+// bundle of the document, and copies the local files they refer to into
+// the bundle.
+func bundleLinks(sections []*section, source, outname string) error {
+	var err error
+	for _, s := range sections {
+		if !strings.Contains(s.Doc, "](") {
+			continue
+		}
+		lines := strings.Split(s.Doc, "\n")
+		fenced := false
+		for i, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				fenced = !fenced
+			}
+			if fenced {
+				continue // links in code examples are no links
+			}
+			lines[i] = mdLink.ReplaceAllStringFunc(line, func(link string) string {
+				m := mdLink.FindStringSubmatch(link)
+				target, lerr := bundleLink(m[1], source, outname)
+				if lerr != nil && err == nil {
+					err = lerr
+				}
+				return "](" + target + ")"
+			})
+		}
+		s.Doc = strings.Join(lines, "\n")
+	}
+	return err
+}
+
+// bundleLink returns the target of a link from a page bundle. Targets that
+// are no local files, or that do not exist, stay as they are.
+func bundleLink(target, source, outname string) (string, error) {
+	if target == "" || strings.HasPrefix(target, "#") || path.IsAbs(target) || strings.Contains(target, ":") {
+		return target, nil
+	}
+	file, frag := target, ""
+	if i := strings.Index(target, "#"); i >= 0 {
+		file, frag = target[:i], target[i:]
+	}
+	if f, err := url.PathUnescape(file); err == nil {
+		file = f
+	}
+	// A cross-reference to the document of another input file.
+	if doc := path.Join(path.Dir(outname), file); path.Base(doc) == "index.md" && strings.HasPrefix(doc, hugoContentDir+"/") {
+		return pageLink(outname, doc) + frag, nil
+	}
+	local := filepath.Join(filepath.Dir(source), filepath.FromSlash(file))
+	if isRefInput(local) {
+		return pageLink(outname, outputName(local)) + frag, nil
+	}
+	fi, err := os.Stat(local)
+	if err != nil || !fi.Mode().IsRegular() {
+		return target, nil
+	}
+	name := path.Clean(file)
+	if name == ".." || strings.HasPrefix(name, "../") {
+		name = path.Base(name)
+	}
+	if _, err := copyImage(path.Join(path.Dir(outname), name), local); err != nil {
+		return target, err
+	}
+	return strings.Replace(name, " ", "%20", -1) + frag, nil
+}
+
+// pageLink returns the relative URL from the page of one bundle to the page
+// of another, given the names of their index.md files.
+func pageLink(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(path.Dir(to)))
+	if err != nil || rel == "." {
+		return "./"
+	}
+	return filepath.ToSlash(rel) + "/"
+}
+
+// isRefInput returns true if a file is one of the input files of this run.
+func isRefInput(filename string) bool {
+	refMu.Lock()
+	defer refMu.Unlock()
+	return refInputs[filepath.Clean(filename)]
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckHugo(t *testing.T) {
+	defer func(h bool, s string, m bool, f string) { *hugo, *hugoSection, *md, *frontMatterFmt = h, s, m, f }(*hugo, *hugoSection, *md, *frontMatterFmt)
+	tests := []struct {
+		section string
+		wantErr bool
+	}{
+		{"posts", false},
+		{"blog/go", false},
+		{"..", true},
+		{"../posts", true},
+		{"/posts", true},
+	}
+	for _, tt := range tests {
+		*hugo, *hugoSection, *md, *frontMatterFmt = true, tt.section, false, ""
+		err := checkHugo()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkHugo() with -hugo-section=%s = %v, want error %v", tt.section, err, tt.wantErr)
+		}
+		if err == nil && (!*md || *frontMatterFmt != frontMatterYAML) {
+			t.Errorf("checkHugo() set -md=%v -frontmatter=%q, want -md and yaml", *md, *frontMatterFmt)
+		}
+	}
+}
+
+func TestBundleLinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hugo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(filepath.Join(src, "img"), 0777)
+	ioutil.WriteFile(filepath.Join(src, "img", "flow.svg"), []byte("<svg/>"), 0666)
+	ioutil.WriteFile(filepath.Join(dir, "data.json"), []byte("{}"), 0666)
+	ioutil.WriteFile(filepath.Join(src, "server.go"), []byte("//goweave:slug my-server\npackage main\n"), 0666)
+	ioutil.WriteFile(filepath.Join(src, "client.go"), []byte("package main\n"), 0666)
+
+	defer func(h bool, s, d string) { *hugo, *hugoSection, *outdir = h, s, d }(*hugo, *hugoSection, *outdir)
+	*hugo, *hugoSection, *outdir = true, "posts", filepath.Join(dir, "site")
+	server, client := filepath.Join(src, "server.go"), filepath.Join(src, "client.go")
+	indexRefs([]string{server, client})
+	outname := outputName(server)
+	if outname != "content/posts/my-server/index.md" {
+		t.Errorf("outputName(%q) = %q, want content/posts/my-server/index.md", server, outname)
+	}
+	sections := []*section{{Doc: "![Flow](img/flow.svg) [data](../data.json)\n" +
+		"[client](client.go#Run) [Run](../../../content/posts/client/index.md) [Go](https://go.dev) [missing](nofile.txt)\n" +
+		"```\n[not](img/flow.svg)\n```\n"}}
+	if err := bundleLinks(sections, server, outname); err != nil {
+		t.Fatal(err)
+	}
+	want := "![Flow](img/flow.svg) [data](data.json)\n" +
+		"[client](../client/#Run) [Run](../client/) [Go](https://go.dev) [missing](nofile.txt)\n" +
+		"```\n[not](img/flow.svg)\n```\n"
+	if sections[0].Doc != want {
+		t.Errorf("bundleLinks() = %q, want %q", sections[0].Doc, want)
+	}
+	for _, f := range []string{"img/flow.svg", "data.json"} {
+		if _, err := os.Stat(filepath.Join(dir, "site", "content", "posts", "my-server", filepath.FromSlash(f))); err != nil {
+			t.Errorf("%s is not in the page bundle: %v", f, err)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// ## Links between files
+//
+// In highlighted Go code, the exported identifiers that another input file
+// declares link to their declarations in that file's document. This works
+// for the files of the same package, that is, the input files in the same
+// directory with the same package clause, and for the packages of the same
+// module that a file imports, as far as their files are input files, too.
+// goweave finds the module path in the nearest go.mod file above the
+// source file, so `goweave ./...` in a module links the packages to each
+// other.
+//
+// The weave package parses the code and finds the identifiers that the
+// file does not declare itself; goweave looks them up among the
+// declarations that the cross-references know about (see crossref.go).
+// -identifier-links=false leaves the code unlinked.
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// declTarget is a declaration in another input file.
+type declTarget struct {
+	file     string // the input file
+	fragment string // the ID of the section that contains the declaration
+}
+
+// goModule is the module that a directory belongs to.
+type goModule struct {
+	root string // the directory of the go.mod file
+	path string // the module path
+}
+
+var (
+	modules   = map[string]goModule{} // by directory
+	modulesMu sync.Mutex
+	moduleRe  = regexp.MustCompile(`(?m)^module\s+("[^"]+"|\S+)`)
+)
+
+// findModule returns the module of a directory, or a module with an empty
+// path if there is no go.mod file above the directory.
+func findModule(dir string) goModule {
+	// go.mod may be above the current directory.
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	modulesMu.Lock()
+	m, ok := modules[dir]
+	modulesMu.Unlock()
+	if ok {
+		return m
+	}
+	if src, err := readSource(filepath.Join(dir, "go.mod")); err == nil {
+		if sm := moduleRe.FindSubmatch(src); sm != nil {
+			m.root, m.path = dir, string(sm[1])
+			if p, err := strconv.Unquote(m.path); err == nil {
+				m.path = p
+			}
+		}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		m = findModule(parent)
+	}
+	modulesMu.Lock()
+	modules[dir] = m
+	modulesMu.Unlock()
+	return m
+}
+
+// importDir returns the directory of a package that a source file imports,
+// if the package belongs to the same module. The directory is relative if
+// the source file's is.
+func importDir(filename, importPath string) (string, bool) {
+	dir := filepath.Dir(filename)
+	m := findModule(dir)
+	var sub string
+	switch {
+	case m.path == "":
+		return "", false
+	case importPath == m.path:
+	case strings.HasPrefix(importPath, m.path+"/"):
+		sub = filepath.FromSlash(strings.TrimPrefix(importPath, m.path+"/"))
+	default:
+		return "", false
+	}
+	// The module root is where the directory of the file leads to.
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(abs, m.root)
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(dir, rel, sub), true
+}
+
+// inputsIn returns the input files in a directory, in lexical order.
+func inputsIn(dir string) []string {
+	dir = filepath.Clean(dir)
+	refMu.Lock()
+	var files []string
+	for f := range refInputs {
+		if filepath.Dir(f) == dir && filepath.Ext(f) == ".go" {
+			files = append(files, f)
+		}
+	}
+	refMu.Unlock()
+	sort.Strings(files)
+	return files
+}
+
+// packageDecls returns the exported declarations in the input files of a
+// directory that belong to the package pkg, or with pkg empty, to the
+// package that is not a test package.
+func packageDecls(dir, pkg string) map[string]declTarget {
+	decls := map[string]declTarget{}
+	for _, f := range inputsIn(dir) {
+		p, err := lookupRefPage(f)
+		if err != nil || p.pkg == "" {
+			continue
+		}
+		if pkg != "" && p.pkg != pkg || pkg == "" && strings.HasSuffix(p.pkg, "_test") {
+			continue
+		}
+		for name, a := range p.anchors {
+			if a.code && !strings.Contains(name, ".") && ast.IsExported(name) {
+				decls[name] = declTarget{file: f, fragment: a.fragment}
+			}
+		}
+	}
+	return decls
+}
+
+// linkPackages returns the directories of the packages that the code of a
+// Go source file may link to, by import path, with "" for the file's own
+// package, and the name of the file's package.
+func linkPackages(filename string) (dirs map[string]string, pkg string) {
+	if !*identLinks || sourceLanguage(filename) != weave.Go {
+		return nil, ""
+	}
+	page, err := lookupRefPage(filename)
+	if err != nil || page.pkg == "" {
+		return nil, ""
+	}
+	dirs = map[string]string{"": filepath.Dir(filename)}
+	src, err := readSource(filename)
+	if err != nil {
+		return dirs, page.pkg
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.ImportsOnly)
+	if err != nil {
+		return dirs, page.pkg
+	}
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		if dir, ok := importDir(filename, p); ok {
+			dirs[p] = dir
+		}
+	}
+	return dirs, page.pkg
+}
+
+// linkTargets returns the declarations that the code of a Go source file
+// may link to, by the import path of their package, with "" for the
+// file's own package. It leaves out the file's own declarations.
+func linkTargets(filename string) map[string]map[string]declTarget {
+	filename = filepath.Clean(filename)
+	dirs, pkg := linkPackages(filename)
+	targets := map[string]map[string]declTarget{}
+	for importPath, dir := range dirs {
+		if importPath == "" {
+			targets[importPath] = packageDecls(dir, pkg)
+		} else {
+			targets[importPath] = packageDecls(dir, "")
+		}
+	}
+	for name, t := range targets[""] {
+		if t.file == filename {
+			delete(targets[""], name)
+		}
+	}
+	return targets
+}
+
+// identLinker returns the function that links the identifiers in the code
+// of a source file to their declarations, or nil if there is nothing to
+// link to.
+func identLinker(filename string) func(pkg, name string) string {
+	targets := linkTargets(filename)
+	if len(targets) == 0 {
+		return nil
+	}
+	root := string(rootPath(outputName(filename)))
+	return func(pkg, name string) string {
+		t, ok := targets[pkg][name]
+		if !ok {
+			return ""
+		}
+		return strings.Replace(root+outputName(t.file), " ", "%20", -1) + "#" + t.fragment
+	}
+}
+
+// linkedFiles returns the input files that the code of a source file may
+// link to, for watch mode.
+func linkedFiles(filename string) []string {
+	dirs, _ := linkPackages(filepath.Clean(filename))
+	var files []string
+	for _, dir := range dirs {
+		files = append(files, inputsIn(dir)...)
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIdentLinker(t *testing.T) {
+	defer func(inputs map[string]bool, pages map[string]*refPage) { refInputs, refPages = inputs, pages }(refInputs, refPages)
+	refInputs, refPages = map[string]bool{}, map[string]*refPage{}
+	dir, err := ioutil.TempDir("", "goweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":          "module example.com/mod\n",
+		"main.go":         "package main\n\nimport \"example.com/mod/shapes\"\n\nfunc main() { Run(shapes.Square{}) }\n",
+		"run.go":          "package main\n\n// Run runs.\nfunc Run(s shapes.Square) {}\n\nfunc helper() {}\n",
+		"run_test.go":     "package main_test\n\n// TestRun tests.\nfunc TestRun() {}\n",
+		"shapes/shape.go": "package shapes\n\n// Square is a shape.\ntype Square struct{}\n\n// Area returns the area.\nfunc (s Square) Area() float64 { return 0 }\n",
+	}
+	var names []string
+	for name, src := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(name) == ".go" {
+			names = append(names, filename)
+		}
+	}
+	indexRefs(names)
+	source := filepath.Join(dir, "main.go")
+	links := identLinker(source)
+	if links == nil {
+		t.Fatal("identLinker() = nil")
+	}
+	run, err := lookupRefPage(filepath.Join(dir, "run.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	square, err := lookupRefPage(filepath.Join(dir, "shapes", "shape.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := string(rootPath(outputName(source)))
+	tests := []struct {
+		pkg, name, want string
+	}{
+		{"", "Run", root + outputName(filepath.Join(dir, "run.go")) + "#" + run.anchors["Run"].fragment},
+		{"example.com/mod/shapes", "Square", root + outputName(filepath.Join(dir, "shapes", "shape.go")) + "#" + square.anchors["Square"].fragment},
+		{"", "helper", ""},
+		{"", "TestRun", ""},
+		{"", "main", ""},
+		{"example.com/mod/shapes", "Area", ""},
+		{"example.com/other", "Square", ""},
+	}
+	for _, tt := range tests {
+		if got := links(tt.pkg, tt.name); got != tt.want {
+			t.Errorf("links(%q, %q) = %q, want %q", tt.pkg, tt.name, got, tt.want)
+		}
+	}
+	if got := linkedFiles(source); len(got) != 4 {
+		t.Errorf("linkedFiles() = %v, want the four Go files", got)
+	}
+
+	defer func(l bool) { *identLinks = l }(*identLinks)
+	*identLinks = false
+	if identLinker(source) != nil {
+		t.Error("identLinker() with -identifier-links=false != nil")
+	}
+}
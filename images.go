@@ -0,0 +1,226 @@
+// ## Images
+//
+// Comments can show images through Markdown, as in `![Diagram](img/flow.png)`.
+// With -images, goweave copies the images that comments refer to into the
+// output directory, next to the document, so that the document can be
+// published as it is. On the way, it scales down PNG and JPEG images wider
+// than -max-image-width, as a screenshot straight from a high-resolution
+// display easily weighs several megabytes. It also adds width and height
+// attributes to the `<img>` elements, so that the browser can lay out the
+// page before the images arrive, and asks the browser to load the images
+// lazily.
+//
+// Only local images are processed. Images with absolute paths or URLs, or
+// images that would end up outside the output directory, are left alone.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	_ "image/gif" // for the size of GIF images
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jpegQuality is the quality of scaled JPEG images.
+const jpegQuality = 85
+
+// imgTag matches the start of an `<img>` element, up to its src attribute,
+// as blackfriday writes it.
+var imgTag = regexp.MustCompile(`<img src="([^"]*)"`)
+
+// imageInfo describes a copied image.
+type imageInfo struct {
+	modTime       time.Time // of the original image
+	width, height int       // 0 if unknown
+}
+
+// copiedImages remembers the images that have already been copied, keyed
+// by their destination, as many documents can share the same image.
+var (
+	copiedImages   = map[string]imageInfo{}
+	copiedImagesMu sync.Mutex
+)
+
+// processImages copies the images that the rendered comments refer to, and
+// adds size and loading attributes to their `<img>` elements.
+func processImages(sections []*section, source, outname string) error {
+	var err error
+	for _, s := range sections {
+		s.Doc = imgTag.ReplaceAllStringFunc(s.Doc, func(tag string) string {
+			src := html.UnescapeString(imgTag.FindStringSubmatch(tag)[1])
+			srcPath, dstPath, ok := imagePaths(src, source, outname)
+			if !ok || err != nil {
+				return tag
+			}
+			var info imageInfo
+			info, err = copyImage(dstPath, srcPath)
+			if err != nil {
+				return tag
+			}
+			if info.width > 0 {
+				tag += fmt.Sprintf(` width="%d" height="%d"`, info.width, info.height)
+			}
+			return tag + ` loading="lazy"`
+		})
+	}
+	return err
+}
+
+// imagePaths returns the path of the original image and the name of its
+// copy in the output. ok is false for images that goweave does not process.
+func imagePaths(src, source, outname string) (srcPath, dstPath string, ok bool) {
+	srcPath, ok = localImage(src, source)
+	if !ok {
+		return "", "", false
+	}
+	dst := path.Join(path.Dir(outname), src)
+	if dst == ".." || strings.HasPrefix(dst, "../") {
+		return "", "", false
+	}
+	return srcPath, dst, true
+}
+
+// copyImage copies an image, scaling it down if necessary, and returns its
+// size. Images that have been copied before are only copied again if the
+// original has changed since.
+func copyImage(dst, src string) (imageInfo, error) {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return imageInfo{}, err
+	}
+	copiedImagesMu.Lock()
+	defer copiedImagesMu.Unlock()
+	if info, ok := copiedImages[dst]; ok && info.modTime.Equal(fi.ModTime()) {
+		return info, nil
+	}
+	var info imageInfo
+	if inOutputDir(dst, src) {
+		// The output directory is the source directory. Never touch the
+		// original image.
+		info = imageSize(src)
+	} else {
+		info, err = scaleImage(dst, src)
+		if err != nil {
+			return imageInfo{}, err
+		}
+	}
+	info.modTime = fi.ModTime()
+	copiedImages[dst] = info
+	return info, nil
+}
+
+// imageSize returns the size of an image, or no size if the image format
+// is unknown.
+func imageSize(filename string) imageInfo {
+	f, err := os.Open(filename)
+	if err != nil {
+		return imageInfo{}
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return imageInfo{}
+	}
+	return imageInfo{width: cfg.Width, height: cfg.Height}
+}
+
+// scaleImage writes a scaled-down copy of a PNG or JPEG image that is wider
+// than -max-image-width. Any other image is copied as it is.
+func scaleImage(dst, src string) (imageInfo, error) {
+	data, info, err := shrinkImage(src)
+	if err != nil {
+		return imageInfo{}, err
+	}
+	if data == nil {
+		return info, copyToOutput(dst, src)
+	}
+	return info, outputFS().WriteFile(dst, data)
+}
+
+// shrinkImage returns a scaled-down copy of a PNG or JPEG image that is
+// wider than -max-image-width, along with its size. For any other image, the
+// copy is nil, and the size is that of the image, if Go knows its format.
+func shrinkImage(src string) ([]byte, imageInfo, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, imageInfo{}, err
+	}
+	defer f.Close()
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		// Not an image format that Go knows, like SVG.
+		return nil, imageInfo{}, nil
+	}
+	info := imageInfo{width: cfg.Width, height: cfg.Height}
+	if *maxImageWidth <= 0 || cfg.Width <= *maxImageWidth || format == "gif" {
+		return nil, info, nil
+	}
+	_, err = f.Seek(0, 0)
+	if err != nil {
+		return nil, imageInfo{}, err
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, imageInfo{}, fmt.Errorf("%s: %v", src, err)
+	}
+	info.height = cfg.Height * *maxImageWidth / cfg.Width
+	info.width = *maxImageWidth
+	if info.height < 1 {
+		info.height = 1
+	}
+	scaled := scaleDown(img, info.width, info.height)
+	var out bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&out, scaled, &jpeg.Options{Quality: jpegQuality})
+	} else {
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&out, scaled)
+	}
+	if err != nil {
+		return nil, imageInfo{}, err
+	}
+	return out.Bytes(), info, nil
+}
+
+// scaleDown shrinks an image to the given size. Each pixel of the result is
+// the average of the pixels of the original that it covers.
+func scaleDown(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := b.Min.Y + (y+1)*b.Dy()/height
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := b.Min.X + (x+1)*b.Dx()/width
+			if x1 == x0 {
+				x1++
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			scaled.Set(x, y, color.NRGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+	return scaled
+}
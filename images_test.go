@@ -0,0 +1,75 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImagePaths(t *testing.T) {
+	defer func(d string) { *outdir = d }(*outdir)
+	*outdir = "out"
+	tests := []struct {
+		src, outname string
+		wantSrc      string
+		wantDst      string
+		wantOK       bool
+	}{
+		{"img/a.png", "doc.html", filepath.Join("src", "img", "a.png"), "img/a.png", true},
+		{"../img/a.png", "pkg/doc.html", filepath.Join("img", "a.png"), "img/a.png", true},
+		{"../a.png", "doc.html", "", "", false},
+		{"/a.png", "doc.html", "", "", false},
+		{"https://example.com/a.png", "doc.html", "", "", false},
+	}
+	for _, tt := range tests {
+		src, dst, ok := imagePaths(tt.src, filepath.Join("src", "doc.go"), tt.outname)
+		if ok != tt.wantOK || src != tt.wantSrc || dst != tt.wantDst {
+			t.Errorf("imagePaths(%q, %q) = %q, %q, %v; want %q, %q, %v", tt.src, tt.outname, src, dst, ok, tt.wantSrc, tt.wantDst, tt.wantOK)
+		}
+	}
+}
+
+func TestProcessImages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		img.Set(x, 0, color.NRGBA{255, 0, 0, 255})
+	}
+	f, err := os.Create(filepath.Join(dir, "wide.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, img)
+	f.Close()
+
+	defer func(d string, w int) { *outdir, *maxImageWidth = d, w }(*outdir, *maxImageWidth)
+	*outdir, *maxImageWidth = filepath.Join(dir, "out"), 10
+	sections := []*section{{Doc: `<p><img src="wide.png" alt="Wide" /> <img src="http://example.com/x.png" alt="" /></p>`}}
+	if err := processImages(sections, filepath.Join(dir, "doc.go"), "doc.html"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sections[0].Doc, `<img src="wide.png" width="10" height="5" loading="lazy" alt="Wide" />`) {
+		t.Errorf("processImages() = %s, want size and loading attributes", sections[0].Doc)
+	}
+	if !strings.Contains(sections[0].Doc, `<img src="http://example.com/x.png" alt="" />`) {
+		t.Errorf("processImages() = %s, want the remote image unchanged", sections[0].Doc)
+	}
+	f, err = os.Open(filepath.Join(dir, "out", "wide.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cfg, err := png.DecodeConfig(f)
+	if err != nil || cfg.Width != 10 || cfg.Height != 5 {
+		t.Errorf("copied image is %dx%d (%v), want 10x5", cfg.Width, cfg.Height, err)
+	}
+}
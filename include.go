@@ -0,0 +1,167 @@
+// ## Includes
+//
+// An article about a program seldom finds all the code it talks about in
+// one file. An include pragma pulls the lines of another file into the
+// woven source, where the pragma stands:
+//
+//	//goweave:include store/cache.go#lookup
+//
+// The path is relative to the directory of the file with the pragma. After
+// the "#" comes an optional region, the lines between two marker pragmas
+// of the other file:
+//
+//	//goweave:start lookup
+//	func (c *Cache) Lookup(key string) (Value, bool) {
+//	//goweave:end lookup
+//
+// Without a region, the whole file goes in. The included lines weave as if
+// they were written in place, in sections of their own: comments in the
+// language of the including file are prose, and the rest is code. Like the
+// prose of sidecar files, they have no lines in the source file, so they
+// get no line numbers and no source links, and the source file itself
+// stays untouched. Unweaving writes no prose back to them either. Included
+// files can include others in turn. Marker pragmas stay out of the output,
+// and so do includes that point nowhere or in a circle, with a warning.
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// The pragma keys of includes and their regions.
+const (
+	includeKey     = "include"
+	regionStartKey = "start"
+	regionEndKey   = "end"
+)
+
+// includeInserts returns the included lines of a source file, by the index
+// of the line of the include pragma. An empty line and an empty comment
+// line before them, and an empty comment line after them, end the sections
+// around them, so that they make sections of their own; sourcePositions
+// drops the empty comment lines from the prose again. With warn set, it
+// logs the includes that fail.
+func includeInserts(filename string, src []byte, warn bool) map[int][]string {
+	logf := log.Printf
+	if !warn {
+		logf = func(string, ...interface{}) {}
+	}
+	lang := sourceLanguage(filename)
+	if lang.LineComment == "" {
+		return nil
+	}
+	comment := lang.LineComment + *proseMarker
+	inserts := map[int][]string{}
+	for i, line := range strings.Split(string(src), "\n") {
+		key, ref, ok := lang.ParsePragma(strings.TrimRight(line, "\r"))
+		if !ok || key != includeKey {
+			continue
+		}
+		lines, err := includeLines(filename, ref, []string{filepath.Clean(filename)})
+		if err != nil {
+			logf("%s:%d: %v", filename, i+1, err)
+			continue
+		}
+		inserts[i] = append(append([]string{"", comment}, lines...), comment)
+	}
+	return inserts
+}
+
+// includeLines returns the lines that an include pragma of a file refers
+// to, with the includes of these lines resolved. Stack holds the files
+// that include the file, to catch circles.
+func includeLines(filename, ref string, stack []string) ([]string, error) {
+	name, region := includeTarget(filename, ref)
+	if name == "" {
+		return nil, fmt.Errorf("include of %q names no file", strings.TrimSpace(ref))
+	}
+	for _, f := range stack {
+		if f == name {
+			return nil, fmt.Errorf("%s includes itself through %s", name, strings.Join(stack, ", "))
+		}
+	}
+	src, err := readSource(name)
+	if err != nil {
+		return nil, err
+	}
+	lang := sourceLanguage(name)
+	var lines []string
+	in := region == ""
+	found := false
+	for _, line := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+		line = strings.TrimRight(line, "\r")
+		key, value, _ := lang.ParsePragma(line)
+		switch key {
+		case regionStartKey, regionEndKey:
+			if region != "" && strings.TrimSpace(value) == region {
+				in, found = key == regionStartKey, true
+			}
+			continue
+		case includeKey:
+			if in {
+				nested, err := includeLines(name, value, append(stack, name))
+				if err != nil {
+					return nil, err
+				}
+				lines = append(lines, nested...)
+			}
+			continue
+		}
+		if in {
+			lines = append(lines, line)
+		}
+	}
+	if region != "" && !found {
+		return nil, fmt.Errorf("%s has no region %s", name, region)
+	}
+	return lines, nil
+}
+
+// includedFiles returns the files that the include pragmas of a source
+// file name, and those that the included files include in turn, for watch
+// mode.
+func includedFiles(filename string) []string {
+	var files []string
+	seen := map[string]bool{filepath.Clean(filename): true}
+	var walk func(filename string)
+	walk = func(filename string) {
+		src, err := readSource(filename)
+		if err != nil {
+			return
+		}
+		lang := sourceLanguage(filename)
+		for _, line := range strings.Split(string(src), "\n") {
+			key, ref, ok := lang.ParsePragma(strings.TrimRight(line, "\r"))
+			if !ok || key != includeKey {
+				continue
+			}
+			if name, _ := includeTarget(filename, ref); name != "" && !seen[name] {
+				seen[name] = true
+				files = append(files, name)
+				walk(name)
+			}
+		}
+	}
+	walk(filename)
+	return files
+}
+
+// includeTarget splits the reference of an include pragma of a file into
+// the path of the included file and the region, if any. The path is empty
+// if the reference names no file.
+func includeTarget(filename, ref string) (name, region string) {
+	name = strings.TrimSpace(ref)
+	if i := strings.LastIndexByte(name, '#'); i >= 0 {
+		name, region = strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+1:])
+	}
+	if name == "" {
+		return "", region
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(filepath.Dir(filename), filepath.FromSlash(name))
+	}
+	return filepath.Clean(name), region
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestIncludes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"article.go": "package article\n\n// The cache looks up keys like this:\n//goweave:include store/cache.go#lookup\n\nvar x = 1\n" +
+			"//goweave:include store/cache.go#missing\n//goweave:include loop.go\n",
+		"store/cache.go": "package store\n\n//goweave:start lookup\n// Lookup finds a key.\nfunc Lookup(key string) {}\n//goweave:end lookup\n\nfunc other() {}\n",
+		"loop.go":        "//goweave:include article.go\n",
+	}
+	for name, src := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filename := filepath.Join(dir, "article.go")
+	src := []byte(files["article.go"])
+	want := "package article\n\n// The cache looks up keys like this:\n\n//\n// Lookup finds a key.\nfunc Lookup(key string) {}\n//\n//goweave:include store/cache.go#lookup\n\nvar x = 1\n" +
+		"//goweave:include store/cache.go#missing\n//goweave:include loop.go\n"
+	if got := string(withSidecar(filename, src, false)); got != want {
+		t.Errorf("withSidecar() = %q, want %q", got, want)
+	}
+	if got := includedFiles(filename); len(got) != 2 || got[0] != filepath.Join(dir, "store", "cache.go") || got[1] != filepath.Join(dir, "loop.go") {
+		t.Errorf("includedFiles() = %v", got)
+	}
+
+	// The included lines make a section of their own, without a position
+	// in the source.
+	sections := extractWithSidecar(filename, src, false)
+	wantSections := []struct {
+		doc, code           string
+		docStart, codeStart int
+	}{
+		{"", "package article\n\n", 0, 1},
+		{"The cache looks up keys like this:\n", "\n", 3, 0},
+		{"Lookup finds a key.\n", "func Lookup(key string) {}\n", 0, 0},
+		{"", "\nvar x = 1\n\n", 0, 5},
+	}
+	if len(sections) != len(wantSections) {
+		t.Fatalf("extractWithSidecar() = %d sections, want %d", len(sections), len(wantSections))
+	}
+	for i, w := range wantSections {
+		s := sections[i]
+		if s.Doc != w.doc || s.Code != w.code || s.DocStart != w.docStart || s.CodeStart != w.codeStart {
+			t.Errorf("section %d = %q %q at %d and %d, want %q %q at %d and %d", i, s.Doc, s.Code, s.DocStart, s.CodeStart, w.doc, w.code, w.docStart, w.codeStart)
+		}
+	}
+
+	// Unweaving finds the sections, and leaves the included prose alone.
+	weave.AssignIDs(sections)
+	doc := `<div class="tr section" data-section-id="` + sections[1].ID + `"><div class="td doc"><p>The cache finds keys like this:</p></div></div>` +
+		`<div class="tr section" data-section-id="` + sections[2].ID + `"><div class="td doc"><p>Lookup finds it.</p></div></div>`
+	got, n, err := unweave(filename, string(src), "article.html", []byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(string(src), "looks up", "finds", 1); got != want || n != 1 {
+		t.Errorf("unweave() = %q, %d, want %q, 1", got, n, want)
+	}
+}
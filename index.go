@@ -0,0 +1,127 @@
+// ## The index page
+//
+// When goweave processes more than one file, it also writes an index.html
+// file into the output directory that links to all documents, along with
+// the first paragraph of each document as a summary. The index page has its
+// own template, goweave-index.templ, in the resource directory. Resource
+// directories installed by older versions of goweave have no such template;
+// goweave then uses the built-in one.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io/fs"
+	"log"
+	"sort"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+const (
+	indexfilename = "goweave-index.templ"
+	indexName     = "index.html"
+)
+
+var indexTempl *template.Template
+
+// indexEntry is a link to a document, as seen by the index template.
+type indexEntry struct {
+	Title   string
+	Href    urlPath
+	Source  string
+	Summary template.HTML // the rendered summary
+	Project bool          // true for project files, like go.mod
+}
+
+// indexData is what the index template gets to see.
+type indexData struct {
+	Title             string
+	CssPath           urlPath
+	Style             template.CSS
+	FontCSS           template.CSS
+	InlineCSS         bool
+	ColorScheme       string
+	ColorSchemeToggle bool
+	ScriptPath        urlPath
+	Script            template.JS
+	Pages             []indexEntry
+	ProjectFiles      []indexEntry
+}
+
+// loadIndexTemplate parses the index template from the resource directory,
+// or the built-in one if the resource directory has none.
+func loadIndexTemplate(path string) (*template.Template, error) {
+	data, err := fs.ReadFile(resourceFS(path), indexfilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = fs.ReadFile(weave.Resources(), indexfilename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return template.New(indexfilename).Funcs(templateFuncs).Parse(string(data))
+}
+
+// indexEntries returns the entries of the index page, sorted by source path.
+func indexEntries() []indexEntry {
+	pagesMu.Lock()
+	entries := make([]indexEntry, 0, len(pages))
+	for _, p := range pages {
+		entries = append(entries, indexEntry{
+			Title:   p.Title,
+			Href:    p.Output,
+			Source:  p.Source,
+			Summary: template.HTML(markdownString(p.Summary)),
+			Project: isProjectFile(p.Source),
+		})
+	}
+	pagesMu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Source < entries[j].Source })
+	return entries
+}
+
+// saveIndex writes the index page, unless there is only one document, or
+// one of the documents is named index.html already.
+func saveIndex(name string) error {
+	entries := indexEntries()
+	if len(entries) < 2 {
+		return nil
+	}
+	for _, e := range entries {
+		if e.Href == indexName {
+			log.Printf("Not writing an index page: %s is the document of %s.", indexName, e.Source)
+			return nil
+		}
+	}
+	// Templates of older versions list all pages; the built-in one lists
+	// the project files apart.
+	var projectFiles []indexEntry
+	for _, e := range entries {
+		if e.Project {
+			projectFiles = append(projectFiles, e)
+		}
+	}
+	if err := copyAssets(true); err != nil {
+		return err
+	}
+	scriptPath, js := pageScript("", true)
+	text := *indexTitle
+	for _, e := range entries {
+		text += e.Title + e.Source + string(e.Summary)
+	}
+	fonts, err := fontCSS("", text)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	err = indexTempl.Execute(&b, indexData{*indexTitle, cssHref(), style, fonts, *inline, fixedColorScheme(), colorSchemeToggle(true), scriptPath, js, entries, projectFiles})
+	if err != nil {
+		return err
+	}
+	err = outputFS().WriteFile(name, b.Bytes())
+	siteMu.Lock()
+	indexWritten = err == nil
+	siteMu.Unlock()
+	return err
+}
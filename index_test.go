@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	indexTempl, err = loadIndexTemplate(dir) // falls back to the built-in template
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(p map[string]pageInfo) { pages = p }(pages)
+	pages = map[string]pageInfo{}
+	defer func(o string) { *outdir = o }(*outdir)
+	*outdir = dir
+	filename := filepath.Join(dir, indexName)
+
+	addPage(pageInfo{Source: "b.go", Output: "b.html", Title: "B", Summary: "About *B*."})
+	if err := saveIndex(indexName); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("saveIndex() wrote an index page for a single document")
+	}
+
+	addPage(pageInfo{Source: "a/a.go", Output: "a/a.html", Title: "A <1>"})
+	if err := saveIndex(indexName); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := string(data)
+	for _, want := range []string{`<a href="a/a.html">A &lt;1&gt;</a>`, `<a href="b.html">B</a>`, "<em>B</em>"} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index page does not contain %s:\n%s", want, index)
+		}
+	}
+	if strings.Index(index, "a/a.html") > strings.Index(index, "b.html") {
+		t.Errorf("index page is not sorted by source path:\n%s", index)
+	}
+
+	addPage(pageInfo{Source: "go.mod", Output: "go.mod.html", Title: "go.mod"})
+	if err := saveIndex(indexName); err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index = string(data)
+	if i, j := strings.Index(index, "Project files"), strings.Index(index, `<a href="go.mod.html">go.mod</a>`); i < 0 || j < i || strings.Count(index, "go.mod.html") != 1 {
+		t.Errorf("index page does not list go.mod under the project files:\n%s", index)
+	}
+}
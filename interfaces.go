@@ -0,0 +1,313 @@
+// ## Interface implementations
+//
+// In Go, a type implements an interface without saying so, which is
+// elegant in the code and a puzzle in the documentation: nothing in the
+// document of a type tells which interfaces it satisfies. With -interfaces,
+// goweave type-checks the Go input files, package by package, and writes
+// interfaces.html into the output directory. The page has a matrix of the
+// types of the input files against their interfaces, with a mark where a
+// type implements an interface, and `*` where only the pointer to the type
+// does. Below the matrix, each interface lists its implementations, with
+// links to the sections that declare the methods.
+//
+// Imports of packages of the same module resolve to the input files of
+// those packages, so that a type of one package shows up as an
+// implementation of an interface of another. Other imports come from the
+// Go installation. The page covers the interfaces with methods of the
+// input files; generic types and interfaces, and test files, are left out.
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"html/template"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const interfacesName = "interfaces.html"
+
+// ifaceMethod is a method of an implementation, linked to its section.
+type ifaceMethod struct {
+	Name string
+	Href urlPath
+}
+
+// ifaceImpl is a type that implements an interface.
+type ifaceImpl struct {
+	Type    string
+	Href    urlPath
+	Pointer bool // only the pointer to the type implements the interface
+	Methods []ifaceMethod
+}
+
+// ifaceEntry is an interface, with its implementations.
+type ifaceEntry struct {
+	Name  string
+	ID    string // the ID of its list of implementations on the page
+	Href  urlPath
+	Impls []ifaceImpl
+}
+
+// ifaceRow is a type of the matrix, with the interfaces that it
+// implements, in the order of the columns.
+type ifaceRow struct {
+	Type  string
+	Href  urlPath
+	Cells []*ifaceImpl
+}
+
+// goPackages type-checks the Go input files, and resolves the imports of
+// the packages of the same module to the input files.
+type goPackages struct {
+	fset    *token.FileSet
+	std     types.Importer
+	checked map[string]*types.Package // by directory
+}
+
+// newGoPackages returns an empty set of packages.
+func newGoPackages() *goPackages {
+	fset := token.NewFileSet()
+	return &goPackages{
+		fset:    fset,
+		std:     importer.ForCompiler(fset, "source", nil),
+		checked: map[string]*types.Package{},
+	}
+}
+
+// check type-checks the package of the Go input files in a directory, or
+// returns nil if there are none. Type errors do not stop it; what cannot
+// be typed is left out of the page.
+func (g *goPackages) check(dir string) *types.Package {
+	if pkg, ok := g.checked[dir]; ok {
+		return pkg
+	}
+	g.checked[dir] = nil // against import cycles
+	var files []*ast.File
+	for _, f := range inputsIn(dir) {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		src, err := readSource(f)
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(g.fset, f, src, 0)
+		if err != nil || len(files) > 0 && file.Name.Name != files[0].Name.Name {
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if sub, ok := importDir(filepath.Join(dir, "x.go"), path); ok {
+				if pkg := g.check(filepath.Clean(sub)); pkg != nil {
+					return pkg, nil
+				}
+			}
+			return g.std.Import(path)
+		}),
+		Error: func(error) {},
+	}
+	pkg, _ := conf.Check(dir, g.fset, files, nil)
+	g.checked[dir] = pkg
+	return pkg
+}
+
+// importerFunc turns a function into a types.Importer.
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// interfaceMatrix type-checks the Go input files, and returns the
+// interfaces of the input files with their implementations, and the rows
+// of the matrix.
+func interfaceMatrix() ([]ifaceEntry, []ifaceRow) {
+	refMu.Lock()
+	dirs := map[string]bool{}
+	for f := range refInputs {
+		if filepath.Ext(f) == ".go" {
+			dirs[filepath.Dir(f)] = true
+		}
+	}
+	refMu.Unlock()
+	g := newGoPackages()
+	var named []*types.TypeName
+	for dir := range dirs {
+		pkg := g.check(dir)
+		if pkg == nil {
+			continue
+		}
+		for _, name := range pkg.Scope().Names() {
+			if tn, ok := pkg.Scope().Lookup(name).(*types.TypeName); ok && !tn.IsAlias() {
+				if t, ok := tn.Type().(*types.Named); ok && t.TypeParams().Len() == 0 {
+					named = append(named, tn)
+				}
+			}
+		}
+	}
+	sort.Slice(named, func(i, j int) bool { return g.qualified(named[i]) < g.qualified(named[j]) })
+	var ifaces, concrete []*types.TypeName
+	for _, tn := range named {
+		if it, ok := tn.Type().Underlying().(*types.Interface); ok {
+			if it.NumMethods() > 0 && it.IsMethodSet() {
+				ifaces = append(ifaces, tn)
+			}
+		} else {
+			concrete = append(concrete, tn)
+		}
+	}
+	entries := make([]ifaceEntry, len(ifaces))
+	for i, tn := range ifaces {
+		entries[i] = ifaceEntry{Name: g.qualified(tn), ID: "iface-" + strings.Replace(g.qualified(tn), ".", "-", -1), Href: g.declHref(tn.Pos(), tn.Name())}
+	}
+	var rows []ifaceRow
+	for _, tn := range concrete {
+		row := ifaceRow{Type: g.qualified(tn), Href: g.declHref(tn.Pos(), tn.Name()), Cells: make([]*ifaceImpl, len(ifaces))}
+		found := false
+		for i, itn := range ifaces {
+			it := itn.Type().Underlying().(*types.Interface)
+			var impl *ifaceImpl
+			switch {
+			case types.Implements(tn.Type(), it):
+				impl = &ifaceImpl{Type: row.Type, Href: row.Href}
+			case types.Implements(types.NewPointer(tn.Type()), it):
+				impl = &ifaceImpl{Type: "*" + row.Type, Href: row.Href, Pointer: true}
+			default:
+				continue
+			}
+			for j := 0; j < it.NumMethods(); j++ {
+				m := it.Method(j)
+				obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(tn.Type()), true, m.Pkg(), m.Name())
+				impl.Methods = append(impl.Methods, ifaceMethod{m.Name(), g.methodHref(obj)})
+			}
+			row.Cells[i], found = impl, true
+			entries[i].Impls = append(entries[i].Impls, *impl)
+		}
+		if found {
+			rows = append(rows, row)
+		}
+	}
+	return entries, rows
+}
+
+// qualified returns the name of a type with its package name.
+func (g *goPackages) qualified(tn *types.TypeName) string {
+	return tn.Pkg().Name() + "." + tn.Name()
+}
+
+// declHref returns the link from the output directory to the section that
+// declares a name at a position, or nothing if the declaration is not in
+// an input file.
+func (g *goPackages) declHref(pos token.Pos, name string) urlPath {
+	if !pos.IsValid() {
+		return ""
+	}
+	filename := g.fset.Position(pos).Filename
+	p, err := lookupRefPage(filename)
+	if err != nil {
+		return ""
+	}
+	href := fsPath(p.output).toURL()
+	if a, ok := p.anchors[name]; ok {
+		href += "#" + urlPath(a.fragment)
+	}
+	return href
+}
+
+// methodHref returns the link to the section that declares a method.
+func (g *goPackages) methodHref(obj types.Object) urlPath {
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return ""
+	}
+	name := fn.Name()
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		t := recv.Type()
+		if p, ok := t.(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		if n, ok := t.(*types.Named); ok {
+			name = n.Obj().Name() + "." + name
+		}
+	}
+	return g.declHref(fn.Pos(), name)
+}
+
+var interfacesTempl = template.Must(template.New("interfaces").Parse(`<!DOCTYPE html>
+<html{{with .ColorScheme}} data-color-scheme="{{.}}"{{end}}>
+<head>
+<title>Interfaces</title>
+<meta charset="utf-8"/>
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+{{if .InlineCSS}}
+<style type="text/css">{{.Style}}</style>
+{{else}}
+<link rel="stylesheet" href="{{.CssPath}}">
+{{end}}
+</head>
+<body>
+<div id="goweave">
+	<header class="title"><h1>Interfaces</h1></header>
+	<nav class="index">
+		{{if .Rows}}
+		<table class="interfaces">
+			<thead><tr><th>Type</th>{{range .Interfaces}}<th><a href="#{{.ID}}">{{.Name}}</a></th>{{end}}</tr></thead>
+			<tbody>
+			{{range .Rows}}<tr><th><a href="{{.Href}}">{{.Type}}</a></th>{{range .Cells}}<td>{{with .}}{{if .Pointer}}*{{else}}✓{{end}}{{end}}</td>{{end}}</tr>
+			{{end}}
+			</tbody>
+		</table>
+		{{else}}
+		<p>No type of the input files implements one of their interfaces.</p>
+		{{end}}
+		{{range .Interfaces}}
+		<h2 id="{{.ID}}"><a href="{{.Href}}">{{.Name}}</a></h2>
+		<ul>
+			{{range .Impls}}<li><a href="{{.Href}}">{{.Type}}</a>: {{range $i, $m := .Methods}}{{if $i}}, {{end}}{{if $m.Href}}<a href="{{$m.Href}}">{{$m.Name}}</a>{{else}}{{$m.Name}}{{end}}{{end}}</li>
+			{{else}}<li>No implementations.</li>
+			{{end}}
+		</ul>
+		{{end}}
+	</nav>
+</div>
+</body>
+</html>
+`))
+
+// saveInterfaces writes the page of the interface implementations, unless
+// one of the documents has its name already.
+func saveInterfaces(name string) error {
+	pagesMu.Lock()
+	for _, p := range pages {
+		if p.Output == urlPath(name) {
+			pagesMu.Unlock()
+			log.Printf("Not writing the interface matrix: %s is the document of %s.", name, p.Source)
+			return nil
+		}
+	}
+	pagesMu.Unlock()
+	entries, rows := interfaceMatrix()
+	var b bytes.Buffer
+	err := interfacesTempl.Execute(&b, struct {
+		CssPath     urlPath
+		Style       template.CSS
+		InlineCSS   bool
+		ColorScheme string
+		Interfaces  []ifaceEntry
+		Rows        []ifaceRow
+	}{cssHref(), style, *inline, fixedColorScheme(), entries, rows})
+	if err != nil {
+		return err
+	}
+	return outputFS().WriteFile(name, b.Bytes())
+}
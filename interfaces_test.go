@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterfaceMatrix(t *testing.T) {
+	defer func(inputs map[string]bool, pages map[string]*refPage) { refInputs, refPages = inputs, pages }(refInputs, refPages)
+	refInputs, refPages = map[string]bool{}, map[string]*refPage{}
+	dir, err := ioutil.TempDir("", "goweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":          "module example.com/mod\n",
+		"shape.go":        "package main\n\n// Shape has an area.\ntype Shape interface {\n\tArea() float64\n}\n\n// Named has a name.\ntype Named interface{ Name() string }\n",
+		"shapes/shape.go": "package shapes\n\n// Square is a shape.\ntype Square struct{}\n\n// Area returns the area.\nfunc (s *Square) Area() float64 { return 0 }\n\n// Circle is round.\ntype Circle struct{ Square }\n\nfunc (Circle) Name() string { return \"circle\" }\n\ntype Other int\n",
+		"main.go":         "package main\n\nimport \"example.com/mod/shapes\"\n\nvar _ Shape = (*shapes.Square)(nil)\n",
+	}
+	var names []string
+	for name, src := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(name) == ".go" {
+			names = append(names, filename)
+		}
+	}
+	indexRefs(names)
+	entries, rows := interfaceMatrix()
+	if len(entries) != 2 || entries[0].Name != "main.Named" || entries[1].Name != "main.Shape" {
+		t.Fatalf("interfaceMatrix() = %+v, want main.Named and main.Shape", entries)
+	}
+	if len(rows) != 2 || rows[0].Type != "shapes.Circle" || rows[1].Type != "shapes.Square" {
+		t.Fatalf("interfaceMatrix() has the rows %+v, want shapes.Circle and shapes.Square", rows)
+	}
+	// Circle is a Named, and *Circle a Shape through the embedded Square.
+	if c := rows[0].Cells; c[0] == nil || c[0].Pointer || c[1] == nil || !c[1].Pointer {
+		t.Errorf("the cells of Circle are %+v, want Named and *Shape", c)
+	}
+	square, err := lookupRefPage(filepath.Join(dir, "shapes", "shape.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fsPath(square.output).toURL() + "#" + urlPath(square.anchors["Square.Area"].fragment)
+	if impls := entries[1].Impls; len(impls) != 2 || impls[1].Type != "*shapes.Square" || impls[1].Methods[0].Href != want || impls[0].Methods[0].Href != want {
+		t.Errorf("the implementations of Shape are %+v, want *shapes.Circle and *shapes.Square, with Area at %s", impls, want)
+	}
+}
@@ -0,0 +1,133 @@
+// ## JSON
+//
+// Tools that want goweave's analysis of a source file rather than a
+// document, like custom renderers, search indexers, or editors, get it with
+// -format=json, which writes the sections as JSON, with the extension
+// .json. Each section has its comment both as the Markdown of the source
+// and as HTML, and its code both as it is and highlighted, along with the
+// lines that both span in the source, its IDs, and what pragmas and
+// options attach to it: the caption, the review status, the order key, and
+// the footnotes of -footnotes. With -boilerplate=collapse, the package
+// clause and the imports come separately, as boilerplate.
+//
+//	{
+//	  "source": "server.go",
+//	  "title": "server.go",
+//	  "language": "go",
+//	  "sections": [
+//	    {
+//	      "id": "5c2f0e1a9b3d",
+//	      "anchor": "the-server.1",
+//	      "doc": "## The server\n\n...",
+//	      "docHtml": "<h2 id=\"the-server\">The server</h2>\n...",
+//	      "docStart": 1,
+//	      "docEnd": 3,
+//	      "code": "type Server struct {\n...",
+//	      "codeHtml": "<span class=\"keyword\">type</span> ...",
+//	      "codeStart": 4,
+//	      "codeEnd": 7
+//	    },
+//	    ...
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// jsonDocument is the JSON of a document.
+type jsonDocument struct {
+	Source   string        `json:"source"`
+	Title    string        `json:"title"`
+	Language string        `json:"language"`
+	Sections []jsonSection `json:"sections"`
+}
+
+// jsonSection is the JSON of a section.
+type jsonSection struct {
+	ID              string         `json:"id"`
+	Anchor          string         `json:"anchor,omitempty"`
+	Position        int            `json:"position"`
+	Doc             string         `json:"doc"`
+	DocHTML         string         `json:"docHtml"`
+	DocStart        int            `json:"docStart,omitempty"`
+	DocEnd          int            `json:"docEnd,omitempty"`
+	Code            string         `json:"code"`
+	CodeHTML        string         `json:"codeHtml"`
+	CodeStart       int            `json:"codeStart,omitempty"`
+	CodeEnd         int            `json:"codeEnd,omitempty"`
+	Boilerplate     string         `json:"boilerplate,omitempty"`
+	BoilerplateHTML string         `json:"boilerplateHtml,omitempty"`
+	Caption         *jsonCaption   `json:"caption,omitempty"`
+	Status          string         `json:"status,omitempty"`
+	Order           string         `json:"order,omitempty"`
+	Footnotes       []jsonFootnote `json:"footnotes,omitempty"`
+}
+
+// jsonCaption is the JSON of a caption.
+type jsonCaption struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Text  string `json:"text"`
+}
+
+// jsonFootnote is the JSON of a footnote.
+type jsonFootnote struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+}
+
+// renderJSON returns the JSON of the sections.
+func renderJSON(d docs, sections []*section) (string, error) {
+	weave.NumberCaptions(sections)
+	splitBoilerplate(sections)
+	doc := jsonDocument{
+		Source:   d.Filename,
+		Title:    d.Title,
+		Language: sourceLanguage(d.Filename).Name,
+		Sections: make([]jsonSection, len(sections)),
+	}
+	for i, s := range sections {
+		js := jsonSection{
+			ID:          s.ID,
+			Anchor:      s.Anchor,
+			Position:    s.Position,
+			Doc:         s.Doc,
+			DocStart:    s.DocStart,
+			DocEnd:      s.DocEnd,
+			Code:        s.Code,
+			CodeStart:   s.CodeStart,
+			CodeEnd:     s.CodeEnd,
+			Boilerplate: s.Boilerplate,
+			Status:      s.Status,
+			Order:       s.Order,
+		}
+		if c := s.Caption; c != nil {
+			js.Caption = &jsonCaption{c.ID(), c.Label(), c.Text}
+		}
+		doc.Sections[i] = js
+	}
+	opts := weaverOptions(d.Filename)
+	if d.source != "" {
+		opts.Links = identLinker(d.source)
+	}
+	weave.New(opts).HighlightSections(sections)
+	markdownComments(sections)
+	for i, s := range sections {
+		js := &doc.Sections[i]
+		js.DocHTML, js.CodeHTML, js.BoilerplateHTML = s.Doc, s.Code, s.Boilerplate
+		for _, f := range s.Footnotes {
+			js.Footnotes = append(js.Footnotes, jsonFootnote{f.Number, f.Text})
+		}
+	}
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
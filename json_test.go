@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderJSON(t *testing.T) {
+	defer func(m bool, f, b string) { *md, *format, *boilerplate = m, f, b }(*md, *format, *boilerplate)
+	*md, *format, *boilerplate = true, formatJSON, boilerplateCollapse
+	src := "// # Title\n//\n// The `main` package.\npackage main\n\nimport \"fmt\"\n\n// Say a & b.\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n"
+	out, err := renderDocs(docs{Filename: "test.go", Title: "test.go"}, extractSections("test.go", src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc jsonDocument
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("renderDocs() is no valid JSON: %v\n%s", err, out)
+	}
+	if doc.Source != "test.go" || doc.Language != "go" || len(doc.Sections) != 2 {
+		t.Fatalf("renderDocs() = %s", out)
+	}
+	first, second := doc.Sections[0], doc.Sections[1]
+	if first.Doc != "# Title\n\nThe `main` package.\n" || !strings.Contains(first.DocHTML, "<code>main</code>") {
+		t.Errorf("doc = %q, docHtml = %q", first.Doc, first.DocHTML)
+	}
+	if !strings.HasPrefix(first.Boilerplate, "package main") || first.BoilerplateHTML == "" || strings.TrimSpace(first.Code) != "" {
+		t.Errorf("boilerplate = %q, code = %q", first.Boilerplate, first.Code)
+	}
+	if strings.TrimSpace(second.Code) != "func main() {\n\tfmt.Println(\"hello\")\n}" || !strings.Contains(second.CodeHTML, `<span class="keyword">func</span>`) {
+		t.Errorf("code = %q, codeHtml = %q", second.Code, second.CodeHTML)
+	}
+	if second.DocStart != 8 || second.DocEnd != 8 || second.CodeStart != 9 || second.CodeEnd != 11 {
+		t.Errorf("lines = %d-%d, %d-%d, want 8-8, 9-11", second.DocStart, second.DocEnd, second.CodeStart, second.CodeEnd)
+	}
+	if !strings.Contains(out, "Say a &amp; b.") {
+		t.Errorf("renderDocs() escapes the HTML for JSON:\n%s", out)
+	}
+}
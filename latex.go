@@ -0,0 +1,94 @@
+// ## LaTeX
+//
+// A thesis about an algorithm can be the program that implements it.
+// -format=tex writes LaTeX documents with the extension .tex, which
+// pdflatex compiles as they are: an article with the prose of the
+// comments (see weave/latex.go) and the code in the listings of the
+// listings package, which knows Go from the definition in the preamble,
+// and the common non-ASCII characters from its literate mappings.
+// -latex-code=minted uses the minted package instead, which highlights
+// better through Pygments but needs `pdflatex -shell-escape`.
+//
+// Each section starts with a \label of its ID, and captions become the
+// captions of the listings, with labels like `listing-1`, so that
+// cross-references can link to them. -toc and -listings add
+// \tableofcontents and the list of listings. With -bare, goweave writes
+// only the body, without \documentclass and preamble, to \input it into a
+// larger document, which then needs the packages that
+// `(*weave.Weaver).LaTeXPreamble` lists. -heading-offset shifts the
+// headings, which start at \section.
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// The values of -latex-code.
+const (
+	latexListings = "listings"
+	latexMinted   = "minted"
+)
+
+// checkLaTeXCode validates the -latex-code option.
+func checkLaTeXCode() error {
+	switch *latexCode {
+	case latexListings, latexMinted:
+		return nil
+	}
+	return fmt.Errorf("-latex-code must be %s or %s, not %q", latexListings, latexMinted, *latexCode)
+}
+
+// renderLaTeX returns the LaTeX document of the sections.
+func renderLaTeX(d docs, sections []*section) string {
+	if *boilerplate == boilerplateHide {
+		splitBoilerplate(sections)
+	}
+	w := weaver()
+	captions := weave.NumberCaptions(sections)
+	w.LaTeXSections(sections)
+	var b strings.Builder
+	if !*bare {
+		b.WriteString("\\documentclass{article}\n\n" + w.LaTeXPreamble() + "\n")
+		if d.ShowTitle {
+			b.WriteString("\\title{" + weave.EscapeLaTeX(d.Title) + "}\n\\date{}\n")
+		}
+		b.WriteString("\n\\begin{document}\n")
+		if d.ShowTitle {
+			b.WriteString("\\maketitle\n")
+		}
+	}
+	if d.TOC != nil {
+		b.WriteString("\\tableofcontents\n")
+	}
+	lang := sourceLanguage(d.Filename).Name
+	for _, s := range sections {
+		if s.ID != "" {
+			b.WriteString("\n\\phantomsection\\label{" + s.ID + "}\n")
+		}
+		if doc := strings.TrimSpace(s.Doc); doc != "" {
+			b.WriteString("\n" + doc + "\n")
+		}
+		if *intro || strings.TrimSpace(s.Code) == "" {
+			continue
+		}
+		caption, label := "", ""
+		if c := s.Caption; c != nil {
+			caption, label = c.Text, c.ID()
+		}
+		b.WriteString("\n" + w.LaTeXCode(s.Code, lang, caption, label))
+	}
+	if *listings && len(captions) > 0 {
+		if *latexCode == latexMinted {
+			b.WriteString("\n\\listoflistings\n")
+		} else {
+			b.WriteString("\n\\lstlistoflistings\n")
+		}
+	}
+	if !*bare {
+		b.WriteString("\n\\end{document}\n")
+	}
+	return b.String()
+}
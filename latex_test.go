@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestRenderLaTeX(t *testing.T) {
+	sections := extractSections("p.go", "// Add adds.\nfunc Add() {}\n\n// See [Add](#x).\nvar x = 1\n")
+	weave.AssignIDs(sections)
+	sections[1].Doc = "See [Add](#" + sections[0].ID + ").\n"
+	got := renderLaTeX(docs{Filename: "p.go"}, sections)
+	for _, want := range []string{
+		"\n\\phantomsection\\label{" + sections[0].ID + "}\n\nAdd adds.\n",
+		"\\hyperref[" + sections[0].ID + "]{Add}",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderLaTeX() =\n%s\nwant %q in it", got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// ## Line numbers
+//
+// With -linenums, each code block of an HTML document gets a gutter with
+// the numbers of its lines. The numbers are those of the source file, not
+// of the snippet: the second section of a file continues where the code
+// of the first one ends, after the lines of its comment, so that a line
+// number in a compiler message or a stack trace can be found right away.
+// Lines that the document leaves out, like the directives in the code and
+// the package clause and imports with -boilerplate, take their numbers
+// with them.
+//
+// The numbers sit in a `pre` of their own, next to the code, which readers
+// cannot select, so copying the code does not copy them.
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// checkLineNums returns an error if -linenums cannot apply to the output.
+func checkLineNums() error {
+	if *lineNums && *md {
+		return fmt.Errorf("-linenums works with HTML output only")
+	}
+	return nil
+}
+
+// sourceLines returns the line in the source of each line of the code of a
+// section, or 0 for lines that do not come from the source.
+func sourceLines(s *section) []int {
+	n := strings.Count(s.Code, "\n")
+	if !strings.HasSuffix(s.Code, "\n") {
+		n++
+	}
+	lines := make([]int, n)
+	if s.CodeStart == 0 {
+		return lines
+	}
+	skipped := map[int]bool{}
+	for _, l := range s.SkippedLines {
+		skipped[l] = true
+	}
+	i := 0
+	for l := s.CodeStart; l <= s.CodeEnd && i < n; l++ {
+		if !skipped[l] {
+			lines[i] = l
+			i++
+		}
+	}
+	return lines
+}
+
+// lineGutters returns the line numbers of the code of the sections, one
+// line per line of code, by section ID, leaving out blank code. before has
+// the lines of the sections ahead of splitBoilerplate, which drops lines
+// from the start of the code.
+func lineGutters(sections []*section, before map[*section][]int) map[string]string {
+	gutters := map[string]string{}
+	for _, s := range sections {
+		if strings.TrimSpace(s.Code) == "" {
+			continue
+		}
+		lines := before[s]
+		if drop := len(lines) - len(sourceLines(s)); drop > 0 {
+			lines = lines[drop:]
+		}
+		var b strings.Builder
+		numbered := false
+		for i, l := range lines {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			if l > 0 {
+				b.WriteString(strconv.Itoa(l))
+				numbered = true
+			}
+		}
+		if numbered {
+			gutters[s.ID] = b.String()
+		}
+	}
+	return gutters
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestLineGutters(t *testing.T) {
+	defer func(b string) { *boilerplate = b }(*boilerplate)
+	src := "// Doc\npackage main\n\nfunc f() {}\n//go:generate x\nfunc g() {}\n\n// More doc\n// on two lines\nfunc h() {}\n"
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{boilerplateShow, []string{"2\n3\n4\n6\n7", "10\n"}},
+		{boilerplateCollapse, []string{"4\n6\n7", "10\n"}},
+		{boilerplateHide, []string{"4\n6\n7", "10\n"}},
+	}
+	for _, tt := range tests {
+		*boilerplate = tt.mode
+		sections := weave.New(weave.Options{}).Sections(src)
+		weave.AssignIDs(sections)
+		lines := map[*section][]int{}
+		for _, s := range sections {
+			lines[s] = sourceLines(s)
+		}
+		splitBoilerplate(sections)
+		got := lineGutters(sections, lines)
+		for i, s := range sections {
+			if got[s.ID] != tt.want[i] {
+				t.Errorf("lineGutters() with %s for section %d = %q, want %q", tt.mode, i, got[s.ID], tt.want[i])
+			}
+		}
+	}
+}
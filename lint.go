@@ -0,0 +1,325 @@
+// ## Linting
+//
+// Prose rots quietly: the code changes, and the comments keep talking
+// about what it used to do. `goweave lint` checks literate source files
+// for the signs of that, without weaving them:
+//
+//	goweave lint [-rules long-code,stale-ident,heading-level] [-max-code-lines 30] <file or dir>...
+//
+// The rules are:
+//
+// * long-code: a section with more than -max-code-lines lines of code and
+// no prose to speak of, that is, fewer words than a documented section
+// needs for -coverage. The package clause and the imports do not count.
+// * stale-ident: a code span in the prose of a Go file that looks like an
+// identifier, like `ParseFile`, `maxLen`, `run()`, or `Weaver.Render`, but
+// names nothing in the package of the file. Plain lowercase words, like
+// `goweave`, and names in capitals, like `GOPATH`, are no identifiers to
+// the rule, as they are as likely to be commands or environment
+// variables; neither are selectors on imported packages, like
+// `strings.Fields`, or on unknown lowercase names, like `robots.txt`.
+// * heading-level: a heading that is more than one level below the
+// heading before it, like a `####` after a `##`.
+//
+// lint writes one line per problem in the format of compilers and vet,
+// `file:line: message (rule)`, which editors and CI systems pick up, and
+// fails if it finds any. The global options that decide what the sections
+// are, like -prose-marker, apply.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The lint rules.
+const (
+	lintLongCode     = "long-code"
+	lintStaleIdent   = "stale-ident"
+	lintHeadingLevel = "heading-level"
+)
+
+var lintRules = []string{lintLongCode, lintStaleIdent, lintHeadingLevel}
+
+// lintProblem is a problem that a lint rule found.
+type lintProblem struct {
+	file string
+	line int
+	rule string
+	msg  string
+}
+
+func (p lintProblem) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", p.file, p.line, p.msg, p.rule)
+}
+
+// linter holds the settings of a lint run.
+type linter struct {
+	rules        map[string]bool
+	maxCodeLines int
+}
+
+var (
+	// proseCodeSpan matches the code spans of Markdown prose.
+	proseCodeSpan = regexp.MustCompile("`([^`\n]+)`")
+	// identSpan matches the code spans that may name identifiers: an
+	// identifier or a selector, with a pointer star or call parentheses.
+	identSpan = regexp.MustCompile(`^\*?([A-Za-z_][A-Za-z0-9_]*)(?:\.([A-Za-z_][A-Za-z0-9_]*))?(?:\(\))?$`)
+	// lintHeading matches the lines of Markdown headings.
+	lintHeading = regexp.MustCompile(`^\s*(#{1,6})\s+\S`)
+)
+
+// lintCommand runs the `lint` subcommand.
+func lintCommand(args []string) error {
+	fset := flag.NewFlagSet("lint", flag.ContinueOnError)
+	rules := fset.String("rules", strings.Join(lintRules, ","), "the rules to check, separated by commas")
+	maxCodeLines := fset.Int("max-code-lines", 30, "the number of lines of code that a section may have without prose")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() == 0 {
+		return fmt.Errorf("usage: goweave lint [-rules rules] [-max-code-lines n] <file or dir>...")
+	}
+	l, err := newLinter(*rules, *maxCodeLines)
+	if err != nil {
+		return err
+	}
+	files, err := expandArgs(fset.Args())
+	if err != nil {
+		return err
+	}
+	n, err := l.lintFiles(os.Stdout, files)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%d problem(s) in %d file(s)", n, len(files))
+	}
+	return nil
+}
+
+// newLinter returns a linter for the rules in a comma-separated list.
+func newLinter(rules string, maxCodeLines int) (*linter, error) {
+	l := &linter{rules: map[string]bool{}, maxCodeLines: maxCodeLines}
+	for _, r := range strings.Split(rules, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		known := false
+		for _, k := range lintRules {
+			known = known || r == k
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown lint rule %q; the rules are %s", r, strings.Join(lintRules, ", "))
+		}
+		l.rules[r] = true
+	}
+	if maxCodeLines < 1 {
+		return nil, fmt.Errorf("-max-code-lines must be at least 1, not %d", maxCodeLines)
+	}
+	return l, nil
+}
+
+// lintFiles writes the problems of the files to w and returns their number.
+func (l *linter) lintFiles(w io.Writer, files []string) (int, error) {
+	n := 0
+	for _, f := range files {
+		src, err := ioutil.ReadFile(f)
+		if err != nil {
+			return n, err
+		}
+		for _, p := range l.lint(f, string(src)) {
+			fmt.Fprintln(w, p)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// lint returns the problems of a source file, by line.
+func (l *linter) lint(filename, src string) []lintProblem {
+	sections := extractSections(filename, src)
+	var problems []lintProblem
+	if l.rules[lintLongCode] {
+		problems = append(problems, l.longCode(filename, sections)...)
+	}
+	if l.rules[lintStaleIdent] && isGoFile(filename) {
+		problems = append(problems, staleIdents(filename, src, sections)...)
+	}
+	if l.rules[lintHeadingLevel] {
+		problems = append(problems, headingLevels(filename, sections)...)
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].line < problems[j].line })
+	return problems
+}
+
+// longCode finds the sections with too much code and too little prose.
+func (l *linter) longCode(filename string, sections []*section) []lintProblem {
+	var problems []lintProblem
+	for i, s := range sections {
+		code := s.Code
+		if i == 0 {
+			code = code[boilerplateLen(code):]
+		}
+		n := 0
+		for _, line := range strings.Split(code, "\n") {
+			if strings.TrimSpace(line) != "" {
+				n++
+			}
+		}
+		if n > l.maxCodeLines && len(strings.Fields(s.Doc)) < minProseWords {
+			problems = append(problems, lintProblem{filename, s.CodeStart, lintLongCode,
+				fmt.Sprintf("%d lines of code without prose (more than %d)", n, l.maxCodeLines)})
+		}
+	}
+	return problems
+}
+
+// headingLevels finds the headings that skip a level.
+func headingLevels(filename string, sections []*section) []lintProblem {
+	var problems []lintProblem
+	last := 0
+	for _, s := range sections {
+		fenced := false
+		for i, line := range strings.Split(s.Doc, "\n") {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				fenced = !fenced
+				continue
+			}
+			m := lintHeading.FindStringSubmatch(line)
+			if fenced || m == nil {
+				continue
+			}
+			level := len(m[1])
+			if last > 0 && level > last+1 {
+				problems = append(problems, lintProblem{filename, s.DocStart + i, lintHeadingLevel,
+					fmt.Sprintf("heading level %d follows level %d", level, last)})
+			}
+			last = level
+		}
+	}
+	return problems
+}
+
+// staleIdents finds the code spans in the prose of a Go file that name
+// identifiers that the package of the file does not have.
+func staleIdents(filename, src string, sections []*section) []lintProblem {
+	idents, imports, ok := packageIdents(filename, src)
+	if !ok {
+		return nil
+	}
+	known := func(name string) bool {
+		return idents[name] || types.Universe.Lookup(name) != nil || token.Lookup(name).IsKeyword()
+	}
+	var problems []lintProblem
+	for _, s := range sections {
+		fenced := false
+		for i, line := range strings.Split(s.Doc, "\n") {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				fenced = !fenced
+			}
+			if fenced {
+				continue
+			}
+			for _, span := range proseCodeSpan.FindAllStringSubmatch(line, -1) {
+				m := identSpan.FindStringSubmatch(span[1])
+				if m == nil || !looksLikeIdent(span[1]) {
+					continue
+				}
+				name, sel := m[1], m[2]
+				missing := ""
+				switch {
+				case sel != "" && imports[name]:
+				case sel != "" && !known(name) && !ast.IsExported(name):
+					// Like robots.txt, or a package that the file does
+					// not import.
+				case !known(name):
+					missing = name
+				case sel != "" && !known(sel):
+					missing = sel
+				}
+				if missing != "" {
+					problems = append(problems, lintProblem{filename, s.DocStart + i, lintStaleIdent,
+						fmt.Sprintf("`%s`: the package has no %s", span[1], missing)})
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// looksLikeIdent tells whether a code span is more likely an identifier
+// than a plain word or an environment variable: it has lowercase letters,
+// and an uppercase letter, an underscore, a digit, a selector, or call
+// parentheses.
+func looksLikeIdent(span string) bool {
+	return strings.ContainsAny(span, "abcdefghijklmnopqrstuvwxyz") &&
+		strings.ContainsAny(span, "ABCDEFGHIJKLMNOPQRSTUVWXYZ_0123456789.(")
+}
+
+// packageIdents returns the identifiers of the package of a Go file, that
+// is, of the .go files in its directory with the same package clause, and
+// the names of the imports of the file. ok is false if the file does not
+// parse.
+func packageIdents(filename, src string) (idents, imports map[string]bool, ok bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ImportsOnly)
+	if err != nil {
+		return nil, nil, false
+	}
+	imports = map[string]bool{}
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		name := path.Base(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		imports[name] = true
+	}
+	pkg := file.Name.Name
+	names, _ := filepath.Glob(filepath.Join(filepath.Dir(filename), "*.go"))
+	sources := map[string]string{filename: src}
+	for _, n := range names {
+		if _, ok := sources[n]; ok || sameFile(fsPath(n), fsPath(filename)) {
+			continue
+		}
+		if data, err := ioutil.ReadFile(n); err == nil {
+			sources[n] = string(data)
+		}
+	}
+	idents = map[string]bool{}
+	for name, s := range sources {
+		f, err := parser.ParseFile(fset, name, s, 0)
+		if err != nil {
+			if name == filename {
+				return nil, nil, false
+			}
+			continue
+		}
+		// The external tests of a package talk about it, too.
+		if strings.TrimSuffix(f.Name.Name, "_test") != strings.TrimSuffix(pkg, "_test") {
+			continue
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				idents[id.Name] = true
+			}
+			return true
+		})
+	}
+	return idents, imports, true
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goweave-lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	other := "package server\n\n// Handler serves.\ntype Handler struct{ maxConns int }\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "handler.go"), []byte(other), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src := `// ## Server
+//
+// The server uses a ` + "`Handler`" + ` and ` + "`strings.Fields`" + `, but no
+// longer ` + "`oldLimit`" + ` or ` + "`Handler.Serve()`" + `. Plain ` + "`goweave`" + `.
+package server
+
+import "strings"
+
+// #### Too deep
+//
+// Counts the fields.
+func count(s string) int { return len(strings.Fields(s)) }
+
+// Long.
+func long() {
+	_ = 1
+	_ = 2
+	_ = 3
+}
+`
+	filename := filepath.Join(dir, "server.go")
+	if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := newLinter("long-code,stale-ident,heading-level", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range l.lint(filename, src) {
+		got = append(got, strings.TrimPrefix(p.String(), filename))
+	}
+	want := []string{
+		":4: `oldLimit`: the package has no oldLimit (stale-ident)",
+		":4: `Handler.Serve()`: the package has no Serve (stale-ident)",
+		":9: heading level 4 follows level 2 (heading-level)",
+		":15: 5 lines of code without prose (more than 2) (long-code)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lint() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := newLinter("long-code,typos", 30); err == nil {
+		t.Error("newLinter() accepts an unknown rule")
+	}
+}
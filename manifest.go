@@ -0,0 +1,189 @@
+// ## The site manifest
+//
+// With -manifest, goweave describes every generated document in a JSON file,
+// so that external tools can find out what was generated without scraping
+// the HTML.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go/ast"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+const manifestFilename = "manifest.json"
+
+type manifest struct {
+	Pages []pageInfo `json:"pages"`
+}
+
+// pageInfo describes a single generated document.
+type pageInfo struct {
+	Source   string   `json:"source"`
+	Output   urlPath  `json:"output"`
+	Title    string   `json:"title"`
+	Summary  string   `json:"summary"`
+	Headings []string `json:"headings"`
+	Hash     string   `json:"hash"`
+	// Package and Symbols describe Go source: the import path of the
+	// package, or its name outside of a module, and the exported
+	// declarations, for `goweave portal`.
+	Package string       `json:"package,omitempty"`
+	Symbols []symbolInfo `json:"symbols,omitempty"`
+}
+
+// symbolInfo is an exported declaration and the ID of its section.
+type symbolInfo struct {
+	Name   string `json:"name"` // like Func or Type.Method
+	Anchor string `json:"anchor"`
+}
+
+// The pages of all files processed so far, by source path. Files are
+// processed concurrently, so access must be synchronized.
+var (
+	pagesMu sync.Mutex
+	pages   = map[string]pageInfo{}
+)
+
+// newPageInfo collects the manifest data of a source file. It must be called
+// before the sections are rendered.
+func newPageInfo(filename, outname, title string, src []byte, sections []*section) pageInfo {
+	sum := sha256.Sum256(src)
+	p := pageInfo{
+		Source:   fsPath(filename).toURL().String(),
+		Output:   fsPath(outname).toURL(),
+		Title:    title,
+		Summary:  docSummary(sections),
+		Headings: docHeadings(sections),
+		Hash:     "sha256:" + hex.EncodeToString(sum[:]),
+	}
+	if *writeManifest && sourceLanguage(filename) == weave.Go {
+		p.Package, p.Symbols = pageSymbols(filename, src)
+	}
+	return p
+}
+
+// pageSymbols returns the package and the exported declarations of a Go
+// file, as far as the file parses.
+func pageSymbols(filename string, src []byte) (string, []symbolInfo) {
+	ref := newRefPage(filename, src)
+	if ref.pkg == "" {
+		return "", nil
+	}
+	pkg := importPath(filename)
+	if pkg == "" {
+		pkg = ref.pkg
+	}
+	var symbols []symbolInfo
+	for name, a := range ref.anchors {
+		if a.code && isExportedName(name) {
+			symbols = append(symbols, symbolInfo{name, a.fragment})
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Name < symbols[j].Name })
+	return pkg, symbols
+}
+
+// isExportedName returns true if a declaration like Type.Method is
+// exported, including the type of a method.
+func isExportedName(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if !ast.IsExported(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func addPage(p pageInfo) {
+	pagesMu.Lock()
+	pages[p.Source] = p
+	pagesMu.Unlock()
+}
+
+// removePage drops the page of a source file, for example, after the file
+// became a draft.
+func removePage(filename string) {
+	pagesMu.Lock()
+	delete(pages, fsPath(filename).toURL().String())
+	pagesMu.Unlock()
+}
+
+// saveManifest writes all collected pages to a JSON file in the output. The pages are
+// sorted by source path, so that the manifest does not depend on the order
+// in which the workers finished their files.
+func saveManifest(name string) error {
+	pagesMu.Lock()
+	m := manifest{Pages: make([]pageInfo, 0, len(pages))}
+	for _, p := range pages {
+		m.Pages = append(m.Pages, p)
+	}
+	pagesMu.Unlock()
+	sort.Slice(m.Pages, func(i, j int) bool { return m.Pages[i].Source < m.Pages[j].Source })
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return outputFS().WriteFile(name, append(data, '\n'))
+}
+
+// ### Headings and summaries
+//
+// Both are taken from the raw Markdown of the comments.
+
+var heading = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.*?)[\s#]*$`)
+
+// docLines calls fn for each line of the comments, skipping lines within
+// fenced code blocks, as these may contain `#` characters that are no headings.
+func docLines(sections []*section, fn func(line string)) {
+	for _, s := range sections {
+		fenced := false
+		for _, line := range strings.Split(s.Doc, "\n") {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				fenced = !fenced
+				continue
+			}
+			if !fenced {
+				fn(line)
+			}
+		}
+	}
+}
+
+// docHeadings returns the text of all Markdown headings in the comments.
+func docHeadings(sections []*section) []string {
+	headings := []string{}
+	docLines(sections, func(line string) {
+		if m := heading.FindStringSubmatch(line); m != nil {
+			headings = append(headings, m[2])
+		}
+	})
+	return headings
+}
+
+// docSummary returns the first paragraph of the comments that is not
+// a heading, with all lines joined into one.
+func docSummary(sections []*section) string {
+	var para []string
+	done := false
+	docLines(sections, func(line string) {
+		line = strings.TrimSpace(line)
+		switch {
+		case done:
+		case line == "":
+			done = len(para) > 0
+		case heading.MatchString(line):
+			done = len(para) > 0
+		default:
+			para = append(para, line)
+		}
+	})
+	return strings.Join(para, " ")
+}
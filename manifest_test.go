@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocHeadings(t *testing.T) {
+	tests := []struct {
+		sections []*section
+		want     []string
+	}{
+		{[]*section{{Doc: "# Title\nText\n", Code: "code\n"}, {Doc: "## Sub ##\n```\n# not a heading\n```\n", Code: ""}},
+			[]string{"Title", "Sub"}},
+		{[]*section{{Doc: "Just text\n", Code: ""}}, []string{}},
+	}
+	for _, tt := range tests {
+		if got := docHeadings(tt.sections); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("docHeadings() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDocSummary(t *testing.T) {
+	tests := []struct {
+		sections []*section
+		want     string
+	}{
+		{[]*section{{Doc: "# Title\n\nFirst line\nsecond line\n\nMore\n", Code: ""}}, "First line second line"},
+		{[]*section{{Doc: "", Code: "package main\n"}, {Doc: "Summary\n# Next\n", Code: ""}}, "Summary"},
+		{[]*section{{Doc: "", Code: "package main\n"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := docSummary(tt.sections); got != tt.want {
+			t.Errorf("docSummary() = %q, want %q", got, tt.want)
+		}
+	}
+}
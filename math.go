@@ -0,0 +1,38 @@
+// ## Math
+//
+// Write-ups of algorithms need formulas, and TeX is how most people write
+// them. With -math, the comments can have TeX between dollar signs, `$O(n
+// \log n)$` within the text and `$$...$$` on lines of their own, which the
+// weave package keeps away from Markdown. The browser typesets the formulas
+// with [KaTeX](https://katex.org), from the directory that -katex-url points
+// to: by default the one on jsDelivr, or a copy within the output
+// directory, given by its path relative to it, for documents that have to
+// work offline. Documents load KaTeX only if they have formulas.
+package main
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// katexHref returns the KaTeX directory of -katex-url as a document in
+// root sees it.
+func katexHref(root urlPath) string {
+	dir := strings.TrimSuffix(*katexURL, "/")
+	u, err := url.Parse(dir)
+	if err != nil || u.Scheme != "" || u.Host != "" || path.IsAbs(u.Path) {
+		return dir
+	}
+	return string(root) + dir
+}
+
+// hasMath reports whether any section has a formula.
+func hasMath(sections []*section) bool {
+	for _, s := range sections {
+		if strings.Contains(s.Doc, `class="math `) {
+			return true
+		}
+	}
+	return false
+}
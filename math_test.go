@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestKatexHref(t *testing.T) {
+	defer func(u string) { *katexURL = u }(*katexURL)
+	tests := []struct {
+		url  string
+		root urlPath
+		want string
+	}{
+		{"https://cdn.jsdelivr.net/npm/katex@0.16.11/dist/", "../", "https://cdn.jsdelivr.net/npm/katex@0.16.11/dist"},
+		{"/katex", "../", "/katex"},
+		{"katex", "../../", "../../katex"},
+		{"katex", "", "katex"},
+	}
+	for _, tt := range tests {
+		*katexURL = tt.url
+		if got := katexHref(tt.root); got != tt.want {
+			t.Errorf("katexHref(%q) with %q = %q, want %q", tt.root, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestHasMath(t *testing.T) {
+	defer func(m bool) { *texMath = m }(*texMath)
+	for _, on := range []bool{false, true} {
+		*texMath = on
+		sections := []*section{{Doc: "Costs $O(n \\log n)$ time.\n"}}
+		markdownComments(sections)
+		if got := hasMath(sections); got != on {
+			t.Errorf("hasMath() with -math=%v = %v, doc %s", on, got, sections[0].Doc)
+		}
+	}
+}
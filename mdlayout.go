@@ -0,0 +1,69 @@
+// ## Side-by-side Markdown
+//
+// Markdown has no columns, so the Markdown output puts the code of each
+// section beneath its prose. With -md-layout=table, goweave writes an HTML
+// table with one row per section instead, the prose in the left cell and
+// the code in the right one. The cells keep their Markdown: CommonMark
+// ends an HTML block at a blank line, so the prose and the fenced code
+// between the blank lines around them are Markdown again, and renderers
+// that allow inline HTML, like those of GitHub and GitLab, show the prose
+// and the code side by side, with the code highlighted as usual.
+//
+// Hugo leaves out inline HTML unless the site sets
+// `markup.goldmark.renderer.unsafe`. The intro of -intro has no code, so it
+// stays plain.
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// The values of -md-layout.
+const (
+	mdLayoutPlain = "plain"
+	mdLayoutTable = "table"
+)
+
+// checkMDLayout validates the -md-layout option, and warns if -intro
+// leaves it without effect.
+func checkMDLayout() error {
+	switch *mdLayout {
+	case mdLayoutPlain:
+		return nil
+	case mdLayoutTable:
+		if *intro {
+			log.Print("-md-layout=table has no effect with -intro: the intro has no code to put beside the prose")
+		}
+		return nil
+	}
+	return fmt.Errorf("-md-layout must be %s or %s, not %q", mdLayoutPlain, mdLayoutTable, *mdLayout)
+}
+
+// tableLayout joins the sections of a Markdown document into a table of
+// two columns, prose and code. The rows get the IDs of the sections, for
+// links to them.
+func tableLayout(sections []*section) string {
+	b := getBuffer()
+	defer bufPool.Put(b)
+	b.WriteString("<table>\n")
+	for _, s := range sections {
+		if s.ID != "" {
+			b.WriteString(`<tr id="` + s.ID + `">` + "\n")
+		} else {
+			b.WriteString("<tr>\n")
+		}
+		for _, cell := range []string{s.Doc, s.Code} {
+			cell = strings.Trim(cell, "\n")
+			if strings.TrimSpace(cell) == "" {
+				b.WriteString("<td></td>\n")
+				continue
+			}
+			b.WriteString(`<td valign="top">` + "\n\n" + cell + "\n\n</td>\n")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n")
+	return b.String()
+}
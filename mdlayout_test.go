@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTableLayout(t *testing.T) {
+	sections := []*section{
+		{Doc: "# Title\n\nThe prose.\n", Code: "\n", ID: "a1"},
+		{Doc: "", Code: "\n```go\nfunc main() {}\n```\n", ID: "b2"},
+	}
+	want := "<table>\n" +
+		"<tr id=\"a1\">\n<td valign=\"top\">\n\n# Title\n\nThe prose.\n\n</td>\n<td></td>\n</tr>\n" +
+		"<tr id=\"b2\">\n<td></td>\n<td valign=\"top\">\n\n```go\nfunc main() {}\n```\n\n</td>\n</tr>\n" +
+		"</table>\n"
+	if got := tableLayout(sections); got != want {
+		t.Errorf("tableLayout() = %q, want %q", got, want)
+	}
+
+	// The markers of -section-ids still work for unweave.
+	markSections(sections)
+	prose := markdownProse([]byte(tableLayout(sections)))
+	if got := prose["a1"]; got != "# Title\n\nThe prose." {
+		t.Errorf("prose of a1 = %q", got)
+	}
+	if got, ok := prose["b2"]; !ok || strings.TrimSpace(got) != "" {
+		t.Errorf("prose of b2 = %q, %v", got, ok)
+	}
+}
+
+func TestCheckMDLayout(t *testing.T) {
+	defer func(l string, i bool) { *mdLayout, *intro = l, i }(*mdLayout, *intro)
+	defer log.SetOutput(os.Stderr)
+	for _, tt := range []struct {
+		layout string
+		intro  bool
+		ok     bool
+		warn   bool
+	}{
+		{mdLayoutPlain, false, true, false},
+		{mdLayoutTable, false, true, false},
+		{mdLayoutPlain, true, true, false},
+		{mdLayoutTable, true, true, true},
+		{"grid", false, false, false},
+	} {
+		var logged bytes.Buffer
+		log.SetOutput(&logged)
+		*mdLayout, *intro = tt.layout, tt.intro
+		if err := checkMDLayout(); (err == nil) != tt.ok {
+			t.Errorf("checkMDLayout() with %q = %v", tt.layout, err)
+		}
+		if warned := logged.Len() > 0; warned != tt.warn {
+			t.Errorf("checkMDLayout() with %q and -intro=%v warned %q", tt.layout, tt.intro, logged.String())
+		}
+	}
+}
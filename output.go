@@ -0,0 +1,113 @@
+// ## Output destinations
+//
+// goweave writes everything it generates, from the documents to the CSS,
+// the images, and the index page, through a weave.OutputFS. Usually, that
+// is the -outdir directory. An -outdir that ends in `.zip` is a zip archive
+// instead, which makes publishing the site a matter of uploading one file:
+//
+//	goweave -outdir=site.zip ./...
+//
+// An archive always starts out empty, so there is no build cache to use,
+// and -watch and -serve need a directory.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+var (
+	output     weave.OutputFS // the destination, if it is not the -outdir directory
+	outputFile *os.File       // the archive file behind output, if any
+)
+
+// removeOutput deletes a file that an earlier run wrote to the output
+// directory. An archive starts out empty, so it has nothing to delete.
+func removeOutput(name string) error {
+	dir, ok := outputFS().(weave.Dir)
+	if !ok {
+		return nil
+	}
+	err := os.Remove(filepath.Join(string(dir), filepath.FromSlash(name)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// outputFS returns the destination of the generated files.
+func outputFS() weave.OutputFS {
+	if output != nil {
+		return output
+	}
+	return weave.Dir(*outdir)
+}
+
+// isOutputArchive returns true if -outdir names a zip archive.
+func isOutputArchive() bool {
+	return strings.HasSuffix(strings.ToLower(*outdir), ".zip")
+}
+
+// openOutput creates the archive that -outdir names, if it names one.
+func openOutput() error {
+	if !isOutputArchive() {
+		return nil
+	}
+	if *watch || serve != "" {
+		return fmt.Errorf("-watch and -serve need an output directory, not an archive")
+	}
+	f, err := os.Create(*outdir)
+	if err != nil {
+		return err
+	}
+	outputFile, output = f, weave.NewZipFS(f)
+	return nil
+}
+
+// closeOutput completes the output archive, if there is one.
+func closeOutput() error {
+	z, ok := output.(*weave.ZipFS)
+	if !ok {
+		return nil
+	}
+	err := z.Close()
+	if cerr := outputFile.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// copyToOutput copies a file to the output, under the slash-separated name.
+// If the output is a directory, the file is copied without reading it into
+// memory, and a file that is its own destination is left alone.
+func copyToOutput(name, src string) error {
+	dir, ok := outputFS().(weave.Dir)
+	if !ok {
+		data, err := ioutil.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		return outputFS().WriteFile(name, data)
+	}
+	dst := fsPath(dir).join(filepath.FromSlash(name))
+	if sameFile(dst, fsPath(src)) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(string(dst)), 0755); err != nil {
+		return err
+	}
+	return copyFile(string(dst), src)
+}
+
+// inOutputDir reports whether the file src is where the output would put a
+// copy of it, which happens when the output directory is the source
+// directory.
+func inOutputDir(name, src string) bool {
+	dir, ok := outputFS().(weave.Dir)
+	return ok && sameFile(fsPath(dir).join(filepath.FromSlash(name)), fsPath(src))
+}
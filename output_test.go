@@ -0,0 +1,94 @@
+package main
+
+import (
+	"archive/zip"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestMemoryOutput(t *testing.T) {
+	defer func(o weave.OutputFS, m bool) { output, *md = o, m }(output, *md)
+	mem := &weave.MemFS{}
+	output, *md = mem, true
+	if err := writeDocument(docs{Title: "a.go"}, extractSections("a.go", "// Text\npackage main"), "a.go", "pkg/a.md"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeRedirects([]string{"old.html"}, "pkg/a.md", "A", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := mem.Names(), []string{"old.html", "pkg/a.md"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("the output holds %v, want %v", got, want)
+	}
+}
+
+func TestArchiveOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(o weave.OutputFS, d string, m bool) { output, *outdir, *md = o, d, m }(output, *outdir, *md)
+	*outdir, *md = filepath.Join(dir, "site.zip"), true
+	if err := openOutput(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeDocument(docs{Title: "a.go"}, extractSections("a.go", "// Text\npackage main"), "a.go", "a.md"); err != nil {
+		t.Fatal(err)
+	}
+	if err := closeOutput(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(*outdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	doc, err := fs.ReadFile(zr, "a.md")
+	if err != nil || !strings.Contains(string(doc), "Text") {
+		t.Errorf("the archive holds %q, %v; want the document", doc, err)
+	}
+}
+
+func TestRemoveDraft(t *testing.T) {
+	dir, err := ioutil.TempDir("", "draft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(o weave.OutputFS, d string, m, dr bool) { output, *outdir, *md, *drafts = o, d, m, dr }(output, *outdir, *md, *drafts)
+	output, *outdir, *md, *drafts = nil, filepath.Join(dir, "out"), true, false
+	filename := filepath.Join(dir, "a.go")
+	if err := ioutil.WriteFile(filename, []byte("// Text\npackage main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := processFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	doc := filepath.Join(*outdir, filepath.FromSlash(outputName(filename)))
+	if _, err := os.Stat(doc); err != nil {
+		t.Fatalf("no document: %v", err)
+	}
+	source := fsPath(filename).toURL().String()
+	if _, ok := pages[source]; !ok {
+		t.Fatalf("the manifest has no page for %s", source)
+	}
+	if err := ioutil.WriteFile(filename, []byte("//goweave:draft\n\n// Text\npackage main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := processFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(doc); !os.IsNotExist(err) {
+		t.Errorf("the document of the draft is still there: %v", err)
+	}
+	if _, ok := pages[source]; ok {
+		t.Errorf("the manifest still has a page for %s", source)
+	}
+}
@@ -0,0 +1,122 @@
+// ## PDF output
+//
+// Literate articles often go out as handouts. With -pdf, goweave prints
+// each document to a PDF file next to it, with the same name and the
+// extension `.pdf`. The printing is left to a Chrome or Chromium browser in
+// headless mode, which renders the document as it looks on screen, with the
+// rules of the `@media print` block of the CSS file applied on top. goweave
+// looks for the browser in the PATH and in the usual install locations;
+// -pdf-browser names another one.
+//
+// The browser loads the document from the output directory, along with the
+// CSS, fonts, and images, so -pdf needs an output directory rather than an
+// archive, and HTML rather than Markdown.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+var (
+	// pdfBrowsers are the browser commands that -pdf tries, in order.
+	pdfBrowsers = []string{
+		"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome",
+		"microsoft-edge", "msedge",
+	}
+	// pdfBrowserPaths are the usual install locations of the browsers that
+	// are not in the PATH.
+	pdfBrowserPaths = []string{
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+		`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+	}
+	// pdfBrowserPath is the browser that checkPDF found.
+	pdfBrowserPath string
+)
+
+// checkPDF returns an error if -pdf cannot work with the other options, or
+// if there is no browser to print with.
+func checkPDF() error {
+	if !*pdf {
+		return nil
+	}
+	if *md {
+		return fmt.Errorf("-pdf does not work with -md")
+	}
+	if _, ok := outputFS().(weave.Dir); !ok {
+		return fmt.Errorf("-pdf needs an output directory, not an archive")
+	}
+	browser, err := findBrowser()
+	if err != nil {
+		return err
+	}
+	pdfBrowserPath = browser
+	return nil
+}
+
+// findBrowser returns the path of the browser that prints the documents.
+func findBrowser() (string, error) {
+	if *pdfBrowser != "" {
+		return exec.LookPath(*pdfBrowser)
+	}
+	for _, name := range pdfBrowsers {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+	for _, p := range pdfBrowserPaths {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("-pdf needs Chrome or Chromium; install one, or name it with -pdf-browser")
+}
+
+// pdfName returns the name of the PDF file of a document.
+func pdfName(outname string) string {
+	return strings.TrimSuffix(outname, path.Ext(outname)) + ".pdf"
+}
+
+// writePDF prints a document of the output directory to its PDF file.
+func writePDF(outname string) error {
+	dir, ok := outputFS().(weave.Dir)
+	if !ok {
+		return fmt.Errorf("-pdf needs an output directory, not an archive")
+	}
+	src, err := filepath.Abs(filepath.Join(string(dir), filepath.FromSlash(outname)))
+	if err != nil {
+		return err
+	}
+	dst := strings.TrimSuffix(src, filepath.Ext(src)) + ".pdf"
+	// Remove the old file, so that a browser that fails silently does not
+	// leave it behind as the result.
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	p := filepath.ToSlash(src)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // C:/dir becomes /C:/dir
+	}
+	u := url.URL{Scheme: "file", Path: p}
+	cmd := exec.Command(pdfBrowserPath, "--headless", "--disable-gpu",
+		"--no-pdf-header-footer", "--print-to-pdf-no-header",
+		"--print-to-pdf="+dst, u.String())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("printing %s to PDF: %v: %s", outname, err, bytes.TrimSpace(out))
+	}
+	if _, err := os.Stat(dst); err != nil {
+		return fmt.Errorf("printing %s to PDF: the browser wrote no PDF file: %s", outname, bytes.TrimSpace(out))
+	}
+	return nil
+}
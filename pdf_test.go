@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// fakeBrowser writes a shell script that acts like a headless Chrome: it
+// writes the URL that it got into the file of --print-to-pdf.
+const fakeBrowser = `#!/bin/sh
+for arg; do
+	case "$arg" in
+	--print-to-pdf=*) out="${arg#--print-to-pdf=}" ;;
+	file://*) url="$arg" ;;
+	esac
+done
+echo "$url" > "$out"
+`
+
+func TestPDF(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake browser is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(o weave.OutputFS, d string, p, m bool, b, path string) {
+		output, *outdir, *pdf, *md, *pdfBrowser, pdfBrowserPath = o, d, p, m, b, path
+	}(output, *outdir, *pdf, *md, *pdfBrowser, pdfBrowserPath)
+	browser := filepath.Join(dir, "browser")
+	if err := ioutil.WriteFile(browser, []byte(fakeBrowser), 0755); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	output, *outdir, *pdf, *md, *pdfBrowser = nil, out, true, false, browser
+	if err := checkPDF(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeDocument(docs{Title: "a.go"}, extractSections("a.go", "// Text\npackage main"), "a.go", "pkg/a.html"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(out, "pkg", "a.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/pkg/a.html"; !strings.HasPrefix(string(got), "file://") || !strings.Contains(string(got), want) {
+		t.Errorf("the browser printed %s, want a file URL of %s", got, want)
+	}
+
+	*md = true
+	if err := checkPDF(); err == nil {
+		t.Errorf("checkPDF() with -md succeeded")
+	}
+	*md = false
+	output = &weave.MemFS{}
+	if err := checkPDF(); err == nil {
+		t.Errorf("checkPDF() with an archive succeeded")
+	}
+	output, *pdfBrowser = nil, filepath.Join(dir, "no-such-browser")
+	if err := checkPDF(); err == nil {
+		t.Errorf("checkPDF() with a missing browser succeeded")
+	}
+}
+
+func TestPDFName(t *testing.T) {
+	tests := []struct{ outname, want string }{
+		{"a.html", "a.pdf"},
+		{"pkg/b.go.html", "pkg/b.go.pdf"},
+		{"c", "c.pdf"},
+	}
+	for _, tt := range tests {
+		if got := pdfName(tt.outname); got != tt.want {
+			t.Errorf("pdfName(%q) = %q, want %q", tt.outname, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// ## Package summary
+//
+// With -pkg-summary, a Go document starts with a summary in the manner of
+// godoc: the name of the package, its import path, the first sentence of
+// the package comment, and a list of the exported identifiers, each
+// linking to the section that declares it. Together with -by-decl, this
+// turns a woven library into lightweight API documentation. As in the
+// links of cross-references, a section on another page of a -split
+// document is linked on that page, and text formats without anchors for
+// sections, like Markdown, list the identifiers without links.
+//
+// The import path is the module path from the go.mod file above the
+// source file, plus the directory of the file within the module. Without
+// a go.mod file, or for package main, the summary has no import path.
+package main
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"path/filepath"
+	"strings"
+)
+
+// summaryID is the ID of the section that holds the package summary.
+const summaryID = "pkg-summary"
+
+// summarySections returns the sections with the package summary in front.
+// source is the input file, if any, for the import path and the pages of
+// -split, and outname the name of the document. The sections stay as they
+// are if their code does not parse.
+func summarySections(sections []*section, source, outname string) []*section {
+	c, err := parseSections(sections)
+	if err != nil {
+		return sections
+	}
+	// go/doc wants the name of a Go file; the positions stay valid for
+	// c.sectionOf, as the file set is the same.
+	file, err := parser.ParseFile(c.fset, "summary.go", c.src, 0)
+	if err != nil {
+		return sections
+	}
+	pkg, err := doc.NewFromFiles(c.fset, []*ast.File{file}, "")
+	if err != nil {
+		return sections
+	}
+	var b strings.Builder
+	b.WriteString("## Package " + pkg.Name + "\n\n")
+	if path := importPath(source); path != "" && pkg.Name != "main" {
+		b.WriteString("`import \"" + path + "\"`\n\n")
+	}
+	if s := pkg.Synopsis(c.packageDoc(file)); s != "" {
+		b.WriteString(s + "\n\n")
+	}
+	href := func(id string) string { return "#" + id }
+	if page, err := lookupRefPage(source); source != "" && err == nil {
+		href = func(id string) string { return splitHref(page, id, outname, true) + "#" + id }
+	}
+	link := func(indent, text string, n ast.Node) {
+		if !sectionAnchors() {
+			b.WriteString(indent + "* `" + text + "`\n")
+			return
+		}
+		b.WriteString(indent + "* [`" + text + "`](" + href(c.sectionOf(n.Pos()).ID) + ")\n")
+	}
+	values := func(indent string, values []*doc.Value) {
+		for _, v := range values {
+			link(indent, v.Decl.Tok.String()+" "+strings.Join(v.Names, ", "), v.Decl)
+		}
+	}
+	funcs := func(indent string, funcs []*doc.Func) {
+		for _, f := range funcs {
+			name := f.Name
+			if f.Recv != "" {
+				name = "(" + f.Recv + ") " + name
+			}
+			link(indent, "func "+name, f.Decl)
+		}
+	}
+	values("", pkg.Consts)
+	values("", pkg.Vars)
+	funcs("", pkg.Funcs)
+	for _, t := range pkg.Types {
+		link("", "type "+t.Name, t.Decl)
+		values("  ", t.Consts)
+		values("  ", t.Vars)
+		funcs("  ", t.Funcs)
+		funcs("  ", t.Methods)
+	}
+	summary := &section{Doc: b.String(), Code: "\n", ID: summaryID}
+	return append([]*section{summary}, sections...)
+}
+
+// packageDoc returns the prose above the package clause, without the
+// Markdown headings of literate files, which would make no synopsis.
+func (c *sectionCode) packageDoc(file *ast.File) string {
+	i := c.sectionAt(file.Package)
+	if strings.TrimSpace(c.src[c.starts[i]:c.fset.Position(file.Package).Offset]) != "" {
+		return ""
+	}
+	var lines []string
+	for _, l := range strings.Split(c.sections[i].Doc, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(l), "#") {
+			lines = append(lines, l)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// importPath returns the import path of the package of a source file, or
+// "" if the file belongs to no module.
+func importPath(source string) string {
+	if source == "" {
+		return ""
+	}
+	dir := filepath.Dir(source)
+	m := findModule(dir)
+	if m.path == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(m.root, abs)
+	if err != nil || rel == "." {
+		return m.path
+	}
+	return m.path + "/" + filepath.ToSlash(rel)
+}
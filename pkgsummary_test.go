@@ -0,0 +1,92 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestPackageSummary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(dir, "shapes", "shapes.go")
+	src := "// ## Shapes\n//\n// Package shapes draws shapes. It knows circles.\npackage shapes\n\n" +
+		"// Pi is round.\nconst Pi = 3.14\n\n// Circle is a shape.\ntype Circle struct{}\n\n" +
+		"// NewCircle returns a circle.\nfunc NewCircle() *Circle { return nil }\n\n// Draw draws it.\nfunc (c *Circle) Draw() {}\n\nfunc helper() {}\n"
+	sections := extractSections(source, src)
+	weave.AssignIDs(sections)
+	sections = summarySections(sections, source, outputName(source))
+	if sections[0].ID != summaryID {
+		t.Fatalf("summarySections() put %s first, want %s", sections[0].ID, summaryID)
+	}
+	doc := sections[0].Doc
+	idOf := func(code string) string {
+		for _, s := range sections {
+			if strings.Contains(s.Code, code) {
+				return s.ID
+			}
+		}
+		return "missing"
+	}
+	for _, want := range []string{
+		"## Package shapes\n",
+		"`import \"example.com/m/shapes\"`",
+		"\nPackage shapes draws shapes.\n",
+		"* [`const Pi`](#" + idOf("const Pi") + ")\n",
+		"* [`type Circle`](#" + idOf("type Circle") + ")\n",
+		"  * [`func NewCircle`](#" + idOf("func NewCircle") + ")\n",
+		"  * [`func (*Circle) Draw`](#" + idOf("func (c *Circle) Draw") + ")\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("the summary is\n%s\nwant %q in it", doc, want)
+		}
+	}
+	if strings.Contains(doc, "helper") {
+		t.Errorf("the summary lists the unexported helper:\n%s", doc)
+	}
+
+	// Markdown has no anchors for sections.
+	defer func(m bool) { *md = m }(*md)
+	*md = true
+	sections = extractSections(source, src)
+	weave.AssignIDs(sections)
+	if doc := summarySections(sections, source, outputName(source))[0].Doc; !strings.Contains(doc, "* `const Pi`\n") {
+		t.Errorf("the Markdown summary is\n%s\nwant the entries without links", doc)
+	}
+	*md = false
+
+	// With -split, the links lead to the pages of the sections.
+	defer func(n int) { *split = n }(*split)
+	defer func(inputs map[string]bool, pages map[string]*refPage) { refInputs, refPages = inputs, pages }(refInputs, refPages)
+	refInputs, refPages = map[string]bool{}, map[string]*refPage{}
+	*split = 2
+	src = strings.Replace(src, "// Draw draws it.", "// ## Drawing\n//\n// Draw draws it.", 1)
+	if err := os.MkdirAll(filepath.Dir(source), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(source, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	indexRefs([]string{source})
+	sections = extractSections(source, src)
+	weave.AssignIDs(sections)
+	sections = summarySections(sections, source, outputName(source))
+	for _, want := range []string{
+		"* [`const Pi`](#" + idOf("const Pi") + ")\n",
+		"  * [`func (*Circle) Draw`](shapes-drawing.html#" + idOf("func (c *Circle) Draw") + ")\n",
+	} {
+		if !strings.Contains(sections[0].Doc, want) {
+			t.Errorf("the split summary is\n%s\nwant %q in it", sections[0].Doc, want)
+		}
+	}
+}
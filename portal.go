@@ -0,0 +1,298 @@
+// ## Portals
+//
+// Organizations with many repositories get one site per repository. The
+// `portal` subcommand joins them:
+//
+//	goweave portal [-outdir portal] [-title Documentation] <site or module>...
+//
+// Each argument is the output directory of a run with -manifest, or the
+// root directory of a module, which the portal weaves into a directory of
+// its own in the portal directory first. A `name=dir` argument sets the
+// name of the site; the default is the module path. The portal writes an
+// index.html that lists the documents of all sites, with a search box that
+// finds documents, headings, and exported identifiers across all of them,
+// so that a reader who looks for `Client.Do` lands on the section that
+// declares it, whatever repository it lives in.
+//
+// The links are relative, so the sites have to keep their places relative
+// to the portal directory when they get published.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// portalSite is a woven site as seen by the portal template.
+type portalSite struct {
+	Name  string
+	Pages []portalPage
+}
+
+// portalPage is a document of a site.
+type portalPage struct {
+	Title   string
+	Href    urlPath
+	Summary string
+}
+
+// portalEntry is something that the search of the portal can find.
+type portalEntry struct {
+	Kind   string  `json:"kind"` // page, heading, or symbol
+	Text   string  `json:"text"`
+	Detail string  `json:"detail"` // the site and the page, or the package
+	Href   urlPath `json:"href"`
+}
+
+// portalCommand runs the `portal` subcommand.
+func portalCommand(args []string) error {
+	fset := flag.NewFlagSet("portal", flag.ContinueOnError)
+	// The portal directory is the output directory, for outputFS.
+	fset.StringVar(outdir, "outdir", "portal", "the directory of the portal")
+	title := fset.String("title", "Documentation", "the title of the portal")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() == 0 {
+		return fmt.Errorf("usage: goweave portal [-outdir dir] [-title title] <site or module>...")
+	}
+	if err := os.MkdirAll(*outdir, 0755); err != nil {
+		return err
+	}
+	var sites []portalSite
+	var entries []portalEntry
+	subdirs := map[string]int{}
+	for _, arg := range fset.Args() {
+		name, dir := "", arg
+		if i := strings.Index(arg, "="); i > 0 {
+			name, dir = arg[:i], arg[i+1:]
+		}
+		if name == "" {
+			name = siteName(dir)
+		}
+		// Modules get woven into a directory named like the last element
+		// of their path.
+		sub := path.Base(filepath.ToSlash(name))
+		if subdirs[sub]++; subdirs[sub] > 1 {
+			sub += "-" + strconv.Itoa(subdirs[sub])
+		}
+		site, err := portalManifest(dir, filepath.Join(*outdir, sub))
+		if err != nil {
+			return err
+		}
+		s, e, err := portalEntries(name, site, *outdir)
+		if err != nil {
+			return err
+		}
+		sites = append(sites, s)
+		entries = append(entries, e...)
+	}
+	css, err := fs.ReadFile(weave.Resources(), cssfilename)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	err = portalTempl.Execute(&b, struct {
+		Title  string
+		Style  template.CSS
+		Sites  []portalSite
+		Search []portalEntry
+	}{*title, template.CSS(css), sites, entries})
+	if err != nil {
+		return err
+	}
+	log.Printf("%s: %d site(s), %d search entries", filepath.Join(*outdir, indexName), len(sites), len(entries))
+	return outputFS().WriteFile(indexName, b.Bytes())
+}
+
+// siteName returns the default name of a site: the module path of a
+// module root, or else the name of the directory.
+func siteName(dir string) string {
+	if m := findModule(dir); m.path != "" {
+		if abs, err := filepath.Abs(dir); err == nil && abs == m.root {
+			return m.path
+		}
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return filepath.Base(dir)
+}
+
+// portalManifest returns the directory of the manifest of a site. A
+// module root without a manifest gets woven into the directory site by
+// another goweave process, which runs in the module root with the global
+// options of this one.
+func portalManifest(dir, site string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, manifestFilename)); err == nil {
+		return dir, nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+		return "", fmt.Errorf("%s has neither a %s nor a go.mod file", dir, manifestFilename)
+	}
+	abs, err := filepath.Abs(site)
+	if err != nil {
+		return "", err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	var args []string
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "outdir" && f.Name != "manifest" {
+			args = append(args, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	args = append(args, "-manifest", "-outdir="+abs, "./...")
+	log.Printf("Weaving %s into %s", dir, site)
+	cmd := exec.Command(self, args...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("weaving %s: %v", dir, err)
+	}
+	return site, nil
+}
+
+// portalEntries reads the manifest of a site and returns the site and its
+// search entries, with links relative to the portal directory.
+func portalEntries(name, dir, outdir string) (portalSite, []portalEntry, error) {
+	site := portalSite{Name: name}
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestFilename))
+	if err != nil {
+		return site, nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return site, nil, fmt.Errorf("%s: %v", filepath.Join(dir, manifestFilename), err)
+	}
+	rel, err := relDir(outdir, dir)
+	if err != nil {
+		return site, nil, err
+	}
+	var entries []portalEntry
+	for _, p := range m.Pages {
+		href := rel.join(string(p.Output))
+		site.Pages = append(site.Pages, portalPage{p.Title, href, p.Summary})
+		entries = append(entries, portalEntry{"page", p.Title, name, href})
+		for _, h := range p.Headings {
+			entries = append(entries, portalEntry{"heading", h, name + " › " + p.Title, href})
+		}
+		for _, s := range p.Symbols {
+			entries = append(entries, portalEntry{"symbol", path.Base(p.Package) + "." + s.Name, p.Package, urlPath(string(href) + "#" + s.Anchor)})
+		}
+	}
+	return site, entries, nil
+}
+
+// relDir returns the path from one directory to another as a URL path.
+func relDir(from, to string) (urlPath, error) {
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		return "", err
+	}
+	absTo, err := filepath.Abs(to)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absFrom, absTo)
+	if err != nil {
+		return "", err
+	}
+	return fsPath(rel).toURL(), nil
+}
+
+var portalTempl = template.Must(template.New("portal").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>{{.Title}}</title>
+<meta charset="utf-8"/>
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<style type="text/css">{{.Style}}</style>
+</head>
+<body>
+<div id="goweave">
+	<header class="title"><h1>{{.Title}}</h1></header>
+	<nav class="index">
+		<p><input id="portal-search" type="search" placeholder="Search documents, headings, and identifiers" aria-label="Search" autocomplete="off" size="40"></p>
+		<ul id="portal-results" hidden></ul>
+		<div id="portal-sites">
+		{{range .Sites}}
+		<h2>{{.Name}}</h2>
+		<ul>
+			{{range .Pages}}
+			<li>
+				<a href="{{.Href}}">{{.Title}}</a>
+				{{if .Summary}}<div class="summary">{{.Summary}}</div>{{end}}
+			</li>
+			{{end}}
+		</ul>
+		{{end}}
+		</div>
+	</nav>
+</div>
+<script>
+(function() {
+	var entries = {{.Search}} || [];
+	var input = document.getElementById("portal-search");
+	var results = document.getElementById("portal-results");
+	var sites = document.getElementById("portal-sites");
+	var kinds = {symbol: 0, heading: 1, page: 2};
+	input.addEventListener("input", function() {
+		var words = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+		results.textContent = "";
+		results.hidden = words.length === 0;
+		sites.hidden = words.length > 0;
+		if (words.length === 0) {
+			return;
+		}
+		var found = entries.filter(function(e) {
+			var text = (e.text + " " + e.detail).toLowerCase();
+			return words.every(function(w) { return text.indexOf(w) >= 0; });
+		});
+		// Identifiers first, and exact names before partial ones.
+		var query = input.value.trim().toLowerCase();
+		found.sort(function(a, b) {
+			var ea = a.text.toLowerCase() === query || a.text.toLowerCase().endsWith("." + query);
+			var eb = b.text.toLowerCase() === query || b.text.toLowerCase().endsWith("." + query);
+			return (eb - ea) || (kinds[a.kind] - kinds[b.kind]) || a.text.localeCompare(b.text);
+		});
+		found.slice(0, 50).forEach(function(e) {
+			var li = document.createElement("li");
+			var a = document.createElement("a");
+			a.href = e.href;
+			a.textContent = e.text;
+			if (e.kind === "symbol") {
+				a.className = "symbol";
+			}
+			var detail = document.createElement("div");
+			detail.className = "summary";
+			detail.textContent = e.detail;
+			li.appendChild(a);
+			li.appendChild(detail);
+			results.appendChild(li);
+		});
+		if (found.length === 0) {
+			results.innerHTML = "<li>Nothing found.</li>";
+		}
+	});
+})();
+</script>
+</body>
+</html>
+`))
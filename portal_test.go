@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPageSymbols(t *testing.T) {
+	src := "package p\n\n// T is a type.\ntype T struct{}\n\n// Get gets.\nfunc (t *T) Get() {}\n\nfunc (t *T) set() {}\n\ntype hidden int\n\n// New makes a T.\nfunc New() *T { return nil }\n"
+	pkg, symbols := pageSymbols("p.go", []byte(src))
+	// Within a module, the package is the import path of the directory.
+	want := importPath("p.go")
+	if want == "" {
+		want = "p"
+	}
+	if pkg != want {
+		t.Errorf("pageSymbols() returned the package %q, want %q", pkg, want)
+	}
+	var names []string
+	for _, s := range symbols {
+		names = append(names, s.Name)
+		if s.Anchor == "" {
+			t.Errorf("%s has no anchor", s.Name)
+		}
+	}
+	if wantNames := []string{"New", "T", "T.Get"}; !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("pageSymbols() = %q, want %q", names, wantNames)
+	}
+}
+
+func TestPortalEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "portal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	site := filepath.Join(dir, "sites", "lib")
+	if err := os.MkdirAll(site, 0755); err != nil {
+		t.Fatal(err)
+	}
+	m := `{"pages": [{"source": "lib.go", "output": "lib.html", "title": "The lib", "summary": "Does things.", "headings": ["Usage"],
+		"package": "example.com/lib", "symbols": [{"name": "Client.Do", "anchor": "abc123"}]}]}`
+	if err := ioutil.WriteFile(filepath.Join(site, manifestFilename), []byte(m), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := portalManifest(site, filepath.Join(dir, "portal", "lib"))
+	if err != nil || got != site {
+		t.Fatalf("portalManifest() = %q, %v, want the site directory", got, err)
+	}
+	s, entries, err := portalEntries("lib", site, filepath.Join(dir, "portal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []portalPage{{"The lib", "../sites/lib/lib.html", "Does things."}}; !reflect.DeepEqual(s.Pages, want) {
+		t.Errorf("portalEntries() has the pages %v, want %v", s.Pages, want)
+	}
+	want := []portalEntry{
+		{"page", "The lib", "lib", "../sites/lib/lib.html"},
+		{"heading", "Usage", "lib › The lib", "../sites/lib/lib.html"},
+		{"symbol", "lib.Client.Do", "example.com/lib", "../sites/lib/lib.html#abc123"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("portalEntries() = %v, want %v", entries, want)
+	}
+
+	if _, err := portalManifest(dir, filepath.Join(dir, "portal", "x")); err == nil {
+		t.Errorf("portalManifest() accepted a directory without a manifest or a go.mod file")
+	}
+
+	// The index goes to the portal directory, through outputFS.
+	defer func(d string) { *outdir = d }(*outdir)
+	portal := filepath.Join(dir, "portal")
+	if err := portalCommand([]string{"-outdir", portal, "-title", "Docs", "lib=" + site}); err != nil {
+		t.Fatal(err)
+	}
+	if index, err := ioutil.ReadFile(filepath.Join(portal, indexName)); err != nil || !strings.Contains(string(index), "The lib") {
+		t.Errorf("portalCommand() wrote %q, %v", index, err)
+	}
+}
@@ -0,0 +1,34 @@
+// ## Project files
+//
+// A Go project is more than its .go files. With -project-files, goweave
+// also picks up the go.mod, go.sum, and go.work files, Makefiles, and
+// Dockerfiles in the directories it walks, and weaves them like any other
+// source file, by the comment syntax that the weave package registers for
+// their names. Their documents keep the full file name, like
+// `go.mod.html`, so that `go.mod` and `go.sum` do not compete for
+// `go.html`. The index page lists them apart from the Go files, and they
+// do not count toward the documentation coverage.
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// projectFileNames are the names of the project files, in lower case.
+var projectFileNames = map[string]bool{
+	"go.mod": true, "go.sum": true, "go.work": true,
+	"makefile": true, "gnumakefile": true, "dockerfile": true,
+}
+
+// isProjectFile returns true if the file describes the project rather than
+// implementing it, like go.mod.
+func isProjectFile(name string) bool {
+	return projectFileNames[strings.ToLower(filepath.Base(name))]
+}
+
+// isInputFile returns true for the files that goweave collects from
+// directories.
+func isInputFile(name string) bool {
+	return isGoFile(name) || *projectFiles && isProjectFile(name)
+}
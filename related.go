@@ -0,0 +1,179 @@
+// ## Related pages
+//
+// With -related, each document ends with a list of links to the documents
+// that are most similar to it. To find them, goweave reads all input files
+// before generating any document, and compares them by three features:
+//
+// * the tags from the `//goweave:tags` pragma,
+// * the packages that the files import, and
+// * the words in the comments.
+//
+// Each feature contributes the share of items that two files have in common
+// (the Jaccard index), with tags weighing most, as they are chosen by the
+// author for exactly this purpose.
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxRelated is the maximum number of related pages per document.
+const maxRelated = 3
+
+// The weights of the features. They add up to 1.
+const (
+	tagWeight    = 0.5
+	importWeight = 0.25
+	wordWeight   = 0.25
+)
+
+// relatedPage is a link to a related document, as seen by the template.
+type relatedPage struct {
+	Title string
+	Href  urlPath
+}
+
+// pageFeatures are the properties of a source file that the similarity is
+// computed from.
+type pageFeatures struct {
+	title   string
+	output  string
+	tags    map[string]bool
+	imports map[string]bool
+	words   map[string]bool
+}
+
+// relatedIndex holds the features of all input files, keyed by file name.
+var (
+	relatedIndex   = map[string]pageFeatures{}
+	relatedIndexMu sync.Mutex
+)
+
+// word matches the words that are long enough to say something about the
+// topic of a text.
+var word = regexp.MustCompile(`\pL{4,}`)
+
+// newPageFeatures extracts the features of a source file. Files that do not
+// parse as Go source simply have no imports.
+func newPageFeatures(filename string, src []byte, fm frontMatter) pageFeatures {
+	f := pageFeatures{
+		title:   filepath.Base(filename),
+		output:  outputName(filename),
+		tags:    map[string]bool{},
+		imports: map[string]bool{},
+		words:   map[string]bool{},
+	}
+	if t := fm["title"]; t != "" {
+		f.title = t
+	}
+	for _, tag := range strings.FieldsFunc(fm["tags"], func(r rune) bool { return r == ',' || r == ' ' }) {
+		f.tags[strings.ToLower(tag)] = true
+	}
+	if file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.ImportsOnly); err == nil {
+		for _, imp := range file.Imports {
+			if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+				f.imports[path] = true
+			}
+		}
+	}
+	for _, s := range extractSections(filename, string(src)) {
+		for _, w := range word.FindAllString(s.Doc, -1) {
+			f.words[strings.ToLower(w)] = true
+		}
+	}
+	return f
+}
+
+// indexRelated reads all input files and records their features. Files that
+// cannot be read are left out here; processFile reports the error later.
+func indexRelated(filenames []string) {
+	for _, filename := range filenames {
+		if tooLarge(filename) {
+			continue
+		}
+		src, err := readSource(filename)
+		if err != nil || isBinary(src) {
+			continue
+		}
+		fm := parseFrontMatter(filename, string(src))
+		if !fm.isDraft() {
+			setRelated(filename, newPageFeatures(filename, src, fm))
+		}
+	}
+}
+
+// setRelated updates the features of a file, for example, after the file
+// has changed in watch mode.
+func setRelated(filename string, f pageFeatures) {
+	relatedIndexMu.Lock()
+	relatedIndex[filename] = f
+	relatedIndexMu.Unlock()
+}
+
+// jaccard returns the share of items that two sets have in common.
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for k := range a {
+		if b[k] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// similarity returns a value between 0 (nothing in common) and 1 (same
+// tags, imports, and words).
+func similarity(a, b pageFeatures) float64 {
+	return tagWeight*jaccard(a.tags, b.tags) +
+		importWeight*jaccard(a.imports, b.imports) +
+		wordWeight*jaccard(a.words, b.words)
+}
+
+// relatedPages returns up to maxRelated documents that are most similar to
+// the given file, most similar first. Documents with nothing in common with
+// the file are never related to it.
+func relatedPages(filename string) []relatedPage {
+	relatedIndexMu.Lock()
+	defer relatedIndexMu.Unlock()
+	self, ok := relatedIndex[filename]
+	if !ok {
+		return nil
+	}
+	type candidate struct {
+		pageFeatures
+		score float64
+	}
+	var candidates []candidate
+	for name, f := range relatedIndex {
+		if name == filename {
+			continue
+		}
+		if score := similarity(self, f); score > 0 {
+			candidates = append(candidates, candidate{f, score})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].output < candidates[j].output
+	})
+	if len(candidates) > maxRelated {
+		candidates = candidates[:maxRelated]
+	}
+	related := make([]relatedPage, len(candidates))
+	for i, c := range candidates {
+		related[i] = relatedPage{Title: c.title, Href: rootPath(self.output) + urlPath(c.output)}
+	}
+	return related
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJaccard(t *testing.T) {
+	set := func(keys ...string) map[string]bool {
+		m := map[string]bool{}
+		for _, k := range keys {
+			m[k] = true
+		}
+		return m
+	}
+	tests := []struct {
+		a, b map[string]bool
+		want float64
+	}{
+		{set(), set("a"), 0},
+		{set("a"), set("a"), 1},
+		{set("a", "b"), set("b", "c"), 1.0 / 3},
+		{set("a"), set("b"), 0},
+	}
+	for _, tt := range tests {
+		if got := jaccard(tt.a, tt.b); got != tt.want {
+			t.Errorf("jaccard(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRelatedPages(t *testing.T) {
+	defer func(idx map[string]pageFeatures) { relatedIndex = idx }(relatedIndex)
+	relatedIndex = map[string]pageFeatures{}
+	files := map[string]string{
+		"server.go": "//goweave:tags http, web\n// A web server.\npackage main\n\nimport \"net/http\"\n",
+		"client.go": "//goweave:title An HTTP Client\n//goweave:tags http\n// A web client.\npackage main\n\nimport \"net/http\"\n",
+		"math.go":   "// Some arithmetic.\npackage main\n\nimport \"math\"\n",
+		"router.go": "// Routing requests in a web server.\npackage main\n",
+	}
+	for name, src := range files {
+		setRelated(name, newPageFeatures(name, []byte(src), parseFrontMatter(name, src)))
+	}
+	want := []relatedPage{{"An HTTP Client", "client.html"}, {"router.go", "router.html"}}
+	if got := relatedPages("server.go"); !reflect.DeepEqual(got, want) {
+		t.Errorf("relatedPages() = %v, want %v", got, want)
+	}
+	if got := relatedPages("unknown.go"); got != nil {
+		t.Errorf("relatedPages() of an unknown file = %v, want none", got)
+	}
+}
@@ -0,0 +1,132 @@
+// ## Remote code
+//
+// The code function of the templates also takes a URL, for an example that
+// lives elsewhere, like in the repository of a library:
+//
+//	{{code "https://example.com/hello.go#sha256=2c26b46b68ffc68f..." 1 0}}
+//
+// goweave downloads the file at build time and keeps it in its cache
+// directory, under the SHA-256 hash of its content. The hash in the
+// fragment of the URL pins the content: goweave takes the file from the
+// cache without going online, which makes the build reproducible and work
+// offline after the first run, and fails if a download has another hash. A
+// URL without a hash gets downloaded on every run, and goweave logs the
+// hash to pin it with.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// unpinnedURLs are the URLs without a hash that goweave logged the hash of
+// already.
+var unpinnedURLs sync.Map
+
+// isRemote returns true if a file name is an HTTP or HTTPS URL.
+func isRemote(name string) bool {
+	return strings.HasPrefix(name, "https://") || strings.HasPrefix(name, "http://")
+}
+
+// remoteName returns the path of the file that a URL points to, for
+// picking the language of the code.
+func remoteName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return path.Base(u.Path)
+	}
+	return rawURL
+}
+
+// readCodeFile reads a local file or, for a URL, a remote one.
+func readCodeFile(name string) ([]byte, error) {
+	if isRemote(name) {
+		return readRemote(name)
+	}
+	return ioutil.ReadFile(name)
+}
+
+// readRemote returns the content of a URL, from the cache if the URL pins
+// a hash that the cache has.
+func readRemote(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	pin := ""
+	if u.Fragment != "" {
+		if !strings.HasPrefix(u.Fragment, "sha256=") {
+			return nil, fmt.Errorf("%s: the fragment must be a hash like #sha256=<hex>", rawURL)
+		}
+		pin = strings.ToLower(strings.TrimPrefix(u.Fragment, "sha256="))
+		if _, err := hex.DecodeString(pin); err != nil || len(pin) != 2*sha256.Size {
+			return nil, fmt.Errorf("%s: %q is no SHA-256 hash", rawURL, pin)
+		}
+	}
+	u.Fragment = ""
+	if pin != "" {
+		if data, err := ioutil.ReadFile(remoteCachePath(pin)); err == nil && contentHash(data) == pin {
+			return data, nil
+		}
+	}
+	data, err := download(u.String())
+	if err != nil {
+		return nil, err
+	}
+	sum := contentHash(data)
+	if pin != "" && sum != pin {
+		return nil, fmt.Errorf("%s: the download has the hash %s, not the pinned %s", u, sum, pin)
+	}
+	if pin == "" {
+		if _, logged := unpinnedURLs.LoadOrStore(u.String(), true); !logged {
+			log.Printf("%s is not pinned; add #sha256=%s to the URL to pin its content.", u, sum)
+		}
+	}
+	if err := cacheRemote(sum, data); err != nil {
+		log.Printf("Cannot cache %s: %v", u, err)
+	}
+	return data, nil
+}
+
+// contentHash returns the hex SHA-256 hash of data.
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// remoteCachePath returns the path of the cached file with a hash.
+func remoteCachePath(sum string) string {
+	return filepath.Join(cacheDir(), "remote", sum)
+}
+
+// cacheRemote stores a download in the cache, atomically, so that
+// concurrent builds never see half of a file.
+func cacheRemote(sum string, data []byte) error {
+	name := remoteCachePath(sum)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(name), "download")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
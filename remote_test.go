@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadRemote(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(x string) { os.Setenv("XDG_CACHE_HOME", x) }(os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", dir)
+
+	content := "package hello\n"
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/hello.go" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	url := server.URL + "/hello.go"
+	sum := contentHash([]byte(content))
+	for _, u := range []string{url, url + "#sha256=" + sum} {
+		got, err := readCodeFile(u)
+		if err != nil || string(got) != content {
+			t.Errorf("readCodeFile(%s) = %q, %v, want %q", u, got, err, content)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("the pinned URL was downloaded again, not taken from the cache (%d requests)", requests)
+	}
+
+	// A pinned file does not need the server, and a wrong pin is an error.
+	server.Close()
+	if got, err := readRemote(url + "#sha256=" + sum); err != nil || string(got) != content {
+		t.Errorf("readRemote() offline = %q, %v, want %q", got, err, content)
+	}
+	for _, u := range []string{url + "#sha256=" + strings.Repeat("0", 64), url + "#sha256=abc", url + "#md5=abc"} {
+		if _, err := readRemote(u); err == nil {
+			t.Errorf("readRemote(%s) succeeded, want an error", u)
+		}
+	}
+
+	if got := remoteName(url + "#sha256=" + sum); got != "hello.go" {
+		t.Errorf("remoteName() = %q, want hello.go", got)
+	}
+}
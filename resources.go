@@ -1,329 +0,0 @@
-// Code generated by go-bindata.
-// sources:
-// resources/.goweave.css.swp
-// resources/.goweave.css.un~
-// resources/goweave.css
-// resources/goweave.css.bak
-// resources/goweave.templ
-// DO NOT EDIT!
-
-package main
-
-import (
-	"bytes"
-	"compress/gzip"
-	"fmt"
-	"io"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
-)
-
-func bindataRead(data []byte, name string) ([]byte, error) {
-	gz, err := gzip.NewReader(bytes.NewBuffer(data))
-	if err != nil {
-		return nil, fmt.Errorf("Read %q: %v", name, err)
-	}
-
-	var buf bytes.Buffer
-	_, err = io.Copy(&buf, gz)
-	clErr := gz.Close()
-
-	if err != nil {
-		return nil, fmt.Errorf("Read %q: %v", name, err)
-	}
-	if clErr != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
-}
-
-type asset struct {
-	bytes []byte
-	info  os.FileInfo
-}
-
-type bindataFileInfo struct {
-	name    string
-	size    int64
-	mode    os.FileMode
-	modTime time.Time
-}
-
-func (fi bindataFileInfo) Name() string {
-	return fi.name
-}
-func (fi bindataFileInfo) Size() int64 {
-	return fi.size
-}
-func (fi bindataFileInfo) Mode() os.FileMode {
-	return fi.mode
-}
-func (fi bindataFileInfo) ModTime() time.Time {
-	return fi.modTime
-}
-func (fi bindataFileInfo) IsDir() bool {
-	return false
-}
-func (fi bindataFileInfo) Sys() interface{} {
-	return nil
-}
-
-var _resourcesGoweaveCssSwp = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xec\xda\x5b\x8c\x14\x4b\x19\x07\xf0\xe2\x04\xf0\x0c\xca\xf1\x5c\x4c\x3c\x6a\xf4\xd4\x59\x50\x16\x32\xd3\x33\xb3\x17\x58\x67\xbd\xb0\x2e\x72\x5d\x78\x41\x50\x44\x1e\x6a\xba\x6b\xa6\xdb\xed\xee\x6a\xab\x6b\x66\x77\x31\x0b\x3e\x40\x50\xe3\x25\x1a\x4d\x34\x0a\x0a\xea\x83\x4f\x10\x88\xca\x83\x0f\x44\xa3\x08\xc2\xc6\x47\x34\x10\xf5\x59\x13\x10\x09\x5e\xe2\x83\xff\xee\xe9\x9e\xe9\xe9\x99\xd9\x85\x17\xd1\xd8\xdf\xe6\x77\xa6\xb7\xb6\xba\xba\xea\xab\x4b\xef\x09\x5b\x2d\x1d\xde\xb3\x9f\x6e\xd3\xc6\x08\xe2\x45\x42\x5e\x39\xb2\xfe\xa3\x3f\xbc\x77\xe7\x39\x7a\x96\x10\xdd\x94\x96\xaf\x84\x67\x92\xe5\x63\x46\x28\xd3\x16\xd2\xe2\x6e\x61\x66\xea\x80\x56\x93\x96\x3a\xae\x55\xc5\x7c\xba\xde\x89\x76\x83\xc5\x1d\xbc\xc9\x6d\xe1\x39\xdc\x55\xc5\x5d\xa2\x38\x2d\x0c\x5e\xf4\xa5\x5e\xac\x5b\xca\x6c\x54\x35\x5d\x38\xc5\x76\xe5\x2a\x97\x75\x2e\x8b\x75\x31\xc7\x59\x93\x17\x25\xf7\x45\x43\xea\xdc\x8f\x4b\x34\xdd\xf7\x57\xe8\x61\x16\x59\xfc\x9f\x47\x43\xd5\x0a\x13\xeb\xc9\xe8\x48\xb9\x14\x7c\xbb\x61\xe8\x75\xfa\xca\xcb\x87\x9e\x75\xaf\xb2\xc8\x22\x8b\x2c\xb2\xc8\x22\x8b\x2c\xb2\xc8\x22\x8b\xff\x60\x28\x6f\x15\x39\x89\xcf\xe7\xa2\xef\xaf\x45\x9f\xab\x52\x9f\x59\x64\x91\x45\x16\x59\x64\x91\x45\x16\x59\x64\x91\x45\x16\x59\x64\x91\xc5\xff\x6e\x30\x83\x90\x75\xf8\x9f\xff\xf7\xac\x21\xe1\xbf\xff\x5f\x8b\xca\x1f\xbc\x99\x90\xfb\xf0\x59\x30\xa1\x06\x55\x60\xf0\x71\xd8\x0d\x3b\xe1\xc3\xf0\x01\x78\x0d\x5e\x80\x7f\xbd\x40\xc8\x4d\xb8\x0a\xdf\x83\xf3\xf0\x5d\xf8\x3c\x4c\x43\x09\x34\x28\x40\x1e\xde\x0a\x6b\xe1\xef\xeb\x09\xb9\x0b\xbf\x83\xdf\xc2\x75\xb8\x0c\x97\xe0\x22\x9c\x85\x33\x70\x1a\x4e\xc1\x1c\x54\x61\x1f\x94\xe1\x1d\xf0\x36\x78\x15\x72\xf0\xe8\x4d\x84\x3c\x84\xbf\xc0\x3d\xb8\x01\xd7\xe1\x97\x70\x19\xce\xc1\xb7\xe1\x5b\xf0\x39\x68\x80\x0f\x12\x8e\xc1\x0c\xec\x85\x3d\x30\x0e\x43\x40\xe1\x35\x58\x05\x3f\x7b\x23\xfa\x05\xdf\x84\x53\xe0\xc0\x21\xf8\x20\xbc\x1f\xde\x07\x8f\xd7\x11\xf2\x7b\xb8\x07\x77\xe1\x16\x5c\x81\xef\xc0\x17\x61\x1e\x74\x38\x0c\xdb\x61\x18\x5e\x82\x7f\xe4\x08\xf9\x1b\x3c\x86\x3f\xc2\x6f\x60\x09\x6e\xc3\x4f\xe1\x22\x7c\x03\x3e\x03\x36\x1c\x81\xed\xb0\x11\x86\xe0\x75\x58\x0b\x0f\x9f\xc7\x3c\xc2\x7d\x58\x82\x1f\xc1\x15\xb8\x0c\x5f\x87\x53\x20\xe0\x28\xec\x80\x0f\xc1\x46\x78\x0b\xbc\x0c\x2f\xc1\x3f\xdf\x80\x76\x60\x09\x6e\xc3\x8f\xe1\xfb\xf0\x55\x38\x0d\x0d\x90\xf0\x29\x38\x06\x7b\x61\x17\xec\x84\x51\xa0\xf0\x3c\xac\x81\xd5\xf0\xd7\xb5\x18\x13\x2c\xc1\xaf\xe1\x26\x5c\x85\x1f\xc0\x05\x38\x0f\x27\xc1\x81\x4f\xc2\x3e\xa8\xc0\x36\x18\x87\x31\x78\x15\x5e\x84\x3f\x63\xcd\xfe\x09\xee\xc0\xaf\xe0\x17\xf0\x73\xb8\x04\x5f\x83\xaf\xc0\x97\xe1\x4b\x70\x06\x4e\xc2\x09\x68\xc2\x27\xe0\x08\x7c\x0c\x76\x42\x1e\x36\xc3\xf0\x9a\xd6\x5e\x78\x17\xbc\x13\xde\x0e\xeb\xe0\xd1\x6a\xf4\x1d\x1e\xc0\x1f\xe0\x36\xdc\x82\x1b\xf0\x13\xb8\x00\xe7\xe1\x1c\x7c\x61\xf5\x33\xdc\xd0\xff\xcd\xb1\x88\xbd\xb9\x48\x72\x39\x5d\xd8\x89\x4b\x21\x2b\x74\x03\x63\x8c\xf4\x96\xa1\x64\xb1\x5f\xcd\xdc\x13\x57\x4d\x17\x0d\x6c\x71\xc3\x72\x35\xe3\xcf\x2a\xd3\x67\xeb\x52\x34\x5c\xa3\x10\x57\xab\xd5\x6a\x93\xb8\x3b\xfa\x5b\x24\xaa\xe9\xc2\xe0\xf4\xd3\x2b\xd5\xaf\x0a\x63\x21\xa8\xe5\x49\xcb\x55\xb8\xd8\xee\x70\xc3\x62\x41\x86\xc2\xfb\x3c\x66\x18\x96\x5b\xaf\xd0\x92\x36\xce\x1d\xda\xfe\x4f\xf2\x49\x86\xd5\xd4\x94\x81\x7b\x69\xfa\x9e\x3e\xf5\x64\xf0\x30\xe1\xda\x0b\xd4\xd7\x25\xe7\x2e\x65\xae\x41\x87\x1d\x36\x5f\x98\xb3\x0c\x65\x56\xe8\x18\x5a\xdf\xdc\xee\x08\x25\xc5\x2d\xf4\x30\x97\x0b\xd4\x65\x52\x8a\x39\xda\xb4\xf8\x9c\x27\xa4\xf2\xe9\x96\x62\xbb\x97\x86\xe5\x7b\x36\x5b\xa8\x50\x57\xb8\xbc\xb7\x6b\x61\x2a\x34\xee\x78\x0a\x43\xa5\xa9\x5b\xaa\xb6\xd0\x67\x7b\xbb\xa9\xf9\x5c\x57\x96\x70\x35\x57\x74\x25\x32\x1a\x5c\x41\x09\xaf\x42\xcb\xdc\x19\x98\xf1\xde\x8a\x39\xc7\x72\xe3\x51\xa6\x52\xa3\x19\x42\x4f\xdf\x19\xde\x15\x9b\xec\xed\x32\xed\x37\x05\x7d\x07\xd7\x53\x53\x06\x69\x88\x16\x86\x90\x06\x97\xed\xcc\xe5\x1c\x26\xeb\x96\x8b\x0e\xe2\x1b\x4a\x73\x51\x77\xcb\xa5\xd2\xbb\x83\x66\x56\x4a\x1b\xab\xda\x3c\x6c\x7b\xb9\x19\xde\x5a\xea\x99\xe1\x03\x03\x26\x37\x35\xb1\xcb\xcf\x2b\xea\xb7\x7a\x5f\xa8\x0a\xa5\x84\x83\x6e\x6b\x5b\xc3\xd4\x45\xc5\x36\xaf\xa9\x0a\x65\x0d\x25\x3a\x65\xd2\xaa\x9b\x9d\xc2\xf4\xf0\xd2\x0f\xc4\x34\x25\x16\x44\xb2\x83\xe1\xd0\x0b\x3a\xf3\x82\x45\x93\xbe\xb1\xdf\x6a\x5a\x24\x14\x11\x6f\xc6\xb1\x52\xf0\x95\xb8\xaf\xb5\x98\x3c\xc9\x69\x78\x11\x3d\xae\xcf\x36\xe6\x7a\xf0\x35\x19\xb6\xd6\xe4\x52\x59\x3a\xb3\x0b\xcc\xb6\xea\x98\x44\xac\x3d\x0c\x4a\xa0\xb8\x66\x8b\xb9\xc2\xfc\x80\xc1\x87\x8b\x31\xb8\x3f\x5e\xb3\x51\x79\x39\x5d\xde\x4a\xe0\x48\x98\xd3\xd6\x64\xb6\xda\xeb\xdd\x02\x9d\xb9\x08\xd7\x3f\x9e\xd0\x6f\x68\xed\x34\x24\xbb\x68\x5a\x86\xc1\xdd\x81\x0f\x7e\x92\x7e\xc6\xcf\x4c\x75\x33\xa8\x92\x58\x87\xa3\xed\x71\x27\x36\xe6\x48\xeb\xb6\x9a\x70\x55\xc1\xb7\x8e\x73\xb4\x2e\xe3\x6a\xe9\xc9\x1a\x98\xf2\x9e\x7d\xbd\x18\x3d\xbb\x93\x91\x68\x69\x76\x6a\x46\x2b\xc3\x2c\xe7\x3b\xd7\x23\x89\xeb\xd1\xc4\xf5\x58\xe2\x7a\xbc\xa7\xfd\x56\xb2\x3a\x83\x4b\xed\x89\x54\x79\x7a\x0d\xa4\xa6\xad\x55\xa8\xf8\xbc\x8a\x47\x18\x34\x8f\x0c\xd9\x96\xcb\x0b\x26\x4f\xcc\x40\x2b\x69\x35\xe6\x58\x36\x36\xc4\xa6\x29\xa4\x06\xdb\x7b\xc6\xaa\x4a\xbe\x29\x4f\x37\xcd\x30\x25\x82\xcf\xdd\xdc\x6e\xf2\x20\x67\xf8\xc6\x67\xae\x5f\xf0\x51\xaf\x96\x48\x45\x90\x82\x60\xe8\xc1\x90\x83\xa1\x26\x87\x18\x4f\xc1\x68\x95\x8d\x96\x4a\x5d\x6b\xca\x09\xfe\x86\x37\x5a\x7b\x83\xb7\x95\xf0\xb8\x44\x47\xe4\x93\x6c\x41\xcb\x68\xb7\x98\xac\x38\x3d\xf5\xde\xe9\x52\x57\x45\xdb\x52\x68\xd5\xee\xad\xaa\x97\xb7\x6d\xed\xae\x3a\xcb\x17\xe6\x70\xe4\x76\xaa\xb6\x4f\x10\xcb\x0d\x52\xda\x7d\x74\x3c\xe5\xdc\x96\xb4\x89\xa7\x9d\xdd\xf6\xd3\xbc\x01\x27\x1a\xb7\xed\x9e\x73\xb0\x7f\x55\x9c\xe0\x3d\x07\x5f\x7c\x6e\x85\x6f\x99\xe0\xcc\xb2\x99\xe7\x63\x53\xc5\x57\xed\x1d\xda\x7a\xc5\xa4\xdb\x4c\xb7\xd7\x7a\xbf\x84\xbf\xc0\x25\xb7\xa8\x36\x31\x8e\x4d\x1a\x0e\xb0\x6b\x0d\xee\xe7\xae\x2d\xf2\x74\xbf\x70\x99\x8e\xcf\x69\xe1\xfa\xc2\x66\x7e\x9e\x0e\xcd\x34\x74\xcb\x60\x51\x09\x1f\xca\x53\x47\xb8\xc2\xf7\x98\x8e\x47\x2a\x95\x0f\x0f\xdd\x3c\x9d\xad\x1a\xc1\x1a\x75\xe2\xdc\x74\xaf\x79\x6d\x74\xf0\x51\xd1\xbd\x15\x56\x5a\xfa\x89\xb3\xab\x67\xae\x12\x05\xfd\x7e\x89\x9b\x08\xbe\x26\x49\xfc\x6b\x5c\x77\x5e\xca\x23\x1e\xb6\xaa\xa9\x9c\x70\x69\x06\x2f\x55\x4a\xd0\x35\x49\x95\xc9\x29\xb6\x01\xde\x48\x0c\x09\xad\x72\xd3\xc2\x3b\x5a\x99\x96\xaf\x11\x64\xcb\x57\xd4\xe1\xcc\x6f\x60\x34\xd8\x00\x3e\x65\x38\xaa\xeb\x56\x13\xaf\x72\xcb\xa5\xdc\xd1\xe8\x41\xce\xe9\xd1\x8f\xa0\x8d\x5d\xc2\x36\xac\xe0\x55\xe9\xd3\x29\xcf\x93\x82\xe9\xe6\xb1\x61\x53\x29\xaf\x52\x2c\xd6\xdb\x3f\x63\xd1\x8f\xc2\xbf\xa6\x37\xb8\x23\x8a\x9b\x49\xf0\xd6\x8f\xa7\x76\xfa\xe0\x41\x4a\xfe\x1d\x00\x00\xff\xff\x4e\x50\xce\xf0\x00\x30\x00\x00")
-
-func resourcesGoweaveCssSwpBytes() ([]byte, error) {
-	return bindataRead(
-		_resourcesGoweaveCssSwp,
-		"resources/.goweave.css.swp",
-	)
-}
-
-func resourcesGoweaveCssSwp() (*asset, error) {
-	bytes, err := resourcesGoweaveCssSwpBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "resources/.goweave.css.swp", size: 12288, mode: os.FileMode(420), modTime: time.Unix(1460492574, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _resourcesGoweaveCssUn = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xec\xdd\x5b\x6c\x1c\xd5\xfd\x07\xf0\xe3\x10\x12\x86\x78\x13\xdf\xe3\x5c\x19\x72\x21\x10\x6c\x63\x27\x71\x6e\x26\xe0\xdc\x21\x04\xfe\xf0\x07\xb2\x04\xfe\x10\xc6\xbb\x6b\x7b\xff\xd9\xdd\x59\xd6\x1b\x9b\x94\xf2\xd0\x87\x52\x04\x29\x54\x55\x45\xa1\xdc\x84\x4a\x5b\x89\x87\x3e\x54\x95\x5a\x01\x85\x00\x2d\x14\x51\x89\xbe\xf5\xa1\xaa\xd4\x96\x5e\x54\xf5\xa1\x3c\xf4\x22\xb5\x54\xf4\x7c\xcf\x9c\x33\xf3\x9b\xd9\xf1\x7a\xf0\x1c\x47\x7d\x38\x3f\x34\xe1\xec\xf1\x9c\x73\x66\xcf\x67\xee\x3b\x97\x13\xc5\xf2\x8b\x77\x56\x0e\xb9\xbf\x67\x8b\x5a\xce\xfd\xf9\xbd\x7b\x0f\x7c\xfc\xd1\xde\x33\xaf\x3c\x77\x6b\xe7\xb1\xdc\x9f\x46\xdf\xfa\x65\x65\xf8\xce\x8f\xfe\xfd\xe8\xaf\xbe\xb6\xe4\xe8\x2f\xca\x8c\xb1\xf3\x7c\x68\xb7\xc6\xdd\x4a\xbd\x7f\xaa\xf8\xb9\xc2\x5e\x7b\x68\x70\x70\xf3\x08\xcf\x3c\xc9\x87\x56\x3e\xac\xe0\x83\xcb\xbc\x70\x64\x5a\x7c\xce\x66\x4e\x2e\x5f\xdc\xc2\x13\xbd\xec\xd4\xcf\xe5\x08\x6d\x2c\x08\x14\xbc\x56\xe5\x7d\xca\x83\xb5\x30\x13\xf3\x8d\x03\xcc\xe3\x50\x69\x15\x27\xf8\xd0\x85\x44\x76\x89\xf5\xeb\xc5\x5e\x0f\xff\xad\x97\xff\x03\xc3\x7d\x7c\x78\x86\x0f\xc8\xee\xb1\x79\x94\x9d\xda\x44\xb1\xd2\x5f\x77\xab\x1c\x7a\x60\x67\xa1\x3c\x32\xfc\x05\x81\x07\xac\x76\x52\x2d\xd0\x0c\x9e\xae\x48\x8d\xd7\x1d\xc5\xa3\x76\x30\xea\x20\xb5\xc2\xd1\xd8\xe9\x8a\xd4\x76\x5d\x51\x3b\x42\x07\xaa\x4e\x52\x29\x18\x0d\x9d\xae\x48\x42\xf7\x9b\x66\x74\x9d\x51\xba\x40\xae\x43\xd5\x21\x03\x8a\xa3\xea\x83\x91\x4b\x19\x49\xe4\xfe\x42\xe5\x46\xe5\x88\xcf\x84\x72\xf6\xab\x1c\x89\x06\xa4\x6e\x52\x5d\x17\x33\x68\xfa\xe2\x20\x0b\xd0\x0e\x92\x7c\x8a\xf6\x71\x1c\xda\x37\x90\x23\x89\x30\x62\x0f\x29\xdc\x2d\xc7\xb1\xf0\xc1\x10\xa5\x8c\x43\x2c\x20\x3a\x44\xf2\x29\xd1\x5f\x29\x11\x96\x20\x58\x3e\xcb\xbc\x35\xe2\xba\x8d\x13\xee\x4c\xc1\x99\x2e\xd8\x93\x43\x7d\xf6\xe4\x36\x3e\x6c\xe7\xc3\x0e\x3e\x0c\xdb\x0f\x49\x43\x98\xad\x24\xb5\xc3\xd3\x18\xea\x8a\x24\x86\xff\x68\x66\xb8\xc9\x37\xac\xb8\x39\x37\xdf\x94\x12\x74\xbd\xa4\x11\xb0\x82\x52\xac\x45\x0d\x65\xca\x48\x42\xf9\x49\x33\xca\xcd\x3e\xe5\xc0\xdc\x96\xb0\x5b\x45\x5a\x41\x85\xb0\xbc\x1c\x1f\x8c\x65\xca\x48\x60\x79\x69\x4b\x33\xcb\xfe\x58\x4b\x3f\x1d\x6f\x8a\x8a\x56\x93\xd6\xe0\x0b\xd3\x7e\x7c\x30\xa6\x29\x23\x89\xe9\x45\xcd\x4c\x87\xe7\x34\xf5\xd3\x8d\xb6\xb0\x5c\x43\x5a\x85\x33\x6c\xf7\xe0\x83\xb1\x4d\x19\x49\x6c\x17\x37\xb3\xdd\xf7\x99\x6c\xfd\x74\xd8\x18\xa6\x6b\x49\xeb\xf0\x86\xb1\x38\x29\x60\x8c\x53\x46\x12\xe3\x65\xd4\xf8\x80\x1c\xf1\xd9\x50\x8e\x52\x57\xc7\x28\x40\x5a\x47\xaa\x5b\xcb\xc8\x01\x8f\x41\x4b\x19\x87\x59\x80\x76\x98\xe4\x53\xb4\x8c\x40\xbb\xd8\x23\x3a\x28\x47\x7c\x0e\x7f\xf4\x73\x0e\xa9\x1c\x89\x06\xa4\xf5\xa4\xba\x75\x72\x3c\x73\xaa\x4d\x47\x1c\x61\x01\xda\x11\x92\x4f\xd1\x56\xc7\x2d\x69\xcf\xb3\x59\xce\x92\x0e\x06\xe7\xda\x40\x75\x19\xa9\x14\x8c\x86\x4e\x57\x24\xa1\x5b\x23\xe8\x96\xc4\xd3\x35\x39\x4b\x0a\x29\x9b\xd4\x09\xc5\xc5\xea\x83\x91\x5b\xe8\xc8\x2e\x7b\x6f\x11\x5d\xe8\x68\x40\xaf\xfd\x9a\xad\xb6\xda\x89\x39\x78\xfb\xed\xf6\xd6\x6b\xe4\x1f\x97\x8c\xb9\xf9\xb3\xf6\x43\x3c\xd5\x0b\xdc\x31\x27\x77\x7a\xa2\xe6\x9e\xa9\xe4\xf7\xda\x1b\xc7\x07\xf1\x1f\x4e\x95\xb7\x05\xf0\xde\xf2\x8a\x2a\x91\x57\x75\xf2\xf9\x62\x65\xc2\xcf\x1c\x42\xa6\xf8\x8d\x79\xdc\x29\x17\x4b\x67\xf7\xda\x5b\x8e\x3b\x75\x77\x4b\x9f\xbd\xe5\x86\x42\x69\xba\x50\x2f\xe6\x1c\xfe\x61\xca\xa9\x4c\xf5\x4f\x15\x6a\xc5\x71\x51\x7b\xe8\x57\x69\xaf\xa2\x2e\xab\x54\xac\x14\xfa\x27\x0b\xc5\x89\xc9\x3a\x7e\xc2\xdc\xee\xe5\xb7\x3c\x2c\x27\xbc\xcb\xdf\x29\xcb\x17\xa7\x07\xea\xce\x58\xa9\x20\xbe\x46\x9b\x95\x2f\x4e\x55\x4b\x0e\x6f\x5a\x64\xa2\xd0\x1a\x6b\xcc\xad\xe5\x0b\xb5\xfe\x9c\x5b\x2a\x39\xd5\x29\xde\x8c\x4a\x85\xea\x6c\x0f\xd7\x59\x13\x15\x76\x45\x2a\xec\xaf\xb9\x33\xcd\x4a\xe5\x45\xa9\xee\x68\xa9\x5c\xa1\x54\x0a\x15\x6b\xf5\x8b\x55\xbd\x76\xe2\xd6\x8b\x2c\xfc\x73\x6e\xcd\xeb\x0d\xf9\x97\x55\xe4\x2f\x63\x6e\xbd\xee\x96\xf9\x9f\x06\x76\x7b\x7f\xa4\x3f\x24\x96\x0a\xe3\x41\x29\x7f\x02\x96\xd3\xe9\x0e\x26\xc1\x9f\xee\x62\x05\x04\xa1\x22\x9d\xc1\x9e\xf0\xe9\xc2\xd9\x19\xde\xa9\xa2\x9c\x58\x2f\xf0\x1e\x75\x6b\x98\x6b\xb6\x39\x43\x3b\xc6\x66\x2b\x56\x2a\xd6\x0b\x35\xa7\xd4\x58\x6c\x77\x6e\xac\xb0\x73\xc7\x2c\x1d\x3b\x50\xcc\x17\x2a\x75\x51\xa8\x9d\x14\x9a\x99\xe4\xb5\xcd\x32\x57\x0c\xb8\x55\xde\x50\xdd\xf5\x10\x97\x5b\xb3\x16\x21\x13\x97\x73\xcb\x65\xd5\xce\x0a\x3a\x71\x8e\xe3\xd0\x22\xcd\xcf\x8d\xf2\x11\xae\xb3\xc2\xcb\xc0\xfe\x69\x3e\xaf\x3b\xf6\xf1\xe2\x58\xad\x80\x65\x61\xee\x65\xa2\x23\x32\xff\x13\xd4\x7a\xe1\xc1\x7a\xbf\x53\x2a\x4e\xf0\x65\x11\xb2\x23\xf3\x9a\x79\x56\xc6\xcc\x3c\x43\x49\x67\x9d\xc3\xf3\x3e\x22\xf2\xd3\x5e\x47\xcd\x72\xad\x02\x6d\x6b\x45\xd0\x56\xde\xcd\x05\xab\x2a\xde\xa5\xe8\xb9\x92\xea\x0a\x5e\x7c\x24\x3a\x53\xed\x18\xc4\x7f\x62\x6d\x15\x77\xe5\x8b\xd7\x6b\xbc\xe9\x99\x62\xbe\x3e\xb9\xd7\xde\x26\xbf\xa7\xec\xcd\x07\x55\xfe\x76\x99\xdf\x6a\x79\x19\xce\x99\xba\xeb\x77\x94\x5c\x05\x86\xba\x7d\x25\xfd\x43\x2d\x44\xd8\x43\xff\xe4\xf5\xee\x36\x52\xc8\xe5\x5f\x6b\xbc\xe4\xce\xf4\x3f\xb8\xd7\x9e\x2c\xe6\xf9\x6c\x3f\xdb\xfc\x2d\xba\xb1\x5a\x53\x6b\xbd\x08\x3e\x2d\xd4\x16\x29\xf4\x50\x74\x9d\xbb\xdb\xeb\x8e\xe8\xd7\x9b\x63\x52\xe3\xbf\x5f\xa7\x15\x3b\xbb\x75\x58\xf4\x9b\xa9\x16\x9a\x41\xf2\x95\xb6\xbf\x31\xea\x57\xa0\xdb\x07\xf1\x5f\xa8\x4b\x56\xc7\x74\x89\x48\x88\xef\x79\xa4\x61\x83\x74\x73\xa1\x52\x72\xfb\xec\x9b\xdd\x8a\x93\xe3\xff\x3f\xe8\x56\xa6\xdc\x92\x33\xd5\x67\x6f\x38\x7e\x26\x57\xcc\x3b\x32\xa7\xb0\xa1\xcf\x2e\xbb\x15\x77\xaa\xea\xe4\xc4\x0a\x63\x3d\x99\xb1\x6a\x13\x63\x57\x0e\x6d\x1b\xec\xb3\xd5\x3f\x57\x85\x26\xc9\x8e\x6c\x47\x06\xa6\x0a\xb9\x7a\xd1\xad\xa8\x99\x3f\xb2\x9d\x1a\x2b\xb9\xb9\xd3\xa1\x0a\xd6\x46\x36\x29\x98\xf3\xe7\x2a\xdc\x15\xe9\x78\xd5\xc7\x3e\x88\x27\xa8\xb2\x45\x5b\xeb\xa2\xed\xa0\x85\x81\x42\xb9\x5a\xe7\xfb\x04\x36\x16\xbe\xa0\xa1\x8a\x1b\xd9\x18\xf4\xf0\x7d\x8a\x5b\x9c\x1a\xdf\x1c\xda\xd3\xc5\xc2\x4c\xd5\xad\xd5\xa7\xbc\x1d\x8b\xa5\xa3\xe5\x42\x9e\xaf\xef\x78\x72\xa3\x5b\x29\x9d\xb5\xa7\x72\xb5\x42\xa1\x62\x3b\x95\xbc\x7d\x25\x59\xae\x76\xf2\x99\xe3\x2a\xf1\x85\x44\x7d\x56\xdc\x26\xdd\x16\x0b\x6f\xcc\xf7\x6d\xb7\x6c\x5b\xce\xb0\xde\x02\x6d\x8b\x59\xd8\xf2\x77\x53\x46\xc4\xf4\xcb\x4d\x7f\x30\xfd\x8b\x2c\xf5\x05\x36\x45\x1a\xac\xf5\xd9\x0d\x9b\x72\x5b\xcc\xbb\xd1\xe6\xed\x50\x3d\x6d\x56\xe3\x2a\x8a\x4f\x32\x59\xb1\xf8\x0b\x81\x15\x5a\x5b\x0c\x89\xe5\x34\xa8\xa8\xdd\x8a\x59\x52\xe7\x2a\xb4\xde\x9a\x0b\xb1\xcd\x6a\x54\xf4\xca\xfb\x96\x6b\xb8\xe5\x89\x42\xed\xac\x5d\x49\x05\xba\x63\x98\x82\x76\x44\xfb\xd7\xdb\xb0\xfa\xdf\xc7\xef\x17\x39\x31\x76\x4c\x19\x6f\x07\x63\x0d\x2d\x33\xc0\x1b\xb1\xfd\x7f\xa2\x5f\x46\x1e\x8f\xe0\xf8\xe3\x72\x16\x04\xea\xfe\x91\xfa\x60\x8e\x47\x16\x3a\xb2\xad\x6f\x7e\x6f\xb6\xe3\x11\x30\x24\x38\x1e\x59\x1f\x3e\x1e\xf1\x37\x01\xe6\xa8\xc4\x1c\x95\x98\xa3\x12\x73\x54\x62\x8e\x4a\xcc\x51\x89\x39\x2a\x31\x47\x25\xe6\xa8\xc4\x1c\x95\xfc\xd7\x1f\x95\xf0\x58\xe2\x4d\x2d\xa6\xbb\x5a\x2b\xca\xed\x39\x62\xa9\xe5\xef\xf3\xae\xb2\xe2\x56\x2f\xe3\xe3\xe3\x23\x73\x8b\x24\x2c\xdb\x65\xc5\xef\x2d\xf1\x59\xa3\x71\x5f\x29\xbe\x18\xdd\x5b\x22\xc5\x82\x7d\xa5\xa0\x18\xe9\x38\xb2\xb7\xb4\xc2\x6a\xd8\xf1\x09\x8a\x74\x5b\xb3\xec\x2d\x35\x2d\xd5\x65\xc5\xef\x30\x65\x82\xe9\xf3\x76\x97\x82\x22\x2d\x0f\xcb\x63\x45\x08\x6f\x60\x41\xe0\xb8\xb1\xc2\xe4\x6d\x52\xe6\x58\x71\xa1\x23\xdb\xfa\xd6\xc3\xe2\x58\x71\xa9\x77\xac\x88\xae\xaf\xf2\xe1\x0d\xe6\xfd\xea\xd8\x62\x85\xb2\xc5\x51\xbc\x94\x83\xd4\x66\x52\x15\x14\x27\xf9\x70\x29\x3e\x18\xb9\x85\x8e\x6c\xeb\xdb\xbf\xa3\x47\xf9\x13\x7c\x28\xb2\x40\xae\x61\x87\x40\xb2\x81\xe9\x0a\x3e\x6c\x92\xf5\x80\x10\x77\x9a\x9a\x6b\x8d\x2f\x48\x70\xb6\x4f\x29\x1b\xba\xbe\xc6\x87\x37\x59\x68\x81\x43\xf6\x03\xcc\xd3\x54\x0b\x1c\xa4\xb6\x90\xaa\xae\x90\xe3\x88\xbb\x12\x8d\xdc\x42\x47\xb6\xf5\x9d\x0c\x95\xc3\xfa\x10\x72\xe7\x99\x27\xd7\xe1\xed\x90\x4c\xa8\xdd\x6d\xbe\x27\x23\xe1\x00\x75\x25\xa9\x09\x88\x80\x5b\x82\x0f\x06\x6e\xa1\x83\xc3\x75\x0a\xb8\x4b\xe2\xe1\x3a\x1b\xe1\x46\xc4\x88\x0f\x90\x11\xd5\x42\x08\xbb\xad\xa4\x72\xb8\x66\x98\x77\x6b\xb7\xb1\x5c\xf0\xc8\xb6\xbe\xbb\x59\x58\x5a\x9e\xa5\xea\xfa\xb7\x58\x68\xf5\xa9\xb2\xcf\xa3\x8c\x94\x83\xd4\xd5\x7c\xb8\x4a\x56\x05\xc5\x1a\x33\x72\x17\x28\xb2\x99\xc5\x77\x08\xb9\x4b\x99\xbf\x70\x4d\xb1\x40\xae\xd3\x0a\x9f\x3d\x22\x57\x26\x42\xaa\x8f\x54\x75\x35\x93\x7b\x99\x08\x23\xb7\xd0\x91\xcd\x2c\x19\xa6\x1b\x3e\x74\x3d\x16\xb0\xb7\x99\x27\x17\xe4\xe2\x02\x47\x78\xaa\x45\x0e\x50\xfd\xa4\xa6\x3e\x39\x8e\xd9\xd7\xd4\x11\xe3\x24\x7d\x9c\xa4\xa7\x55\x82\xc3\x65\x29\x9c\x7a\xd0\xcc\x8f\x59\xc3\xca\x72\xb9\xcc\xf6\x46\x5c\x26\x73\xde\x91\x23\x76\xd7\xeb\x7d\xe2\x0c\x6a\x9f\x7d\x7a\x2c\x8f\x1f\x26\xca\x55\xff\xe6\x0a\xa0\x0e\x90\xe6\xfb\x65\x95\x22\x0c\x72\xca\x98\x24\xe9\x5b\x48\x9a\x22\x9f\x8c\x22\xc3\xee\x27\xcc\xb3\x3b\x6a\xe9\x39\xdf\x2d\xb5\xa1\x7b\x0d\x99\x0e\xc8\x1b\x6d\x5d\x91\x44\xfb\x6e\xa1\xbd\x2c\xd8\xff\xc1\x05\xfa\xef\xb2\xd8\xfd\x1f\xcc\x04\x6a\x65\x0c\xa9\x41\x52\x27\x14\x4f\xc9\x62\x46\x2e\x6d\xfc\x3f\x49\xdf\x4a\xd2\x44\xee\x92\x97\xe8\x72\x7a\x1f\xf3\xba\x1f\xe1\x9d\xaf\x89\xfc\xfc\x26\xd9\xc0\x34\x44\x2a\x04\x21\xce\xf3\x88\x0b\xfc\x0d\x5b\xca\x28\x92\xf4\x6c\x6c\x2f\x0b\xb6\x56\x8f\x6d\x92\x14\x42\xb6\xa6\x5f\x13\x25\x36\x70\xb7\x91\xc9\x00\xfc\x63\xea\x83\xc1\x4e\x19\x2f\x91\xf4\x37\x49\x1a\xf7\xe0\x88\x7e\xcd\x66\xda\xbe\x48\x97\x51\x74\xfd\x13\x4c\xae\x46\xfd\x9c\xc7\x59\x78\xc5\x0a\xa4\xed\xa4\x3a\x00\x7e\x99\x99\x15\xab\x9e\x78\x99\xa4\xbf\x4d\xd2\x14\xed\x11\x8a\x86\xae\x7f\x92\x0f\x3f\x45\x8e\x24\x02\xc9\x0e\x52\x18\x5c\xe7\x98\xb7\xa3\x6b\x88\xd2\xc6\xb7\x48\xfa\x3b\x24\x4d\x89\x1e\xa3\x44\x58\x82\xc0\xf4\xbe\x1c\xa1\xd9\x8f\xb9\x52\x10\x62\xc3\xa4\x6e\x68\x1a\x41\x5d\x91\x5a\x70\x35\x11\x8c\x07\x04\xd8\x4e\x52\x35\x30\x0d\xa0\xae\x48\x02\xf8\x78\x33\xc0\x55\x04\x30\xd6\x0f\x5e\xbb\x48\xcd\xb0\x34\x7e\xba\x22\x89\xdf\xb9\x66\x7e\xbd\xc4\x2f\x8e\x0f\x5c\xbb\x49\xc5\xa0\x04\x9f\x39\xfb\xad\x23\x92\xf0\x3d\x91\x74\xf1\x1b\x8a\xf3\x83\xd7\x1e\x52\x33\x2c\x8d\x9f\xae\x48\xed\xd7\x1b\xf1\x8b\xf2\x81\x6b\x2f\xa9\x18\x94\x86\x4f\x57\x24\xe1\x7b\xb2\x19\xdf\xca\x08\x5f\x44\x0f\x5a\x23\xa4\x5e\x48\x42\xcf\x3c\x3f\x48\x47\x24\xd1\xfb\xca\x67\x59\xf8\x86\x22\x7c\xe0\xba\x96\x54\x0c\x4a\xc3\xa7\x2b\x52\xf3\x45\x17\x3e\xb9\xfe\x54\x7a\xd0\xda\x47\xea\x85\xa4\xd1\xd3\x15\x49\xf4\xbe\x2a\xf4\x32\xf1\x7a\x3d\x31\x7a\x01\x1e\xb0\xae\x23\xd5\x02\xf2\x4b\xcc\x3c\x54\x48\x4f\xf8\x3d\x29\xd3\xea\xa6\x5b\x9c\xbd\xde\x88\x44\x36\xd3\x7e\x8c\x2e\x7a\x8f\xf0\xe1\x51\x16\xe0\x25\xba\xdf\x41\x52\x82\xee\x7a\xd2\x38\x58\x31\x33\x98\x5d\x18\x1d\x91\x84\xf2\x16\x41\xb9\xbc\x71\x39\x0c\x72\xce\xa9\x1c\x89\x06\xa4\x51\xd2\x0c\x00\xcf\xa8\x0f\x06\x2d\x65\xa0\x27\x17\xc9\xf4\x34\x49\x63\xe5\x79\x31\x12\x1c\xed\x03\x81\xb6\xc2\x23\xaa\xb3\xa0\xfb\x31\x72\x70\xd1\x53\xe8\xae\xca\xae\xc8\xb5\x50\xf4\x31\x6d\x10\xdc\x4f\xa6\x61\x94\x0f\x4f\xa9\x0f\x46\x34\x65\x94\x48\xda\x25\x69\xf2\x7b\x60\xc7\x77\xe9\x1a\xf5\x69\xe6\x3d\x59\xff\x67\xcc\x13\xf5\x6e\x39\x12\x7f\xc2\x3b\x11\x9e\x95\x7f\x0a\xae\x93\x7a\x4a\xe6\xaa\x55\xb0\xfc\xc5\xfe\xeb\xb2\xae\xf7\x43\x23\x22\x87\xfe\x8e\x31\xca\xc2\x6f\x6a\xd8\x2f\xc7\x11\x61\xf0\x53\xc6\xd3\x24\xfd\x0c\x49\x9f\x50\x09\x8e\xff\x7d\x8a\x0f\x34\xe0\x7f\xc0\x3c\xfc\xde\x86\x5b\xfc\x9d\x2a\xee\xf2\xf4\x6e\x92\x94\x86\x30\xa3\x2f\x6e\x38\x20\x5b\x36\x27\x52\x75\x44\x12\xc3\x1f\x46\x0d\x31\x22\x0c\xe5\x99\xb8\x59\x10\xa5\x9f\x7a\x30\xa3\x8a\x83\xb2\x55\xb3\x4b\xab\x23\x92\xf8\xbd\x9a\xd4\x4f\xdc\xf8\x14\xf5\x53\x0f\xb2\x55\x71\x88\x19\x3f\x7d\x91\xda\xaf\x37\xea\x17\xe1\x03\x17\x7d\x80\xea\x61\x66\xf8\xf4\x45\x6a\xbe\x95\x51\xbe\xb0\x1e\xb4\x8e\x92\x7a\x8f\x30\xa3\xa7\x2f\x52\xeb\xf5\x44\xf5\x42\x78\xc0\xba\x81\x54\x7b\x94\x19\x3c\x7d\x91\x1a\xaf\x3b\x8a\x47\xed\x60\x75\x23\xa9\xf5\x06\x66\xec\xf4\x45\x6a\xbb\xae\xa8\x1d\xa1\x03\xd5\x31\x52\xe9\x8d\xcc\xd0\xe9\x8b\xd4\x74\x9d\x51\xba\x40\x0e\x52\x37\x91\x3a\x8f\x31\x23\xa7\x2f\x92\xc8\xbd\x26\xe4\xda\xe2\xe5\x1a\x9e\xe2\xe3\xc3\x01\x8a\xde\x52\x75\x93\x2c\x61\xce\x75\xeb\x88\x04\x70\xab\x6f\x8d\x3e\xc1\x12\x37\xcb\x2b\xb8\xc6\x27\x58\x4a\x37\x38\xdd\x4c\x6a\x84\xe1\x45\xcc\x5c\x8a\xad\x27\x9e\x23\xe9\xe7\x49\x9a\xba\x9d\x12\x6e\x1d\x9e\x1b\xce\x89\xe1\x3c\xf8\x87\x4c\x9e\xeb\xb4\x45\x36\x44\x16\xcb\x6c\x75\x66\x13\x52\xf4\x8e\x27\x28\xe2\x4d\x96\x82\xdf\xc8\xa5\x0c\xaa\xf5\x02\x49\x13\xb9\x35\xf7\xd2\x25\xee\x12\xe6\xdd\xd1\x0b\x1a\x64\xcf\xf1\x40\x58\x89\x08\xb4\xff\x21\xd5\x03\x14\x88\xe2\xdd\x79\x06\x31\x65\x24\x41\xbc\x6f\x5e\x88\x39\x8a\x08\x34\x7a\x2b\x14\x40\x81\x28\x5e\xa0\x66\x10\x53\x46\x12\xc4\x53\xf3\x44\xcc\x05\x88\x40\xbb\x8d\x54\x0f\x50\xb3\x3a\xd5\x15\x09\x10\xd7\xae\x9d\x37\x62\x4e\x21\x02\xed\x7f\x49\xf5\x00\x35\xab\x53\x5d\x91\x04\x71\xdd\xbc\x10\x77\x53\x44\xa0\xdd\x4e\xaa\x07\xa8\x59\x9d\xea\x8a\x24\x88\xeb\xe7\x89\xb8\x3b\x40\x04\xda\x1d\xa4\x7a\x80\xe2\xa2\x60\xf3\x9b\xad\x8e\x48\x82\xb8\x95\x22\xaa\xab\xe9\x15\x62\xe3\x13\xe0\xa5\x1b\x9c\xee\x24\x35\xc2\x10\xd7\xb1\x61\x2e\x30\x6e\x69\x23\x89\xdb\xb5\xd4\x4d\x5d\x0d\xaa\xdc\x22\xcf\xe0\x97\x68\x77\xd0\x2a\x98\x07\x88\x8b\x26\xc4\xeb\x13\x0d\x5a\xca\x48\x82\x16\xba\x66\x74\x94\x79\xd7\x3c\x28\xb4\xe8\x6b\x10\xa4\x1a\x94\xb2\x91\xfa\x8c\x9a\xae\x48\xad\xb6\x3c\xac\x26\xd1\x50\xfc\x2e\x52\x1d\x00\x0d\x9a\xae\x48\x8d\x96\x09\xa1\x49\x33\x18\x9d\x24\xb5\xc1\xcf\x98\xe9\x8a\x24\x66\xc7\x9b\x99\xb5\x52\x33\x49\x06\xa2\xbb\x49\x65\xe0\xc3\x53\x43\x0c\x99\x8e\xf0\x7b\x52\xa6\x3b\x64\x7a\x5a\xa5\xb3\x99\x75\x17\x51\x32\xf5\x94\x1d\x45\x16\xf7\xb2\x22\x31\xe2\x10\x19\x51\x9d\xa8\x86\xdd\x3d\xa4\x75\xb8\x0e\x33\x63\xa9\x27\xfc\x9e\x94\xe9\x38\xcb\x7b\xa8\xa5\x7a\xac\x44\xac\xa5\x7c\x2b\x82\x18\x71\x98\x8c\xa8\x2c\x61\xf7\x7f\xa4\x75\xb8\x9a\x55\xa9\xae\xf0\x7b\x52\xa6\x57\xc8\xf4\xb4\x4a\x73\xcb\xcf\x0b\xcb\xce\x04\x7b\x9a\xbb\x76\xed\x12\x23\xed\x27\x23\x29\x47\xb8\xdd\x4b\x5a\x86\x29\x1e\xc8\x26\x5e\xac\x6e\x1c\x53\x46\x12\xc7\x17\xe8\x32\x89\xe7\xa3\x9d\x66\x81\x63\xb3\xf7\x36\x49\x41\x88\xdd\x47\xda\x84\x26\x04\x7b\xf0\xc1\x08\xa6\x8c\x24\x82\x2f\x7e\x66\xc1\xf1\x90\x20\xc4\x4e\x91\x36\xa1\x09\x41\xf3\x8b\x83\x8e\x48\x22\xf8\xd2\x3c\x04\xc7\x89\x20\xc4\xee\x27\x6d\x42\x13\x2f\x7e\x31\x27\x39\x75\x84\xdf\x93\x32\xbd\x4e\xa6\xa7\x55\x9a\x0b\xfe\x9d\x0a\xe2\x9d\x5e\xb8\x05\x2d\x74\xa6\x7a\xf6\xb7\xcd\x49\x44\xa0\x39\xa4\xd9\xfb\xf9\xf0\xba\xfa\x60\x10\x53\xc6\xeb\x24\xfd\x21\x49\xfb\x37\x7f\x66\x33\xeb\xdb\x29\xe2\x6b\xcc\x7b\x69\x12\x68\xb0\x37\x62\xde\x00\x46\x5f\x14\x7d\x3f\x1f\xc6\x48\x37\x3a\xb2\xc7\x44\x98\x99\x35\x65\xbc\x3e\x4b\x9a\xce\xac\xde\x8b\x51\xba\xbc\x99\xf5\x55\x16\x74\xbf\xe8\x78\xc9\x04\x96\x1c\xa9\x00\x64\xe6\x90\x57\x57\x24\x38\xe4\x5d\xff\x5b\xc1\xd4\x1d\x3e\xe4\x85\x69\xc3\x21\x6f\xce\xd9\x93\x1b\x1c\x0c\x1f\xf2\x0a\x7c\x69\x09\xbb\x3c\x69\x1d\xae\x9d\xcc\x58\xea\x89\x04\x96\x97\x75\xd3\xed\x03\x32\xb1\x5d\x50\x96\x2b\x22\x4f\x01\x97\x6a\x50\x2a\x90\x76\x20\x68\xd4\x74\x45\x12\xb5\x7d\x89\xd5\xf6\xf8\x6a\x50\xa2\x2f\xe9\x80\xa0\x51\xd3\x15\x49\xd4\xae\x6b\xa6\xb6\x9c\xaa\xf9\x68\x40\x9a\x20\xcd\x00\xd0\xa0\xe9\x8a\x24\x68\xd7\x0b\xb4\x9e\x78\xb4\x0c\x45\x53\x66\x30\xa2\xaf\xce\x80\x9f\x39\xff\xa7\x2b\x92\x98\xfd\x40\x98\xad\x0c\x9f\x7b\x50\x66\xb3\x9e\x7b\x08\xae\x57\x55\x6f\x2f\x55\x01\xcd\xa5\xea\x83\x11\x4c\x19\x09\x04\xef\xfa\x03\x5d\x55\x2e\x95\x05\x70\xe8\xda\x12\xca\xc5\xa5\x8b\x74\x7f\x12\x50\xf4\xdd\x27\x40\xc4\xbb\x9b\xc5\x3b\x81\x0c\x5c\xca\xc0\x35\x86\xad\x24\x1d\x07\xf7\x2f\x0a\xd7\x26\xff\x70\x9e\xc5\xbc\x55\x66\x28\x78\x8a\x94\x5a\x44\x55\x80\x70\x25\x33\x5b\x39\x3d\x91\x84\x2d\xf4\x16\x60\xfc\xcc\x81\xff\x9f\x67\x71\x87\x01\xc3\x6a\x3b\x07\x25\xfa\x84\x2a\x08\x42\xcd\xdc\x96\xa8\x23\x12\xa8\x9d\x6c\x69\xa6\x16\x5a\xd8\x06\x02\x36\x30\x95\x49\x43\x20\x34\x6c\xba\x22\x09\xdb\xa2\xc4\x0b\x1b\x67\x93\x6a\x50\xaa\x90\x76\x20\x78\x92\x99\x55\xa4\x9e\x48\xa2\x66\x51\x35\x75\x0d\xd5\x79\x26\xaf\xf9\x0e\x6d\xd9\x06\x83\x17\xa6\xa9\xdf\x4d\x54\xc0\xd0\xb8\xe9\x8a\x24\x6e\xcb\x9a\xb9\x85\x8e\xba\x7d\xb4\x4a\xa4\x19\x00\x1a\x34\x5d\x91\x04\xcd\x7b\xb7\x7d\x6f\x3c\x5a\xe8\xa8\x7b\x84\xbc\x50\x5b\x05\x5e\xd1\x8c\x17\x6b\xe3\x85\xda\xe6\xe9\x16\x17\x24\xb2\x99\xc5\xb7\xd1\x05\x2d\xfa\x42\xed\xe8\x43\x64\x07\xc9\x63\xb9\x37\xc8\x3a\xf0\x64\xe1\xcd\x7c\xd8\xc4\xbc\x45\xce\xbc\x98\xf9\x82\x44\xb6\xf5\xed\x4f\x28\x1d\xba\xbe\xca\x87\x37\xfc\x9c\x0a\xc9\xf1\x4e\xa1\x94\x9d\xda\x44\xb1\xd2\x3f\xe6\xd6\xeb\x6e\x99\x6f\xf1\x06\x76\x72\x4e\xf1\xd4\x92\x88\x29\x02\x9e\x1b\x99\x31\xbd\x80\xc1\x4d\xff\xa9\xd3\xf4\x8f\xaf\xfc\x27\x00\x00\xff\xff\x1f\x5c\x01\x48\x63\xc2\x00\x00")
-
-func resourcesGoweaveCssUnBytes() ([]byte, error) {
-	return bindataRead(
-		_resourcesGoweaveCssUn,
-		"resources/.goweave.css.un~",
-	)
-}
-
-func resourcesGoweaveCssUn() (*asset, error) {
-	bytes, err := resourcesGoweaveCssUnBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "resources/.goweave.css.un~", size: 49763, mode: os.FileMode(420), modTime: time.Unix(1460492563, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _resourcesGoweaveCss = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xa4\x56\xcd\x72\xdb\x36\x10\x3e\x93\x4f\xb1\x93\x4c\xc7\x49\x46\xa2\x64\xc9\x76\x5d\xe9\x52\x8f\x0f\xed\xc1\xe9\xc5\x9d\x5e\x3a\x3d\x80\xc4\x52\xc4\x18\x04\x38\x20\x2c\x59\xcd\xe8\xdd\xbb\xa0\x08\x9a\x3f\x70\x92\x69\xc2\x61\x0c\x2d\x3e\xec\xdf\xb7\x8b\xe5\xe2\x13\xec\xf4\x01\xd9\x1e\xe1\xfe\xf1\x11\xe2\x18\x3e\xeb\xda\x42\x89\xac\x7e\x36\x58\xa2\xb2\x35\x30\x83\xb0\x13\x7b\x54\x20\x14\x60\x99\xc0\x23\x22\xfc\xfd\x67\x81\xf0\x9b\x96\x5c\x48\x9d\x3d\xd5\x70\x57\x55\x46\xb3\xac\xf8\xe7\x43\x61\x6d\xb5\x59\x2c\x76\xdd\x1e\x6b\xb7\x92\x4c\x97\x0b\x8e\xa5\x5e\x7c\x8c\x21\xd7\x06\x2c\xa9\x30\xcc\x0a\xad\x98\x44\x48\xb1\x10\x8a\x93\x50\xd4\x49\x0c\x31\x7c\x5a\xc4\x71\x61\x4b\x09\x5f\xe2\x28\xd7\xca\xce\x6b\xf1\x2f\x6e\xe0\x72\x55\xd9\x6d\x7c\x8a\xe3\x54\xf3\x23\xed\x01\xfd\x4b\x59\xf6\xb4\x33\xfa\x59\xf1\x79\xa6\xa5\x36\x1b\x78\x9f\xdf\xba\x67\xdb\x6c\x97\xcc\xec\x84\xda\xc0\x12\xcb\xb3\xa0\x62\x9c\x0b\xb5\xeb\x49\x1a\x03\x39\x2b\x85\x3c\x6e\xe0\xe2\x81\x59\x7d\x31\x83\x8b\xdf\x51\xee\xd1\x8a\x8c\xd1\x8f\x9a\xa9\x7a\x5e\xa3\x11\xf9\x76\xe8\x8f\x71\x3a\x22\x29\x14\xce\x0b\x14\xbb\xc2\x92\x2c\x59\x3b\x21\x39\x69\xed\x0c\x32\xcd\x71\x06\x4f\x29\x77\x4a\xca\xaa\xf5\x79\x60\xf1\x33\x2a\xa9\x67\x94\x7b\xc5\x32\xfa\x7b\xaf\x55\xad\x25\xab\x67\xf0\xee\xe1\x39\x13\x9c\xb5\x12\x7c\x37\x83\x52\x2b\x5d\x57\x2c\xc3\xa1\x1b\xc9\xed\x35\x39\xe2\x4c\xc6\xef\x3d\xa3\x5c\xec\x13\xcb\x52\xca\x2d\xa5\x90\x8b\xba\x92\x8c\x6c\x35\x12\x3a\x7c\x10\xdc\x16\xe4\xeb\x72\xf9\x13\xfd\x4a\xb5\xe1\x68\x5c\xfa\x24\xab\x6a\x52\xe8\x57\x4d\x18\x43\x95\x66\xaa\x6f\x6e\xf4\x21\x80\xe4\x01\x64\x86\x52\x0e\xa1\x3e\x25\x67\x9e\xe6\x56\x57\x3d\x66\x5a\xa1\x39\x67\x76\x2c\x4e\xb5\xb5\xba\x24\x79\x72\x3b\xda\x91\x98\x7b\xfc\xc8\xad\xd6\x5a\xe7\x96\x50\x8e\xbc\x21\x2c\x79\xc2\xe3\x81\x52\xd2\x62\x7d\x55\x65\x97\x3f\xdf\x2c\x97\x23\xa8\x14\x16\x0d\x93\x23\xe8\xfd\xdd\x2f\xf7\x13\xa8\xe0\xd4\x51\x23\xe0\xd5\xd2\x3d\x23\xa0\xae\x48\xa5\xd5\x4d\xa6\xbf\x0a\xa4\x9e\x2a\xa7\x3a\xd7\x29\x5b\x2f\x97\x03\x64\x71\x39\x83\x62\x45\xef\x9a\xde\x2b\x7a\xaf\xbb\xc6\xea\xea\xfe\x6e\x4f\xf5\xcd\xe0\x41\xa4\x06\x5d\xfd\x7f\xb3\x0f\x86\x55\xef\x19\xb0\xf8\x62\xe7\x4c\x8a\x1d\xf5\x9c\xa3\x61\xfb\xa3\xec\x5e\x7e\x1f\xb7\x89\xd2\xae\xd7\x9a\x50\xbb\xf5\xaa\xb7\x5e\xf7\xd6\x57\xbd\xf5\x75\xa0\x00\x2f\x93\x9b\x89\x7e\xae\xb3\x16\x49\x89\x72\xf9\x90\x3e\x4c\x3a\xb2\x0d\xb2\x3a\xbd\x2a\x1a\x43\x64\xa5\xed\xbf\x55\x2f\xe8\x17\x2f\x5c\x37\xc2\xb6\x45\xd9\xb3\xd5\x83\x7b\x6b\x94\x43\x2f\x35\x23\x1a\xbc\xfc\x9c\xad\x95\x17\x6b\xf2\x3d\x97\xfa\x30\x7f\xd9\x40\x21\x38\x55\xe4\xa4\xa4\x28\x27\x95\x69\x6e\x8d\x11\x69\x01\xe0\x97\xb7\xdd\x1c\x19\x0e\xf9\x19\x05\x0a\x20\xea\x7b\xf8\xaa\x35\x98\xf2\x28\x70\xf5\x63\xe6\x9e\xb7\x82\x6a\x16\xdf\xd1\x82\xe7\x9b\x2e\xa9\x31\x73\x03\xca\x97\x4a\xe0\x3a\x63\x95\x03\x84\x2e\x3f\x57\x2f\xa1\x83\xa9\x1b\x8b\x93\xd8\xcf\x91\x46\x83\x12\x1f\xca\xba\x76\xf0\xa5\x39\xb6\xe7\x2c\x25\x58\x56\x96\x06\x23\xf4\x0c\x2a\xdd\xde\x6f\x34\xee\xff\x60\x86\xee\x6a\xd8\x0b\x3c\x54\xda\xd0\x78\xa7\x39\xfb\x6b\x89\x9c\xfa\x3e\xd6\x4a\x1e\xa1\xce\x0c\xd2\xac\x67\x34\x8b\x3f\xf4\x4a\xf2\x86\xb8\xf9\x48\x61\xc4\x51\x68\xbe\x90\xb5\x69\x7c\x00\x83\x21\xe3\x30\xdd\x28\xa6\xed\x76\xe6\x78\xf7\xa2\xd3\x44\xb7\x09\x2a\x86\x10\xb4\x99\x34\x01\x64\xd4\xcd\x7a\xaa\x37\xff\x8e\x8d\xb5\x8d\x4d\xee\xbd\xf6\xe5\xb9\x14\xa3\x41\xcb\xd1\xd1\xf1\x49\x4f\xee\xb7\x80\x6f\x96\xd3\x34\x6d\xa7\x49\x6c\x63\x62\xc7\xcc\xd2\x91\x33\xb9\x7f\xa1\x39\x82\xfa\x9f\x0c\x5f\x5d\x87\x19\x36\xfd\xf8\x7c\x62\x4e\xf4\x89\x16\xa4\xe0\x15\x97\x90\x3e\xe8\xfe\xf3\x5e\x9e\x3d\x89\x2b\x23\x9a\xa9\xe5\xbe\x3c\x9a\xef\xb8\x28\xd4\xca\x79\x9e\x4f\xb8\xea\x12\xf7\x35\xfc\x29\xfe\x2f\x00\x00\xff\xff\x8d\x11\x9d\x9c\xda\x0a\x00\x00")
-
-func resourcesGoweaveCssBytes() ([]byte, error) {
-	return bindataRead(
-		_resourcesGoweaveCss,
-		"resources/goweave.css",
-	)
-}
-
-func resourcesGoweaveCss() (*asset, error) {
-	bytes, err := resourcesGoweaveCssBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "resources/goweave.css", size: 2778, mode: os.FileMode(420), modTime: time.Unix(1460492563, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _resourcesGoweaveCssBak = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xa4\x56\xcd\x6e\xe3\x36\x10\x3e\x4b\x4f\x31\xd8\xc5\x22\xbb\x0b\x5b\xb6\xe3\x24\x4d\x9d\x4b\x83\x1c\xda\x43\xb6\x97\x14\xbd\x14\x3d\x50\xe2\xc8\x22\x42\x91\x02\xc5\xd8\x71\x03\xbf\x7b\x87\x92\xe8\xe8\x87\xd9\x0d\xda\x15\xb4\xa1\x87\x1f\xe7\xef\x9b\xe1\x68\xf1\x15\xb6\x7a\x8f\x6c\x87\x70\xf7\xf0\x00\x71\x0c\xdf\x74\x6d\xa1\x44\x56\x3f\x19\x2c\x51\xd9\x1a\x98\x41\xd8\x8a\x1d\x2a\x10\x0a\xb0\x4c\xe0\x01\x11\xfe\xfa\xa3\x40\xf8\x55\x4b\x2e\xa4\xce\x1e\x6b\xb8\xad\x2a\xa3\x59\x56\xfc\xfd\xb9\xb0\xb6\xda\x2c\x16\xdb\xd3\x1e\xeb\xb6\x92\x4c\x97\x0b\x8e\xa5\x5e\x7c\x89\x21\xd7\x06\x2c\xa9\x30\xcc\x0a\xad\x98\x44\x48\xb1\x10\x8a\x93\x50\xd4\x49\x0c\x31\x7c\x5d\xc4\x71\xaa\xf9\x01\x5e\x62\xa0\x7f\x29\xcb\x1e\xb7\x46\x3f\x29\x3e\xcf\xb4\xd4\x66\x03\x1f\xf3\x6b\xf7\xdc\x34\xdb\x25\x33\x5b\xa1\x36\xb0\xc4\xb2\x15\x54\x8c\x73\xa1\xb6\x3d\x49\xae\x95\x9d\xe7\xac\x14\xf2\xb0\x81\xb3\x7b\x66\xf5\xd9\x0c\xce\x7e\x43\xb9\x43\x2b\x32\x46\x3f\x6a\xa6\xea\x79\x8d\x46\xe4\x37\x71\xd4\xc0\x6b\xf1\x0f\x6e\x60\xe5\x54\x44\x52\x28\x9c\x17\x28\xb6\x85\x25\x51\xb2\x76\xc2\x63\x1c\x5b\x3b\x83\x4c\x73\x9c\xc1\x63\xca\x9d\x8e\xb2\xea\x5c\x1e\x18\xfc\x86\x4a\xea\x19\xa5\x57\xb1\x8c\xfe\xde\x69\x55\x6b\xc9\xea\x19\x7c\xb8\x7f\xca\x04\x67\x9d\x04\x3f\xcc\xa0\xd4\x4a\xd7\x15\xcb\x70\xe8\xc5\xf5\xe5\x27\x67\x2f\xfe\xe8\x19\xe3\x62\x97\x58\x96\x52\xee\x5e\xe2\x88\x8b\xba\x92\x8c\x0c\x35\x12\x3a\xb9\x17\xdc\x16\xe4\xe8\x72\xf9\x89\x7e\xa5\xda\x70\x34\x2e\x75\x92\x55\x35\x69\xf3\xab\x26\x86\xa1\x4a\x33\xd5\x37\x37\x7a\x1f\x40\xf2\x00\x32\x43\x29\x87\x50\x9f\x8f\x96\xa3\xb9\xd5\x55\x8f\x95\x4e\x68\xda\xb4\x8e\xc5\xa9\xb6\x56\x97\x24\x4f\xae\x47\x3b\x12\x73\x8f\x1f\xb9\xd5\x59\x3b\xb9\x25\x94\x63\x6e\x08\x4b\x1e\xf1\xb0\xa7\x94\x74\x58\x5f\x51\xd9\xea\xa7\xab\xe5\x72\x04\x95\xc2\xa2\x61\x72\x04\xbd\xbb\xfd\xf9\x6e\x02\x15\x9c\x3a\x66\x04\xbc\x58\xba\x67\x04\xd4\x15\xa9\xb4\xba\xc9\xf4\x77\x81\xd4\x33\xe5\x54\xe7\x3a\x65\xeb\xe5\x72\x80\x2c\x56\x33\x28\xce\xe9\x5d\xd3\x7b\x41\xef\xa5\xd3\x3d\xac\xf9\xdb\x1d\xd5\x36\x83\x7b\x91\x1a\x74\xb5\xff\xc3\x1e\x18\x96\xbc\x67\xc0\xe2\xb3\x9d\x33\x29\xb6\xd4\x6f\x8e\x86\x9b\xff\xcb\xee\xea\x7d\xdc\x26\x4a\xbb\x46\x6b\x42\x3d\xad\xcf\x7b\xeb\x75\x6f\x7d\xd1\x5b\x5f\x06\x0a\x70\x95\x5c\x4d\xf4\x73\x9d\x75\x48\x4a\x94\xcb\x87\xf4\x61\xd2\x91\x9b\x20\xab\x83\x6b\xa2\x69\xb5\xc6\x10\x59\xe9\xfa\xef\xbc\x17\xf4\xb3\x17\xae\x1b\x61\xd7\xa2\xec\xc9\xea\xc1\x9d\x35\xca\xa1\x97\x9a\x11\x0d\x5e\xde\x66\xeb\xdc\x8b\x35\xf9\x9e\x4b\xbd\x9f\x3f\x6f\xa0\x10\x9c\x2a\x72\x52\x52\x94\x93\xca\x34\xb7\xc6\x88\xb4\x00\xf0\xe5\x6d\x37\x47\x86\x43\x7e\x46\x81\x02\x88\xfa\x1e\xbe\x6a\x0d\xa6\x3c\x0a\x5c\xfb\x98\xb9\xe7\xad\xa0\x9a\xc5\x3b\x5a\xb0\xbd\xe9\x92\x1a\x33\x37\x80\x7c\xa9\x04\xae\x33\x56\x39\x40\xe8\xf2\x73\xf5\x12\x3a\x98\xba\xb1\x37\x89\xbd\x8d\x34\x1a\x94\xf8\x50\x76\x6a\x07\x5f\x9a\x63\x7b\xce\x52\x82\x65\x65\x69\x28\x42\xcf\xa0\xd2\xdd\xfd\x46\xe3\xfc\x77\x66\xe8\xae\x86\x9d\xc0\x7d\xa5\x0d\x8d\x6f\x9a\xa3\xbf\x94\xc8\xa9\xef\x63\xad\xe4\x01\xea\xcc\x20\xcd\x72\x46\xb3\xf6\x73\xaf\x24\xaf\x88\x9b\x2f\x14\x46\x1c\x85\xe6\x0b\x59\x9b\xc6\x07\x30\x18\x32\x0e\x73\x1a\xc3\xb4\xdd\xcd\x1c\xef\x5e\x74\x9c\xe8\x36\x41\xc5\x10\x82\x36\x93\x26\x80\x8c\x4e\x73\x9e\xea\xcd\xbf\x63\x63\x5d\x63\x93\x7b\xaf\x7d\xd9\x96\x62\x34\x68\x39\x3a\x3a\x3e\xe9\xc9\xfd\x11\xf0\xcd\x72\x9a\xa6\xed\x38\x89\x6d\x4c\xec\x98\x59\x3a\xd2\x92\xfb\x27\x9a\x03\xa8\xff\xc8\xf0\xc5\x65\x98\x61\xd3\x8f\xcf\x27\xe6\x48\x9f\x60\x41\x0a\x5e\x71\x09\xe9\x83\xd3\x7f\xde\xcb\xd6\x93\xb8\x32\xa2\x99\x5a\xee\xcb\xa3\xf9\x86\x8b\x42\xad\x9c\xe7\xf9\x84\xab\x53\xe2\xbe\x87\x3f\xc6\xff\x06\x00\x00\xff\xff\x50\xe6\x76\x70\xba\x0a\x00\x00")
-
-func resourcesGoweaveCssBakBytes() ([]byte, error) {
-	return bindataRead(
-		_resourcesGoweaveCssBak,
-		"resources/goweave.css.bak",
-	)
-}
-
-func resourcesGoweaveCssBak() (*asset, error) {
-	bytes, err := resourcesGoweaveCssBakBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "resources/goweave.css.bak", size: 2746, mode: os.FileMode(420), modTime: time.Unix(1460477885, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-var _resourcesGoweaveTempl = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x8c\x92\x41\x8f\x9b\x30\x10\x85\xcf\xe1\x57\xb8\x3e\x37\xd0\xde\x7a\x00\x2e\x6c\x57\xea\xa9\x2b\xd1\x4b\x8f\x8e\x3d\x09\xd6\x1a\x1b\xd9\x13\x52\x84\xf8\xef\x1d\x9b\x80\x9a\xaa\x91\x7a\x32\xbc\x99\xf7\xfc\xd9\xe3\x79\xd6\x67\x96\xbf\x5e\x8d\x59\x96\xf2\xc3\xcb\xf7\xe6\xc7\xcf\xb7\xaf\xac\xc3\xde\xd4\x59\xb9\x2d\x20\x14\x2d\xa8\xd1\x40\x3d\xcf\xf9\xab\x36\x60\x45\x0f\xe4\x28\x56\x31\x2b\x7b\x40\xc1\x64\x27\x7c\x00\xac\xf8\x15\xcf\xc7\x2f\xbc\xd8\xf4\xd8\x5c\xf1\x51\xc3\x6d\x70\x1e\x39\x93\xce\x22\x58\xea\xbb\x69\x85\x5d\xa5\x60\xd4\x12\x8e\xe9\xe7\x23\xd3\x56\xa3\x16\xe6\x18\xa4\x30\x50\x7d\xce\x3f\xf1\x3a\x5b\x29\xbf\x59\xa3\x2d\x34\x6d\xbb\x2c\x59\x19\x70\x32\xc0\x70\x1a\x28\x19\xe1\x17\x16\x32\x04\x1e\xe9\xda\x58\x88\x68\xa9\x23\x7a\xc1\x04\x88\x16\x72\xbf\x33\x0f\xa6\xe2\xa9\x14\x3a\x00\x82\xe9\x3c\x9c\x2b\xf2\x35\x21\xbc\x09\xec\x96\x25\x59\xac\x8a\x8e\xe2\x7e\xf4\x93\x53\xd3\x1f\xb2\xd2\x23\xd3\xaa\xe2\x17\x77\x03\x31\x02\x01\x1e\x76\xed\x24\xe4\xfb\xc5\xbb\xab\x55\xbc\x2e\x0b\x52\xb7\xa2\x34\x22\x04\x62\x15\x27\x93\x1c\x87\x79\xf6\xc2\x5e\x80\xe5\x2d\x48\xd4\xce\x06\x8a\x3e\x44\x99\xce\x6a\x49\x6e\x9c\x02\xc6\xf9\xaa\x3e\x66\x78\x16\x56\x4f\x0a\xfa\xbb\xaa\x98\x72\x32\xdd\xc5\x8b\x93\xf1\x26\x56\x8a\x7f\xf4\x49\xda\x82\x30\x07\x0f\x75\x19\xbf\xa3\x27\x6e\x1b\x4d\xe9\xbf\x2c\x52\x6d\x4f\xd8\x2f\xf3\x39\x12\xb3\x6e\x8d\x7d\x46\xb6\x37\xfc\x27\x20\x83\x7e\xc0\x89\x3f\x52\xa4\x41\x50\xfb\xa6\xed\xd2\x5d\xb9\x2f\x0f\xcf\xbb\x58\xc7\x48\x53\x8d\xef\x7a\x73\xfc\x0e\x00\x00\xff\xff\x88\x15\xc2\xf1\x02\x03\x00\x00")
-
-func resourcesGoweaveTemplBytes() ([]byte, error) {
-	return bindataRead(
-		_resourcesGoweaveTempl,
-		"resources/goweave.templ",
-	)
-}
-
-func resourcesGoweaveTempl() (*asset, error) {
-	bytes, err := resourcesGoweaveTemplBytes()
-	if err != nil {
-		return nil, err
-	}
-
-	info := bindataFileInfo{name: "resources/goweave.templ", size: 770, mode: os.FileMode(420), modTime: time.Unix(1458206570, 0)}
-	a := &asset{bytes: bytes, info: info}
-	return a, nil
-}
-
-// Asset loads and returns the asset for the given name.
-// It returns an error if the asset could not be found or
-// could not be loaded.
-func Asset(name string) ([]byte, error) {
-	cannonicalName := strings.Replace(name, "\\", "/", -1)
-	if f, ok := _bindata[cannonicalName]; ok {
-		a, err := f()
-		if err != nil {
-			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
-		}
-		return a.bytes, nil
-	}
-	return nil, fmt.Errorf("Asset %s not found", name)
-}
-
-// MustAsset is like Asset but panics when Asset would return an error.
-// It simplifies safe initialization of global variables.
-func MustAsset(name string) []byte {
-	a, err := Asset(name)
-	if err != nil {
-		panic("asset: Asset(" + name + "): " + err.Error())
-	}
-
-	return a
-}
-
-// AssetInfo loads and returns the asset info for the given name.
-// It returns an error if the asset could not be found or
-// could not be loaded.
-func AssetInfo(name string) (os.FileInfo, error) {
-	cannonicalName := strings.Replace(name, "\\", "/", -1)
-	if f, ok := _bindata[cannonicalName]; ok {
-		a, err := f()
-		if err != nil {
-			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
-		}
-		return a.info, nil
-	}
-	return nil, fmt.Errorf("AssetInfo %s not found", name)
-}
-
-// AssetNames returns the names of the assets.
-func AssetNames() []string {
-	names := make([]string, 0, len(_bindata))
-	for name := range _bindata {
-		names = append(names, name)
-	}
-	return names
-}
-
-// _bindata is a table, holding each asset generator, mapped to its name.
-var _bindata = map[string]func() (*asset, error){
-	"resources/.goweave.css.swp": resourcesGoweaveCssSwp,
-	"resources/.goweave.css.un~": resourcesGoweaveCssUn,
-	"resources/goweave.css": resourcesGoweaveCss,
-	"resources/goweave.css.bak": resourcesGoweaveCssBak,
-	"resources/goweave.templ": resourcesGoweaveTempl,
-}
-
-// AssetDir returns the file names below a certain
-// directory embedded in the file by go-bindata.
-// For example if you run go-bindata on data/... and data contains the
-// following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
-// then AssetDir("data") would return []string{"foo.txt", "img"}
-// AssetDir("data/img") would return []string{"a.png", "b.png"}
-// AssetDir("foo.txt") and AssetDir("notexist") would return an error
-// AssetDir("") will return []string{"data"}.
-func AssetDir(name string) ([]string, error) {
-	node := _bintree
-	if len(name) != 0 {
-		cannonicalName := strings.Replace(name, "\\", "/", -1)
-		pathList := strings.Split(cannonicalName, "/")
-		for _, p := range pathList {
-			node = node.Children[p]
-			if node == nil {
-				return nil, fmt.Errorf("Asset %s not found", name)
-			}
-		}
-	}
-	if node.Func != nil {
-		return nil, fmt.Errorf("Asset %s not found", name)
-	}
-	rv := make([]string, 0, len(node.Children))
-	for childName := range node.Children {
-		rv = append(rv, childName)
-	}
-	return rv, nil
-}
-
-type bintree struct {
-	Func     func() (*asset, error)
-	Children map[string]*bintree
-}
-var _bintree = &bintree{nil, map[string]*bintree{
-	"resources": &bintree{nil, map[string]*bintree{
-		".goweave.css.swp": &bintree{resourcesGoweaveCssSwp, map[string]*bintree{}},
-		".goweave.css.un~": &bintree{resourcesGoweaveCssUn, map[string]*bintree{}},
-		"goweave.css": &bintree{resourcesGoweaveCss, map[string]*bintree{}},
-		"goweave.css.bak": &bintree{resourcesGoweaveCssBak, map[string]*bintree{}},
-		"goweave.templ": &bintree{resourcesGoweaveTempl, map[string]*bintree{}},
-	}},
-}}
-
-// RestoreAsset restores an asset under the given directory
-func RestoreAsset(dir, name string) error {
-	data, err := Asset(name)
-	if err != nil {
-		return err
-	}
-	info, err := AssetInfo(name)
-	if err != nil {
-		return err
-	}
-	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
-	if err != nil {
-		return err
-	}
-	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
-	if err != nil {
-		return err
-	}
-	err = os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-// RestoreAssets restores an asset under the given directory recursively
-func RestoreAssets(dir, name string) error {
-	children, err := AssetDir(name)
-	// File
-	if err != nil {
-		return RestoreAsset(dir, name)
-	}
-	// Dir
-	for _, child := range children {
-		err = RestoreAssets(dir, filepath.Join(name, child))
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func _filePath(dir, name string) string {
-	cannonicalName := strings.Replace(name, "\\", "/", -1)
-	return filepath.Join(append([]string{dir}, strings.Split(cannonicalName, "/")...)...)
-}
-
@@ -0,0 +1,81 @@
+// The change markers of the preview server of goweave -serve: the sections
+// that changed since the last load of the page light up briefly, and a red
+// line shows where sections were removed. The preview server adds this
+// script to the pages it serves; the documents never load it.
+(function() {
+	var style = document.createElement("style");
+	style.textContent = [
+		"@keyframes goweave-changed { from { background-color: rgba(255, 196, 0, 0.35); } }",
+		"@keyframes goweave-added { from { background-color: rgba(46, 160, 67, 0.35); } }",
+		"@keyframes goweave-removed-before { from { box-shadow: inset 0 3px rgba(218, 54, 51, 0.8); } }",
+		"@keyframes goweave-removed-after { from { box-shadow: inset 0 -3px rgba(218, 54, 51, 0.8); } }",
+		"#goweave div.goweave-changed > div { animation: goweave-changed 3s ease-in; }",
+		"#goweave div.goweave-added > div { animation: goweave-added 3s ease-in; }",
+		"#goweave div.goweave-removed-before > div { animation: goweave-removed-before 3s ease-in; }",
+		"#goweave div.goweave-removed-after > div { animation: goweave-removed-after 3s ease-in; }"
+	].join("\n");
+	document.head.appendChild(style);
+	var key = "goweave-sections:" + location.pathname;
+	var hash = function(s) {
+		var h = 0;
+		for (var i = 0; i < s.length; i++) {
+			h = (h * 31 + s.charCodeAt(i)) | 0;
+		}
+		return h.toString(36);
+	};
+	var sections = Array.prototype.slice.call(document.querySelectorAll("#goweave div.section"));
+	var now = sections.map(function(s) { return hash(s.innerHTML); });
+	var before = null;
+	try {
+		before = JSON.parse(sessionStorage.getItem(key));
+		sessionStorage.setItem(key, JSON.stringify(now));
+	} catch (e) {}
+	if (!before) {
+		return;
+	}
+	// Skip the common start and end, and match the rest by the longest
+	// common subsequence.
+	var start = 0, n = before.length, m = now.length;
+	while (start < n && start < m && before[start] === now[start]) {
+		start++;
+	}
+	while (n > start && m > start && before[n-1] === now[m-1]) {
+		n--;
+		m--;
+	}
+	var lcs = [];
+	for (var i = n; i >= start; i--) {
+		lcs[i] = [];
+		for (var j = m; j >= start; j--) {
+			lcs[i][j] = i === n || j === m ? 0 : before[i] === now[j] ? lcs[i+1][j+1] + 1 : Math.max(lcs[i+1][j], lcs[i][j+1]);
+		}
+	}
+	var mark = function(k, cls) {
+		if (k >= 0 && k < sections.length) {
+			sections[k].classList.add(cls);
+		}
+	};
+	var added = [], removed = 0;
+	var flush = function(j) {
+		added.forEach(function(k) { mark(k, removed > 0 ? "goweave-changed" : "goweave-added"); });
+		if (removed > 0 && added.length === 0) {
+			mark(j < sections.length ? j : j - 1, j < sections.length ? "goweave-removed-before" : "goweave-removed-after");
+		}
+		added = [];
+		removed = 0;
+	};
+	var i = start, j = start;
+	while (i < n || j < m) {
+		if (i < n && j < m && before[i] === now[j]) {
+			flush(j);
+			i++;
+			j++;
+		} else if (j < m && (i === n || lcs[i][j+1] >= lcs[i+1][j])) {
+			added.push(j++);
+		} else {
+			removed++;
+			i++;
+		}
+	}
+	flush(j);
+})();
@@ -0,0 +1,47 @@
+// The buttons of goweave documents: the toggle between the woven document
+// and the plain source of -rawview, and the toggle between the light and
+// the dark palette of -color-scheme=auto. The documents load this file only
+// if they have one of them.
+(function() {
+	var button = document.getElementById("toggle");
+	var raw = document.getElementById("raw");
+	var woven = document.querySelector("#goweave div.table");
+	if (!button || !raw || !woven) {
+		return;
+	}
+	button.addEventListener("click", function() {
+		var showRaw = raw.style.display === "none";
+		raw.style.display = showRaw ? "" : "none";
+		woven.style.display = showRaw ? "none" : "";
+		button.textContent = showRaw ? "Show side by side" : "Show plain source";
+	});
+})();
+
+(function() {
+	var button = document.getElementById("color-scheme-toggle");
+	if (!button) {
+		return;
+	}
+	var root = document.documentElement;
+	var system = window.matchMedia("(prefers-color-scheme: dark)");
+	function current() {
+		return root.getAttribute("data-color-scheme") || (system.matches ? "dark" : "light");
+	}
+	function label() {
+		var dark = current() === "dark";
+		button.textContent = dark ? "\u2600" : "\u263E";
+		button.title = dark ? "Switch to light colors" : "Switch to dark colors";
+	}
+	button.addEventListener("click", function() {
+		var scheme = current() === "dark" ? "light" : "dark";
+		root.setAttribute("data-color-scheme", scheme);
+		try {
+			localStorage.setItem("goweave-color-scheme", scheme);
+		} catch (e) {}
+		label();
+	});
+	if (system.addEventListener) {
+		system.addEventListener("change", label);
+	}
+	label();
+})();
@@ -0,0 +1,13 @@
+// Package resources holds the built-in resource files of goweave: the CSS
+// file, the templates, the script, and the themes. goweave uses them
+// whenever no resource directory has its own, and -install writes them out
+// for customizing. Library users get them through weave.Resources.
+package resources
+
+import "embed"
+
+// Files are the resource files, by their names within the resource
+// directory, like "goweave.css" and "themes/dark.css".
+//
+//go:embed *.css *.templ *.js themes
+var Files embed.FS
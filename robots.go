@@ -0,0 +1,80 @@
+// ## Keeping documents out of search engines
+//
+// Some literate documents are meant for internal readers only. A
+// `//goweave:noindex` pragma asks search engines not to index a single
+// document, and -noindex does the same for all documents. goweave adds a
+// robots meta tag to each of these documents, and with -robots, it also
+// writes a robots.txt file that tells crawlers to stay away from them.
+package main
+
+import (
+	"bytes"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const robotsFilename = "robots.txt"
+
+// noindexPages collects the output names of the documents that must not be
+// indexed.
+var (
+	noindexPages   = map[string]bool{}
+	noindexPagesMu sync.Mutex
+)
+
+// setNoindex records whether a document must not be indexed. In watch mode,
+// a document can change its mind, so this also removes documents from the
+// set.
+func setNoindex(outname string, noindex bool) {
+	noindexPagesMu.Lock()
+	if noindex {
+		noindexPages[outname] = true
+	} else {
+		delete(noindexPages, outname)
+	}
+	noindexPagesMu.Unlock()
+}
+
+// robotsPrefix returns the path of the output directory on the web server,
+// taken from -base-url. Without a base URL, goweave assumes that the output
+// directory is the root of the site.
+func robotsPrefix() string {
+	u, err := url.Parse(*baseURL)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return strings.TrimSuffix(u.Path, "/") + "/"
+}
+
+// robotsTxt returns the contents of robots.txt. With -noindex, it disallows
+// the whole site; otherwise, it disallows each noindex document.
+func robotsTxt() []byte {
+	var b bytes.Buffer
+	b.WriteString("User-agent: *\n")
+	prefix := robotsPrefix()
+	if *noindex {
+		b.WriteString("Disallow: " + prefix + "\n")
+		return b.Bytes()
+	}
+	noindexPagesMu.Lock()
+	names := make([]string, 0, len(noindexPages))
+	for name := range noindexPages {
+		names = append(names, name)
+	}
+	noindexPagesMu.Unlock()
+	if len(names) == 0 {
+		b.WriteString("Disallow:\n") // allow everything
+		return b.Bytes()
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString("Disallow: " + prefix + name + "\n")
+	}
+	return b.Bytes()
+}
+
+func saveRobotsTxt(name string) error {
+	return outputFS().WriteFile(name, robotsTxt())
+}
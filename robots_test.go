@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestRobotsTxt(t *testing.T) {
+	defer func(n bool, u string) { *noindex, *baseURL = n, u }(*noindex, *baseURL)
+	defer func(p map[string]bool) { noindexPages = p }(noindexPages)
+	tests := []struct {
+		noindex bool
+		baseURL string
+		pages   []string
+		want    string
+	}{
+		{false, "", nil, "User-agent: *\nDisallow:\n"},
+		{true, "", []string{"a.html"}, "User-agent: *\nDisallow: /\n"},
+		{true, "https://example.com/docs/", nil, "User-agent: *\nDisallow: /docs/\n"},
+		{false, "", []string{"b.html", "a.html"}, "User-agent: *\nDisallow: /a.html\nDisallow: /b.html\n"},
+		{false, "https://example.com/docs", []string{"a.html"}, "User-agent: *\nDisallow: /docs/a.html\n"},
+	}
+	for _, tt := range tests {
+		*noindex, *baseURL = tt.noindex, tt.baseURL
+		noindexPages = map[string]bool{}
+		for _, p := range tt.pages {
+			setNoindex(p, true)
+		}
+		if got := string(robotsTxt()); got != tt.want {
+			t.Errorf("robotsTxt() with -noindex=%v, -base-url=%q, pages %v = %q, want %q", tt.noindex, tt.baseURL, tt.pages, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// ## reStructuredText
+//
+// Projects that document themselves with Sphinx can include woven files
+// in their documentation tree with -format=rst, which writes
+// reStructuredText documents with the extension .rst. The prose goes
+// through the same Markdown parser as for HTML, and comes out as
+// reStructuredText (see weave/rst.go); the code goes into
+// `.. code-block::` directives for the language of the file, which Sphinx
+// highlights with Pygments, and captions become the `:caption:` of the
+// directive. The table of contents is a `.. contents::` directive, and the
+// links to related pages and to the pages of split documents use the
+// `:doc:` role of Sphinx. The sections start with labels of their IDs, for
+// the links of cross-references and package summaries.
+package main
+
+import (
+	"path"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// renderRST returns the reStructuredText document of the sections.
+func renderRST(d docs, sections []*section) string {
+	if *boilerplate == boilerplateHide {
+		splitBoilerplate(sections)
+	}
+	captions := weave.NumberCaptions(sections)
+	weaver().RSTSections(sections)
+	var b strings.Builder
+	if d.ShowTitle {
+		line := strings.Repeat("=", len([]rune(d.Title)))
+		b.WriteString(line + "\n" + d.Title + "\n" + line + "\n\n")
+	}
+	if d.TOC != nil {
+		b.WriteString(".. contents::\n   :local:\n\n")
+	}
+	lang := sourceLanguage(d.Filename).Name
+	if lang == "" {
+		lang = "text"
+	}
+	for _, s := range sections {
+		if doc := strings.TrimSpace(s.Doc); doc != "" {
+			b.WriteString(doc + "\n\n")
+		}
+		if *intro || strings.TrimSpace(s.Code) == "" {
+			continue
+		}
+		b.WriteString(".. code-block:: " + lang + "\n")
+		if c := s.Caption; c != nil {
+			b.WriteString("   :caption: " + c.Label() + ": " + c.Text + "\n")
+		}
+		b.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimRight(s.Code, "\n"), "\n") {
+			if strings.TrimSpace(line) != "" {
+				b.WriteString("   " + line)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	if *listings && len(captions) > 0 {
+		b.WriteString(".. rubric:: Listings\n\n")
+		for _, c := range captions {
+			b.WriteString("* " + c.Label() + ": " + c.Text + "\n")
+		}
+		b.WriteString("\n")
+	}
+	var pages []string
+	if d.Prev != nil {
+		pages = append(pages, rstDocLink("← "+d.Prev.Title, d.Prev.Href))
+	}
+	if d.Next != nil {
+		pages = append(pages, rstDocLink(d.Next.Title+" →", d.Next.Href))
+	}
+	if len(pages) > 0 {
+		b.WriteString(strings.Join(pages, " · ") + "\n\n")
+	}
+	if len(d.Related) > 0 {
+		b.WriteString(".. rubric:: Related\n\n")
+		for _, r := range d.Related {
+			b.WriteString("* " + rstDocLink(r.Title, r.Href) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// rstDocLink links to another document of the Sphinx project, which the
+// :doc: role names without the extension.
+func rstDocLink(title string, href urlPath) string {
+	name := strings.TrimSuffix(string(href), path.Ext(string(href)))
+	return ":doc:`" + strings.Replace(title, "<", `\<`, -1) + " <" + name + ">`"
+}
@@ -0,0 +1,123 @@
+// ## Schema tables
+//
+// Structs with `json:` or `yaml:` tags describe a data format, and the
+// readers of their documents want to know that format rather than the Go
+// declaration. With -schema, goweave adds a table beneath the section of
+// each such struct that lists the fields with their types, tags, and
+// comments:
+//
+//	| Field | Type | Tag | Description |
+//	| --- | --- | --- | --- |
+//	| `Title` | `string` | `json:"title,omitempty"` | The title of the page. |
+//
+// As the table comes from the code, it cannot get out of date. The
+// description of a field is the comment above it, or else the comment at
+// the end of its line. Fields that neither JSON nor YAML would encode, like
+// unexported fields without a tag, do not show up.
+//
+// The table is a section of its own, with the ID `schema-<type name>`; see
+// tables.go.
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// schemaIDPrefix starts the IDs of the sections that hold schema tables.
+const schemaIDPrefix = "schema-"
+
+// schemaField is a row of a schema table.
+type schemaField struct {
+	name, typ, tag, doc string
+}
+
+// schemaTables returns a schema table for each struct with `json:` or
+// `yaml:` tags.
+func schemaTables(c *sectionCode) []codeTable {
+	var tables []codeTable
+	for _, decl := range c.file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			var fields []schemaField
+			tagged := false
+			for _, fd := range st.Fields.List {
+				if fd.Tag != nil && (strings.Contains(fd.Tag.Value, "json:") || strings.Contains(fd.Tag.Value, "yaml:")) {
+					tagged = true
+				}
+				fields = append(fields, structFields(fd, c.docOf(fd.Pos(), fd.Doc, fd.Comment))...)
+			}
+			if !tagged || fields == nil {
+				continue
+			}
+			tables = append(tables, c.tableSection(schemaIDPrefix+ts.Name.Name, schemaTable(ts.Name.Name, fields), ts.Pos(), st.Fields.Closing))
+		}
+	}
+	return tables
+}
+
+// structFields returns the rows of the names of a field declaration, if the
+// field has a `json:` or `yaml:` tag, or is exported.
+func structFields(fd *ast.Field, doc string) []schemaField {
+	var tag reflect.StructTag
+	if fd.Tag != nil {
+		if t, err := strconv.Unquote(fd.Tag.Value); err == nil {
+			tag = reflect.StructTag(t)
+		}
+	}
+	var tags []string
+	skipped := 0
+	for _, key := range []string{"json", "yaml"} {
+		if v, ok := tag.Lookup(key); ok {
+			tags = append(tags, key+":"+strconv.Quote(v))
+			if v == "-" {
+				skipped++
+			}
+		}
+	}
+	if len(tags) > 0 && skipped == len(tags) {
+		return nil
+	}
+	names := fd.Names
+	if len(names) == 0 { // an embedded field
+		t := fd.Type
+		if star, ok := t.(*ast.StarExpr); ok {
+			t = star.X
+		}
+		if sel, ok := t.(*ast.SelectorExpr); ok {
+			t = sel.Sel
+		}
+		if id, ok := t.(*ast.Ident); ok {
+			names = []*ast.Ident{id}
+		}
+	}
+	var fields []schemaField
+	for _, n := range names {
+		if len(tags) == 0 && !n.IsExported() {
+			continue
+		}
+		fields = append(fields, schemaField{n.Name, types.ExprString(fd.Type), strings.Join(tags, " "), doc})
+	}
+	return fields
+}
+
+// schemaTable returns the schema table of a struct as Markdown.
+func schemaTable(name string, fields []schemaField) string {
+	rows := make([][]string, len(fields))
+	for i, f := range fields {
+		rows[i] = []string{codeSpan(f.name), codeSpan(f.typ), codeSpan(f.tag), f.doc}
+	}
+	return markdownTable("Schema of `"+name+"`", []string{"Field", "Type", "Tag", "Description"}, rows)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaTables(t *testing.T) {
+	defer func(s, c bool) { *schema, *constTables = s, c }(*schema, *constTables)
+	*schema, *constTables = true, false
+	src := "package p\n\n// Page is a page.\ntype Page struct {\n\t// The title of the page.\n\tTitle string `json:\"title\" yaml:\"title\"`\n" +
+		"\tTags []string `json:\"tags,omitempty\"` // a|b\n\tdraft bool\n\tSecret string `json:\"-\"`\n\tMeta\n}\n\n// Plain has no tags.\ntype Plain struct {\n\tX int\n}\n"
+	sections := tableSections(extractSections("p.go", src))
+	var table *section
+	for i, s := range sections {
+		if strings.HasPrefix(s.ID, schemaIDPrefix) {
+			if table != nil {
+				t.Fatalf("tableSections() added more than one table: %q", s.Doc)
+			}
+			if !strings.Contains(sections[i-1].Code, "}") {
+				t.Errorf("the schema table follows %q, want the end of the struct", sections[i-1].Code)
+			}
+			table = s
+		}
+	}
+	if table == nil || table.ID != "schema-Page" {
+		t.Fatalf("tableSections() = %v, want a table for Page", sections)
+	}
+	for _, row := range []string{
+		"| `Title` | `string` | `json:\"title\" yaml:\"title\"` | The title of the page. |",
+		"| `Tags` | `[]string` | `json:\"tags,omitempty\"` | a\\|b |",
+		"| `Meta` | `Meta` | | |",
+	} {
+		if !strings.Contains(table.Doc, row) {
+			t.Errorf("the schema table is\n%s\nwant the row %s", table.Doc, row)
+		}
+	}
+	for _, field := range []string{"draft", "Secret"} {
+		if strings.Contains(table.Doc, "`"+field+"`") {
+			t.Errorf("the schema table is\n%s\nwant no %s", table.Doc, field)
+		}
+	}
+	if got := tableSections(extractSections("p.go", "package p\n\nfunc (\n")); len(got) != 1 {
+		t.Errorf("tableSections() of broken code = %d sections, want 1", len(got))
+	}
+}
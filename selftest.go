@@ -0,0 +1,206 @@
+// ## Self-test
+//
+// `goweave selftest` weaves a bundled corpus of tricky inputs, like block
+// comments, strings that contain `//`, cgo preambles, and generics, with the
+// current template, CSS, and options. It checks two things:
+//
+// * The structure of each HTML document: every tag is closed in the right
+// order, and every section shows up, in the order of the source.
+// * The documents are the same as the snapshots that
+// `goweave selftest -update` stored before.
+//
+// This is meant for theme authors: store the snapshots while the template
+// works, and run the self-test after each change. A broken structure fails
+// right away; any other difference shows up with the first line that
+// changed, to be checked and accepted with -update. The snapshots go to
+// `goweave/selftest`, or to the directory given after the options.
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+//go:embed testdata/selftest/*.go
+var selftestCorpus embed.FS
+
+const (
+	selftestCorpusDir  = "testdata/selftest"
+	defaultSnapshotDir = "goweave/selftest"
+)
+
+// selftestCommand runs the `selftest` subcommand and returns an error if
+// any document fails.
+func selftestCommand(args []string) error {
+	fset := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	update := fset.Bool("update", false, "store the documents as the new snapshots")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() > 1 {
+		return fmt.Errorf("usage: goweave selftest [-update] [snapshot dir]")
+	}
+	dir := defaultSnapshotDir
+	if fset.NArg() == 1 {
+		dir = fset.Arg(0)
+	}
+	resourcedir = findResources()
+	if err := loadResources(resourcedir); err != nil {
+		return err
+	}
+	if err := loadFonts(); err != nil {
+		return err
+	}
+	if err := loadHighlighter(); err != nil {
+		return err
+	}
+	if n, err := selftest(os.Stdout, dir, *update); err != nil || n > 0 {
+		if err == nil {
+			err = fmt.Errorf("%d document(s) failed the self-test", n)
+		}
+		return err
+	}
+	return nil
+}
+
+// selftest weaves the corpus, writes a report to w, and returns the number
+// of documents that fail. With update, it stores the snapshots in dir
+// instead of comparing them.
+func selftest(w io.Writer, dir string, update bool) (int, error) {
+	names, err := fs.Glob(selftestCorpus, selftestCorpusDir+"/*.go")
+	if err != nil {
+		return 0, err
+	}
+	if update {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, err
+		}
+	}
+	failed := 0
+	for _, name := range names {
+		src, err := fs.ReadFile(selftestCorpus, name)
+		if err != nil {
+			return failed, err
+		}
+		name = path.Base(name)
+		sections := extractSections(name, string(src))
+		outname := outputName(name)
+		doc, err := renderDocs(docs{Filename: name, Title: name, outname: outname}, sections)
+		if err == nil && !*md {
+			err = checkStructure(doc, sections)
+		}
+		snapshot := filepath.Join(dir, filepath.FromSlash(outname))
+		if err == nil && update {
+			err = ioutil.WriteFile(snapshot, []byte(doc), 0666)
+			if err != nil {
+				return failed, err
+			}
+		} else if err == nil {
+			err = compareSnapshot(doc, snapshot)
+		}
+		if err != nil {
+			fmt.Fprintf(w, "FAIL  %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "ok    %s\n", name)
+	}
+	return failed, nil
+}
+
+// compareSnapshot returns an error that shows the first line in which the
+// document differs from the snapshot.
+func compareSnapshot(doc, snapshot string) error {
+	want, err := ioutil.ReadFile(snapshot)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no snapshot in %s; run goweave selftest -update", snapshot)
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(want, []byte(doc)) {
+		return nil
+	}
+	got, wantLines := strings.Split(doc, "\n"), strings.Split(string(want), "\n")
+	for i := 0; ; i++ {
+		var g, s string
+		if i < len(got) {
+			g = got[i]
+		}
+		if i < len(wantLines) {
+			s = wantLines[i]
+		}
+		if g != s || i >= len(got) || i >= len(wantLines) {
+			return fmt.Errorf("differs from %s in line %d:\n  got:  %s\n  want: %s", snapshot, i+1, g, s)
+		}
+	}
+}
+
+var (
+	htmlTagRe    = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)[^>]*?(/?)>`)
+	htmlSkipRe   = regexp.MustCompile(`(?is)<!--.*?-->|<script\b.*?</script>|<style\b.*?</style>`)
+	voidElements = map[string]bool{
+		"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+		"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+	}
+	// optionalEnd lists the elements whose end tags HTML allows to leave
+	// out.
+	optionalEnd = map[string]bool{
+		"p": true, "li": true, "dt": true, "dd": true, "tr": true, "td": true, "th": true,
+		"thead": true, "tbody": true, "tfoot": true, "option": true,
+	}
+)
+
+// checkStructure returns an error if the tags of an HTML document are not
+// balanced, or if the document does not contain the IDs of all sections in
+// order.
+func checkStructure(doc string, sections []*section) error {
+	var open []string
+	for _, m := range htmlTagRe.FindAllStringSubmatch(htmlSkipRe.ReplaceAllString(doc, ""), -1) {
+		closing, tag, selfClosing := m[1] == "/", strings.ToLower(m[2]), m[3] == "/"
+		switch {
+		case voidElements[tag] || selfClosing:
+		case !closing:
+			open = append(open, tag)
+		default:
+			for len(open) > 0 && open[len(open)-1] != tag && optionalEnd[open[len(open)-1]] {
+				open = open[:len(open)-1]
+			}
+			if len(open) == 0 {
+				return fmt.Errorf("</%s> closes no element", tag)
+			}
+			if top := open[len(open)-1]; top != tag {
+				return fmt.Errorf("</%s> closes <%s>", tag, top)
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	for len(open) > 0 && optionalEnd[open[len(open)-1]] {
+		open = open[:len(open)-1]
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("<%s> is never closed", open[len(open)-1])
+	}
+	pos := 0
+	for _, s := range sections {
+		if s.ID == "" {
+			continue
+		}
+		i := strings.Index(doc[pos:], `"`+s.ID+`"`)
+		if i < 0 {
+			return fmt.Errorf("section %s is missing or out of order", s.ID)
+		}
+		pos += i
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSelftest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "selftest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var report strings.Builder
+	if n, err := selftest(&report, dir, true); err != nil || n > 0 {
+		t.Fatalf("selftest() with -update = %d, %v:\n%s", n, err, report.String())
+	}
+	report.Reset()
+	if n, err := selftest(&report, dir, false); err != nil || n > 0 {
+		t.Fatalf("selftest() = %d, %v:\n%s", n, err, report.String())
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "generics.html"), []byte("changed\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	report.Reset()
+	if n, _ := selftest(&report, dir, false); n != 1 || !strings.Contains(report.String(), "FAIL  generics.go: differs") {
+		t.Errorf("selftest() with a changed snapshot = %d:\n%s", n, report.String())
+	}
+}
+
+func TestCheckStructure(t *testing.T) {
+	sections := []*section{{ID: "one"}, {ID: "two"}}
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{`<div id="one"><p>a<br><img src="x"/></div><ul><li>b</ul><div id="two"></div>`, ""},
+		{`<div id="one"><pre></div></pre><div id="two"></div>`, "</div> closes <pre>"},
+		{`<div id="one"><div id="two"></div>`, "<div> is never closed"},
+		{`<div id="one"></div></div><div id="two"></div>`, "</div> closes no element"},
+		{`<div id="two"></div><div id="one"></div>`, "section two is missing or out of order"},
+		{`<script>if (a<b) {}</script><div id="one"></div><div id="two"></div>`, ""},
+	}
+	for _, tt := range tests {
+		err := checkStructure(tt.doc, sections)
+		if got := fmt.Sprint(err); (tt.want == "" && err != nil) || (tt.want != "" && got != tt.want) {
+			t.Errorf("checkStructure(%s) = %v, want %q", tt.doc, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// ## Preview server
+//
+// With -serve, goweave serves the output directory over HTTP after
+// generating the documents. Together with -watch, this makes for a live
+// preview: goweave adds a small script to each HTML page it serves, and the
+// script reloads the page as soon as goweave has regenerated the documents,
+// and marks the sections that changed.
+//
+// `-serve` alone listens on localhost:8080; `-serve=:3000` or
+// `-serve=0.0.0.0:3000` choose another address.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// defaultServeAddr is the address that -serve listens on if none is given.
+const defaultServeAddr = "localhost:8080"
+
+// reloadPath is the URL path of the event stream that tells the pages to
+// reload.
+const reloadPath = "/_goweave/reload"
+
+// reloadScript reloads the page when the server sends an event. If the
+// connection breaks, the browser retries on its own. It comes with the
+// change markers of changes.go.
+const reloadScript = `<script src="` + changesPath + `"></script>
+<script>new EventSource("` + reloadPath + `").onmessage = function() { location.reload(); };</script>
+`
+
+// serveAddr implements -serve. It acts like a boolean flag, so that
+// `-serve` works without an address.
+type serveAddr string
+
+func (a *serveAddr) String() string {
+	if a == nil {
+		return ""
+	}
+	return string(*a)
+}
+
+func (a *serveAddr) Set(s string) error {
+	switch s {
+	case "true":
+		*a = defaultServeAddr
+	case "false":
+		*a = ""
+	default:
+		if !strings.Contains(s, ":") {
+			return fmt.Errorf("%q is not an address like :8080 or localhost:8080", s)
+		}
+		*a = serveAddr(s)
+	}
+	return nil
+}
+
+func (a *serveAddr) IsBoolFlag() bool { return true }
+
+var serve serveAddr
+
+func init() {
+	flag.Var(&serve, "serve", "serve the output directory over HTTP at this address (default "+defaultServeAddr+")")
+}
+
+// reloader sends an event to every connected page when notified.
+type reloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+var reloads = reloader{clients: map[chan struct{}]bool{}}
+
+// notify tells all connected pages to reload.
+func (r *reloader) notify() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for c := range r.clients {
+		select {
+		case c <- struct{}{}:
+		default: // a reload is pending already
+		}
+	}
+}
+
+// ServeHTTP streams reload events to a page.
+func (r *reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	c := make(chan struct{}, 1)
+	r.mu.Lock()
+	r.clients[c] = true
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.clients, c)
+		r.mu.Unlock()
+	}()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	flusher.Flush()
+	for {
+		select {
+		case <-c:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+// previewHandler serves the files in dir, with the reload script added to
+// HTML pages.
+func previewHandler(dir string) http.Handler {
+	root := http.Dir(dir)
+	files := http.FileServer(root)
+	mux := http.NewServeMux()
+	mux.Handle(reloadPath, &reloads)
+	mux.HandleFunc(changesPath, serveChangeScript)
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		name := req.URL.Path
+		if strings.HasSuffix(name, "/") {
+			name += "index.html"
+		}
+		if path.Ext(name) != ".html" {
+			files.ServeHTTP(w, req)
+			return
+		}
+		f, err := root.Open(name)
+		if err != nil {
+			files.ServeHTTP(w, req) // let the file server report the error
+			return
+		}
+		page, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte(injectReloadScript(string(page))))
+	})
+	return mux
+}
+
+// injectReloadScript adds the reload script to the end of the body of an
+// HTML page.
+func injectReloadScript(page string) string {
+	if i := strings.LastIndex(page, "</body>"); i >= 0 {
+		return page[:i] + reloadScript + page[i:]
+	}
+	return page + reloadScript
+}
+
+// servePreview serves the output directory until goweave gets stopped.
+// With -webhook-secret, pushes rebuild the documents of args; see
+// webhook.go.
+func servePreview(addr string, args []string) error {
+	host := addr
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
+	}
+	log.Printf("Serving %s at http://%s/", *outdir, host)
+	handler := previewHandler(*outdir)
+	if *webhookSecret != "" {
+		hook := newWebhook(*webhookSecret, args)
+		mux := http.NewServeMux()
+		mux.Handle(webhookPath, hook)
+		mux.HandleFunc(buildStatusPath, hook.serveStatus)
+		mux.Handle("/", handler)
+		handler = mux
+		log.Printf("Accepting push events at http://%s%s", host, webhookPath)
+	}
+	return http.ListenAndServe(addr, handler)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeAddr(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    serveAddr
+		wantErr bool
+	}{
+		{"true", defaultServeAddr, false},
+		{"false", "", false},
+		{":3000", ":3000", false},
+		{"0.0.0.0:3000", "0.0.0.0:3000", false},
+		{"3000", "", true},
+	}
+	for _, tt := range tests {
+		var a serveAddr
+		err := a.Set(tt.value)
+		if (err != nil) != tt.wantErr || a != tt.want {
+			t.Errorf("Set(%q) = %q, %v; want %q, error %v", tt.value, a, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPreviewHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("<html><body><p>Index</p></body></html>"), 0666)
+	ioutil.WriteFile(filepath.Join(dir, "goweave.css"), []byte("body {}"), 0666)
+	h := previewHandler(dir)
+	tests := []struct {
+		path       string
+		wantCode   int
+		wantScript bool
+	}{
+		{"/", 200, true},
+		{"/index.html", 200, true},
+		{"/goweave.css", 200, false},
+		{"/missing.html", 404, false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("GET %s: status %d, want %d", tt.path, w.Code, tt.wantCode)
+		}
+		if got := strings.Contains(w.Body.String(), reloadPath); got != tt.wantScript {
+			t.Errorf("GET %s: reload script included = %v, want %v:\n%s", tt.path, got, tt.wantScript, w.Body.String())
+		}
+		if got := strings.Contains(w.Body.String(), `<script src="`+changesPath+`">`); got != tt.wantScript {
+			t.Errorf("GET %s: change markers included = %v, want %v:\n%s", tt.path, got, tt.wantScript, w.Body.String())
+		}
+	}
+}
+
+func TestChangeScript(t *testing.T) {
+	w := httptest.NewRecorder()
+	previewHandler(".").ServeHTTP(w, httptest.NewRequest("GET", changesPath, nil))
+	if w.Code != 200 || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/javascript") {
+		t.Fatalf("GET %s: status %d, type %q", changesPath, w.Code, w.Header().Get("Content-Type"))
+	}
+	// The script keeps the sections in the session storage and marks them
+	// with the classes of its style sheet.
+	for _, want := range []string{`sessionStorage`, `#goweave div.section`, `goweave-changed`, `goweave-added`, `goweave-removed-before`} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("GET %s: the script has no %s", changesPath, want)
+		}
+	}
+}
+
+func TestInjectReloadScript(t *testing.T) {
+	if got := injectReloadScript("<body>x</body>"); got != "<body>x"+reloadScript+"</body>" {
+		t.Errorf("injectReloadScript() = %q", got)
+	}
+	if got := injectReloadScript("<div>fragment</div>"); got != "<div>fragment</div>"+reloadScript {
+		t.Errorf("injectReloadScript() = %q", got)
+	}
+}
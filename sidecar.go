@@ -0,0 +1,330 @@
+// ## Sidecar files
+//
+// Some code cannot carry comments of its own, like generated code or a
+// vendored copy of a third-party package. Its documentation can live in a
+// sidecar file next to it, a Markdown file named after the source file,
+// like `parser.go.weave.md`. Markers attach each piece of prose to a range
+// of lines or to a declaration:
+//
+//	<!-- goweave:lines 12-30 -->
+//	The lexer hands the tokens over to the parser here.
+//
+//	<!-- goweave:symbol Parser.Next -->
+//	Next is where the parser spends most of its time.
+//
+// Text before the first marker goes to the top of the file. goweave turns
+// the prose into comment lines of the source file's language and inserts
+// them before the line or the declaration, or rather before its doc
+// comment, which the prose then extends. So the prose starts a section
+// that begins with the lines it annotates; after a line range, the code
+// after the range starts a section of its own. The source file itself
+// stays untouched, and so does the plain source of -rawview. Markers that
+// point nowhere get a warning.
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sidecarSuffix is appended to the name of a source file to get the name
+// of its sidecar file.
+const sidecarSuffix = ".weave.md"
+
+// sidecarMarker matches the lines that attach the prose after them to
+// lines or to a symbol.
+var sidecarMarker = regexp.MustCompile(`^<!--\s*goweave:(lines|symbol)\s+(.*?)\s*-->\s*$`)
+
+// sidecarNote is a piece of prose from a sidecar file.
+type sidecarNote struct {
+	kind   string // "lines", "symbol", or "" for the text before the first marker
+	target string
+	line   int // the line of the marker in the sidecar file
+	text   []string
+}
+
+// sidecarFile returns the name of the sidecar file of a source file.
+func sidecarFile(filename string) string {
+	return filename + sidecarSuffix
+}
+
+// parseSidecar splits a sidecar file into its notes.
+func parseSidecar(src string) []sidecarNote {
+	notes := []sidecarNote{{}}
+	for i, line := range strings.Split(src, "\n") {
+		if m := sidecarMarker.FindStringSubmatch(line); m != nil {
+			notes = append(notes, sidecarNote{kind: m[1], target: m[2], line: i + 1})
+			continue
+		}
+		n := &notes[len(notes)-1]
+		n.text = append(n.text, strings.TrimRight(line, "\r"))
+	}
+	for i := range notes {
+		notes[i].text = trimBlankLines(notes[i].text)
+	}
+	return notes
+}
+
+// trimBlankLines removes the empty lines at the start and at the end.
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// parseLineRange parses a range like "12-30", or a single line like "12".
+func parseLineRange(s string) (from, to int, err error) {
+	a, b := s, s
+	if i := strings.Index(s, "-"); i >= 0 {
+		a, b = s[:i], s[i+1:]
+	}
+	from, err = strconv.Atoi(strings.TrimSpace(a))
+	if err == nil {
+		to, err = strconv.Atoi(strings.TrimSpace(b))
+	}
+	if err != nil || from < 1 || to < from {
+		return 0, 0, fmt.Errorf("invalid line range %q", s)
+	}
+	return from, to, nil
+}
+
+// declLines returns the first line of each declaration of a Go file, or of
+// its doc comment, by the name that cross-references use, like
+// "Parser.Next".
+func declLines(filename string, src []byte) map[string]int {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+	lines := map[string]int{}
+	add := func(name string, pos token.Pos, doc *ast.CommentGroup) {
+		if doc != nil {
+			pos = doc.Pos()
+		}
+		lines[name] = fset.Position(pos).Line
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverType(d.Recv.List[0].Type) + "." + name
+			}
+			add(name, d.Pos(), d.Doc)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				pos, doc := spec.Pos(), d.Doc
+				if !d.Lparen.IsValid() {
+					pos = d.Pos() // the spec's line has the keyword, too
+				} else if s, ok := spec.(*ast.ValueSpec); ok && s.Doc != nil {
+					doc = s.Doc
+				} else if s, ok := spec.(*ast.TypeSpec); ok && s.Doc != nil {
+					doc = s.Doc
+				} else {
+					doc = nil
+				}
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					add(s.Name.Name, pos, doc)
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						add(n.Name, pos, doc)
+					}
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// withSidecar returns the source of a file with the prose of its sidecar
+// file inserted as comments, and its includes resolved (see include.go),
+// or the source as it is if there is neither. With warn set, it logs the
+// markers and includes that point nowhere.
+func withSidecar(filename string, src []byte, warn bool) []byte {
+	inserts := sourceInserts(filename, src, warn)
+	if len(inserts) == 0 {
+		return src
+	}
+	return insertLines(src, inserts)
+}
+
+// extractWithSidecar splits a source file with the prose of its sidecar
+// file and the lines of its includes into sections. The lines and offsets
+// of the sections refer to the source file itself, not counting the
+// inserted lines.
+func extractWithSidecar(filename string, src []byte, warn bool) []*section {
+	inserts := sourceInserts(filename, src, warn)
+	if len(inserts) == 0 {
+		return extractSections(filename, string(src))
+	}
+	sections := extractSections(filename, string(insertLines(src, inserts)))
+	sourcePositions(sections, src, inserts)
+	return sections
+}
+
+// sourceInserts returns the lines to insert into a source file: the prose
+// of its sidecar file, and before each include pragma, the included lines.
+func sourceInserts(filename string, src []byte, warn bool) map[int][]string {
+	inserts := sidecarInserts(filename, src, warn)
+	includes := includeInserts(filename, src, warn)
+	if len(includes) > 0 && inserts == nil {
+		inserts = map[int][]string{}
+	}
+	for i, lines := range includes {
+		inserts[i] = append(inserts[i], lines...)
+	}
+	return inserts
+}
+
+// sidecarInserts returns the comment lines of the sidecar file of a source
+// file, by the index of the line of the source that they go before.
+func sidecarInserts(filename string, src []byte, warn bool) map[int][]string {
+	logf := log.Printf
+	if !warn {
+		logf = func(string, ...interface{}) {}
+	}
+	if _, err := sourceSize(sidecarFile(filename)); err != nil {
+		return nil // no sidecar file
+	}
+	side, err := readSource(sidecarFile(filename))
+	if err != nil {
+		logf("%v", err)
+		return nil
+	}
+	comment := sourceLanguage(filename).LineComment
+	if comment == "" {
+		logf("%s: %s has no line comments to put the notes into", sidecarFile(filename), sourceLanguage(filename).Name)
+		return nil
+	}
+	comment += *proseMarker
+	lines := strings.SplitAfter(string(src), "\n")
+	inserts := map[int][]string{} // the comment lines before each line, from 0
+	var decls map[string]int
+	for _, n := range parseSidecar(string(side)) {
+		at, end := 0, -1
+		switch n.kind {
+		case "":
+			if len(n.text) == 0 {
+				continue
+			}
+		case "lines":
+			from, to, err := parseLineRange(n.target)
+			if err != nil || to > len(lines) {
+				logf("%s:%d: there are no lines %s in %s", sidecarFile(filename), n.line, n.target, filename)
+				continue
+			}
+			at, end = from-1, to
+		case "symbol":
+			if decls == nil {
+				decls = declLines(filename, src)
+			}
+			line, ok := decls[n.target]
+			if !ok {
+				logf("%s:%d: %s declares no %s", sidecarFile(filename), n.line, filename, n.target)
+				continue
+			}
+			at = line - 1
+		}
+		if len(inserts[at]) > 0 {
+			inserts[at] = append(inserts[at], comment)
+		}
+		for _, t := range n.text {
+			if t == "" {
+				inserts[at] = append(inserts[at], comment)
+			} else {
+				inserts[at] = append(inserts[at], comment+" "+t)
+			}
+		}
+		// Code after the range starts a section of its own.
+		if end >= 0 && end < len(lines) && len(inserts[end]) == 0 {
+			inserts[end] = []string{comment}
+		}
+	}
+	return inserts
+}
+
+// insertLines inserts lines into a source, before the lines of the source
+// with the given indexes.
+func insertLines(src []byte, inserts map[int][]string) []byte {
+	lines := strings.SplitAfter(string(src), "\n")
+	at := make([]int, 0, len(inserts))
+	for i := range inserts {
+		at = append(at, i)
+	}
+	sort.Ints(at)
+	var b strings.Builder
+	next := 0
+	for i, line := range lines {
+		for next < len(at) && at[next] == i {
+			for _, c := range inserts[at[next]] {
+				b.WriteString(c)
+				b.WriteByte('\n')
+			}
+			next++
+		}
+		b.WriteString(line)
+	}
+	return []byte(b.String())
+}
+
+// sourcePositions maps the lines and offsets of sections from a source with
+// inserted lines back to the source without them. A comment that consists
+// of inserted lines only has no position in the source. A comment that
+// starts with an inserted empty line, which only ends the section before an
+// include, loses that line.
+func sourcePositions(sections []*section, src []byte, inserts map[int][]string) {
+	lines := strings.SplitAfter(string(src), "\n")
+	offsets := make([]int, len(lines)+1) // of the lines of src, from 0
+	var orig []int                       // the line of src of each line, from 1
+	for i, line := range lines {
+		for range inserts[i] {
+			orig = append(orig, 0)
+		}
+		orig = append(orig, i+1)
+		offsets[i+1] = offsets[i] + len(line)
+	}
+	// toSource returns the first and the last line of src within the
+	// lines from start to end, and their offsets.
+	toSource := func(start, end int) (int, int, int, int) {
+		first, last := 0, 0
+		for l := start; l >= 1 && l <= end && l <= len(orig); l++ {
+			if orig[l-1] != 0 {
+				if first == 0 {
+					first = orig[l-1]
+				}
+				last = orig[l-1]
+			}
+		}
+		if first == 0 {
+			return 0, 0, 0, 0
+		}
+		return first, last, offsets[first-1], offsets[last]
+	}
+	for _, s := range sections {
+		if s.DocStart >= 1 && s.DocStart <= len(orig) && orig[s.DocStart-1] == 0 && strings.HasPrefix(s.Doc, "\n") {
+			s.Doc = s.Doc[1:]
+		}
+		s.DocStart, s.DocEnd, s.DocOffset, s.DocEndOffset = toSource(s.DocStart, s.DocEnd)
+		s.CodeStart, s.CodeEnd, s.CodeOffset, s.CodeEndOffset = toSource(s.CodeStart, s.CodeEnd)
+		var skipped []int
+		for _, l := range s.SkippedLines {
+			if l >= 1 && l <= len(orig) && orig[l-1] != 0 {
+				skipped = append(skipped, orig[l-1])
+			}
+		}
+		s.SkippedLines = skipped
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithSidecar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := "package gen\n\nvar a = 1\nvar b = 2\nvar c = 3\n\n// Next is documented.\nfunc (p *Parser) Next() {}\n\ntype T int\n"
+	filename := filepath.Join(dir, "gen.go")
+	if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(withSidecar(filename, []byte(src), false)); got != src {
+		t.Errorf("withSidecar() without a sidecar file = %q, want the source", got)
+	}
+
+	side := "# Generated code\n\n<!-- goweave:lines 3-4 -->\nThe first two.\n\nAll of them.\n" +
+		"<!-- goweave:symbol Parser.Next -->\nMore about Next.\n\n<!-- goweave:symbol T -->\nThe type.\n" +
+		"<!-- goweave:symbol Missing -->\nLost.\n<!-- goweave:lines 40-41 -->\nLost, too.\n"
+	if err := ioutil.WriteFile(sidecarFile(filename), []byte(side), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := "// # Generated code\npackage gen\n\n// The first two.\n//\n// All of them.\nvar a = 1\nvar b = 2\n//\nvar c = 3\n\n" +
+		"// More about Next.\n// Next is documented.\nfunc (p *Parser) Next() {}\n\n// The type.\ntype T int\n"
+	if got := string(withSidecar(filename, []byte(src), false)); got != want {
+		t.Errorf("withSidecar() = %q, want %q", got, want)
+	}
+
+	// The positions of the sections leave out the inserted prose.
+	sections := extractWithSidecar(filename, []byte(src), false)
+	positions := [][4]int{{0, 0, 1, 2}, {0, 0, 3, 4}, {0, 0, 5, 6}, {7, 7, 8, 9}, {0, 0, 10, 10}}
+	if len(sections) != len(positions) {
+		t.Fatalf("extractWithSidecar() = %d sections, want %d", len(sections), len(positions))
+	}
+	for i, s := range sections {
+		if got := [4]int{s.DocStart, s.DocEnd, s.CodeStart, s.CodeEnd}; got != positions[i] {
+			t.Errorf("section %d is at the lines %v, want %v", i, got, positions[i])
+		}
+	}
+	if s := sections[1]; s.CodeOffset != 13 || src[s.CodeOffset:s.CodeEndOffset] != "var a = 1\nvar b = 2\n" {
+		t.Errorf("section 1 has the code at %d-%d, want 13-33", s.CodeOffset, s.CodeEndOffset)
+	}
+}
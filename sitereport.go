@@ -0,0 +1,190 @@
+// ## Site report
+//
+// Large literate sites decay like any other documentation: a file gets
+// deleted, and the comments that refer to it keep pointing into the void.
+// With -site-report, goweave prints a maintenance report after processing
+// all files. It lists
+//
+// * orphaned pages, which no chain of links leads to from the index page,
+// * cross-references that cannot be resolved,
+// * images that do not exist, and
+// * links to documents that are neither generated in this run nor present
+// in the output directory.
+//
+// The index page is the document named `index.html` (`index.md` with -md),
+// or else the one that goweave writes. The latter links to every page, so
+// orphans only show up with -index=false or with an index page of your own.
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	siteMu       sync.Mutex
+	pageLinks    = map[string][]string{} // the documents that each document links to
+	deadRefs     = map[string][]string{} // the dead references of each source file
+	indexWritten bool                    // true if goweave wrote the index page
+)
+
+// htmlLink and mdLink match the targets of links in generated HTML and
+// Markdown documents.
+var (
+	htmlLink = regexp.MustCompile(`<a [^>]*\bhref="([^"]*)"`)
+	mdLink   = regexp.MustCompile(`\]\(([^)\s]+)\)`)
+)
+
+// addDeadRef records a reference to a missing target.
+func addDeadRef(source, problem string) {
+	siteMu.Lock()
+	deadRefs[source] = append(deadRefs[source], problem)
+	siteMu.Unlock()
+}
+
+// resetSiteReport forgets what is known about a document, before it gets
+// generated anew in watch mode.
+func resetSiteReport(source string) {
+	siteMu.Lock()
+	delete(deadRefs, source)
+	siteMu.Unlock()
+}
+
+// recordLinks collects the links and images of a generated document.
+func recordLinks(doc, source, outname string) {
+	link := htmlLink
+	if *md {
+		link = mdLink
+	}
+	var targets []string
+	for _, m := range link.FindAllStringSubmatch(doc, -1) {
+		if t, ok := linkTarget(html.UnescapeString(m[1]), outname); ok {
+			targets = append(targets, t)
+		}
+	}
+	for _, m := range imgTag.FindAllStringSubmatch(doc, -1) {
+		src := html.UnescapeString(m[1])
+		filename, ok := localImage(src, source)
+		if !ok {
+			continue
+		}
+		var err error
+		if *copyImages {
+			// The document refers to the copy.
+			_, err = fs.Stat(outputFS(), path.Join(path.Dir(outname), src))
+		} else {
+			_, err = os.Stat(filename)
+		}
+		if err != nil {
+			addDeadRef(source, fmt.Sprintf("image %s does not exist", src))
+		}
+	}
+	siteMu.Lock()
+	pageLinks[outname] = targets
+	siteMu.Unlock()
+}
+
+// linkTarget returns the document that a link points to, relative to the
+// output directory. ok is false for links to other sites, links within the
+// same document, and links to anything but documents.
+func linkTarget(href, outname string) (string, bool) {
+	if i := strings.IndexAny(href, "#?"); i >= 0 {
+		href = href[:i]
+	}
+	if href == "" || strings.Contains(href, ":") || strings.HasPrefix(href, "/") {
+		return "", false
+	}
+	target := path.Join(path.Dir(outname), href)
+	if strings.HasSuffix(href, "/") {
+		target = path.Join(target, indexName)
+	}
+	switch path.Ext(target) {
+	case ".html", ".md":
+		return target, true
+	}
+	return "", false
+}
+
+// collectSiteReport computes the orphaned pages and the dead references.
+func collectSiteReport() (orphans []string, root string, dead []string) {
+	siteMu.Lock()
+	defer siteMu.Unlock()
+	reached := map[string]bool{}
+	var visit func(page string)
+	visit = func(page string) {
+		if reached[page] {
+			return
+		}
+		reached[page] = true
+		for _, t := range pageLinks[page] {
+			visit(t)
+		}
+	}
+	indexPage := indexName
+	if *md {
+		indexPage = "index.md"
+	}
+	if _, ok := pageLinks[indexPage]; ok {
+		root = indexPage
+		visit(indexPage)
+	} else if indexWritten {
+		root = indexPage
+		for _, e := range indexEntries() {
+			visit(string(e.Href))
+		}
+	}
+	for page, targets := range pageLinks {
+		if root != "" && !reached[page] {
+			orphans = append(orphans, page)
+		}
+		for _, t := range targets {
+			if _, ok := pageLinks[t]; ok {
+				continue
+			}
+			if _, err := fs.Stat(outputFS(), t); err != nil {
+				dead = append(dead, fmt.Sprintf("%s: link to %s, which does not exist", page, t))
+			}
+		}
+	}
+	for source, problems := range deadRefs {
+		for _, p := range problems {
+			dead = append(dead, source+": "+p)
+		}
+	}
+	sort.Strings(orphans)
+	sort.Strings(dead)
+	return orphans, root, dead
+}
+
+// printSiteReport writes the site report to w.
+func printSiteReport(w io.Writer) {
+	orphans, root, dead := collectSiteReport()
+	fmt.Fprintln(w, "Site report:")
+	switch {
+	case root == "":
+		fmt.Fprintln(w, "  There is no index page, so there are no orphaned pages.")
+	case len(orphans) == 0:
+		fmt.Fprintf(w, "  All pages are reachable from %s.\n", root)
+	default:
+		fmt.Fprintf(w, "  Pages that are not reachable from %s:\n", root)
+		for _, o := range orphans {
+			fmt.Fprintf(w, "    %s\n", o)
+		}
+	}
+	if len(dead) == 0 {
+		fmt.Fprintln(w, "  No dead references.")
+		return
+	}
+	fmt.Fprintln(w, "  Dead references:")
+	for _, d := range dead {
+		fmt.Fprintf(w, "    %s\n", d)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLinkTarget(t *testing.T) {
+	tests := []struct {
+		href, outname string
+		want          string
+		wantOK        bool
+	}{
+		{"b.html", "a.html", "b.html", true},
+		{"../b.html#x", "sub/a.html", "b.html", true},
+		{"sub/", "a.html", "sub/index.html", true},
+		{"#x", "a.html", "", false},
+		{"https://example.com/b.html", "a.html", "", false},
+		{"/b.html", "a.html", "", false},
+		{"img/flow.png", "a.html", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := linkTarget(tt.href, tt.outname)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("linkTarget(%q, %q) = %q, %v; want %q, %v", tt.href, tt.outname, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSiteReport(t *testing.T) {
+	defer func(l, d map[string][]string, w bool) { pageLinks, deadRefs, indexWritten = l, d, w }(pageLinks, deadRefs, indexWritten)
+	defer func(o string) { *outdir = o }(*outdir)
+	dir, err := ioutil.TempDir("", "goweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	*outdir = dir
+	pageLinks = map[string][]string{}
+	deadRefs = map[string][]string{}
+	indexWritten = false
+	recordLinks(`<a href="a.html">A</a> <a href="sub/">Sub</a>`, "index.go", "index.html")
+	recordLinks(`<a href="#top">Top</a> <a href="gone.html">Gone</a>`, "a.go", "a.html")
+	recordLinks(`<a href="../a.html">A</a>`, "sub/index.go", "sub/index.html")
+	recordLinks(`<a href="a.html">A</a><img src="missing.png" alt="">`, "orphan.go", "orphan.html")
+
+	orphans, root, dead := collectSiteReport()
+	if root != "index.html" || !reflect.DeepEqual(orphans, []string{"orphan.html"}) {
+		t.Errorf("collectSiteReport() = orphans %v from %q, want [orphan.html] from index.html", orphans, root)
+	}
+	want := []string{"a.html: link to gone.html, which does not exist", "orphan.go: image missing.png does not exist"}
+	if !reflect.DeepEqual(dead, want) {
+		t.Errorf("collectSiteReport() = dead references %q, want %q", dead, want)
+	}
+
+	var b bytes.Buffer
+	delete(pageLinks, "index.html")
+	printSiteReport(&b)
+	if !strings.Contains(b.String(), "There is no index page") {
+		t.Errorf("printSiteReport() without an index page = %q", b.String())
+	}
+}
@@ -0,0 +1,218 @@
+// ## Splitting long files
+//
+// A document of five thousand lines is hard to read and slow to load. With
+// -split=1, goweave splits the document of a file into pages at the `#`
+// headings of its comments, and with -split=2 at the `##` headings, too.
+// The first page keeps the name of the document, so links to it keep
+// working, and the other pages get the name of the document plus the ID of
+// their first heading, like `server-routing.html`. Each page links to the
+// pages before and after it, and all pages share a table of contents of
+// the whole document.
+//
+// goweave can split only between sections, so a page starts with the
+// section whose comment has the heading. References to headings,
+// declarations, and sections of a split document link to the right page.
+// Captions are numbered per page.
+package main
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// splitLink links to another page of a split document.
+type splitLink struct {
+	Title string
+	Href  urlPath
+}
+
+// splitPoint is where a page of a split document starts.
+type splitPoint struct {
+	index int    // the first section of the page
+	name  string // the output name of the page
+}
+
+// checkSplit validates the -split option.
+func checkSplit() error {
+	if *split < 0 || *split > 6 {
+		return fmt.Errorf("-split must be a heading level from 1 to 6, or 0, not %d", *split)
+	}
+	if *split > 0 && *hugo {
+		return fmt.Errorf("-split does not work with -hugo, which writes one page bundle per file")
+	}
+	return nil
+}
+
+// splitPoints returns the pages of a document with the output name
+// outname, or nil if -split is off or finds only one page.
+func splitPoints(sections []*section, outname string) []splitPoint {
+	if *split == 0 {
+		return nil
+	}
+	ext := path.Ext(outname)
+	base := strings.TrimSuffix(outname, ext)
+	points := []splitPoint{{0, outname}}
+	seen := map[string]bool{outname: true}
+	for i, s := range sections {
+		headings := weave.Headings([]*section{s}, *split)
+		if i == 0 || len(headings) == 0 {
+			continue
+		}
+		id := headings[0].ID
+		if id == "" {
+			id = strconv.Itoa(len(points) + 1)
+		}
+		name := base + "-" + id + ext
+		for n := 2; seen[name]; n++ {
+			name = base + "-" + id + "-" + strconv.Itoa(n) + ext
+		}
+		seen[name] = true
+		points = append(points, splitPoint{i, name})
+	}
+	if len(points) == 1 {
+		return nil
+	}
+	return points
+}
+
+// splitParts returns the sections of each page.
+func splitParts(sections []*section, points []splitPoint) [][]*section {
+	parts := make([][]*section, len(points))
+	for i, p := range points {
+		end := len(sections)
+		if i+1 < len(points) {
+			end = points[i+1].index
+		}
+		parts[i] = sections[p.index:end]
+	}
+	return parts
+}
+
+// splitFragments returns the page of each section ID and heading ID of a
+// split document.
+func splitFragments(sections []*section, points []splitPoint) map[string]int {
+	pageOf := map[string]int{}
+	for i, part := range splitParts(sections, points) {
+		for _, s := range part {
+			pageOf[s.ID] = i
+		}
+		for _, h := range weave.Headings(part, 6) {
+			pageOf[h.ID] = i
+		}
+	}
+	return pageOf
+}
+
+// writeSplit writes the document of a file, as pages if points splits it.
+// The names of the points are those of the default language; the pages of
+// a translation get the names of its variants.
+func writeSplit(d docs, sections []*section, points []splitPoint, filename, outname string, translations []translation) error {
+	if len(points) == 0 {
+		return writeDocument(d, sections, filename, outname)
+	}
+	parts := splitParts(sections, points)
+	names := make([]string, len(points))
+	links := make([]splitLink, len(points))
+	for i, p := range points {
+		names[i] = p.name
+		if d.Lang != *lang {
+			names[i] = variantName(p.name, d.Lang)
+		}
+		links[i] = splitLink{d.Title, urlPath(path.Base(names[i]))}
+		if i == 0 {
+			continue
+		}
+		// A translation may lack the heading.
+		links[i].Title = path.Base(names[i])
+		if h := weave.Headings(parts[i][:1], *split); len(h) > 0 {
+			links[i].Title = h[0].Text
+		}
+	}
+	tocs := make([][]tocEntry, len(parts))
+	for i, part := range parts {
+		tocs[i] = tableOfContents(part)
+	}
+	for i, part := range parts {
+		pd := d
+		pd.outname = names[i]
+		pd.Prev, pd.Next = nil, nil
+		if i > 0 {
+			pd.Title, pd.ShowTitle = links[i].Title+" – "+d.Title, false
+			pd.Canonical = canonicalURL(frontMatter{}, names[i])
+			pd.Prev = &links[i-1]
+		}
+		if i+1 < len(parts) {
+			pd.Next = &links[i+1]
+		}
+		if len(translations) > 0 {
+			pd.Languages = languageLinks(points[i].name, d.Lang, translations)
+		}
+		pd.TOC, pd.TOCStyle = nil, *tocStyle
+		for j, entries := range tocs {
+			for _, e := range entries {
+				if j != i {
+					e.Href = links[j].Href + e.Href
+				}
+				pd.TOC = append(pd.TOC, e)
+			}
+		}
+		if err := writeDocument(pd, part, filename, names[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markdownPageLinks returns the links to the pages before and after a page
+// of a split Markdown document.
+func markdownPageLinks(prev, next *splitLink) string {
+	var links []string
+	if prev != nil {
+		links = append(links, "[← "+prev.Title+"]("+string(prev.Href)+")")
+	}
+	if next != nil {
+		links = append(links, "["+next.Title+" →]("+string(next.Href)+")")
+	}
+	if len(links) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(links, " · ") + "\n"
+}
+
+// splitHref returns the link from the page outname to the page of a split
+// document that has a fragment, or "" if the document is not split or the
+// fragment is on that page. same tells whether outname is a page of the
+// split document.
+func splitHref(page *refPage, fragment, outname string, same bool) string {
+	i, ok := page.pageOf[fragment]
+	if !ok {
+		return ""
+	}
+	name := page.pages[i]
+	if !same {
+		return string(rootPath(outname)) + name
+	}
+	if lang := variantLang(page.pages, outname); lang != "" {
+		name = variantName(name, lang)
+	}
+	if name == outname {
+		return ""
+	}
+	return path.Base(name)
+}
+
+// variantLang returns the language of a page of a translation of a split
+// document, or "" for a page in the default language.
+func variantLang(pages []string, outname string) string {
+	for _, p := range pages {
+		if p == outname {
+			return ""
+		}
+	}
+	rest := strings.TrimSuffix(outname, path.Ext(outname))
+	return rest[strings.LastIndex(rest, ".")+1:]
+}
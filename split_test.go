@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+const splitSource = `// # Big
+//
+// Intro.
+package main
+
+// # Setup
+func setup() {}
+
+// ## Details
+func detail() {}
+
+// # Setup
+func again() {}
+`
+
+func TestSplitPoints(t *testing.T) {
+	defer func(n int) { *split = n }(*split)
+	tests := []struct {
+		level int
+		want  []splitPoint
+	}{
+		{0, nil},
+		{1, []splitPoint{{0, "big.html"}, {1, "big-setup.html"}, {3, "big-setup-2.html"}}},
+		{2, []splitPoint{{0, "big.html"}, {1, "big-setup.html"}, {2, "big-details.html"}, {3, "big-setup-2.html"}}},
+	}
+	for _, tt := range tests {
+		*split = tt.level
+		if got := splitPoints(extractSections("big.go", splitSource), "big.html"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPoints() with -split=%d = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestSplitHref(t *testing.T) {
+	defer func(n int) { *split = n }(*split)
+	*split = 1
+	sections := extractSections("big.go", splitSource)
+	weave.AssignIDs(sections)
+	points := splitPoints(sections, "big.html")
+	page := &refPage{pages: []string{"big.html", "big-setup.html", "big-setup-2.html"}, pageOf: splitFragments(sections, points)}
+	detail := sections[2].ID
+	tests := []struct {
+		fragment, outname string
+		same              bool
+		want              string
+	}{
+		{"details", "big.html", true, "big-setup.html"},
+		{"details", "big-setup.html", true, ""},
+		{detail, "big.de.html", true, "big-setup.de.html"},
+		{"details", "docs/other.html", false, "../big-setup.html"},
+		{"nowhere", "big.html", true, ""},
+	}
+	for _, tt := range tests {
+		if got := splitHref(page, tt.fragment, tt.outname, tt.same); got != tt.want {
+			t.Errorf("splitHref(%s, %s) = %q, want %q", tt.fragment, tt.outname, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// ## Links to the source
+//
+// A reader who wants to see a piece of code in its context, with its
+// history and blame, looks it up in the hosting repository. With
+// -srclink-base, each code block of an HTML document gets a small link
+// that goes right to its lines there:
+//
+//	goweave -srclink-base='https://github.com/me/repo/blob/main/{file}#L{line}-L{end}' ./...
+//
+// In the pattern, `{file}` is the path of the source file within its git
+// repository, or within the current directory if the file is in no
+// repository, and `{line}` and `{end}` are the first and the last line of
+// the code, leaving out blank lines around it.
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	repoRoots   = map[string]string{} // by directory
+	repoRootsMu sync.Mutex
+)
+
+// checkSrcLink returns an error if -srclink-base is no usable pattern.
+func checkSrcLink() error {
+	if *srcLinkBase == "" {
+		return nil
+	}
+	if *md {
+		return fmt.Errorf("-srclink-base works with HTML output only")
+	}
+	if !strings.Contains(*srcLinkBase, "{line}") {
+		return fmt.Errorf("-srclink-base needs {line} in the pattern, as in https://github.com/me/repo/blob/main/{file}#L{line}")
+	}
+	return nil
+}
+
+// repoRoot returns the directory of the git repository that contains a
+// directory, or the empty string if there is none.
+func repoRoot(dir string) string {
+	repoRootsMu.Lock()
+	root, ok := repoRoots[dir]
+	repoRootsMu.Unlock()
+	if ok {
+		return root
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = repoRoot(parent)
+	}
+	repoRootsMu.Lock()
+	repoRoots[dir] = root
+	repoRootsMu.Unlock()
+	return root
+}
+
+// repoPath returns the slash-separated path of a source file within its
+// git repository, or within the current directory.
+func repoPath(filename string) string {
+	if revTree != nil {
+		if p, err := treePath(filename); err == nil {
+			return p
+		}
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	base := repoRoot(filepath.Dir(abs))
+	if base == "" {
+		base, _ = os.Getwd()
+	}
+	if rel, err := filepath.Rel(base, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(filename)
+}
+
+// srcLinks returns the links of -srclink-base to the code of the sections,
+// by section ID.
+func srcLinks(filename string, sections []*section) map[string]string {
+	segments := strings.Split(repoPath(filename), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	file := strings.Join(segments, "/")
+	links := map[string]string{}
+	for _, s := range sections {
+		first, last, ok := codeLines(s)
+		if !ok {
+			continue
+		}
+		links[s.ID] = strings.NewReplacer("{file}", file, "{line}", strconv.Itoa(first), "{end}", strconv.Itoa(last)).Replace(*srcLinkBase)
+	}
+	return links
+}
+
+// codeLines returns the first and the last line of the code of a section
+// in the source, without the blank lines around it. ok is false if the
+// section has no code.
+func codeLines(s *section) (first, last int, ok bool) {
+	if s.CodeStart == 0 {
+		return 0, 0, false
+	}
+	lines := strings.SplitAfter(s.Code, "\n")
+	if n := s.CodeEnd - s.CodeStart + 1; n < len(lines) {
+		lines = lines[:n]
+	}
+	from, to := 0, len(lines)-1
+	for from <= to && strings.TrimSpace(lines[from]) == "" {
+		from++
+	}
+	for to >= from && strings.TrimSpace(lines[to]) == "" {
+		to--
+	}
+	if from > to {
+		return 0, 0, false
+	}
+	return s.CodeStart + from, s.CodeStart + to, true
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestSrcLinks(t *testing.T) {
+	defer func(b string) { *srcLinkBase = b }(*srcLinkBase)
+	*srcLinkBase = "https://example.com/{file}#L{line}-L{end}"
+	src := "// Doc\npackage p\n\n// Add adds.\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\n// The end.\n"
+	sections := extractSections("srclink_test.go", src)
+	for i, s := range sections {
+		s.ID = string(rune('a' + i))
+	}
+	links := srcLinks("srclink_test.go", sections)
+	want := map[string]string{
+		"a": "https://example.com/srclink_test.go#L2-L2",
+		"b": "https://example.com/srclink_test.go#L6-L8",
+	}
+	if len(links) != len(want) {
+		t.Errorf("srcLinks() = %v, want %v", links, want)
+	}
+	for id, w := range want {
+		if links[id] != w {
+			t.Errorf("the link of section %s is %q, want %q", id, links[id], w)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// ## Standalone documents
+//
+// A document that goes into an email or gets attached to a ticket has to
+// get along without the files next to it. -standalone writes HTML documents
+// that carry everything they need: the CSS and the fonts inline, as with
+// -inline, the script of the buttons, and the local images that comments
+// refer to, as data URIs, scaled down to -max-image-width like those of
+// -images. Images that goweave cannot read make the build fail, rather than
+// leave a broken image in a document that is meant to travel alone; remote
+// images stay as they are.
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// checkStandalone checks -standalone against the other options, and turns
+// on -inline for it.
+func checkStandalone() error {
+	if !*standalone {
+		return nil
+	}
+	if *md {
+		return errors.New("-standalone works only with -format=html")
+	}
+	if *copySource {
+		return errors.New("-standalone and -copysrc exclude each other; use -rawview for the source")
+	}
+	*inline = true
+	return nil
+}
+
+// embedImages replaces the sources of the `<img>` elements that refer to
+// local images with data URIs, and adds the size of the images.
+func embedImages(sections []*section, source string) error {
+	var err error
+	for _, s := range sections {
+		s.Doc = imgTag.ReplaceAllStringFunc(s.Doc, func(tag string) string {
+			src := html.UnescapeString(imgTag.FindStringSubmatch(tag)[1])
+			filename, ok := localImage(src, source)
+			if !ok || err != nil {
+				return tag
+			}
+			var uri string
+			var info imageInfo
+			uri, info, err = imageDataURI(filename)
+			if err != nil {
+				return tag
+			}
+			tag = `<img src="` + html.EscapeString(uri) + `"`
+			if info.width > 0 {
+				tag += fmt.Sprintf(` width="%d" height="%d"`, info.width, info.height)
+			}
+			return tag
+		})
+	}
+	return err
+}
+
+// imageDataURI returns the data URI of an image file, scaled down if
+// necessary, and the size of the image.
+func imageDataURI(filename string) (string, imageInfo, error) {
+	data, info, err := shrinkImage(filename)
+	if err != nil {
+		return "", imageInfo{}, err
+	}
+	if data == nil {
+		data, err = ioutil.ReadFile(filename)
+		if err != nil {
+			return "", imageInfo{}, err
+		}
+	}
+	typ := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))
+	if typ == "" {
+		typ = http.DetectContentType(data)
+	}
+	return "data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(data), info, nil
+}
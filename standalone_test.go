@@ -0,0 +1,51 @@
+package main
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbedImages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "standalone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f, err := os.Create(filepath.Join(dir, "wide.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 40, 20)))
+	f.Close()
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.svg"), []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(w int) { *maxImageWidth = w }(*maxImageWidth)
+	*maxImageWidth = 10
+	sections := []*section{{Doc: `<p><img src="wide.png" alt="Wide" /> <img src="a.svg" alt="" /> <img src="http://example.com/x.png" alt="" /></p>`}}
+	if err := embedImages(sections, filepath.Join(dir, "doc.go")); err != nil {
+		t.Fatal(err)
+	}
+	doc := sections[0].Doc
+	for _, want := range []string{
+		`<img src="data:image/png;base64,`,
+		`width="10" height="5" alt="Wide" />`,
+		`<img src="data:image/svg+xml;base64,`,
+		`<img src="http://example.com/x.png" alt="" />`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("embedImages() = %s, want %s", doc, want)
+		}
+	}
+
+	sections = []*section{{Doc: `<img src="missing.png" alt="" />`}}
+	if err := embedImages(sections, filepath.Join(dir, "doc.go")); err == nil {
+		t.Error("embedImages() with a missing image succeeded")
+	}
+}
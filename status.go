@@ -0,0 +1,185 @@
+// ## Review status
+//
+// Literate documents can serve as design documents under review. A status
+// pragma before a section marks how far the review of the section got:
+//
+//	//goweave:status reviewed
+//
+// The status is `reviewed`, `draft`, or `obsolete`, and shows as a colored
+// badge next to the section's prose. With -status-report, goweave also
+// writes status-report.html into the output directory, which counts the
+// sections of each status and lists them by document, linked to the
+// sections.
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+const statusReportName = "status-report.html"
+
+// statusEntry is a section with a review status, as listed in the report.
+type statusEntry struct {
+	Status string
+	Title  string
+	Href   urlPath
+}
+
+// statusPage is a document with sections that have a review status.
+type statusPage struct {
+	Title    string
+	Href     urlPath
+	Source   string
+	Sections []statusEntry
+}
+
+// The sections with a status of all files processed so far, by source path.
+var (
+	statusMu    sync.Mutex
+	statusPages = map[string]statusPage{}
+)
+
+// addStatuses collects the sections of a source file that have a review
+// status, and warns about the statuses that goweave does not know. It must
+// be called before the sections are rendered.
+func addStatuses(filename, outname, title string, sections []*section) {
+	weave.AssignIDs(sections)
+	href := fsPath(outname).toURL()
+	p := statusPage{Title: title, Href: href, Source: fsPath(filename).toURL().String()}
+	for _, s := range weave.InSourceOrder(sections) {
+		if s.Status == "" {
+			continue
+		}
+		if !weave.KnownStatus(s.Status) {
+			log.Printf("%s: unknown review status %q (want %s)", filename, s.Status, strings.Join(weave.Statuses, ", "))
+		}
+		p.Sections = append(p.Sections, statusEntry{s.Status, statusTitle(s), href + "#" + urlPath(s.ID)})
+	}
+	statusMu.Lock()
+	if len(p.Sections) > 0 {
+		statusPages[filename] = p
+	} else {
+		delete(statusPages, filename)
+	}
+	statusMu.Unlock()
+}
+
+// statusTitle returns the first heading of a section's comment, or the
+// start of its first paragraph.
+func statusTitle(s *section) string {
+	one := []*section{s}
+	if h := docHeadings(one); len(h) > 0 {
+		return h[0]
+	}
+	t := docSummary(one)
+	if utf8.RuneCountInString(t) > 80 {
+		t = string([]rune(t)[:79]) + "…"
+	}
+	if t == "" {
+		t = strings.TrimSpace(strings.SplitN(s.Code, "\n", 2)[0])
+	}
+	return t
+}
+
+// statusCount is the number of sections with a status.
+type statusCount struct {
+	Status string
+	Count  int
+}
+
+// statusReport returns the documents with sections that have a review
+// status, sorted by source path, and the number of sections per status,
+// the known statuses first.
+func statusReport() ([]statusPage, []statusCount) {
+	statusMu.Lock()
+	report := make([]statusPage, 0, len(statusPages))
+	for _, p := range statusPages {
+		report = append(report, p)
+	}
+	statusMu.Unlock()
+	sort.Slice(report, func(i, j int) bool { return report[i].Source < report[j].Source })
+	n := map[string]int{}
+	var other []string
+	for _, p := range report {
+		for _, s := range p.Sections {
+			if n[s.Status] == 0 && !weave.KnownStatus(s.Status) {
+				other = append(other, s.Status)
+			}
+			n[s.Status]++
+		}
+	}
+	sort.Strings(other)
+	var counts []statusCount
+	for _, s := range append(append([]string(nil), weave.Statuses...), other...) {
+		counts = append(counts, statusCount{s, n[s]})
+	}
+	return report, counts
+}
+
+var statusTempl = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html{{with .ColorScheme}} data-color-scheme="{{.}}"{{end}}>
+<head>
+<title>Review status</title>
+<meta charset="utf-8"/>
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+{{if .InlineCSS}}
+<style type="text/css">{{.Style}}</style>
+{{else}}
+<link rel="stylesheet" href="{{.CssPath}}">
+{{end}}
+</head>
+<body>
+<div id="goweave">
+	<header class="title"><h1>Review status</h1></header>
+	<nav class="index">
+		<p>{{range $i, $c := .Counts}}{{if $i}}, {{end}}<span class="status status-{{$c.Status}}">{{$c.Status}}</span> {{$c.Count}}{{end}}</p>
+		{{range .Pages}}
+		<h2><a href="{{.Href}}">{{.Title}}</a></h2>
+		<ul>
+			{{range .Sections}}<li><span class="status status-{{.Status}}">{{.Status}}</span> <a href="{{.Href}}">{{.Title}}</a></li>
+			{{end}}
+		</ul>
+		{{else}}
+		<p>No section has a review status.</p>
+		{{end}}
+	</nav>
+</div>
+</body>
+</html>
+`))
+
+// saveStatusReport writes the report of the review statuses, unless one of
+// the documents has its name already.
+func saveStatusReport(name string) error {
+	pagesMu.Lock()
+	for _, p := range pages {
+		if p.Output == urlPath(name) {
+			pagesMu.Unlock()
+			log.Printf("Not writing the status report: %s is the document of %s.", name, p.Source)
+			return nil
+		}
+	}
+	pagesMu.Unlock()
+	report, counts := statusReport()
+	var b bytes.Buffer
+	err := statusTempl.Execute(&b, struct {
+		CssPath     urlPath
+		Style       template.CSS
+		InlineCSS   bool
+		ColorScheme string
+		Pages       []statusPage
+		Counts      []statusCount
+	}{cssHref(), style, *inline, fixedColorScheme(), report, counts})
+	if err != nil {
+		return err
+	}
+	return outputFS().WriteFile(name, b.Bytes())
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStatusReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(p map[string]statusPage) { statusPages = p }(statusPages)
+	statusPages = map[string]statusPage{}
+	defer func(o string) { *outdir = o }(*outdir)
+	*outdir = dir
+
+	addStatuses("b.go", "b.html", "B", extractSections("b.go", "// # Parser\n//goweave:status draft\npackage b\n// The lexer\n//goweave:status reviewed\nfunc lex() {}\n"))
+	addStatuses("a.go", "a.html", "A", extractSections("a.go", "// Intro\n//goweave:status obsolete\npackage a\n// More\n//goweave:status wip\nfunc f() {}\n"))
+	addStatuses("c.go", "c.html", "C", extractSections("c.go", "// Intro\npackage c\n"))
+
+	report, counts := statusReport()
+	var titles []string
+	for _, p := range report {
+		for _, s := range p.Sections {
+			titles = append(titles, p.Title+": "+s.Status+" "+s.Title)
+		}
+	}
+	want := []string{"A: obsolete Intro", "A: wip More", "B: draft Parser", "B: reviewed The lexer"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("statusReport() = %q, want %q", titles, want)
+	}
+	wantCounts := []statusCount{{"reviewed", 1}, {"draft", 1}, {"obsolete", 1}, {"wip", 1}}
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("statusReport() counts = %v, want %v", counts, wantCounts)
+	}
+
+	if err := saveStatusReport(statusReportName); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, statusReportName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<span class="status status-draft">draft</span> <a href="b.html#`) {
+		t.Errorf("status report does not link to the draft section:\n%s", data)
+	}
+}
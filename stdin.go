@@ -0,0 +1,83 @@
+// ## Standard input and output
+//
+// `goweave -` reads a source file from standard input and writes the
+// document to standard output, for pipelines, editor plugins, and
+// pre-commit hooks that have no file to point goweave to. Nothing goes to
+// the output directory: no CSS file, no index page, no build cache. The
+// document links to the CSS file as usual, unless -inline puts the styles
+// into it. The source has no file name, so it counts as Go and the title
+// is "stdin"; -stdin-name gives it a name, which picks the language by its
+// extension and serves as the default title.
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// isStdin reports whether the arguments ask goweave to read from standard
+// input.
+func isStdin(args []string) bool {
+	return len(args) == 1 && args[0] == "-"
+}
+
+// stdinCommand weaves standard input into standard output.
+func stdinCommand() error {
+	if *watch || serve != "" {
+		return fmt.Errorf("-watch and -serve need input files, not standard input")
+	}
+	resourcedir = findResources()
+	if err := loadResources(resourcedir); err != nil {
+		return err
+	}
+	if err := loadFonts(); err != nil {
+		return err
+	}
+	if err := loadHighlighter(); err != nil {
+		return err
+	}
+	return weaveStdin(os.Stdin, os.Stdout)
+}
+
+// weaveStdin reads a source file from r and writes its document to w.
+func weaveStdin(r io.Reader, w io.Writer) error {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if isBinary(src) {
+		return fmt.Errorf("standard input appears to be binary")
+	}
+	filename := *stdinName
+	if filename == "" {
+		filename = "stdin"
+	}
+	name := filepath.Base(filename)
+	fm := parseFrontMatter(filename, string(src))
+	d := docs{Filename: name, Title: name, Lang: *lang, Pragmas: fm}
+	if t := fm["title"]; t != "" {
+		d.Title, d.ShowTitle = t, true
+	}
+	if *title != "" {
+		d.Title, d.ShowTitle = *title, true
+	}
+	if *rawView {
+		d.RawSource = template.HTML(weaverFor(filename).Highlight(string(src)))
+	}
+	doc, err := renderDocs(d, extractSections(filename, string(src)))
+	if err != nil {
+		return err
+	}
+	if *postprocess != "" {
+		doc, err = postProcess(doc, filename, "-")
+		if err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, doc)
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestWeaveStdin(t *testing.T) {
+	templ = template.Must(template.ParseFS(weave.Resources(), tplfilename))
+	defer func(m bool, name string) { *md, *stdinName = m, name }(*md, *stdinName)
+	tests := []struct {
+		md        bool
+		name, src string
+		want      []string
+	}{
+		{false, "", "// Doc\npackage main\n", []string{"<title>stdin</title>", "<p>Doc</p>"}},
+		{false, "", "//goweave:title Piped\n// Doc\npackage main\n", []string{"<title>Piped</title>"}},
+		{true, "tool.py", "# Doc\nx = 1\n", []string{"Doc\n", "```python\nx = 1\n"}},
+	}
+	for _, tt := range tests {
+		*md, *stdinName = tt.md, tt.name
+		var b bytes.Buffer
+		if err := weaveStdin(strings.NewReader(tt.src), &b); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("weaveStdin(%q) with -md=%v = %q, want %q in it", tt.src, tt.md, b.String(), want)
+			}
+		}
+	}
+	if err := weaveStdin(strings.NewReader("\x00\x01\x02"), &bytes.Buffer{}); err == nil {
+		t.Errorf("weaveStdin() accepted binary input")
+	}
+}
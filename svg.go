@@ -0,0 +1,317 @@
+// ## Inline SVG
+//
+// Diagrams in SVG format can become part of the document itself, instead of
+// being referenced through an `<img>` element. This way, they can use the
+// fonts and colors of the page, and the browser needs no extra request to
+// get them. -inline-svg sets the size limit; SVG files up to this size get
+// inlined, larger ones stay separate files.
+//
+// An SVG file can contain scripts, so goweave sanitizes it on the way: it
+// drops script and foreignObject elements, event handler attributes, and
+// links and url() values other than to fragments within the document. The
+// style sheets of an SVG would apply to the whole page, so their rules get
+// restricted to the SVG, through the ID of its root element, and lose
+// their @import rules.
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// imgElement matches a complete `<img>` element as blackfriday writes it.
+var imgElement = regexp.MustCompile(`<img src="([^"]*)"[^>]*>`)
+
+var imgAlt = regexp.MustCompile(`\balt="([^"]*)"`)
+
+// localImage returns the path of an image that a comment refers to, or
+// false if the image is not a local file.
+func localImage(src, source string) (string, bool) {
+	if src == "" || path.IsAbs(src) || strings.Contains(src, ":") {
+		return "", false
+	}
+	return filepath.Join(filepath.Dir(source), filepath.FromSlash(src)), true
+}
+
+// inlineSVGs replaces the `<img>` elements that refer to small SVG files
+// with the sanitized contents of these files.
+func inlineSVGs(sections []*section, source string) error {
+	var err error
+	for _, s := range sections {
+		s.Doc = imgElement.ReplaceAllStringFunc(s.Doc, func(tag string) string {
+			src := html.UnescapeString(imgElement.FindStringSubmatch(tag)[1])
+			filename, ok := localImage(src, source)
+			if !ok || err != nil || strings.ToLower(path.Ext(src)) != ".svg" {
+				return tag
+			}
+			fi, serr := os.Stat(filename)
+			if serr != nil || fi.Size() > *inlineSVG {
+				return tag // missing files are left for -images to report
+			}
+			data, rerr := ioutil.ReadFile(filename)
+			if rerr != nil {
+				err = rerr
+				return tag
+			}
+			alt := ""
+			if m := imgAlt.FindStringSubmatch(tag); m != nil {
+				alt = html.UnescapeString(m[1])
+			}
+			svg, serr := sanitizeSVG(data, alt)
+			if serr != nil {
+				err = fmt.Errorf("%s: %v", filename, serr)
+				return tag
+			}
+			return svg
+		})
+	}
+	return err
+}
+
+// unsafeSVGElements can run scripts or embed arbitrary HTML.
+var unsafeSVGElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+}
+
+// styleElement matches the start of a style element.
+var styleElement = regexp.MustCompile(`(?i)<style\b`)
+
+// sanitizeSVG returns the SVG markup without anything that can run scripts
+// or load other resources, and without the XML declaration and doctype.
+// The root element gets the alternative text of the image as its label,
+// and if the SVG has a style sheet but its root no ID, an ID derived from
+// the SVG, for scoping the style sheet.
+func sanitizeSVG(data []byte, alt string) (string, error) {
+	var b bytes.Buffer
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	skip := 0 // depth within an unsafe element
+	root := true
+	rootID := ""
+	var css *strings.Builder // the style sheet of the style element, if in one
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 || unsafeSVGElements[strings.ToLower(t.Name.Local)] || animatesLink(t) {
+				skip++
+				continue
+			}
+			b.WriteString("<" + qualifiedName(t.Name))
+			for _, a := range t.Attr {
+				if safeSVGAttr(a) {
+					b.WriteString(" " + qualifiedName(a.Name) + `="`)
+					xml.EscapeText(&b, []byte(localURLs(a.Value)))
+					b.WriteString(`"`)
+					if root && a.Name.Space == "" && a.Name.Local == "id" {
+						rootID = a.Value
+					}
+				}
+			}
+			if root {
+				if t.Name.Local != "svg" {
+					return "", fmt.Errorf("the root element is %s, not svg", t.Name.Local)
+				}
+				if rootID == "" && styleElement.Match(data) {
+					rootID = fmt.Sprintf("svg-%x", sha1.Sum(data))[:12]
+					b.WriteString(` id="` + rootID + `"`)
+				}
+				b.WriteString(` role="img" aria-label="`)
+				xml.EscapeText(&b, []byte(alt))
+				b.WriteString(`"`)
+				root = false
+			}
+			b.WriteString(">")
+			if strings.ToLower(t.Name.Local) == "style" {
+				css = &strings.Builder{}
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if css != nil {
+				xml.EscapeText(&b, []byte(scopeCSS(css.String(), rootID)))
+				css = nil
+			}
+			b.WriteString("</" + qualifiedName(t.Name) + ">")
+		case xml.CharData:
+			switch {
+			case skip > 0 || root:
+			case css != nil:
+				css.Write(t)
+			default:
+				xml.EscapeText(&b, t)
+			}
+		}
+		// Comments, processing instructions, and directives are dropped.
+	}
+	if root {
+		return "", fmt.Errorf("no svg element found")
+	}
+	return b.String(), nil
+}
+
+// animatesLink returns true for animation elements that change a link,
+// which could turn a harmless link into a javascript: URL.
+func animatesLink(e xml.StartElement) bool {
+	switch strings.ToLower(e.Name.Local) {
+	case "set", "animate", "animatemotion", "animatetransform":
+	default:
+		return false
+	}
+	for _, a := range e.Attr {
+		if a.Name.Local == "attributeName" && strings.HasSuffix(strings.ToLower(a.Value), "href") {
+			return true
+		}
+	}
+	return false
+}
+
+// safeSVGAttr returns false for event handlers and for links to anything
+// but fragments.
+func safeSVGAttr(a xml.Attr) bool {
+	name := strings.ToLower(a.Name.Local)
+	if strings.HasPrefix(name, "on") {
+		return false
+	}
+	if name == "href" {
+		return strings.HasPrefix(strings.TrimSpace(a.Value), "#")
+	}
+	return true
+}
+
+// cssImport matches the @import rules of a style sheet.
+var cssImport = regexp.MustCompile(`(?i)@import[^;]*;?`)
+
+// cssURL matches the url() values of a style sheet or an attribute, with
+// the URL in the second group.
+var cssURL = regexp.MustCompile(`(?i)url\(\s*(['"]?)([^'")]*)(['"]?)\s*\)`)
+
+// localURLs replaces the url() values that point to other resources than
+// fragments with none.
+func localURLs(s string) string {
+	return cssURL.ReplaceAllStringFunc(s, func(u string) string {
+		if strings.HasPrefix(strings.TrimSpace(cssURL.FindStringSubmatch(u)[2]), "#") {
+			return u
+		}
+		return "none"
+	})
+}
+
+// scopeCSS returns the style sheet of an SVG, whose root element has the
+// ID scope, with its selectors restricted to the SVG, and without imports
+// and links to other resources.
+func scopeCSS(css, scope string) string {
+	css = localURLs(cssImport.ReplaceAllString(css, ""))
+	var b strings.Builder
+	for css != "" {
+		css = scopeRules(&b, css, "#"+scope)
+	}
+	return b.String()
+}
+
+// scopeRules writes the rules of a style sheet with scoped selectors, up to
+// the brace that closes the block they are in, and returns the rest. The
+// rules within @media and @supports get scoped, too; other at-rules, like
+// @keyframes and @font-face, have no selectors.
+func scopeRules(b *strings.Builder, css, scope string) string {
+	for {
+		i := strings.IndexAny(css, "{}")
+		if i < 0 {
+			return "" // no more rules
+		}
+		if css[i] == '}' {
+			return css[i+1:]
+		}
+		prelude, rest := css[:i], css[i+1:]
+		p := strings.ToLower(strings.TrimSpace(prelude))
+		switch {
+		case strings.HasPrefix(p, "@media") || strings.HasPrefix(p, "@supports"):
+			b.WriteString(prelude + "{")
+			css = scopeRules(b, rest, scope)
+			b.WriteString("}")
+			continue
+		case strings.HasPrefix(p, "@"):
+			b.WriteString(prelude + "{")
+		default:
+			b.WriteString(scopeSelectors(prelude, scope) + "{")
+		}
+		j := closingBrace(rest)
+		b.WriteString(rest[:j])
+		b.WriteString("}")
+		if j == len(rest) {
+			return ""
+		}
+		css = rest[j+1:]
+	}
+}
+
+// closingBrace returns the index of the brace that closes the block that
+// css starts in, or the length of css if it does not close.
+func closingBrace(css string) int {
+	depth := 0
+	for i, c := range css {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return len(css)
+}
+
+// scopeSelectors restricts a list of selectors to the descendants of the
+// element that scope selects. Selectors that start with scope stay as they
+// are.
+func scopeSelectors(selectors, scope string) string {
+	list := strings.Split(selectors, ",")
+	for i, s := range list {
+		s = strings.TrimSpace(s)
+		if rest := strings.TrimPrefix(s, scope); rest != s && (rest == "" || !isNameChar(rune(rest[0]))) {
+			list[i] = s
+			continue
+		}
+		list[i] = scope + " " + s
+	}
+	return strings.Join(list, ",")
+}
+
+// isNameChar reports whether a character can be part of a CSS name.
+func isNameChar(c rune) bool {
+	return c == '-' || c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// qualifiedName returns the name with its namespace prefix, as RawToken
+// leaves prefixes untranslated.
+func qualifiedName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
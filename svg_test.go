@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	tests := []struct {
+		svg     string
+		want    string
+		wantErr bool
+	}{
+		{`<?xml version="1.0"?><!DOCTYPE svg><!-- c --><svg xmlns="http://www.w3.org/2000/svg" width="10"><rect fill="currentColor"/></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg" width="10" role="img" aria-label="A &amp; B"><rect fill="currentColor"></rect></svg>`, false},
+		{`<svg onload="alert(1)"><script>alert(2)</script><a xlink:href="javascript:alert(3)"><text>x</text></a></svg>`,
+			`<svg role="img" aria-label="A &amp; B"><a><text>x</text></a></svg>`, false},
+		{`<svg><a href="#x"><set attributeName="href" to="javascript:alert(1)"/></a><foreignObject><div>html</div></foreignObject></svg>`,
+			`<svg role="img" aria-label="A &amp; B"><a href="#x"></a></svg>`, false},
+		{`<svg><a href="https://example.com"><image xlink:href="http://example.com/x.png" style="fill: url(https://example.com/f)"/></a><rect fill="url(#g)"/></svg>`,
+			`<svg role="img" aria-label="A &amp; B"><a><image style="fill: none"></image></a><rect fill="url(#g)"></rect></svg>`, false},
+		{`<svg id="d"><style>@import url(x.css); #d{color:red} .n, text > tspan{fill:url(http://x/y)} @media print{p{x:y}} @keyframes k{from{o:0}}</style></svg>`,
+			`<svg id="d" role="img" aria-label="A &amp; B"><style>#d{color:red}#d .n,#d text &gt; tspan{fill:none} @media print{#d p{x:y}} @keyframes k{from{o:0}}</style></svg>`, false},
+		{`<svg><style>.n{fill:red}</style></svg>`,
+			`<svg id="svg-30838d25" role="img" aria-label="A &amp; B"><style>#svg-30838d25 .n{fill:red}</style></svg>`, false},
+		{`<html><body/></html>`, "", true},
+		{`not xml`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := sanitizeSVG([]byte(tt.svg), "A & B")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("sanitizeSVG(%s) = %s, %v; want %s, error %v", tt.svg, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestInlineSVGs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "small.svg"), []byte(`<svg><circle r="1"/></svg>`), 0666)
+	ioutil.WriteFile(filepath.Join(dir, "large.svg"), []byte(`<svg><circle r="1"/>`+strings.Repeat(" ", 100)+`</svg>`), 0666)
+	defer func(n int64) { *inlineSVG = n }(*inlineSVG)
+	*inlineSVG = 50
+	sections := []*section{{Doc: `<p><img src="small.svg" alt="Small" /><img src="large.svg" alt="" /></p>`}}
+	if err := inlineSVGs(sections, filepath.Join(dir, "doc.go")); err != nil {
+		t.Fatal(err)
+	}
+	want := `<p><svg role="img" aria-label="Small"><circle r="1"></circle></svg><img src="large.svg" alt="" /></p>`
+	if sections[0].Doc != want {
+		t.Errorf("inlineSVGs() = %s, want %s", sections[0].Doc, want)
+	}
+}
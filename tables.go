@@ -0,0 +1,176 @@
+// ## Tables from the code
+//
+// -schema and -const-tables add tables that goweave derives from the Go
+// code to the document. Both need the code parsed, and both put their
+// tables into sections of their own, right beneath the section where the
+// declaration ends. The code of the sections, in the order of the source,
+// is the source without its comments, so goweave parses that rather than
+// the file, and maps the positions of the declarations back to the
+// sections.
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// tableIDPrefixes start the IDs of the sections that hold generated tables,
+// or the package summary.
+var tableIDPrefixes = []string{schemaIDPrefix, constIDPrefix, summaryID}
+
+// isTableSection returns true if a section ID belongs to a generated table,
+// which is no prose of the source.
+func isTableSection(id string) bool {
+	for _, p := range tableIDPrefixes {
+		if strings.HasPrefix(id, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionCode is the parsed code of the sections of a Go file.
+type sectionCode struct {
+	sections []*section // in the order of the source
+	starts   []int      // the offsets of the code of the sections
+	src      string
+	fset     *token.FileSet
+	file     *ast.File
+}
+
+// parseSections parses the code of the sections.
+func parseSections(sections []*section) (*sectionCode, error) {
+	c := &sectionCode{sections: append([]*section(nil), sections...), fset: token.NewFileSet()}
+	sort.SliceStable(c.sections, func(i, j int) bool { return c.sections[i].Position < c.sections[j].Position })
+	var b strings.Builder
+	c.starts = make([]int, len(c.sections))
+	for i, s := range c.sections {
+		c.starts[i] = b.Len()
+		b.WriteString(s.Code)
+	}
+	c.src = b.String()
+	var err error
+	c.file, err = parser.ParseFile(c.fset, "", c.src, parser.ParseComments)
+	return c, err
+}
+
+// sectionAt returns the index of the section that contains a position.
+func (c *sectionCode) sectionAt(pos token.Pos) int {
+	off := c.fset.Position(pos).Offset
+	return sort.Search(len(c.starts), func(i int) bool { return c.starts[i] > off }) - 1
+}
+
+// sectionOf returns the section that contains a position.
+func (c *sectionCode) sectionOf(pos token.Pos) *section {
+	return c.sections[c.sectionAt(pos)]
+}
+
+// docOf returns the first paragraph of the comment of a declaration: the
+// comment above it, either in the code or as the prose of the section that
+// the declaration starts, or else the comment at the end of its line.
+func (c *sectionCode) docOf(pos token.Pos, doc, comment *ast.CommentGroup) string {
+	if doc != nil {
+		return firstParagraph(doc.Text())
+	}
+	if comment != nil {
+		return firstParagraph(comment.Text())
+	}
+	i := c.sectionAt(pos)
+	if strings.TrimSpace(c.src[c.starts[i]:c.fset.Position(pos).Offset]) == "" {
+		return firstParagraph(c.sections[i].Doc)
+	}
+	return ""
+}
+
+// codeTable is a generated table and the section that it goes beneath.
+type codeTable struct {
+	after *section
+	table *section
+}
+
+// tableSections returns the sections with the tables of -schema and
+// -const-tables. The sections stay as they are if their code does not
+// parse.
+func tableSections(sections []*section) []*section {
+	c, err := parseSections(sections)
+	if err != nil {
+		return sections
+	}
+	var tables []codeTable
+	if *schema {
+		tables = append(tables, schemaTables(c)...)
+	}
+	if *constTables {
+		tables = append(tables, constBlockTables(c)...)
+	}
+	if len(tables) == 0 {
+		return sections
+	}
+	// The tables beneath the same section come in the order of their
+	// declarations.
+	sort.SliceStable(tables, func(i, j int) bool { return tables[i].table.Position < tables[j].table.Position })
+	after := map[*section][]*section{}
+	for _, t := range tables {
+		after[t.after] = append(after[t.after], t.table)
+	}
+	result := make([]*section, 0, len(sections)+len(tables))
+	for _, s := range sections {
+		result = append(result, s)
+		result = append(result, after[s]...)
+	}
+	return result
+}
+
+// tableSection returns a section that holds a generated table, beneath the
+// section of the code from pos to end.
+func (c *sectionCode) tableSection(id, table string, pos, end token.Pos) codeTable {
+	after := c.sectionOf(end)
+	return codeTable{after, &section{
+		Doc:      table,
+		Code:     "\n",
+		ID:       id,
+		Position: c.sectionOf(pos).Position,
+	}}
+}
+
+// markdownTable returns a Markdown table with a caption above it.
+func markdownTable(caption string, header []string, rows [][]string) string {
+	cell := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+	var b strings.Builder
+	b.WriteString("*" + caption + "*\n\n|")
+	for _, h := range header {
+		b.WriteString(" " + h + " |")
+	}
+	b.WriteString("\n|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		b.WriteString("|")
+		for _, v := range row {
+			if v != "" {
+				v = " " + cell(v)
+			}
+			b.WriteString(v + " |")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// codeSpan returns a Markdown code span, or nothing for an empty string.
+func codeSpan(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+// firstParagraph returns the first paragraph of a comment, on one line.
+func firstParagraph(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.Index(text, "\n\n"); i >= 0 {
+		text = text[:i]
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
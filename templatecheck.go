@@ -0,0 +1,448 @@
+// ## Checking templates
+//
+// A theme with a broken template fails in the middle of a build, and only
+// for the documents that happen to reach the broken branch. `goweave
+// template check` finds such bugs up front:
+//
+//	goweave template check ./mytheme
+//
+// It parses goweave.templ and goweave-index.templ of the directory, along
+// with the file of -template, and then checks them in two ways. First, it
+// follows every field, method, and variable in every branch of the
+// templates through the types of the template data, as documented under
+// "Template data", and reports the names that the data does not have.
+// Second, if that finds nothing, it executes the templates with sample
+// data, once with every field set and once with the fields left empty,
+// which catches what the types cannot tell, like calling a function with
+// the wrong arguments.
+//
+// Where the type of a value is unknown, like the result of `and` or of a
+// method that returns an interface, the check gives the benefit of the
+// doubt.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"text/template/parse"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// templateChecker checks templates against the types of their data.
+type templateChecker struct {
+	t        *template.Template
+	seen     map[string]bool // the templates checked so far, with the type of dot
+	problems []string
+}
+
+// checkTemplate checks the named template with dot of the given type.
+func (c *templateChecker) checkTemplate(name string, dot reflect.Type) {
+	key := name + "\x00" + dot.String()
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	t := c.t.Lookup(name)
+	if t == nil || t.Tree == nil || t.Tree.Root == nil {
+		return
+	}
+	c.walk(t.Tree, t.Tree.Root, dot, map[string]reflect.Type{"$": dot})
+}
+
+// report records a problem at a node.
+func (c *templateChecker) report(tree *parse.Tree, n parse.Node, format string, args ...interface{}) {
+	location, _ := tree.ErrorContext(n)
+	c.problems = append(c.problems, location+": "+fmt.Sprintf(format, args...))
+}
+
+// walk checks a node and the nodes below it. A nil dot is of unknown type.
+func (c *templateChecker) walk(tree *parse.Tree, n parse.Node, dot reflect.Type, vars map[string]reflect.Type) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(tree, child, dot, vars)
+		}
+	case *parse.ActionNode:
+		c.pipeType(tree, n.Pipe, dot, vars)
+	case *parse.IfNode:
+		c.branch(tree, &n.BranchNode, dot, vars, func(reflect.Type) reflect.Type { return dot })
+	case *parse.WithNode:
+		c.branch(tree, &n.BranchNode, dot, vars, func(t reflect.Type) reflect.Type { return t })
+	case *parse.RangeNode:
+		c.branch(tree, &n.BranchNode, dot, vars, elemType)
+	case *parse.TemplateNode:
+		if c.t.Lookup(n.Name) == nil {
+			c.report(tree, n, "no template %q", n.Name)
+			return
+		}
+		var t reflect.Type
+		if n.Pipe != nil {
+			t = c.pipeType(tree, n.Pipe, dot, vars)
+		}
+		if t != nil {
+			c.checkTemplate(n.Name, t)
+		}
+	}
+}
+
+// branch checks an if, with, or range node. inner returns the type of dot
+// inside the branch from the type of the pipeline.
+func (c *templateChecker) branch(tree *parse.Tree, n *parse.BranchNode, dot reflect.Type, vars map[string]reflect.Type, inner func(reflect.Type) reflect.Type) {
+	scope := copyVars(vars)
+	value := c.pipeType(tree, n.Pipe, dot, scope)
+	var t reflect.Type
+	if value != nil {
+		t = inner(value)
+	}
+	if n.NodeType == parse.NodeRange && len(n.Pipe.Decl) > 0 {
+		// {{range $i, $v := .X}} or {{range $v := .X}}
+		if len(n.Pipe.Decl) == 2 {
+			scope[n.Pipe.Decl[0].Ident[0]], scope[n.Pipe.Decl[1].Ident[0]] = keyType(value), t
+		} else {
+			scope[n.Pipe.Decl[0].Ident[0]] = t
+		}
+	}
+	c.walk(tree, n.List, t, scope)
+	c.walk(tree, n.ElseList, dot, copyVars(vars))
+}
+
+// pipeType checks a pipeline and returns the type of its value, or nil if
+// that is unknown. Variables that the pipeline declares go into vars.
+func (c *templateChecker) pipeType(tree *parse.Tree, pipe *parse.PipeNode, dot reflect.Type, vars map[string]reflect.Type) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+	var t reflect.Type
+	for _, cmd := range pipe.Cmds {
+		t = c.cmdType(tree, cmd, dot, vars)
+	}
+	if !pipe.IsAssign {
+		for _, v := range pipe.Decl {
+			vars[v.Ident[0]] = t
+		}
+	}
+	return t
+}
+
+// cmdType checks a command and returns the type of its value.
+func (c *templateChecker) cmdType(tree *parse.Tree, cmd *parse.CommandNode, dot reflect.Type, vars map[string]reflect.Type) reflect.Type {
+	var args []reflect.Type
+	for _, arg := range cmd.Args[1:] {
+		args = append(args, c.argType(tree, arg, dot, vars))
+	}
+	if id, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+		return funcType(id.Ident, args)
+	}
+	return c.argType(tree, cmd.Args[0], dot, vars)
+}
+
+// argType checks an operand and returns its type.
+func (c *templateChecker) argType(tree *parse.Tree, n parse.Node, dot reflect.Type, vars map[string]reflect.Type) reflect.Type {
+	switch n := n.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return c.fieldsType(tree, n, dot, n.Ident)
+	case *parse.VariableNode:
+		t, ok := vars[n.Ident[0]]
+		if !ok {
+			return nil // declared in a way the check does not follow
+		}
+		return c.fieldsType(tree, n, t, n.Ident[1:])
+	case *parse.ChainNode:
+		return c.fieldsType(tree, n, c.argType(tree, n.Node, dot, vars), n.Field)
+	case *parse.PipeNode:
+		return c.pipeType(tree, n, dot, copyVars(vars))
+	case *parse.StringNode:
+		return reflect.TypeOf("")
+	case *parse.BoolNode:
+		return reflect.TypeOf(true)
+	}
+	return nil
+}
+
+// fieldsType follows a chain of field and method names from a type.
+func (c *templateChecker) fieldsType(tree *parse.Tree, n parse.Node, t reflect.Type, names []string) reflect.Type {
+	for _, name := range names {
+		if t == nil {
+			return nil
+		}
+		next, ok := fieldType(t, name)
+		if !ok {
+			c.report(tree, n, "can't evaluate field %s in type %s", name, t)
+			return nil
+		}
+		t = next
+	}
+	return t
+}
+
+// fieldType returns the type of a field, method, or map element of a type.
+// The type is nil if it is unknown; ok is false if there is no such name.
+func fieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if m, ok := reflect.PtrTo(t).MethodByName(name); ok {
+		return methodResult(m.Type), true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if f, ok := t.FieldByName(name); ok && f.PkgPath == "" {
+			return f.Type, true
+		}
+		return nil, false
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return t.Elem(), true
+		}
+	case reflect.Interface:
+		return nil, true
+	}
+	return nil, false
+}
+
+// methodResult returns the type of the first result of a method, or nil if
+// that is an interface or there is none.
+func methodResult(m reflect.Type) reflect.Type {
+	if m.NumOut() == 0 || m.Out(0).Kind() == reflect.Interface {
+		return nil
+	}
+	return m.Out(0)
+}
+
+// funcType returns the type of the result of a template function.
+func funcType(name string, args []reflect.Type) reflect.Type {
+	switch name {
+	case "index":
+		if len(args) == 0 {
+			return nil
+		}
+		t := args[0]
+		for i := 1; t != nil && i < len(args); i++ {
+			t = elemType(t)
+		}
+		return t
+	case "len":
+		return reflect.TypeOf(0)
+	case "not", "eq", "ne", "lt", "le", "gt", "ge":
+		return reflect.TypeOf(true)
+	case "print", "printf", "println", "html", "js", "urlquery":
+		return reflect.TypeOf("")
+	}
+	if f, ok := templateFuncs[name]; ok {
+		return methodResult(reflect.TypeOf(f))
+	}
+	return nil
+}
+
+// elemType returns the type of the elements of a slice, array, or map, or
+// nil if that is unknown.
+func elemType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		if t.Elem().Kind() == reflect.Interface {
+			return nil
+		}
+		return t.Elem()
+	}
+	return nil
+}
+
+// keyType returns the type of the keys of a map, or int for slices.
+func keyType(t reflect.Type) reflect.Type {
+	if t != nil && t.Kind() == reflect.Map {
+		return t.Key()
+	}
+	return reflect.TypeOf(0)
+}
+
+func copyVars(vars map[string]reflect.Type) map[string]reflect.Type {
+	c := make(map[string]reflect.Type, len(vars))
+	for k, v := range vars {
+		c[k] = v
+	}
+	return c
+}
+
+// sampleSource is the Go file that the sample documents come from.
+const sampleSource = `// # A sample document
+//
+// Some *prose*, with a [link](other.html).
+package main
+
+import "fmt"
+
+//goweave:caption The main function
+//goweave:status draft
+// ## Main
+//
+// The main function.
+func main() {
+	fmt.Println("Hello") // says hello
+}
+`
+
+// sampleDocs returns a document with every field set, for executing
+// templates.
+func sampleDocs() docs {
+	w := weave.New(weave.Options{Footnotes: true, Highlighter: codeHighlighter})
+	sections := w.Sections(sampleSource)
+	weave.AssignIDs(sections)
+	listings := weave.NumberCaptions(sections)
+	toc := tableOfContents(sections)
+	for _, s := range sections {
+		if n := boilerplateLen(s.Code); n > 0 {
+			s.Boilerplate, s.Code = s.Code[:n], s.Code[n:]
+			break
+		}
+	}
+	w.HighlightSections(sections)
+	w.MarkdownSections(sections)
+	ids := map[string]string{}
+	for _, s := range sections {
+		ids[s.ID] = "1"
+	}
+	return docs{
+		Filename:          "sample.go",
+		Title:             "A sample document",
+		ShowTitle:         true,
+		Sections:          weave.HTMLSections(sections),
+		CssPath:           urlPath(cssfilename),
+		Root:              "./",
+		Path:              "sample.html",
+		Style:             "body {}",
+		FontCSS:           "body {}",
+		Full:              true,
+		InlineCSS:         true,
+		HighlightCSS:      ".k {}",
+		ColorScheme:       "dark",
+		ColorSchemeToggle: true,
+		ScriptPath:        urlPath(jsfilename),
+		Script:            "f()",
+		Pragmas:           frontMatter{"title": "A sample document"},
+		WrapperClass:      "goweave",
+		Permalinks:        true,
+		SourceLink:        "sample.go",
+		RawSource:         template.HTML(sampleSource),
+		Listings:          listings,
+		TOC:               toc,
+		TOCStyle:          tocSidebar,
+		Related:           []relatedPage{{"Another document", "other.html"}},
+		Prev:              &splitLink{"The page before", "sample-before.html"},
+		Next:              &splitLink{"The page after", "sample-after.html"},
+		Canonical:         "https://example.com/sample.html",
+		NoIndex:           true,
+		Lang:              "en",
+		Languages:         []language{{"en", "sample.html", true}, {"de", "sample.de.html", false}},
+		SrcLinks:          ids,
+		LineNums:          ids,
+	}
+}
+
+// sampleIndex returns index data with every field set.
+func sampleIndex() indexData {
+	pages := []indexEntry{
+		{Title: "A sample document", Href: "sample.html", Source: "sample.go", Summary: "Some <em>prose</em>."},
+		{Title: "go.mod", Href: "go.mod.html", Source: "go.mod", Project: true},
+	}
+	return indexData{"Index", urlPath(cssfilename), "body {}", "body {}", true, "dark", true, urlPath(jsfilename), "f()", pages, pages[1:]}
+}
+
+// checkTemplates checks the document and index templates, writes the
+// problems to w, and returns their number.
+func checkTemplates(w io.Writer, t, index *template.Template) int {
+	c := &templateChecker{t: t, seen: map[string]bool{}}
+	docsType := reflect.TypeOf(docs{})
+	c.checkTemplate(tplfilename, docsType)
+	c.checkTemplate("fragment", docsType)
+	if index != nil {
+		ic := &templateChecker{t: index, seen: map[string]bool{}}
+		ic.checkTemplate(indexfilename, reflect.TypeOf(indexData{}))
+		c.problems = append(c.problems, ic.problems...)
+	}
+	if len(c.problems) > 0 {
+		// Executing would only repeat them.
+		return printProblems(w, c.problems)
+	}
+	full, empty := sampleDocs(), docs{Filename: "sample.go", Title: "sample.go"}
+	for _, name := range []string{tplfilename, "fragment"} {
+		if t.Lookup(name) == nil {
+			c.problems = append(c.problems, fmt.Sprintf("%s: no template %q", tplfilename, name))
+			continue
+		}
+		for _, d := range []docs{full, empty} {
+			if err := t.ExecuteTemplate(ioutil.Discard, name, d); err != nil {
+				c.problems = append(c.problems, err.Error())
+			}
+		}
+	}
+	if index != nil {
+		for _, d := range []indexData{sampleIndex(), {}} {
+			if err := index.Execute(ioutil.Discard, d); err != nil {
+				c.problems = append(c.problems, err.Error())
+			}
+		}
+	}
+	return printProblems(w, c.problems)
+}
+
+// printProblems writes the problems to w, each once, and returns their number.
+func printProblems(w io.Writer, problems []string) int {
+	seen := map[string]bool{}
+	for _, p := range problems {
+		if !seen[p] {
+			fmt.Fprintln(w, p)
+			seen[p] = true
+		}
+	}
+	return len(seen)
+}
+
+// templateCommand implements `goweave template check [dir]`, which checks
+// the templates of a resource directory, by default the one that goweave
+// uses.
+func templateCommand(args []string) error {
+	fset := flag.NewFlagSet("template", flag.ContinueOnError)
+	if len(args) == 0 || args[0] != "check" {
+		return fmt.Errorf("usage: goweave template check [dir]")
+	}
+	if err := fset.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fset.NArg() > 1 {
+		return fmt.Errorf("usage: goweave template check [dir]")
+	}
+	var dir string
+	if fset.NArg() == 1 {
+		dir = fset.Arg(0)
+	} else {
+		dir = findResources()
+	}
+	if err := loadHighlighter(); err != nil {
+		return err
+	}
+	if err := loadResources(dir); err != nil {
+		return err
+	}
+	if n := checkTemplates(os.Stdout, templ, indexTempl); n > 0 {
+		return fmt.Errorf("%s: %d problem(s) in the templates", resourcePath(dir, tplfilename), n)
+	}
+	fmt.Printf("%s: the templates are fine.\n", resourcePath(dir, tplfilename))
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestCheckTemplates(t *testing.T) {
+	const fragment = `{{define "fragment"}}{{range .Sections}}{{.Doc}}{{end}}{{end}}`
+	tests := []struct {
+		templ string
+		want  []string // the problems, in order
+	}{
+		{`{{.Title}}{{range .Sections}}{{.ID}}{{with .Caption}}{{.Label}}{{end}}{{end}}`, nil},
+		{`{{range $i, $s := .Sections}}{{$i}}{{$s.Code}}{{index $.SrcLinks $s.ID}}{{end}}{{.Pragmas.title}}`, nil},
+		{`{{with index .LineNums "x"}}{{len .}}{{end}}{{range .TOC}}{{.Level}}{{$.Title}}{{end}}`, nil},
+		{`{{.Titel}}`, []string{"can't evaluate field Titel in type main.docs"}},
+		{`{{range .Related}}{{.Title}}{{.Name}}{{end}}`, []string{"can't evaluate field Name in type main.relatedPage"}},
+		{`{{if .Full}}{{else}}{{.Section}}{{end}}`, []string{"can't evaluate field Section in type main.docs"}},
+		{`{{template "missing" .}}`, []string{`no template "missing"`}},
+		{`{{template "x" .Sections}}{{define "x"}}{{range .}}{{.Footnotes}}{{.Bogus}}{{end}}{{end}}`, []string{"can't evaluate field Bogus in type weave.HTMLSection"}},
+		{`{{relpath "a.html" | printf "%s"}}{{(slugify .Title).Foo}}`, []string{"can't evaluate field Foo in type string"}},
+		{`{{code .Title}}`, []string{"wrong number of args for code"}},
+	}
+	for _, tt := range tests {
+		tpl := template.Must(template.New(tplfilename).Funcs(templateFuncs).Parse(tt.templ + fragment))
+		var b bytes.Buffer
+		n := checkTemplates(&b, tpl, nil)
+		got := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if n != len(tt.want) {
+			t.Errorf("checkTemplates(%q) found %d problem(s), want %d:\n%s", tt.templ, n, len(tt.want), b.String())
+			continue
+		}
+		for i, w := range tt.want {
+			if !strings.Contains(got[i], w) {
+				t.Errorf("checkTemplates(%q): problem %d = %q, want %q", tt.templ, i, got[i], w)
+			}
+		}
+	}
+}
+
+func TestCheckBuiltinTemplates(t *testing.T) {
+	data, err := fs.ReadFile(weave.Resources(), tplfilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := template.Must(template.New(tplfilename).Funcs(templateFuncs).Parse(string(data)))
+	index, err := loadIndexTemplate("testdata/no-such-dir") // the built-in one
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if n := checkTemplates(&b, tpl, index); n > 0 {
+		t.Errorf("checkTemplates() found %d problem(s) in the built-in templates:\n%s", n, b.String())
+	}
+}
@@ -0,0 +1,25 @@
+/*
+# Block comments
+
+A block comment that spans several lines, with `code` and *emphasis*.
+*/
+package selftest
+
+/* A block comment on a single line. */
+var a = 1
+
+/*
+   An indented block comment,
+   with a list:
+
+   * one
+   * two
+*/
+
+func f() int {
+	/* a block comment within the code */
+	return a /* and one at the end of a line */
+}
+
+// A line comment after a block comment.
+var b = 2 /* trailing */ + 3
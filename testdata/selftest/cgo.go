@@ -0,0 +1,21 @@
+// # cgo
+//
+// The preamble of `import "C"` is C code in a comment, and `//export` is a
+// directive.
+package selftest
+
+/*
+#include <stdio.h>
+
+static void hello(void) { printf("hello\n"); }
+*/
+import "C"
+
+//go:generate echo generated
+
+// Hello calls C.
+//
+//export Hello
+func Hello() {
+	C.hello()
+}
@@ -0,0 +1,31 @@
+// # Generics
+//
+// Type parameters, constraints, and instantiations.
+package selftest
+
+// Number is a constraint with a type set.
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+// Sum adds up numbers of any type that satisfies Number.
+func Sum[T Number](xs ...T) T {
+	var s T
+	for _, x := range xs {
+		s += x
+	}
+	return s
+}
+
+// Pair has two type parameters.
+type Pair[K, V any] struct {
+	Key K
+	Val V
+}
+
+// Swap is a method of a generic type.
+func (p Pair[K, V]) Swap() Pair[V, K] {
+	return Pair[V, K]{p.Val, p.Key}
+}
+
+var total = Sum[float64](1, 2.5)
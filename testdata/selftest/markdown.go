@@ -0,0 +1,26 @@
+// # Markdown
+//
+// ## Lists
+//
+// 1. first
+// 2. second
+//   - nested
+//
+// ## Code blocks
+//
+// ```go
+// fmt.Println("in a comment")
+// ```
+//
+//	indented code
+//
+// ## Tables
+//
+// | Name | Value |
+// |------|-------|
+// | a    | 1     |
+//
+// > A quote with a [link](https://golang.org).
+package selftest
+
+// No code follows this comment.
@@ -0,0 +1,15 @@
+// # Strings that look like comments
+//
+// None of the strings below starts a comment.
+package selftest
+
+const (
+	url   = "http://example.com/path" // a URL
+	slash = '/'
+	block = "/* not a comment */"
+	raw   = `// not a comment either
+/* nor this */`
+)
+
+// HTML in strings and comments gets escaped: <b>bold</b> & "quotes".
+var html = "<b>bold</b> & 'quotes'"
@@ -0,0 +1,316 @@
+// ## Checking the contrast of a theme
+//
+// `goweave theme lint [file.css]` checks whether the text colors of a
+// theme stand out enough against their backgrounds to be readable. It
+// computes the contrast ratio of each pair of colors as defined by the Web
+// Content Accessibility Guidelines (WCAG) 2, and reports the pairs below
+// the ratio that level AA requires for normal text, 4.5:1. Without a file
+// name, it checks the CSS file in the resource directory.
+//
+// The checker does not implement the CSS cascade. Instead, it knows which
+// rules of a goweave theme set the colors of prose and code, and looks up
+// the last `color` or `background-color` declaration of these rules,
+// resolving `var()` through the custom properties of `:root`. Rules inside
+// `@media` blocks are ignored, as they only apply to some screens or to
+// print, except for those of a plain `@media screen` block. If the style
+// sheet offers both palettes, with `color-scheme: light dark`, the dark
+// palette of `:root:where([data-color-scheme="dark"])` gets checked, too
+// (see colorscheme.go). With -theme, the rules of the theme count, too.
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// minContrast is the WCAG AA contrast ratio for normal text.
+const minContrast = 4.5
+
+// contrastChecks lists the color pairs of a theme. Selectors match any rule
+// that ends with them, so `.keyword` matches `#goweave .keyword`.
+var contrastChecks = []struct {
+	name, fg, bg string
+}{
+	{"prose", ".doc", "body"},
+	{"code", ".code pre code", ".code"},
+	{"keywords", ".keyword", ".code"},
+	{"literals", ".literal", ".code"},
+	{"identifiers", ".ident", ".code"},
+	{"operators", ".operator", ".code"},
+	{"comments", ".comment", ".code"},
+	{"links", "a:link", "body"},
+	{"navigation", "nav.source a", "body"},
+}
+
+// darkRoot is the selector of the dark palette's custom properties.
+const darkRoot = `:root:where([data-color-scheme="dark"])`
+
+// cssRules maps each selector to its declarations. The last declaration of
+// a property wins.
+type cssRules map[string]map[string]string
+
+var cssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// parseCSS collects the declarations of the top-level rules of a style
+// sheet, and of the rules in `@media screen` blocks. Selector lists are
+// split into single selectors.
+func parseCSS(css string) cssRules {
+	css = cssComment.ReplaceAllString(css, "")
+	rules := cssRules{}
+	depth := 0
+	top := 1 // the depth of the rules that count
+	start := 0
+	var selectors []string
+	for i, c := range css {
+		switch c {
+		case '{':
+			depth++
+			if depth == top {
+				selectors = strings.Split(css[start:i], ",")
+				if top == 1 && strings.Join(strings.Fields(css[start:i]), " ") == "@media screen" {
+					top++
+				}
+			}
+			start = i + 1
+		case '}':
+			if depth == top && !strings.HasPrefix(strings.TrimSpace(selectors[0]), "@") {
+				for _, sel := range selectors {
+					sel = strings.Join(strings.Fields(sel), " ")
+					if rules[sel] == nil {
+						rules[sel] = map[string]string{}
+					}
+					for _, decl := range strings.Split(css[start:i], ";") {
+						if kv := strings.SplitN(decl, ":", 2); len(kv) == 2 {
+							rules[sel][strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+						}
+					}
+				}
+			}
+			if top > 1 && depth == top-1 {
+				top-- // the end of the @media screen block
+			}
+			if depth > 0 {
+				depth--
+			}
+			start = i + 1
+		}
+	}
+	return rules
+}
+
+// lookup returns the value of a property from the rules whose selectors
+// end with the given selector. If several rules set the property, the one
+// with the longest selector wins, as a rough guess at specificity.
+func (r cssRules) lookup(selector string, props ...string) string {
+	value, best := "", -1
+	for sel, decls := range r {
+		if sel != selector && !strings.HasSuffix(sel, " "+selector) {
+			continue
+		}
+		for _, p := range props {
+			if v, ok := decls[p]; ok && len(sel) > best {
+				value, best = v, len(sel)
+			}
+		}
+	}
+	return value
+}
+
+var cssVar = regexp.MustCompile(`^var\(\s*(--[\w-]+)\s*(?:,\s*(.*?))?\s*\)$`)
+
+// resolveVars replaces a `var()` value with the value of the custom
+// property in props, or with the fallback of the `var()` if props lacks the
+// property.
+func resolveVars(value string, props map[string]string) string {
+	for i := 0; i < 10; i++ { // custom properties may refer to each other
+		m := cssVar.FindStringSubmatch(strings.TrimSpace(value))
+		if m == nil {
+			break
+		}
+		if v, ok := props[m[1]]; ok {
+			value = v
+		} else {
+			value = m[2]
+		}
+	}
+	return value
+}
+
+// palettes returns the custom properties of the light palette and, if the
+// style sheet offers one, of the dark palette.
+func (r cssRules) palettes() (light, dark map[string]string) {
+	light = r[":root"]
+	scheme := strings.Fields(light["color-scheme"])
+	if r[darkRoot] == nil || len(scheme) < 2 {
+		return light, nil
+	}
+	dark = map[string]string{}
+	for p, v := range light {
+		dark[p] = v
+	}
+	for p, v := range r[darkRoot] {
+		dark[p] = v
+	}
+	return light, dark
+}
+
+// rgb is a color with components between 0 and 255.
+type rgb [3]float64
+
+var (
+	hexColor   = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})\b`)
+	funcColor  = regexp.MustCompile(`^rgba?\(\s*(\d+)\s*,\s*(\d+)\s*,\s*(\d+)\s*[,)]`)
+	namedColor = map[string]rgb{
+		"black": {0, 0, 0},
+		"white": {255, 255, 255},
+		"gray":  {128, 128, 128},
+		"grey":  {128, 128, 128},
+	}
+)
+
+// parseColor reads the color at the start of a CSS value, so that
+// shorthands like `background: #fff url(bg.png)` work, too.
+func parseColor(value string) (rgb, error) {
+	value = strings.TrimSpace(value)
+	if m := hexColor.FindStringSubmatch(value); m != nil {
+		h := m[1]
+		if len(h) == 3 {
+			h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+		}
+		n, _ := strconv.ParseUint(h, 16, 32)
+		return rgb{float64(n >> 16 & 0xff), float64(n >> 8 & 0xff), float64(n & 0xff)}, nil
+	}
+	if m := funcColor.FindStringSubmatch(value); m != nil {
+		var c rgb
+		for i := range c {
+			c[i], _ = strconv.ParseFloat(m[i+1], 64)
+		}
+		return c, nil
+	}
+	if c, ok := namedColor[strings.ToLower(strings.Fields(value + " ")[0])]; ok {
+		return c, nil
+	}
+	return rgb{}, fmt.Errorf("cannot check the color %q", value)
+}
+
+// luminance returns the relative luminance of a color, as defined by WCAG.
+func (c rgb) luminance() float64 {
+	var l [3]float64
+	for i, v := range c {
+		v /= 255
+		if v <= 0.03928 {
+			l[i] = v / 12.92
+		} else {
+			l[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return 0.2126*l[0] + 0.7152*l[1] + 0.0722*l[2]
+}
+
+// contrast returns the WCAG contrast ratio of two colors, between 1 and 21.
+func contrast(a, b rgb) float64 {
+	la, lb := a.luminance(), b.luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// contrastOf returns the contrast ratio of two CSS color values.
+func contrastOf(fgValue, bgValue string) (float64, error) {
+	fg, err := parseColor(fgValue)
+	if err != nil {
+		return 0, err
+	}
+	bg, err := parseColor(bgValue)
+	if err != nil {
+		return 0, err
+	}
+	return contrast(fg, bg), nil
+}
+
+// lintTheme checks the color pairs of a style sheet, writes a report to w,
+// and returns the number of pairs that fail the check.
+func lintTheme(w io.Writer, css string) int {
+	rules := parseCSS(css)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	light, dark := rules.palettes()
+	failed := lintPalette(tw, rules, light, "")
+	if dark != nil {
+		failed += lintPalette(tw, rules, dark, " (dark)")
+	}
+	tw.Flush()
+	return failed
+}
+
+// lintPalette checks the color pairs of a style sheet with the custom
+// properties of a palette, and returns the number of pairs that fail the
+// check. The suffix goes after the name of each pair.
+func lintPalette(tw io.Writer, rules cssRules, props map[string]string, suffix string) int {
+	failed := 0
+	for _, c := range contrastChecks {
+		name := c.name + suffix
+		fgValue := resolveVars(rules.lookup(c.fg, "color"), props)
+		bgValue := resolveVars(rules.lookup(c.bg, "background-color", "background"), props)
+		if bgValue == "" {
+			bgValue = resolveVars(rules.lookup("body", "background-color", "background"), props)
+		}
+		if fgValue == "" {
+			fmt.Fprintf(tw, "%s\t%s\t\t\tskipped: no color set\n", name, c.fg)
+			continue
+		}
+		if bgValue == "" {
+			bgValue = "white" // the browser default
+		}
+		ratio, err := contrastOf(fgValue, bgValue)
+		if err != nil {
+			fmt.Fprintf(tw, "%s\t%s\t\t\tFAIL: %v\n", name, c.fg, err)
+			failed++
+			continue
+		}
+		result := "ok"
+		if ratio < minContrast {
+			result = fmt.Sprintf("FAIL: below %.1f:1", minContrast)
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s on %s\t%.1f:1\t%s\n", name, c.fg, fgValue, bgValue, ratio, result)
+	}
+	return failed
+}
+
+// themeCommand runs the `theme` subcommand and returns an error if the
+// theme fails the check.
+func themeCommand(args []string) error {
+	if len(args) == 0 || args[0] != "lint" || len(args) > 2 {
+		return fmt.Errorf("usage: goweave theme lint [file.css]")
+	}
+	var filename string
+	var css []byte
+	var err error
+	if len(args) == 2 {
+		filename = args[1]
+		css, err = ioutil.ReadFile(filename)
+	} else {
+		// The stylesheet of the documents, with -theme.
+		path := findResources()
+		filename = resourcePath(path, cssfilename)
+		if *theme != "" {
+			filename += " with theme " + *theme
+		}
+		css, err = stylesheet(path)
+	}
+	if err != nil {
+		return err
+	}
+	if n := lintTheme(os.Stdout, string(css)); n > 0 {
+		return fmt.Errorf("%s: %d color pair(s) fail the contrast check", filename, n)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    rgb
+		wantErr bool
+	}{
+		{"#fff", rgb{255, 255, 255}, false},
+		{"#c17600", rgb{0xc1, 0x76, 0x00}, false},
+		{"rgb(1, 2, 3)", rgb{1, 2, 3}, false},
+		{"rgba(1,2,3,0.5)", rgb{1, 2, 3}, false},
+		{"White url(bg.png)", rgb{255, 255, 255}, false},
+		{"hsl(0, 0%, 0%)", rgb{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseColor(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseColor(%q) = %v, %v; want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestContrast(t *testing.T) {
+	tests := []struct {
+		a, b rgb
+		want float64
+	}{
+		{rgb{0, 0, 0}, rgb{255, 255, 255}, 21},
+		{rgb{255, 255, 255}, rgb{0, 0, 0}, 21},
+		{rgb{128, 128, 128}, rgb{128, 128, 128}, 1},
+		{rgb{0x76, 0x76, 0x76}, rgb{255, 255, 255}, 4.54},
+	}
+	for _, tt := range tests {
+		if got := contrast(tt.a, tt.b); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("contrast(%v, %v) = %.2f, want %.2f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLintTheme(t *testing.T) {
+	css := `/* a theme */
+body { background-color: #ffffff; }
+#goweave .doc { color: #000; }
+#goweave .code { background: #fff; }
+#goweave .keyword, #goweave .literal { color: #bbbbbb; }
+@media print { #goweave .keyword { color: #000; } }
+`
+	var b bytes.Buffer
+	if got := lintTheme(&b, css); got != 2 {
+		t.Errorf("lintTheme() = %d failures, want 2:\n%s", got, b.String())
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, "keywords") && !strings.Contains(line, "FAIL") {
+			t.Errorf("lintTheme() did not fail the keywords: %s", line)
+		}
+		if strings.HasPrefix(line, "comments") && !strings.Contains(line, "skipped") {
+			t.Errorf("lintTheme() did not skip the comments: %s", line)
+		}
+	}
+}
+
+func TestLintPalettes(t *testing.T) {
+	css := `:root { color-scheme: light dark; --bg: #fff; --fg: #000; --kw: #000; }
+@media screen {
+	:root:where([data-color-scheme="dark"]) { --bg: #1e1e1e; --fg: #d4d4d4; }
+}
+@media screen and (prefers-color-scheme: dark) {
+	:root { --fg: #1e1e1e; }
+}
+body { background-color: var(--bg); }
+#goweave .doc { color: var(--fg); }
+#goweave .keyword { color: var(--kw); }
+#goweave .comment { color: var(--missing, #666); }
+`
+	var b bytes.Buffer
+	if got := lintTheme(&b, css); got != 2 {
+		t.Errorf("lintTheme() = %d failures, want 2:\n%s", got, b.String())
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "prose (dark)"), strings.HasPrefix(line, "prose "):
+			if !strings.Contains(line, "ok") {
+				t.Errorf("lintTheme() failed the prose: %s", line)
+			}
+		case strings.HasPrefix(line, "keywords (dark)"), strings.HasPrefix(line, "comments (dark)"):
+			if !strings.Contains(line, "FAIL") {
+				t.Errorf("lintTheme() did not fail the dark palette: %s", line)
+			}
+		}
+	}
+
+	// A theme that fixes the palette has no dark one to check.
+	b.Reset()
+	lintTheme(&b, css+":root { color-scheme: light; }")
+	if strings.Contains(b.String(), "(dark)") {
+		t.Errorf("lintTheme() checked the dark palette of a light theme:\n%s", b.String())
+	}
+}
@@ -0,0 +1,112 @@
+// ## Themes
+//
+// goweave.css holds the layout and the colors of the documents. A theme
+// changes the look without repeating the layout: it is a CSS file of rules
+// that goweave appends to goweave.css, so that they override the rules
+// before them. Mostly, a theme sets the custom properties of the colors,
+// for both palettes (see colorscheme.go). goweave ships with these themes:
+//
+// * `classic`: the look of the original docco, serif prose and code on a
+// pale blue background.
+// * `dark`: light text on dark backgrounds.
+// * `high-contrast`: black on white, with syntax colors that pass WCAG AAA.
+// * `print`: black on white with a serif font, for paper and PDF files.
+//
+// `-theme=<name>` picks one; the stylesheet that goweave copies to the
+// output directory, or inlines with -inline, is then goweave.css plus the
+// theme. A theme file in the `themes` directory of the resource directory,
+// like `goweave/resources/themes/dark.css`, takes precedence over the
+// bundled one of the same name, and any other CSS file there is a theme,
+// too. `-list-themes` prints the names of all themes.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// themeDir is the directory of the themes, within the resource directory
+// and among the bundled resources.
+const themeDir = "themes"
+
+// themeNames returns the names of the bundled themes and of the themes in
+// the resource directory, in lexical order.
+func themeNames(resdir string) []string {
+	seen := map[string]bool{}
+	var bundled []string
+	entries, _ := fs.ReadDir(weave.Resources(), themeDir)
+	for _, e := range entries {
+		bundled = append(bundled, e.Name())
+	}
+	var local []string
+	if resdir != "" {
+		local, _ = filepath.Glob(filepath.Join(resdir, themeDir, "*.css"))
+	}
+	var names []string
+	for _, f := range append(bundled, local...) {
+		if filepath.Ext(f) != ".css" {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(f), ".css")
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// themeFile returns the path of a theme in the resource directory.
+func themeFile(resdir, name string) string {
+	return filepath.Join(resdir, themeDir, name+".css")
+}
+
+// readTheme returns the rules of a theme, from the resource directory or
+// from the bundled themes.
+func readTheme(resdir, name string) ([]byte, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid theme name %q", name)
+	}
+	data, err := fs.ReadFile(resourceFS(resdir), path.Join(themeDir, name+".css"))
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = fs.ReadFile(weave.Resources(), path.Join(themeDir, name+".css"))
+		if err != nil {
+			return nil, fmt.Errorf("unknown theme %q (see -list-themes)", name)
+		}
+	}
+	return data, err
+}
+
+// stylesheet returns the CSS file of the documents: goweave.css from the
+// resource directory, followed by the rules of -theme.
+func stylesheet(resdir string) ([]byte, error) {
+	css, err := fs.ReadFile(resourceFS(resdir), cssfilename)
+	if err != nil || *theme == "" {
+		return css, err
+	}
+	rules, err := readTheme(resdir, *theme)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(css, "\n\n"...), rules...), nil
+}
+
+// listThemes prints the names of the themes, marking the one that -theme
+// selects.
+func listThemes(resdir string) {
+	for _, name := range themeNames(resdir) {
+		if name == *theme {
+			fmt.Println(name, "(selected)")
+		} else {
+			fmt.Println(name)
+		}
+	}
+}
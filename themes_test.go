@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestThemes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, themeDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		cssfilename:                            "body { color: gray; }",
+		filepath.Join(themeDir, "dark.css"):    "body { color: white; }",
+		filepath.Join(themeDir, "company.css"): "body { color: blue; }",
+	}
+	for name, css := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(css), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"classic", "company", "dark", "high-contrast", "print"}
+	if got := themeNames(dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("themeNames() = %v, want %v", got, want)
+	}
+
+	defer func(s string) { *theme = s }(*theme)
+	tests := []struct {
+		theme   string
+		want    string // a prefix, for the bundled themes
+		wantErr bool
+	}{
+		{"", "body { color: gray; }", false},
+		{"dark", "body { color: gray; }\n\nbody { color: white; }", false},
+		{"company", "body { color: gray; }\n\nbody { color: blue; }", false},
+		{"classic", "body { color: gray; }\n\n/* goweave theme: classic", false},
+		{"missing", "", true},
+		{"../goweave", "", true},
+	}
+	for _, tt := range tests {
+		*theme = tt.theme
+		got, err := stylesheet(dir)
+		if (err != nil) != tt.wantErr || !strings.HasPrefix(string(got), tt.want) {
+			t.Errorf("stylesheet() with -theme=%s = %q, %v; want %q", tt.theme, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// ## Table of contents
+//
+// Long literate articles are hard to navigate. With -toc, goweave collects
+// the `#` and `##` headings of the comments and lists them at the top of
+// the document, linked to the headings. With -toc-style=sidebar, the list
+// goes into a sidebar that stays in place while the reader scrolls. The
+// template decides how the list looks; it gets the headings as .TOC, and
+// -toc-style as .TOCStyle.
+//
+// Each heading gets an ID derived from its text, like `getting-started` for
+// `## Getting Started`, so links to a heading keep working as long as the
+// heading stays the same.
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// The values of the -toc-style option.
+const (
+	tocTop     = "top"
+	tocSidebar = "sidebar"
+)
+
+// tocDepth is the level of the deepest headings in the table of contents.
+const tocDepth = 2
+
+// checkTOC validates the -toc-style option.
+func checkTOC() error {
+	switch *tocStyle {
+	case tocTop, tocSidebar:
+		return nil
+	}
+	return fmt.Errorf("-toc-style must be %s or %s, not %q", tocTop, tocSidebar, *tocStyle)
+}
+
+// tocEntry is a heading in the table of contents. Href links to it, on
+// another page for the parts of a file that -split splits.
+type tocEntry struct {
+	weave.Heading
+	Href urlPath
+}
+
+// tableOfContents returns the headings for the table of contents of the
+// unrendered sections.
+func tableOfContents(sections []*section) []tocEntry {
+	var entries []tocEntry
+	for _, h := range weave.Headings(sections, tocDepth) {
+		entries = append(entries, tocEntry{h, urlPath("#" + h.ID)})
+	}
+	return entries
+}
+
+// markdownTOC renders the table of contents as a Markdown list.
+func markdownTOC(headings []tocEntry) string {
+	if len(headings) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## Contents\n\n")
+	for _, h := range headings {
+		b.WriteString(strings.Repeat("  ", h.Level-1))
+		b.WriteString("* [" + h.Text + "](" + string(h.Href) + ")\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownTOC(t *testing.T) {
+	defer func(m, c bool) { *md, *toc = m, c }(*md, *toc)
+	*md, *toc = true, true
+	got, err := renderDocs(docs{}, extractSections("test.go", "// # Title\n// ## Part One\n// ## Part One\npackage main"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## Contents\n\n* [Title](#title)\n  * [Part One](#part-one)\n  * [Part One](#part-one-1)\n\n"
+	if !strings.HasPrefix(got, want) {
+		t.Errorf("renderDocs() = %q, want it to start with %q", got, want)
+	}
+}
+
+func TestHTMLTOC(t *testing.T) {
+	defer func(c bool, s string) { *toc, *tocStyle = c, s }(*toc, *tocStyle)
+	*toc, *tocStyle = true, tocSidebar
+	got := generateDocs("test.go", "// # Title\n//\n// Text\npackage main\n")
+	for _, want := range []string{`class="with-toc-sidebar"`, `<nav class="toc sidebar">`, `<a href="#title">Title</a>`, `id="title"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generateDocs() = %s, want it to contain %s", got, want)
+		}
+	}
+}
+
+func TestCheckTOC(t *testing.T) {
+	defer func(s string) { *tocStyle = s }(*tocStyle)
+	for style, ok := range map[string]bool{tocTop: true, tocSidebar: true, "left": false} {
+		*tocStyle = style
+		if err := checkTOC(); (err == nil) != ok {
+			t.Errorf("checkTOC() with %q = %v", style, err)
+		}
+	}
+}
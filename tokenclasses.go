@@ -0,0 +1,57 @@
+// ## Token classes
+//
+// Woven documents that go into a site that already colors code, with the
+// stylesheet of highlight.js or Prism, can leave the colors to it:
+// -token-classes renames the classes of the tokens to the ones that the
+// stylesheet expects. Its value lists presets, `highlightjs` and `prism`,
+// which know the classes of both litebrite and Chroma, and single renames
+// like `keyword=kw`, which override the presets before them:
+//
+//	goweave -fragment -token-classes=prism,ident=token variable server.go
+//
+// The CSS file of goweave and the rules of -chroma-style know only the
+// classes of the highlighters, so goweave leaves out the rules of the
+// Chroma style then; the stylesheet of the site takes over.
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+// tokenClassPresets are the presets of -token-classes.
+var tokenClassPresets = map[string]map[string]string{
+	"highlightjs": weave.HighlightJSClasses,
+	"prism":       weave.PrismClasses,
+}
+
+// tokenClasses are the renames of -token-classes, or nil for none.
+var tokenClasses map[string]string
+
+// parseTokenClasses reads the value of -token-classes.
+func parseTokenClasses(s string) (map[string]string, error) {
+	var classes map[string]string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if classes == nil {
+			classes = map[string]string{}
+		}
+		if preset, ok := tokenClassPresets[item]; ok {
+			for k, v := range preset {
+				classes[k] = v
+			}
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("-token-classes: %q is neither highlightjs, prism, nor of the form class=new class", item)
+		}
+		classes[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return classes, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestParseTokenClasses(t *testing.T) {
+	tests := []struct {
+		s    string
+		want map[string]string
+		err  bool
+	}{
+		{"", nil, false},
+		{"keyword=kw, comment = c note", map[string]string{"keyword": "kw", "comment": "c note"}, false},
+		{"ident=", map[string]string{"ident": ""}, false},
+		{"keyword", nil, true},
+		{"=kw", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTokenClasses(tt.s)
+		if (err != nil) != tt.err || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTokenClasses(%q) = %v, %v", tt.s, got, err)
+		}
+	}
+	got, err := parseTokenClasses("prism, keyword=kw")
+	if err != nil || got["keyword"] != "kw" || got["kd"] != weave.PrismClasses["kd"] {
+		t.Errorf("parseTokenClasses() with a preset = %v, %v", got, err)
+	}
+}
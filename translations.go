@@ -0,0 +1,152 @@
+// ## Translations
+//
+// The comments of a source file can be translated into other languages
+// without touching the source file. A translation lives next to the source
+// file, in a Markdown file named after the source file and the language,
+// like `server.go.de.md`. It consists of the translated comments, each one
+// introduced by a marker with the ID of its section:
+//
+//	<!-- goweave:title Ein Webserver in 50 Zeilen -->
+//
+//	<!-- goweave:section 3f2a9c01b7de -->
+//	Dieser Abschnitt startet den Server.
+//
+// The section IDs are the ones in the `data-section-id` attributes of the
+// generated document. As they are derived from the content of a section,
+// a section that changes gets a new ID, and its translation stops showing
+// up until it is updated. goweave warns about such stale translations.
+// Sections without a translation show the original comment.
+//
+// For each translation, goweave generates a variant of the document, like
+// `server.de.html`, and links all variants to each other.
+package main
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// overlayMarker matches the lines that start a translated section or set
+// the translated title.
+var overlayMarker = regexp.MustCompile(`^<!--\s*goweave:(section|title)\s+(.*?)\s*-->\s*$`)
+
+// langTag matches simple language tags like "de" or "pt-BR".
+var langTag = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// translation holds the translated comments of a source file, keyed by
+// section ID.
+type translation struct {
+	lang     string
+	filename string
+	title    string
+	docs     map[string]string
+}
+
+// language is a link to a variant of a document, as seen by the template.
+type language struct {
+	Lang    string
+	Href    urlPath
+	Current bool
+}
+
+// findTranslations reads all translations of a source file, sorted by
+// language.
+func findTranslations(filename string) ([]translation, error) {
+	matches, err := globSources(filename + ".*.md")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	var ts []translation
+	for _, m := range matches {
+		lang := strings.TrimSuffix(strings.TrimPrefix(m, filename+"."), ".md")
+		if !langTag.MatchString(lang) {
+			continue
+		}
+		src, err := readSource(m)
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseTranslation(lang, m, string(src))
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+// parseTranslation splits a translation file into its sections.
+func parseTranslation(lang, filename, src string) (translation, error) {
+	t := translation{lang: lang, filename: filename, docs: map[string]string{}}
+	id := ""
+	var doc []string
+	flush := func() {
+		if id != "" {
+			t.docs[id] = strings.Trim(strings.Join(doc, "\n"), "\n") + "\n"
+		}
+		doc = doc[:0]
+	}
+	for i, line := range strings.Split(src, "\n") {
+		m := overlayMarker.FindStringSubmatch(line)
+		switch {
+		case m != nil && m[1] == "title":
+			t.title = m[2]
+		case m != nil:
+			flush()
+			id = m[2]
+			if _, dup := t.docs[id]; dup {
+				return t, fmt.Errorf("%s:%d: section %s is translated twice", filename, i+1, id)
+			}
+		case id == "" && strings.TrimSpace(line) != "":
+			return t, fmt.Errorf("%s:%d: text outside of a section", filename, i+1)
+		default:
+			doc = append(doc, line)
+		}
+	}
+	flush()
+	return t, nil
+}
+
+// apply returns a copy of the sections, with the translated comments in
+// place of the original ones. The sections must have their IDs assigned.
+func (t translation) apply(sections []*section) []*section {
+	used := map[string]bool{}
+	translated := make([]*section, len(sections))
+	for i, s := range sections {
+		c := *s
+		if doc, ok := t.docs[s.ID]; ok {
+			c.Doc = doc
+			used[s.ID] = true
+		}
+		translated[i] = &c
+	}
+	for id := range t.docs {
+		if !used[id] {
+			log.Printf("%s: there is no section %s; is the translation out of date?", t.filename, id)
+		}
+	}
+	return translated
+}
+
+// variantName returns the name of the document in the given language.
+func variantName(outname, lang string) string {
+	ext := filepath.Ext(outname)
+	return strings.TrimSuffix(outname, ext) + "." + lang + ext
+}
+
+// languageLinks returns the links to all variants of a document, with the
+// one in the current language marked.
+func languageLinks(outname, current string, ts []translation) []language {
+	links := []language{{Lang: *lang, Href: urlPath(path.Base(outname)), Current: current == *lang}}
+	for _, t := range ts {
+		name := variantName(outname, t.lang)
+		links = append(links, language{Lang: t.lang, Href: urlPath(path.Base(name)), Current: current == t.lang})
+	}
+	return links
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestParseTranslation(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    translation
+		wantErr bool
+	}{
+		{"<!-- goweave:title Titel -->\n\n<!-- goweave:section abc -->\nEins\n\n<!--goweave:section def-->\n\nZwei\nDrei\n\n",
+			translation{lang: "de", filename: "a.go.de.md", title: "Titel", docs: map[string]string{"abc": "Eins\n", "def": "Zwei\nDrei\n"}}, false},
+		{"Text\n<!-- goweave:section abc -->\nEins\n", translation{}, true},
+		{"<!-- goweave:section abc -->\nEins\n<!-- goweave:section abc -->\nZwei\n", translation{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTranslation("de", "a.go.de.md", tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTranslation(%q) error = %v, wantErr %v", tt.src, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTranslation(%q) = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestApplyTranslation(t *testing.T) {
+	sections := []*section{{Doc: "One\n", Code: "a\n"}, {Doc: "Two\n", Code: "b\n"}}
+	weave.AssignIDs(sections)
+	tr := translation{lang: "de", docs: map[string]string{sections[1].ID: "Zwei\n"}}
+	got := tr.apply(sections)
+	if got[0].Doc != "One\n" || got[1].Doc != "Zwei\n" || got[1].ID != sections[1].ID {
+		t.Errorf("apply() = %v, %v; want the second comment translated", got[0], got[1])
+	}
+	if sections[1].Doc != "Two\n" {
+		t.Errorf("apply() changed the original section to %v", sections[1])
+	}
+}
+
+func TestVariantName(t *testing.T) {
+	if got := variantName("server.html", "pt-BR"); got != "server.pt-BR.html" {
+		t.Errorf("variantName() = %q, want server.pt-BR.html", got)
+	}
+}
@@ -0,0 +1,653 @@
+// ## Unweaving
+//
+// Not everyone who can improve the prose of a document wants to touch
+// the source code. `goweave unweave` closes the loop: it takes a document
+// that goweave generated and that someone edited since, and writes the
+// prose of the changed sections back into the comments of the source
+// file:
+//
+//	goweave unweave server.html server.go
+//
+// The sections of the document carry the IDs that goweave derives from
+// their content (see weave/ids.go), so the source must not have changed
+// in between. HTML documents have the IDs anyway, in the
+// `data-section-id` attributes; Markdown documents get them with
+// `-section-ids`, as HTML comments around the prose of each section:
+//
+//	<!-- goweave:section 3f2a9c0b1d4e -->
+//	The prose, to be edited.
+//	<!-- goweave:code -->
+//
+// In HTML, goweave turns the paragraphs, headings, lists, links, emphasis,
+// and the formulas of -math back into Markdown; other markup keeps only
+// its text. Only the
+// sections whose text changed get new comments, which keep the
+// indentation of the old ones, and their pragmas. Changes to the code do
+// not go back, and neither does prose that a sidecar file or an include
+// adds (see include.go), as it has no comment in the source file. The
+// options of the weaving, like -prose-marker, have to be the same, as
+// they decide what the sections are. `-print` prints the new source
+// rather than writing it to the file.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/christophberger/goweave/weave"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// unweaveMarker matches the lines that -section-ids puts around the prose
+// of each section of a Markdown document.
+var unweaveMarker = regexp.MustCompile(`^<!--\s*goweave:(section\s+(\S+)|code)\s*-->\s*$`)
+
+// markSections puts the markers of -section-ids around the prose of the
+// sections. The generated tables of -schema and -const-tables are no prose
+// of the source.
+func markSections(sections []*section) {
+	for _, s := range sections {
+		if isTableSection(s.ID) {
+			continue
+		}
+		s.Doc = "<!-- goweave:section " + s.ID + " -->\n" + s.Doc + "<!-- goweave:code -->\n"
+	}
+}
+
+// unweaveCommand runs the `unweave` subcommand.
+func unweaveCommand(args []string) error {
+	fset := flag.NewFlagSet("unweave", flag.ContinueOnError)
+	toStdout := fset.Bool("print", false, "print the new source rather than writing it to the file")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	if fset.NArg() != 2 {
+		return fmt.Errorf("usage: goweave unweave [-print] <document> <source file>")
+	}
+	docname, filename := fset.Arg(0), fset.Arg(1)
+	doc, err := ioutil.ReadFile(docname)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	out, n, err := unweave(filename, string(src), docname, doc)
+	if err != nil {
+		return err
+	}
+	if *toStdout {
+		_, err = os.Stdout.WriteString(out)
+		return err
+	}
+	log.Printf("%s: new prose for %d section(s)", filename, n)
+	if n == 0 {
+		return nil
+	}
+	return ioutil.WriteFile(filename, []byte(out), fi.Mode().Perm())
+}
+
+// unweave returns the source of a file with the prose of the edited
+// document in the comments, and the number of sections that changed.
+func unweave(filename, src, docname string, doc []byte) (string, int, error) {
+	// The IDs are those of the woven sections, with the prose of the
+	// sidecar file, which then stays out of the comments.
+	sections := extractWithSidecar(filename, []byte(src), false)
+	weave.AssignIDs(sections)
+	notes := sidecarProse(filename, []byte(src))
+	byID := map[string]*section{}
+	linked := map[string]string{}          // the comments as the document shows them
+	refs := map[string]map[string]string{} // the references of the links
+	for _, s := range sections {
+		byID[s.ID] = s
+		linked[s.ID], refs[s.ID] = refLinks(s.Doc, filename)
+	}
+	var prose map[string]string
+	var err error
+	switch strings.ToLower(filepath.Ext(docname)) {
+	case ".md", ".markdown":
+		prose = markdownProse(doc)
+		if len(prose) == 0 {
+			return "", 0, fmt.Errorf("%s has no section IDs (see -section-ids)", docname)
+		}
+	default:
+		prose, err = htmlProse(doc, byID, linked)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	var changed []*section
+	for id, p := range prose {
+		s, ok := byID[id]
+		p = unresolveRefs(p, refs[id])
+		switch {
+		case !ok:
+			log.Printf("%s: section %s is not in %s; did the source change?", docname, id, filename)
+		case strings.Join(strings.Fields(p), " ") == strings.Join(strings.Fields(s.Doc), " "):
+			// unchanged
+		case s.DocStart == 0:
+			log.Printf("%s: section %s has no comment in %s to write the prose to", docname, id, filename)
+		default:
+			own, ok := cutWords(p, notes[s.DocStart])
+			if !ok {
+				log.Printf("%s: section %s changes the prose of %s, which unweave does not write back", docname, id, sidecarFile(filename))
+				continue
+			}
+			s.Doc = own
+			changed = append(changed, s)
+		}
+	}
+	return rewriteComments(filename, src, changed), len(changed), nil
+}
+
+// sidecarProse returns the prose that the sidecar file of a source file
+// puts before the lines of the source, by line, from 1.
+func sidecarProse(filename string, src []byte) map[int]string {
+	comment := sourceLanguage(filename).LineComment + *proseMarker
+	prose := map[int]string{}
+	for i, lines := range sidecarInserts(filename, src, false) {
+		text := make([]string, len(lines))
+		for j, l := range lines {
+			text[j] = strings.TrimPrefix(strings.TrimPrefix(l, comment), " ")
+		}
+		prose[i+1] = strings.Join(text, "\n")
+	}
+	return prose
+}
+
+// cutWords removes the words of prefix from the start of s, whatever the
+// space between them, and reports whether s starts with these words.
+func cutWords(s, prefix string) (string, bool) {
+	for _, w := range strings.Fields(prefix) {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if !strings.HasPrefix(s, w) {
+			return s, false
+		}
+		s = s[len(w):]
+		if s != "" && !strings.ContainsAny(s[:1], " \t\r\n") {
+			return s, false
+		}
+	}
+	return strings.TrimLeft(s, " \t\r\n"), true
+}
+
+// refLinks resolves the cross-references of a comment of a source file, as
+// the weaving does, and returns the comment with the links, and the
+// references by link. References that point nowhere stay as they are.
+func refLinks(doc, source string) (string, map[string]string) {
+	refs := map[string]string{}
+	doc = replaceRefs(doc, func(ref, target, text string) string {
+		filename, _ := refFile(target, source)
+		indexRefs([]string{filename})
+		link, err := resolveRef(target, text, source, outputName(source))
+		if err != nil {
+			return ref
+		}
+		refs[link] = ref
+		return link
+	})
+	return doc, refs
+}
+
+// unresolveRefs turns the links of the references of a comment back into
+// the references.
+func unresolveRefs(prose string, refs map[string]string) string {
+	for link, ref := range refs {
+		prose = strings.Replace(prose, link, ref, -1)
+	}
+	return prose
+}
+
+// markdownProse returns the prose between the markers of a Markdown
+// document, by section ID.
+func markdownProse(doc []byte) map[string]string {
+	prose := map[string]string{}
+	id := ""
+	var lines []string
+	for _, line := range strings.Split(string(doc), "\n") {
+		line = strings.TrimRight(line, "\r")
+		m := unweaveMarker.FindStringSubmatch(line)
+		switch {
+		case m != nil && m[2] != "":
+			id, lines = m[2], nil
+		case m != nil:
+			if id != "" {
+				prose[id] = strings.Join(trimBlankLines(lines), "\n")
+			}
+			id = ""
+		case id != "":
+			lines = append(lines, line)
+		}
+	}
+	return prose
+}
+
+// htmlProse returns the prose of the sections of an HTML document whose
+// text differs from the sections of the source, as Markdown, by section
+// ID. The prose of the other sections is their comment text as it is.
+// linked holds the comments with their references resolved, as the
+// document shows them.
+func htmlProse(doc []byte, byID map[string]*section, linked map[string]string) (map[string]string, error) {
+	root, err := html.Parse(bytes.NewReader(doc))
+	if err != nil {
+		return nil, err
+	}
+	prose := map[string]string{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if id := attr(n, "data-section-id"); n.Type == html.ElementNode && id != "" {
+			if isTableSection(id) {
+				return // a generated table
+			}
+			cell := findElement(n, func(c *html.Node) bool { return c.Data == "div" && hasClass(c, "doc") })
+			if cell == nil {
+				return
+			}
+			s, ok := byID[id]
+			switch {
+			case ok && sameText(cell, renderedDoc(linked[id], findElement(cell, isMath) != nil)):
+				prose[id] = s.Doc
+			case ok:
+				prose[id] = restoreDiagrams(htmlMarkdown(cell), s.Doc)
+			default:
+				prose[id] = restoreDiagrams(htmlMarkdown(cell), "")
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	if len(prose) == 0 {
+		return nil, fmt.Errorf("the document has no section IDs")
+	}
+	return prose, nil
+}
+
+// renderedDoc returns the HTML of a comment, as the documents show it, with
+// formulas if math is set, as in the documents of -math.
+func renderedDoc(doc string, math bool) *html.Node {
+	opts := weaverOptions("")
+	opts.Math = math
+	div := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, _ := html.ParseFragment(strings.NewReader(weave.New(opts).Markdown(doc)), div)
+	for _, n := range nodes {
+		div.AppendChild(n)
+	}
+	return div
+}
+
+// findElement returns the first element below n that match accepts.
+func findElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && match(c) {
+			return c
+		}
+		if e := findElement(c, match); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// hasClass reports whether an element has the class.
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// isDecoration reports whether an element of a doc cell is something that
+// goweave adds to the prose: a permalink, a status badge, or the
+// footnotes.
+func isDecoration(n *html.Node) bool {
+	return n.Type == html.ElementNode &&
+		(n.Data == "a" && hasClass(n, "permalink") ||
+			n.Data == "span" && hasClass(n, "status") ||
+			n.Data == "ol" && hasClass(n, "codenotes"))
+}
+
+// isMath reports whether an element of a doc cell is a formula of -math.
+func isMath(n *html.Node) bool {
+	return n.Type == html.ElementNode && (n.Data == "span" || n.Data == "div") && hasClass(n, "math")
+}
+
+// mathSource returns the TeX of a formula between the dollar signs of the
+// comments.
+func mathSource(n *html.Node) string {
+	tex := strings.TrimSpace(textContent(n))
+	if n.Data == "div" {
+		return "$$\n" + strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(tex, `\[`), `\]`)) + "\n$$"
+	}
+	return "$" + strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(tex, `\(`), `\)`)) + "$"
+}
+
+// diagramMarker stands for a diagram in the text of a doc cell.
+const diagramMarker = "\x00diagram\x00"
+
+// isDiagram reports whether an element of a doc cell is a diagram of
+// -diagrams, or the fenced block that it comes from. The text of a drawn
+// diagram is no prose, and the source of the diagram is not in the
+// document.
+func isDiagram(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch {
+	case n.Data == "figure" && hasClass(n, "diagram"), n.Data == "pre" && hasClass(n, "mermaid"):
+		return true
+	case n.Data == "pre":
+		c := findElement(n, func(c *html.Node) bool { return c.Data == "code" })
+		return c != nil && (hasClass(c, "language-mermaid") || hasClass(c, "language-dot"))
+	}
+	return false
+}
+
+// restoreDiagrams replaces the diagrams of the Markdown of a doc cell with
+// the fenced blocks of the diagrams of the comment, in order. Diagrams
+// beyond those of the comment are dropped.
+func restoreDiagrams(md, doc string) string {
+	if !strings.Contains(md, diagramMarker) {
+		return md
+	}
+	fences := diagramFences(doc)
+	var lines []string
+	for _, line := range strings.Split(md, "\n") {
+		if strings.TrimSpace(line) != diagramMarker {
+			lines = append(lines, line)
+			continue
+		}
+		if len(fences) > 0 {
+			lines = append(lines, fences[0])
+			fences = fences[1:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// diagramFences returns the fenced mermaid and dot blocks of a comment.
+func diagramFences(doc string) []string {
+	var fences, block []string
+	fence := ""
+	diagram := false
+	for _, line := range strings.Split(doc, "\n") {
+		t := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			block = append(block, line)
+			if strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+				if diagram {
+					fences = append(fences, strings.Join(block, "\n"))
+				}
+				fence = ""
+			}
+		case strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~"):
+			fence = t[:3]
+			info := strings.Fields(strings.TrimLeft(t, t[:1]) + " ")
+			diagram = len(info) > 0 && diagramLanguages[strings.ToLower(info[0])]
+			block = []string{line}
+		}
+	}
+	return fences
+}
+
+// diagramLanguages are the languages of the fenced blocks that -diagrams
+// draws.
+var diagramLanguages = map[string]bool{"mermaid": true, "dot": true}
+
+// sameText reports whether two elements have the same text, apart from
+// white space and decorations.
+func sameText(a, b *html.Node) bool {
+	return strings.Join(strings.Fields(proseText(a)), " ") == strings.Join(strings.Fields(proseText(b)), " ")
+}
+
+// proseText returns the text of an element without its decorations.
+func proseText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	if isDecoration(n) {
+		return ""
+	}
+	if isDiagram(n) {
+		return " " + diagramMarker + " "
+	}
+	if isMath(n) {
+		return mathSource(n)
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(proseText(c))
+	}
+	return b.String()
+}
+
+// htmlMarkdown turns the HTML of a doc cell back into Markdown.
+func htmlMarkdown(n *html.Node) string {
+	var b strings.Builder
+	writeMarkdown(&b, n)
+	// Collapse the white space between blocks.
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(b.String(), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// writeMarkdown writes the children of n as Markdown.
+func writeMarkdown(b *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeMarkdownNode(b, c)
+	}
+}
+
+func writeMarkdownNode(b *strings.Builder, n *html.Node) {
+	if n.Type == html.TextNode {
+		b.WriteString(n.Data)
+		return
+	}
+	if n.Type != html.ElementNode || isDecoration(n) {
+		return
+	}
+	if isDiagram(n) {
+		b.WriteString("\n" + diagramMarker + "\n\n")
+		return
+	}
+	if isMath(n) {
+		if n.Data == "div" {
+			b.WriteString("\n" + mathSource(n) + "\n\n")
+		} else {
+			b.WriteString(mathSource(n))
+		}
+		return
+	}
+	inner := func(n *html.Node) string {
+		var c strings.Builder
+		writeMarkdown(&c, n)
+		return c.String()
+	}
+	switch n.Data {
+	case "p":
+		var lines []string
+		for _, l := range strings.Split(inner(n), "\n") {
+			if l = strings.TrimSpace(l); l != "" {
+				lines = append(lines, l)
+			}
+		}
+		b.WriteString("\n" + strings.Join(lines, "\n") + "\n\n")
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1]-'0') - *headingOffset
+		if level < 1 {
+			level = 1
+		}
+		b.WriteString("\n" + strings.Repeat("#", level) + " " + strings.Join(strings.Fields(inner(n)), " ") + "\n\n")
+	case "em", "i":
+		b.WriteString("*" + inner(n) + "*")
+	case "strong", "b":
+		b.WriteString("**" + inner(n) + "**")
+	case "code":
+		b.WriteString("`" + textContent(n) + "`")
+	case "a":
+		b.WriteString("[" + inner(n) + "](" + attr(n, "href") + ")")
+	case "img":
+		b.WriteString("![" + attr(n, "alt") + "](" + attr(n, "src") + ")")
+	case "br":
+		b.WriteString("\n")
+	case "pre":
+		b.WriteString("\n")
+		for _, l := range strings.Split(strings.TrimRight(textContent(n), "\n"), "\n") {
+			b.WriteString("\t" + l + "\n")
+		}
+		b.WriteString("\n")
+	case "ul", "ol":
+		b.WriteString("\n")
+		i := 0
+		for li := n.FirstChild; li != nil; li = li.NextSibling {
+			if li.Type != html.ElementNode || li.Data != "li" {
+				continue
+			}
+			i++
+			bullet := "* "
+			if n.Data == "ol" {
+				bullet = strconv.Itoa(i) + ". "
+			}
+			text := strings.TrimSpace(htmlMarkdown(li))
+			b.WriteString(bullet + strings.Replace(text, "\n", "\n"+strings.Repeat(" ", len(bullet)), -1) + "\n")
+		}
+		b.WriteString("\n")
+	case "blockquote":
+		b.WriteString("\n")
+		for _, l := range strings.Split(strings.TrimSpace(htmlMarkdown(n)), "\n") {
+			b.WriteString(strings.TrimRight("> "+l, " ") + "\n")
+		}
+		b.WriteString("\n")
+	default:
+		writeMarkdown(b, n)
+	}
+}
+
+// rewriteComments returns the source with the comments of the sections
+// replaced by their Doc. The pragmas and directives before the prose of a
+// comment stay above the new prose, and the others below it, along with
+// the empty comment lines that set them apart.
+func rewriteComments(filename, src string, sections []*section) string {
+	lang := sourceLanguage(filename)
+	lines := strings.Split(src, "\n")
+	// From the bottom up, so that the line numbers of the sections above
+	// stay valid.
+	sort.Slice(sections, func(i, j int) bool { return sections[i].DocStart > sections[j].DocStart })
+	for _, s := range sections {
+		old := lines[s.DocStart-1 : s.DocEnd]
+		pragma := func(l string) bool { return lang.IsPragma(l) || lang.IsDirective(l) }
+		first, last := -1, -1 // the first and the last line of prose
+		for i, l := range old {
+			if !pragma(l) && !isEmptyComment(lang, l) {
+				if first < 0 {
+					first = i
+				}
+				last = i
+			}
+		}
+		if first < 0 {
+			first, last = len(old), len(old)-1
+		}
+		var before, middle, after []string
+		for i, l := range old {
+			switch {
+			case i < first:
+				before = append(before, l)
+			case i > last:
+				after = append(after, l)
+			case pragma(l):
+				middle = append(middle, l)
+			}
+		}
+		if !hasPragma(before, pragma) {
+			before = nil
+		}
+		// Directives after the comment can be outside of it.
+		next := s.DocEnd < len(lines) && pragma(lines[s.DocEnd])
+		if after = append(middle, after...); !next && !hasPragma(after, pragma) {
+			after = nil
+		}
+		line := old[0]
+		if first < len(old) {
+			line = old[first]
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		block := lang.BlockStart != "" && strings.HasPrefix(strings.TrimSpace(line), lang.BlockStart)
+		repl := append(before, commentLines(lang, strings.Split(strings.TrimRight(s.Doc, "\n"), "\n"), indent, block)...)
+		repl = append(repl, after...)
+		lines = append(lines[:s.DocStart-1], append(repl, lines[s.DocEnd:]...)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isEmptyComment reports whether a line is a line comment without text.
+func isEmptyComment(lang *weave.Language, line string) bool {
+	t := strings.TrimSpace(line)
+	return lang.LineComment != "" && (t == lang.LineComment || t == lang.LineComment+*proseMarker)
+}
+
+// hasPragma reports whether any of the lines is a pragma.
+func hasPragma(lines []string, pragma func(string) bool) bool {
+	for _, l := range lines {
+		if pragma(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// commentLines turns lines of prose into the comment lines of a language,
+// with the given indentation. With block set, or if the language has no
+// line comments, they become a block comment.
+func commentLines(lang *weave.Language, prose []string, indent string, block bool) []string {
+	lines := make([]string, len(prose))
+	if lang.LineComment == "" || block && lang.BlockStart != "" {
+		for i, p := range prose {
+			lines[i] = indent + p
+		}
+		lines[0] = strings.TrimRight(indent+lang.BlockStart+" "+prose[0], " ")
+		lines[len(lines)-1] += " " + lang.BlockEnd
+		return lines
+	}
+	comment := lang.LineComment + *proseMarker
+	for i, p := range prose {
+		if p == "" {
+			lines[i] = indent + comment
+		} else {
+			lines[i] = indent + comment + " " + p
+		}
+	}
+	return lines
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+	"golang.org/x/net/html"
+)
+
+func TestUnweave(t *testing.T) {
+	src := "// Package p.\npackage p\n\n//goweave:status draft\n// Add adds.\nfunc Add(a, b int) int {\n\t// The sum.\n\treturn a + b\n}\n\n/* Block\ncomment */\nvar x = 1\n"
+	sections := extractSections("p.go", src)
+	weave.AssignIDs(sections)
+	id := func(i int) string { return sections[i].ID }
+	tests := []struct {
+		docname, doc string
+		want         string
+		n            int
+	}{
+		{"p.md", "<!-- goweave:section " + id(1) + " -->\nAdd adds two numbers.\n\nIt is *simple*.\n<!-- goweave:code -->\n",
+			"//goweave:status draft\n// Add adds two numbers.\n//\n// It is *simple*.\nfunc Add", 1},
+		{"p.md", "<!-- goweave:section " + id(2) + " -->\nThe total.\n<!-- goweave:code -->\n<!-- goweave:section " + id(3) + " -->\nBlock\ncomment\n<!-- goweave:code -->\n",
+			"\t// The total.\n\treturn", 1},
+		{"p.md", "<!-- goweave:section " + id(3) + " -->\nBlock\ncomment, edited\n<!-- goweave:code -->\n",
+			"/* Block\ncomment, edited */\nvar x", 1},
+		{"p.html", `<div class="tr section" data-section-id="` + id(1) + `"><div class="td doc"><span class="status status-draft">draft</span><p>Add adds <em>two</em> numbers.</p><ul><li>fast</li><li>safe</li></ul></div></div>` +
+			`<div class="tr section" data-section-id="` + id(2) + `"><div class="td doc"><p>The sum.</p></div></div>`,
+			"// Add adds *two* numbers.\n//\n// * fast\n// * safe\nfunc Add", 1},
+	}
+	for _, tt := range tests {
+		got, n, err := unweave("p.go", src, tt.docname, []byte(tt.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != tt.n || !strings.Contains(got, tt.want) {
+			t.Errorf("unweave(%q) = %q, %d, want %q in it, %d", tt.doc, got, n, tt.want, tt.n)
+		}
+	}
+	if _, _, err := unweave("p.go", src, "p.md", []byte("Add adds.\n")); err == nil {
+		t.Errorf("unweave() accepted a Markdown document without section IDs")
+	}
+}
+
+func TestUnweaveSidecar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "p.go")
+	src := "package p\n\n//goweave:caption F\n// F does f.\n//\n//go:noinline\nfunc F() {}\n"
+	if err := ioutil.WriteFile(sidecarFile(filename), []byte("<!-- goweave:symbol F -->\nSidecar note about F.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sections := extractWithSidecar(filename, []byte(src), false)
+	weave.AssignIDs(sections)
+	id := sections[len(sections)-1].ID
+	tests := []struct {
+		prose, want string
+		n           int
+	}{
+		{"Sidecar note about F.\nF does f, edited.\n",
+			"package p\n\n//goweave:caption F\n// F does f, edited.\n//\n//go:noinline\nfunc F() {}\n", 1},
+		{"A new note about F.\nF does f.\n", src, 0},
+	}
+	for _, tt := range tests {
+		doc := "<!-- goweave:section " + id + " -->\n" + tt.prose + "<!-- goweave:code -->\n"
+		got, n, err := unweave(filename, src, "p.md", []byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || n != tt.n {
+			t.Errorf("unweave(%q) = %q, %d, want %q, %d", tt.prose, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestUnweaveDiagrams(t *testing.T) {
+	src := "// Flow:\n//\n// ```mermaid\n// graph LR\n// A-->B\n// ```\nfunc f() {}\n"
+	sections := extractSections("p.go", src)
+	weave.AssignIDs(sections)
+	cell := func(text string) string {
+		return `<div class="tr section" data-section-id="` + sections[0].ID + `"><div class="td doc"><p>` + text + `</p>` +
+			`<figure class="diagram"><svg><g id="diagram1-node"><text>A</text><text>B</text></g></svg></figure></div></div>`
+	}
+	tests := []struct {
+		doc, want string
+		n         int
+	}{
+		{cell("Flow:"), src, 0},
+		{cell("The flow:"), "// The flow:\n//\n// ```mermaid\n// graph LR\n// A-->B\n// ```\nfunc f() {}\n", 1},
+	}
+	for _, tt := range tests {
+		got, n, err := unweave("p.go", src, "p.html", []byte(tt.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || n != tt.n {
+			t.Errorf("unweave(%q) = %q, %d, want %q, %d", tt.doc, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestUnweaveMath(t *testing.T) {
+	src := "// Sum $a_1 + b_1$.\nfunc f() {}\n"
+	sections := extractSections("p.go", src)
+	weave.AssignIDs(sections)
+	cell := func(text string) string {
+		return `<div class="tr section" data-section-id="` + sections[0].ID + `"><div class="td doc"><p>` + text + `</p></div></div>`
+	}
+	tests := []struct {
+		doc, want string
+		n         int
+	}{
+		{cell(`Sum <span class="math inline">\(a_1 + b_1\)</span>.`), src, 0},
+		{cell(`The sum <span class="math inline">\(a_1 + b_1\)</span>.`), "// The sum $a_1 + b_1$.\nfunc f() {}\n", 1},
+	}
+	for _, tt := range tests {
+		got, n, err := unweave("p.go", src, "p.html", []byte(tt.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || n != tt.n {
+			t.Errorf("unweave(%q) = %q, %d, want %q, %d", tt.doc, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestUnweaveRefs(t *testing.T) {
+	defer func(inputs map[string]bool, pages map[string]*refPage) { refInputs, refPages = inputs, pages }(refInputs, refPages)
+	refInputs, refPages = map[string]bool{}, map[string]*refPage{}
+	dir, err := ioutil.TempDir("", "goweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "p.go")
+	src := "// ## Setup\npackage p\n\n// Add adds, after the [[#setup]].\nfunc Add() {}\n"
+	if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sections := extractSections(filename, src)
+	weave.AssignIDs(sections)
+	cell := func(text string) string {
+		return `<div class="tr section" data-section-id="` + sections[1].ID + `"><div class="td doc"><p>` + text + `</p></div></div>`
+	}
+	tests := []struct {
+		doc, want string
+		n         int
+	}{
+		{cell(`Add adds, after the <a href="#setup">Setup</a>.`), src, 0},
+		{cell(`Add sums, after the <a href="#setup">Setup</a>.`), "// ## Setup\npackage p\n\n// Add sums, after the [[#setup]].\nfunc Add() {}\n", 1},
+	}
+	for _, tt := range tests {
+		got, n, err := unweave(filename, src, "p.html", []byte(tt.doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || n != tt.n {
+			t.Errorf("unweave(%q) = %q, %d, want %q, %d", tt.doc, got, n, tt.want, tt.n)
+		}
+	}
+	md := "<!-- goweave:section " + sections[1].ID + " -->\nAdd adds, after the [Setup](#setup).\n<!-- goweave:code -->\n"
+	if got, n, err := unweave(filename, src, "p.md", []byte(md)); err != nil || got != src || n != 0 {
+		t.Errorf("unweave(%q) = %q, %d, %v, want the source unchanged", md, got, n, err)
+	}
+}
+
+func TestHTMLMarkdown(t *testing.T) {
+	tests := []struct {
+		html, want string
+	}{
+		{"<h2>Title</h2>\n<p>Some\n<strong>bold</strong> <code>x := 1</code> and a <a href=\"https://go.dev\">link</a>.</p>",
+			"## Title\n\nSome\n**bold** `x := 1` and a [link](https://go.dev).\n"},
+		{"<p>List:</p><ol><li>one</li><li>two</li></ol><pre><code>a\n\tb\n</code></pre>",
+			"List:\n\n1. one\n2. two\n\n\ta\n\t\tb\n"},
+		{`<a class="permalink" href="#x">¶</a><blockquote><p>Quote</p></blockquote>`, "> Quote\n"},
+		{`<p>Sum <span class="math inline">\(a_1 + b_1\)</span>:</p><div class="math display">\[x^2\]</div>`, "Sum $a_1 + b_1$:\n\n$$\nx^2\n$$\n"},
+	}
+	for _, tt := range tests {
+		root, err := html.Parse(strings.NewReader("<div>" + tt.html + "</div>"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		div := findElement(root, func(n *html.Node) bool { return n.Data == "div" })
+		if got := htmlMarkdown(div); got != tt.want {
+			t.Errorf("htmlMarkdown(%q) = %q, want %q", tt.html, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// ## Directories and packages
+//
+// Besides single files, goweave accepts directories: `goweave ./mypkg`
+// processes all .go files in mypkg, and `goweave ./...` processes all .go
+// files in the current directory and below. Like the go tool, goweave skips
+// directories named testdata and directories whose names start with "." or
+// "_". With -skip-tests, it also skips _test.go files, and with -exclude,
+// the files that match one of the given patterns, like `vendor,*_gen.go`.
+// A pattern without a slash applies to the name of the file and to the
+// names of the directories above it; a pattern with a slash applies to the
+// path of the file or of a directory above it, relative to the directory on
+// the command line.
+//
+// The documents of files found this way keep their relative paths under
+// the output directory, so `goweave -outdir=doc ./...` turns `a/b/c.go` into
+// `doc/a/b/c.html`. Files named on the command line go straight into the
+// output directory, as before.
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// outputDirs maps files found in directories to the directory of their
+// documents, relative to the output directory and separated by slashes.
+// expandArgs fills it before any document gets generated; after that, it
+// is only read.
+var outputDirs = map[string]string{}
+
+// expandArgs turns the command line arguments into the list of files to
+// process, walking any directories among them.
+func expandArgs(args []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	add := func(filename string) {
+		if !seen[filename] {
+			seen[filename] = true
+			files = append(files, filename)
+		}
+	}
+	for _, arg := range args {
+		root, recursive := arg, false
+		if dir, ok, err := inputArchive(arg); err != nil {
+			return nil, err
+		} else if ok {
+			root, recursive = dir, true
+		} else if arg == "..." || strings.HasSuffix(arg, "/...") {
+			root, recursive = strings.TrimSuffix(strings.TrimSuffix(arg, "..."), "/"), true
+			if root == "" {
+				root = "."
+			}
+		}
+		dir, err := isSourceDir(root)
+		if err != nil {
+			return nil, err
+		}
+		if !dir {
+			add(arg)
+			continue
+		}
+		found, err := sourceFiles(root, recursive)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range found {
+			if rel, err := filepath.Rel(root, f); err == nil && excluded(filepath.ToSlash(rel)) {
+				continue
+			}
+			outputDirs[f] = outputDir(root, f)
+			add(f)
+		}
+	}
+	return files, nil
+}
+
+// sourceFiles returns the .go files in a directory, and with recursive set,
+// in its subdirectories, in lexical order. With -project-files, it also
+// returns the project files; see project.go.
+func sourceFiles(root string, recursive bool) ([]string, error) {
+	if revTree != nil {
+		return revFiles(root, recursive, isInputFile)
+	}
+	var files []string
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if p != root && (!recursive || skipDir(fi.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isInputFile(p) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// isGoFile returns true for the .go files that sourceFiles collects.
+func isGoFile(name string) bool {
+	return filepath.Ext(name) == ".go" && !(*skipTests && strings.HasSuffix(name, "_test.go"))
+}
+
+// excluded returns true if the slash-separated path matches one of the
+// -exclude patterns.
+func excluded(p string) bool {
+	for _, pattern := range strings.Split(*exclude, ",") {
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if pattern == "" {
+			continue
+		}
+		for q := p; q != "." && q != "/"; q = path.Dir(q) {
+			name := q
+			if !strings.Contains(pattern, "/") {
+				name = path.Base(q)
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// skipDir returns true for the directories that the go tool ignores.
+func skipDir(name string) bool {
+	return name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// outputDir returns the directory of the document of a file found in root.
+// Files below the current directory keep their path relative to it; other
+// files get their path relative to root.
+func outputDir(root, filename string) string {
+	dir := filepath.Dir(filename)
+	if rel, err := filepath.Rel(".", dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(rel)
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// rootPath returns the relative URL from a document back to the output
+// directory, like "../../" for "a/b/c.html", or "" for "c.html".
+func rootPath(outname string) urlPath {
+	return urlPath(strings.Repeat("../", strings.Count(path.Clean(outname), "/")))
+}
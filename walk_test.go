@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandArgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, f := range []string{"a.go", "a_test.go", "notes.txt", "b/c.go", "b/testdata/d.go", "b/.git/e.go", "_f/g.go", "go.mod", "b/Makefile"} {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte("package a\n"), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(dir)
+	defer func(s, p bool) { *skipTests, *projectFiles = s, p }(*skipTests, *projectFiles)
+	defer func(m map[string]string) { outputDirs = m }(outputDirs)
+
+	tests := []struct {
+		args         []string
+		skipTests    bool
+		projectFiles bool
+		want         []string
+		outnames     []string
+	}{
+		{[]string{"./..."}, false, false,
+			[]string{"a.go", "a_test.go", filepath.Join("b", "c.go")},
+			[]string{"a.html", "a_test.html", "b/c.html"}},
+		{[]string{"."}, true, false, []string{"a.go"}, []string{"a.html"}},
+		{[]string{"b", "b/c.go"}, false, false, []string{filepath.Join("b", "c.go")}, []string{"b/c.html"}},
+		{[]string{"./..."}, true, true,
+			[]string{"a.go", filepath.Join("b", "Makefile"), filepath.Join("b", "c.go"), "go.mod"},
+			[]string{"a.html", "b/Makefile.html", "b/c.html", "go.mod.html"}},
+	}
+	for _, tt := range tests {
+		*skipTests, *projectFiles = tt.skipTests, tt.projectFiles
+		outputDirs = map[string]string{}
+		got, err := expandArgs(tt.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandArgs(%v) = %v, want %v", tt.args, got, tt.want)
+			continue
+		}
+		for i, f := range got {
+			if o := outputName(f); o != tt.outnames[i] {
+				t.Errorf("outputName(%q) = %q, want %q", f, o, tt.outnames[i])
+			}
+		}
+	}
+	if _, err := expandArgs([]string{"missing.go"}); err == nil {
+		t.Errorf("expandArgs() accepted a missing file")
+	}
+}
+
+func TestRootPath(t *testing.T) {
+	tests := []struct {
+		outname string
+		want    urlPath
+	}{
+		{"a.html", ""},
+		{"a/b.html", "../"},
+		{"a/b/c.html", "../../"},
+	}
+	for _, tt := range tests {
+		if got := rootPath(tt.outname); got != tt.want {
+			t.Errorf("rootPath(%q) = %q, want %q", tt.outname, got, tt.want)
+		}
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	defer func(e string) { *exclude = e }(*exclude)
+	*exclude = "vendor, *_gen.go,internal/old/"
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{"vendor/x/y.go", true},
+		{"a/vendor/y.go", true},
+		{"parser_gen.go", true},
+		{"internal/old/a.go", true},
+		{"internal/new/a.go", false},
+		{"x/internal/old/a.go", false},
+	}
+	for _, tt := range tests {
+		if got := excluded(tt.path); got != tt.want {
+			t.Errorf("excluded(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// ## Watch mode
+//
+// With -watch, goweave does not exit after generating the documents. Instead,
+// it polls the modification times of all input files and regenerates the
+// affected documents when something changes. To find out which documents are
+// affected, goweave records the input files of each document as it is
+// generated.
+//
+// Changes to the template or the CSS trigger reloading them. If the template
+// is broken, goweave keeps running with the last working version, and
+// replaces the documents with an error page until the template is fixed.
+// The same happens if a document cannot be generated for other reasons.
+// This way, the browser shows the error right where the document would be.
+package main
+
+import (
+	"html/template"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"text/template/parse"
+	"time"
+)
+
+// pollInterval is the time between two checks for changed files.
+const pollInterval = 250 * time.Millisecond
+
+// resourceDeps lists the files that every HTML document depends on: the
+// files in the resource directory, and the files that the template includes
+// through the code function. loadResources sets this up.
+var resourceDeps []string
+
+// depGraph maps each source file to the files its document was generated
+// from, including the source file itself.
+type depGraph struct {
+	mu   sync.Mutex
+	deps map[string][]string
+}
+
+var deps = depGraph{deps: map[string][]string{}}
+
+func (g *depGraph) set(source string, files []string) {
+	g.mu.Lock()
+	g.deps[source] = files
+	g.mu.Unlock()
+}
+
+// files returns all files that any document depends on.
+func (g *depGraph) files() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	seen := map[string]bool{}
+	var files []string
+	for _, fs := range g.deps {
+		for _, f := range fs {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
+
+// sources returns all source files, in sorted order.
+func (g *depGraph) sources() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	sources := make([]string, 0, len(g.deps))
+	for source := range g.deps {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// affected returns the source files whose documents depend on any of the
+// changed files, in sorted order.
+func (g *depGraph) affected(changed map[string]bool) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var sources []string
+	for source, fs := range g.deps {
+		for _, f := range fs {
+			if changed[f] {
+				sources = append(sources, source)
+				break
+			}
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// isResource returns true if the file is one of the resource files, which
+// need to be reloaded before regenerating any document.
+func isResource(filename string) bool {
+	for _, r := range resourceDeps {
+		if r == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// modTimes returns the modification time of each file. Files that cannot be
+// accessed (for example, because an editor is just replacing them) get the
+// zero time.
+func modTimes(files []string) map[string]time.Time {
+	times := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			times[f] = fi.ModTime()
+		} else {
+			times[f] = time.Time{}
+		}
+	}
+	return times
+}
+
+// watchFiles polls the input files of all documents forever and regenerates
+// documents as their inputs change.
+func watchFiles(filenames []string) {
+	log.Printf("Watching %d file(s) for changes.", len(filenames))
+	last := modTimes(deps.files())
+	for {
+		time.Sleep(pollInterval)
+		now := modTimes(deps.files())
+		changed := map[string]bool{}
+		reload := false
+		for f, t := range now {
+			if !t.Equal(last[f]) {
+				changed[f] = true
+				reload = reload || isResource(f)
+			}
+		}
+		last = now
+		if len(changed) == 0 {
+			continue
+		}
+		if reload {
+			if err := loadResources(resourcedir); err != nil {
+				log.Print(err)
+				showError(deps.sources(), err)
+				reloads.notify()
+				continue
+			}
+			resetAssets() // copy the changed CSS file again
+		}
+		sources := deps.affected(changed)
+		log.Printf("Regenerating %d document(s).", len(sources))
+		processFiles(sources) // failed documents show the error page
+		if err := finishBuild(); err != nil {
+			log.Print(err)
+		}
+		reloads.notify()
+	}
+}
+
+// templateCodeFiles returns the files that the template includes by calling
+// the code function with a literal file name, as in {{code "file.go" 1 10}}.
+func templateCodeFiles(t *template.Template) []string {
+	var files []string
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Cmds {
+				walk(c)
+			}
+		case *parse.CommandNode:
+			if len(n.Args) > 1 {
+				id, isIdent := n.Args[0].(*parse.IdentifierNode)
+				name, isString := n.Args[1].(*parse.StringNode)
+				if isIdent && isString && id.Ident == "code" && !isRemote(name.Text) {
+					files = append(files, name.Text)
+				}
+			}
+			for _, a := range n.Args {
+				walk(a)
+			}
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	for _, tt := range t.Templates() {
+		if tt.Tree != nil {
+			walk(tt.Tree.Root)
+		}
+	}
+	return files
+}
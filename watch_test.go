@@ -0,0 +1,79 @@
+package main
+
+import (
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/christophberger/goweave/weave"
+)
+
+func TestTemplateCodeFiles(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{`{{code "a.go" 1 2}}{{if .Full}}{{code "b.go" 3 0 | printf "%s"}}{{end}}`, []string{"a.go", "b.go"}},
+		{`{{define "x"}}{{range .Sections}}{{code "c.go" 1 1}}{{end}}{{end}}`, []string{"c.go"}},
+		{`{{.Title}}`, nil},
+	}
+	for _, tt := range tests {
+		tpl := template.Must(template.New("t").Funcs(templateFuncs).Parse(tt.text))
+		if got := templateCodeFiles(tpl); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("templateCodeFiles(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDepGraphAffected(t *testing.T) {
+	g := depGraph{deps: map[string][]string{
+		"a.go": {"a.go", "goweave.templ"},
+		"b.go": {"b.go", "goweave.templ", "snippet.go"},
+	}}
+	tests := []struct {
+		changed map[string]bool
+		want    []string
+	}{
+		{map[string]bool{"a.go": true}, []string{"a.go"}},
+		{map[string]bool{"snippet.go": true}, []string{"b.go"}},
+		{map[string]bool{"goweave.templ": true}, []string{"a.go", "b.go"}},
+		{map[string]bool{"other.go": true}, nil},
+	}
+	for _, tt := range tests {
+		if got := g.affected(tt.changed); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("affected(%v) = %v, want %v", tt.changed, got, tt.want)
+		}
+	}
+}
+
+func TestProcessFileDeps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(o weave.OutputFS, d string, m, w bool) { output, *outdir, *md, *watch = o, d, m, w }(output, *outdir, *md, *watch)
+	output, *outdir, *md, *watch = nil, filepath.Join(dir, "out"), true, true
+	filename := filepath.Join(dir, "a.go")
+	translation := filename + ".de.md"
+	ioutil.WriteFile(filename, []byte("// Text\npackage main\n"), 0644)
+	ioutil.WriteFile(translation, []byte("<!-- goweave:title A -->\n"), 0644)
+	if err := processFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	deps.mu.Lock()
+	got := deps.deps[filename]
+	deps.mu.Unlock()
+	for _, want := range []string{filename, sidecarFile(filename), translation} {
+		found := false
+		for _, f := range got {
+			found = found || f == want
+		}
+		if !found {
+			t.Errorf("the dependencies of %s are %q, without %s", filename, got, want)
+		}
+	}
+}
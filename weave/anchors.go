@@ -0,0 +1,41 @@
+package weave
+
+import "strconv"
+
+// Content hashes make stable IDs for tools, but poor links for readers.
+// For links that people share, each section also gets an anchor made of
+// the ID of the nearest heading at or above the section and the number of
+// the section below that heading: the section with `## The main loop` is
+// "the-main-loop.1", the next one "the-main-loop.2". Sections before the
+// first heading are "section.1", "section.2", and so on. Heading IDs
+// contain no dots, so the anchors cannot clash with them; the IDs of
+// duplicate headings already have numbers appended.
+//
+// An anchor changes only if a heading above the section changes, or if
+// sections get added or removed below the same heading.
+
+// assignAnchors sets the Anchor of each section that has none.
+func assignAnchors(sections []*Section) {
+	used := map[string]bool{}
+	for _, s := range sections {
+		if s.Anchor != "" {
+			used[s.Anchor] = true
+		}
+	}
+	heading, n := "section", 0
+	for i, hs := range sectionHeadings(sections) {
+		if len(hs) > 0 && hs[len(hs)-1].ID != "" {
+			heading, n = hs[len(hs)-1].ID, 0
+		}
+		n++
+		if sections[i].Anchor != "" {
+			continue
+		}
+		anchor := heading + "." + strconv.Itoa(n)
+		for k := 2; used[anchor]; k++ {
+			anchor = heading + "." + strconv.Itoa(n) + "-" + strconv.Itoa(k)
+		}
+		used[anchor] = true
+		sections[i].Anchor = anchor
+	}
+}
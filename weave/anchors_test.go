@@ -0,0 +1,39 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssignAnchors(t *testing.T) {
+	sections := []*Section{
+		{Doc: "Intro\n"},
+		{Code: "package p\n"},
+		{Doc: "## The main loop\n", Code: "for {}\n"},
+		{Doc: "More\n", Code: "x++\n"},
+		{Doc: "```\n# not a heading\n```\n"},
+		{Doc: "## Setup\n"},
+		{Doc: "## Setup\n", Anchor: "setup-1.1"},
+		{Doc: "Text\n"},
+	}
+	AssignIDs(sections)
+	want := []string{"section.1", "section.2", "the-main-loop.1", "the-main-loop.2", "the-main-loop.3", "setup.1", "setup-1.1", "setup-1.2"}
+	for i, s := range sections {
+		if s.Anchor != want[i] {
+			t.Errorf("section %d: Anchor = %q, want %q", i, s.Anchor, want[i])
+		}
+	}
+}
+
+func TestPermalinks(t *testing.T) {
+	for _, on := range []bool{false, true} {
+		got, err := New(Options{Permalinks: on}).Render("// ## Start\npackage main\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		link := `<a class="permalink" id="start.1" href="#start.1"`
+		if strings.Contains(got, link) != on {
+			t.Errorf("Render() with Permalinks = %v: %s", on, got)
+		}
+	}
+}
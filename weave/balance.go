@@ -0,0 +1,249 @@
+package weave
+
+import "strings"
+
+// Side by side, a section whose code runs on for screens next to a short
+// comment, or the other way round, leaves one column blank for as long.
+// Options.Balance splits such sections into several, of about Balance lines
+// each. The longer side breaks at blank lines, preferably before
+// unindented lines, such as the next declaration or paragraph, and never
+// within a fenced block of a comment. The paragraphs and blocks of the
+// shorter side go with the piece that they face in the source. A piece
+// without prose of its own gets the marker Continued, so that the reader
+// knows that the code goes on from the row above.
+
+// Continued is the prose of the pieces of a balanced section that have no
+// comment of their own.
+const Continued = "*(continued)*\n"
+
+// sectionLines are the source lines of the lines of a section's comment
+// and code, counting from 1, with 0 for lines that are not in the source.
+type sectionLines struct {
+	doc, code []int
+}
+
+// balance splits the sections that are out of balance by more than max
+// lines, and numbers the positions of the sections anew.
+func balance(sections []*Section, lines map[*Section]*sectionLines, source string, max int) []*Section {
+	starts := lineStarts(source)
+	var out []*Section
+	for _, s := range sections {
+		out = append(out, balanceSection(s, lines[s], starts, len(source), max)...)
+	}
+	for i, s := range InSourceOrder(out) {
+		s.Position = i
+	}
+	return out
+}
+
+// lineStarts returns the offsets of the lines of the source.
+func lineStarts(source string) []int {
+	starts := []int{0}
+	for i, c := range source {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// balanceSection splits a section if it is out of balance.
+func balanceSection(s *Section, nums *sectionLines, starts []int, size, max int) []*Section {
+	doc, code := splitLines(s.Doc), splitLines(s.Code)
+	docLen, codeLen := contentLen(doc), contentLen(code)
+	if nums == nil || abs(docLen-codeLen) <= max {
+		return []*Section{s}
+	}
+	docNums := padLines(nums.doc, len(doc))
+	codeNums := padLines(nums.code, len(code))
+	codeLong := codeLen > docLen
+	long, short, longLen := code, doc, codeLen
+	if !codeLong {
+		long, short, longLen = doc, code, docLen
+	}
+	cuts := cutPoints(long, longLen, codeLong, max)
+	if len(cuts) == 0 {
+		return []*Section{s}
+	}
+	// The units of the shorter side go to the piece that they face.
+	shortUnits := units(short, !codeLong)
+	shortCuts := make([]int, len(cuts))
+	for i, c := range cuts {
+		shortCuts[i] = len(short)
+		for _, u := range shortUnits {
+			if u >= c {
+				shortCuts[i] = u
+				break
+			}
+		}
+	}
+	docCuts, codeCuts := shortCuts, cuts
+	if !codeLong {
+		docCuts, codeCuts = cuts, shortCuts
+	}
+	var pieces []*Section
+	for i := 0; i <= len(cuts); i++ {
+		p := *s
+		d0, d1 := piece(docCuts, i, len(doc))
+		c0, c1 := piece(codeCuts, i, len(code))
+		p.Doc = strings.Join(doc[d0:d1], "")
+		p.Code = strings.Join(code[c0:c1], "")
+		p.DocStart, p.DocEnd, p.DocOffset, p.DocEndOffset = lineSpan(docNums[d0:d1], starts, size)
+		p.CodeStart, p.CodeEnd, p.CodeOffset, p.CodeEndOffset = lineSpan(codeNums[c0:c1], starts, size)
+		p.SkippedLines = nil
+		for _, l := range s.SkippedLines {
+			if l > p.CodeStart && l < p.CodeEnd {
+				p.SkippedLines = append(p.SkippedLines, l)
+			}
+		}
+		if i > 0 {
+			p.Caption = nil
+			if strings.TrimSpace(p.Doc) == "" && strings.TrimSpace(p.Code) != "" {
+				p.Doc = Continued
+			}
+		}
+		pieces = append(pieces, &p)
+	}
+	return pieces
+}
+
+// splitLines splits text into lines, each with its newline.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// contentLen returns the number of lines up to the last non-blank one.
+func contentLen(lines []string) int {
+	n := len(lines)
+	for n > 0 && strings.TrimSpace(lines[n-1]) == "" {
+		n--
+	}
+	return n
+}
+
+// padLines returns the line numbers of n lines. Lines before the recorded
+// ones, like the headings of Options.ByDecl, are not in the source.
+func padLines(nums []int, n int) []int {
+	if len(nums) >= n {
+		return nums[len(nums)-n:]
+	}
+	return append(make([]int, n-len(nums)), nums...)
+}
+
+// cutPoints returns the indexes of the lines where the pieces of the
+// longer side of a section start, apart from the first, so that no piece
+// runs on for much more than max lines.
+func cutPoints(lines []string, length int, code bool, max int) []int {
+	starts := units(lines, code)
+	var cuts []int
+	prev := 0
+	for length-prev > max {
+		c := bestBreak(lines, starts, prev, prev+max, code)
+		if c <= prev {
+			break
+		}
+		cuts = append(cuts, c)
+		prev = c
+	}
+	return cuts
+}
+
+// bestBreak returns the line that starts the piece after the one that
+// starts at after, at the latest at limit: the last unit that starts in the
+// second half of the piece, unindented if possible. Without one, code
+// breaks at limit, and prose at the next paragraph, if any, or not at all,
+// which bestBreak tells by returning after.
+func bestBreak(lines []string, starts []int, after, limit int, code bool) int {
+	for _, indented := range []bool{false, true} {
+		best := 0
+		for _, u := range starts {
+			if u > after+(limit-after)/2 && u <= limit && (indented || !isIndented(lines[u])) {
+				best = u
+			}
+		}
+		if best > 0 {
+			return best
+		}
+	}
+	if code {
+		return limit
+	}
+	for _, u := range starts {
+		if u > after {
+			return u
+		}
+	}
+	return after
+}
+
+// units returns the indexes of the lines that follow a blank line and
+// start a block of code, or an unindented paragraph of prose outside of
+// fenced blocks.
+func units(lines []string, code bool) []int {
+	var starts []int
+	fenced := false
+	for i, line := range lines {
+		t := strings.TrimSpace(line)
+		if i > 0 && !fenced && t != "" && strings.TrimSpace(lines[i-1]) == "" && (code || !isIndented(line)) {
+			starts = append(starts, i)
+		}
+		if !code && (strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~")) {
+			fenced = !fenced
+		}
+	}
+	return starts
+}
+
+// isIndented reports whether a line starts with whitespace.
+func isIndented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// piece returns the range of the lines of the i-th piece.
+func piece(cuts []int, i, n int) (int, int) {
+	from, to := 0, n
+	if i > 0 {
+		from = cuts[i-1]
+	}
+	if i < len(cuts) {
+		to = cuts[i]
+	}
+	if from > to {
+		from = to
+	}
+	return from, to
+}
+
+// lineSpan returns the first and the last source line of the lines of a
+// piece, and their offsets, or zeros if none is in the source.
+func lineSpan(nums []int, starts []int, size int) (start, end, offset, endOffset int) {
+	for _, n := range nums {
+		if n == 0 {
+			continue
+		}
+		if start == 0 {
+			start = n
+		}
+		end = n
+	}
+	if start == 0 {
+		return 0, 0, 0, 0
+	}
+	offset, endOffset = starts[start-1], size
+	if end < len(starts) {
+		endOffset = starts[end]
+	}
+	return start, end, offset, endOffset
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,60 @@
+package weave
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBalance(t *testing.T) {
+	// A short comment and three functions of seven lines each.
+	var b strings.Builder
+	b.WriteString("package p\n\n// The functions.\n")
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(&b, "func f%d() {\n\ta()\n\n\tb()\n\tc()\n\td()\n}\n\n", i)
+	}
+	source := b.String()
+	sections := New(Options{Balance: 8}).Sections(source)
+	if len(sections) != 4 {
+		t.Fatalf("Sections() = %d sections, want 4", len(sections))
+	}
+	for i, want := range []struct {
+		doc, code          string
+		codeStart, codeEnd int
+	}{
+		{"The functions.", "func f0()", 4, 11},
+		{strings.TrimSpace(Continued), "func f1()", 12, 19},
+		{strings.TrimSpace(Continued), "func f2()", 20, 27},
+	} {
+		s := sections[i+1]
+		if strings.TrimSpace(s.Doc) != want.doc || !strings.HasPrefix(s.Code, want.code) || s.CodeStart != want.codeStart || s.CodeEnd != want.codeEnd {
+			t.Errorf("piece %d = %q, %q, lines %d-%d; want %q, %q..., lines %d-%d",
+				i, s.Doc, s.Code, s.CodeStart, s.CodeEnd, want.doc, want.code, want.codeStart, want.codeEnd)
+		}
+		if !strings.HasPrefix(s.Code, source[s.CodeOffset:s.CodeEndOffset]) {
+			t.Errorf("piece %d: the offsets span %q, not the code %q", i, source[s.CodeOffset:s.CodeEndOffset], s.Code)
+		}
+		if s.Position != i+1 {
+			t.Errorf("piece %d has the position %d", i, s.Position)
+		}
+	}
+	if sections[2].Caption != nil || sections[1].DocStart != 3 || sections[2].DocStart != 0 {
+		t.Errorf("the continued pieces have a comment of their own: %+v", sections[2])
+	}
+
+	// Long prose breaks between paragraphs, but not within a fenced block.
+	prose := "// One.\n//\n// ```\n// x\n//\n// y\n// ```\n//\n// Two.\n//\n// Three.\n//\n// Four.\nfunc f() {}\n"
+	sections = New(Options{Balance: 4}).Sections(prose)
+	var docs []string
+	for _, s := range sections {
+		docs = append(docs, strings.TrimSpace(s.Doc))
+	}
+	if len(docs) != 4 || docs[1] != "```\nx\n\ny\n```" || sections[0].Code == "" || sections[3].Code != "" {
+		t.Errorf("Sections() of long prose = %q", docs)
+	}
+
+	// Balanced sections stay as they are.
+	if got := len(New(Options{Balance: 40}).Sections(source)); got != 2 {
+		t.Errorf("Sections() with a large Balance = %d sections, want 2", got)
+	}
+}
@@ -0,0 +1,62 @@
+package weave
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Caption is a numbered caption below a code block, as set through a
+// pragma right before the code:
+//
+//	//goweave:caption Listing: parsing loop
+//
+// The word before the colon is the kind of the caption; each kind gets
+// numbered on its own, so a document can have Listing 1 and Figure 1. A
+// caption without a kind is a listing.
+type Caption struct {
+	Kind   string
+	Number int // assigned by NumberCaptions
+	Text   string
+}
+
+// defaultCaptionKind is the kind of captions that do not name one.
+const defaultCaptionKind = "Listing"
+
+// parseCaption splits the value of a caption pragma into kind and text.
+func parseCaption(v string) *Caption {
+	if i := strings.Index(v, ":"); i > 0 {
+		kind := strings.TrimSpace(v[:i])
+		if kind != "" && strings.IndexFunc(kind, unicode.IsSpace) < 0 {
+			return &Caption{Kind: kind, Text: strings.TrimSpace(v[i+1:])}
+		}
+	}
+	return &Caption{Kind: defaultCaptionKind, Text: strings.TrimSpace(v)}
+}
+
+// Label returns the kind and number of the caption, as in "Listing 4".
+func (c *Caption) Label() string {
+	return c.Kind + " " + strconv.Itoa(c.Number)
+}
+
+// ID returns the ID of the caption's element, as in "listing-4", for
+// linking to the code block.
+func (c *Caption) ID() string {
+	return strings.ToLower(c.Kind) + "-" + strconv.Itoa(c.Number)
+}
+
+// NumberCaptions numbers the captions of the sections in order, per kind,
+// and returns them as the list of listings.
+func NumberCaptions(sections []*Section) []*Caption {
+	var captions []*Caption
+	count := map[string]int{}
+	for _, s := range sections {
+		if s.Caption == nil {
+			continue
+		}
+		count[s.Caption.Kind]++
+		s.Caption.Number = count[s.Caption.Kind]
+		captions = append(captions, s.Caption)
+	}
+	return captions
+}
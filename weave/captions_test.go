@@ -0,0 +1,69 @@
+package weave
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCaption(t *testing.T) {
+	tests := []struct {
+		value string
+		want  Caption
+	}{
+		{"Listing: parsing loop", Caption{Kind: "Listing", Text: "parsing loop"}},
+		{"Figure:the flow", Caption{Kind: "Figure", Text: "the flow"}},
+		{"parsing loop", Caption{Kind: "Listing", Text: "parsing loop"}},
+		{"Note this: a colon", Caption{Kind: "Listing", Text: "Note this: a colon"}},
+	}
+	for _, tt := range tests {
+		if got := parseCaption(tt.value); *got != tt.want {
+			t.Errorf("parseCaption(%q) = %+v, want %+v", tt.value, *got, tt.want)
+		}
+	}
+}
+
+func TestCaptions(t *testing.T) {
+	src := `// Doc
+//goweave:caption Listing: first
+a := 1
+// More
+b := 2
+//goweave:caption Figure: not a listing
+
+c := 3
+// Last
+//goweave:caption Listing: second
+d := 4
+`
+	sections := New(Options{}).Sections(src)
+	captions := NumberCaptions(sections)
+	var got []string
+	for _, s := range sections {
+		if s.Caption != nil {
+			got = append(got, s.Caption.ID())
+		} else {
+			got = append(got, "")
+		}
+	}
+	// "b := 2" and "c := 3" share a section, so the figure caption goes to
+	// that section even though it follows some of its code.
+	want := []string{"listing-1", "figure-1", "listing-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("caption IDs = %v, want %v", got, want)
+	}
+	if len(captions) != 3 || captions[2].Label() != "Listing 2" {
+		t.Errorf("NumberCaptions() returned %d captions, want 3 with Listing 2 last", len(captions))
+	}
+
+	html, err := New(Options{}).Render(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, `<div class="caption" id="listing-2"><span class="label">Listing 2:</span> second</div>`) {
+		t.Errorf("Render() = %s, want a caption for Listing 2", html)
+	}
+	if !strings.Contains(html, `<li><a href="#listing-2">Listing 2</a>: second</li>`) {
+		t.Errorf("Render() = %s, want Listing 2 in the list of listings", html)
+	}
+}
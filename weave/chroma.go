@@ -0,0 +1,105 @@
+package weave
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+)
+
+// Chroma is a highlighter based on the Chroma library, which tokenizes
+// more accurately than litebrite and comes with many color styles. It
+// wraps the tokens in elements with Chroma's short class names, like "kd"
+// for keywords that start a declaration; CSS returns the matching rules.
+type Chroma struct {
+	lexer chroma.Lexer
+	style *chroma.Style
+}
+
+// NewChroma returns a Chroma highlighter for Go with the named color
+// style, like "github" or "monokai".
+func NewChroma(style string) (*Chroma, error) {
+	s, ok := styles.Registry[style]
+	if !ok {
+		return nil, fmt.Errorf("unknown Chroma style %q (available: %s)", style, strings.Join(styles.Names(), ", "))
+	}
+	return &Chroma{lexer: chroma.Coalesce(lexers.Get("go")), style: s}, nil
+}
+
+// ForLanguage implements LanguageHighlighter. Languages unknown to Chroma
+// stay plain, except for go.mod and go.sum files.
+func (c *Chroma) ForLanguage(name string) Highlighter {
+	if name == GoMod.Name || name == GoSum.Name {
+		return goModChroma
+	}
+	l := lexers.Get(name)
+	if l == nil {
+		l = lexers.Fallback
+	}
+	return &Chroma{lexer: chroma.Coalesce(l), style: c.style}
+}
+
+// Highlight implements Highlighter.
+func (c *Chroma) Highlight(code string) string {
+	it, err := c.lexer.Tokenise(nil, code)
+	if err != nil {
+		return html.EscapeString(code)
+	}
+	var b strings.Builder
+	for _, t := range it.Tokens() {
+		text := html.EscapeString(t.Value)
+		if class := tokenClass(t.Type); class != "" {
+			b.WriteString(`<span class="` + class + `">` + text + "</span>")
+		} else {
+			b.WriteString(text)
+		}
+	}
+	out := b.String()
+	// The lexer adds a final newline if the code has none.
+	if !strings.HasSuffix(code, "\n") {
+		if i := strings.LastIndex(out, "\n"); i >= 0 {
+			out = out[:i] + out[i+1:]
+		}
+	}
+	return out
+}
+
+// tokenClass returns the class of a token type, or the class of the
+// nearest more general type that has one.
+func tokenClass(t chroma.TokenType) string {
+	for ; t != 0; t = t.Parent() {
+		if class, ok := chroma.StandardTypes[t]; ok {
+			return class
+		}
+	}
+	return chroma.StandardTypes[t]
+}
+
+// CSS returns the rules of the color style for code within the elements
+// that scope selects, as in `#goweave .code`.
+func (c *Chroma) CSS(scope string) string {
+	var b strings.Builder
+	bg := c.style.Get(chroma.Background)
+	fmt.Fprintf(&b, "%s { %s }\n", scope, chromahtml.StyleEntryToCSS(bg))
+	var types []int
+	for t := range chroma.StandardTypes {
+		if t > 0 { // the negative types are for Chroma's own HTML wrappers
+			types = append(types, int(t))
+		}
+	}
+	sort.Ints(types)
+	for _, t := range types {
+		tt := chroma.TokenType(t)
+		entry := c.style.Get(tt).Sub(bg)
+		if entry.IsZero() || chroma.StandardTypes[tt] == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s .%s { %s }\n", scope, chroma.StandardTypes[tt], chromahtml.StyleEntryToCSS(entry))
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChroma(t *testing.T) {
+	if _, err := NewChroma("no-such-style"); err == nil {
+		t.Error("NewChroma() accepted an unknown style")
+	}
+	c, err := NewChroma("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		code, want string
+	}{
+		{"func f() {}\n", `<span class="kd">func</span> <span class="nf">f</span><span class="p">()</span> <span class="p">{}</span>` + "\n"},
+		{`s := "<b>"`, `<span class="nx">s</span> <span class="o">:=</span> <span class="s">&#34;&lt;b&gt;&#34;</span>`},
+	}
+	for _, tt := range tests {
+		if got := c.Highlight(tt.code); got != tt.want {
+			t.Errorf("Highlight(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+	css := c.CSS("#goweave .code")
+	for _, want := range []string{"#goweave .code { background-color: ", "#goweave .code .kd { "} {
+		if !strings.Contains(css, want) {
+			t.Errorf("CSS() = %s, want it to contain %q", css, want)
+		}
+	}
+	if got := New(Options{Highlighter: c}).Highlight("\n  \n"); got != "" {
+		t.Errorf("Highlight() of empty code = %q, want nothing", got)
+	}
+}
@@ -0,0 +1,102 @@
+package weave
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Sites that already ship the stylesheet of a highlighter, like that of
+// highlight.js or Prism, can color woven code with it if the tokens carry
+// the classes that the stylesheet expects: "hljs-keyword" rather than
+// "keyword" or "kd", or "token keyword". Options.TokenClasses renames the
+// classes that the highlighter writes; HighlightJSClasses and PrismClasses
+// do it for both litebrite and Chroma. Classes without a new name stay as
+// they are.
+
+// HighlightJSClasses map the classes of litebrite and Chroma to those of
+// highlight.js.
+var HighlightJSClasses = map[string]string{
+	// litebrite
+	"keyword": "hljs-keyword", "literal": "hljs-string", "comment": "hljs-comment",
+	"operator": "hljs-operator", "ident": "",
+	// Chroma
+	"k": "hljs-keyword", "kd": "hljs-keyword", "kn": "hljs-keyword", "kp": "hljs-keyword",
+	"kr": "hljs-keyword", "kt": "hljs-type", "kc": "hljs-literal", "nb": "hljs-built_in",
+	"nf": "hljs-title function_", "fm": "hljs-title function_",
+	"s": "hljs-string", "s1": "hljs-string", "s2": "hljs-string", "sa": "hljs-string",
+	"sb": "hljs-string", "sc": "hljs-string", "sd": "hljs-string", "dl": "hljs-string",
+	"se": "hljs-char escape_", "si": "hljs-subst", "sr": "hljs-regexp",
+	"m": "hljs-number", "mb": "hljs-number", "mf": "hljs-number", "mh": "hljs-number",
+	"mi": "hljs-number", "il": "hljs-number", "mo": "hljs-number",
+	"c": "hljs-comment", "c1": "hljs-comment", "cm": "hljs-comment", "ch": "hljs-comment",
+	"cs": "hljs-comment", "cp": "hljs-meta", "cpf": "hljs-meta",
+	"o": "hljs-operator", "ow": "hljs-operator", "p": "hljs-punctuation",
+}
+
+// PrismClasses map the classes of litebrite and Chroma to those of Prism.
+var PrismClasses = map[string]string{
+	// litebrite
+	"keyword": "token keyword", "literal": "token string", "comment": "token comment",
+	"operator": "token operator", "ident": "",
+	// Chroma
+	"k": "token keyword", "kd": "token keyword", "kn": "token keyword", "kp": "token keyword",
+	"kr": "token keyword", "kt": "token builtin", "kc": "token boolean", "nb": "token builtin",
+	"nf": "token function", "fm": "token function",
+	"s": "token string", "s1": "token string", "s2": "token string", "sa": "token string",
+	"sb": "token string", "sc": "token char", "sd": "token string", "dl": "token string",
+	"se": "token string", "si": "token string", "sr": "token regex",
+	"m": "token number", "mb": "token number", "mf": "token number", "mh": "token number",
+	"mi": "token number", "il": "token number", "mo": "token number",
+	"c": "token comment", "c1": "token comment", "cm": "token comment", "ch": "token comment",
+	"cs": "token comment", "cp": "token directive", "cpf": "token directive",
+	"o": "token operator", "ow": "token operator", "p": "token punctuation",
+}
+
+// spanClass matches the elements that highlighters wrap tokens in.
+var spanClass = regexp.MustCompile(`<span class="([^"]*)">`)
+
+// classHighlighter renames the classes of the tokens of a highlighter.
+type classHighlighter struct {
+	h       Highlighter
+	classes map[string]string
+}
+
+func (c classHighlighter) Highlight(code string) string {
+	return spanClass.ReplaceAllStringFunc(c.h.Highlight(code), func(span string) string {
+		class := c.rename(spanClass.FindStringSubmatch(span)[1])
+		if class == "" {
+			return "<span>"
+		}
+		return `<span class="` + html.EscapeString(class) + `">`
+	})
+}
+
+// HighlightLines implements LineHighlighter, so that the tokens of a
+// highlighter keep coming from its lexer when their classes get new names.
+func (c classHighlighter) HighlightLines(code, lang string) ([]Line, error) {
+	lines, err := HighlightLines(c.h, code, lang)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		for i := range l.Tokens {
+			l.Tokens[i].Class = c.rename(l.Tokens[i].Class)
+		}
+	}
+	return lines, nil
+}
+
+// rename renames each class of a class attribute.
+func (c classHighlighter) rename(class string) string {
+	var names []string
+	for _, name := range strings.Fields(class) {
+		if n, ok := c.classes[name]; ok {
+			name = n
+		}
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,55 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenClasses(t *testing.T) {
+	chroma, err := NewChroma("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		h       Highlighter
+		classes map[string]string
+		want    string
+	}{
+		{Litebrite, map[string]string{"keyword": "kw"},
+			`<span class="kw">return</span> <span class="ident">x</span>`},
+		{Litebrite, HighlightJSClasses,
+			`<span class="hljs-keyword">return</span> <span>x</span>`},
+		{Litebrite, PrismClasses,
+			`<span class="token keyword">return</span> <span>x</span>`},
+		{chroma, PrismClasses,
+			`<span class="token keyword">return</span> <span class="nx">x</span>`},
+	}
+	for _, tt := range tests {
+		w := New(Options{Highlighter: tt.h, TokenClasses: tt.classes})
+		if got := w.Highlight("return x"); got != tt.want {
+			t.Errorf("Highlight() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestTokenClassesLines(t *testing.T) {
+	chroma, err := NewChroma("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := classHighlighter{chroma, PrismClasses}
+	if _, ok := Highlighter(h).(LineHighlighter); !ok {
+		t.Fatal("classHighlighter is no LineHighlighter")
+	}
+	lines, err := HighlightLines(h, "return x", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tok := range lines[0].Tokens {
+		got = append(got, tok.Class+":"+tok.Text)
+	}
+	if want := "token keyword:return :  nx:x"; strings.Join(got, " ") != want {
+		t.Errorf("HighlightLines() = %q, want %q", strings.Join(got, " "), want)
+	}
+}
@@ -0,0 +1,180 @@
+package weave
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Sections go by the position of the comments, so a function without a
+// doc comment ends up in the section of the function before it, and a
+// comment inside a function body starts a new section. With
+// Options.ByDecl, Go source gets one section per top-level declaration
+// instead: a func, a type, or a const or var block, together with its doc
+// comment and all of its body. Each section starts with a heading made
+// from the names that it declares, like "func (*Weaver) Render", and the
+// sections come in the order of godoc, which go/doc knows: constants,
+// variables, functions, then the types, each with its constants,
+// variables, constructors, and methods, one heading level down. The
+// package clause and the imports stay at the top, and a comment that
+// belongs to no declaration moves along with the declaration after it.
+
+// decl is a top-level declaration of Go source.
+type decl struct {
+	start, end int    // the first and the last line, counting from 0
+	heading    string // like "func (*Weaver) Render"
+	level      int    // the heading level: 2, or 3 for what go/doc puts under a type
+	rank       int    // the place in the order of go/doc
+}
+
+// goDecls returns the top-level declarations of Go source except the
+// imports, in source order. ok is false if the source does not parse.
+func goDecls(source string) (decls []*decl, ok bool) {
+	fset := token.NewFileSet()
+	// go/doc wants the name of a Go file.
+	f, err := parser.ParseFile(fset, "source.go", source, parser.ParseComments)
+	if err != nil {
+		return nil, false
+	}
+	var nodes []ast.Decl
+	for _, d := range f.Decls {
+		if g, ok := d.(*ast.GenDecl); ok && g.Tok == token.IMPORT {
+			continue
+		}
+		nodes = append(nodes, d)
+		decls = append(decls, &decl{
+			start:   fset.Position(d.Pos()).Line - 1,
+			end:     fset.Position(d.End()).Line - 1,
+			heading: declHeading(d),
+			level:   2,
+			rank:    -1,
+		})
+	}
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "", doc.AllDecls|doc.PreserveAST)
+	if err != nil {
+		return decls, true // no godoc order, but still one section per declaration
+	}
+	rank := 0
+	// add ranks the declaration that contains a node of go/doc, which may
+	// be a single type out of a type block.
+	add := func(n ast.Node, level int) {
+		for i, d := range nodes {
+			if d.Pos() <= n.Pos() && n.Pos() < d.End() && decls[i].rank < 0 {
+				decls[i].rank, decls[i].level = rank, level
+				rank++
+			}
+		}
+	}
+	addValues := func(values []*doc.Value, level int) {
+		for _, v := range values {
+			add(v.Decl, level)
+		}
+	}
+	addFuncs := func(funcs []*doc.Func, level int) {
+		for _, f := range funcs {
+			add(f.Decl, level)
+		}
+	}
+	addValues(pkg.Consts, 2)
+	addValues(pkg.Vars, 2)
+	addFuncs(pkg.Funcs, 2)
+	for _, t := range pkg.Types {
+		add(t.Decl, 2)
+		addValues(t.Consts, 3)
+		addValues(t.Vars, 3)
+		addFuncs(t.Funcs, 3)
+		addFuncs(t.Methods, 3)
+	}
+	// go/doc leaves out init functions, blank identifiers, and the
+	// constructors and methods of types of other files. They come last.
+	for _, d := range decls {
+		if d.rank < 0 {
+			d.rank = rank
+			rank++
+		}
+	}
+	return decls, true
+}
+
+// declHeading returns the heading of a declaration, like "func New",
+// "func (*Weaver) Render", "type Section", or "const Go, GoMod".
+func declHeading(d ast.Decl) string {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if d.Recv == nil || len(d.Recv.List) == 0 {
+			return "func " + d.Name.Name
+		}
+		return "func (" + recvName(d.Recv.List[0].Type) + ") " + d.Name.Name
+	case *ast.GenDecl:
+		var names []string
+		for _, s := range d.Specs {
+			switch s := s.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					names = append(names, n.Name)
+				}
+			}
+		}
+		const maxNames = 4
+		if len(names) > maxNames {
+			names = append(names[:maxNames-1], "…")
+		}
+		return d.Tok.String() + " " + strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// recvName returns the type of a receiver without its type parameters,
+// like "*Map" for `(m *Map[K, V])`.
+func recvName(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.StarExpr:
+		return "*" + recvName(x.X)
+	case *ast.ParenExpr:
+		return recvName(x.X)
+	case *ast.IndexExpr:
+		return recvName(x.X)
+	case *ast.IndexListExpr:
+		return recvName(x.X)
+	case *ast.Ident:
+		return x.Name
+	}
+	return "?"
+}
+
+// markdownEscaper escapes the characters of Go names that Markdown would
+// take for emphasis or links.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`)
+
+// declOrder adds the headings of the declarations to the sections, and,
+// unless sourceOrder is set, sorts the sections into the order of go/doc.
+// decls holds the declaration of each section, or nil for sections without
+// one, which stay at the top if they come before the first declaration and
+// move along with the next declaration otherwise.
+func declOrder(sections []*Section, decls []*decl, sourceOrder bool) {
+	for i, s := range sections {
+		if d := decls[i]; d != nil {
+			s.Doc = strings.Repeat("#", d.level) + " " + markdownEscaper.Replace(d.heading) + "\n\n" + s.Doc
+		}
+	}
+	if sourceOrder {
+		return
+	}
+	ranks := make(map[*Section]int, len(sections))
+	next := len(sections) // the rank of the sections after the last declaration
+	for i := len(sections) - 1; i >= 0; i-- {
+		if d := decls[i]; d != nil {
+			next = d.rank
+		}
+		ranks[sections[i]] = next
+	}
+	for i := 0; i < len(sections) && decls[i] == nil; i++ {
+		ranks[sections[i]] = -1
+	}
+	sort.SliceStable(sections, func(i, j int) bool { return ranks[sections[i]] < ranks[sections[j]] })
+}
@@ -0,0 +1,72 @@
+package weave
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestByDecl(t *testing.T) {
+	source := `// Package p is an example.
+package p
+
+import "fmt"
+
+// Print prints a T.
+func (t *T) Print() {
+	// Say it.
+	fmt.Println(t.n)
+}
+
+func helper() {}
+
+// A note on what follows.
+
+// T is a thing.
+type T struct{ n int }
+
+// NewT returns a T.
+func NewT() *T { return &T{} }
+
+const max_size, b = 1, 2
+
+// Trailing words.
+`
+	type section struct{ doc, code string }
+	want := []section{
+		{"Package p is an example.", `package p
+
+import "fmt"`},
+		{"## const max\\_size, b", "const max_size, b = 1, 2"},
+		{"## func helper", "func helper() {}"},
+		{"A note on what follows.", ""},
+		{"## type T\n\nT is a thing.", "type T struct{ n int }"},
+		{"### func NewT\n\nNewT returns a T.", "func NewT() *T { return &T{} }"},
+		{"### func (\\*T) Print\n\nPrint prints a T.", "func (t *T) Print() {\n\t// Say it.\n\tfmt.Println(t.n)\n}"},
+		{"Trailing words.", ""},
+	}
+	var got []section
+	for _, s := range New(Options{ByDecl: true}).Sections(source) {
+		got = append(got, section{strings.TrimSpace(s.Doc), strings.TrimSpace(s.Code)})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sections() with ByDecl =\n%q\nwant\n%q", got, want)
+	}
+
+	var positions []int
+	for _, s := range New(Options{ByDecl: true, SourceOrder: true}).Sections(source) {
+		positions = append(positions, s.Position)
+	}
+	for i, p := range positions {
+		if p != i {
+			t.Errorf("Sections() with SourceOrder has the positions %v, want source order", positions)
+			break
+		}
+	}
+
+	// Source that does not parse gets the usual sections.
+	broken := "// A\nfunc a( {\n// B\nb()\n"
+	if got, want := len(New(Options{ByDecl: true}).Sections(broken)), 2; got != want {
+		t.Errorf("Sections(%q) with ByDecl has %d sections, want %d", broken, got, want)
+	}
+}
@@ -0,0 +1,359 @@
+package weave
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// Section is a comment group along with the code that follows it. Before
+// rendering, Doc and Code hold the comment text and the code; afterwards,
+// they hold HTML.
+type Section struct {
+	Doc  string
+	Code string
+	ID   string // stable, content-derived ID; see AssignIDs()
+	// Anchor is a readable ID for links to the section, derived from the
+	// nearest heading; see AssignIDs().
+	Anchor string
+	// Boilerplate is the package clause and imports, if a caller has split
+	// them off from Code.
+	Boilerplate string
+	// Caption is the caption of the code, if any.
+	Caption *Caption
+	// Footnotes are the comments that Options.Footnotes moved out of the
+	// code; see footnotes.go.
+	Footnotes []Footnote
+	// Status is the review status from a status pragma, if any; see
+	// status.go.
+	Status string
+	// Order is the key of the order pragma that the section falls under,
+	// if any; see order.go.
+	Order string
+	// Position is the index of the section in the source.
+	Position int
+	// DocStart and DocEnd are the first and the last line of the comment
+	// in the source, counting from 1, or zero if the section has none.
+	DocStart, DocEnd int
+	// CodeStart and CodeEnd are the first and the last line of the code,
+	// likewise.
+	CodeStart, CodeEnd int
+	// DocOffset and DocEndOffset are the byte offsets of the comment in the
+	// source, from its first byte to the byte after its last line, and
+	// CodeOffset and CodeEndOffset those of the code. They are zero if
+	// the section has no comment or no code.
+	DocOffset, DocEndOffset   int
+	CodeOffset, CodeEndOffset int
+	// SkippedLines are the lines between CodeStart and CodeEnd that Code
+	// leaves out, like directives, or nil if there are none.
+	SkippedLines []int
+}
+
+// span is the range of lines and bytes of the source that the comment or
+// the code of a section comes from.
+type span struct {
+	start, end, offset, endOffset int
+}
+
+// add extends the span by a line, given by its index and its offset.
+func (s *span) add(i, offset int, line string, source string) {
+	if s.start == 0 {
+		s.start, s.offset = i+1, offset
+	}
+	s.end, s.endOffset = i+1, offset+len(line)+1
+	if s.endOffset > len(source) {
+		s.endOffset = len(source) // the last line has no newline
+	}
+}
+
+// goCommentLines returns the indexes of the lines of Go source that are
+// comment lines: lines within a comment that has only whitespace before it
+// on its first line and after it on its last line. Unlike the patterns of
+// commentFinder, the parser knows that `//` in a string is no comment. ok
+// is false if the source does not parse.
+func goCommentLines(source string) (lines map[int]bool, ok bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", source, parser.ParseComments)
+	if err != nil {
+		return nil, false
+	}
+	lines = map[int]bool{}
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			start, end := fset.Position(c.Pos()), fset.Position(c.End())
+			lineStart := strings.LastIndexByte(source[:start.Offset], '\n') + 1
+			lineEnd := strings.IndexByte(source[end.Offset:], '\n')
+			if lineEnd < 0 {
+				lineEnd = len(source) - end.Offset
+			}
+			if strings.TrimSpace(source[lineStart:start.Offset]) != "" || strings.TrimSpace(source[end.Offset:end.Offset+lineEnd]) != "" {
+				continue
+			}
+			for l := start.Line; l <= end.Line; l++ {
+				lines[l-1] = true
+			}
+		}
+	}
+	return lines, true
+}
+
+// Determine if the current line belongs to a comment region. A comment region
+// is either a line comment (starting with `//` in Go) or a multi-line comment
+// (`/*...*/` in Go).
+func commentFinder(syn *syntax) func(string) bool {
+	commentSectionInProgress := false
+	return func(line string) bool {
+		// If the current line is at the start `/*` of a multi-line comment,
+		// set a flag to remember we're within a multi-line comment, unless
+		// the comment ends on the same line.
+		if match(syn.commentStart, line) {
+			loc := syn.commentStart.FindStringIndex(line)
+			commentSectionInProgress = !match(syn.commentEnd, line[loc[1]:])
+			return true
+		}
+		if match(syn.comment, line) {
+			// "//" Comment line found.
+			return true
+		}
+		// At the end `*/` of a multi-line comment, clear the flag.
+		if match(syn.commentEnd, line) {
+			commentSectionInProgress = false
+			return true
+		}
+		// The current line is within a `/*...*/` section.
+		if commentSectionInProgress {
+			return true
+		}
+		// Anything else is not a comment region.
+		return false
+	}
+}
+
+// IsDirective returns true if the input argument is a Go directive.
+func IsDirective(line string) bool {
+	return Go.IsDirective(line)
+}
+
+// IsPragma returns true if the line is a goweave pragma of the form
+// `//goweave:<key> <value>` or `//meta: <key>=<value>`.
+func IsPragma(line string) bool {
+	return Go.IsPragma(line)
+}
+
+// ParsePragma returns the key and the value of a goweave pragma in Go
+// source. ok is false if the line is no pragma.
+func ParsePragma(line string) (key, value string, ok bool) {
+	return Go.ParsePragma(line)
+}
+
+// Sections splits the source into sections, where each section contains a
+// comment group and the code that follows that group, using the comment
+// syntax of Options.Language. Go source gets parsed, so that only real
+// comments on lines of their own become prose, and not `//` in a string or
+// a comment after code; Go source that does not parse, and other
+// languages, go by the comment syntax alone. Directives and goweave
+// pragmas are left out;
+// a caption pragma attaches its caption to the section of the next line of
+// code, and a status pragma its status to the section of the next line.
+// In Go, the cgo preamble, which is the comment right before `import "C"`,
+// is C code rather than prose and stays with the code.
+// With Options.ProseMarker, the other comments stay in the code, and with
+// Options.KeepDirectives, the directives do.
+// Unless Options.SourceOrder is set, the sections come in the order that
+// the order pragmas ask for. Options.ByDecl makes one section per
+// declaration instead, in the order of godoc; see decls.go.
+func (w *Weaver) Sections(source string) []*Section {
+	var sections []*Section
+	// Collect the lines of the current section in builders rather than
+	// through string concatenation, which would copy the section
+	// text over and over again.
+	var doc, code strings.Builder
+	var caption, pending *Caption
+	var order, pendingOrder string
+	var status, pendingStatus string
+	var chapters []string
+	var docSpan, codeSpan span
+	var skipped, pendingSkips []int // directives after the code so far
+	ordered := false
+	// With Options.ByDecl, the declaration of each section; see decls.go.
+	var current *decl
+	var sectionDecls []*decl
+	// With Options.Balance, the source lines of each section; see
+	// balance.go.
+	var nums sectionLines
+	lineNums := map[*Section]*sectionLines{}
+	flush := func() {
+		sectionDecls = append(sectionDecls, current)
+		current = nil
+		sections = append(sections, &Section{Doc: doc.String(), Code: code.String(), Caption: caption, Status: status, Order: order, Position: len(sections),
+			DocStart: docSpan.start, DocEnd: docSpan.end, CodeStart: codeSpan.start, CodeEnd: codeSpan.end,
+			DocOffset: docSpan.offset, DocEndOffset: docSpan.endOffset, CodeOffset: codeSpan.offset, CodeEndOffset: codeSpan.endOffset,
+			SkippedLines: skipped})
+		if w.opts.Balance > 0 {
+			lineNums[sections[len(sections)-1]] = &sectionLines{nums.doc, nums.code}
+			nums = sectionLines{}
+		}
+		doc.Reset()
+		code.Reset()
+		caption, status = nil, ""
+		docSpan, codeSpan = span{}, span{}
+		skipped, pendingSkips = nil, nil
+	}
+	// Order and status pragmas apply to the section of the next line.
+	applyPragmas := func() {
+		if ordered {
+			order, ordered = pendingOrder, false
+		}
+		if pendingStatus != "" {
+			status, pendingStatus = pendingStatus, ""
+		}
+	}
+	lang := w.opts.Language
+	syn := lang.proseSyntax(w.opts.ProseMarker)
+	isInComment := commentFinder(syn)
+	lines := strings.Split(source, "\n")
+	preamble := map[int]bool{}
+	// In Go source that parses, the parser tells the comment lines, and
+	// the patterns only tell prose from other comments.
+	var goComments map[int]bool
+	isComment := func(i int, line string) bool { return isInComment(line) }
+	if lang == Go {
+		preamble = cgoPreamble(lines, lang.syntax())
+		if c, ok := goCommentLines(source); ok {
+			goComments = c
+			isComment = func(i int, line string) bool {
+				return goComments[i] && (w.opts.ProseMarker == "" || match(syn.comment, line))
+			}
+		}
+	}
+	// With Options.ByDecl, each declaration starts a section, and its
+	// lines are code.
+	declAt := map[int]*decl{}
+	byDecl := false
+	if w.opts.ByDecl && lang == Go {
+		if decls, ok := goDecls(source); ok {
+			byDecl = true
+			for _, d := range decls {
+				declAt[d.start] = d
+				for j := d.start + 1; j <= d.end; j++ {
+					preamble[j] = true
+				}
+			}
+		}
+	}
+
+	offset := 0
+	for i, line := range lines {
+		lineOffset := offset
+		offset += len(line) + 1
+		// The empty string after the final newline is no line of the
+		// source.
+		last := i == len(lines)-1 && line == ""
+		if d := declAt[i]; d != nil {
+			if strings.TrimSpace(code.String()) != "" {
+				flush()
+			}
+			current = d
+		}
+		if preamble[i] {
+			code.WriteString(line)
+			code.WriteByte('\n')
+			codeSpan.add(i, lineOffset, line, source)
+			nums.code = append(nums.code, i+1)
+			continue
+		}
+		// Skip the line if it is a directive like Go's //go:generate
+		// or a goweave pragma. Kept directives are code.
+		commentLine := goComments == nil || goComments[i]
+		kept := w.opts.KeepDirectives && match(syn.directive, line)
+		if !kept && commentLine && (match(syn.directive, line) || match(syn.pragma, line)) {
+			if codeSpan.start != 0 {
+				pendingSkips = append(pendingSkips, i+1)
+			}
+			switch key, value, _ := lang.ParsePragma(line); key {
+			case "caption":
+				pending = parseCaption(value)
+			case "order":
+				pendingOrder, ordered = strings.TrimSpace(value), true
+			case "status":
+				pendingStatus = parseStatus(value)
+			case "chapters":
+				chapters = parseChapters(value)
+			}
+			continue
+		}
+		// Determine if the line belongs to a comment.
+		if !kept && isComment(i, line) {
+			// If currently in a Code group, switch to a new section.
+			if code.Len() > 0 {
+				flush()
+			}
+			applyPragmas()
+			docSpan.add(i, lineOffset, line, source)
+			// Strip out any comment delimiter and add the line to the
+			// Doc group.
+			doc.WriteString(syn.delims.ReplaceAllString(line, ""))
+			doc.WriteByte('\n')
+			nums.doc = append(nums.doc, i+1)
+
+		} else {
+			// Stop here if only the intro text shall be rendered.
+			if w.opts.IntroOnly {
+				break
+			}
+			// Attach a pending caption to the first line of code after it.
+			if pending != nil && strings.TrimSpace(line) != "" && caption == nil {
+				caption, pending = pending, nil
+			}
+			if strings.TrimSpace(line) != "" {
+				applyPragmas()
+			}
+			// Add the current line to the Code group.
+			code.WriteString(line)
+			code.WriteByte('\n')
+			if !last {
+				codeSpan.add(i, lineOffset, line, source)
+				skipped, pendingSkips = append(skipped, pendingSkips...), nil
+				nums.code = append(nums.code, i+1)
+			} else {
+				nums.code = append(nums.code, 0)
+			}
+		}
+	}
+	flush()
+	if byDecl {
+		declOrder(sections, sectionDecls, w.opts.SourceOrder)
+	} else if !w.opts.SourceOrder {
+		orderSections(sections, chapters)
+	}
+	if w.opts.Balance > 0 {
+		sections = balance(sections, lineNums, source, w.opts.Balance)
+	}
+	return sections
+}
+
+// cgoPreamble returns the indexes of the lines of the comments that
+// directly precede an `import "C"` line.
+func cgoPreamble(lines []string, syn *syntax) map[int]bool {
+	preamble := map[int]bool{}
+	for i, line := range lines {
+		if strings.TrimSpace(line) != `import "C"` {
+			continue
+		}
+		j := i - 1
+		if j >= 0 && match(syn.commentEnd, lines[j]) && !match(syn.comment, lines[j]) {
+			for j >= 0 && !match(syn.commentStart, lines[j]) {
+				j--
+			}
+			j--
+		} else {
+			for j >= 0 && match(syn.comment, lines[j]) {
+				j--
+			}
+		}
+		for k := j + 1; k < i; k++ {
+			preamble[k] = true
+		}
+	}
+	return preamble
+}
@@ -0,0 +1,214 @@
+package weave
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestCommentFinder(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"// Comment", true},
+		{"package main", false},
+		{"/* Begin", true},
+		{"within", true},
+		{"", true},
+		{"End */", true},
+		{"func test() {", false},
+		{"", false},
+	}
+	isInComment := commentFinder(Go.syntax())
+	for _, tt := range tests {
+		if got := isInComment(tt.line); got != tt.want {
+			t.Errorf("%q. commentFinder() = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestIsDirective(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"//go:generate blah blah", true},
+		{"//go:newdirective blah blah", true},
+		{"// +build linux", true},
+		{"// go:generate blah blah", false},
+		{"/*go:generate blah blah", false},
+		{"/* go:generate blah blah", false},
+		{"//gotcha", false},
+		{"//export Hello", true},
+		{"//exported names", false},
+		{"//line a.go:10", true},
+	}
+	for _, tt := range tests {
+		if got := IsDirective(tt.line); got != tt.want {
+			t.Errorf("IsDirective(%s) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestExtractSections(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []*Section
+	}{
+		{`// Test comment
+// more comment
+
+Test code
+More code
+
+// Second comment
+  Second code snippet
+
+/* Third comment
+In comment section
+End of comment */
+`,
+			[]*Section{{Doc: `Test comment
+more comment
+`,
+				Code: `
+Test code
+More code
+
+`, DocStart: 1, DocEnd: 2, CodeStart: 3, CodeEnd: 6, DocEndOffset: 32, CodeOffset: 32, CodeEndOffset: 54},
+				{Doc: "Second comment\n",
+					Code: "  Second code snippet\n\n", Position: 1, DocStart: 7, DocEnd: 7, CodeStart: 8, CodeEnd: 9,
+					DocOffset: 54, DocEndOffset: 72, CodeOffset: 72, CodeEndOffset: 95},
+				{Doc: "Third comment\nIn comment section\nEnd of comment\n",
+					Code: "\n", Position: 2, DocStart: 10, DocEnd: 12, DocOffset: 95, DocEndOffset: 149},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := New(Options{}).Sections(tt.source); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Sections(%v) = %v, want %v", tt.source, spew.Sdump(got), spew.Sdump(tt.want))
+		}
+	}
+}
+
+func TestCgoPreamble(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []*Section
+	}{
+		{"// Doc\npackage p\n\n/*\n#include <stdio.h>\n*/\nimport \"C\"\n",
+			[]*Section{{Doc: "Doc\n", Code: "package p\n\n/*\n#include <stdio.h>\n*/\nimport \"C\"\n\n", DocStart: 1, DocEnd: 1, CodeStart: 2, CodeEnd: 7, DocEndOffset: 7, CodeOffset: 7, CodeEndOffset: 54}}},
+		{"// #include <stdlib.h>\nimport \"C\"",
+			[]*Section{{Code: "// #include <stdlib.h>\nimport \"C\"\n", CodeStart: 1, CodeEnd: 2, CodeEndOffset: 33}}},
+		{"// Doc\n\n// #include <stdlib.h>\nimport \"C\"",
+			[]*Section{{Doc: "Doc\n", Code: "\n// #include <stdlib.h>\nimport \"C\"\n", DocStart: 1, DocEnd: 1, CodeStart: 2, CodeEnd: 4, DocEndOffset: 7, CodeOffset: 7, CodeEndOffset: 41}}},
+	}
+	for _, tt := range tests {
+		if got := New(Options{}).Sections(tt.source); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Sections(%q) = %v, want %v", tt.source, spew.Sdump(got), spew.Sdump(tt.want))
+		}
+	}
+}
+
+func TestPragmas(t *testing.T) {
+	got := New(Options{}).Sections("//goweave:title Title\n// Doc\ncode\n")
+	want := []*Section{{Doc: "Doc\n", Code: "code\n\n", DocStart: 2, DocEnd: 2, CodeStart: 3, CodeEnd: 3, DocOffset: 22, DocEndOffset: 29, CodeOffset: 29, CodeEndOffset: 34}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sections() = %v, want %v", got, want)
+	}
+	if key, value, ok := ParsePragma("//goweave:title A Title "); !ok || key != "title" || value != "A Title" {
+		t.Errorf("ParsePragma() = %q, %q, %v; want title, A Title, true", key, value, ok)
+	}
+}
+
+func TestSkippedLines(t *testing.T) {
+	got := New(Options{}).Sections("// Doc\nfunc f() {}\n//go:generate x\nfunc g() {}\n//go:generate y\n// Next\ncode\n")
+	if len(got) != 2 {
+		t.Fatalf("Sections() returned %d sections, want 2", len(got))
+	}
+	if want := []int{3}; !reflect.DeepEqual(got[0].SkippedLines, want) {
+		t.Errorf("SkippedLines = %v, want %v", got[0].SkippedLines, want)
+	}
+	if got[1].SkippedLines != nil {
+		t.Errorf("SkippedLines = %v, want nil", got[1].SkippedLines)
+	}
+}
+
+func TestKeepDirectives(t *testing.T) {
+	src := "//go:build linux\n\n// Doc\nfunc f() {}\n//go:generate x\n//goweave:status draft\nfunc g() {}\n"
+	tests := []struct {
+		keep  bool
+		codes []string
+	}{
+		{false, []string{"\n", "func f() {}\nfunc g() {}\n\n"}},
+		{true, []string{"//go:build linux\n\n", "func f() {}\n//go:generate x\nfunc g() {}\n\n"}},
+	}
+	for _, tt := range tests {
+		got := New(Options{KeepDirectives: tt.keep, SourceOrder: true}).Sections(src)
+		var codes []string
+		for _, s := range got {
+			codes = append(codes, s.Code)
+		}
+		if !reflect.DeepEqual(codes, tt.codes) {
+			t.Errorf("Sections() with KeepDirectives %v: Code = %q, want %q", tt.keep, codes, tt.codes)
+		}
+	}
+}
+
+func TestGoCommentLines(t *testing.T) {
+	tests := []struct {
+		source string
+		codes  []string // the code of the sections
+	}{
+		// A raw string with lines that look like comments.
+		{"package p\n\n// Doc\nvar s = `\n// no comment\n/* nor this\n`\n", []string{"package p\n\n", "var s = `\n// no comment\n/* nor this\n`\n\n"}},
+		// A block comment at the end of a line of code.
+		{"package p\n\n// Doc\nvar x = 1 /* one */\nvar y = 2 /* two\ntwo */\n", []string{"package p\n\n", "var x = 1 /* one */\nvar y = 2 /* two\ntwo */\n\n"}},
+		// A directive in a raw string stays.
+		{"package p\n\n// Doc\nvar s = `\n//go:generate x\n`\n", []string{"package p\n\n", "var s = `\n//go:generate x\n`\n\n"}},
+		// Code that does not parse falls back to the patterns.
+		{"// Doc\nx := `\n// Comment\n`\n", []string{"x := `\n", "`\n\n"}},
+	}
+	for _, tt := range tests {
+		var codes []string
+		for _, s := range New(Options{SourceOrder: true}).Sections(tt.source) {
+			codes = append(codes, s.Code)
+		}
+		if !reflect.DeepEqual(codes, tt.codes) {
+			t.Errorf("Sections(%q): Code = %q, want %q", tt.source, codes, tt.codes)
+		}
+	}
+}
+
+func TestIntroOnly(t *testing.T) {
+	got := New(Options{IntroOnly: true}).Sections("// Intro\npackage main\n// More\n")
+	want := []*Section{{Doc: "Intro\n", DocStart: 1, DocEnd: 1, DocEndOffset: 9}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sections() = %v, want %v", spew.Sdump(got), spew.Sdump(want))
+	}
+}
+
+func TestProseMarker(t *testing.T) {
+	tests := []struct {
+		marker string
+		lang   *Language
+		source string
+		want   []*Section
+	}{
+		{":", Go, "//: Intro\npackage main\n\n// Not prose.\n/* Nor this. */\nvar x = 1 // trailing\n//:\n//: # Section\nfunc f() {}\n", []*Section{
+			{Doc: "Intro\n", Code: "package main\n\n// Not prose.\n/* Nor this. */\nvar x = 1 // trailing\n", DocStart: 1, DocEnd: 1, CodeStart: 2, CodeEnd: 6, DocEndOffset: 10, CodeOffset: 10, CodeEndOffset: 76},
+			{Doc: "\n# Section\n", Code: "func f() {}\n\n", Position: 1, DocStart: 7, DocEnd: 8, CodeStart: 9, CodeEnd: 9, DocOffset: 76, DocEndOffset: 94, CodeOffset: 94, CodeEndOffset: 106},
+		}},
+		{" |", LanguageNamed("python"), "# | Doc\nx = 1\n# a comment\n", []*Section{
+			{Doc: "Doc\n", Code: "x = 1\n# a comment\n\n", DocStart: 1, DocEnd: 1, CodeStart: 2, CodeEnd: 3, DocEndOffset: 8, CodeOffset: 8, CodeEndOffset: 26},
+		}},
+	}
+	for _, tt := range tests {
+		got := New(Options{ProseMarker: tt.marker, Language: tt.lang}).Sections(tt.source)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Sections() with marker %q = %v, want %v", tt.marker, spew.Sdump(got), spew.Sdump(tt.want))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package weave
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Comments show how to call a command or what a request looks like in
+// fenced blocks, and these look odd in plain black next to the colored
+// code column. Markdown runs fenced blocks through the highlighter of the
+// Weaver, in the language of their info string, so that a ```` ```go ````
+// block looks like the code next to it, and with Chroma, a ```` ```json ````
+// or ```` ```sh ```` block like JSON or a shell script. Blocks without a
+// language stay plain, as do those in languages that the highlighter does
+// not know, and the diagram languages, whose blocks goweave -diagrams
+// turns into pictures.
+
+// diagramLanguages are the languages of fenced blocks that are not code.
+var diagramLanguages = map[string]bool{"mermaid": true, "dot": true}
+
+// fenceHighlighter returns the highlighter for a fenced block with an info
+// string, or nil if the block stays plain.
+func (w *Weaver) fenceHighlighter(info string) Highlighter {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return nil
+	}
+	lang := strings.ToLower(fields[0])
+	if diagramLanguages[lang] {
+		return nil
+	}
+	h := w.base.Highlighter
+	if h == nil {
+		h = Litebrite
+	}
+	if lang != Go.Name {
+		lh, ok := h.(LanguageHighlighter)
+		if !ok {
+			return nil
+		}
+		h = lh.ForLanguage(lang)
+	}
+	if h == PlainText {
+		return nil
+	}
+	if len(w.opts.TokenClasses) > 0 {
+		h = classHighlighter{h, w.opts.TokenClasses}
+	}
+	return h
+}
+
+func (r headingRenderer) BlockCode(out *bytes.Buffer, text []byte, info string) {
+	h := r.w.fenceHighlighter(info)
+	if h == nil {
+		r.Renderer.BlockCode(out, text, info)
+		return
+	}
+	// Keep the element that blackfriday writes, with the language class,
+	// and replace its content.
+	var b bytes.Buffer
+	r.Renderer.BlockCode(&b, text, info)
+	block := b.String()
+	i := strings.Index(block, "<code")
+	j := strings.IndexByte(block[i:], '>')
+	out.WriteString(block[:i+j+1])
+	out.WriteString(h.Highlight(string(text)))
+	out.WriteString("</code></pre>\n")
+}
@@ -0,0 +1,35 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFencedBlocks(t *testing.T) {
+	chroma, err := NewChroma("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		h        Highlighter
+		classes  map[string]string
+		in, want string
+	}{
+		{Litebrite, nil, "```go\nreturn x\n```\n",
+			`<pre><code class="language-go"><span class="keyword">return</span> <span class="ident">x</span>` + "\n</code></pre>\n"},
+		{Litebrite, nil, "```sh\necho $HOME\n```\n",
+			`<pre><code class="language-sh">echo $HOME` + "\n</code></pre>\n"},
+		{Litebrite, nil, "```\nreturn x\n```\n", "<pre><code>return x\n</code></pre>\n"},
+		{Litebrite, PrismClasses, "```go\nreturn x\n```\n",
+			`<pre><code class="language-go"><span class="token keyword">return</span> <span>x</span>` + "\n</code></pre>\n"},
+		{chroma, nil, "```json\n{\"a\": 1}\n```\n", `<span class="mi">1</span>`},
+		{chroma, nil, "```mermaid\ngraph LR\n  A --> B\n```\n",
+			"<pre><code class=\"language-mermaid\">graph LR\n  A --&gt; B\n</code></pre>\n"},
+	}
+	for _, tt := range tests {
+		w := New(Options{Highlighter: tt.h, TokenClasses: tt.classes, Language: LanguageNamed("python")})
+		if got := w.Markdown(tt.in); !strings.Contains(got, tt.want) {
+			t.Errorf("Markdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package weave
+
+import (
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// With Options.Footnotes, HighlightSections moves the comments at the end
+// of lines of Go code, like
+//
+//	n := len(buf) // the bytes read so far
+//
+// out of the code, and puts a numbered marker in their place. The comments
+// themselves become the Footnotes of the section, which the templates list
+// below the section's prose. Comments without a space after the `//`, like
+// `//nolint:errcheck`, are directives for tools and stay in the code, as do
+// the comments on lines of their own, which start sections anyway.
+
+// Footnote is a comment that Options.Footnotes moved out of the code.
+type Footnote struct {
+	Number int    // counts from 1 through the whole document
+	Text   string // the comment without the `//`
+	line   int    // the line of the section's code that the marker goes to
+}
+
+// ID returns the ID of the footnote's element, as in "codenote-3".
+func (f Footnote) ID() string {
+	return "codenote-" + strconv.Itoa(f.Number)
+}
+
+// RefID returns the ID of the footnote's marker in the code, as in
+// "codenote-ref-3".
+func (f Footnote) RefID() string {
+	return "codenote-ref-" + strconv.Itoa(f.Number)
+}
+
+// takeFootnotes moves the trailing comments out of the code of the
+// sections, numbering them in the order of the sections.
+func takeFootnotes(sections []*Section) {
+	n := 0
+	for _, s := range sections {
+		s.Footnotes = nil
+		code := []byte(s.Code)
+		fset := token.NewFileSet()
+		file := fset.AddFile("", -1, len(code))
+		var sc scanner.Scanner
+		sc.Init(file, code, nil, scanner.ScanComments)
+		type cut struct{ off, end int }
+		var cuts []cut
+		codeLine := 0 // the last line with a token other than a comment
+		for {
+			pos, tok, lit := sc.Scan()
+			if tok == token.EOF {
+				break
+			}
+			line := file.Line(pos)
+			if tok != token.COMMENT {
+				if lit != "\n" { // an automatic semicolon
+					codeLine = line
+				}
+				continue
+			}
+			if line != codeLine || !strings.HasPrefix(lit, "// ") {
+				continue
+			}
+			text := strings.TrimSpace(lit[len("//"):])
+			if text == "" {
+				continue
+			}
+			n++
+			off := file.Offset(pos)
+			cuts = append(cuts, cut{off, off + len(lit)})
+			s.Footnotes = append(s.Footnotes, Footnote{Number: n, Text: text, line: line - 1})
+		}
+		if len(cuts) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, c := range cuts {
+			b.WriteString(strings.TrimRight(s.Code[last:c.off], " \t"))
+			last = c.end
+		}
+		b.WriteString(s.Code[last:])
+		s.Code = b.String()
+	}
+}
+
+// addFootnoteRefs puts the markers of the footnotes at the ends of their
+// lines in the highlighted code.
+func addFootnoteRefs(s *Section) {
+	if len(s.Footnotes) == 0 {
+		return
+	}
+	lines := strings.Split(s.Code, "\n")
+	for _, f := range s.Footnotes {
+		i := f.line
+		if i >= len(lines) {
+			i = len(lines) - 1
+		}
+		lines[i] += ` <sup class="codenote-ref"><a id="` + f.RefID() + `" href="#` + f.ID() + `">` + strconv.Itoa(f.Number) + `</a></sup>`
+	}
+	s.Code = strings.Join(lines, "\n")
+}
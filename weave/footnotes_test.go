@@ -0,0 +1,57 @@
+package weave
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFootnotes(t *testing.T) {
+	src := `package p
+
+// Read reads.
+func Read(buf []byte) int {
+	n := len(buf)   // the bytes read so far
+	s := "// not a comment"
+	x := 1 //nolint:ineffassign
+	// A comment of its own.
+	return n
+}
+
+// Other counts on.
+var y = 2 // the second note
+`
+	w := New(Options{Highlighter: PlainText, Footnotes: true})
+	sections := w.Sections(src)
+	w.HighlightSections(sections)
+	var notes []Footnote
+	for _, s := range sections {
+		for _, f := range s.Footnotes {
+			notes = append(notes, Footnote{Number: f.Number, Text: f.Text})
+		}
+	}
+	want := []Footnote{{Number: 1, Text: "the bytes read so far"}, {Number: 2, Text: "the second note"}}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("HighlightSections() footnotes = %v, want %v", notes, want)
+	}
+	for _, want := range []string{
+		`n := len(buf) <sup class="codenote-ref"><a id="codenote-ref-1" href="#codenote-1">1</a></sup>` + "\n",
+		`s := &#34;// not a comment&#34;`,
+		`x := 1 //nolint:ineffassign`,
+	} {
+		if !strings.Contains(sections[1].Code, want) {
+			t.Errorf("section 1 = %s, want it to contain %s", sections[1].Code, want)
+		}
+	}
+	if got := sections[3].Code; !strings.Contains(got, `var y = 2 <sup class="codenote-ref"><a id="codenote-ref-2"`) {
+		t.Errorf("section 3 = %s, want the second marker", got)
+	}
+
+	got, err := New(Options{Footnotes: true}).Render("// Doc\nx := 1 // one\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, `<ol class="codenotes"><li id="codenote-1" value="1">one <a href="#codenote-ref-1"`) {
+		t.Errorf("Render() = %s, want a list of footnotes", got)
+	}
+}
@@ -0,0 +1,197 @@
+package weave
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Chat bots, editors, and wikis often want the woven view of one symbol
+// rather than of a whole file: a function with the prose of its doc
+// comment, or a type with its fields. WeaveDecl finds the declaration of a
+// symbol in a package, by import path, as the go command would find it from
+// the current directory, or by the directory of the package, and weaves it
+// with its doc comment into a Fragment:
+//
+//	f, err := weave.WeaveDecl("github.com/christophberger/goweave/weave", "Weaver.Render")
+//
+// Symbols name a top-level function, type, constant, or variable, like
+// "New", or a method of a type, like "Weaver.Render" or "(*Weaver).Render".
+// A constant or variable of a block gets the whole block, as the block
+// usually explains its members together.
+
+// A Fragment is the woven view of a single declaration.
+type Fragment struct {
+	// Package is the import path of the package of the declaration, or its
+	// directory if it has none.
+	Package string
+	// Symbol is the symbol as asked for.
+	Symbol string
+	// Filename is the path of the file of the declaration.
+	Filename string
+	// Start and End are the first and the last line of the declaration
+	// in the file, with its doc comment, counting from 1.
+	Start, End int
+	// Source is the text of these lines.
+	Source string
+	// Sections are the rendered sections of the declaration, with the
+	// lines and offsets of the file.
+	Sections []*Section
+	// HTML is the fragment as Render writes it.
+	HTML string
+}
+
+// WeaveDecl weaves the declaration of a symbol with the default options;
+// see Weaver.WeaveDecl.
+func WeaveDecl(pkgPath, symbol string) (Fragment, error) {
+	return New(Options{}).WeaveDecl(pkgPath, symbol)
+}
+
+// WeaveDecl finds the declaration of a symbol in the package of an import
+// path or a directory, and weaves it with its doc comment.
+func (w *Weaver) WeaveDecl(pkgPath, symbol string) (Fragment, error) {
+	var pkg *build.Package
+	var err error
+	if build.IsLocalImport(pkgPath) || filepath.IsAbs(pkgPath) {
+		pkg, err = build.ImportDir(pkgPath, 0)
+	} else {
+		pkg, err = build.Import(pkgPath, ".", 0)
+	}
+	if err != nil {
+		return Fragment{}, err
+	}
+	recv, name := splitSymbol(symbol)
+	if name == "" {
+		return Fragment{}, fmt.Errorf("invalid symbol %q", symbol)
+	}
+	fset := token.NewFileSet()
+	for _, file := range append(pkg.GoFiles, pkg.CgoFiles...) {
+		filename := filepath.Join(pkg.Dir, file)
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return Fragment{}, err
+		}
+		f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return Fragment{}, err
+		}
+		d := findDecl(f, recv, name)
+		if d == nil {
+			continue
+		}
+		from := d.Pos()
+		if doc := declDoc(d); doc != nil {
+			from = doc.Pos()
+		}
+		start, end := fset.Position(from), fset.Position(d.End())
+		lineStart := start.Offset - (start.Column - 1)
+		lineEnd := end.Offset
+		if i := strings.IndexByte(string(src[lineEnd:]), '\n'); i >= 0 {
+			lineEnd += i + 1
+		} else {
+			lineEnd = len(src)
+		}
+		frag := Fragment{
+			Package:  pkg.ImportPath,
+			Symbol:   symbol,
+			Filename: filename,
+			Start:    start.Line,
+			End:      end.Line,
+			Source:   string(src[lineStart:lineEnd]),
+		}
+		if frag.Package == "" || frag.Package == "." {
+			frag.Package = pkg.Dir
+		}
+		frag.Sections = w.Sections(frag.Source)
+		for _, s := range frag.Sections {
+			s.moveLines(start.Line-1, lineStart)
+		}
+		frag.HTML, err = w.renderSections(frag.Sections)
+		return frag, err
+	}
+	return Fragment{}, fmt.Errorf("%s: no declaration of %s", pkgPath, symbol)
+}
+
+// splitSymbol splits a symbol like "(*Weaver).Render" into the type of
+// the receiver and the name, or returns no receiver for a plain name.
+func splitSymbol(symbol string) (recv, name string) {
+	i := strings.LastIndexByte(symbol, '.')
+	if i < 0 {
+		return "", strings.TrimSpace(symbol)
+	}
+	recv = strings.Trim(symbol[:i], "()* ")
+	return recv, strings.TrimSpace(symbol[i+1:])
+}
+
+// findDecl returns the top-level declaration of a name in a file, or for
+// a receiver type, that of its method.
+func findDecl(f *ast.File, recv, name string) ast.Decl {
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name != name {
+				continue
+			}
+			hasRecv := d.Recv != nil && len(d.Recv.List) > 0
+			if !hasRecv && recv == "" || hasRecv && strings.TrimPrefix(recvName(d.Recv.List[0].Type), "*") == recv {
+				return d
+			}
+		case *ast.GenDecl:
+			if recv != "" || d.Tok == token.IMPORT {
+				continue
+			}
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					if s.Name.Name == name {
+						return d
+					}
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.Name == name {
+							return d
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// declDoc returns the doc comment of a declaration, if any.
+func declDoc(d ast.Decl) *ast.CommentGroup {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+// moveLines moves the lines and offsets of a section, which come from an
+// excerpt of a file, to those of the file.
+func (s *Section) moveLines(lines, offset int) {
+	for _, l := range []*int{&s.DocStart, &s.DocEnd, &s.CodeStart, &s.CodeEnd} {
+		if *l > 0 {
+			*l += lines
+		}
+	}
+	if s.DocStart > 0 {
+		s.DocOffset += offset
+		s.DocEndOffset += offset
+	}
+	if s.CodeStart > 0 {
+		s.CodeOffset += offset
+		s.CodeEndOffset += offset
+	}
+	for i := range s.SkippedLines {
+		s.SkippedLines[i] += lines
+	}
+}
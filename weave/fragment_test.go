@@ -0,0 +1,50 @@
+package weave
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWeaveDecl(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fragment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := "// Package p is a test.\npackage p\n\n// T is a *type*.\ntype T struct{}\n\n// Name returns the name.\nfunc (t *T) Name() string {\n\treturn \"t\"\n}\n\n// Names are the names.\nconst (\n\tA = \"a\"\n\tB = \"b\"\n)\n\n// Name is no method.\nfunc Name() {}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		symbol     string
+		start, end int
+		want       string
+	}{
+		{"T", 4, 5, "<em>type</em>"},
+		{"(*T).Name", 7, 10, "returns the name"},
+		{"T.Name", 7, 10, "returns the name"},
+		{"Name", 18, 19, "no method"},
+		{"B", 12, 16, "the names"},
+	}
+	for _, tt := range tests {
+		f, err := WeaveDecl(dir, tt.symbol)
+		if err != nil {
+			t.Errorf("WeaveDecl(%q): %v", tt.symbol, err)
+			continue
+		}
+		if f.Start != tt.start || f.End != tt.end || f.Filename != filepath.Join(dir, "p.go") || !strings.Contains(f.HTML, tt.want) {
+			t.Errorf("WeaveDecl(%q) = lines %d-%d of %s, %s; want lines %d-%d with %q", tt.symbol, f.Start, f.End, f.Filename, f.HTML, tt.start, tt.end, tt.want)
+		}
+		if s := f.Sections[0]; s.DocStart != tt.start || src[s.DocOffset:s.DocEndOffset] != f.Source[:s.DocEndOffset-s.DocOffset] {
+			t.Errorf("WeaveDecl(%q): the comment is at line %d, offset %d, of the file", tt.symbol, s.DocStart, s.DocOffset)
+		}
+	}
+	for _, symbol := range []string{"U", "T.Other", ""} {
+		if _, err := WeaveDecl(dir, symbol); err == nil {
+			t.Errorf("WeaveDecl(%q) succeeded", symbol)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package weave
+
+import (
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// forFile returns a Weaver for the language of the named file, unless
+// Options.Language was set explicitly.
+func (w *Weaver) forFile(name string) *Weaver {
+	if w.base.Language != nil {
+		return w
+	}
+	l := LanguageFor(name)
+	if l == nil || l == w.opts.Language {
+		return w
+	}
+	opts := w.base
+	opts.Language = l
+	return New(opts)
+}
+
+// RenderFile weaves the named file of fsys, like Render. Unless
+// Options.Language is set, the extension of the name selects the language;
+// unknown extensions are taken for Go.
+func (w *Weaver) RenderFile(fsys fs.FS, name string) (string, error) {
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+	return w.forFile(name).Render(string(src))
+}
+
+// RenderFS weaves the files of fsys in and below the directory root, in
+// lexical order, and calls fn with the name and the document of each. It
+// picks the files whose extensions belong to a registered language, or
+// with Options.Language set, the files with the extensions of that
+// language. Like the go tool, it skips directories named testdata and
+// directories whose names start with "." or "_". If fn returns an error,
+// RenderFS stops and returns that error.
+func (w *Weaver) RenderFS(fsys fs.FS, root string, fn func(name, doc string) error) error {
+	return fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			base := path.Base(name)
+			if name != root && (base == "testdata" || strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		l := LanguageFor(name)
+		if l == nil || (w.base.Language != nil && l.Name != w.base.Language.Name) {
+			return nil
+		}
+		doc, err := w.RenderFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		return fn(name, doc)
+	})
+}
+
+// ParseTemplateFS parses templates from the files of fsys that match the
+// patterns, like template.ParseFS, for use as Options.Template. If the
+// files define a template named "fragment", as the templates of the goweave
+// command do, the result is that template; otherwise, it is the template of
+// the first file.
+func ParseTemplateFS(fsys fs.FS, patterns ...string) (*template.Template, error) {
+	t, err := template.ParseFS(fsys, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if f := t.Lookup("fragment"); f != nil {
+		return f, nil
+	}
+	return t, nil
+}
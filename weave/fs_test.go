@@ -0,0 +1,50 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.go":             {Data: []byte("// # Go\npackage a\n")},
+		"b/script.py":      {Data: []byte("# Python\nx = 1\n")},
+		"b/notes.txt":      {Data: []byte("no source\n")},
+		"b/testdata/c.go":  {Data: []byte("package c\n")},
+		"_hidden/d.go":     {Data: []byte("package d\n")},
+		"tmpl/page.templ":  {Data: []byte(`{{define "fragment"}}<main>{{len .Sections}}</main>{{end}}`)},
+		"tmpl/other.templ": {Data: []byte(`{{define "other"}}other{{end}}`)},
+	}
+	var names []string
+	docs := map[string]string{}
+	err := New(Options{}).RenderFS(fsys, ".", func(name, doc string) error {
+		names = append(names, name)
+		docs[name] = doc
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(names, " "), "a.go b/script.py"; got != want {
+		t.Errorf("RenderFS() rendered %s, want %s", got, want)
+	}
+	if !strings.Contains(docs["b/script.py"], "<p>Python</p>") {
+		t.Errorf("RenderFS() did not take # lines in Python for comments: %s", docs["b/script.py"])
+	}
+
+	tpl, err := ParseTemplateFS(fsys, "tmpl/*.templ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := New(Options{Template: tpl}).RenderFile(fsys, "a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "<main>1</main>" {
+		t.Errorf("RenderFile() with a template from ParseTemplateFS = %q", got)
+	}
+	if _, err := New(Options{}).RenderFile(fsys, "missing.go"); err == nil {
+		t.Errorf("RenderFile() rendered a missing file")
+	}
+}
@@ -0,0 +1,183 @@
+package weave
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// Highlighters see the T in `func F[T any](x T)` as just another
+// identifier. markGenerics looks up the type parameters of the whole file,
+// and wraps each use of a type parameter in an element of class
+// "typeparam", whose title names the constraint. Constraints get the class
+// "constraint". Both link to the definition of the constraint if it is a
+// type of the same file, or to the documentation of `any` and
+// `comparable`.
+
+// builtinConstraints are the predeclared constraints.
+var builtinConstraints = map[string]bool{"any": true, "comparable": true}
+
+// markGenerics marks the type parameters and constraints in the code.
+func (c *goCode) markGenerics() {
+	if !strings.Contains(c.src, "[") {
+		return // no generics here
+	}
+	file := c.file
+	// link returns the URL of the definition of a constraint.
+	link := func(id *ast.Ident) string {
+		if id.Obj == nil && builtinConstraints[id.Name] {
+			return "https://pkg.go.dev/builtin#" + id.Name
+		}
+		if id.Obj == nil || file.Scope.Lookup(id.Name) != id.Obj {
+			return ""
+		}
+		decl, ok := id.Obj.Decl.(*ast.TypeSpec)
+		if !ok {
+			return ""
+		}
+		return c.sectionLink(decl.Pos())
+	}
+	types := map[string]*ast.TypeSpec{}
+	for _, decl := range file.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok {
+			for _, spec := range d.Specs {
+				if s, ok := spec.(*ast.TypeSpec); ok {
+					types[s.Name.Name] = s
+				}
+			}
+		}
+	}
+
+	// markParams marks the type parameters of a declaration and all their
+	// uses within the node. The parser does not resolve the type parameters
+	// of receivers, so these are found by name, as unresolved identifiers.
+	markParams := func(params []*ast.Ident, constraints []ast.Expr, node ast.Node) {
+		type param struct{ title, href string }
+		objs := map[*ast.Object]param{}
+		names := map[string]param{}
+		for i, name := range params {
+			if name.Name == "_" {
+				continue
+			}
+			constraint := constraints[i]
+			text := strings.Join(strings.Fields(c.src[c.offset(constraint.Pos()):c.offset(constraint.End())]), " ")
+			p := param{title: "type parameter " + name.Name + " " + text}
+			if id, ok := constraint.(*ast.Ident); ok {
+				p.href = link(id)
+			}
+			if name.Obj != nil {
+				objs[name.Obj] = p
+			} else {
+				names[name.Name] = p
+			}
+		}
+		var visit func(n ast.Node) bool
+		visit = func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				// Skip the selector, which may be a field named like a type
+				// parameter.
+				ast.Inspect(n.X, visit)
+				return false
+			case *ast.Ident:
+				p, ok := objs[n.Obj]
+				if n.Obj == nil {
+					p, ok = names[n.Name]
+				}
+				if ok {
+					c.mark(n, "typeparam", p.title, p.href)
+				}
+			}
+			return true
+		}
+		ast.Inspect(node, visit)
+	}
+	// fields flattens a type parameter list, and marks the constraints.
+	fields := func(list *ast.FieldList) (params []*ast.Ident, constraints []ast.Expr) {
+		for _, f := range list.List {
+			ast.Inspect(f.Type, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					if href := link(id); href != "" {
+						c.mark(id, "constraint", "constraint "+id.Name, href)
+					}
+				}
+				return true
+			})
+		}
+		return flatten(list)
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			var params []*ast.Ident
+			var constraints []ast.Expr
+			if d.Type.TypeParams != nil {
+				params, constraints = fields(d.Type.TypeParams)
+			}
+			// The type parameters of a method's receiver are those of the
+			// receiver type.
+			if d.Recv != nil && len(d.Recv.List) == 1 {
+				names, base := receiverParams(d.Recv.List[0].Type)
+				if s, ok := types[base]; ok && s.TypeParams != nil {
+					_, typeConstraints := flatten(s.TypeParams)
+					for i, name := range names {
+						if i < len(typeConstraints) {
+							params = append(params, name)
+							constraints = append(constraints, typeConstraints[i])
+						}
+					}
+				}
+			}
+			if len(params) > 0 {
+				markParams(params, constraints, d)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if s, ok := spec.(*ast.TypeSpec); ok && s.TypeParams != nil {
+					params, constraints := fields(s.TypeParams)
+					markParams(params, constraints, s)
+				}
+			}
+		}
+	}
+}
+
+// flatten returns the names of a type parameter list, along with the
+// constraint of each.
+func flatten(list *ast.FieldList) (params []*ast.Ident, constraints []ast.Expr) {
+	for _, f := range list.List {
+		for _, name := range f.Names {
+			params = append(params, name)
+			constraints = append(constraints, f.Type)
+		}
+	}
+	return params, constraints
+}
+
+// receiverParams returns the type parameters of a method receiver, like K
+// and V in `(p *Pair[K, V])`, and the name of the receiver's base type.
+func receiverParams(expr ast.Expr) (params []*ast.Ident, base string) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	var x ast.Expr
+	var indices []ast.Expr
+	switch t := expr.(type) {
+	case *ast.IndexExpr:
+		x, indices = t.X, []ast.Expr{t.Index}
+	case *ast.IndexListExpr:
+		x, indices = t.X, t.Indices
+	default:
+		return nil, ""
+	}
+	id, ok := x.(*ast.Ident)
+	if !ok {
+		return nil, ""
+	}
+	for _, index := range indices {
+		if name, ok := index.(*ast.Ident); ok {
+			params = append(params, name)
+		}
+	}
+	return params, id.Name
+}
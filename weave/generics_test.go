@@ -0,0 +1,69 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerics(t *testing.T) {
+	src := `package p
+
+// Number is a constraint.
+type Number interface{ ~int | ~float64 }
+
+// Sum adds up numbers.
+func Sum[T Number](xs ...T) T {
+	var s T
+	return s
+}
+
+// Pair has a field named like a type parameter.
+type Pair[K comparable, V any] struct{ K K }
+
+// Key is a method of a generic type.
+func (p *Pair[K, V]) Key() K { return p.K }
+
+var total = Sum[float64](1, 2.5)
+`
+	w := New(Options{Highlighter: PlainText})
+	sections := w.Sections(src)
+	AssignIDs(sections)
+	w.HighlightSections(sections)
+	number := "#" + sections[1].ID
+	tests := []struct {
+		section int
+		want    []string
+	}{
+		{2, []string{
+			`func Sum[<a href="` + number + `" class="typeparam" title="type parameter T Number">T</a> <a href="` + number + `" class="constraint" title="constraint Number">Number</a>]`,
+			`(xs ...<a href="` + number + `" class="typeparam"`,
+			`var s <a href="` + number + `" class="typeparam"`,
+		}},
+		{3, []string{
+			`<a href="https://pkg.go.dev/builtin#comparable" class="constraint" title="constraint comparable">comparable</a>`,
+			`struct{ K <a href="https://pkg.go.dev/builtin#comparable" class="typeparam" title="type parameter K comparable">K</a> }`,
+		}},
+		{4, []string{
+			`Key() <a href="https://pkg.go.dev/builtin#comparable" class="typeparam"`,
+			`return p.K }`,
+		}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if got := sections[tt.section].Code; !strings.Contains(got, want) {
+				t.Errorf("section %d = %s, want it to contain %s", tt.section, got, want)
+			}
+		}
+	}
+	if got := sections[4].Code; strings.Contains(got, "float64</a>") {
+		t.Errorf("instantiation got marked: %s", got)
+	}
+
+	for _, src := range []string{"func broken[T any](", "x = a[i]\n"} {
+		sections := w.Sections(src)
+		w.HighlightSections(sections)
+		if strings.Contains(sections[0].Code, "<") {
+			t.Errorf("HighlightSections(%q) marked %s", src, sections[0].Code)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package weave
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+)
+
+// goModHighlighter highlights go.mod and go.sum files, which neither
+// litebrite nor Chroma know: directives like `require` become keywords,
+// module paths identifiers, and versions and hashes literals. It wraps the
+// tokens in elements of the given classes.
+type goModHighlighter struct {
+	keyword, ident, literal, comment, operator string
+}
+
+// goModLitebrite uses the classes of Litebrite, goModChroma those of
+// Chroma.
+var (
+	goModLitebrite = goModHighlighter{"keyword", "ident", "literal", "comment", "operator"}
+	goModChroma    = goModHighlighter{
+		keyword:  tokenClass(chroma.Keyword),
+		ident:    tokenClass(chroma.NameNamespace),
+		literal:  tokenClass(chroma.LiteralString),
+		comment:  tokenClass(chroma.CommentSingle),
+		operator: tokenClass(chroma.Operator),
+	}
+)
+
+// goModDirectives are the directives of go.mod and go.work files.
+var goModDirectives = map[string]bool{
+	"module": true, "go": true, "toolchain": true, "godebug": true, "require": true,
+	"replace": true, "exclude": true, "retract": true, "tool": true, "ignore": true, "use": true,
+}
+
+// goModVersion matches versions, like v1.2.3, 1.21, or go1.21.0, and the
+// hashes of go.sum files.
+var goModVersion = regexp.MustCompile(`^(?:v\d|\d|go\d|h\d+:)`)
+
+// goModDelims end a word.
+const goModDelims = " \t\r\n()[],\"`"
+
+// Highlight implements Highlighter. A directive is only a keyword at the
+// start of a line outside of a parenthesized block, where the module paths
+// go.
+func (h goModHighlighter) Highlight(code string) string {
+	var b strings.Builder
+	inBlock := false
+	for _, line := range strings.SplitAfter(code, "\n") {
+		first := true
+		for rest := line; rest != ""; {
+			n, class := 1, ""
+			switch c := rest[0]; {
+			case strings.HasPrefix(rest, "//"):
+				n, class = len(strings.TrimRight(rest, "\r\n")), h.comment
+			case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			case strings.HasPrefix(rest, "=>"):
+				n, class = 2, h.operator
+			case c == '(' || c == ')':
+				inBlock = c == '('
+				class = h.operator
+			case c == '[' || c == ']' || c == ',':
+				class = h.operator
+			case c == '"' || c == '`':
+				if i := strings.IndexByte(rest[1:], c); i >= 0 {
+					n = i + 2
+				} else {
+					n = len(strings.TrimRight(rest, "\r\n"))
+				}
+				class = h.literal
+			default:
+				if i := strings.IndexAny(rest, goModDelims); i >= 0 {
+					n = i
+				} else {
+					n = len(rest)
+				}
+				word := rest[:n]
+				switch {
+				case first && !inBlock && goModDirectives[word]:
+					class = h.keyword
+				case goModVersion.MatchString(word):
+					class = h.literal
+				default:
+					class = h.ident
+				}
+				first = false
+			}
+			text := html.EscapeString(rest[:n])
+			if class != "" {
+				text = `<span class="` + class + `">` + text + "</span>"
+			}
+			b.WriteString(text)
+			rest = rest[n:]
+		}
+	}
+	return b.String()
+}
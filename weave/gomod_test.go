@@ -0,0 +1,32 @@
+package weave
+
+import "testing"
+
+func TestGoModHighlighter(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"module example.com/m\n",
+			`<span class="keyword">module</span> <span class="ident">example.com/m</span>` + "\n"},
+		{"go 1.22 // minimum",
+			`<span class="keyword">go</span> <span class="literal">1.22</span> <span class="comment">// minimum</span>`},
+		{"require (\n\tgo v1.0.0\n)\n",
+			`<span class="keyword">require</span> <span class="operator">(</span>` + "\n\t" +
+				`<span class="ident">go</span> <span class="literal">v1.0.0</span>` + "\n" +
+				`<span class="operator">)</span>` + "\n"},
+		{"replace a => ../a",
+			`<span class="keyword">replace</span> <span class="ident">a</span> <span class="operator">=&gt;</span> <span class="ident">../a</span>`},
+		{"retract [v1.0.0, v1.0.5]",
+			`<span class="keyword">retract</span> <span class="operator">[</span><span class="literal">v1.0.0</span><span class="operator">,</span> ` +
+				`<span class="literal">v1.0.5</span><span class="operator">]</span>`},
+		{"golang.org/x/net v0.22.0/go.mod h1:abc=",
+			`<span class="ident">golang.org/x/net</span> <span class="literal">v0.22.0/go.mod</span> <span class="literal">h1:abc=</span>`},
+	}
+	h := Litebrite.(LanguageHighlighter).ForLanguage(GoMod.Name)
+	for _, tt := range tests {
+		if got := h.Highlight(tt.code); got != tt.want {
+			t.Errorf("Highlight(%q) =\n%s\nwant\n%s", tt.code, got, tt.want)
+		}
+	}
+}
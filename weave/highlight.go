@@ -0,0 +1,131 @@
+package weave
+
+import (
+	"html"
+	"strings"
+
+	"github.com/dhconnelly/litebrite"
+)
+
+// Highlighter turns a snippet of source code into HTML. Highlighters must
+// escape the code, and must keep no state between calls, so that
+// goroutines can share them.
+type Highlighter interface {
+	Highlight(code string) string
+}
+
+// LanguageHighlighter is a Highlighter that can switch to other languages
+// than Go. ForLanguage receives a Language.Name.
+type LanguageHighlighter interface {
+	Highlighter
+	ForLanguage(name string) Highlighter
+}
+
+// Litebrite is the default highlighter. It knows Go only, and wraps the
+// tokens in elements of the classes "operator", "ident", "literal",
+// "keyword", and "comment". Code in other languages stays plain, except
+// for go.mod and go.sum files.
+var Litebrite Highlighter = litebriteHighlighter{}
+
+// PlainText escapes the code without highlighting it.
+var PlainText Highlighter = plainHighlighter{}
+
+type litebriteHighlighter struct{}
+
+type plainHighlighter struct{}
+
+func (plainHighlighter) Highlight(s string) string {
+	return html.EscapeString(s)
+}
+
+// litebrite eats leading whitespace when fed with code snippets.
+// To address this, splitLeadingWs splits the code into leading whitespace
+// and the rest, to be re-joined after highlighting.
+func splitLeadingWs(s string) (string, string) {
+	code := strings.TrimLeft(s, "\t ")
+	return s[:strings.Index(s, code)], code
+}
+
+// The highlighter keeps no state between calls, so all files and
+// goroutines can share a single instance.
+var highlighter = litebrite.Highlighter{
+	OperatorClass: "operator",
+	IdentClass:    "ident",
+	LiteralClass:  "literal",
+	KeywordClass:  "keyword",
+	CommentClass:  "comment",
+}
+
+func (litebriteHighlighter) Highlight(s string) string {
+	ws, code := splitLeadingWs(s)
+	return ws + highlighter.Highlight(code)
+}
+
+func (l litebriteHighlighter) ForLanguage(name string) Highlighter {
+	switch name {
+	case Go.Name:
+		return l
+	case GoMod.Name, GoSum.Name:
+		return goModLitebrite
+	}
+	return PlainText
+}
+
+// Highlight applies syntax highlighting to a code snippet, using
+// Litebrite.
+func Highlight(s string) string {
+	return highlightWith(Litebrite, s)
+}
+
+// Highlight applies syntax highlighting to a code snippet, using
+// Options.Highlighter.
+func (w *Weaver) Highlight(s string) string {
+	return highlightWith(w.opts.Highlighter, s)
+}
+
+func highlightWith(h Highlighter, s string) string {
+	if strings.TrimSpace(strings.Trim(s, "\n")) == "" {
+		return "" // make empty Code *really* empty
+	}
+	return h.Highlight(s)
+}
+
+// HighlightSections applies syntax highlighting to each section's code.
+// In Go code, it also marks the type parameters and their constraints, and
+// with Options.Links, the identifiers declared in other files; see
+// marks.go. Call AssignIDs first, so that the constraints can link to the
+// sections that define them. With Options.Footnotes, it moves the comments
+// at the ends of lines of Go code into footnotes; see footnotes.go. With
+// Options.LineHook, it hands each line of the code to the hook; see
+// lines.go.
+func (w *Weaver) HighlightSections(sections []*Section) {
+	if w.opts.Footnotes && w.opts.Language == Go {
+		takeFootnotes(sections)
+		defer func() {
+			for _, s := range sections {
+				addFootnoteRefs(s)
+			}
+		}()
+	}
+	if w.opts.LineHook != nil {
+		defer w.hookLines(sections) // before the footnote references
+	}
+	var c *goCode
+	if w.opts.Language == Go {
+		c = parseGoCode(sections, w.opts.Links != nil)
+	}
+	if c == nil {
+		for _, s := range sections {
+			s.Code = w.Highlight(s.Code)
+			s.Boilerplate = w.Highlight(s.Boilerplate)
+		}
+		return
+	}
+	c.markGenerics()
+	if w.opts.Links != nil {
+		c.markLinks(w.opts.Links)
+	}
+	for _, p := range c.parts {
+		*p.code = highlightMarked(w.opts.Highlighter, *p.code, p.marks)
+	}
+}
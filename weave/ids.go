@@ -0,0 +1,56 @@
+package weave
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// AssignIDs gives each section an ID derived from its content, so that
+// external tools can refer to a section even after the document was
+// generated anew. The ID consists of the first 12 hex digits of the SHA-256
+// hash of the comment text and the code, separated by a NUL byte. Trailing
+// whitespace and leading or trailing empty lines do not count, so only real
+// edits to a section change its ID. If several sections have the same
+// content, the second one gets "-2" appended to its ID, the third one "-3",
+// and so forth.
+//
+// Sections that have an ID already keep it. Call AssignIDs before rendering,
+// as the IDs are computed from the unrendered text. AssignIDs also gives the
+// sections their anchors; see assignAnchors.
+func AssignIDs(sections []*Section) {
+	defer assignAnchors(sections)
+	seen := map[string]int{}
+	for _, s := range sections {
+		if s.ID != "" {
+			continue
+		}
+		id := sectionID(s)
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			id += "-" + strconv.Itoa(n)
+		}
+		s.ID = id
+	}
+}
+
+// sectionID computes the content hash of an unrendered section.
+func sectionID(s *Section) string {
+	h := sha256.New()
+	io.WriteString(h, normalizeSpace(s.Doc))
+	h.Write([]byte{0})
+	io.WriteString(h, normalizeSpace(s.Code))
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// normalizeSpace removes trailing whitespace from each line, as well as
+// leading and trailing empty lines.
+func normalizeSpace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t\r")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,34 @@
+package weave
+
+import "testing"
+
+func TestAssignSectionIDs(t *testing.T) {
+	sections := []*Section{
+		{Doc: "Doc\n", Code: "code\n"},
+		{Doc: "Doc  \n\n", Code: "\ncode\n\n"},
+		{Doc: "Other doc\n", Code: "code\n"},
+		{Doc: "Doc\n", Code: "code\n"},
+	}
+	AssignIDs(sections)
+	id := sections[0].ID
+	if len(id) != 12 {
+		t.Fatalf("AssignIDs() produced ID %q, want 12 hex digits", id)
+	}
+	want := []string{id, id + "-2", sections[2].ID, id + "-3"}
+	for i, s := range sections {
+		if s.ID != want[i] {
+			t.Errorf("section %d: ID = %q, want %q", i, s.ID, want[i])
+		}
+	}
+	if sections[2].ID == id {
+		t.Errorf("sections with different content got the same ID %q", id)
+	}
+}
+
+func TestAssignIDsKeepsIDs(t *testing.T) {
+	sections := []*Section{{Doc: "Doc\n", ID: "given"}}
+	AssignIDs(sections)
+	if sections[0].ID != "given" {
+		t.Errorf("AssignIDs() replaced ID %q with %q", "given", sections[0].ID)
+	}
+}
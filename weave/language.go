@@ -0,0 +1,245 @@
+package weave
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Language describes the comment syntax of a programming language, which
+// is all that Sections needs to know to tell comments from code. Once a
+// Weaver has used a Language, it must not change.
+type Language struct {
+	// Name is the language's name for the info string of Markdown code
+	// fences and for Chroma's lexers, like "python".
+	Name string
+	// LineComment starts a comment that runs to the end of the line, like
+	// "#". Empty if the language has no such comments.
+	LineComment string
+	// BlockStart and BlockEnd delimit comments that can span several
+	// lines, like "/*" and "*/". Empty if the language has no such
+	// comments.
+	BlockStart, BlockEnd string
+	// Directives are prefixes of comment lines that address a tool rather
+	// than the reader, like "//go:" or the "#!" of a shebang line. Sections
+	// leaves them out, unless Options.KeepDirectives is set.
+	Directives []string
+}
+
+// Go is the language of Go source files, and the default of
+// Options.Language.
+var Go = &Language{Name: "go", LineComment: "//", BlockStart: "/*", BlockEnd: "*/", Directives: []string{"//go:", "// +build ", "//export ", "//extern ", "//line "}}
+
+// Comment styles that many languages share.
+func cStyle(name string) *Language {
+	return &Language{Name: name, LineComment: "//", BlockStart: "/*", BlockEnd: "*/"}
+}
+
+func hashStyle(name string) *Language {
+	return &Language{Name: name, LineComment: "#", Directives: []string{"#!"}}
+}
+
+// The languages of the files that describe a Go project, rather than
+// implement it. The highlighters know the directives of go.mod files, and
+// the module paths, versions, and hashes of go.sum files, which have no
+// comments.
+var (
+	GoMod = &Language{Name: "go.mod", LineComment: "//"}
+	GoSum = &Language{Name: "go.sum"}
+)
+
+var (
+	langMu    sync.RWMutex
+	languages = map[string]*Language{
+		".go":    Go,
+		".c":     cStyle("c"),
+		".h":     cStyle("c"),
+		".cc":    cStyle("cpp"),
+		".cpp":   cStyle("cpp"),
+		".hpp":   cStyle("cpp"),
+		".cs":    cStyle("csharp"),
+		".java":  cStyle("java"),
+		".js":    cStyle("javascript"),
+		".mjs":   cStyle("javascript"),
+		".ts":    cStyle("typescript"),
+		".kt":    cStyle("kotlin"),
+		".rs":    cStyle("rust"),
+		".scala": cStyle("scala"),
+		".swift": cStyle("swift"),
+		".css":   {Name: "css", BlockStart: "/*", BlockEnd: "*/"},
+		".py":    hashStyle("python"),
+		".rb":    hashStyle("ruby"),
+		".pl":    hashStyle("perl"),
+		".sh":    hashStyle("bash"),
+		".bash":  hashStyle("bash"),
+		".yaml":  hashStyle("yaml"),
+		".yml":   hashStyle("yaml"),
+		".toml":  hashStyle("toml"),
+		".sql":   {Name: "sql", LineComment: "--", BlockStart: "/*", BlockEnd: "*/"},
+		".lua":   {Name: "lua", LineComment: "--", BlockStart: "--[[", BlockEnd: "]]", Directives: []string{"#!"}},
+		".hs":    {Name: "haskell", LineComment: "--", BlockStart: "{-", BlockEnd: "-}"},
+		".mk":    {Name: "makefile", LineComment: "#"},
+
+		// Files known by their names rather than their extensions.
+		"go.mod":      GoMod,
+		"go.sum":      GoSum,
+		"go.work":     GoMod,
+		"makefile":    {Name: "makefile", LineComment: "#"},
+		"gnumakefile": {Name: "makefile", LineComment: "#"},
+		"dockerfile":  {Name: "dockerfile", LineComment: "#", Directives: []string{"# syntax=", "# escape="}},
+	}
+)
+
+// LanguageFor returns the language of a source file by its name, like
+// "Makefile", or else by its extension, or nil if neither is registered.
+// Case does not matter.
+func LanguageFor(filename string) *Language {
+	langMu.RLock()
+	defer langMu.RUnlock()
+	if l, ok := languages[strings.ToLower(filepath.Base(filename))]; ok {
+		return l
+	}
+	return languages[strings.ToLower(filepath.Ext(filename))]
+}
+
+// LanguageNamed returns the registered language with the given name, like
+// "python", or nil if there is none.
+func LanguageNamed(name string) *Language {
+	langMu.RLock()
+	defer langMu.RUnlock()
+	for _, l := range languages {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// RegisterLanguage sets the language of source files with the extension
+// ext, like ".py", replacing any language registered before. Without a
+// leading dot, ext is the full name of the files, like "Jenkinsfile". A nil
+// language removes the registration.
+func RegisterLanguage(ext string, l *Language) {
+	langMu.Lock()
+	if l == nil {
+		delete(languages, strings.ToLower(ext))
+	} else {
+		languages[strings.ToLower(ext)] = l
+	}
+	langMu.Unlock()
+}
+
+// syntax holds the patterns that recognize the comments of a language.
+// A nil pattern matches nothing.
+type syntax struct {
+	comment      *regexp.Regexp // a line comment
+	commentStart *regexp.Regexp // the start of a block comment
+	commentEnd   *regexp.Regexp // the end of a block comment
+	delims       *regexp.Regexp // any comment delimiter, for stripping
+	directive    *regexp.Regexp
+	pragma       *regexp.Regexp
+}
+
+// syntaxes caches the patterns of each language.
+var syntaxes sync.Map
+
+// syntax returns the patterns of the language's comments.
+func (l *Language) syntax() *syntax {
+	if s, ok := syntaxes.Load(l); ok {
+		return s.(*syntax)
+	}
+	s := &syntax{}
+	var delims []string
+	// The block delimiters come first, as they may start with the line
+	// comment marker, like Lua's "--[[".
+	if l.BlockStart != "" && l.BlockEnd != "" {
+		start := `^\s*` + regexp.QuoteMeta(l.BlockStart) + `\s?`
+		end := `\s?` + regexp.QuoteMeta(l.BlockEnd) + `\s*$`
+		s.commentStart = regexp.MustCompile(start)
+		s.commentEnd = regexp.MustCompile(end)
+		delims = append(delims, start, end)
+	}
+	if l.LineComment != "" {
+		line := `^\s*` + regexp.QuoteMeta(l.LineComment) + `\s?`
+		s.comment = regexp.MustCompile(line)
+		delims = append(delims, line)
+		s.pragma = regexp.MustCompile(`^` + regexp.QuoteMeta(l.LineComment) + `(?:goweave:([\w-]+)(?:\s+(.*?))?|meta:\s*([\w-]+)\s*=\s*(.*?))\s*$`)
+	}
+	if len(delims) > 0 {
+		s.delims = regexp.MustCompile(strings.Join(delims, "|"))
+	}
+	if len(l.Directives) > 0 {
+		prefixes := make([]string, len(l.Directives))
+		for i, d := range l.Directives {
+			prefixes[i] = regexp.QuoteMeta(d)
+		}
+		s.directive = regexp.MustCompile(`^(?:` + strings.Join(prefixes, "|") + `)`)
+	}
+	actual, _ := syntaxes.LoadOrStore(l, s)
+	return actual.(*syntax)
+}
+
+// markedLanguage is a language along with a prose marker.
+type markedLanguage struct {
+	lang   *Language
+	marker string
+}
+
+// proseSyntaxes caches the patterns of each language and prose marker.
+var proseSyntaxes sync.Map
+
+// proseSyntax returns the patterns of the language's comments for
+// Options.ProseMarker: only the line comments that start with the marker
+// count as comments, and block comments are code. Languages without line
+// comments keep their block comments.
+func (l *Language) proseSyntax(marker string) *syntax {
+	if marker == "" || l.LineComment == "" {
+		return l.syntax()
+	}
+	key := markedLanguage{l, marker}
+	if s, ok := proseSyntaxes.Load(key); ok {
+		return s.(*syntax)
+	}
+	s := *l.syntax()
+	s.comment = regexp.MustCompile(`^\s*` + regexp.QuoteMeta(l.LineComment+marker) + `\s?`)
+	s.commentStart, s.commentEnd = nil, nil
+	s.delims = s.comment
+	actual, _ := proseSyntaxes.LoadOrStore(key, &s)
+	return actual.(*syntax)
+}
+
+// match returns true if the pattern exists and matches the line.
+func match(re *regexp.Regexp, line string) bool {
+	return re != nil && re.MatchString(line)
+}
+
+// IsDirective returns true if the line is a directive of the language.
+func (l *Language) IsDirective(line string) bool {
+	return match(l.syntax().directive, line)
+}
+
+// IsPragma returns true if the line is a goweave pragma, which is a line
+// comment of the form `<marker>goweave:<key> <value>`, as in
+// `#goweave:title` for Python, or a meta line of the form
+// `<marker>meta: <key>=<value>`, as in `//meta: title=Parsing`.
+func (l *Language) IsPragma(line string) bool {
+	return match(l.syntax().pragma, line)
+}
+
+// ParsePragma returns the key and the value of a goweave pragma. ok is
+// false if the line is no pragma.
+func (l *Language) ParsePragma(line string) (key, value string, ok bool) {
+	p := l.syntax().pragma
+	if p == nil {
+		return "", "", false
+	}
+	m := p.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	if m[1] == "" {
+		return m[3], m[4], true // a meta line
+	}
+	return m[1], m[2], true
+}
@@ -0,0 +1,98 @@
+package weave
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+func TestLanguageFor(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"main.go", "go"},
+		{"script.py", "python"},
+		{"dir/setup.SH", "bash"},
+		{"query.sql", "sql"},
+		{"README", ""},
+		{"go.mod", "go.mod"},
+		{"dir/Makefile", "makefile"},
+		{"rules.mk", "makefile"},
+		{"Dockerfile", "dockerfile"},
+	}
+	for _, tt := range tests {
+		got := ""
+		if l := LanguageFor(tt.filename); l != nil {
+			got = l.Name
+		}
+		if got != tt.want {
+			t.Errorf("LanguageFor(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterLanguage(t *testing.T) {
+	RegisterLanguage(".pyx", LanguageNamed("python"))
+	if l := LanguageFor("a.pyx"); l == nil || l.Name != "python" {
+		t.Errorf("LanguageFor(a.pyx) = %v after registering python", l)
+	}
+	RegisterLanguage(".pyx", nil)
+	if l := LanguageFor("a.pyx"); l != nil {
+		t.Errorf("LanguageFor(a.pyx) = %v after removing the registration", l)
+	}
+	if l := LanguageNamed("python"); l == nil {
+		t.Errorf("LanguageNamed(python) = nil after removing .pyx")
+	}
+}
+
+func TestLanguageSections(t *testing.T) {
+	tests := []struct {
+		ext    string
+		source string
+		want   []*Section
+	}{
+		{".py", "#!/usr/bin/env python\n#goweave:title Script\n# Doc\nx = 1  # not a section",
+			[]*Section{{Doc: "Doc\n", Code: "x = 1  # not a section\n", DocStart: 3, DocEnd: 3, CodeStart: 4, CodeEnd: 4, DocOffset: 44, DocEndOffset: 50, CodeOffset: 50, CodeEndOffset: 72}}},
+		{".sql", "-- Doc\nSELECT 1;\n/* Block\ndoc */\nSELECT 2;",
+			[]*Section{{Doc: "Doc\n", Code: "SELECT 1;\n", DocStart: 1, DocEnd: 1, CodeStart: 2, CodeEnd: 2, DocEndOffset: 7, CodeOffset: 7, CodeEndOffset: 17},
+				{Doc: "Block\ndoc\n", Code: "SELECT 2;\n", Position: 1, DocStart: 3, DocEnd: 4, CodeStart: 5, CodeEnd: 5, DocOffset: 17, DocEndOffset: 33, CodeOffset: 33, CodeEndOffset: 42}}},
+		{".lua", "--[[ Block\n]]\n-- Line\nprint(1)",
+			[]*Section{{Doc: "Block\n\nLine\n", Code: "print(1)\n", DocStart: 1, DocEnd: 3, CodeStart: 4, CodeEnd: 4, DocEndOffset: 22, CodeOffset: 22, CodeEndOffset: 30}}},
+		{".css", "/* Doc */\nbody {}",
+			[]*Section{{Doc: "Doc\n", Code: "body {}\n", DocStart: 1, DocEnd: 1, CodeStart: 2, CodeEnd: 2, DocEndOffset: 10, CodeOffset: 10, CodeEndOffset: 17}}},
+	}
+	for _, tt := range tests {
+		got := New(Options{Language: LanguageFor(tt.ext)}).Sections(tt.source)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Sections() = %v, want %v", tt.ext, spew.Sdump(got), spew.Sdump(tt.want))
+		}
+	}
+}
+
+func TestLanguagePragma(t *testing.T) {
+	py := LanguageFor(".py")
+	if key, value, ok := py.ParsePragma("#goweave:title Script"); !ok || key != "title" || value != "Script" {
+		t.Errorf("ParsePragma() = %q, %q, %v, want title, Script, true", key, value, ok)
+	}
+	if key, value, ok := py.ParsePragma("#meta: tags = a, b "); !ok || key != "tags" || value != "a, b" {
+		t.Errorf("ParsePragma() = %q, %q, %v, want tags, a, b, true", key, value, ok)
+	}
+	if py.IsPragma("#meta: not a key value pair") {
+		t.Errorf("IsPragma() accepts a meta line without a key")
+	}
+	if py.IsPragma("//goweave:title Script") {
+		t.Errorf("IsPragma() accepts a Go pragma in Python")
+	}
+	if LanguageFor(".css").IsPragma("/*goweave:title x*/") {
+		t.Errorf("IsPragma() = true for a language without line comments")
+	}
+}
+
+func TestLanguageHighlighter(t *testing.T) {
+	w := New(Options{Language: LanguageFor(".py")})
+	if got, want := w.Highlight("if a < b:\n"), "if a &lt; b:\n"; got != want {
+		t.Errorf("Highlight() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,272 @@
+package weave
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// LaTeX and LaTeXSections turn the Markdown of the comments into LaTeX,
+// with the LaTeX renderer of blackfriday, which needs a few corrections:
+// it writes a whole document rather than a fragment, escapes backslashes
+// and tildes into line breaks and accents, and knows no heading offset.
+// Headings become \section, \subsection, \subsubsection, \paragraph,
+// \subparagraph, and bold text, each with a \label of its ID, so that
+// links to `#id` become \hyperref links. Fenced code becomes an lstlisting
+// environment of the listings package, or with Options.Minted, a minted
+// environment. The fragments need the packages hyperref, graphicx, ulem,
+// and listings or minted.
+
+// latexEscaper escapes the characters that LaTeX takes for markup.
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// latexURLEscaper escapes the characters of URLs that \href and \url
+// would take for markup.
+var latexURLEscaper = strings.NewReplacer(`\`, `\\`, `#`, `\#`, `%`, `\%`)
+
+// EscapeLaTeX escapes text for LaTeX.
+func EscapeLaTeX(text string) string {
+	return latexEscaper.Replace(text)
+}
+
+// latexCommands are the commands for the heading levels.
+var latexCommands = [...]string{`\section`, `\subsection`, `\subsubsection`, `\paragraph`, `\subparagraph`, `\textbf`}
+
+// listingsLanguages are the names that the listings package has for the
+// languages of fenced code. listings fails on languages it does not know,
+// so code in other languages has none. listings has no Go; LaTeXPreamble
+// defines it.
+var listingsLanguages = map[string]string{
+	"go": "Go", "golang": "Go", "c": "C", "c++": "C++", "cpp": "C++", "java": "Java",
+	"python": "Python", "py": "Python", "bash": "bash", "sh": "sh", "shell": "bash",
+	"sql": "SQL", "html": "HTML", "xml": "XML", "ruby": "Ruby", "perl": "Perl",
+	"php": "PHP", "lua": "Lua", "haskell": "Haskell", "r": "R", "tex": "TeX", "latex": "TeX",
+}
+
+// LaTeXLanguage returns the name of a language for the code environment:
+// the name of listings, or "" if listings does not know it, or for minted,
+// whose Pygments knows most languages by their usual names, the name
+// itself, or "text".
+func (w *Weaver) LaTeXLanguage(lang string) string {
+	lang = strings.ToLower(lang)
+	if w.opts.Minted {
+		if lang == "" {
+			return "text"
+		}
+		return lang
+	}
+	return listingsLanguages[lang]
+}
+
+// LaTeXCode returns the code environment for code in a language, with a
+// caption and a label if caption is not empty.
+func (w *Weaver) LaTeXCode(code, lang, caption, label string) string {
+	code = strings.TrimRight(code, "\n") + "\n"
+	lang = w.LaTeXLanguage(lang)
+	if w.opts.Minted {
+		env := `\begin{minted}{` + lang + "}\n" + code + "\\end{minted}\n"
+		if caption == "" {
+			return env
+		}
+		return "\\begin{listing}[htbp]\n" + env + `\caption{` + EscapeLaTeX(caption) + `}\label{` + label + "}\n\\end{listing}\n"
+	}
+	var opts []string
+	if lang != "" {
+		opts = append(opts, "language="+lang)
+	}
+	if caption != "" {
+		opts = append(opts, "caption={"+EscapeLaTeX(caption)+"}", "label={"+label+"}")
+	}
+	begin := `\begin{lstlisting}`
+	if len(opts) > 0 {
+		begin += "[" + strings.Join(opts, ", ") + "]"
+	}
+	return begin + "\n" + code + "\\end{lstlisting}\n"
+}
+
+// LaTeX renders Markdown as LaTeX.
+func (w *Weaver) LaTeX(input string) string {
+	var formulas []formula
+	if w.opts.Math {
+		input, formulas = protectMath(input)
+	}
+	out := string(blackfriday.MarkdownOptions([]byte(input), latexRenderer{blackfriday.LatexRenderer(0), w},
+		blackfriday.Options{Extensions: markdownExtensions}))
+	return restoreMath(out, formulas, latexFormula)
+}
+
+// LaTeXSections renders the documentation of each section as LaTeX.
+func (w *Weaver) LaTeXSections(sections []*Section) {
+	for _, s := range sections {
+		s.Doc = w.LaTeX(s.Doc)
+	}
+}
+
+// latexRenderer corrects the LaTeX renderer of blackfriday.
+type latexRenderer struct {
+	blackfriday.Renderer
+	w *Weaver
+}
+
+func (r latexRenderer) DocumentHeader(out *bytes.Buffer) {}
+func (r latexRenderer) DocumentFooter(out *bytes.Buffer) {}
+
+func (r latexRenderer) BlockCode(out *bytes.Buffer, text []byte, infoString string) {
+	lang := ""
+	if f := strings.Fields(infoString); len(f) > 0 {
+		lang = f[0]
+	}
+	out.WriteString("\n" + r.w.LaTeXCode(string(text), lang, "", ""))
+}
+
+// BlockHtml leaves out HTML blocks, which LaTeX cannot show.
+func (r latexRenderer) BlockHtml(out *bytes.Buffer, text []byte) {}
+
+func (r latexRenderer) Header(out *bytes.Buffer, text func() bool, level int, id string) {
+	marker := out.Len()
+	out.WriteString("\n" + latexCommands[r.w.HeadingLevel(level)-1] + "{")
+	if !text() {
+		out.Truncate(marker)
+		return
+	}
+	out.WriteString("}")
+	if id != "" {
+		out.WriteString(`\label{` + id + "}")
+	}
+	out.WriteString("\n")
+}
+
+func (r latexRenderer) HRule(out *bytes.Buffer) {
+	out.WriteString("\n\\par\\noindent\\rule{\\linewidth}{0.4pt}\n")
+}
+
+func (r latexRenderer) List(out *bytes.Buffer, text func() bool, flags int) {
+	env := "itemize"
+	switch {
+	case flags&blackfriday.LIST_TYPE_DEFINITION != 0:
+		env = "description"
+	case flags&blackfriday.LIST_TYPE_ORDERED != 0:
+		env = "enumerate"
+	}
+	marker := out.Len()
+	out.WriteString("\n\\begin{" + env + "}\n")
+	if !text() {
+		out.Truncate(marker)
+		return
+	}
+	out.WriteString("\n\\end{" + env + "}\n")
+}
+
+func (r latexRenderer) ListItem(out *bytes.Buffer, text []byte, flags int) {
+	switch {
+	case flags&blackfriday.LIST_TYPE_TERM != 0:
+		out.WriteString("\n\\item[" + strings.TrimSpace(string(text)) + "] ")
+	case flags&blackfriday.LIST_TYPE_DEFINITION != 0:
+		out.Write(text)
+	default:
+		out.WriteString("\n\\item ")
+		out.Write(text)
+	}
+}
+
+func (r latexRenderer) AutoLink(out *bytes.Buffer, link []byte, kind int) {
+	url := string(link)
+	if kind == blackfriday.LINK_TYPE_EMAIL && !strings.HasPrefix(url, "mailto:") {
+		url = "mailto:" + url
+	}
+	out.WriteString(`\href{` + latexURLEscaper.Replace(url) + "}{" + EscapeLaTeX(string(link)) + "}")
+}
+
+func (r latexRenderer) CodeSpan(out *bytes.Buffer, text []byte) {
+	out.WriteString(`\texttt{` + EscapeLaTeX(string(text)) + "}")
+}
+
+// Link links to a label for a fragment of the same document, like a
+// heading, and to a URL otherwise.
+func (r latexRenderer) Link(out *bytes.Buffer, link []byte, title []byte, content []byte) {
+	if id := string(link); strings.HasPrefix(id, "#") {
+		out.WriteString(`\hyperref[` + id[1:] + "]{")
+	} else {
+		out.WriteString(`\href{` + latexURLEscaper.Replace(id) + "}{")
+	}
+	out.Write(content)
+	out.WriteString("}")
+}
+
+func (r latexRenderer) Entity(out *bytes.Buffer, entity []byte) {
+	out.WriteString(EscapeLaTeX(html.UnescapeString(string(entity))))
+}
+
+func (r latexRenderer) NormalText(out *bytes.Buffer, text []byte) {
+	out.WriteString(EscapeLaTeX(string(text)))
+}
+
+// listingsGo defines Go for the listings package.
+const listingsGo = `\lstdefinelanguage{Go}{
+  morekeywords={break,case,chan,const,continue,default,defer,else,fallthrough,for,func,go,goto,if,import,interface,map,package,range,return,select,struct,switch,type,var},
+  morekeywords=[2]{any,bool,byte,comparable,complex64,complex128,error,float32,float64,int,int8,int16,int32,int64,rune,string,uint,uint8,uint16,uint32,uint64,uintptr,true,false,nil,iota},
+  sensitive=true,
+  morecomment=[l]{//},
+  morecomment=[s]{/*}{*/},
+  morestring=[b]",
+  morestring=[b]',
+  morestring=[b]` + "`" + `,
+}
+`
+
+// listingsLiterate maps the non-ASCII characters that code commonly has to
+// LaTeX, as listings takes UTF-8 for single bytes under pdflatex. Code
+// with other characters needs minted, or xelatex or lualatex.
+var listingsLiterate = [][2]string{
+	{"ä", `\"a`}, {"ö", `\"o`}, {"ü", `\"u`}, {"Ä", `\"A`}, {"Ö", `\"O`}, {"Ü", `\"U`}, {"ß", `\ss{}`},
+	{"á", `\'a`}, {"é", `\'e`}, {"í", `\'i`}, {"ó", `\'o`}, {"ú", `\'u`}, {"à", "\\`a"}, {"è", "\\`e"},
+	{"â", `\^a`}, {"ê", `\^e`}, {"ç", `\c{c}`}, {"ñ", `\~n`}, {"å", `\aa{}`}, {"ø", `\o{}`},
+	{"“", "``"}, {"”", "''"}, {"‘", "`"}, {"’", "'"}, {"–", "--"}, {"—", "---"}, {"…", `\ldots{}`},
+	{"€", `\texteuro{}`}, {"£", `\pounds{}`}, {"°", `\textdegree{}`}, {"×", `$\times$`}, {"·", `$\cdot$`},
+	{"→", `$\rightarrow$`}, {"←", `$\leftarrow$`}, {"≤", `$\le$`}, {"≥", `$\ge$`}, {"≠", `$\ne$`},
+	{"λ", `$\lambda$`}, {"π", `$\pi$`}, {"µ", `$\mu$`}, {"μ", `$\mu$`},
+}
+
+// listingsLiterateOption returns the literate option of listings for
+// listingsLiterate.
+func listingsLiterateOption() string {
+	var b strings.Builder
+	b.WriteString("literate=")
+	for _, m := range listingsLiterate {
+		b.WriteString("{" + m[0] + "}{{" + m[1] + "}}1 ")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// LaTeXPreamble returns the \usepackage commands and settings that the
+// LaTeX of the Weaver needs, for the preamble of a document.
+func (w *Weaver) LaTeXPreamble() string {
+	preamble := `\usepackage[utf8]{inputenc}
+\usepackage[T1]{fontenc}
+\usepackage{graphicx}
+\usepackage[normalem]{ulem}
+\usepackage{hyperref}
+`
+	if w.opts.Minted {
+		return preamble + `\usepackage{minted}
+\setminted{fontsize=\small,tabsize=4,breaklines}
+`
+	}
+	return preamble + `\usepackage{listings}
+` + listingsGo + `\lstset{basicstyle=\ttfamily\small,keywordstyle=\bfseries,commentstyle=\itshape,columns=fullflexible,tabsize=4,breaklines=true,
+  extendedchars=true,` + listingsLiterateOption() + `}
+`
+}
@@ -0,0 +1,53 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLaTeX(t *testing.T) {
+	tests := []struct {
+		minted   bool
+		in, want string
+	}{
+		{false, "## Costs\n\nThe *fee* is 5% of `a_b` \\~ [more](#costs).\n",
+			"\n\\subsection{Costs}\\label{costs}\n\nThe \\textit{fee} is 5\\% of \\texttt{a\\_b} \\textasciitilde{} \\hyperref[costs]{more}.\n"},
+		{false, "```go\nx := 1\n```\n",
+			"\n\\begin{lstlisting}[language=Go]\nx := 1\n\\end{lstlisting}\n"},
+		{false, "```zig\nconst x = 1;\n```\n",
+			"\n\\begin{lstlisting}\nconst x = 1;\n\\end{lstlisting}\n"},
+		{true, "```zig\nconst x = 1;\n```\n",
+			"\n\\begin{minted}{zig}\nconst x = 1;\n\\end{minted}\n"},
+	}
+	for _, tt := range tests {
+		w := New(Options{Minted: tt.minted})
+		if got := w.LaTeX(tt.in); got != tt.want {
+			t.Errorf("LaTeX(%q) =\n%q\nwant\n%q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLaTeXCode(t *testing.T) {
+	w := New(Options{})
+	want := "\\begin{lstlisting}[language=Go, caption={Costs \\& fees}, label={listing-1}]\nx := 1\n\\end{lstlisting}\n"
+	if got := w.LaTeXCode("x := 1\n\n", "go", "Costs & fees", "listing-1"); got != want {
+		t.Errorf("LaTeXCode() = %q, want %q", got, want)
+	}
+	w = New(Options{Minted: true})
+	want = "\\begin{listing}[htbp]\n\\begin{minted}{go}\nx := 1\n\\end{minted}\n\\caption{Costs}\\label{listing-1}\n\\end{listing}\n"
+	if got := w.LaTeXCode("x := 1", "go", "Costs", "listing-1"); got != want {
+		t.Errorf("LaTeXCode() with Minted = %q, want %q", got, want)
+	}
+}
+
+func TestLaTeXPreamble(t *testing.T) {
+	got := New(Options{}).LaTeXPreamble()
+	for _, want := range []string{`\lstdefinelanguage{Go}`, `extendedchars=true`, `literate={ä}{{\"a}}1 `, `{→}{{$\rightarrow$}}1`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("LaTeXPreamble() =\n%s\nwant %q in it", got, want)
+		}
+	}
+	if got := New(Options{Minted: true}).LaTeXPreamble(); strings.Contains(got, "literate") {
+		t.Errorf("LaTeXPreamble() with Minted =\n%s\nwant no listings options", got)
+	}
+}
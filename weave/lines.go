@@ -0,0 +1,246 @@
+package weave
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+)
+
+// A Highlighter returns HTML, which is all that a template needs, but a
+// poor base for features that work line by line, like coverage overlays
+// or anchors for each line: they would have to take the HTML apart again,
+// with elements that span several lines, like block comments. So the
+// highlighted code is also available as lines of tokens, and with
+// Options.LineHook, HighlightSections hands each line to a function that
+// can add an ID or a class to the line, or change its tokens.
+
+// Token is a piece of highlighted code, like a keyword or an identifier.
+type Token struct {
+	Text  string // the code, not escaped
+	Class string // the class of the token, or "" for plain text like whitespace
+	// Mark, Href, and Title come from the identifiers that HighlightSections
+	// wraps (see marks.go): the class of the element around the token, and
+	// its link and title.
+	Mark, Href, Title string
+}
+
+// Line is a line of highlighted code, without its newline.
+type Line struct {
+	Number int // counting from 1 within the code
+	Tokens []Token
+	// ID and Class, if set, wrap the line in a span with these
+	// attributes.
+	ID, Class string
+}
+
+// LineHighlighter is a Highlighter that returns the lines of tokens
+// directly, rather than HTML that HighlightLines has to parse. lang is a
+// Language.Name, or "" for the language of the highlighter.
+type LineHighlighter interface {
+	Highlighter
+	HighlightLines(code, lang string) ([]Line, error)
+}
+
+// HighlightLines highlights code into lines of tokens. The HTML of
+// highlighters that are no LineHighlighter gets parsed; lang picks the
+// language of a LanguageHighlighter.
+func HighlightLines(h Highlighter, code, lang string) ([]Line, error) {
+	if lh, ok := h.(LineHighlighter); ok {
+		return lh.HighlightLines(code, lang)
+	}
+	if lh, ok := h.(LanguageHighlighter); ok && lang != "" {
+		h = lh.ForLanguage(lang)
+	}
+	return ParseLines(highlightWith(h, code))
+}
+
+var htmlTag = regexp.MustCompile(`^<(/?)(\w+)((?:\s+\w+="[^"]*")*)\s*>`)
+
+var htmlAttr = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// htmlElement is an open element while parsing highlighted code.
+type htmlElement struct {
+	tag   string
+	attrs map[string]string
+}
+
+// ParseLines splits the HTML of a highlighter into lines of tokens. It
+// knows the elements that highlighters and marks use: a span with a class
+// around a token, and a span or a link around that for a mark. An element
+// that spans several lines ends up in a token on each of them.
+func ParseLines(highlighted string) ([]Line, error) {
+	lines := []Line{{Number: 1}}
+	var open []htmlElement
+	add := func(text string) {
+		t := Token{Text: text}
+		for i, e := range open {
+			mark := e.tag == "a" || e.attrs["title"] != "" || i < len(open)-1
+			if mark {
+				t.Mark, t.Href, t.Title = e.attrs["class"], e.attrs["href"], e.attrs["title"]
+			} else {
+				t.Class = e.attrs["class"]
+			}
+		}
+		for i, part := range strings.Split(text, "\n") {
+			if i > 0 {
+				lines = append(lines, Line{Number: len(lines) + 1})
+			}
+			if part != "" {
+				t.Text = part
+				l := &lines[len(lines)-1]
+				l.Tokens = append(l.Tokens, t)
+			}
+		}
+	}
+	for rest := highlighted; rest != ""; {
+		if rest[0] != '<' {
+			n := strings.IndexByte(rest, '<')
+			if n < 0 {
+				n = len(rest)
+			}
+			add(html.UnescapeString(rest[:n]))
+			rest = rest[n:]
+			continue
+		}
+		m := htmlTag.FindStringSubmatch(rest)
+		if m == nil {
+			return nil, fmt.Errorf("unexpected markup in highlighted code: %.20q", rest)
+		}
+		rest = rest[len(m[0]):]
+		if m[1] == "/" {
+			if len(open) == 0 || open[len(open)-1].tag != m[2] {
+				return nil, fmt.Errorf("unbalanced </%s> in highlighted code", m[2])
+			}
+			open = open[:len(open)-1]
+			continue
+		}
+		e := htmlElement{tag: m[2], attrs: map[string]string{}}
+		for _, a := range htmlAttr.FindAllStringSubmatch(m[3], -1) {
+			e.attrs[a[1]] = html.UnescapeString(a[2])
+		}
+		open = append(open, e)
+	}
+	if len(open) > 0 {
+		return nil, fmt.Errorf("unclosed <%s> in highlighted code", open[len(open)-1].tag)
+	}
+	return lines, nil
+}
+
+// RenderLines returns the HTML of lines of tokens, with a newline between
+// two lines.
+func RenderLines(lines []Line) string {
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if l.ID != "" || l.Class != "" {
+			b.WriteString("<span")
+			if l.ID != "" {
+				b.WriteString(` id="` + html.EscapeString(l.ID) + `"`)
+			}
+			if l.Class != "" {
+				b.WriteString(` class="` + html.EscapeString(l.Class) + `"`)
+			}
+			b.WriteString(">")
+		}
+		for _, t := range l.Tokens {
+			renderToken(&b, t)
+		}
+		if l.ID != "" || l.Class != "" {
+			b.WriteString("</span>")
+		}
+	}
+	return b.String()
+}
+
+// renderToken writes a token the way highlightMarked does.
+func renderToken(b *strings.Builder, t Token) {
+	mark := t.Mark != "" || t.Href != "" || t.Title != ""
+	tag := "span"
+	if mark {
+		if t.Href != "" {
+			tag = "a"
+		}
+		b.WriteString("<" + tag)
+		if t.Href != "" {
+			b.WriteString(` href="` + html.EscapeString(t.Href) + `"`)
+		}
+		b.WriteString(` class="` + t.Mark + `"`)
+		if t.Title != "" {
+			b.WriteString(` title="` + html.EscapeString(t.Title) + `"`)
+		}
+		b.WriteString(">")
+	}
+	if t.Class != "" {
+		b.WriteString(`<span class="` + t.Class + `">` + html.EscapeString(t.Text) + "</span>")
+	} else {
+		b.WriteString(html.EscapeString(t.Text))
+	}
+	if mark {
+		b.WriteString("</" + tag + ">")
+	}
+}
+
+// HighlightLines implements LineHighlighter with the tokens of Chroma's
+// lexer.
+func (c *Chroma) HighlightLines(code, lang string) ([]Line, error) {
+	lexer := c.lexer
+	if lang != "" && lang != Go.Name {
+		if lang == GoMod.Name || lang == GoSum.Name {
+			return ParseLines(highlightWith(goModChroma, code))
+		}
+		if l := lexers.Get(lang); l != nil {
+			lexer = chroma.Coalesce(l)
+		} else {
+			lexer = chroma.Coalesce(lexers.Fallback)
+		}
+	}
+	lines := []Line{{Number: 1}}
+	if strings.TrimSpace(strings.Trim(code, "\n")) == "" {
+		return lines, nil
+	}
+	it, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range it.Tokens() {
+		for i, part := range strings.Split(t.Value, "\n") {
+			if i > 0 {
+				lines = append(lines, Line{Number: len(lines) + 1})
+			}
+			if part != "" {
+				l := &lines[len(lines)-1]
+				l.Tokens = append(l.Tokens, Token{Text: part, Class: tokenClass(t.Type)})
+			}
+		}
+	}
+	// The lexer adds a final newline if the code has none.
+	if !strings.HasSuffix(code, "\n") && len(lines) > 1 && len(lines[len(lines)-1].Tokens) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+// hookLines hands each line of the code of the sections to
+// Options.LineHook. The code of a section stays as it is if its HTML does
+// not parse.
+func (w *Weaver) hookLines(sections []*Section) {
+	for _, s := range sections {
+		if s.Code == "" {
+			continue
+		}
+		lines, err := ParseLines(s.Code)
+		if err != nil {
+			continue
+		}
+		for i := range lines {
+			w.opts.LineHook(s, &lines[i])
+		}
+		s.Code = RenderLines(lines)
+	}
+}
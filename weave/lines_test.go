@@ -0,0 +1,86 @@
+package weave
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLines(t *testing.T) {
+	tests := []struct {
+		html string
+		want []Line
+	}{
+		{"", []Line{{Number: 1}}},
+		{`<span class="keyword">var</span> x` + "\n", []Line{
+			{Number: 1, Tokens: []Token{{Text: "var", Class: "keyword"}, {Text: " x"}}},
+			{Number: 2},
+		}},
+		{`<span class="comment">/* a` + "\n" + `b */</span>`, []Line{
+			{Number: 1, Tokens: []Token{{Text: "/* a", Class: "comment"}}},
+			{Number: 2, Tokens: []Token{{Text: "b */", Class: "comment"}}},
+		}},
+		{`<a href="#s1" class="typeparam" title="any"><span class="ident">T</span></a> &lt; 1`, []Line{
+			{Number: 1, Tokens: []Token{{Text: "T", Class: "ident", Mark: "typeparam", Href: "#s1", Title: "any"}, {Text: " < 1"}}},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLines(tt.html)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLines(%q) = %+v, %v; want %+v", tt.html, got, err, tt.want)
+			continue
+		}
+		// Elements that span lines come back once per line.
+		if again, err := ParseLines(RenderLines(got)); err != nil || !reflect.DeepEqual(again, got) {
+			t.Errorf("ParseLines(RenderLines(%+v)) = %+v, %v", got, again, err)
+		}
+	}
+	for _, bad := range []string{"<span class=\"x\">a", "a</span>", "<b"} {
+		if _, err := ParseLines(bad); err == nil {
+			t.Errorf("ParseLines(%q) returned no error", bad)
+		}
+	}
+}
+
+func TestChromaLines(t *testing.T) {
+	c, err := NewChroma("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{"func f() {}\n", "/* a\nb */\nx := `1\n2`\n", "module m\n\ngo 1.21\n"} {
+		lang := ""
+		if strings.HasPrefix(code, "module") {
+			lang = GoMod.Name
+		}
+		got, err := c.HighlightLines(code, lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := Highlighter(c)
+		if lang != "" {
+			h = c.ForLanguage(lang)
+		}
+		want, err := ParseLines(h.Highlight(code))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("HighlightLines(%q) = %+v, want %+v", code, got, want)
+		}
+	}
+}
+
+func TestLineHook(t *testing.T) {
+	w := New(Options{Highlighter: PlainText, LineHook: func(s *Section, l *Line) {
+		if len(l.Tokens) > 0 {
+			l.ID = fmt.Sprintf("L%d", s.CodeStart+l.Number-1)
+		}
+	}})
+	sections := w.Sections("// Doc\nx := `1\n2`\n")
+	w.HighlightSections(sections)
+	want := `<span id="L2">x := ` + "`1</span>\n<span id=\"L3\">2`</span>\n\n"
+	if sections[0].Code != want {
+		t.Errorf("Code = %q, want %q", sections[0].Code, want)
+	}
+}
@@ -0,0 +1,86 @@
+package weave
+
+import (
+	"go/ast"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// With Options.Links set, markLinks wraps the identifiers that the file
+// uses but does not declare, like a function of another file of the same
+// package, or the `Parse` in `parser.Parse`, in links of class "xref".
+// Options.Links knows where the declarations are; markLinks only tells it
+// the import path of the package, or "" for the file's own package, and
+// the name.
+
+// majorVersion matches the major version at the end of an import path,
+// like "v2" in "example.com/mod/v2" or ".v3" in "gopkg.in/yaml.v3".
+var majorVersion = regexp.MustCompile(`(?:/v[0-9]+|\.v[0-9]+)$`)
+
+// importName returns the name of an imported package, as far as the import
+// path tells: the last element, without any major version.
+func importName(importPath string) string {
+	return path.Base(majorVersion.ReplaceAllString(importPath, ""))
+}
+
+// markLinks marks the identifiers that links resolves.
+func (c *goCode) markLinks(links func(pkg, name string) string) {
+	imports := map[string]string{} // by the name of the package in the file
+	for _, imp := range c.file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importName(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name != "_" && name != "." {
+			imports[name] = p
+		}
+	}
+	// The parser leaves some identifiers unresolved that declare nothing
+	// and refer to nothing at the package level.
+	skip := map[*ast.Ident]bool{c.file.Name: true}
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ImportSpec:
+			return false
+		case *ast.FuncDecl:
+			if n.Recv != nil {
+				skip[n.Name] = true // a method
+			}
+		case *ast.CompositeLit:
+			for _, e := range n.Elts {
+				if kv, ok := e.(*ast.KeyValueExpr); ok {
+					if id, ok := kv.Key.(*ast.Ident); ok {
+						skip[id] = true // a field name
+					}
+				}
+			}
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok && x.Obj == nil {
+				if p, ok := imports[x.Name]; ok {
+					if href := links(p, n.Sel.Name); href != "" {
+						c.mark(n.Sel, "xref", x.Name+"."+n.Sel.Name, href)
+					}
+					return false
+				}
+			}
+			// Skip the selector, which names a field or method.
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.Ident:
+			if n.Obj == nil && !skip[n] && !strings.HasPrefix(n.Name, "_") {
+				if href := links("", n.Name); href != "" {
+					c.mark(n, "xref", n.Name, href)
+				}
+			}
+		}
+		return true
+	}
+	ast.Inspect(c.file, visit)
+}
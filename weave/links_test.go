@@ -0,0 +1,87 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	src := `package p
+
+import (
+	"example.com/mod/shapes"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Area adds up the areas.
+func Area(s []shapes.Shape) float64 {
+	var total float64
+	for _, x := range s {
+		total += x.Area()
+	}
+	return total + Unit(yaml.Marshal)
+}
+
+// Unit is a method named like a function of another file.
+func (b Box) Unit() Point { return Point{Unit: 1} }
+`
+	links := func(pkg, name string) string {
+		switch pkg + "." + name {
+		case "example.com/mod/shapes.Shape":
+			return "shapes/shape.html#shape"
+		case "gopkg.in/yaml.v3.Marshal":
+			return "yaml.html#marshal"
+		case ".Unit", ".Point", ".Box":
+			return "other.html#" + strings.ToLower(name)
+		}
+		return ""
+	}
+	w := New(Options{Highlighter: PlainText, Links: links})
+	sections := w.Sections(src)
+	w.HighlightSections(sections)
+	tests := []struct {
+		section int
+		want    []string
+		notWant []string
+	}{
+		{1, []string{
+			`[]shapes.<a href="shapes/shape.html#shape" class="xref" title="shapes.Shape">Shape</a>`,
+			`<a href="other.html#unit" class="xref" title="Unit">Unit</a>(yaml.<a href="yaml.html#marshal" class="xref" title="yaml.Marshal">Marshal</a>)`,
+		}, []string{
+			`x.<a`,
+		}},
+		{2, []string{
+			`func (b <a href="other.html#box" class="xref" title="Box">Box</a>) Unit() <a href="other.html#point"`,
+			`return <a href="other.html#point" class="xref" title="Point">Point</a>{Unit: 1}`,
+		}, nil},
+	}
+	for _, tt := range tests {
+		got := sections[tt.section].Code
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("section %d = %s, want it to contain %s", tt.section, got, want)
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if strings.Contains(got, notWant) {
+				t.Errorf("section %d = %s, want it not to contain %s", tt.section, got, notWant)
+			}
+		}
+	}
+}
+
+func TestImportName(t *testing.T) {
+	tests := []struct {
+		path, want string
+	}{
+		{"fmt", "fmt"},
+		{"net/http", "http"},
+		{"example.com/mod/v2", "mod"},
+		{"gopkg.in/yaml.v3", "yaml"},
+	}
+	for _, tt := range tests {
+		if got := importName(tt.path); got != tt.want {
+			t.Errorf("importName(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
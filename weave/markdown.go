@@ -0,0 +1,82 @@
+package weave
+
+import (
+	"bytes"
+
+	"github.com/russross/blackfriday"
+)
+
+// markdownExtensions are the Markdown extensions of the comments.
+const markdownExtensions = 0 |
+	blackfriday.EXTENSION_NO_INTRA_EMPHASIS |
+	blackfriday.EXTENSION_TABLES |
+	blackfriday.EXTENSION_FENCED_CODE |
+	blackfriday.EXTENSION_AUTOLINK |
+	blackfriday.EXTENSION_STRIKETHROUGH |
+	blackfriday.EXTENSION_SPACE_HEADERS |
+	blackfriday.EXTENSION_HEADER_IDS |
+	blackfriday.EXTENSION_AUTO_HEADER_IDS |
+	blackfriday.EXTENSION_BACKSLASH_LINE_BREAK |
+	blackfriday.EXTENSION_DEFINITION_LISTS
+
+// Markdown applies markdown to the input string, using the
+// commonHtmlFlags and commonExtensions as defined in blackfriday/markdown.go,
+// plus HTML_HREF_TARGET_BLANK. Headings get IDs as described at Headings.
+func (w *Weaver) Markdown(input string) string {
+	return w.markdown(input, headingIDs{})
+}
+
+// markdown renders the input, with heading IDs that are unique among ids.
+func (w *Weaver) markdown(input string, ids headingIDs) string {
+	const (
+		htmlFlags = 0 |
+			blackfriday.HTML_USE_XHTML |
+			blackfriday.HTML_USE_SMARTYPANTS |
+			blackfriday.HTML_SMARTYPANTS_FRACTIONS |
+			blackfriday.HTML_SMARTYPANTS_DASHES |
+			blackfriday.HTML_HREF_TARGET_BLANK
+	)
+	var formulas []formula
+	if w.opts.Math {
+		input, formulas = protectMath(input)
+	}
+	renderer := headingRenderer{blackfriday.HtmlRenderer(htmlFlags, "", ""), w, ids}
+	out := string(blackfriday.MarkdownOptions([]byte(input), renderer,
+		blackfriday.Options{Extensions: markdownExtensions}))
+	return restoreMath(out, formulas, htmlFormula)
+}
+
+// MarkdownSections applies markdown to each section's documentation. The
+// heading IDs are unique across all sections.
+func (w *Weaver) MarkdownSections(sections []*Section) {
+	ids := headingIDs{}
+	for _, section := range sections {
+		section.Doc = w.markdown(section.Doc, ids)
+	}
+}
+
+// headingRenderer shifts and caps the heading levels of the wrapped
+// renderer, and keeps the heading IDs of a document unique.
+type headingRenderer struct {
+	blackfriday.Renderer
+	w   *Weaver
+	ids headingIDs
+}
+
+func (r headingRenderer) Header(out *bytes.Buffer, text func() bool, level int, id string) {
+	r.Renderer.Header(out, text, r.w.HeadingLevel(level), r.ids.unique(id))
+}
+
+// HeadingLevel applies Options.HeadingOffset and Options.MaxHeading to a
+// heading level. The result is always a valid HTML heading level between
+// 1 and 6.
+func (w *Weaver) HeadingLevel(level int) int {
+	level += w.opts.HeadingOffset
+	if level > w.opts.MaxHeading {
+		level = w.opts.MaxHeading
+	}
+	if level < 1 {
+		level = 1
+	}
+	return level
+}
@@ -0,0 +1,39 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeadingLevel(t *testing.T) {
+	tests := []struct {
+		offset, max int
+		level, want int
+	}{
+		{0, 6, 1, 1},
+		{1, 6, 1, 2},
+		{1, 6, 6, 6},
+		{0, 3, 5, 3},
+		{2, 4, 3, 4},
+		{-1, 6, 1, 1},
+		{0, 0, 6, 6},
+		{3, 9, 5, 6},
+	}
+	for _, tt := range tests {
+		w := New(Options{HeadingOffset: tt.offset, MaxHeading: tt.max})
+		if got := w.HeadingLevel(tt.level); got != tt.want {
+			t.Errorf("HeadingLevel(%d) with offset %d, max %d = %d, want %d", tt.level, tt.offset, tt.max, got, tt.want)
+		}
+	}
+	w := New(Options{HeadingOffset: 1})
+	if got := w.Markdown("# Title\n"); !strings.HasPrefix(got, "<h2") {
+		t.Errorf("Markdown() with offset 1 = %q, want an h2 heading", got)
+	}
+}
+
+func TestHeadingIDs(t *testing.T) {
+	got := New(Options{}).Markdown("## Serving requests\n")
+	if !strings.Contains(got, `id="serving-requests"`) {
+		t.Errorf("Markdown() = %q, want the heading ID that cross-references link to", got)
+	}
+}
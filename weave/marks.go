@@ -0,0 +1,149 @@
+package weave
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"html"
+	"sort"
+	"strings"
+)
+
+// Highlighters work on tokens and know nothing about scopes or other files.
+// For Go code, HighlightSections therefore parses the code of all sections
+// as one file, and wraps some identifiers in elements of their own: type
+// parameters and constraints (see generics.go), and identifiers declared in
+// other files (see links.go).
+
+// codeMark is an identifier that HighlightSections wraps.
+type codeMark struct {
+	off, end int // the identifier's position within the code
+	class    string
+	title    string
+	href     string
+}
+
+// codePart is the Boilerplate or the Code of a section, as a part of the
+// file that the sections come from.
+type codePart struct {
+	start   int
+	section int
+	code    *string
+	marks   []codeMark
+}
+
+// goCode is the code of the sections, parsed as a single Go file.
+type goCode struct {
+	sections []*Section
+	parts    []*codePart
+	src      string
+	prefix   string // added to the code to make it parse
+	fset     *token.FileSet
+	file     *ast.File
+}
+
+// parseGoCode parses the code of the sections, which must be Go, in source
+// order. It returns nil if the code does not parse. Without links to look
+// for, it does not bother with code that cannot be generic.
+func parseGoCode(sections []*Section, links bool) *goCode {
+	sections = InSourceOrder(sections)
+	c := &goCode{sections: sections, fset: token.NewFileSet()}
+	var b strings.Builder
+	for i, s := range sections {
+		for _, code := range []*string{&s.Boilerplate, &s.Code} {
+			if *code != "" {
+				c.parts = append(c.parts, &codePart{start: b.Len(), section: i, code: code})
+				b.WriteString(*code)
+			}
+		}
+	}
+	c.src = b.String()
+	if !links && !strings.Contains(c.src, "[") {
+		return nil // no generics here
+	}
+	var err error
+	c.file, err = parser.ParseFile(c.fset, "", c.src, 0)
+	if err != nil {
+		// With -boilerplate=hide, the package clause is gone.
+		c.prefix = "package p;"
+		c.fset = token.NewFileSet()
+		c.file, err = parser.ParseFile(c.fset, "", c.prefix+c.src, 0)
+		if err != nil {
+			return nil
+		}
+	}
+	return c
+}
+
+// offset returns the offset of a position within the code.
+func (c *goCode) offset(p token.Pos) int {
+	return c.fset.Position(p).Offset - len(c.prefix)
+}
+
+// part returns the part that contains the offset.
+func (c *goCode) part(off int) *codePart {
+	i := sort.Search(len(c.parts), func(i int) bool { return c.parts[i].start > off }) - 1
+	if i < 0 {
+		return nil
+	}
+	return c.parts[i]
+}
+
+// mark wraps an identifier in an element.
+func (c *goCode) mark(id *ast.Ident, class, title, href string) {
+	off := c.offset(id.Pos())
+	if p := c.part(off); p != nil {
+		p.marks = append(p.marks, codeMark{off - p.start, off - p.start + len(id.Name), class, title, href})
+	}
+}
+
+// sectionLink returns a link to the section that contains a position, or ""
+// if the section has no ID.
+func (c *goCode) sectionLink(pos token.Pos) string {
+	if p := c.part(c.offset(pos)); p != nil && c.sections[p.section].ID != "" {
+		return "#" + c.sections[p.section].ID
+	}
+	return ""
+}
+
+// highlightMarked highlights code with the marks wrapped around their
+// identifiers.
+func highlightMarked(h Highlighter, code string, marks []codeMark) string {
+	if len(marks) == 0 {
+		return highlightWith(h, code)
+	}
+	sort.Slice(marks, func(i, j int) bool { return marks[i].off < marks[j].off })
+	var b strings.Builder
+	last := 0
+	for _, m := range marks {
+		if m.off < last {
+			continue
+		}
+		b.WriteString(highlightChunk(h, code[last:m.off]))
+		tag, attrs := "span", ""
+		if m.href != "" {
+			tag, attrs = "a", ` href="`+html.EscapeString(m.href)+`"`
+		}
+		attrs += ` class="` + m.class + `"`
+		if m.title != "" {
+			attrs += ` title="` + html.EscapeString(m.title) + `"`
+		}
+		b.WriteString("<" + tag + attrs + ">")
+		b.WriteString(h.Highlight(code[m.off:m.end]))
+		b.WriteString("</" + tag + ">")
+		last = m.end
+	}
+	b.WriteString(highlightChunk(h, code[last:]))
+	return b.String()
+}
+
+// highlightChunk highlights a piece of code between two marks. Whitespace
+// at either end stays as it is, as highlighters may drop it.
+func highlightChunk(h Highlighter, chunk string) string {
+	core := strings.Trim(chunk, " \t\r\n")
+	if core == "" {
+		return chunk
+	}
+	i := strings.Index(chunk, core)
+	return chunk[:i] + h.Highlight(core) + chunk[i+len(core):]
+}
@@ -0,0 +1,181 @@
+package weave
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Markdown knows nothing of formulas: it takes the underscores of `$a_1 +
+// b_1$` for emphasis and the backslashes of `\{` for escapes. With
+// Options.Math, TeX between dollar signs bypasses Markdown, `$...$` within
+// the text and `$$...$$` on lines of its own, and comes out in elements
+// that KaTeX and MathJax know how to typeset, along with the delimiters
+// that they look for:
+//
+//	<span class="math inline">\(a_1 + b_1\)</span>
+//	<div class="math display">\[\sum_{i=1}^n i\]</div>
+//
+// Dollar signs in code spans and code blocks stay as they are, and so do
+// escaped ones, `\$`, and those that cannot open or close a formula: an
+// opening one followed by a space, or a closing one preceded by a space
+// or followed by a digit, as in "$5 and $10". LaTeX documents get the
+// formulas as they are, and reStructuredText the math role and directive.
+
+// mathPlaceholder stands for a formula while Markdown renders the rest.
+// Markdown leaves letters and digits alone.
+var mathPlaceholder = regexp.MustCompile(`GoweaveMath(\d+)X`)
+
+// A formula is the TeX of a formula and whether it is displayed.
+type formula struct {
+	tex     string
+	display bool
+}
+
+// protectMath replaces the formulas of Markdown text with placeholders.
+func protectMath(text string) (string, []formula) {
+	var formulas []formula
+	var b strings.Builder
+	fence := ""
+	lines := strings.SplitAfter(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		t := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(t, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~"):
+			fence = t[:3]
+		case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+			// an indented code block, or the continuation of a list item
+		case strings.HasPrefix(t, "$$"):
+			// Display math runs up to the line that ends with $$.
+			tex := strings.TrimPrefix(t, "$$")
+			j := i
+			for !strings.HasSuffix(strings.TrimSpace(tex), "$$") && j+1 < len(lines) {
+				j++
+				tex += "\n" + strings.TrimRight(lines[j], "\r\n")
+			}
+			if tex = strings.TrimSpace(tex); strings.HasSuffix(tex, "$$") {
+				formulas = append(formulas, formula{strings.TrimSpace(strings.TrimSuffix(tex, "$$")), true})
+				fmt.Fprintf(&b, "\nGoweaveMath%dX\n\n", len(formulas)-1)
+				i = j
+				continue
+			}
+		default:
+			line = protectInlineMath(line, &formulas)
+		}
+		b.WriteString(line)
+	}
+	return b.String(), formulas
+}
+
+// protectInlineMath replaces the inline formulas of a line with
+// placeholders, skipping code spans.
+func protectInlineMath(line string, formulas *[]formula) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			// Markdown does not know the escaped dollar sign.
+			if line[i+1] == '$' {
+				b.WriteByte('$')
+			} else {
+				b.WriteString(line[i : i+2])
+			}
+			i++
+			continue
+		case c == '`':
+			// Skip the code span, up to the same number of backticks.
+			n := 1
+			for i+n < len(line) && line[i+n] == '`' {
+				n++
+			}
+			ticks := line[i : i+n]
+			if end := strings.Index(line[i+n:], ticks); end >= 0 {
+				b.WriteString(line[i : i+n+end+n])
+				i += n + end + n - 1
+				continue
+			}
+		case c == '$' && i+1 < len(line) && line[i+1] != ' ' && line[i+1] != '$':
+			if end := closingDollar(line, i+1); end > 0 {
+				*formulas = append(*formulas, formula{line[i+1 : end], false})
+				fmt.Fprintf(&b, "GoweaveMath%dX", len(*formulas)-1)
+				i = end
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// closingDollar returns the index of the dollar sign that closes an inline
+// formula that starts at from, or -1 if there is none.
+func closingDollar(line string, from int) int {
+	for j := from; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case '$':
+			if line[j-1] == ' ' || j+1 < len(line) && line[j+1] >= '0' && line[j+1] <= '9' {
+				return -1
+			}
+			return j
+		}
+	}
+	return -1
+}
+
+// mathParagraph matches a paragraph of nothing but a placeholder, which a
+// displayed formula must not end up in.
+var mathParagraph = regexp.MustCompile(`<p>GoweaveMath\d+X</p>`)
+
+// restoreMath replaces the placeholders of rendered Markdown with the
+// formulas, as render writes them.
+func restoreMath(rendered string, formulas []formula, render func(formula) string) string {
+	if len(formulas) == 0 {
+		return rendered
+	}
+	replace := func(m string) string {
+		n, _ := strconv.Atoi(mathPlaceholder.FindStringSubmatch(m)[1])
+		if n >= len(formulas) {
+			return m
+		}
+		if !formulas[n].display && strings.HasPrefix(m, "<p>") {
+			return "<p>" + render(formulas[n]) + "</p>"
+		}
+		return render(formulas[n])
+	}
+	rendered = mathParagraph.ReplaceAllStringFunc(rendered, replace)
+	return mathPlaceholder.ReplaceAllStringFunc(rendered, replace)
+}
+
+// htmlFormula returns the element of a formula.
+func htmlFormula(f formula) string {
+	if f.display {
+		return `<div class="math display">\[` + html.EscapeString(f.tex) + `\]</div>`
+	}
+	return `<span class="math inline">\(` + html.EscapeString(f.tex) + `\)</span>`
+}
+
+// latexFormula returns a formula as LaTeX, which needs no help with it.
+func latexFormula(f formula) string {
+	if f.display {
+		return "\\[" + f.tex + "\\]\n"
+	}
+	return "\\(" + f.tex + "\\)"
+}
+
+// rstFormula returns a formula as reStructuredText.
+func rstFormula(f formula) string {
+	if f.display {
+		return ".. math::\n\n   " + strings.ReplaceAll(f.tex, "\n", "\n   ")
+	}
+	return ":math:`" + f.tex + "`"
+}
@@ -0,0 +1,39 @@
+package weave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Sum $a_1 + b_1$ up.\n",
+			`<p>Sum <span class="math inline">\(a_1 + b_1\)</span> up.</p>` + "\n"},
+		{"$a + *b* + c$\n",
+			`<p><span class="math inline">\(a + *b* + c\)</span></p>` + "\n"},
+		{"Before\n\n$$\n\\sum_{i=1}^n i < x\n$$\n\nAfter\n",
+			"<p>Before</p>\n\n" + `<div class="math display">\[\sum_{i=1}^n i &lt; x\]</div>` + "\n\n<p>After</p>\n"},
+		{"$$x^2$$\n",
+			`<div class="math display">\[x^2\]</div>` + "\n"},
+		{"It costs $5 and $10.\n", "<p>It costs $5 and $10.</p>\n"},
+		{"Not \\$x$ nor `$y$`.\n", "<p>Not $x$ nor <code>$y$</code>.</p>\n"},
+		{"```\n$a_1$\n```\n", "<pre><code>$a_1$\n</code></pre>\n"},
+	}
+	w := New(Options{Math: true})
+	for _, tt := range tests {
+		if got := w.Markdown(tt.in); got != tt.want {
+			t.Errorf("Markdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got, want := New(Options{}).Markdown("$a + *b* + c$\n"), "<p>$a + <em>b</em> + c$</p>\n"; got != want {
+		t.Errorf("Markdown() without Math = %q, want %q", got, want)
+	}
+	if got, want := w.LaTeX("Sum $a_1$.\n"), `Sum \(a_1\).`; !strings.Contains(got, want) {
+		t.Errorf("LaTeX() = %q, want it to contain %q", got, want)
+	}
+	if got, want := w.RST("Sum $a_1$.\n"), "Sum :math:`a_1`."; !strings.Contains(got, want) {
+		t.Errorf("RST() = %q, want it to contain %q", got, want)
+	}
+}
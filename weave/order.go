@@ -0,0 +1,90 @@
+package weave
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source code has to follow the compiler's order, which is not always the
+// best order for explaining it. An order pragma moves the section that it
+// precedes, and the sections after it up to the next order pragma, to
+// another place in the document:
+//
+//	//goweave:order 20
+//
+// Sections sort by the number of their pragma. The sections before the
+// first order pragma, usually the package clause and the intro, stay at the
+// top. Instead of numbers, the pragmas can name chapters, which go in the
+// order of a chapters pragma:
+//
+//	//goweave:chapters intro, model, handlers, main
+//	...
+//	//goweave:order model
+//
+// Chapters that the chapters pragma does not list follow the listed ones,
+// in the order in which they first appear. All sections of a chapter come
+// together, in source order. Numbers go before chapter names.
+//
+// Each section remembers its place in the source, so that InSourceOrder
+// can restore the original order.
+
+// orderRank is the sort key of a section.
+type orderRank struct {
+	class int // 0: before any order pragma, 1: numbers, 2: listed chapters, 3: other chapters
+	value float64
+}
+
+// parseChapters splits the value of a chapters pragma into the names of the
+// chapters.
+func parseChapters(v string) []string {
+	var chapters []string
+	for _, c := range strings.Split(v, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			chapters = append(chapters, c)
+		}
+	}
+	return chapters
+}
+
+// orderSections sorts the sections by their order keys. Sections with the
+// same key keep their source order.
+func orderSections(sections []*Section, chapters []string) {
+	listed := map[string]int{}
+	for i, c := range chapters {
+		if _, ok := listed[c]; !ok {
+			listed[c] = i
+		}
+	}
+	other := map[string]int{}
+	ranks := make(map[*Section]orderRank, len(sections))
+	for _, s := range sections {
+		var r orderRank
+		if n, err := strconv.ParseFloat(s.Order, 64); err == nil {
+			r = orderRank{1, n}
+		} else if i, ok := listed[s.Order]; ok {
+			r = orderRank{2, float64(i)}
+		} else if s.Order != "" {
+			if _, ok := other[s.Order]; !ok {
+				other[s.Order] = len(other)
+			}
+			r = orderRank{3, float64(other[s.Order])}
+		}
+		ranks[s] = r
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		a, b := ranks[sections[i]], ranks[sections[j]]
+		if a.class != b.class {
+			return a.class < b.class
+		}
+		return a.value < b.value
+	})
+}
+
+// InSourceOrder returns the sections in the order of the source, undoing
+// any order pragmas.
+func InSourceOrder(sections []*Section) []*Section {
+	sorted := append([]*Section(nil), sections...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+	return sorted
+}
@@ -0,0 +1,42 @@
+package weave
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrderSections(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []string // the code of the sections, in order
+	}{
+		{"// Intro\npackage p\n// C\n//goweave:order 20\nc()\n// C2\nc2()\n// A\n//goweave:order 10\na()\n// B\n//goweave:order 15\nb()\n",
+			[]string{"package p", "a()", "b()", "c()", "c2()"}},
+		{"//goweave:chapters model, main\n// Intro\npackage p\n//goweave:order main\n// Main\nmain()\n//goweave:order extra\n// Extra\nextra()\n//goweave:order model\n// Model\nmodel()\n//goweave:order main\n// More main\nmore()\n//goweave:order 1\n// First\nfirst()\n",
+			[]string{"package p", "first()", "model()", "main()", "more()", "extra()"}},
+		{"// Intro\npackage p\n// A\na()\n//goweave:order 2.5\nb()\n// C\nc()\n//goweave:order 1\nd()\n",
+			[]string{"package p", "c()\nd()", "a()\nb()"}},
+	}
+	for _, tt := range tests {
+		sections := New(Options{}).Sections(tt.source)
+		var got []string
+		for _, s := range sections {
+			got = append(got, strings.TrimSpace(s.Code))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Sections(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+		var restored []string
+		for _, s := range InSourceOrder(sections) {
+			restored = append(restored, strings.TrimSpace(s.Code))
+		}
+		var source []string
+		for _, s := range New(Options{SourceOrder: true}).Sections(tt.source) {
+			source = append(source, strings.TrimSpace(s.Code))
+		}
+		if !reflect.DeepEqual(restored, source) {
+			t.Errorf("InSourceOrder() = %q, want %q", restored, source)
+		}
+	}
+}
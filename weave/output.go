@@ -0,0 +1,174 @@
+package weave
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+)
+
+// OutputFS is a destination for generated files: a directory, an archive,
+// memory, or anything else that files can be written to, like a server that
+// they get uploaded to. Names are slash-separated paths relative to the
+// root of the destination, as with fs.FS.
+//
+// Through fs.FS, an OutputFS reads back the files it holds, so that callers
+// can find out what exists already. Destinations that cannot do that, like
+// archives, report every file as nonexistent.
+type OutputFS interface {
+	fs.FS
+	// WriteFile creates or replaces the named file, along with any missing
+	// parent directories.
+	WriteFile(name string, data []byte) error
+}
+
+// validName returns an error for names that fs.FS does not accept.
+func validName(op, name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return nil
+}
+
+// Dir is an OutputFS for a directory of the local file system. It replaces
+// files atomically, so that a web server never serves half-written files.
+type Dir string
+
+// Open opens a file of the directory.
+func (d Dir) Open(name string) (fs.File, error) {
+	return os.DirFS(string(d)).Open(name)
+}
+
+// WriteFile writes a file into the directory.
+func (d Dir) WriteFile(name string, data []byte) error {
+	if err := validName("write", name); err != nil {
+		return err
+	}
+	dst := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".write")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// MemFS is an OutputFS that keeps the files in memory, for tests and for
+// callers that process the files further. The zero value is an empty MemFS
+// ready to use. It is safe for concurrent use.
+type MemFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+// Open opens a file of the MemFS.
+func (m *MemFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.Open(name)
+}
+
+// WriteFile stores a copy of data under the name.
+func (m *MemFS) WriteFile(name string, data []byte) error {
+	if err := validName("write", name); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = fstest.MapFS{}
+	}
+	m.files[name] = &fstest.MapFile{Data: append([]byte(nil), data...), Mode: 0644, ModTime: time.Now()}
+	return nil
+}
+
+// Names returns the names of all files, in lexical order.
+func (m *MemFS) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ZipFS is an OutputFS that writes the files into a zip archive. Each file
+// can be written only once, and Close must be called to complete the
+// archive. It is safe for concurrent use.
+type ZipFS struct {
+	mu    sync.Mutex
+	zw    *zip.Writer
+	names map[string]bool
+}
+
+// NewZipFS returns a ZipFS that writes the archive to w.
+func NewZipFS(w io.Writer) *ZipFS {
+	return &ZipFS{zw: zip.NewWriter(w), names: map[string]bool{}}
+}
+
+// Open reports that the file does not exist, as a ZipFS cannot read back
+// what it wrote.
+func (z *ZipFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// WriteFile adds a file to the archive.
+func (z *ZipFS) WriteFile(name string, data []byte) error {
+	if err := validName("write", name); err != nil {
+		return err
+	}
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	if z.names[name] {
+		return fmt.Errorf("%s: written twice into the archive", name)
+	}
+	z.names[name] = true
+	f, err := z.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// Close writes the directory of the archive. It does not close the
+// underlying writer.
+func (z *ZipFS) Close() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.zw.Close()
+}
+
+// WeaveFS weaves the files of fsys like RenderFS, and writes each document
+// to dst, named like its source file with the extension replaced by ".html".
+func (w *Weaver) WeaveFS(fsys fs.FS, root string, dst OutputFS) error {
+	return w.RenderFS(fsys, root, func(name, doc string) error {
+		return dst.WriteFile(strings.TrimSuffix(name, path.Ext(name))+".html", []byte(doc))
+	})
+}
@@ -0,0 +1,76 @@
+package weave
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOutputFS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, dst := range map[string]OutputFS{"Dir": Dir(dir), "MemFS": &MemFS{}} {
+		if err := dst.WriteFile("a/b.html", []byte("old")); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := dst.WriteFile("a/b.html", []byte("new")); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got, err := fs.ReadFile(dst, "a/b.html")
+		if err != nil || string(got) != "new" {
+			t.Errorf("%s: read back %q, %v; want %q", name, got, err, "new")
+		}
+		for _, bad := range []string{"../x.html", "/x.html", "."} {
+			if err := dst.WriteFile(bad, nil); err == nil {
+				t.Errorf("%s: WriteFile(%q) succeeded", name, bad)
+			}
+		}
+	}
+}
+
+func TestZipFS(t *testing.T) {
+	var buf bytes.Buffer
+	z := NewZipFS(&buf)
+	if err := z.WriteFile("a/b.html", []byte("doc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.WriteFile("a/b.html", []byte("doc")); err == nil {
+		t.Errorf("ZipFS accepted the same file twice")
+	}
+	if _, err := fs.Stat(z, "a/b.html"); err == nil {
+		t.Errorf("ZipFS claims to read back a file")
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.ReadFile(zr, "a/b.html")
+	if err != nil || string(got) != "doc" {
+		t.Errorf("archive holds %q, %v; want %q", got, err, "doc")
+	}
+}
+
+func TestWeaveFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.go":        {Data: []byte("// # Go\npackage a\n")},
+		"b/script.py": {Data: []byte("# Python\nx = 1\n")},
+	}
+	var dst MemFS
+	if err := New(Options{}).WeaveFS(fsys, ".", &dst); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(dst.Names(), " "), "a.html b/script.html"; got != want {
+		t.Errorf("WeaveFS() wrote %s, want %s", got, want)
+	}
+}
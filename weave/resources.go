@@ -0,0 +1,81 @@
+package weave
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/christophberger/goweave/resources"
+)
+
+// Tools that weave whole pages need more than fragments: templates,
+// stylesheets, and themes. Users customize these by keeping copies of them
+// in a directory, and the tool falls back to the copies that it embeds.
+// FindResources does the search. It does not write anything, unless
+// ResourceOptions.Install asks for it, as a tool that silently adds a
+// directory of resource files to the project of its user makes a mess.
+// Resources are goweave's own, which the binary embeds, and which servers
+// can hand out as they are:
+//
+//	http.Handle("/goweave/", http.StripPrefix("/goweave/", http.FileServer(http.FS(weave.Resources()))))
+
+// Resources returns the built-in resource files of goweave, by their names
+// within the resource directory: goweave.css, goweave.templ,
+// goweave-index.templ, goweave.js, goweave-changes.js, and the themes, like
+// themes/dark.css.
+func Resources() fs.FS {
+	return resources.Files
+}
+
+// ResourceOptions control where FindResources looks for resource files.
+type ResourceOptions struct {
+	// Dirs are the directories to search, in order.
+	Dirs []string
+	// Marker is the file that a directory needs to count as having the
+	// resources, like "goweave.css". If it is empty, any existing directory
+	// counts.
+	Marker string
+	// Defaults are the built-in resources, for when no directory has any.
+	// If it is nil, they are those of Resources.
+	Defaults fs.FS
+	// Install, if set, makes FindResources write Defaults into this
+	// directory if no directory has the resources, and use them from
+	// there. Otherwise, FindResources uses Defaults in memory.
+	Install string
+}
+
+// FindResources returns the resources of the first of opts.Dirs that has
+// them, along with that directory, or else opts.Defaults and "", or with
+// opts.Install, the installed resources and opts.Install.
+func FindResources(opts ResourceOptions) (fs.FS, string, error) {
+	for _, dir := range opts.Dirs {
+		if _, err := os.Stat(filepath.Join(dir, opts.Marker)); err == nil {
+			return os.DirFS(dir), dir, nil
+		}
+	}
+	if opts.Defaults == nil {
+		opts.Defaults = Resources()
+	}
+	if opts.Install == "" {
+		return opts.Defaults, "", nil
+	}
+	if err := InstallResources(opts.Defaults, opts.Install); err != nil {
+		return nil, "", err
+	}
+	return os.DirFS(opts.Install), opts.Install, nil
+}
+
+// InstallResources writes the files of fsys into the directory dir,
+// creating it if needed.
+func InstallResources(fsys fs.FS, dir string) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		return Dir(dir).WriteFile(name, data)
+	})
+}
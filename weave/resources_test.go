@@ -0,0 +1,85 @@
+package weave
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFindResources(t *testing.T) {
+	root, err := ioutil.TempDir("", "resources")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	custom := filepath.Join(root, "custom")
+	if err := Dir(custom).WriteFile("page.css", []byte("custom")); err != nil {
+		t.Fatal(err)
+	}
+	defaults := fstest.MapFS{
+		"page.css":         {Data: []byte("default")},
+		"themes/print.css": {Data: []byte("print")},
+	}
+	empty := filepath.Join(root, "empty")
+	if err := os.Mkdir(empty, 0755); err != nil {
+		t.Fatal(err)
+	}
+	install := filepath.Join(root, "install")
+	tests := []struct {
+		opts    ResourceOptions
+		wantDir string
+		wantCSS string
+	}{
+		{ResourceOptions{Dirs: []string{empty, custom}, Marker: "page.css", Defaults: defaults}, custom, "custom"},
+		{ResourceOptions{Dirs: []string{empty}, Marker: "page.css", Defaults: defaults}, "", "default"},
+		{ResourceOptions{Dirs: []string{empty}, Defaults: defaults}, empty, ""},
+		{ResourceOptions{Marker: "page.css", Defaults: defaults, Install: install}, install, "default"},
+	}
+	for i, tt := range tests {
+		fsys, dir, err := FindResources(tt.opts)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if dir != tt.wantDir {
+			t.Errorf("%d: dir = %q, want %q", i, dir, tt.wantDir)
+		}
+		if css, _ := fs.ReadFile(fsys, "page.css"); string(css) != tt.wantCSS {
+			t.Errorf("%d: page.css = %q, want %q", i, css, tt.wantCSS)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(install, "themes", "print.css")); err != nil {
+		t.Errorf("the themes were not installed: %v", err)
+	}
+}
+
+func TestResources(t *testing.T) {
+	for _, name := range []string{"goweave.css", "goweave.templ", "goweave-index.templ", "goweave.js", "themes/dark.css"} {
+		if data, err := fs.ReadFile(Resources(), name); err != nil || len(data) == 0 {
+			t.Errorf("Resources() has no %s: %v", name, err)
+		}
+	}
+	if _, err := fs.Stat(Resources(), "resources.go"); err == nil {
+		t.Error("Resources() has the Go source of the package")
+	}
+	// Library users can weave with the template of the goweave command.
+	tpl, err := ParseTemplateFS(Resources(), "goweave.templ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := New(Options{Template: tpl, WrapperClass: "doc"}).Render("// Doc\npackage main\n")
+	if err != nil {
+		t.Fatalf("Render() with goweave.templ: %v", err)
+	}
+	if !strings.Contains(got, `<div class="doc">`) || !strings.Contains(got, `<span class="keyword">package</span>`) || strings.Contains(got, "linenums") {
+		t.Errorf("Render() with goweave.templ = %s", got)
+	}
+	fsys, dir, err := FindResources(ResourceOptions{Marker: "goweave.css"})
+	if err != nil || dir != "" || fsys != Resources() {
+		t.Errorf("FindResources() without defaults = %v, %q, %v; want Resources()", fsys, dir, err)
+	}
+}
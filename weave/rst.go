@@ -0,0 +1,340 @@
+package weave
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday"
+)
+
+// The comments are Markdown, whatever the output, so reStructuredText
+// output needs a renderer of its own for blackfriday: RST and RSTSections
+// parse the comments just like Markdown does, and write what they find as
+// reStructuredText, for Sphinx and docutils. Fenced code becomes a
+// `code-block` directive, tables become `list-table` directives, images
+// become substitutions, and headings get underlines of `=`, `-`, `~`, `^`,
+// `"`, and `'` for the levels 1 to 6, after Options.HeadingOffset and
+// Options.MaxHeading. reStructuredText has no strikethrough and no inline
+// HTML; the text of both stays, without the markup.
+
+// rstUnderlines are the characters that underline the headings of each
+// level.
+const rstUnderlines = "=-~^\"'"
+
+// rstEscaper escapes the characters of plain text that reStructuredText
+// would take for markup.
+var rstEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "`", "\\`", "_", `\_`, "|", `\|`)
+
+// RST renders Markdown as reStructuredText.
+func (w *Weaver) RST(input string) string {
+	return w.rst(input, &rstRenderer{w: w})
+}
+
+// RSTSections renders the documentation of each section as
+// reStructuredText. The names of the image substitutions are unique across
+// all sections. Sections with an ID start with a label of that name, and
+// links to "#" and the ID refer to the label, because docutils drops the
+// leading digits of the HTML IDs that it makes of labels.
+func (w *Weaver) RSTSections(sections []*Section) {
+	r := &rstRenderer{w: w, labels: map[string]bool{}}
+	for _, s := range sections {
+		if s.ID != "" {
+			r.labels[s.ID] = true
+		}
+	}
+	for _, s := range sections {
+		s.Doc = w.rst(s.Doc, r)
+		if s.ID != "" {
+			s.Doc = ".. _" + s.ID + ":\n\n" + s.Doc
+		}
+	}
+}
+
+func (w *Weaver) rst(input string, r *rstRenderer) string {
+	var formulas []formula
+	if w.opts.Math {
+		input, formulas = protectMath(input)
+	}
+	out := string(blackfriday.MarkdownOptions([]byte(input), r,
+		blackfriday.Options{Extensions: markdownExtensions}))
+	return restoreMath(out, formulas, rstFormula)
+}
+
+// rstRenderer is a blackfriday renderer for reStructuredText.
+type rstRenderer struct {
+	w      *Weaver
+	images []string        // the substitutions of the images of the current document
+	nimg   int             // the number of images so far, for unique names
+	labels map[string]bool // the labels of the sections, by name
+	// afterBuf and after are the buffer and the end of the last inline
+	// markup. blackfriday renders some spans into buffers of their own.
+	afterBuf *bytes.Buffer
+	after    int
+}
+
+// atMarkupEnd tells whether the output ends with inline markup.
+func (r *rstRenderer) atMarkupEnd(out *bytes.Buffer) bool {
+	return out == r.afterBuf && out.Len() == r.after && out.Len() > 0
+}
+
+// blankLine makes a block start after an empty line, as reStructuredText
+// wants it.
+func blankLine(out *bytes.Buffer) {
+	switch b := out.Bytes(); {
+	case len(b) == 0, bytes.HasSuffix(b, []byte("\n\n")):
+	case b[len(b)-1] == '\n':
+		out.WriteByte('\n')
+	default:
+		out.WriteString("\n\n")
+	}
+}
+
+// indent indents all non-empty lines of text but the first one.
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = prefix + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// openInline starts inline markup. reStructuredText recognizes markup only
+// after a space or punctuation, so after a letter or other markup, an
+// escaped space separates it, which renders as nothing.
+func (r *rstRenderer) openInline(out *bytes.Buffer) {
+	last, _ := utf8.DecodeLastRune(out.Bytes())
+	if r.atMarkupEnd(out) || out.Len() > 0 && (unicode.IsLetter(last) || unicode.IsDigit(last)) {
+		out.WriteString(`\ `)
+	}
+}
+
+// closeInline ends inline markup.
+func (r *rstRenderer) closeInline(out *bytes.Buffer) {
+	r.afterBuf, r.after = out, out.Len()
+}
+
+func (r *rstRenderer) BlockCode(out *bytes.Buffer, text []byte, infoString string) {
+	blankLine(out)
+	lang := strings.Fields(infoString)
+	if len(lang) == 0 {
+		out.WriteString("::\n\n   ")
+	} else {
+		out.WriteString(".. code-block:: " + lang[0] + "\n\n   ")
+	}
+	out.WriteString(indent(strings.TrimRight(string(text), "\n"), "   "))
+	out.WriteString("\n\n")
+}
+
+func (r *rstRenderer) BlockQuote(out *bytes.Buffer, text []byte) {
+	blankLine(out)
+	out.WriteString("    " + indent(strings.TrimRight(string(text), "\n"), "    ") + "\n\n")
+}
+
+func (r *rstRenderer) BlockHtml(out *bytes.Buffer, text []byte) {
+	blankLine(out)
+	out.WriteString(".. raw:: html\n\n   " + indent(strings.TrimRight(string(text), "\n"), "   ") + "\n\n")
+}
+
+func (r *rstRenderer) Header(out *bytes.Buffer, text func() bool, level int, id string) {
+	marker := out.Len()
+	blankLine(out)
+	start := out.Len()
+	if !text() {
+		out.Truncate(marker)
+		return
+	}
+	title := strings.TrimSpace(out.String()[start:])
+	out.Truncate(start)
+	n := utf8.RuneCountInString(title)
+	if n < 4 {
+		n = 4
+	}
+	c := rstUnderlines[r.w.HeadingLevel(level)-1]
+	out.WriteString(title + "\n" + strings.Repeat(string(c), n) + "\n\n")
+}
+
+func (r *rstRenderer) HRule(out *bytes.Buffer) {
+	blankLine(out)
+	out.WriteString("----\n\n")
+}
+
+func (r *rstRenderer) List(out *bytes.Buffer, text func() bool, flags int) {
+	marker := out.Len()
+	blankLine(out)
+	if !text() {
+		out.Truncate(marker)
+		return
+	}
+	blankLine(out)
+}
+
+func (r *rstRenderer) ListItem(out *bytes.Buffer, text []byte, flags int) {
+	item := strings.TrimRight(string(text), "\n")
+	switch {
+	case flags&blackfriday.LIST_TYPE_TERM != 0:
+		out.WriteString(item + "\n")
+	case flags&blackfriday.LIST_TYPE_DEFINITION != 0:
+		out.WriteString("   " + indent(item, "   ") + "\n")
+	case flags&blackfriday.LIST_TYPE_ORDERED != 0:
+		out.WriteString("#. " + indent(item, "   ") + "\n")
+	default:
+		out.WriteString("* " + indent(item, "  ") + "\n")
+	}
+	if flags&blackfriday.LIST_ITEM_CONTAINS_BLOCK != 0 {
+		out.WriteString("\n")
+	}
+}
+
+func (r *rstRenderer) Paragraph(out *bytes.Buffer, text func() bool) {
+	marker := out.Len()
+	blankLine(out)
+	if !text() {
+		out.Truncate(marker)
+		return
+	}
+	out.WriteString("\n\n")
+}
+
+func (r *rstRenderer) Table(out *bytes.Buffer, header []byte, body []byte, columnData []int) {
+	blankLine(out)
+	out.WriteString(".. list-table::\n")
+	if len(bytes.TrimSpace(header)) > 0 {
+		out.WriteString("   :header-rows: 1\n")
+	}
+	rows := strings.TrimRight(string(header)+string(body), "\n")
+	out.WriteString("\n   " + indent(rows, "   ") + "\n\n")
+}
+
+func (r *rstRenderer) TableRow(out *bytes.Buffer, text []byte) {
+	out.WriteString("* " + indent(strings.TrimRight(string(text), "\n"), "  ") + "\n")
+}
+
+func (r *rstRenderer) TableHeaderCell(out *bytes.Buffer, text []byte, flags int) {
+	r.TableCell(out, text, flags)
+}
+
+func (r *rstRenderer) TableCell(out *bytes.Buffer, text []byte, flags int) {
+	out.WriteString(strings.TrimRight("- "+strings.TrimSpace(string(text)), " ") + "\n")
+}
+
+func (r *rstRenderer) Footnotes(out *bytes.Buffer, text func() bool) {
+	blankLine(out)
+	text()
+}
+
+func (r *rstRenderer) FootnoteItem(out *bytes.Buffer, name, text []byte, flags int) {
+	out.WriteString(".. [#" + string(name) + "] " + indent(strings.TrimSpace(string(text)), "   ") + "\n")
+}
+
+func (r *rstRenderer) TitleBlock(out *bytes.Buffer, text []byte) {
+	out.Write(text)
+}
+
+func (r *rstRenderer) AutoLink(out *bytes.Buffer, link []byte, kind int) {
+	r.openInline(out)
+	out.WriteString(strings.TrimPrefix(string(link), "mailto:"))
+	r.closeInline(out)
+}
+
+func (r *rstRenderer) CodeSpan(out *bytes.Buffer, text []byte) {
+	r.openInline(out)
+	out.WriteString("``" + string(text) + "``")
+	r.closeInline(out)
+}
+
+func (r *rstRenderer) DoubleEmphasis(out *bytes.Buffer, text []byte) {
+	r.openInline(out)
+	out.WriteString("**" + string(text) + "**")
+	r.closeInline(out)
+}
+
+func (r *rstRenderer) Emphasis(out *bytes.Buffer, text []byte) {
+	r.openInline(out)
+	out.WriteString("*" + string(text) + "*")
+	r.closeInline(out)
+}
+
+// TripleEmphasis is strong emphasis, as reStructuredText cannot nest
+// markup.
+func (r *rstRenderer) TripleEmphasis(out *bytes.Buffer, text []byte) {
+	r.DoubleEmphasis(out, text)
+}
+
+func (r *rstRenderer) Image(out *bytes.Buffer, link []byte, title []byte, alt []byte) {
+	r.nimg++
+	name := fmt.Sprintf("image-%d", r.nimg)
+	def := ".. |" + name + "| image:: " + string(link)
+	if len(alt) > 0 {
+		def += "\n   :alt: " + string(alt)
+	}
+	r.images = append(r.images, def)
+	r.openInline(out)
+	out.WriteString("|" + name + "|")
+	r.closeInline(out)
+}
+
+func (r *rstRenderer) LineBreak(out *bytes.Buffer) {
+	out.WriteString("\n")
+}
+
+// Link writes an anonymous hyperlink, or a reference to the label of a
+// section. The text of a link cannot have markup of its own.
+func (r *rstRenderer) Link(out *bytes.Buffer, link []byte, title []byte, content []byte) {
+	text := strings.NewReplacer("``", "", "**", "", `\ `, "").Replace(string(content))
+	r.openInline(out)
+	if bytes.HasPrefix(link, []byte("#")) && r.labels[string(link[1:])] {
+		out.WriteString("`" + strings.Replace(text, "<", `\<`, -1) + " <" + string(link[1:]) + "_>`__")
+	} else if text == string(link) {
+		out.WriteString(text)
+	} else {
+		out.WriteString("`" + strings.Replace(text, "<", `\<`, -1) + " <" + string(link) + ">`__")
+	}
+	r.closeInline(out)
+}
+
+func (r *rstRenderer) RawHtmlTag(out *bytes.Buffer, tag []byte) {}
+
+func (r *rstRenderer) StrikeThrough(out *bytes.Buffer, text []byte) {
+	out.Write(text)
+}
+
+func (r *rstRenderer) FootnoteRef(out *bytes.Buffer, ref []byte, id int) {
+	r.openInline(out)
+	out.WriteString("[#" + string(ref) + "]_")
+	r.closeInline(out)
+}
+
+func (r *rstRenderer) Entity(out *bytes.Buffer, entity []byte) {
+	out.WriteString(rstEscaper.Replace(html.UnescapeString(string(entity))))
+}
+
+func (r *rstRenderer) NormalText(out *bytes.Buffer, text []byte) {
+	first, _ := utf8.DecodeRune(text)
+	if r.atMarkupEnd(out) && (unicode.IsLetter(first) || unicode.IsDigit(first)) {
+		out.WriteString(`\ `)
+	}
+	out.WriteString(rstEscaper.Replace(string(text)))
+}
+
+func (r *rstRenderer) DocumentHeader(out *bytes.Buffer) {
+	r.images = nil
+	r.afterBuf = nil
+}
+
+// DocumentFooter writes the substitutions of the images.
+func (r *rstRenderer) DocumentFooter(out *bytes.Buffer) {
+	if len(r.images) > 0 {
+		blankLine(out)
+		out.WriteString(strings.Join(r.images, "\n") + "\n")
+	}
+}
+
+func (r *rstRenderer) GetFlags() int {
+	return 0
+}
@@ -0,0 +1,45 @@
+package weave
+
+import "testing"
+
+func TestRST(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"## Reading the input\n\nThe *first* and **second** `Reader`s.\n",
+			"Reading the input\n-----------------\n\nThe *first* and **second** ``Reader``\\ s.\n\n"},
+		{"See [the spec](https://go.dev/ref/spec) and snake_case.\n",
+			"See `the spec <https://go.dev/ref/spec>`__ and snake\\_case.\n\n"},
+		{"* one\n* two\n  1. nested\n",
+			"* one\n* two\n\n  #. nested\n\n"},
+		{"```go\nfunc main() {\n\tprintln()\n}\n```\n",
+			".. code-block:: go\n\n   func main() {\n   \tprintln()\n   }\n\n"},
+		{"| A | B |\n|---|---|\n| 1 | 2 |\n",
+			".. list-table::\n   :header-rows: 1\n\n   * - A\n     - B\n   * - 1\n     - 2\n\n"},
+		{"An ![icon](icon.png) here.\n",
+			"An |image-1| here.\n\n.. |image-1| image:: icon.png\n   :alt: icon\n"},
+	}
+	w := New(Options{})
+	for _, tt := range tests {
+		if got := w.RST(tt.in); got != tt.want {
+			t.Errorf("RST(%q) =\n%q\nwant\n%q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRSTSectionLabels(t *testing.T) {
+	sections := []*Section{
+		{Doc: "See [the loop](#0f3a) and [the spec](#spec).\n", ID: "9c1e"},
+		{Doc: "", Code: "for {}\n", ID: "0f3a"},
+	}
+	New(Options{}).RSTSections(sections)
+	want := []string{
+		".. _9c1e:\n\nSee `the loop <0f3a_>`__ and `the spec <#spec>`__.\n\n",
+		".. _0f3a:\n\n",
+	}
+	for i, s := range sections {
+		if s.Doc != want[i] {
+			t.Errorf("section %d =\n%q\nwant\n%q", i, s.Doc, want[i])
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package weave
+
+import "strings"
+
+// A status pragma marks the review status of the section of the next line,
+// for documents that serve as design documents under review:
+//
+//	//goweave:status reviewed
+//
+// The templates show the status as a badge next to the section's prose.
+// Values other than Statuses get through as they are, without a color of
+// their own.
+
+// Statuses are the review statuses that goweave.css has colors for.
+var Statuses = []string{"reviewed", "draft", "obsolete"}
+
+// KnownStatus reports whether a status is one of Statuses.
+func KnownStatus(status string) bool {
+	for _, s := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStatus normalizes the value of a status pragma.
+func parseStatus(v string) string {
+	return strings.ToLower(strings.TrimSpace(v))
+}
@@ -0,0 +1,27 @@
+package weave
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSectionStatus(t *testing.T) {
+	source := "// Intro\npackage p\n//goweave:status Reviewed\n// A\na()\n// B\nb()\n//goweave:status draft\nb2()\n// C\nc()\n//goweave:status obsolete\n"
+	want := []string{"", "reviewed", "draft", ""}
+	var got []string
+	for _, s := range New(Options{}).Sections(source) {
+		got = append(got, s.Status)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sections(%q) statuses = %q, want %q", source, got, want)
+	}
+
+	out, err := New(Options{}).Render("// A\n//goweave:status draft\na()\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `<span class="status status-draft" title="Review status">draft</span>`) {
+		t.Errorf("Render() lacks the status badge:\n%s", out)
+	}
+}
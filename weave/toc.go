@@ -0,0 +1,83 @@
+package weave
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// Heading is a Markdown heading in the comments, as listed in a table of
+// contents.
+type Heading struct {
+	Level int    // 1 for `#`, 2 for `##`, and so forth
+	Text  string // the heading without Markdown emphasis
+	ID    string // the ID of the heading element
+}
+
+var (
+	headingLine = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.*?)[\s#]*$`)
+	explicitID  = regexp.MustCompile(`\s*\{#([^}]*)\}$`)
+	emphasis    = strings.NewReplacer("*", "", "`", "")
+)
+
+// Headings returns the headings of the sections' comments up to the given
+// level, along with the IDs that MarkdownSections gives them: the heading
+// text in lowercase with dashes between words, or the ID set through
+// `# Heading {#id}`. A repeated ID gets "-1" appended the second time,
+// "-2" the third time, and so forth, as on GitHub. Call Headings before
+// rendering the comments.
+func Headings(sections []*Section, maxLevel int) []Heading {
+	var headings []Heading
+	for _, hs := range sectionHeadings(sections) {
+		for _, h := range hs {
+			if h.Level <= maxLevel {
+				headings = append(headings, h)
+			}
+		}
+	}
+	return headings
+}
+
+// sectionHeadings returns the headings of each section's comment.
+func sectionHeadings(sections []*Section) [][]Heading {
+	headings := make([][]Heading, len(sections))
+	ids := headingIDs{}
+	for i, s := range sections {
+		fenced := false
+		for _, line := range strings.Split(s.Doc, "\n") {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				fenced = !fenced
+				continue
+			}
+			m := headingLine.FindStringSubmatch(line)
+			if fenced || m == nil {
+				continue
+			}
+			text, id := m[2], blackfriday.SanitizedAnchorName(m[2])
+			if e := explicitID.FindStringSubmatchIndex(text); e != nil {
+				text, id = text[:e[0]], text[e[2]:e[3]]
+			}
+			id = ids.unique(id)
+			headings[i] = append(headings[i], Heading{Level: len(m[1]), Text: emphasis.Replace(text), ID: id})
+		}
+	}
+	return headings
+}
+
+// headingIDs counts the uses of heading IDs within a document.
+type headingIDs map[string]int
+
+// unique returns id, or if id is in use already, id with a number appended.
+func (ids headingIDs) unique(id string) string {
+	if id == "" {
+		return ""
+	}
+	for n, found := ids[id]; found; n, found = ids[id] {
+		ids[id] = n + 1
+		id += "-" + strconv.Itoa(n+1)
+	}
+	ids[id] = 0
+	return id
+}
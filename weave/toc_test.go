@@ -0,0 +1,30 @@
+package weave
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHeadings(t *testing.T) {
+	sections := []*Section{
+		{Doc: "# The *Title*\n\n## Setup\n```\n# not a heading\n```\n"},
+		{Doc: "### Deep\n## Setup\n## Custom {#mine}\n"},
+	}
+	want := []Heading{
+		{1, "The Title", "the-title"},
+		{2, "Setup", "setup"},
+		{2, "Setup", "setup-1"},
+		{2, "Custom", "mine"},
+	}
+	if got := Headings(sections, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("Headings() = %v, want %v", got, want)
+	}
+	New(Options{}).MarkdownSections(sections)
+	doc := sections[0].Doc + sections[1].Doc
+	for _, h := range want {
+		if !strings.Contains(doc, `id="`+h.ID+`"`) {
+			t.Errorf("MarkdownSections() = %q, want a heading with ID %q", doc, h.ID)
+		}
+	}
+}
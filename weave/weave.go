@@ -0,0 +1,236 @@
+// Package weave turns Go source code into documents that show comments and
+// code side by side. It is the engine behind the goweave command, for tools
+// like static site generators or doc servers that want to weave Go files
+// without running a separate program:
+//
+//	html, err := weave.New(weave.Options{HeadingOffset: 1}).Render(src)
+//
+// Render returns an HTML fragment that fits into an existing page. Code gets
+// highlighted by litebrite, unless Options.Highlighter names another
+// highlighter, like Chroma; see NewChroma. Tools
+// that need more control can run the steps on their own: Sections splits
+// the source into sections, in the order that the `//goweave:order`
+// pragmas ask for, AssignIDs gives each section a stable ID,
+// HighlightSections and MarkdownSections turn code and comments into HTML,
+// and HTMLSections prepares the result for a template. WeaveDecl weaves a
+// single declaration of a package.
+//
+// Despite its roots, the package weaves other languages, too:
+// Options.Language sets the comment syntax, and LanguageFor looks up the
+// language of a file by its extension.
+//
+// Render works on source text. RenderFile and RenderFS read the sources from
+// an fs.FS instead, like an embed.FS, a zip.Reader, or os.DirFS, and
+// ParseTemplateFS loads templates from one. WeaveFS writes the documents to
+// an OutputFS: a Dir, a MemFS, a ZipFS, or any other destination.
+package weave
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// Options control how a Weaver renders a source file. The zero value
+// renders Markdown headings as they are and wraps fragments in an element
+// of class "goweave".
+type Options struct {
+	// HeadingOffset is added to the level of each Markdown heading, so that
+	// `# Title` in a comment can become an `<h2>` if the surrounding page
+	// owns the `<h1>`.
+	HeadingOffset int
+	// MaxHeading caps the heading levels after applying HeadingOffset.
+	// 0 means 6, the deepest HTML heading level.
+	MaxHeading int
+	// IntroOnly stops at the first line of code, so that only the leading
+	// comment gets rendered.
+	IntroOnly bool
+	// WrapperClass is the class of the element that wraps a fragment.
+	// The default is "goweave".
+	WrapperClass string
+	// Highlighter highlights the code. The default is Litebrite.
+	Highlighter Highlighter
+	// TokenClasses renames the classes of the tokens that the Highlighter
+	// writes, like "keyword" to "hljs-keyword", for the stylesheet of
+	// another highlighter. A new name can have several classes, like
+	// "token keyword"; an empty one leaves the token without a class. See
+	// classes.go.
+	TokenClasses map[string]string
+	// Language is the language of the source, which determines the comment
+	// syntax. The default is Go. For other languages, a Highlighter that
+	// implements LanguageHighlighter gets asked for a highlighter of that
+	// language.
+	Language *Language
+	// ProseMarker, if set, restricts the prose to the line comments that
+	// start with it right after the comment delimiter, like ":" for `//:`
+	// or " |" for `// |`. All other comments, block comments included,
+	// stay in the code. Languages without line comments ignore it.
+	ProseMarker string
+	// Links, if set, links identifiers in Go code to declarations in other
+	// files. It gets the import path of the package that declares the
+	// identifier, or "" for the package of the code, and the name, and
+	// returns the URL of the declaration, or "" for none.
+	Links func(pkg, name string) string
+	// Footnotes moves the comments at the ends of lines of Go code into
+	// numbered footnotes, which the template lists next to the code.
+	Footnotes bool
+	// Permalinks adds a "¶" link to each section that points to the
+	// section's anchor.
+	Permalinks bool
+	// SourceOrder keeps the sections in the order of the source, ignoring
+	// order pragmas.
+	SourceOrder bool
+	// LineHook, if set, gets called by HighlightSections for each line of
+	// the highlighted code of a section, and may set the ID and the class
+	// of the line, or change its tokens.
+	LineHook func(s *Section, l *Line)
+	// KeepDirectives keeps the directives of the language, like
+	// `//go:generate` and the build constraints of Go, in the code rather
+	// than leaving them out.
+	KeepDirectives bool
+	// ByDecl makes one section per top-level declaration of Go source,
+	// with a heading made from its names, in the order of godoc rather
+	// than that of the order pragmas; see decls.go.
+	ByDecl bool
+	// Minted makes LaTeX put code into the minted environments of the
+	// minted package rather than the lstlisting environments of listings;
+	// see latex.go.
+	Minted bool
+	// Balance splits the sections whose code runs on for more than Balance
+	// lines past their comment, or the other way round, into sections of
+	// about Balance lines; see balance.go. Zero leaves them as they are.
+	Balance int
+	// Math keeps the TeX of `$...$` and `$$...$$` in comments away from
+	// Markdown, for KaTeX or MathJax to typeset; see math.go.
+	Math bool
+	// Template replaces the built-in fragment template. Render executes it
+	// with a *Document.
+	Template *template.Template
+}
+
+// Weaver renders source files. A Weaver keeps no state between calls,
+// so goroutines can share one.
+type Weaver struct {
+	opts Options
+	base Options // the options as passed to New
+}
+
+// New returns a Weaver that renders with the given options.
+func New(opts Options) *Weaver {
+	base := opts
+	if opts.MaxHeading <= 0 || opts.MaxHeading > 6 {
+		opts.MaxHeading = 6
+	}
+	if opts.WrapperClass == "" {
+		opts.WrapperClass = "goweave"
+	}
+	if opts.Highlighter == nil {
+		opts.Highlighter = Litebrite
+	}
+	if opts.Language == nil {
+		opts.Language = Go
+	}
+	if lh, ok := opts.Highlighter.(LanguageHighlighter); ok && opts.Language.Name != Go.Name {
+		opts.Highlighter = lh.ForLanguage(opts.Language.Name)
+	}
+	if len(opts.TokenClasses) > 0 {
+		opts.Highlighter = classHighlighter{opts.Highlighter, opts.TokenClasses}
+	}
+	if opts.Template == nil {
+		opts.Template = fragmentTemplate
+	}
+	return &Weaver{opts: opts, base: base}
+}
+
+// Document is the data that Render passes to the template.
+type Document struct {
+	WrapperClass string
+	Sections     []HTMLSection
+	Listings     []*Caption // the captioned code blocks, listed after the sections
+	Permalinks   bool
+	// SrcLinks are the links to the code of the sections in a repository,
+	// by section ID, as the templates of the goweave command expect them.
+	// Render leaves them empty.
+	SrcLinks map[string]string
+	// LineNums are the line numbers of the code of the sections, one line
+	// per line of code, by section ID, for the same templates. Render
+	// leaves them empty.
+	LineNums map[string]string
+}
+
+// HTMLSection is a section as seen by an HTML template. Doc and Code hold
+// markup generated by blackfriday and litebrite, which both escape their
+// input, so they can safely be marked as trusted HTML.
+type HTMLSection struct {
+	*Section
+	Doc         template.HTML
+	Code        template.HTML
+	Boilerplate template.HTML
+}
+
+// HTMLSections wraps rendered sections for use in an HTML template.
+func HTMLSections(sections []*Section) []HTMLSection {
+	hs := make([]HTMLSection, len(sections))
+	for i, s := range sections {
+		hs[i] = HTMLSection{s, template.HTML(s.Doc), template.HTML(s.Code), template.HTML(s.Boilerplate)}
+	}
+	return hs
+}
+
+// Render weaves Go source code into HTML. Unless Options.Template says
+// otherwise, the result is a fragment: a single element of class
+// Options.WrapperClass that contains the sections.
+func (w *Weaver) Render(src string) (string, error) {
+	return w.renderSections(w.Sections(src))
+}
+
+// renderSections renders the sections of a source like Render.
+func (w *Weaver) renderSections(sections []*Section) (string, error) {
+	AssignIDs(sections)
+	listings := NumberCaptions(sections)
+	w.HighlightSections(sections)
+	w.MarkdownSections(sections)
+	var b bytes.Buffer
+	err := w.opts.Template.Execute(&b, &Document{
+		WrapperClass: w.opts.WrapperClass,
+		Sections:     HTMLSections(sections),
+		Listings:     listings,
+		Permalinks:   w.opts.Permalinks,
+	})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// fragmentTemplate renders the sections in the same markup as the goweave
+// command's default template, so that its CSS file applies, followed by
+// the list of listings if there are captions.
+var fragmentTemplate = template.Must(template.New("fragment").Parse(`<div class="{{.WrapperClass}}">
+	<div class="table">
+		{{range .Sections}}
+			{{if or (ne .Code "") .Boilerplate}}
+				<div class="tr section" id="{{.ID}}" data-section-id="{{.ID}}">
+					<div class="td doc">{{if $.Permalinks}}{{with .Anchor}}<a class="permalink" id="{{.}}" href="#{{.}}" title="Link to this section">¶</a>{{end}}{{end}}{{with .Status}}<span class="status status-{{.}}" title="Review status">{{.}}</span>{{end}}{{.Doc}}{{with .Footnotes}}<ol class="codenotes">{{range .}}<li id="{{.ID}}" value="{{.Number}}">{{.Text}} <a href="#{{.RefID}}" title="Back to the code">↩</a></li>{{end}}</ol>{{end}}</div>
+					<div class="td code">
+						{{- if .Boilerplate}}<details class="boilerplate"><summary>Package and imports</summary><pre><code>{{.Boilerplate}}</code></pre></details>{{end -}}
+						<pre><code>{{.Code}}</code></pre>
+						{{- with .Caption}}<div class="caption" id="{{.ID}}"><span class="label">{{.Label}}:</span> {{.Text}}</div>{{end -}}
+					</div>
+			{{else}}
+				<div class="tr section nocode" id="{{.ID}}" data-section-id="{{.ID}}">
+					<div class="td doc nocode">{{if $.Permalinks}}{{with .Anchor}}<a class="permalink" id="{{.}}" href="#{{.}}" title="Link to this section">¶</a>{{end}}{{end}}{{with .Status}}<span class="status status-{{.}}" title="Review status">{{.}}</span>{{end}}{{.Doc}}</div>
+					<div class="td code empty"></div>
+			{{end}}
+		</div>
+		{{end}}
+	</div>
+	{{- if .Listings}}
+	<nav class="listings">
+		<h2>Listings</h2>
+		<ul>
+			{{range .Listings}}<li><a href="#{{.ID}}">{{.Label}}</a>: {{.Text}}</li>{{end}}
+		</ul>
+	</nav>
+	{{- end}}
+</div>
+`))
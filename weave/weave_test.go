@@ -0,0 +1,32 @@
+package weave
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	got, err := New(Options{WrapperClass: "doc"}).Render("// # Doc\npackage main\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<div class="doc">`, "<h1", `<span class="keyword">package</span>`, "data-section-id="} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Render() = %s, want it to contain %s", got, want)
+		}
+	}
+
+	tpl := template.Must(template.New("count").Parse(`{{len .Sections}}`))
+	got, err = New(Options{Template: tpl}).Render("// One\na := 1\n// Two\nb := 2\n")
+	if err != nil || got != "2" {
+		t.Errorf("Render() with template = %q, %v; want 2 sections", got, err)
+	}
+
+	// The templates of the goweave command look up the source links.
+	tpl = template.Must(template.New("links").Parse(`{{range .Sections}}[{{index $.SrcLinks .ID}}]{{end}}`))
+	got, err = New(Options{Template: tpl}).Render("// One\na := 1\n")
+	if err != nil || got != "[]" {
+		t.Errorf("Render() with source links = %q, %v; want no links", got, err)
+	}
+}
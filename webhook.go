@@ -0,0 +1,237 @@
+// ## Webhooks
+//
+// A team without a CI pipeline of its own can still keep its documents up
+// to date: with -webhook-secret, the server of -serve also accepts the push
+// events of GitHub and GitLab at /_goweave/webhook. For a push to the
+// branch that is checked out, goweave runs `git pull --ff-only` in the
+// repository that contains the current directory, and then generates the
+// documents again. The build cache makes sure that only the documents of
+// the changed files get regenerated. In watch mode, goweave only pulls, as
+// the watcher notices the changed files anyway.
+//
+// The secret authenticates the forge: GitHub signs each request with it,
+// GitLab sends it along as a token. Requests without the right signature or
+// token get turned away, and so do the pushes to other branches.
+//
+// One build runs at a time. A push that arrives during a build triggers
+// another build after it. /_goweave/status tells how the last build went,
+// as JSON.
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	webhookPath     = "/_goweave/webhook"
+	buildStatusPath = "/_goweave/status"
+
+	// maxPayload is the largest event that the webhook accepts.
+	maxPayload = 25 << 20
+)
+
+// checkWebhook returns an error if -webhook-secret is set without -serve.
+func checkWebhook() error {
+	if *webhookSecret != "" && serve == "" {
+		return fmt.Errorf("-webhook-secret needs -serve")
+	}
+	return nil
+}
+
+// buildStatus is the state of the builds that pushes trigger, as
+// /_goweave/status reports it.
+type buildStatus struct {
+	State    string `json:"state"` // "idle", "building", "ok", or "failed"
+	Ref      string `json:"ref,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+	Started  string `json:"started,omitempty"`
+	Finished string `json:"finished,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// pushEvent holds the fields of a push event that GitHub and GitLab have
+// in common.
+type pushEvent struct {
+	Ref   string `json:"ref"`
+	After string `json:"after"`
+}
+
+// webhook handles the push events of GitHub and GitLab.
+type webhook struct {
+	secret  string
+	git     func(args ...string) (string, error)
+	rebuild func() error // nil if the watcher rebuilds
+
+	mu      sync.Mutex
+	status  buildStatus
+	running bool
+	pending *pushEvent // a push that arrived during a build
+}
+
+// newWebhook returns the webhook of the server, which rebuilds the
+// documents of args after a pull, unless goweave watches them anyway.
+func newWebhook(secret string, args []string) *webhook {
+	h := &webhook{secret: secret, git: runGit, status: buildStatus{State: "idle"}}
+	if !*watch {
+		h.rebuild = func() error { return rebuildSite(args) }
+	}
+	return h
+}
+
+// runGit runs git in the current directory and returns its output.
+func runGit(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// rebuildSite generates the documents of args again, with the template and
+// the CSS loaded anew, as a pull may have changed them, too.
+func rebuildSite(args []string) error {
+	if err := loadResources(resourcedir); err != nil {
+		return err
+	}
+	resetAssets()
+	files, err := expandArgs(args)
+	if err != nil {
+		return err
+	}
+	if *related {
+		indexRelated(files)
+	}
+	indexRefs(files)
+	err = processFiles(files)
+	if finishErr := finishBuild(); err == nil {
+		err = finishErr
+	}
+	reloads.notify()
+	return err
+}
+
+// ServeHTTP accepts a push event.
+func (h *webhook) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxPayload))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event, ok := h.authenticate(req, body)
+	if !ok {
+		http.Error(w, "invalid signature or token", http.StatusUnauthorized)
+		return
+	}
+	switch event {
+	case "ping":
+		fmt.Fprintln(w, "pong")
+		return
+	case "push", "Push Hook":
+	default:
+		fmt.Fprintf(w, "ignored %s event\n", event)
+		return
+	}
+	var push pushEvent
+	if err := json.Unmarshal(body, &push); err != nil || push.Ref == "" {
+		http.Error(w, "not a push event", http.StatusBadRequest)
+		return
+	}
+	branch, err := h.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if push.Ref != "refs/heads/"+branch {
+		fmt.Fprintf(w, "ignored push to %s, the checkout is on %s\n", push.Ref, branch)
+		return
+	}
+	h.trigger(&push)
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "building %s\n", push.Ref)
+}
+
+// authenticate checks the signature of a GitHub event or the token of a
+// GitLab event, and returns the kind of the event.
+func (h *webhook) authenticate(req *http.Request, body []byte) (event string, ok bool) {
+	if event = req.Header.Get("X-GitHub-Event"); event != "" {
+		sig := strings.TrimPrefix(req.Header.Get("X-Hub-Signature-256"), "sha256=")
+		want, err := hex.DecodeString(sig)
+		if err != nil {
+			return "", false
+		}
+		mac := hmac.New(sha256.New, []byte(h.secret))
+		mac.Write(body)
+		return event, hmac.Equal(mac.Sum(nil), want)
+	}
+	if event = req.Header.Get("X-Gitlab-Event"); event != "" {
+		token := req.Header.Get("X-Gitlab-Token")
+		return event, subtle.ConstantTimeCompare([]byte(token), []byte(h.secret)) == 1
+	}
+	return "", false
+}
+
+// trigger starts a build for a push, or queues it if a build is running.
+func (h *webhook) trigger(push *pushEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.running {
+		h.pending = push
+		return
+	}
+	h.running = true
+	go h.build(push)
+}
+
+// build pulls and rebuilds, and goes on with the pushes that arrive in the
+// meantime.
+func (h *webhook) build(push *pushEvent) {
+	for push != nil {
+		h.mu.Lock()
+		h.status = buildStatus{State: "building", Ref: push.Ref, Commit: push.After, Started: time.Now().Format(time.RFC3339)}
+		h.mu.Unlock()
+		log.Printf("Webhook: building %s at %s.", push.Ref, push.After)
+		_, err := h.git("pull", "--ff-only")
+		if err == nil && h.rebuild != nil {
+			err = h.rebuild()
+		}
+		h.mu.Lock()
+		h.status.Finished = time.Now().Format(time.RFC3339)
+		h.status.State = "ok"
+		if err != nil {
+			log.Printf("Webhook: %v", err)
+			h.status.State, h.status.Error = "failed", err.Error()
+		}
+		push, h.pending = h.pending, nil
+		if push == nil {
+			h.running = false
+		}
+		h.mu.Unlock()
+	}
+}
+
+// serveStatus reports the state of the last build as JSON.
+func (h *webhook) serveStatus(w http.ResponseWriter, req *http.Request) {
+	h.mu.Lock()
+	status := h.status
+	h.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(status)
+}
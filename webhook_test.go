@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhook(t *testing.T) {
+	const secret = "s3cret"
+	push := `{"ref": "refs/heads/main", "after": "abc123"}`
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	built := make(chan bool, 10)
+	var mu sync.Mutex
+	var gitCalls []string
+	h := &webhook{
+		secret: secret,
+		git: func(args ...string) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			gitCalls = append(gitCalls, strings.Join(args, " "))
+			return "main", nil
+		},
+		rebuild: func() error { built <- true; return fmt.Errorf("broken template") },
+		status:  buildStatus{State: "idle"},
+	}
+	tests := []struct {
+		method  string
+		headers map[string]string
+		body    string
+		want    int
+	}{
+		{"GET", nil, "", http.StatusMethodNotAllowed},
+		{"POST", map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign("other")}, push, http.StatusUnauthorized},
+		{"POST", map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "wrong"}, push, http.StatusUnauthorized},
+		{"POST", nil, push, http.StatusUnauthorized},
+		{"POST", map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": sign("{}")}, "{}", http.StatusOK},
+		{"POST", map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign(`{"ref": "refs/heads/dev"}`)}, `{"ref": "refs/heads/dev"}`, http.StatusOK},
+		{"POST", map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign(push)}, push, http.StatusAccepted},
+		{"POST", map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": secret}, push, http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, webhookPath, strings.NewReader(tt.body))
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %v: status %d, want %d (%s)", tt.method, tt.headers, rec.Code, tt.want, rec.Body)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-built:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the pushes triggered %d build(s), want 2", i)
+		}
+	}
+	// Wait for the last build to finish.
+	for i := 0; i < 100; i++ {
+		h.mu.Lock()
+		running := h.running
+		h.mu.Unlock()
+		if !running {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	rec := httptest.NewRecorder()
+	h.serveStatus(rec, httptest.NewRequest("GET", buildStatusPath, nil))
+	var status buildStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "failed" || status.Commit != "abc123" || status.Error != "broken template" {
+		t.Errorf("status = %+v, want a failed build of abc123", status)
+	}
+	if n := strings.Count(strings.Join(gitCalls, "\n"), "pull --ff-only"); n != 2 {
+		t.Errorf("git pulled %d time(s), want 2", n)
+	}
+}